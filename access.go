@@ -0,0 +1,200 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Access is the effective access level of a user on a path, as returned by Connection.EffectiveAccess.
+// Explanation lists what grants the level: ownership, ACL entries of the user and of their groups, rodsadmin status,
+// and notes on inheritance.
+type Access struct {
+	Path        string
+	Principal   string
+	Level       int
+	Explanation []string
+}
+
+// Allows reports whether the effective access level is at least level, in the order of AccessLevels()
+func (access *Access) Allows(level int) bool {
+	return CompareAccess(access.Level, level) >= 0
+}
+
+// String returns the access level and its explanation
+// example: alice#tempZone has write on /tempZone/home/rods/data (write: ACL of group team#tempZone)
+func (access *Access) String() string {
+	return fmt.Sprintf("%v has %v on %v (%v)", access.Principal, getTypeString(access.Level), access.Path, strings.Join(access.Explanation, "; "))
+}
+
+// accessGrant is an ACL entry, or the ownership, considered by EffectiveAccess
+type accessGrant struct {
+	level  int
+	reason string
+}
+
+// grant raises the access level to level when it's higher, and records the reason
+func (access *Access) grant(g accessGrant) {
+	if CompareAccess(g.level, access.Level) > 0 {
+		access.Level = g.level
+	}
+
+	access.Explanation = append(access.Explanation, fmt.Sprintf("%v: %v", getTypeString(g.level), g.reason))
+}
+
+// matchACL returns the entries of acls applying to the user principal, directly or through the groups
+func matchACL(acls []aclEntry, principal string, groups map[string]bool) []accessGrant {
+	var grants []accessGrant
+
+	for _, entry := range acls {
+		if entry.level == Null {
+			continue
+		}
+
+		switch {
+		case entry.group && groups[entry.principal]:
+			grants = append(grants, accessGrant{entry.level, fmt.Sprintf("ACL of group %v", entry.principal)})
+		case !entry.group && entry.principal == principal:
+			grants = append(grants, accessGrant{entry.level, "ACL of the user"})
+		}
+	}
+
+	return grants
+}
+
+// aclEntry is an ACL entry reduced to what EffectiveAccess needs
+type aclEntry struct {
+	principal string
+	group     bool
+	level     int
+}
+
+// EffectiveAccess answers "what can principal (name, or name#zone for users of federated zones) do on p?". It combines
+// the ACL entries of the user and of the groups they belong to (User.Groups()), the ownership of p, and the rodsadmin
+// status of local users. When p doesn't exist, the access is that of creating it: users who can write its parent
+// collection would own it. Notes on the inheritance of the collection, or of the parent of a missing p, are added to
+// the explanation. Listing users and groups may need privileges the connection doesn't have, in which case
+// memberships that couldn't be resolved are left out and mentioned in the explanation.
+func (con *Connection) EffectiveAccess(p string, principal string) (*Access, error) {
+	p = path.Clean(p)
+
+	local, err := con.LocalZone()
+	if err != nil {
+		return nil, err
+	}
+
+	name, zoneName := SplitPrincipal(principal)
+	if zoneName == "" {
+		zoneName = local.Name()
+	}
+
+	access := &Access{
+		Path:      p,
+		Principal: name + "#" + zoneName,
+		Level:     Null,
+	}
+
+	usrs, _ := con.Users()
+	usr := usrs.FindByNameAndZone(name, zoneName, con)
+	if usr == nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS EffectiveAccess Failed: can't initialize user %v", access.Principal))
+	}
+
+	if zoneName == local.Name() && usr.Type() == AdminType {
+		access.grant(accessGrant{Own, "rodsadmin of zone " + zoneName})
+		return access, nil
+	}
+
+	groups := make(map[string]bool)
+
+	if grps, err := usr.Groups(); err == nil {
+		for _, grp := range grps {
+			groups[Principal(grp)] = true
+		}
+	} else {
+		access.Explanation = append(access.Explanation, fmt.Sprintf("group memberships unknown: %v", err))
+	}
+
+	target := p
+
+	typ, err := con.PathType(p)
+	if errors.Is(err, os.ErrNotExist) {
+		// Creating p needs write access to its parent
+		target = path.Dir(p)
+
+		if typ, err = con.PathType(target); err != nil || typ != CollectionType {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS EffectiveAccess Failed: neither %v nor its parent collection exist", p))
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	var obj IRodsObj
+	if typ == CollectionType {
+		obj, err = con.Collection(CollectionOptions{Path: target, SkipCache: true})
+	} else {
+		obj, err = con.DataObject(target)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The collection skips the cache, it's closed once its ACL and inheritance are read
+	if col, ok := obj.(*Collection); ok {
+		defer col.Close()
+	}
+
+	acls, err := obj.ACL()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]aclEntry, len(acls))
+	for inx, acl := range acls {
+		entries[inx] = aclEntry{Principal(acl.AccessObject), acl.Type == GroupType, acl.AccessLevel}
+	}
+
+	parent := &Access{Path: target, Principal: access.Principal, Level: Null}
+
+	if owner := obj.Owner(); owner != nil && Principal(owner) == access.Principal {
+		parent.grant(accessGrant{Own, "owner of " + target})
+	}
+
+	for _, g := range matchACL(entries, access.Principal, groups) {
+		parent.grant(g)
+	}
+
+	if target == p {
+		access.Level = parent.Level
+		access.Explanation = append(access.Explanation, parent.Explanation...)
+	} else {
+		access.Explanation = append(access.Explanation, fmt.Sprintf("%v doesn't exist, creating it needs write on %v", p, target))
+		access.Explanation = append(access.Explanation, parent.Explanation...)
+
+		if parent.Allows(Write) {
+			access.grant(accessGrant{Own, "owner of " + p + " once created"})
+		}
+	}
+
+	if col, ok := obj.(*Collection); ok {
+		if inherit, err := col.Inheritance(); err == nil && inherit {
+			if target == p {
+				access.Explanation = append(access.Explanation, "inheritance is enabled, new objects in "+p+" get its ACL")
+			} else {
+				access.Explanation = append(access.Explanation, "inheritance is enabled on "+target+", "+p+" would get its ACL")
+			}
+		}
+	}
+
+	if len(access.Explanation) == 0 {
+		access.Explanation = append(access.Explanation, "no ACL entry of the user or their groups")
+	}
+
+	return access, nil
+}
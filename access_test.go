@@ -0,0 +1,103 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+func TestMatchACL(t *testing.T) {
+
+	acls := []aclEntry{
+		{"rods#tempZone", false, Own},
+		{"alice#tempZone", false, Read},
+		{"alice#otherZone", false, Own},
+		{"team#tempZone", true, Write},
+		{"auditors#tempZone", true, Read},
+		{"public#tempZone", true, Null},
+	}
+
+	groups := map[string]bool{"team#tempZone": true, "public#tempZone": true}
+
+	access := &Access{Path: "/tempZone/home/rods/data", Principal: "alice#tempZone", Level: Null}
+
+	for _, g := range matchACL(acls, access.Principal, groups) {
+		access.grant(g)
+	}
+
+	if access.Level != Write || len(access.Explanation) != 2 {
+		t.Fatalf("Unexpected access %v", access)
+	}
+
+	if access.Explanation[0] != "read: ACL of the user" || access.Explanation[1] != "write: ACL of group team#tempZone" {
+		t.Errorf("Unexpected explanation %q", access.Explanation)
+	}
+
+	if !access.Allows(Read) || !access.Allows(Write) || access.Allows(Own) || !access.Allows(Null) {
+		t.Errorf("Unexpected Allows of %v", access)
+	}
+
+	if s := access.String(); s != "alice#tempZone has write on /tempZone/home/rods/data (read: ACL of the user; write: ACL of group team#tempZone)" {
+		t.Errorf("Unexpected String %q", s)
+	}
+
+	remote := &Access{Principal: "alice#otherZone", Level: Null}
+
+	for _, g := range matchACL(acls, remote.Principal, nil) {
+		remote.grant(g)
+	}
+
+	if remote.Level != Own || len(remote.Explanation) != 1 {
+		t.Errorf("The federated user with a local namesake got %v", remote)
+	}
+
+}
+
+func TestEffectiveAccessMissing(t *testing.T) {
+	srv := gorodstest.NewServer("tempZone")
+	srv.CreateUser("alice", gorodstest.UserType)
+
+	rods, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rods.Mkdir("/tempZone/home/rods/private", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rods.Chmod("/tempZone/home/rods", "alice", gorodstest.Read, false); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := srv.Connect("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	con, err := NewConnection(&ConnectionOptions{Type: FilesystemDefined, Filesystem: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer con.Disconnect()
+
+	// alice can't create objects in the home of rods
+	access, err := con.EffectiveAccess("/tempZone/home/rods/new.txt", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if access.Level != Null || !strings.Contains(access.String(), "doesn't exist") {
+		t.Errorf("Unexpected access %v", access)
+	}
+
+	// A collection alice can't read isn't missing
+	if access, err := con.EffectiveAccess("/tempZone/home/rods/private", "alice"); err == nil {
+		t.Errorf("Unexpected access %v", access)
+	}
+}
@@ -3,12 +3,12 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
 )
 
 // AccessObject is an interface for Users and Groups, used within ACL slices to denote the access level of a DataObj or Collection
@@ -28,14 +28,18 @@ type ACL struct {
 	AccessObject AccessObject
 	AccessLevel  int
 	Type         int
+
+	// local is the local zone, resolved once for all the entries of a listing
+	local *Zone
 }
 
 // ACLs is a slice of ACL pointers
 type ACLs []*ACL
 
-// User is a shortcut to cast the AccessObject as it's underlying data structure type (*User)
+// User is a shortcut to cast the AccessObject as it's underlying data structure type (*User).
+// Principals of an UnknownType, such as users of a federated zone missing from the local catalog, are returned as *User too.
 func (acl *ACL) User() *User {
-	if acl.Type == UserType || acl.Type == AdminType || acl.Type == GroupAdminType {
+	if acl.Type == UserType || acl.Type == AdminType || acl.Type == GroupAdminType || acl.Type == UnknownType {
 		return acl.AccessObject.(*User)
 	}
 
@@ -61,5 +65,115 @@ func (acl *ACL) AccessLevelString() string {
 func (acl *ACL) String() string {
 	typeString := getTypeString(acl.Type)
 
-	return fmt.Sprintf("%v:%v#%v:%v", typeString, acl.AccessObject.Name(), acl.AccessObject.Zone().Name(), getTypeString(acl.AccessLevel))
+	return fmt.Sprintf("%v:%v:%v", typeString, Principal(acl.AccessObject), getTypeString(acl.AccessLevel))
+}
+
+// Remote reports whether the principal of the ACL belongs to a federated zone
+func (acl *ACL) Remote() bool {
+	zne := acl.AccessObject.Zone()
+	if zne == nil {
+		return false
+	}
+
+	local := acl.local
+	if local == nil {
+		var err error
+
+		if local, err = acl.AccessObject.Con().LocalZone(); err != nil {
+			return false
+		}
+	}
+
+	return zne.Name() != local.Name()
+}
+
+// newACL resolves the principal name#zoneName of an ACL entry to its *User or *Group. Listing users and groups
+// needs privileges the caller may not have, unknown principals are initialized by FindByNameAndZone. local is the
+// local zone, which the callers look up once for all the entries of the listing.
+func newACL(name string, zoneName string, aclType int, accessLevel int, local *Zone, con *Connection) (*ACL, error) {
+	var accessObject AccessObject
+
+	zne := local
+	if zoneName != "" && zoneName != local.Name() {
+		znes, _ := con.Zones()
+		zne = znes.FindByName(zoneName, con)
+	}
+
+	if aclType == GroupType {
+		grps, _ := con.Groups()
+		grp := grps.findByNameAndZone(name, zne, con)
+		if grp == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS GetACL Failed: can't initialize group %v#%v", name, zoneName))
+		}
+
+		accessObject = grp
+	} else {
+		usrs, _ := con.Users()
+		usr := usrs.findByNameAndZone(name, zne, con)
+		if usr == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS GetACL Failed: can't initialize user %v#%v", name, zoneName))
+		}
+
+		if aclType == UnknownType && usr.typ == 0 {
+			// Principals of federated zones aren't always known to the local catalog
+			usr.typ = UnknownType
+		}
+
+		accessObject = usr
+	}
+
+	return &ACL{
+		AccessObject: accessObject,
+		AccessLevel:  accessLevel,
+		Type:         aclType,
+		local:        local,
+	}, nil
+}
+
+// Principal returns the name#zone form of a user or group, which Chmod accepts for principals of federated zones.
+// The zone is left out when it's unknown.
+func Principal(userOrGroup AccessObject) string {
+	if zne := userOrGroup.Zone(); zne != nil && zne.Name() != "" {
+		return userOrGroup.Name() + "#" + zne.Name()
+	}
+
+	return userOrGroup.Name()
+}
+
+// SplitPrincipal splits a name#zone principal, zone is empty when the principal has none
+func SplitPrincipal(principal string) (name string, zone string) {
+	if inx := strings.LastIndex(principal, "#"); inx >= 0 {
+		return principal[:inx], principal[inx+1:]
+	}
+
+	return principal, ""
+}
+
+// AccessLevels returns the access levels from the lowest to the highest: Null, ReadMetadata, Read, CreateMetadata,
+// ModifyMetadata, DeleteMetadata, CreateObject, Write, DeleteObject and Own. Each level grants the levels before it.
+// The granular levels need iRODS 4.3, older servers only know Null, Read, Write and Own.
+func AccessLevels() []int {
+	return append([]int(nil), irodsfs.AccessLevels...)
+}
+
+// CompareAccess returns -1, 0 or 1 when the access level a grants less than, the same as, or more than b.
+// Access levels must be compared with it rather than by value, the constants aren't ordered.
+func CompareAccess(a int, b int) int {
+	return irodsfs.CompareAccess(a, b)
+}
+
+// ParseAccessLevel returns the access level named name. It accepts the names of iRODS 4.3 (read_metadata,
+// modify_object...), those of older catalogs (read object, modify object) and the ichmod names null, read, write and own.
+func ParseAccessLevel(name string) (int, error) {
+	level, err := irodsfs.ParseAccess(name)
+	if err != nil {
+		return Null, newError(Fatal, -1, fmt.Sprintf("iRODS ParseAccessLevel Failed: %v", err))
+	}
+
+	return level, nil
+}
+
+// isAccessLevel reports whether level is one of AccessLevels()
+func isAccessLevel(level int) bool {
+	return irodsfs.IsAccessLevel(level)
 }
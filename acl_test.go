@@ -0,0 +1,116 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+func TestSplitPrincipal(t *testing.T) {
+
+	tests := []struct {
+		principal string
+		name      string
+		zone      string
+	}{
+		{"rods", "rods", ""},
+		{"rods#tempZone", "rods", "tempZone"},
+		{"alice#otherZone", "alice", "otherZone"},
+		{"odd#name#otherZone", "odd#name", "otherZone"},
+		{"public#", "public", ""},
+	}
+
+	for _, test := range tests {
+		if name, zone := SplitPrincipal(test.principal); name != test.name || zone != test.zone {
+			t.Errorf("SplitPrincipal(%q) = %q, %q, want %q, %q", test.principal, name, zone, test.name, test.zone)
+		}
+	}
+
+	usr, _ := initUser("alice", &Zone{name: "otherZone"}, nil)
+
+	if p := Principal(usr); p != "alice#otherZone" {
+		t.Errorf("Principal = %q", p)
+	}
+
+	grp := &Group{name: "lab"}
+
+	if p := Principal(grp); p != "lab" {
+		t.Errorf("Principal of a group without zone = %q", p)
+	}
+
+}
+
+func TestAccessLevels(t *testing.T) {
+
+	levels := AccessLevels()
+	if levels[0] != Null || levels[len(levels)-1] != Own {
+		t.Fatalf("Unexpected order %v", levels)
+	}
+
+	for inx, level := range levels {
+		// Chmod sends the names of getTypeString, ACLs are parsed with ParseAccessLevel
+		if parsed, err := ParseAccessLevel(getTypeString(level)); err != nil || parsed != level {
+			t.Errorf("ParseAccessLevel(%q) = %v, %v, want %v", getTypeString(level), parsed, err, level)
+		}
+
+		if inx > 0 && CompareAccess(levels[inx-1], level) >= 0 {
+			t.Errorf("%v doesn't grant more than %v", getTypeString(level), getTypeString(levels[inx-1]))
+		}
+	}
+
+	if level, err := ParseAccessLevel("modify object"); err != nil || level != ModifyObject {
+		t.Errorf("ParseAccessLevel(\"modify object\") = %v, %v", level, err)
+	}
+}
+
+func TestACLRemote(t *testing.T) {
+	srv := gorodstest.NewServer("tempZone")
+	srv.CreateUser("alice", gorodstest.UserType)
+	srv.CreateUser("auditor#otherZone", gorodstest.UserType)
+
+	fsys, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := "/tempZone/home/rods/shared"
+	fsys.Mkdir(p, false)
+	fsys.Chmod(p, "alice", gorodstest.Read, false)
+	fsys.Chmod(p, "auditor#otherZone", gorodstest.Read, false)
+
+	con, err := NewConnection(&ConnectionOptions{Type: FilesystemDefined, Filesystem: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer con.Disconnect()
+
+	col, err := con.Collection(CollectionOptions{Path: p, SkipCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer col.Close()
+
+	acls, err := col.ACL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := make(map[string]bool)
+
+	for _, acl := range acls {
+		if acl.local == nil {
+			t.Fatalf("The local zone of %v wasn't resolved with the listing", acl)
+		}
+
+		remote[Principal(acl.AccessObject)] = acl.Remote()
+	}
+
+	if len(remote) != 3 || remote["rods#tempZone"] || remote["alice#tempZone"] || !remote["auditor#otherZone"] {
+		t.Fatalf("Unexpected remote principals %v", remote)
+	}
+}
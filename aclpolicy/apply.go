@@ -0,0 +1,479 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package aclpolicy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// FS is the part of irodsfs.Filesystem used by the policy engine
+type FS interface {
+	irodsfs.FS
+	irodsfs.ACLFS
+}
+
+// Options tune Apply
+type Options struct {
+	// DryRun only reports the drift and the calls that would fix it
+	DryRun bool
+
+	// Workers is the number of collections and objects checked, and of calls made, in
+	// parallel. It defaults to 8.
+	Workers int
+
+	// Connect opens the connection of a worker, which is closed at the end of Apply when it
+	// has a Disconnect or Close method. Without it the workers share the FS given to Apply,
+	// whose calls may be serialized: gorods connections run one call at a time.
+	Connect func() (FS, error)
+}
+
+// Change is a difference between a policy and the zone, or a call fixing differences.
+// Principal changes set Principal, From and To; inheritance changes set Inherit.
+type Change struct {
+	Path      string `json:"path"`
+	Principal string `json:"principal,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Inherit   *bool  `json:"inherit,omitempty"`
+	Recursive bool   `json:"recursive,omitempty"`
+}
+
+// String describes the change like ichmod arguments
+func (change Change) String() string {
+	flag := ""
+	if change.Recursive {
+		flag = "-r "
+	}
+
+	if change.Inherit != nil {
+		if *change.Inherit {
+			return fmt.Sprintf("%vinherit %v", flag, change.Path)
+		}
+
+		return fmt.Sprintf("%vnoinherit %v", flag, change.Path)
+	}
+
+	return fmt.Sprintf("%v%v %v %v", flag, change.To, change.Principal, change.Path)
+}
+
+// Failure is an object that couldn't be checked, or a call that failed
+type Failure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Report is the result of Apply. Drift lists every object differing from the policy, Changes
+// the calls made to fix it, or that would be made with DryRun: changes of a principal on a
+// whole tree are collapsed into one recursive call.
+type Report struct {
+	DryRun   bool      `json:"dryRun"`
+	Checked  int       `json:"checked"`
+	Drift    []Change  `json:"drift"`
+	Changes  []Change  `json:"changes"`
+	Failures []Failure `json:"failures,omitempty"`
+}
+
+// InSync reports whether the zone matched the policy when it was checked
+func (report *Report) InSync() bool {
+	return len(report.Drift) == 0 && len(report.Failures) == 0
+}
+
+// Drift compares the zone with the policy without changing anything
+func Drift(fsys FS, policy *Policy, workers int) (*Report, error) {
+	return Apply(fsys, policy, Options{DryRun: true, Workers: workers})
+}
+
+// Apply walks the trees of the policy in parallel, compares the ACLs and inheritance of every
+// object with its rule, and makes the minimal Chmod and SetInheritance calls to converge.
+// Objects that can't be checked and failed calls are reported in Failures, the error is only
+// set for an invalid policy or a worker that can't connect.
+func Apply(fsys FS, policy *Policy, opts Options) (*Report, error) {
+	rules, err := compile(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+
+	// Workers connect before anything is checked, so a failed connection stops Apply
+	conns := make([]FS, opts.Workers)
+
+	for inx := range conns {
+		conns[inx] = fsys
+
+		if opts.Connect != nil {
+			if conns[inx], err = opts.Connect(); err != nil {
+				closeAll(conns[:inx])
+				return nil, fmt.Errorf("aclpolicy: connecting worker %d: %v", inx+1, err)
+			}
+		}
+	}
+
+	if opts.Connect != nil {
+		defer closeAll(conns)
+	}
+
+	w := &walker{
+		rules:    rules,
+		pool:     make(chan FS, opts.Workers),
+		visited:  make(map[string]bool),
+		complete: make(map[string]bool),
+		failed:   make(map[string]bool),
+		report:   &Report{DryRun: opts.DryRun, Drift: []Change{}},
+	}
+
+	for _, con := range conns {
+		w.pool <- con
+	}
+
+	for _, root := range rules.roots() {
+		root := root
+
+		w.spawn(func(fsys FS) {
+			info, err := fsys.Stat(root)
+			if err != nil {
+				w.fail(root, err)
+				return
+			}
+
+			w.visit(fsys, info)
+		})
+	}
+
+	w.wg.Wait()
+
+	report := w.report
+	sortChanges(report.Drift)
+	report.Changes = collapse(report.Drift, w.visited, w.complete, w.failed)
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Path < report.Failures[j].Path
+	})
+
+	if !opts.DryRun {
+		apply(conns, report)
+	}
+
+	return report, nil
+}
+
+// walker traverses the trees of a policy, collecting the drift. pool holds the connections of
+// the idle workers.
+type walker struct {
+	rules compiled
+	pool  chan FS
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	visited  map[string]bool // checked objects, true for collections
+	complete map[string]bool // listed collections
+	failed   map[string]bool // objects that couldn't be checked or listed
+	report   *Report
+}
+
+// objectsPerTask is the number of data objects of a collection checked by one task
+const objectsPerTask = 64
+
+// spawn runs fn with the connection of a worker when one is free
+func (w *walker) spawn(fn func(fsys FS)) {
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		fsys := <-w.pool
+		defer func() { w.pool <- fsys }()
+
+		fn(fsys)
+	}()
+}
+
+func (w *walker) fail(p string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.failed[p] = true
+	w.report.Failures = append(w.report.Failures, Failure{Path: p, Error: err.Error()})
+}
+
+// visit checks info, and lists it when it's a collection governed by a recursive rule
+func (w *walker) visit(fsys FS, info irodsfs.ObjInfo) {
+	st := w.rules.governing(info.Path)
+	if st == nil {
+		return
+	}
+
+	w.check(fsys, info, st)
+
+	if !info.IsDir() || !st.rule.Recursive {
+		return
+	}
+
+	children, err := fsys.List(info.Path)
+	if err != nil {
+		w.fail(info.Path, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.complete[info.Path] = true
+	w.mu.Unlock()
+
+	var objs []irodsfs.ObjInfo
+
+	for _, child := range children {
+		child.Path = path.Join(info.Path, child.Name)
+
+		if child.IsDir() {
+			child := child
+			w.spawn(func(fsys FS) { w.visit(fsys, child) })
+		} else {
+			objs = append(objs, child)
+		}
+	}
+
+	for len(objs) > 0 {
+		n := objectsPerTask
+		if n > len(objs) {
+			n = len(objs)
+		}
+
+		batch := objs[:n]
+		objs = objs[n:]
+
+		w.spawn(func(fsys FS) {
+			for _, obj := range batch {
+				w.visit(fsys, obj)
+			}
+		})
+	}
+}
+
+// check compares the ACL and inheritance of info with its desired state
+func (w *walker) check(fsys FS, info irodsfs.ObjInfo, st *state) {
+	acl, err := fsys.ACL(info.Path)
+	if err != nil {
+		w.fail(info.Path, err)
+		return
+	}
+
+	current := make(map[string]int)
+	for _, entry := range acl {
+		current[entry.Principal] = entry.AccessLevel
+	}
+
+	var drift []Change
+
+	for principal, want := range st.access {
+		have, ok := current[principal]
+		if !ok {
+			have = irodsfs.Null
+		}
+
+		if have != want {
+			drift = append(drift, Change{Path: info.Path, Principal: principal, From: AccessName(have), To: AccessName(want)})
+		}
+	}
+
+	if st.rule.Exclusive {
+		owner := qualify(info.Owner, zoneOf(info.Path))
+
+		for principal, have := range current {
+			if _, granted := st.access[principal]; !granted && principal != owner && have != irodsfs.Null {
+				drift = append(drift, Change{Path: info.Path, Principal: principal, From: AccessName(have), To: AccessName(irodsfs.Null)})
+			}
+		}
+	}
+
+	if info.IsDir() && st.rule.Inherit != nil {
+		inherit, err := fsys.Inheritance(info.Path)
+		if err != nil {
+			w.fail(info.Path, err)
+			return
+		}
+
+		if inherit != *st.rule.Inherit {
+			want := *st.rule.Inherit
+			drift = append(drift, Change{Path: info.Path, Inherit: &want})
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.visited[info.Path] = info.IsDir()
+	w.report.Checked++
+	w.report.Drift = append(w.report.Drift, drift...)
+}
+
+// collapse turns the drift into calls. A principal changed to the same level on every object
+// of a tree that was listed and checked completely is changed with one recursive call on the tree.
+func collapse(drift []Change, visited map[string]bool, complete map[string]bool, failed map[string]bool) []Change {
+	// ancestors returns p and its visited parents, nearest first
+	ancestors := func(p string) []string {
+		var list []string
+
+		for a := p; ; a = path.Dir(a) {
+			if _, ok := visited[a]; !ok {
+				break
+			}
+
+			list = append(list, a)
+
+			if a == "/" {
+				break
+			}
+		}
+
+		return list
+	}
+
+	// Trees are complete when all their collections were listed
+	incomplete := make(map[string]bool)
+	sizes := make(map[string]int)
+
+	for p, isDir := range visited {
+		for _, a := range ancestors(p) {
+			sizes[a]++
+
+			if isDir && !complete[p] {
+				incomplete[a] = true
+			}
+		}
+	}
+
+	for p := range failed {
+		incomplete[p] = true
+
+		for _, a := range ancestors(path.Dir(p)) {
+			incomplete[a] = true
+		}
+	}
+
+	type key struct{ principal, to string }
+
+	counts := make(map[key]map[string]int)
+
+	for _, change := range drift {
+		if change.Inherit != nil {
+			continue
+		}
+
+		k := key{change.Principal, change.To}
+		if counts[k] == nil {
+			counts[k] = make(map[string]int)
+		}
+
+		for _, a := range ancestors(change.Path) {
+			counts[k][a]++
+		}
+	}
+
+	var calls []Change
+
+	seen := make(map[Change]bool)
+
+	for _, change := range drift {
+		if change.Inherit != nil {
+			calls = append(calls, change)
+			continue
+		}
+
+		k := key{change.Principal, change.To}
+		call := change
+
+		// The topmost complete tree of which every object drifts the same way
+		for _, a := range ancestors(change.Path) {
+			if incomplete[a] || counts[k][a] != sizes[a] {
+				break
+			}
+
+			if sizes[a] > 1 {
+				call = Change{Path: a, Principal: change.Principal, To: change.To, Recursive: true}
+			}
+		}
+
+		if !seen[call] {
+			seen[call] = true
+			calls = append(calls, call)
+		}
+	}
+
+	sortChanges(calls)
+
+	return calls
+}
+
+// apply makes the calls of the report, in parallel on the connections of the workers
+func apply(conns []FS, report *Report) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	calls := make(chan Change)
+
+	for _, fsys := range conns {
+		fsys := fsys
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for call := range calls {
+				var err error
+
+				if call.Inherit != nil {
+					err = fsys.SetInheritance(call.Path, *call.Inherit, call.Recursive)
+				} else {
+					level, _ := AccessLevel(call.To)
+					err = fsys.Chmod(call.Path, call.Principal, level, call.Recursive)
+				}
+
+				if err != nil {
+					mu.Lock()
+					report.Failures = append(report.Failures, Failure{Path: call.Path, Error: fmt.Sprintf("%v: %v", call, err)})
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, call := range report.Changes {
+		calls <- call
+	}
+
+	close(calls)
+	wg.Wait()
+}
+
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+
+		return changes[i].Principal < changes[j].Principal
+	})
+}
+
+// closeAll closes the connections of the workers
+func closeAll(conns []FS) {
+	for _, con := range conns {
+		switch c := con.(type) {
+		case interface{ Disconnect() error }:
+			c.Disconnect()
+		case interface{ Close() error }:
+			c.Close()
+		}
+	}
+}
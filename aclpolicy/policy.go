@@ -0,0 +1,272 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package aclpolicy keeps the permissions of collection trees as code. A Policy holds the
+// desired state (principals, access levels, inheritance and the scope of each rule), Drift
+// compares it with the ACLs of the zone, and Apply makes the minimal Chmod and SetInheritance
+// calls to converge:
+//
+//	policy, err := aclpolicy.Load("projects.json")
+//	report, err := aclpolicy.Apply(con.Filesystem(), policy, aclpolicy.Options{
+//		DryRun:  true,
+//		Workers: 8,
+//		Connect: dial,
+//	})
+//
+// The calls of a gorods connection run one at a time, parallel workers open a connection each
+// with Connect.
+//
+// A policy written in JSON:
+//
+//	{"rules": [
+//		{"path": "/tempZone/projects/apollo", "recursive": true, "inherit": true, "exclusive": true,
+//		 "grants": [{"principal": "apollo-team", "access": "write"},
+//		            {"principal": "auditors#otherZone", "access": "read"}]},
+//		{"path": "/tempZone/projects/apollo/raw", "recursive": true,
+//		 "grants": [{"principal": "apollo-team", "access": "read"}]}
+//	]}
+//
+// Built with the gorods_yaml tag, Parse and Load also read policies written in YAML with
+// gopkg.in/yaml.v2:
+//
+//	rules:
+//	- path: /tempZone/projects/apollo
+//	  recursive: true
+//	  inherit: true
+//	  exclusive: true
+//	  grants:
+//	  - {principal: apollo-team, access: write}
+//	  - {principal: auditors#otherZone, access: read}
+//
+// Other programs decode YAML with the library of their choice, then check the policy with
+// Validate.
+//
+// It works with any irodsfs implementation.
+package aclpolicy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/internal/policyfile"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Policy is the desired state of the permissions of collection trees
+type Policy struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule is the desired state of the collection or data object Path, and of everything below it
+// when Recursive is set. When rules overlap, the rule with the deepest Path governs an object.
+type Rule struct {
+	Path string `yaml:"path" json:"path"`
+
+	// Recursive extends the rule to everything below Path
+	Recursive bool `yaml:"recursive,omitempty" json:"recursive,omitempty"`
+
+	// Inherit sets ACL inheritance on the collections of the rule, nil leaves it as it is
+	Inherit *bool `yaml:"inherit,omitempty" json:"inherit,omitempty"`
+
+	// Exclusive revokes the access of principals missing from Grants, except the owner's
+	Exclusive bool `yaml:"exclusive,omitempty" json:"exclusive,omitempty"`
+
+	Grants []Grant `yaml:"grants" json:"grants"`
+}
+
+// Grant is the access level of a user or group. Principal is name#zone, or a name of the zone
+// of the rule's Path. Access is null, read, write, own or one of the levels of iRODS 4.3
+// (read_metadata, create_object...), null revokes the access.
+type Grant struct {
+	Principal string `yaml:"principal" json:"principal"`
+	Access    string `yaml:"access" json:"access"`
+}
+
+// AccessLevel returns the irodsfs access level named name, see irodsfs.ParseAccess
+func AccessLevel(name string) (int, error) {
+	return irodsfs.ParseAccess(name)
+}
+
+// AccessName returns the name of an irodsfs access level: read and write for Read and Write,
+// the iRODS 4.3 name of the others
+func AccessName(level int) string {
+	switch level {
+	case irodsfs.Read:
+		return "read"
+	case irodsfs.Write:
+		return "write"
+	}
+
+	return irodsfs.AccessName(level)
+}
+
+// Parse decodes a JSON policy, or a YAML one in builds with the gorods_yaml tag, and validates
+// it. Unknown fields are an error.
+func Parse(data []byte) (*Policy, error) {
+	policy := new(Policy)
+
+	if err := policyfile.Parse(data, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Load reads and parses the policy file name
+func Load(name string) (*Policy, error) {
+	policy := new(Policy)
+
+	if err := policyfile.Load(name, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Validate checks the paths, principals and access levels of the rules
+func (policy *Policy) Validate() error {
+	seen := make(map[string]bool)
+
+	for inx, rule := range policy.Rules {
+		if !path.IsAbs(rule.Path) || zoneOf(rule.Path) == "" {
+			return fmt.Errorf("rule %d: path %q isn't an absolute iRODS path", inx+1, rule.Path)
+		}
+
+		p := path.Clean(rule.Path)
+		if seen[p] {
+			return fmt.Errorf("rule %d: another rule governs %v", inx+1, p)
+		}
+
+		seen[p] = true
+
+		principals := make(map[string]bool)
+
+		for _, grant := range rule.Grants {
+			name, _ := splitPrincipal(grant.Principal)
+			if name == "" {
+				return fmt.Errorf("rule %d: grant without principal", inx+1)
+			}
+
+			if _, err := AccessLevel(grant.Access); err != nil {
+				return fmt.Errorf("rule %d: %v", inx+1, err)
+			}
+
+			key := qualify(grant.Principal, zoneOf(p))
+			if principals[key] {
+				return fmt.Errorf("rule %d: %v is granted twice", inx+1, key)
+			}
+
+			principals[key] = true
+		}
+	}
+
+	return nil
+}
+
+// state is the desired state of an object, from the rule governing it
+type state struct {
+	rule   *Rule
+	access map[string]int
+}
+
+// compiled holds the rules with their desired states, deepest path first
+type compiled []state
+
+func compile(policy *Policy) (compiled, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	rules := make(compiled, len(policy.Rules))
+
+	for inx := range policy.Rules {
+		rule := policy.Rules[inx]
+		rule.Path = path.Clean(rule.Path)
+
+		access := make(map[string]int)
+		for _, grant := range rule.Grants {
+			access[qualify(grant.Principal, zoneOf(rule.Path))], _ = AccessLevel(grant.Access)
+		}
+
+		rules[inx] = state{rule: &rule, access: access}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].rule.Path) > len(rules[j].rule.Path)
+	})
+
+	return rules, nil
+}
+
+// governing returns the desired state of p, or nil when no rule governs it
+func (rules compiled) governing(p string) *state {
+	for inx := range rules {
+		rule := rules[inx].rule
+
+		if p == rule.Path || (rule.Recursive && isBelow(p, rule.Path)) {
+			return &rules[inx]
+		}
+	}
+
+	return nil
+}
+
+// roots returns the paths of the rules that aren't below a recursive rule, the traversal
+// starts from them
+func (rules compiled) roots() []string {
+	var roots []string
+
+	for _, st := range rules {
+		covered := false
+
+		for _, other := range rules {
+			if other.rule.Recursive && isBelow(st.rule.Path, other.rule.Path) {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			roots = append(roots, st.rule.Path)
+		}
+	}
+
+	sort.Strings(roots)
+
+	return roots
+}
+
+// isBelow reports whether p is below the collection dir
+func isBelow(p string, dir string) bool {
+	if dir == "/" {
+		return p != "/"
+	}
+
+	return strings.HasPrefix(p, dir+"/")
+}
+
+// zoneOf returns the zone of an absolute iRODS path
+func zoneOf(p string) string {
+	return strings.SplitN(strings.TrimPrefix(path.Clean(p), "/"), "/", 2)[0]
+}
+
+// splitPrincipal splits name#zone
+func splitPrincipal(principal string) (string, string) {
+	if inx := strings.LastIndex(principal, "#"); inx >= 0 {
+		return principal[:inx], principal[inx+1:]
+	}
+
+	return principal, ""
+}
+
+// qualify returns the name#zone form of principal, with zone for principals without one
+func qualify(principal string, zone string) string {
+	name, z := splitPrincipal(principal)
+	if z == "" {
+		z = zone
+	}
+
+	return name + "#" + z
+}
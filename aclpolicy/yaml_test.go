@@ -0,0 +1,29 @@
+//go:build gorods_yaml
+// +build gorods_yaml
+
+package aclpolicy
+
+import "testing"
+
+func TestParseYAML(t *testing.T) {
+	policy, err := Parse([]byte(`
+rules:
+- path: /tempZone/home/rods/proj
+  recursive: true
+  inherit: true
+  grants:
+  - {principal: team, access: write}
+  - {principal: auditor#otherZone, access: read}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(policy.Rules) != 1 || !policy.Rules[0].Recursive || *policy.Rules[0].Inherit != true || len(policy.Rules[0].Grants) != 2 {
+		t.Fatalf("Unexpected policy %+v", policy)
+	}
+
+	if _, err := Parse([]byte("rules:\n- path: /tempZone/a\n  recurse: true\n")); err == nil {
+		t.Fatal("Parsed a policy with an unknown field")
+	}
+}
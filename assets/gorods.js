@@ -0,0 +1,338 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+// Behaviour of the GoRODS FileServer collection view. It depends on nothing but the
+// browser and the global "gorods" object set by the collection template.
+(function() {
+	'use strict';
+
+	var $ = function(sel, ctx) { return (ctx || document).querySelector(sel); };
+	var $$ = function(sel, ctx) { return Array.prototype.slice.call((ctx || document).querySelectorAll(sel)); };
+
+	function escapeHtml(text) {
+		return String(text).replace(/["&<>]/g, function(a) {
+			return { '"': '&quot;', '&': '&amp;', '<': '&lt;', '>': '&gt;' }[a];
+		});
+	}
+
+	function request(method, url, headers, body, done) {
+		var xhr = new XMLHttpRequest();
+
+		xhr.open(method, url);
+
+		for ( var h in headers ) xhr.setRequestHeader(h, headers[h]);
+
+		xhr.onload = function() { done(xhr); };
+		xhr.onerror = function() { done(xhr); };
+
+		xhr.send(body);
+	}
+
+	function getJSON(url, done) {
+		request('GET', url, {}, null, function(xhr) {
+			var data = {};
+			try { data = JSON.parse(xhr.responseText); } catch (e) {}
+			done(data);
+		});
+	}
+
+	// post sends form data to one of the FileServer JSON endpoints and reports failures
+	function post(url, data, done) {
+		var body = Object.keys(data).map(function(k) {
+			return encodeURIComponent(k) + '=' + encodeURIComponent(data[k]);
+		}).join('&');
+
+		request('POST', url, {'Content-Type': 'application/x-www-form-urlencoded'}, body, function(xhr) {
+			var response = {};
+			try { response = JSON.parse(xhr.responseText); } catch (e) {}
+
+			if ( response.Success == true ) {
+				done(response);
+			} else {
+				alert("An error has occured: " + (response.Message || ("HTTP " + xhr.status)));
+			}
+		});
+	}
+
+	function formatTime(ts) {
+		return ts ? (new Date(parseInt(ts, 10) * 1000)).toLocaleString() : '';
+	}
+
+	function principalOptions(selected) {
+		var html = '';
+
+		gorods.users.forEach(function(u) {
+			html += '<option value="' + escapeHtml(u) + '"' + (u == selected ? ' selected' : '') + '>User: ' + escapeHtml(u) + '</option>';
+		});
+		gorods.groups.forEach(function(g) {
+			html += '<option value="' + escapeHtml(g) + '"' + (g == selected ? ' selected' : '') + '>Group: ' + escapeHtml(g) + '</option>';
+		});
+
+		return html;
+	}
+
+	var accessLevels = (gorods.accessLevels.length ? gorods.accessLevels : ['read', 'write', 'own']).concat(['null']);
+
+	// Details modal
+
+	var modal, currentURL;
+
+	function chmod(name, access, done) {
+		post(currentURL + '?createacl=1', {name: name, access: access}, done || refreshDetails);
+	}
+
+	function refreshDetails() {
+		getJSON(currentURL + '?meta=1', function(data) {
+			var metaTbl = $('.meta-tbl tbody', modal);
+			var aclTbl = $('.acl-tbl tbody', modal);
+
+			if ( !data.stat ) {
+				metaTbl.innerHTML = '<tr><td colspan="4" class="empty">Error Fetching Metadata</td></tr>';
+				aclTbl.innerHTML = '<tr><td colspan="3" class="empty">Error Fetching ACLs</td></tr>';
+				return;
+			}
+
+			var stat = data.stat[0] || {};
+
+			$$('[data-stat]', modal).forEach(function(td) {
+				var v = stat[td.getAttribute('data-stat')] || '';
+				td.textContent = td.hasAttribute('data-time') ? formatTime(v) : v;
+			});
+
+			metaTbl.innerHTML = data.metadata.length ? data.metadata.map(function(m) {
+				return '<tr><td>' + escapeHtml(m.attribute) + '</td><td>' + escapeHtml(m.value) + '</td><td>' + escapeHtml(m.units) + '</td>' +
+					'<td class="fit"><a class="meta-del danger" title="Delete">&#x2715;</a></td></tr>';
+			}).join('') : '<tr><td colspan="4" class="empty">No Metadata Found</td></tr>';
+
+			$$('.meta-del', metaTbl).forEach(function(del, n) {
+				del.addEventListener('click', function() {
+					var m = data.metadata[n];
+					post(currentURL + '?deletemeta=1', {attribute: m.attribute, value: m.value, units: m.units}, refreshDetails);
+				});
+			});
+
+			aclTbl.innerHTML = data.acl.map(function(acl) {
+				var name = gorods.users.length == 0 ?
+					'<input class="acl-name" disabled value="' + escapeHtml(acl.name) + '">' :
+					'<select class="acl-name" data-prev="' + escapeHtml(acl.name) + '">' + principalOptions(acl.name) + '</select>';
+
+				var levels = accessLevels.map(function(l) {
+					return '<option value="' + l + '"' + (l == acl.accessLevel ? ' selected' : '') + '>' + l + '</option>';
+				}).join('');
+
+				return '<tr><td>' + name + '</td><td>' + escapeHtml(acl.type) + '</td><td><select class="access-level">' + levels + '</select></td></tr>';
+			}).join('');
+
+			$$('tr', aclTbl).forEach(function(tr) {
+				var nameInput = $('.acl-name', tr);
+				var levelSelect = $('.access-level', tr);
+
+				levelSelect.addEventListener('change', function() {
+					chmod(nameInput.value, levelSelect.value);
+				});
+
+				if ( nameInput.tagName == 'SELECT' ) {
+					nameInput.addEventListener('change', function() {
+						var prev = nameInput.getAttribute('data-prev');
+
+						chmod(nameInput.value, levelSelect.value, function() {
+							if ( prev != nameInput.value ) {
+								chmod(prev, 'null');
+							} else {
+								refreshDetails();
+							}
+						});
+					});
+				}
+			});
+		});
+	}
+
+	function showTab(name) {
+		$$('.tabs li, .tab-pane', modal).forEach(function(el) {
+			el.classList.toggle('active', el.getAttribute('data-tab') == name);
+		});
+	}
+
+	function openDetails(url, name, isCollection) {
+		currentURL = url;
+
+		$('.modal-title', modal).textContent = (isCollection ? 'Collection' : 'Data Object') + ' "' + name + '"';
+		showTab('stat');
+		refreshDetails();
+
+		modal.classList.add('open');
+	}
+
+	// Resumable (tus) upload, chunks are sent sequentially and the upload URL is kept
+	// in localStorage so a dropped upload of the same file continues where it left off
+	var tusChunkSize = 8 * 1024 * 1024;
+
+	function tusUpload(file, progress, done) {
+		var colPath = gorods.collectionURL;
+		var storageKey = 'gorods-tus:' + colPath + ':' + file.name + ':' + file.size + ':' + file.lastModified;
+		var uploadURL = window.localStorage ? localStorage.getItem(storageKey) : null;
+
+		var forget = function() {
+			if ( window.localStorage ) localStorage.removeItem(storageKey);
+		};
+
+		var fail = function(xhr) {
+			if ( xhr.status != 0 ) forget();
+			done(xhr.responseText || ('HTTP ' + xhr.status));
+		};
+
+		var sendChunk = function(offset) {
+			progress(offset, file.size);
+
+			if ( offset >= file.size ) {
+				forget();
+				done(null);
+				return;
+			}
+
+			request('PATCH', uploadURL, {
+				'Tus-Resumable': '1.0.0',
+				'Upload-Offset': String(offset),
+				'Content-Type': 'application/offset+octet-stream'
+			}, file.slice(offset, offset + tusChunkSize), function(xhr) {
+				if ( xhr.status != 204 ) return fail(xhr);
+				sendChunk(parseInt(xhr.getResponseHeader('Upload-Offset'), 10));
+			});
+		};
+
+		var create = function() {
+			request('POST', colPath + '?tus=1', {
+				'Tus-Resumable': '1.0.0',
+				'Upload-Length': String(file.size),
+				'Upload-Metadata': 'filename ' + btoa(unescape(encodeURIComponent(file.name)))
+			}, null, function(xhr) {
+				if ( xhr.status != 201 ) return fail(xhr);
+
+				uploadURL = xhr.getResponseHeader('Location');
+				if ( window.localStorage ) localStorage.setItem(storageKey, uploadURL);
+				sendChunk(0);
+			});
+		};
+
+		if ( uploadURL ) {
+			request('HEAD', uploadURL, {'Tus-Resumable': '1.0.0'}, null, function(xhr) {
+				if ( xhr.status != 200 ) {
+					forget();
+					return create();
+				}
+				sendChunk(parseInt(xhr.getResponseHeader('Upload-Offset'), 10));
+			});
+		} else {
+			create();
+		}
+	}
+
+	document.addEventListener('DOMContentLoaded', function() {
+		modal = $('#details-modal');
+
+		$$('.show-details').forEach(function(a) {
+			a.addEventListener('click', function() {
+				openDetails(a.getAttribute('data-url'), a.getAttribute('data-name'), a.getAttribute('data-collection') == 'true');
+			});
+		});
+
+		if ( modal ) {
+			$('.close', modal).addEventListener('click', function() {
+				modal.classList.remove('open');
+			});
+
+			modal.addEventListener('click', function(e) {
+				if ( e.target == modal ) modal.classList.remove('open');
+			});
+
+			$$('.tabs li', modal).forEach(function(li) {
+				li.addEventListener('click', function() {
+					showTab(li.getAttribute('data-tab'));
+				});
+			});
+
+			var principals = $('.principal-select', modal);
+			if ( principals ) principals.innerHTML = '<option></option>' + principalOptions('');
+
+			$('.avu-form', modal).addEventListener('submit', function(e) {
+				var form = this;
+
+				e.preventDefault();
+
+				post(currentURL + '?meta=1', {
+					attribute: form.attribute.value,
+					value: form.value.value,
+					units: form.units.value
+				}, function() {
+					form.reset();
+					refreshDetails();
+				});
+			});
+
+			$('.acl-form', modal).addEventListener('submit', function(e) {
+				var form = this;
+
+				e.preventDefault();
+
+				chmod(form.name.value, form.access.value, function() {
+					form.reset();
+					refreshDetails();
+				});
+			});
+		}
+
+		$$('.delete-obj').forEach(function(a) {
+			a.addEventListener('click', function() {
+				if ( !confirm('Are you sure you want to delete this iRODS object? This action cannot be undone.') ) {
+					return;
+				}
+
+				post(a.getAttribute('data-url') + '?delete=1', {}, function() {
+					document.location.reload(true);
+				});
+			});
+		});
+
+		var createForm = $('.create-collection-form');
+		if ( createForm ) {
+			createForm.addEventListener('submit', function(e) {
+				e.preventDefault();
+
+				post(gorods.collectionURL + '?createcol=1', {colname: $('.collection-name', createForm).value}, function() {
+					document.location.reload(true);
+				});
+			});
+		}
+
+		var uploadBtn = $('.upload-btn');
+		if ( uploadBtn ) {
+			uploadBtn.addEventListener('click', function() {
+				var input = document.createElement('input');
+				input.type = 'file';
+
+				input.addEventListener('change', function() {
+					var file = input.files[0];
+					var progBar = $('.prog-bar');
+
+					if ( !file ) return;
+
+					progBar.style.display = 'block';
+
+					tusUpload(file, function(offset, total) {
+						progBar.style.width = (total > 0 ? (offset / total) * 100 : 100) + '%';
+					}, function(err) {
+						if ( err ) {
+							alert('An error has occured: ' + err);
+							progBar.style.display = 'none';
+						} else {
+							document.location.reload(true);
+						}
+					});
+				});
+
+				input.click();
+			});
+		}
+	});
+})();
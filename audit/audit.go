@@ -0,0 +1,349 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package audit checks the fixity of data object replicas, like ichksum -K and ifsck. Run
+// walks collection trees or the result of a GenQuery and, for every replica, compares the
+// registered checksum and size with those of the data on the resource. It flags checksum and
+// size mismatches, missing checksums, stale replicas and objects with too few good replicas:
+//
+//	report, err := audit.Run(fsys, audit.Options{
+//		Paths:       []string{"/tempZone/projects"},
+//		MinReplicas: 2,
+//		Workers:     8,
+//		Connect:     dial,
+//		ReportFile:  "audit.jsonl",
+//	})
+//
+// The results are written to the report file as JSON lines while the audit runs, and only
+// counted in memory. An interrupted audit started again with the same ReportFile skips the
+// objects already checked. The calls of gorods connections run one at a time: parallel workers
+// need a connection each, opened by Connect.
+//
+// It works with the irodsfs implementations that support replicas, gorods.Connection.Filesystem()
+// and gorodstest.Connection.
+package audit
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// FS is the part of irodsfs used by the audit
+type FS interface {
+	irodsfs.FS
+	irodsfs.ReplicaFS
+}
+
+// Options select the data objects to audit and tune the audit
+type Options struct {
+	// Paths are collections, audited recursively, or data objects
+	Paths []string
+
+	// Query is an iquest query selecting COLL_NAME and DATA_NAME, the data objects of its
+	// rows are audited. The FS given to Run must implement irodsfs.QueryFS.
+	Query string
+
+	// MinReplicas flags the objects with fewer good replicas, 0 disables the check
+	MinReplicas int
+
+	// RegisterMissing computes and registers the checksum of replicas that have none (ichksum)
+	RegisterMissing bool
+
+	// Workers is the number of data objects audited in parallel. It defaults to 4.
+	Workers int
+
+	// Connect opens the connection of a worker, which is closed at the end of the audit when
+	// it has a Disconnect or Close method. Without it the workers share the FS given to Run. The
+	// calls of gorods connections run one at a time, Run fails without Connect when there's
+	// more than one worker.
+	Connect func() (FS, error)
+
+	// ReportFile is the file the results are appended to as JSON lines while the audit runs,
+	// after a line holding the Report and before another one once it completes. An incomplete
+	// report found there is resumed, see Load and ReadResults.
+	ReportFile string
+
+	// SaveEvery is the number of audited objects between two flushes of ReportFile. It
+	// defaults to 100.
+	SaveEvery int
+
+	// Result is called with the result of every audited object, one at a time
+	Result func(res Result)
+}
+
+// Run audits the data objects selected by opts and returns the report. The error is set when
+// the audit can't run or the report can't be saved, objects that can't be audited are reported
+// as failures.
+func Run(fsys FS, opts Options) (*Report, error) {
+	if len(opts.Paths) == 0 && opts.Query == "" {
+		return nil, fmt.Errorf("audit: no paths or query")
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+
+	if opts.SaveEvery <= 0 {
+		opts.SaveEvery = 100
+	}
+
+	if s, ok := fsys.(interface{ Serial() bool }); ok && s.Serial() && opts.Connect == nil && opts.Workers > 1 {
+		return nil, fmt.Errorf("audit: the calls of the connection run one at a time, %d workers need Connect", opts.Workers)
+	}
+
+	// Workers connect before anything is listed, so a failed connection stops the audit
+	conns := make([]FS, opts.Workers)
+
+	for inx := range conns {
+		var err error
+
+		conns[inx] = fsys
+
+		if opts.Connect != nil {
+			if conns[inx], err = opts.Connect(); err != nil {
+				closeAll(conns[:inx])
+				return nil, fmt.Errorf("audit: connecting worker %d: %v", inx+1, err)
+			}
+		}
+	}
+
+	if opts.Connect != nil {
+		defer closeAll(conns)
+	}
+
+	report, done, out, err := resume(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(chan string)
+	results := make(chan Result)
+
+	go func() {
+		defer close(paths)
+
+		emit := func(p string) {
+			if !done[p] {
+				done[p] = true
+				paths <- p
+			}
+		}
+
+		fail := func(p string, err error) {
+			results <- Result{Path: p, Error: err.Error(), CheckedAt: time.Now()}
+		}
+
+		list(fsys, opts, emit, fail)
+	}()
+
+	var wg sync.WaitGroup
+
+	for _, con := range conns {
+		wg.Add(1)
+
+		go func(con FS) {
+			defer wg.Done()
+
+			for p := range paths {
+				results <- check(con, p, opts)
+			}
+		}(con)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var saveErr error
+
+	unsaved := 0
+
+	for res := range results {
+		report.add(res)
+
+		if opts.Result != nil {
+			opts.Result(res)
+		}
+
+		if out == nil || saveErr != nil {
+			continue
+		}
+
+		if saveErr = out.write(res); saveErr == nil {
+			if unsaved++; unsaved >= opts.SaveEvery {
+				saveErr = out.flush()
+				unsaved = 0
+			}
+		}
+	}
+
+	report.Complete = true
+	report.Updated = time.Now()
+
+	if out != nil {
+		if saveErr == nil {
+			saveErr = out.write(report)
+		}
+
+		if err := out.close(); saveErr == nil {
+			saveErr = err
+		}
+	}
+
+	return report, saveErr
+}
+
+// list calls emit with the path of every data object selected by opts, and fail with the
+// paths that can't be listed
+func list(fsys FS, opts Options, emit func(p string), fail func(p string, err error)) {
+	for _, root := range opts.Paths {
+		root = path.Clean(root)
+
+		irodsfs.Walk(fsys, root, func(p string, info irodsfs.ObjInfo, err error) error {
+			switch {
+			case err != nil:
+				fail(p, err)
+			case !info.IsDir():
+				emit(p)
+			}
+
+			return nil
+		})
+	}
+
+	if opts.Query == "" {
+		return
+	}
+
+	qfs, ok := fsys.(irodsfs.QueryFS)
+	if !ok {
+		fail(opts.Query, fmt.Errorf("the filesystem doesn't support queries"))
+		return
+	}
+
+	rows, err := qfs.IQuest(opts.Query, false)
+	if err != nil {
+		fail(opts.Query, err)
+		return
+	}
+
+	for _, row := range rows {
+		coll, name := row["COLL_NAME"], row["DATA_NAME"]
+		if coll == "" || name == "" {
+			fail(opts.Query, fmt.Errorf("the query must select COLL_NAME and DATA_NAME"))
+			return
+		}
+
+		emit(path.Join(coll, name))
+	}
+}
+
+// check audits the replicas of the data object p
+func check(fsys FS, p string, opts Options) Result {
+	res := Result{Path: p, CheckedAt: time.Now()}
+
+	repls, err := fsys.Replicas(p)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	good := 0
+
+	for _, repl := range repls {
+		rr := ReplicaResult{
+			Num:        repl.Num,
+			Resource:   repl.RescHier,
+			Status:     statusName(repl.Status),
+			Size:       repl.Size,
+			Registered: repl.Checksum,
+		}
+
+		switch repl.Status {
+		case irodsfs.Good:
+			good++
+		case irodsfs.Stale:
+			rr.Problems = append(rr.Problems, Stale)
+		}
+
+		if repl.Checksum == "" {
+			rr.Problems = append(rr.Problems, MissingChecksum)
+
+			if opts.RegisterMissing {
+				if rr.Computed, err = fsys.ChecksumReplica(p, repl.Num); err != nil {
+					rr.Error = err.Error()
+					res.Replicas = append(res.Replicas, rr)
+					continue
+				}
+
+				rr.Registered = rr.Computed
+			}
+		}
+
+		fix, err := fsys.VerifyReplica(p, repl.Num)
+
+		switch {
+		case err == irodsfs.ErrChecksumMismatch:
+			rr.Problems = append(rr.Problems, ChecksumMismatch)
+		case err != nil:
+			rr.Error = err.Error()
+			res.Replicas = append(res.Replicas, rr)
+			continue
+		case fix.Checksum != "":
+			rr.Computed = fix.Checksum
+
+			if rr.Registered != "" && fix.Checksum != rr.Registered {
+				rr.Problems = append(rr.Problems, ChecksumMismatch)
+			}
+		}
+
+		rr.PhysicalSize = fix.Size
+
+		if fix.Size != repl.Size {
+			rr.Problems = append(rr.Problems, SizeMismatch)
+		}
+
+		res.Replicas = append(res.Replicas, rr)
+	}
+
+	if opts.MinReplicas > 0 && good < opts.MinReplicas {
+		res.Problems = append(res.Problems, UnderReplicated)
+	}
+
+	return res
+}
+
+// statusName returns the name of a replica status
+func statusName(status int) string {
+	switch status {
+	case irodsfs.Stale:
+		return "stale"
+	case irodsfs.Good:
+		return "good"
+	case irodsfs.Intermediate:
+		return "intermediate"
+	case irodsfs.ReadLocked:
+		return "read-locked"
+	case irodsfs.WriteLocked:
+		return "write-locked"
+	}
+
+	return fmt.Sprintf("status(%d)", status)
+}
+
+// closeAll closes the connections of the workers
+func closeAll(conns []FS) {
+	for _, con := range conns {
+		switch c := con.(type) {
+		case interface{ Disconnect() error }:
+			c.Disconnect()
+		case interface{ Close() error }:
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+const home = "/tempZone/home/rods"
+
+func testServer(t *testing.T) (*gorodstest.Server, *gorodstest.Connection) {
+	srv := gorodstest.NewServer("tempZone")
+	srv.CreateResource("archiveResc")
+
+	con, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	con.Mkdir(home+"/data/sub", true)
+
+	put := func(name string, data string, checksum bool, replicate bool) {
+		p := home + "/data/" + name
+		if err := con.Put(p, []byte(data), gorodstest.PutOptions{Checksum: checksum}); err != nil {
+			t.Fatal(err)
+		}
+
+		if replicate {
+			if err := con.Replicate(p, "archiveResc"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	put("ok.txt", "fine", true, true)
+	put("rot.txt", "abcd", true, true)
+	put("sub/short.txt", "truncated", true, true)
+	put("sub/nosum.txt", "lonely", false, false)
+	put("stale.txt", "old", true, true)
+
+	srv.CorruptReplica(home+"/data/rot.txt", 1, []byte("abce"))
+	srv.CorruptReplica(home+"/data/sub/short.txt", 0, []byte("trunc"))
+	con.Put(home+"/data/stale.txt", []byte("new"), gorodstest.PutOptions{Force: true, Checksum: true})
+
+	return srv, con
+}
+
+// collect returns the Options.Result collecting the results of an audit by path
+func collect(results map[string]Result) func(res Result) {
+	return func(res Result) {
+		results[res.Path] = res
+	}
+}
+
+// problems returns the problems of every object and replica of the results
+func problems(results map[string]Result) map[string][]string {
+	found := make(map[string][]string)
+
+	for _, res := range results {
+		found[res.Path] = append(found[res.Path], res.Problems...)
+
+		for _, rr := range res.Replicas {
+			for _, problem := range rr.Problems {
+				found[res.Path] = append(found[res.Path], problem)
+			}
+		}
+	}
+
+	return found
+}
+
+func TestRun(t *testing.T) {
+	srv, con := testServer(t)
+
+	var dials int32
+
+	results := make(map[string]Result)
+
+	report, err := Run(con, Options{
+		Paths:       []string{home + "/data"},
+		MinReplicas: 2,
+		Workers:     3,
+		Connect: func() (FS, error) {
+			atomic.AddInt32(&dials, 1)
+			return srv.Connect("rods")
+		},
+		Result: collect(results),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dials != 3 || !report.Complete {
+		t.Fatalf("Unexpected dials %v or incomplete report", dials)
+	}
+
+	want := map[string][]string{
+		home + "/data/ok.txt":        nil,
+		home + "/data/rot.txt":       {ChecksumMismatch},
+		home + "/data/stale.txt":     {UnderReplicated, Stale},
+		home + "/data/sub/nosum.txt": {UnderReplicated, MissingChecksum},
+		home + "/data/sub/short.txt": {ChecksumMismatch, SizeMismatch},
+	}
+
+	if got := problems(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected problems %v, want %v", got, want)
+	}
+
+	if report.Summary.Objects != 5 || report.Summary.Replicas != 9 || report.Summary.OK != 1 || report.Summary.Problems[ChecksumMismatch] != 2 {
+		t.Fatalf("Unexpected summary %+v", report.Summary)
+	}
+
+	if s := report.String(); s != "5 objects, 9 replicas: 1 ok, 0 failed, checksum_mismatch 2, size_mismatch 1, missing_checksum 1, stale 1, under_replicated 2" {
+		t.Fatalf("Unexpected summary %q", s)
+	}
+
+	short := results[home+"/data/sub/short.txt"].Replicas[0]
+	if short.Size != 9 || short.PhysicalSize != 5 || short.Computed == short.Registered {
+		t.Fatalf("Unexpected result %+v", short)
+	}
+
+	// The audit doesn't change the catalog
+	if repls, _ := con.Replicas(home + "/data/rot.txt"); repls[1].Checksum != short.Registered && repls[1].Checksum != results[home+"/data/rot.txt"].Replicas[1].Registered {
+		t.Fatalf("The audit registered a checksum: %+v", repls)
+	}
+}
+
+func TestRunSerial(t *testing.T) {
+	_, con := testServer(t)
+
+	if _, err := Run(serialFS{con}, Options{Paths: []string{home + "/data"}}); err == nil {
+		t.Fatal("Workers shared a serial connection")
+	}
+
+	if report, err := Run(serialFS{con}, Options{Paths: []string{home + "/data"}, Workers: 1}); err != nil || report.Summary.Objects != 5 {
+		t.Fatalf("Unexpected report %v %+v", err, report)
+	}
+}
+
+func TestRunQueryAndRegister(t *testing.T) {
+	_, con := testServer(t)
+
+	results := make(map[string]Result)
+
+	_, err := Run(con, Options{
+		Query:           "select COLL_NAME, DATA_NAME where DATA_NAME like '%sum.txt'",
+		RegisterMissing: true,
+		Result:          collect(results),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nosum := results[home+"/data/sub/nosum.txt"]
+	if len(results) != 1 || nosum.Replicas[0].Computed == "" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	if repls, _ := con.Replicas(home + "/data/sub/nosum.txt"); repls[0].Checksum != nosum.Replicas[0].Computed {
+		t.Fatalf("Missing checksum wasn't registered: %+v", repls)
+	}
+
+	report, err := Run(con, Options{Paths: []string{home + "/missing"}})
+	if err != nil || report.Summary.Failed != 1 || report.Summary.Objects != 0 {
+		t.Fatalf("Unexpected report %v %+v", err, report)
+	}
+}
+
+func TestResume(t *testing.T) {
+	_, con := testServer(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "audit.jsonl")
+
+	// An interrupted audit that checked ok.txt, failed to check rot.txt, and was cut while
+	// writing the next result
+	interrupted := `{"started": "2016-04-22T10:02:30Z", "complete": false, "summary": {"objects": 0}}
+{"path": "/tempZone/home/rods/data/ok.txt", "replicas": [{"num": 0, "computed": "from the first run"}]}
+{"path": "/tempZone/home/rods/data/rot.txt", "error": "connection reset"}
+{"path": "/tempZone/home/rods/da`
+
+	if err := ioutil.WriteFile(name, []byte(interrupted), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(con, Options{Paths: []string{home + "/data"}, ReportFile: name, SaveEvery: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Summary.Objects != 5 || report.Started.Year() != 2016 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	results := make(map[string]Result)
+
+	if err := ReadResults(name, func(res Result) error {
+		if _, ok := results[res.Path]; ok {
+			t.Errorf("%v audited twice", res.Path)
+		}
+
+		results[res.Path] = res
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 5 || results[home+"/data/ok.txt"].Replicas[0].Computed != "from the first run" || results[home+"/data/rot.txt"].Error != "" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	saved, err := Load(name)
+	if err != nil || !saved.Complete || !reflect.DeepEqual(saved.Summary, report.Summary) {
+		t.Fatalf("Unexpected saved report %v %+v", err, saved)
+	}
+
+	// A complete report starts a new audit
+	if report, err = Run(con, Options{Paths: []string{home + "/data"}, ReportFile: name}); err != nil || report.Started.Year() == 2016 {
+		t.Fatalf("Complete report was resumed: %v %+v", err, report)
+	}
+
+	if err := ReadResults(name, func(res Result) error {
+		if res.Path == home+"/data/ok.txt" && res.Replicas[0].Computed == "from the first run" {
+			t.Error("Result of the complete report kept")
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serialFS is a connection whose calls run one at a time
+type serialFS struct {
+	*gorodstest.Connection
+}
+
+func (serialFS) Serial() bool {
+	return true
+}
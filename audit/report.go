@@ -0,0 +1,376 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Problems found by the audit
+const (
+	// ChecksumMismatch is a replica whose data doesn't match its registered checksum
+	ChecksumMismatch = "checksum_mismatch"
+
+	// SizeMismatch is a replica whose data doesn't have its registered size
+	SizeMismatch = "size_mismatch"
+
+	// MissingChecksum is a replica without a registered checksum
+	MissingChecksum = "missing_checksum"
+
+	// Stale is a replica left behind by a write to another replica
+	Stale = "stale"
+
+	// UnderReplicated is a data object with fewer good replicas than Options.MinReplicas
+	UnderReplicated = "under_replicated"
+)
+
+// ReplicaResult is the audit of one replica. Size and Registered come from the catalog,
+// PhysicalSize and Computed from the data on the resource. Computed is empty when the server
+// only compares checksums.
+type ReplicaResult struct {
+	Num          int      `json:"num"`
+	Resource     string   `json:"resource"`
+	Status       string   `json:"status"`
+	Size         int64    `json:"size"`
+	PhysicalSize int64    `json:"physicalSize"`
+	Registered   string   `json:"checksum,omitempty"`
+	Computed     string   `json:"computed,omitempty"`
+	Problems     []string `json:"problems,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// Result is the audit of a data object. Error is set when its replicas couldn't be listed, or
+// for a collection or query that couldn't be listed.
+type Result struct {
+	Path      string          `json:"path"`
+	Replicas  []ReplicaResult `json:"replicas,omitempty"`
+	Problems  []string        `json:"problems,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CheckedAt time.Time       `json:"checkedAt"`
+}
+
+// failed reports whether the object or one of its replicas couldn't be audited
+func (res *Result) failed() bool {
+	if res.Error != "" {
+		return true
+	}
+
+	for _, rr := range res.Replicas {
+		if rr.Error != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OK reports whether the object and all its replicas were audited without finding problems
+func (res *Result) OK() bool {
+	if res.Error != "" || len(res.Problems) > 0 {
+		return false
+	}
+
+	for _, rr := range res.Replicas {
+		if rr.Error != "" || len(rr.Problems) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Summary counts the audited objects and replicas, and the problems found. Failed counts the
+// objects and replicas that couldn't be audited.
+type Summary struct {
+	Objects  int            `json:"objects"`
+	Replicas int            `json:"replicas"`
+	OK       int            `json:"ok"`
+	Failed   int            `json:"failed"`
+	Problems map[string]int `json:"problems"`
+}
+
+// Report summarizes an audit. Complete is false while the audit runs, or when it was
+// interrupted. The results of the objects go to Options.Result and to the report file, see
+// ReadResults.
+type Report struct {
+	Started  time.Time `json:"started"`
+	Updated  time.Time `json:"updated"`
+	Complete bool      `json:"complete"`
+	Summary  Summary   `json:"summary"`
+}
+
+func newReport() *Report {
+	return &Report{
+		Started: time.Now(),
+		Summary: Summary{Problems: make(map[string]int)},
+	}
+}
+
+// String summarizes the report
+// example: 1200 objects, 2400 replicas: 1197 ok, 1 failed, checksum_mismatch 1, stale 2
+func (report *Report) String() string {
+	s := fmt.Sprintf("%v objects, %v replicas: %v ok, %v failed", report.Summary.Objects, report.Summary.Replicas, report.Summary.OK, report.Summary.Failed)
+
+	for _, problem := range []string{ChecksumMismatch, SizeMismatch, MissingChecksum, Stale, UnderReplicated} {
+		if n := report.Summary.Problems[problem]; n > 0 {
+			s += fmt.Sprintf(", %v %v", problem, n)
+		}
+	}
+
+	return s
+}
+
+// add counts a result in the summary
+func (report *Report) add(res Result) {
+	report.Updated = time.Now()
+
+	sum := &report.Summary
+
+	if res.Error != "" {
+		sum.Failed++
+		return
+	}
+
+	sum.Objects++
+	sum.Replicas += len(res.Replicas)
+
+	if res.OK() {
+		sum.OK++
+	}
+
+	for _, problem := range res.Problems {
+		sum.Problems[problem]++
+	}
+
+	for _, rr := range res.Replicas {
+		if rr.Error != "" {
+			sum.Failed++
+		}
+
+		for _, problem := range rr.Problems {
+			sum.Problems[problem]++
+		}
+	}
+}
+
+// reportLine is a line of a report file: the Report, written when the audit starts and when it
+// completes, or the Result of an object
+type reportLine struct {
+	Result
+
+	Started  time.Time `json:"started"`
+	Updated  time.Time `json:"updated"`
+	Complete bool      `json:"complete"`
+	Summary  *Summary  `json:"summary"`
+}
+
+// readReport calls fn with each line of the report file name: the report lines set report, the
+// result lines res. The last line of an interrupted audit may be cut, it's skipped.
+func readReport(name string, fn func(report *Report, res *Result) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	for lineNum := 1; ; lineNum++ {
+		data, readErr := r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			var line reportLine
+
+			if err := json.Unmarshal(data, &line); err != nil {
+				if readErr == io.EOF {
+					return nil
+				}
+
+				return fmt.Errorf("%v:%d: %v", name, lineNum, err)
+			}
+
+			if line.Summary != nil {
+				err = fn(&Report{Started: line.Started, Updated: line.Updated, Complete: line.Complete, Summary: *line.Summary}, nil)
+			} else {
+				err = fn(nil, &line.Result)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// Load reads the report of the file name. The summary of an incomplete report counts the
+// results written so far.
+func Load(name string) (*Report, error) {
+	report := newReport()
+
+	err := readReport(name, func(saved *Report, res *Result) error {
+		switch {
+		case saved != nil && saved.Complete:
+			report = saved
+		case saved != nil:
+			report.Started = saved.Started
+		case !report.Complete:
+			report.add(*res)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// ReadResults calls fn with the results of the report file name, in the order the objects were
+// audited. It stops at the first error of fn, and returns it.
+func ReadResults(name string, fn func(res Result) error) error {
+	return readReport(name, func(_ *Report, res *Result) error {
+		if res == nil {
+			return nil
+		}
+
+		return fn(*res)
+	})
+}
+
+// reportWriter appends the lines of a report file
+type reportWriter struct {
+	f   *os.File
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newReportWriter(f *os.File) *reportWriter {
+	buf := bufio.NewWriter(f)
+
+	return &reportWriter{
+		f:   f,
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+// write appends v as a JSON line, to the buffer until flush
+func (rw *reportWriter) write(v interface{}) error {
+	return rw.enc.Encode(v)
+}
+
+func (rw *reportWriter) flush() error {
+	return rw.buf.Flush()
+}
+
+func (rw *reportWriter) close() error {
+	err := rw.buf.Flush()
+
+	if closeErr := rw.f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// errCompleteReport stops the reading of a complete report by resume
+var errCompleteReport = errors.New("complete report")
+
+// resume opens the report file of opts. The results of an incomplete report found there are
+// counted, and copied to a new file without the failures, which are audited again: done holds
+// the objects to skip. A new report is started otherwise.
+func resume(opts Options) (report *Report, done map[string]bool, out *reportWriter, err error) {
+	report = newReport()
+	done = make(map[string]bool)
+
+	if opts.ReportFile == "" {
+		return report, done, nil, nil
+	}
+
+	name := opts.ReportFile
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	out = newReportWriter(tmp)
+	started := false
+
+	err = readReport(name, func(saved *Report, res *Result) error {
+		switch {
+		case saved != nil && saved.Complete:
+			return errCompleteReport
+		case saved != nil:
+			report.Started = saved.Started
+			started = true
+
+			return out.write(report)
+		case res.failed() || done[res.Path]:
+			return nil
+		}
+
+		report.add(*res)
+		done[res.Path] = true
+
+		return out.write(res)
+	})
+
+	switch {
+	case err == errCompleteReport || os.IsNotExist(err):
+		// A new report replaces the old one
+		report = newReport()
+		done = make(map[string]bool)
+		started = false
+
+		if err = tmp.Truncate(0); err == nil {
+			_, err = tmp.Seek(0, io.SeekStart)
+		}
+
+		out = newReportWriter(tmp)
+	}
+
+	if err == nil && !started {
+		err = out.write(report)
+	}
+
+	if err == nil {
+		err = out.close()
+	} else {
+		out.close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, nil, nil, err
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return report, done, newReportWriter(f), nil
+}
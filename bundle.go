@@ -0,0 +1,213 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Data types of the structured files, see BundleOptions.DataType
+const (
+	TarBundle      = "tar"
+	GzipTarBundle  = "gzipTar"
+	Bzip2TarBundle = "bzip2Tar"
+	ZipBundle      = "zipFile"
+)
+
+// BundleOptions store the options of the bundle operations of Collection, like the flags of ibun
+type BundleOptions struct {
+	// Resource holds the structured file created by Bundle, or the files extracted by Extract (ibun -R).
+	// It's a string or a *Resource, the default resource when nil.
+	Resource interface{}
+
+	// Force overwrites an existing structured file, or the data objects extracted over (ibun -f)
+	Force bool
+
+	// DataType is the format of the structured file, TarBundle when empty (ibun -D)
+	DataType string
+
+	// Bulk registers the extracted files in bulk, much faster for many small files (ibun -b)
+	Bulk bool
+}
+
+// resource returns the name of opts.Resource, empty for the default resource
+func (opts *BundleOptions) resource() (string, error) {
+	return resourceName(opts.Resource)
+}
+
+// Bundle creates the structured file tarPath on the server, holding the data objects and sub collections
+// of the collection (ibun -c). Returns the data object of the structured file.
+func (col *Collection) Bundle(tarPath string, opts BundleOptions) (*DataObj, error) {
+	if col.con.fs != nil {
+		return nil, unsupported("Bundle")
+	}
+
+	return col.cBundle(tarPath, opts)
+}
+
+// Extract extracts the structured file tarPath, a data object uploaded beforehand, on the server and registers its
+// files as data objects and sub collections of the collection (ibun -x). Thousands of small files are ingested
+// with one upload and one call, instead of one upload each.
+func (col *Collection) Extract(tarPath string, opts BundleOptions) error {
+	if col.con.fs != nil {
+		return unsupported("Extract")
+	}
+
+	return col.cExtract(tarPath, opts)
+}
+
+// Mount registers the contents of the tar file tarPath as the contents of the collection, which must be empty
+// (imcoll -m tar). The files are read from the tar file, they aren't extracted or copied.
+func (col *Collection) Mount(tarPath string, opts BundleOptions) error {
+	if col.con.fs != nil {
+		return unsupported("Mount")
+	}
+
+	return col.cMount(tarPath, opts)
+}
+
+// Unmount unmounts the collection mounted with Mount (imcoll -U)
+func (col *Collection) Unmount() error {
+	if col.con.fs != nil {
+		return unsupported("Unmount")
+	}
+
+	return col.cUnmount()
+}
+
+// PutBundle uploads the local directory localDir as a sub collection of the collection, with the same name, in
+// one transfer: the directory is written to a temporary tar file, uploaded with Put, extracted on the server
+// with Extract, and the tar file removed. opts.DataType is ignored.
+func (col *Collection) PutBundle(localDir string, opts BundleOptions) (*Collection, error) {
+	localDir = filepath.Clean(localDir)
+
+	if st, err := os.Stat(localDir); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS PutBundle Failed: %v", err))
+	} else if !st.IsDir() {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS PutBundle Failed: %v is not a directory", localDir))
+	}
+
+	file, err := ioutil.TempFile("", "gorods-bundle")
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS PutBundle Failed: %v", err))
+	}
+
+	defer os.Remove(file.Name())
+
+	err = writeTar(file, localDir)
+
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS PutBundle Failed: %v", err))
+	}
+
+	name := "." + filepath.Base(localDir) + "." + strconv.FormatInt(time.Now().UnixNano(), 36) + ".tar"
+
+	tarObj, err := col.Put(file.Name(), DataObjOptions{
+		Name:     name,
+		Force:    true,
+		Resource: opts.Resource,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts.DataType = TarBundle
+
+	err = col.Extract(tarObj.Path(), opts)
+
+	if derr := tarObj.Delete(false); err == nil {
+		err = derr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := col.Refresh(); err != nil {
+		return nil, err
+	}
+
+	sub, err := col.con.Collection(CollectionOptions{
+		Path:      col.path + "/" + filepath.Base(localDir),
+		SkipCache: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The collection isn't cached, its handle is opened again when it's read
+	if err := sub.Close(); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// writeTar writes the directories and regular files below dir to w as a tar archive, with names relative to
+// the parent of dir, so that the archive extracts to a directory named like dir
+func writeTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	parent := filepath.Dir(dir)
+
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Links and devices can't be registered as data objects
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(rel)
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
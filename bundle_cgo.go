@@ -0,0 +1,140 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func (col *Collection) cBundle(tarPath string, opts BundleOptions) (*DataObj, error) {
+	var (
+		errMsg *C.char
+		force  C.int
+	)
+
+	resource, err := opts.resource()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Force {
+		force = C.int(1)
+	}
+
+	cTarPath := C.CString(tarPath)
+	cPath := C.CString(col.path)
+	cResource := C.CString(resource)
+	cDataType := C.CString(opts.DataType)
+
+	defer C.free(unsafe.Pointer(cTarPath))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+	defer C.free(unsafe.Pointer(cDataType))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_bundle(ccon, cTarPath, cPath, cResource, cDataType, force, &errMsg); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Bundle Collection Failed: %v into %v, %v", col.path, tarPath, C.GoString(errMsg)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	return getDataObj(tarPath, col.con)
+}
+
+func (col *Collection) cExtract(tarPath string, opts BundleOptions) error {
+	var (
+		errMsg *C.char
+		force  C.int
+		bulk   C.int
+	)
+
+	resource, err := opts.resource()
+	if err != nil {
+		return err
+	}
+
+	if opts.Force {
+		force = C.int(1)
+	}
+
+	if opts.Bulk {
+		bulk = C.int(1)
+	}
+
+	cTarPath := C.CString(tarPath)
+	cPath := C.CString(col.path)
+	cResource := C.CString(resource)
+	cDataType := C.CString(opts.DataType)
+
+	defer C.free(unsafe.Pointer(cTarPath))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+	defer C.free(unsafe.Pointer(cDataType))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_extract_bundle(ccon, cTarPath, cPath, cResource, cDataType, force, bulk, &errMsg); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Extract Bundle Failed: %v into %v, %v", tarPath, col.path, C.GoString(errMsg)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	return col.Refresh()
+}
+
+func (col *Collection) cMount(tarPath string, opts BundleOptions) error {
+	var errMsg *C.char
+
+	resource, err := opts.resource()
+	if err != nil {
+		return err
+	}
+
+	cTarPath := C.CString(tarPath)
+	cPath := C.CString(col.path)
+	cResource := C.CString(resource)
+
+	defer C.free(unsafe.Pointer(cTarPath))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_mount_collection(ccon, cTarPath, cPath, cResource, &errMsg); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Mount Collection Failed: %v on %v, %v", tarPath, col.path, C.GoString(errMsg)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	return col.Refresh()
+}
+
+func (col *Collection) cUnmount() error {
+	var errMsg *C.char
+
+	cPath := C.CString(col.path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_unmount_collection(ccon, cPath, &errMsg); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Unmount Collection Failed: %v, %v", col.path, C.GoString(errMsg)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	return col.Refresh()
+}
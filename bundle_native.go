@@ -0,0 +1,23 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+func (col *Collection) cBundle(tarPath string, opts BundleOptions) (*DataObj, error) {
+	return nil, errNoC
+}
+
+func (col *Collection) cExtract(tarPath string, opts BundleOptions) error {
+	return errNoC
+}
+
+func (col *Collection) cMount(tarPath string, opts BundleOptions) error {
+	return errNoC
+}
+
+func (col *Collection) cUnmount() error {
+	return errNoC
+}
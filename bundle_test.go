@@ -0,0 +1,63 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := filepath.Join(dir, "run42")
+
+	os.MkdirAll(filepath.Join(run, "lane1"), 0755)
+	os.Mkdir(filepath.Join(run, "empty"), 0755)
+	ioutil.WriteFile(filepath.Join(run, "a.fastq"), []byte("ACGT"), 0644)
+	ioutil.WriteFile(filepath.Join(run, "lane1", "b.fastq"), []byte("TTGA"), 0644)
+	os.Symlink("a.fastq", filepath.Join(run, "link"))
+
+	var buf bytes.Buffer
+
+	if err := writeTar(&buf, run); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	contents := make(map[string]string)
+
+	tr := tar.NewReader(&buf)
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+
+		data, _ := ioutil.ReadAll(tr)
+
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = string(data)
+	}
+
+	want := []string{"run42/", "run42/a.fastq", "run42/empty/", "run42/lane1/", "run42/lane1/b.fastq"}
+
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("Unexpected entries %q", names)
+	}
+
+	if contents["run42/lane1/b.fastq"] != "TTGA" {
+		t.Fatalf("Unexpected contents %q", contents["run42/lane1/b.fastq"])
+	}
+}
@@ -0,0 +1,232 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package checksum parses and computes checksums in the formats iRODS registers them, for each
+// of its hash schemes:
+//
+//	md5      d41d8cd98f00b204e9800998ecf8427e (hex, no prefix)
+//	sha2     sha2:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= (base64 SHA-256)
+//	sha1     sha1:2jmj7l5rSw0yVb/vlWAYkK/YBwk=
+//	sha512   sha512:z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg==
+//	adler32  adler32:00000001 (hex)
+//
+// Local files and readers can be checked against the checksum of a data object, whatever the
+// scheme of the zone:
+//
+//	err := checksum.VerifyFile("results.csv", obj.Checksum())
+package checksum
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"io"
+	"os"
+	"strings"
+)
+
+// Algorithm is an iRODS hash scheme
+type Algorithm int
+
+// Hash schemes supported by iRODS
+const (
+	MD5 Algorithm = iota
+	SHA1
+	SHA256
+	SHA512
+	Adler32
+)
+
+// ErrMismatch is returned by Verify and VerifyFile when the data doesn't match the checksum
+var ErrMismatch = errors.New("checksum mismatch")
+
+// String returns the name of the scheme, as set in irods_default_hash_scheme: md5, sha1, sha2,
+// sha512 or adler32
+func (alg Algorithm) String() string {
+	switch alg {
+	case MD5:
+		return "md5"
+	case SHA1:
+		return "sha1"
+	case SHA256:
+		return "sha2"
+	case SHA512:
+		return "sha512"
+	case Adler32:
+		return "adler32"
+	}
+
+	return fmt.Sprintf("Algorithm(%d)", int(alg))
+}
+
+// New returns a hash computing digests of the algorithm
+func (alg Algorithm) New() hash.Hash {
+	switch alg {
+	case SHA1:
+		return sha1.New()
+	case SHA256:
+		return sha256.New()
+	case SHA512:
+		return sha512.New()
+	case Adler32:
+		return adler32.New()
+	}
+
+	return md5.New()
+}
+
+// prefix returns the prefix of the checksums of the algorithm, MD5 checksums have none
+func (alg Algorithm) prefix() string {
+	if alg == MD5 {
+		return ""
+	}
+
+	return alg.String() + ":"
+}
+
+// hex reports whether the digests of the algorithm are hex encoded, rather than base64
+func (alg Algorithm) hex() bool {
+	return alg == MD5 || alg == Adler32
+}
+
+// ParseAlgorithm returns the algorithm named name. The iRODS names (md5, sha1, sha2, sha512,
+// adler32) and the usual aliases (sha256, SHA-256...) are accepted, in any case.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.Replace(strings.ToLower(strings.TrimSpace(name)), "-", "", -1) {
+	case "md5":
+		return MD5, nil
+	case "sha1":
+		return SHA1, nil
+	case "sha2", "sha256":
+		return SHA256, nil
+	case "sha512":
+		return SHA512, nil
+	case "adler32":
+		return Adler32, nil
+	}
+
+	return MD5, fmt.Errorf("unknown checksum algorithm %q", name)
+}
+
+// Checksum is a digest and the algorithm that computed it
+type Checksum struct {
+	Algorithm Algorithm
+	Digest    []byte
+}
+
+// Parse parses a checksum in the format iRODS registers it. MD5 checksums may also have an
+// md5: prefix.
+func Parse(s string) (Checksum, error) {
+	s = strings.TrimSpace(s)
+
+	alg, encoded := MD5, s
+
+	if split := strings.SplitN(s, ":", 2); len(split) == 2 {
+		var err error
+		if alg, err = ParseAlgorithm(split[0]); err != nil {
+			return Checksum{}, fmt.Errorf("invalid checksum %q: %v", s, err)
+		}
+
+		encoded = split[1]
+	}
+
+	var (
+		digest []byte
+		err    error
+	)
+
+	if alg.hex() {
+		digest, err = hex.DecodeString(encoded)
+	} else {
+		digest, err = base64.StdEncoding.DecodeString(encoded)
+	}
+
+	if err != nil || len(digest) != alg.New().Size() {
+		return Checksum{}, fmt.Errorf("invalid %v checksum %q", alg, s)
+	}
+
+	return Checksum{alg, digest}, nil
+}
+
+// String returns the checksum in the format iRODS registers it
+func (sum Checksum) String() string {
+	if sum.Algorithm.hex() {
+		return sum.Algorithm.prefix() + hex.EncodeToString(sum.Digest)
+	}
+
+	return sum.Algorithm.prefix() + base64.StdEncoding.EncodeToString(sum.Digest)
+}
+
+// Equal reports whether both checksums have the same algorithm and digest
+func (sum Checksum) Equal(other Checksum) bool {
+	return sum.Algorithm == other.Algorithm && bytes.Equal(sum.Digest, other.Digest)
+}
+
+// Compute returns the checksum of the data read from r
+func Compute(alg Algorithm, r io.Reader) (Checksum, error) {
+	h := alg.New()
+
+	if _, err := io.Copy(h, r); err != nil {
+		return Checksum{}, err
+	}
+
+	return Checksum{alg, h.Sum(nil)}, nil
+}
+
+// Bytes returns the checksum of data
+func Bytes(alg Algorithm, data []byte) Checksum {
+	h := alg.New()
+	h.Write(data)
+
+	return Checksum{alg, h.Sum(nil)}
+}
+
+// File returns the checksum of the local file name
+func File(alg Algorithm, name string) (Checksum, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return Checksum{}, err
+	}
+	defer file.Close()
+
+	return Compute(alg, file)
+}
+
+// Verify reads r and compares its checksum to expected, computed with the algorithm of expected.
+// It returns ErrMismatch when they differ.
+func Verify(r io.Reader, expected string) error {
+	want, err := Parse(expected)
+	if err != nil {
+		return err
+	}
+
+	got, err := Compute(want.Algorithm, r)
+	if err != nil {
+		return err
+	}
+
+	if !got.Equal(want) {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// VerifyFile compares the checksum of the local file name to expected, see Verify
+func VerifyFile(name string, expected string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return Verify(file, expected)
+}
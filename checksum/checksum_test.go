@@ -0,0 +1,88 @@
+package checksum
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	sums := map[string]Algorithm{
+		"d41d8cd98f00b204e9800998ecf8427e":                                                                MD5,
+		"sha2:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=":                                               SHA256,
+		"sha1:2jmj7l5rSw0yVb/vlWAYkK/YBwk=":                                                               SHA1,
+		"sha512:z4PhNX7vuL3xVChQ1m2AB9Yg5AULVxXcg/SpIdNs6c5H0NE8XYXysP+DGNKHfuwvY7kxvUdBeoGlODJ6+SfaPg==": SHA512,
+		"adler32:00000001": Adler32,
+	}
+
+	for s, alg := range sums {
+		sum, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sum.Algorithm != alg || sum.String() != s {
+			t.Fatalf("Unexpected checksum %v %v for %q", sum.Algorithm, sum, s)
+		}
+
+		// They are the checksums of empty data
+		if empty := Bytes(alg, nil); !empty.Equal(sum) {
+			t.Fatalf("Unexpected %v checksum %v", alg, empty)
+		}
+	}
+
+	if sum, err := Parse("md5:D41D8CD98F00B204E9800998ECF8427E"); err != nil || sum.String() != "d41d8cd98f00b204e9800998ecf8427e" {
+		t.Fatalf("Unexpected md5: checksum %v %v", sum, err)
+	}
+
+	for _, s := range []string{"", "d41d8cd9", "sha2:d41d8cd98f00b204e9800998ecf8427e", "crc32:00000000", "sha1:not base64"} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("Invalid checksum %q was parsed", s)
+		}
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for name, alg := range map[string]Algorithm{"MD5": MD5, "sha2": SHA256, "SHA-256": SHA256, "sha512": SHA512, "adler32": Adler32} {
+		if got, err := ParseAlgorithm(name); err != nil || got != alg {
+			t.Fatalf("Unexpected algorithm %v %v for %q", got, err, name)
+		}
+	}
+
+	if _, err := ParseAlgorithm("crc32"); err == nil {
+		t.Fatal("Unknown algorithm was parsed")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	sum := Bytes(SHA256, []byte("hello world")).String()
+	if sum != "sha2:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=" {
+		t.Fatalf("Unexpected checksum %v", sum)
+	}
+
+	if err := Verify(strings.NewReader("hello world"), sum); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(strings.NewReader("hello World"), sum); err != ErrMismatch {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	file, err := ioutil.TempFile("", "checksum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	file.WriteString("hello world")
+	file.Close()
+
+	if err := VerifyFile(file.Name(), "5eb63bbbe01eeed093cb22bb8f5acdc3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := File(Adler32, file.Name()); err != nil || got.String() != "adler32:1a0b045d" {
+		t.Fatalf("Unexpected checksum %v %v", got, err)
+	}
+}
@@ -3,18 +3,7 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
-import (
-	"fmt"
-	// "io/ioutil"
-	// "path/filepath"
-	// "strconv"
-	// "strings"
-	// "time"
-	// "unsafe"
-)
+import "fmt"
 
 // Client structs are used to store connection options, and instatiate connections with those options
 type Client struct {
@@ -134,7 +123,3 @@ func New(opts ConnectionOptions) (*Client, error) {
 
 	return cli, nil
 }
-
-func (cli *Client) DisplayMemInfo() {
-	C.display_mallinfo()
-}
@@ -0,0 +1,14 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+func (cli *Client) DisplayMemInfo() {
+	C.display_mallinfo()
+}
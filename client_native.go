@@ -0,0 +1,11 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// DisplayMemInfo is a no-op in builds without the C API, there's no C heap to report
+func (cli *Client) DisplayMemInfo() {
+}
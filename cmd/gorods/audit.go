@@ -0,0 +1,143 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/audit"
+	"github.com/jjacquay712/GoRODS/connect"
+)
+
+func init() {
+	commands["audit"] = &command{"audit [-min n] [-workers n] [-pool] [-register] [-report file] [-query q] [path...]", runAudit}
+}
+
+// runAudit checks the replicas of data objects with package audit. Problems are an error so
+// that the command can check a zone in scripts.
+func runAudit(a *app, args []string) error {
+	flags := a.newFlags("audit")
+	min := flags.Int("min", 0, "flag objects with fewer good replicas")
+	workers := flags.Int("workers", 4, "objects audited in parallel")
+	pool := flags.Bool("pool", false, "open a connection per worker, always done by the cgo client")
+	register := flags.Bool("register", false, "compute and register missing checksums")
+	reportFile := flags.String("report", "", "JSON lines report, resumed when incomplete")
+	query := flags.String("query", "", "audit the objects of a query selecting COLL_NAME and DATA_NAME")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 && *query == "" {
+		return usageError("audit")
+	}
+
+	opts := audit.Options{
+		Query:           *query,
+		MinReplicas:     *min,
+		RegisterMissing: *register,
+		Workers:         *workers,
+		ReportFile:      *reportFile,
+	}
+
+	for _, arg := range flags.Args() {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		opts.Paths = append(opts.Paths, p)
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	afs, ok := fsys.(audit.FS)
+	if !ok {
+		return fmt.Errorf("audit: the connection doesn't support replicas")
+	}
+
+	// The calls of the cgo client run one at a time
+	if s, ok := a.fsys.(interface{ Serial() bool }); *pool || ok && s.Serial() {
+		opts.Connect = func() (audit.FS, error) {
+			con, err := connect.Dial(a.opts)
+			if err != nil {
+				return nil, err
+			}
+
+			afs, ok := con.(audit.FS)
+			if !ok {
+				con.Disconnect()
+				return nil, fmt.Errorf("the connection doesn't support replicas")
+			}
+
+			return afs, nil
+		}
+	}
+
+	// Problems are printed as they're found, or with the report with -json
+	var (
+		problems []audit.Result
+		count    int
+	)
+
+	opts.Result = func(res audit.Result) {
+		if res.OK() {
+			return
+		}
+
+		if count++; a.json {
+			problems = append(problems, res)
+		} else {
+			printAuditResult(a.stdout, res)
+		}
+	}
+
+	report, err := audit.Run(afs, opts)
+	if err != nil {
+		return err
+	}
+
+	err = a.print(struct {
+		*audit.Report
+		Problems []audit.Result `json:"problems"`
+	}{report, problems}, func(w io.Writer) {
+		fmt.Fprintln(w, report)
+	})
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%d objects with problems", count)
+	}
+
+	return nil
+}
+
+// printAuditResult prints the problems of an object and its replicas, one per line
+// example: /tempZone/home/rods/file.txt 1 archiveResc: checksum_mismatch
+func printAuditResult(w io.Writer, res audit.Result) {
+	if res.Error != "" {
+		fmt.Fprintf(w, "%v: %v\n", res.Path, res.Error)
+		return
+	}
+
+	if len(res.Problems) > 0 {
+		fmt.Fprintf(w, "%v: %v\n", res.Path, strings.Join(res.Problems, ", "))
+	}
+
+	for _, rr := range res.Replicas {
+		switch {
+		case rr.Error != "":
+			fmt.Fprintf(w, "%v %v %v: %v\n", res.Path, rr.Num, rr.Resource, rr.Error)
+		case len(rr.Problems) > 0:
+			fmt.Fprintf(w, "%v %v %v: %v\n", res.Path, rr.Num, rr.Resource, strings.Join(rr.Problems, ", "))
+		}
+	}
+}
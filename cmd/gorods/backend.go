@@ -0,0 +1,253 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jjacquay712/GoRODS"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func init() {
+	commands["repl"] = &command{"repl [-R resc] path...", runRepl}
+	commands["trim"] = &command{"trim [-N keep] [-S resc] [-age minutes] path...", runTrim}
+	commands["ticket"] = &command{"ticket ticket <command> [args]", runTicket}
+	commands["serve"] = &command{"serve [-addr :8080] [-path collection] [-download]", runServe}
+}
+
+// backend is the connection of gorods, which provides replication, tickets and FileServer. It
+// uses the pure Go client of package native in static builds.
+type backend struct {
+	con *gorods.Connection
+}
+
+// conOpts returns the gorods options of the global flags
+func (a *app) conOpts() gorods.ConnectionOptions {
+	if a.opts.Environment {
+		return gorods.ConnectionOptions{
+			Type:     gorods.EnvironmentDefined,
+			Password: a.opts.Password,
+			Ticket:   a.ticket,
+		}
+	}
+
+	return gorods.ConnectionOptions{
+		Type:     gorods.UserDefined,
+		Host:     a.opts.Host,
+		Port:     a.opts.Port,
+		Zone:     a.opts.Zone,
+		Username: a.opts.Username,
+		Password: a.opts.Password,
+		Ticket:   a.ticket,
+	}
+}
+
+// connection opens the gorods connection on first use
+func (a *app) connection() (*gorods.Connection, error) {
+	if a.con != nil {
+		return a.con, nil
+	}
+
+	opts := a.conOpts()
+
+	con, err := gorods.NewConnection(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	a.con = con
+	a.session(con.Options.Zone, con.Options.Username)
+
+	return con, nil
+}
+
+func (a *app) dial() (irodsfs.Filesystem, error) {
+	con, err := a.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	return con.Filesystem(), nil
+}
+
+func (a *app) disconnect() {
+	if a.con != nil {
+		a.con.Disconnect()
+		a.con = nil
+	}
+}
+
+// eachObj calls fn with the collection or data object of each path argument
+func (a *app) eachObj(args []string, fn func(obj gorods.IRodsObj) error) error {
+	con, err := a.connection()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		typ, err := con.PathType(p)
+		if err != nil {
+			return err
+		}
+
+		var obj gorods.IRodsObj
+
+		if typ == gorods.CollectionType {
+			obj, err = con.Collection(gorods.CollectionOptions{
+				Path:      p,
+				Recursive: false,
+				SkipCache: true,
+			})
+		} else {
+			obj, err = con.DataObject(p)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(obj)
+
+		// The collections skip the cache, they are closed once done
+		if col, ok := obj.(*gorods.Collection); ok {
+			col.Close()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runRepl(a *app, args []string) error {
+	flags := a.newFlags("repl")
+	resc := flags.String("R", "", "destination resource")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 || *resc == "" {
+		return usageError("repl")
+	}
+
+	return a.eachObj(flags.Args(), func(obj gorods.IRodsObj) error {
+		switch obj := obj.(type) {
+		case *gorods.Collection:
+			return obj.Replicate(*resc, gorods.DataObjOptions{})
+		case *gorods.DataObj:
+			return obj.Replicate(*resc, gorods.DataObjOptions{})
+		}
+
+		return fmt.Errorf("can't replicate %v", obj.Path())
+	})
+}
+
+func runTrim(a *app, args []string) error {
+	flags := a.newFlags("trim")
+	keep := flags.Int("N", 2, "number of replicas to keep")
+	resc := flags.String("S", "", "only trim replicas on this resource")
+	age := flags.Int("age", 0, "only trim replicas older than this many minutes")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return usageError("trim")
+	}
+
+	opts := gorods.TrimOptions{
+		NumCopiesKeep:  *keep,
+		MinAgeMins:     *age,
+		TargetResource: *resc,
+	}
+
+	return a.eachObj(flags.Args(), func(obj gorods.IRodsObj) error {
+		switch obj := obj.(type) {
+		case *gorods.Collection:
+			return obj.TrimRepls(opts)
+		case *gorods.DataObj:
+			return obj.TrimRepls(opts)
+		}
+
+		return fmt.Errorf("can't trim %v", obj.Path())
+	})
+}
+
+func runTicket(a *app, args []string) error {
+	if len(args) < 2 || args[1] == "ticket" {
+		return usageError("ticket")
+	}
+
+	// The command runs on a connection of its own, the ticket and the connection of a shell
+	// session are restored after it
+	con, fsys, ticket := a.con, a.fsys, a.ticket
+	a.con, a.fsys, a.ticket = nil, nil, args[0]
+
+	defer func() {
+		a.disconnect()
+		a.con, a.fsys, a.ticket = con, fsys, ticket
+	}()
+
+	return a.run(args[1], args[2:])
+}
+
+func runServe(a *app, args []string) error {
+	flags := a.newFlags("serve")
+	addr := flags.String("addr", ":8080", "listen address")
+	collection := flags.String("path", ".", "collection to serve")
+	download := flags.Bool("download", false, "serve data objects as attachments")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := a.abs(*collection)
+	if err != nil {
+		return err
+	}
+
+	client, err := gorods.New(a.conOpts())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Serving %v on %v", p, *addr)
+
+	srv := &http.Server{
+		Addr: *addr,
+		Handler: gorods.FileServer(gorods.FSOptions{
+			Client:   client,
+			Path:     p,
+			Download: *download,
+		}),
+	}
+
+	// In the shell, ctrl-C stops the server and returns to the prompt
+	if a.interrupt != nil {
+		done := make(chan struct{})
+		defer close(done)
+
+		go func(interrupt chan struct{}) {
+			select {
+			case <-interrupt:
+				srv.Close()
+			case <-done:
+			}
+		}(a.interrupt)
+	}
+
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,713 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// objJSON is the JSON form of an irodsfs.ObjInfo
+type objJSON struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Id         int64     `json:"id,omitempty"`
+	Size       int64     `json:"size"`
+	Checksum   string    `json:"checksum,omitempty"`
+	Owner      string    `json:"owner"`
+	CreateTime time.Time `json:"createTime"`
+	ModifyTime time.Time `json:"modifyTime"`
+}
+
+func newObjJSON(info irodsfs.ObjInfo) objJSON {
+	typ := "dataobject"
+	if info.IsDir() {
+		typ = "collection"
+	}
+
+	return objJSON{
+		Path:       info.Path,
+		Name:       info.Name,
+		Type:       typ,
+		Id:         info.Id,
+		Size:       info.Size,
+		Checksum:   info.Checksum,
+		Owner:      info.Owner,
+		CreateTime: info.CreateTime,
+		ModifyTime: info.ModifyTime,
+	}
+}
+
+// transfer is a copied data object or file, printed by get, put and cp with -json
+type transfer struct {
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+	Size   int64  `json:"size"`
+}
+
+func printTransfers(a *app, transfers []transfer) error {
+	if transfers == nil {
+		transfers = []transfer{}
+	}
+
+	return a.print(transfers, nil)
+}
+
+func runLs(a *app, args []string) error {
+	flags := a.newFlags("ls")
+	long := flags.Bool("l", false, "long format, with owner, size and modification time")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	var objs []objJSON
+
+	for _, arg := range paths {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		info, err := fsys.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		list := []irodsfs.ObjInfo{info}
+
+		if info.IsDir() {
+			if list, err = fsys.List(p); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range list {
+			objs = append(objs, newObjJSON(item))
+		}
+	}
+
+	if objs == nil {
+		objs = []objJSON{}
+	}
+
+	return a.print(objs, func(w io.Writer) {
+		for _, obj := range objs {
+			name := obj.Name
+			if obj.Type == "collection" {
+				name += "/"
+			}
+
+			if *long {
+				fmt.Fprintf(w, "%-24s %12d %v %v\n", obj.Owner, obj.Size, obj.ModifyTime.Format("2006-01-02 15:04"), name)
+			} else {
+				fmt.Fprintln(w, name)
+			}
+		}
+	})
+}
+
+func runStat(a *app, args []string) error {
+	if len(args) != 1 {
+		return usageError("stat")
+	}
+
+	p, err := a.abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	info, err := fsys.Stat(p)
+	if err != nil {
+		return err
+	}
+
+	obj := newObjJSON(info)
+
+	return a.print(obj, func(w io.Writer) {
+		fmt.Fprintf(w, "path:        %v\n", obj.Path)
+		fmt.Fprintf(w, "type:        %v\n", obj.Type)
+		fmt.Fprintf(w, "id:          %v\n", obj.Id)
+		fmt.Fprintf(w, "size:        %v\n", obj.Size)
+		fmt.Fprintf(w, "checksum:    %v\n", obj.Checksum)
+		fmt.Fprintf(w, "owner:       %v\n", obj.Owner)
+		fmt.Fprintf(w, "created:     %v\n", obj.CreateTime)
+		fmt.Fprintf(w, "modified:    %v\n", obj.ModifyTime)
+	})
+}
+
+// createFlag returns the open flags of a transfer destination, which is only overwritten with force
+func createFlag(force bool) int {
+	if force {
+		return os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	return os.O_WRONLY | os.O_CREATE | os.O_EXCL
+}
+
+// download copies the data object src to the local file dest
+func download(fsys irodsfs.FS, src string, dest string, force bool) (int64, error) {
+	in, err := fsys.Open(src, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, createFlag(force), 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+
+	return n, out.Close()
+}
+
+func runGet(a *app, args []string) error {
+	flags := a.newFlags("get")
+	recursive := flags.Bool("r", false, "download collections recursively")
+	force := flags.Bool("f", false, "overwrite existing files")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError("get")
+	}
+
+	src, err := a.abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	dest := flags.Arg(1)
+	if dest == "" {
+		dest = "."
+	}
+
+	if st, err := os.Stat(dest); err == nil && st.IsDir() {
+		dest = filepath.Join(dest, path.Base(src))
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	info, err := fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	var transfers []transfer
+
+	if !info.IsDir() {
+		n, err := download(fsys, src, dest, *force)
+		if err != nil {
+			return err
+		}
+
+		return printTransfers(a, append(transfers, transfer{Source: src, Dest: dest, Size: n}))
+	}
+
+	if !*recursive {
+		return fmt.Errorf("%v is a collection, use -r", src)
+	}
+
+	err = irodsfs.Walk(fsys, src, func(p string, info irodsfs.ObjInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		local := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(p, src)))
+
+		if info.IsDir() {
+			return os.MkdirAll(local, 0755)
+		}
+
+		n, err := download(fsys, p, local, *force)
+		if err != nil {
+			return err
+		}
+
+		transfers = append(transfers, transfer{Source: p, Dest: local, Size: n})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTransfers(a, transfers)
+}
+
+// upload copies the local file src to the data object dest
+func upload(fsys irodsfs.FS, src string, dest string, force bool) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := fsys.Open(dest, createFlag(force))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+
+	return n, out.Close()
+}
+
+// destPath returns dest, or dest/base(src) when dest is an existing collection
+func destPath(fsys irodsfs.FS, src string, dest string) string {
+	if info, err := fsys.Stat(dest); err == nil && info.IsDir() {
+		return path.Join(dest, path.Base(src))
+	}
+
+	return dest
+}
+
+func runPut(a *app, args []string) error {
+	flags := a.newFlags("put")
+	recursive := flags.Bool("r", false, "upload directories recursively")
+	force := flags.Bool("f", false, "overwrite existing data objects")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError("put")
+	}
+
+	src := flags.Arg(0)
+
+	destArg := flags.Arg(1)
+	if destArg == "" {
+		destArg = "."
+	}
+
+	dest, err := a.abs(destArg)
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	dest = destPath(fsys, filepath.ToSlash(filepath.Clean(src)), dest)
+
+	st, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	var transfers []transfer
+
+	if !st.IsDir() {
+		n, err := upload(fsys, src, dest, *force)
+		if err != nil {
+			return err
+		}
+
+		return printTransfers(a, append(transfers, transfer{Source: src, Dest: dest, Size: n}))
+	}
+
+	if !*recursive {
+		return fmt.Errorf("%v is a directory, use -r", src)
+	}
+
+	err = filepath.Walk(src, func(local string, st os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, local)
+		if err != nil {
+			return err
+		}
+
+		p := path.Join(dest, filepath.ToSlash(rel))
+
+		if st.IsDir() {
+			return fsys.Mkdir(p, true)
+		}
+
+		if !st.Mode().IsRegular() {
+			return nil
+		}
+
+		n, err := upload(fsys, local, p, *force)
+		if err != nil {
+			return err
+		}
+
+		transfers = append(transfers, transfer{Source: local, Dest: p, Size: n})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTransfers(a, transfers)
+}
+
+// copyObj copies the data object src to dest, which is only overwritten with force
+func copyObj(fsys irodsfs.FS, src string, dest string, force bool) (int64, error) {
+	if !force {
+		if _, err := fsys.Stat(dest); err == nil {
+			return 0, fmt.Errorf("%v exists, use -f to overwrite it", dest)
+		}
+	}
+
+	return irodsfs.Copy(fsys, dest, fsys, src)
+}
+
+func runCp(a *app, args []string) error {
+	flags := a.newFlags("cp")
+	recursive := flags.Bool("r", false, "copy collections recursively")
+	force := flags.Bool("f", false, "overwrite existing data objects")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 2 {
+		return usageError("cp")
+	}
+
+	src, err := a.abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	dest, err := a.abs(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	dest = destPath(fsys, src, dest)
+
+	info, err := fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	var transfers []transfer
+
+	if !info.IsDir() {
+		n, err := copyObj(fsys, src, dest, *force)
+		if err != nil {
+			return err
+		}
+
+		return printTransfers(a, append(transfers, transfer{Source: src, Dest: dest, Size: n}))
+	}
+
+	if !*recursive {
+		return fmt.Errorf("%v is a collection, use -r", src)
+	}
+
+	if dest == src || strings.HasPrefix(dest, src+"/") {
+		return fmt.Errorf("can't copy %v into itself", src)
+	}
+
+	err = irodsfs.Walk(fsys, src, func(p string, info irodsfs.ObjInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := dest + strings.TrimPrefix(p, src)
+
+		if info.IsDir() {
+			return fsys.Mkdir(target, true)
+		}
+
+		n, err := copyObj(fsys, p, target, *force)
+		if err != nil {
+			return err
+		}
+
+		transfers = append(transfers, transfer{Source: p, Dest: target, Size: n})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printTransfers(a, transfers)
+}
+
+func runMv(a *app, args []string) error {
+	if len(args) != 2 {
+		return usageError("mv")
+	}
+
+	src, err := a.abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	dest, err := a.abs(args[1])
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	return fsys.Rename(src, destPath(fsys, src, dest))
+}
+
+func runRm(a *app, args []string) error {
+	flags := a.newFlags("rm")
+	recursive := flags.Bool("r", false, "remove collections and their content")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return usageError("rm")
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range flags.Args() {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		if err := fsys.Remove(p, *recursive); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runMkdir(a *app, args []string) error {
+	flags := a.newFlags("mkdir")
+	parents := flags.Bool("p", false, "create missing parents, no error if the collection exists")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return usageError("mkdir")
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range flags.Args() {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		if err := fsys.Mkdir(p, *parents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// avuJSON is the JSON form of an irodsfs.AVU
+type avuJSON struct {
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+	Units     string `json:"units"`
+}
+
+func runMeta(a *app, args []string) error {
+	if len(args) < 2 {
+		return usageError("meta")
+	}
+
+	p, err := a.abs(args[1])
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	switch sub := args[0]; {
+	case sub == "add" && (len(args) == 4 || len(args) == 5):
+		avu := irodsfs.AVU{Attribute: args[2], Value: args[3]}
+		if len(args) == 5 {
+			avu.Units = args[4]
+		}
+
+		return fsys.AddMeta(p, avu)
+
+	case sub == "ls" && len(args) == 2:
+		meta, err := fsys.Meta(p)
+		if err != nil {
+			return err
+		}
+
+		avus := make([]avuJSON, len(meta))
+		for inx, avu := range meta {
+			avus[inx] = avuJSON(avu)
+		}
+
+		return a.print(avus, func(w io.Writer) {
+			for _, avu := range avus {
+				fmt.Fprintf(w, "attribute: %v\nvalue: %v\nunits: %v\n----\n", avu.Attribute, avu.Value, avu.Units)
+			}
+		})
+
+	case sub == "rm" && len(args) == 3:
+		return fsys.DeleteMeta(p, args[2])
+	}
+
+	return usageError("meta")
+}
+
+func runChmod(a *app, args []string) error {
+	flags := a.newFlags("chmod")
+	recursive := flags.Bool("r", false, "apply to the content of collections")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	level := flags.Arg(0)
+	inherit := level == "inherit" || level == "noinherit"
+
+	if (inherit && flags.NArg() < 2) || (!inherit && flags.NArg() < 3) {
+		return usageError("chmod")
+	}
+
+	accessLevel, err := irodsfs.ParseAccess(level)
+	if err != nil && !inherit {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	paths := flags.Args()[1:]
+	if !inherit {
+		paths = flags.Args()[2:]
+	}
+
+	for _, arg := range paths {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		if inherit {
+			err = fsys.SetInheritance(p, level == "inherit", *recursive)
+		} else {
+			err = fsys.Chmod(p, flags.Arg(1), accessLevel, *recursive)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runQuery(a *app, args []string) error {
+	flags := a.newFlags("query")
+	upper := flags.Bool("z", false, "compare the where clause case insensitively")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return usageError("query")
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	rows, err := fsys.IQuest(flags.Arg(0), *upper)
+	if err != nil {
+		return err
+	}
+
+	return a.print(rows, func(w io.Writer) {
+		for _, row := range rows {
+			cols := make([]string, 0, len(row))
+			for col := range row {
+				cols = append(cols, col)
+			}
+
+			sort.Strings(cols)
+
+			for _, col := range cols {
+				fmt.Fprintf(w, "%v = %v\n", col, row[col])
+			}
+
+			fmt.Fprintln(w, "----")
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func testApp(t *testing.T) (*app, *bytes.Buffer) {
+	srv := gorodstest.NewServer("tempZone")
+
+	con, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	return &app{
+		cwd:    "/tempZone/home/rods",
+		stdout: &out,
+		stderr: ioutil.Discard,
+		fsys:   con,
+	}, &out
+}
+
+// environmentApp returns a testApp in environment mode, without -cwd, -zone and -user, reading
+// the irods_environment.json env. The returned func restores $IRODS_ENVIRONMENT_FILE.
+func environmentApp(t *testing.T, env string) (*app, *bytes.Buffer, func()) {
+	a, out := testApp(t)
+
+	a.cwd = ""
+	a.opts.Environment = true
+
+	file, err := ioutil.TempFile("", "irods_environment")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.WriteString(env)
+	file.Close()
+
+	old, set := os.LookupEnv("IRODS_ENVIRONMENT_FILE")
+	os.Setenv("IRODS_ENVIRONMENT_FILE", file.Name())
+
+	return a, out, func() {
+		os.Remove(file.Name())
+
+		if set {
+			os.Setenv("IRODS_ENVIRONMENT_FILE", old)
+		} else {
+			os.Unsetenv("IRODS_ENVIRONMENT_FILE")
+		}
+	}
+}
+
+// runJSON runs a command with -json and decodes its output into v
+func runJSON(t *testing.T, a *app, out *bytes.Buffer, v interface{}, name string, args ...string) {
+	a.json = true
+	out.Reset()
+
+	if err := a.run(name, args); err != nil {
+		t.Fatalf("%v %v: %v", name, args, err)
+	}
+
+	if err := json.Unmarshal(out.Bytes(), v); err != nil {
+		t.Fatalf("%v %v: %v in %s", name, args, err, out.Bytes())
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	a, out := testApp(t)
+
+	local, err := ioutil.TempDir("", "gorods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(local)
+
+	os.MkdirAll(filepath.Join(local, "in", "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(local, "in", "a.txt"), []byte("a"), 0644)
+	ioutil.WriteFile(filepath.Join(local, "in", "sub", "b.txt"), []byte("bb"), 0644)
+
+	if err := a.run("put", []string{filepath.Join(local, "in")}); err == nil {
+		t.Fatal("put of a directory without -r succeeded")
+	}
+
+	var transfers []transfer
+	runJSON(t, a, out, &transfers, "put", "-r", filepath.Join(local, "in"))
+
+	if len(transfers) != 2 || transfers[1].Dest != "/tempZone/home/rods/in/sub/b.txt" || transfers[1].Size != 2 {
+		t.Fatalf("Unexpected transfers %+v", transfers)
+	}
+
+	if err := a.run("put", []string{filepath.Join(local, "in", "a.txt"), "in"}); err == nil {
+		t.Fatal("put overwrote without -f")
+	}
+
+	var objs []objJSON
+	runJSON(t, a, out, &objs, "ls", "in")
+
+	if len(objs) != 2 || objs[0].Name != "sub" || objs[0].Type != "collection" || objs[1].Size != 1 {
+		t.Fatalf("Unexpected listing %+v", objs)
+	}
+
+	runJSON(t, a, out, &transfers, "get", "-r", "in", filepath.Join(local, "out"))
+
+	data, err := ioutil.ReadFile(filepath.Join(local, "out", "sub", "b.txt"))
+	if err != nil || string(data) != "bb" {
+		t.Fatalf("Downloaded %q: %v", data, err)
+	}
+}
+
+func TestCopyMoveRemove(t *testing.T) {
+	a, out := testApp(t)
+
+	a.run("mkdir", []string{"-p", "src/deep"})
+	a.fsys.AddMeta("/tempZone/home/rods/src", irodsfs.AVU{Attribute: "kind", Value: "source"})
+
+	f, _ := a.fsys.Open("/tempZone/home/rods/src/deep/x.txt", os.O_WRONLY|os.O_CREATE)
+	f.Write([]byte("x"))
+	f.Close()
+
+	if err := a.run("cp", []string{"-r", "src", "copy"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.run("mv", []string{"copy", "src"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var obj objJSON
+	runJSON(t, a, out, &obj, "stat", "src/copy/deep/x.txt")
+
+	if obj.Type != "dataobject" || obj.Size != 1 || obj.Owner != "rods#tempZone" {
+		t.Fatalf("Unexpected stat %+v", obj)
+	}
+
+	if err := a.run("rm", []string{"src"}); err == nil {
+		t.Fatal("rm of a non-empty collection without -r succeeded")
+	}
+
+	if err := a.run("rm", []string{"-r", "src"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var objs []objJSON
+	runJSON(t, a, out, &objs, "ls")
+
+	if len(objs) != 0 {
+		t.Fatalf("Unexpected listing %+v", objs)
+	}
+}
+
+func TestMetaChmodQuery(t *testing.T) {
+	a, out := testApp(t)
+
+	a.fsys.Mkdir("/tempZone/home/rods/data", false)
+
+	if err := a.run("meta", []string{"add", "data", "project", "apollo", "mission"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var avus []avuJSON
+	runJSON(t, a, out, &avus, "meta", "ls", "data")
+
+	if len(avus) != 1 || avus[0].Units != "mission" {
+		t.Fatalf("Unexpected AVUs %+v", avus)
+	}
+
+	if err := a.run("meta", []string{"rm", "data", "project"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.run("chmod", []string{"read", "public", "data"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.run("chmod", []string{"inherit", "data"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if inherit, _ := a.fsys.Inheritance("/tempZone/home/rods/data"); !inherit {
+		t.Fatal("Inheritance wasn't enabled")
+	}
+
+	if err := a.run("chmod", []string{"admin", "public", "data"}); err == nil {
+		t.Fatal("Unknown access level accepted")
+	}
+
+	var rows []map[string]string
+	runJSON(t, a, out, &rows, "query", "select COLL_NAME where COLL_NAME like '%/data'")
+
+	if len(rows) != 1 || rows[0]["COLL_NAME"] != "/tempZone/home/rods/data" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	a.json = false
+	out.Reset()
+	a.run("query", []string{"select COLL_NAME where COLL_NAME like '%/data'"})
+
+	if !strings.Contains(out.String(), "COLL_NAME = /tempZone/home/rods/data\n----\n") {
+		t.Fatalf("Unexpected output %q", out.String())
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	a, out := testApp(t)
+
+	a.fsys.Mkdir("/tempZone/home/rods/proj/raw", true)
+
+	file, err := ioutil.TempFile("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"rules": [{"path": "/tempZone/home/rods/proj", "recursive": true, "grants": [{"principal": "public", "access": "read"}]}]}`)
+	file.Close()
+
+	if err := a.run("policy", []string{file.Name()}); err == nil || !strings.Contains(out.String(), "-r read public#tempZone /tempZone/home/rods/proj") {
+		t.Fatalf("Drift not reported: %v %q", err, out.String())
+	}
+
+	if err := a.run("policy", []string{"-apply", file.Name()}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+
+	if err := a.run("policy", []string{file.Name()}); err != nil || !strings.Contains(out.String(), "2 objects checked, 0 drifting") {
+		t.Fatalf("Drift after apply: %v %q", err, out.String())
+	}
+}
+
+func TestAudit(t *testing.T) {
+	a, out := testApp(t)
+
+	a.fsys.Mkdir("/tempZone/home/rods/data", true)
+	a.fsys.(*gorodstest.Connection).Put("/tempZone/home/rods/data/ok.txt", []byte("fine"), gorodstest.PutOptions{Checksum: true})
+	a.fsys.(*gorodstest.Connection).Put("/tempZone/home/rods/data/nosum.txt", []byte("lonely"), gorodstest.PutOptions{})
+
+	if err := a.run("audit", []string{"data"}); err == nil || !strings.Contains(out.String(), "/tempZone/home/rods/data/nosum.txt 0 demoResc: missing_checksum\n") {
+		t.Fatalf("Problems not reported: %v %q", err, out.String())
+	}
+
+	out.Reset()
+
+	// The missing checksum is registered, and still reported
+	if err := a.run("audit", []string{"-register", "data"}); err == nil || !strings.HasSuffix(out.String(), "2 objects, 2 replicas: 1 ok, 0 failed, missing_checksum 1\n") {
+		t.Fatalf("Unexpected audit: %v %q", err, out.String())
+	}
+
+	var report struct {
+		Complete bool
+		Summary  struct{ OK int }
+	}
+
+	runJSON(t, a, out, &report, "audit", "data")
+
+	if !report.Complete || report.Summary.OK != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	a, out := testApp(t)
+
+	con := a.fsys.(*gorodstest.Connection)
+	con.Server().CreateResource("archiveResc")
+	con.Mkdir("/tempZone/home/rods/proj", true)
+	con.Put("/tempZone/home/rods/proj/data.bin", []byte("data"), gorodstest.PutOptions{})
+
+	file, err := ioutil.TempFile("", "placement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"rules": [{"path": "/tempZone/home/rods/proj", "resources": ["demoResc", "archiveResc"]}]}`)
+	file.Close()
+
+	if err := a.run("placement", []string{file.Name()}); err == nil || !strings.Contains(out.String(), "irepl -R archiveResc /tempZone/home/rods/proj/data.bin\n") {
+		t.Fatalf("Operations not reported: %v %q", err, out.String())
+	}
+
+	if err := a.run("placement", []string{"-apply", file.Name()}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+
+	if err := a.run("placement", []string{file.Name()}); err != nil || out.String() != "1 objects scanned, 1 compliant, 0 operations, 0 failed\n" {
+		t.Fatalf("Operations after apply: %v %q", err, out.String())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	a, out := testApp(t)
+
+	dir, err := ioutil.TempDir("", "register")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "run1"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "run1", "a.fastq"), []byte("ACGT"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "run1", "a.fastq.tmp"), []byte("AC"), 0644)
+
+	if err := a.run("register", []string{"-K", "sha2", "-exclude", "*.tmp", filepath.Join(dir, "run1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(out.String(), "1 files: 1 registered\n") {
+		t.Fatalf("Unexpected output %q", out.String())
+	}
+
+	out.Reset()
+
+	if err := a.run("register", []string{"-exclude", "*.tmp", filepath.Join(dir, "run1")}); err != nil || out.String() != "already_registered "+filepath.Join(dir, "run1", "a.fastq")+" /tempZone/home/rods/run1/a.fastq\n1 files: 1 already_registered\n" {
+		t.Fatalf("Unexpected output %v %q", err, out.String())
+	}
+
+	var report struct{ Counts map[string]int }
+	runJSON(t, a, out, &report, "unregister", "run1")
+
+	if report.Counts["unregistered"] != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "run1", "a.fastq")); err != nil {
+		t.Fatalf("File was deleted: %v", err)
+	}
+}
+
+func TestRelativeEnvironment(t *testing.T) {
+	a, out, cleanup := environmentApp(t, `{"irods_zone_name": "tempZone", "irods_user_name": "rods"}`)
+	defer cleanup()
+
+	a.fsys.Mkdir("/tempZone/home/rods/raw", false)
+
+	// ls defaults to ".", the home collection of the user
+	if err := a.run("ls", nil); err != nil || out.String() != "raw/\n" {
+		t.Fatalf("Unexpected listing %v %q", err, out.String())
+	}
+
+	a, out, cleanup = environmentApp(t, `{"irods_zone_name": "tempZone", "irods_user_name": "rods", "irods_cwd": "/tempZone/home/rods/raw"}`)
+	defer cleanup()
+
+	a.fsys.Mkdir("/tempZone/home/rods/raw", false)
+
+	if err := a.run("mkdir", []string{"lane1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := a.fsys.Stat("/tempZone/home/rods/raw/lane1"); err != nil || !info.IsDir() {
+		t.Fatalf("Relative path wasn't resolved against irods_cwd: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// shellBuiltins are completed with the commands
+var shellBuiltins = []string{"cd", "pwd", "history", "help", "exit"}
+
+// complete returns the completions of the last word of line: command names for the first
+// word of a command, AVU attributes after "meta rm path" and "meta add path", and paths
+// otherwise. Completions are whole words, collections end with /.
+func (sh *shell) complete(line string) []string {
+	stages, err := splitPipeline(line)
+	if err != nil || len(stages) == 0 {
+		return nil
+	}
+
+	words := stages[len(stages)-1]
+
+	// A trailing space starts a new, empty word
+	if strings.HasSuffix(line, " ") || len(words) == 0 {
+		words = append(words, "")
+	}
+
+	prefix := words[len(words)-1]
+
+	if len(words) == 1 {
+		return sh.completeCommand(prefix)
+	}
+
+	if words[0] == "meta" && len(words) == 4 && (words[1] == "rm" || words[1] == "add") {
+		return sh.completeAttribute(words[2], prefix)
+	}
+
+	if strings.HasPrefix(prefix, "-") {
+		return nil
+	}
+
+	return sh.completePath(prefix, words[0] == "cd")
+}
+
+func (sh *shell) completeCommand(prefix string) []string {
+	var matches []string
+
+	for name := range commands {
+		if strings.HasPrefix(name, prefix) && name != "shell" {
+			matches = append(matches, name)
+		}
+	}
+
+	for _, name := range shellBuiltins {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+func (sh *shell) completeAttribute(p string, prefix string) []string {
+	abs, err := sh.a.abs(p)
+	if err != nil {
+		return nil
+	}
+
+	fsys, err := sh.a.fs()
+	if err != nil {
+		return nil
+	}
+
+	meta, err := fsys.Meta(abs)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+
+	var matches []string
+
+	for _, avu := range meta {
+		if strings.HasPrefix(avu.Attribute, prefix) && !seen[avu.Attribute] {
+			seen[avu.Attribute] = true
+			matches = append(matches, avu.Attribute)
+		}
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+// completePath completes prefix against the listing of its collection, keeping it relative
+// when it is. dirsOnly restricts the completions to collections.
+func (sh *shell) completePath(prefix string, dirsOnly bool) []string {
+	dir, base := path.Split(prefix)
+
+	absDir, err := sh.a.abs(dir + ".")
+	if err != nil {
+		return nil
+	}
+
+	fsys, err := sh.a.fs()
+	if err != nil {
+		return nil
+	}
+
+	list, err := fsys.List(absDir)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+
+	for _, info := range list {
+		if !strings.HasPrefix(info.Name, base) || (dirsOnly && !info.IsDir()) {
+			continue
+		}
+
+		match := dir + info.Name
+		if info.IsDir() {
+			match += "/"
+		}
+
+		matches = append(matches, match)
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+// commonPrefix returns the longest prefix shared by the words
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+
+	prefix := words[0]
+
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,290 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"errors"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// errInterrupted is returned by the iRODS calls of a shell command after ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// interrupted reports whether ctrl-C was pressed while the current shell command runs
+func (a *app) interrupted() bool {
+	select {
+	case <-a.interrupt:
+		return true
+	default:
+		return false
+	}
+}
+
+// interruptible wraps the connection of a shell command so its calls fail with errInterrupted
+// after ctrl-C: the command stops at its next call to iRODS. The replica and registration calls
+// are kept when the connection has them.
+func interruptible(fsys irodsfs.Filesystem, a *app) irodsfs.Filesystem {
+	ifs := &interruptFS{Filesystem: fsys, a: a}
+
+	rfs, ok := fsys.(irodsfs.ReplicaFS)
+	if !ok {
+		return ifs
+	}
+
+	irfs := &interruptReplicaFS{interruptFS: ifs, replicas: rfs}
+
+	if reg, ok := fsys.(irodsfs.RegisterFS); ok {
+		return &interruptRegisterFS{interruptReplicaFS: irfs, reg: reg}
+	}
+
+	return irfs
+}
+
+type interruptFS struct {
+	irodsfs.Filesystem
+	a *app
+}
+
+func (fsys *interruptFS) check() error {
+	if fsys.a.interrupted() {
+		return errInterrupted
+	}
+
+	return nil
+}
+
+func (fsys *interruptFS) Stat(p string) (irodsfs.ObjInfo, error) {
+	if err := fsys.check(); err != nil {
+		return irodsfs.ObjInfo{}, err
+	}
+
+	return fsys.Filesystem.Stat(p)
+}
+
+func (fsys *interruptFS) List(p string) ([]irodsfs.ObjInfo, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.Filesystem.List(p)
+}
+
+func (fsys *interruptFS) Open(p string, flag int) (irodsfs.File, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.Filesystem.Open(p, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &interruptFile{File: f, fsys: fsys}, nil
+}
+
+func (fsys *interruptFS) Mkdir(p string, recursive bool) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.Mkdir(p, recursive)
+}
+
+func (fsys *interruptFS) Remove(p string, recursive bool) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.Remove(p, recursive)
+}
+
+func (fsys *interruptFS) Rename(src string, dest string) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.Rename(src, dest)
+}
+
+func (fsys *interruptFS) Meta(p string) ([]irodsfs.AVU, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.Filesystem.Meta(p)
+}
+
+func (fsys *interruptFS) AddMeta(p string, avu irodsfs.AVU) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.AddMeta(p, avu)
+}
+
+func (fsys *interruptFS) DeleteMeta(p string, attr string) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.DeleteMeta(p, attr)
+}
+
+func (fsys *interruptFS) ACL(p string) ([]irodsfs.ACL, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.Filesystem.ACL(p)
+}
+
+func (fsys *interruptFS) Chmod(p string, principal string, accessLevel int, recursive bool) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.Chmod(p, principal, accessLevel, recursive)
+}
+
+func (fsys *interruptFS) Inheritance(p string) (bool, error) {
+	if err := fsys.check(); err != nil {
+		return false, err
+	}
+
+	return fsys.Filesystem.Inheritance(p)
+}
+
+func (fsys *interruptFS) SetInheritance(p string, inherit bool, recursive bool) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.Filesystem.SetInheritance(p, inherit, recursive)
+}
+
+func (fsys *interruptFS) QueryMeta(qString string) ([]irodsfs.ObjInfo, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.Filesystem.QueryMeta(qString)
+}
+
+func (fsys *interruptFS) IQuest(query string, upperCase bool) ([]map[string]string, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.Filesystem.IQuest(query, upperCase)
+}
+
+// interruptFile stops the transfers of get, put and cp between two reads or writes
+type interruptFile struct {
+	irodsfs.File
+	fsys *interruptFS
+}
+
+func (f *interruptFile) Read(p []byte) (int, error) {
+	if err := f.fsys.check(); err != nil {
+		return 0, err
+	}
+
+	return f.File.Read(p)
+}
+
+func (f *interruptFile) Write(p []byte) (int, error) {
+	if err := f.fsys.check(); err != nil {
+		return 0, err
+	}
+
+	return f.File.Write(p)
+}
+
+type interruptReplicaFS struct {
+	*interruptFS
+	replicas irodsfs.ReplicaFS
+}
+
+func (fsys *interruptReplicaFS) Replicas(p string) ([]irodsfs.Replica, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	return fsys.replicas.Replicas(p)
+}
+
+func (fsys *interruptReplicaFS) OpenReplica(p string, replNum int, flag int) (irodsfs.File, error) {
+	if err := fsys.check(); err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.replicas.OpenReplica(p, replNum, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &interruptFile{File: f, fsys: fsys.interruptFS}, nil
+}
+
+func (fsys *interruptReplicaFS) ChecksumReplica(p string, replNum int) (string, error) {
+	if err := fsys.check(); err != nil {
+		return "", err
+	}
+
+	return fsys.replicas.ChecksumReplica(p, replNum)
+}
+
+func (fsys *interruptReplicaFS) Replicate(p string, resource string) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.replicas.Replicate(p, resource)
+}
+
+func (fsys *interruptReplicaFS) Trim(p string, replNum int) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.replicas.Trim(p, replNum)
+}
+
+func (fsys *interruptReplicaFS) PhysicalMove(p string, src string, dest string) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.replicas.PhysicalMove(p, src, dest)
+}
+
+func (fsys *interruptReplicaFS) VerifyReplica(p string, replNum int) (irodsfs.Fixity, error) {
+	if err := fsys.check(); err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	return fsys.replicas.VerifyReplica(p, replNum)
+}
+
+type interruptRegisterFS struct {
+	*interruptReplicaFS
+	reg irodsfs.RegisterFS
+}
+
+func (fsys *interruptRegisterFS) Register(physPath string, p string, opts irodsfs.RegisterOptions) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.reg.Register(physPath, p, opts)
+}
+
+func (fsys *interruptRegisterFS) Unregister(p string, replNum int) error {
+	if err := fsys.check(); err != nil {
+		return err
+	}
+
+	return fsys.reg.Unregister(p, replNum)
+}
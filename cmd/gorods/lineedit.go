@@ -0,0 +1,185 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// editor reads lines from a terminal. In raw mode it edits the line in place, with tab
+// completion and history, otherwise it reads plain lines.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	complete func(line string) []string
+	history  []string
+	raw      bool
+
+	line    []rune
+	pos     int
+	lastTab bool
+}
+
+func newEditor(in io.Reader, out io.Writer, complete func(line string) []string) *editor {
+	return &editor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		complete: complete,
+	}
+}
+
+// readLine prints the prompt and returns the next line, or io.EOF at the end of the input
+func (ed *editor) readLine(prompt string) (string, error) {
+	fmt.Fprint(ed.out, prompt)
+
+	if !ed.raw {
+		line, err := ed.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	ed.line, ed.pos, ed.lastTab = nil, 0, false
+	hist := len(ed.history)
+
+	for {
+		r, _, err := ed.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		tab := false
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(ed.out, "\r\n")
+			return string(ed.line), nil
+
+		case 3: // ctrl-C
+			fmt.Fprint(ed.out, "^C\r\n")
+			ed.line, ed.pos = nil, 0
+			fmt.Fprint(ed.out, prompt)
+			continue
+
+		case 4: // ctrl-D
+			if len(ed.line) == 0 {
+				return "", io.EOF
+			}
+
+		case 1: // ctrl-A
+			ed.pos = 0
+
+		case 5: // ctrl-E
+			ed.pos = len(ed.line)
+
+		case 127, 8: // backspace
+			if ed.pos > 0 {
+				ed.line = append(ed.line[:ed.pos-1], ed.line[ed.pos:]...)
+				ed.pos--
+			}
+
+		case '\t':
+			tab = true
+			ed.tab(prompt)
+
+		case 27: // escape sequence of the arrows
+			seq := make([]rune, 2)
+			for inx := range seq {
+				if seq[inx], _, err = ed.in.ReadRune(); err != nil {
+					return "", err
+				}
+			}
+
+			if seq[0] != '[' {
+				break
+			}
+
+			switch seq[1] {
+			case 'A':
+				if hist > 0 {
+					hist--
+					ed.setLine(ed.history[hist])
+				}
+			case 'B':
+				if hist < len(ed.history)-1 {
+					hist++
+					ed.setLine(ed.history[hist])
+				} else {
+					hist = len(ed.history)
+					ed.setLine("")
+				}
+			case 'C':
+				if ed.pos < len(ed.line) {
+					ed.pos++
+				}
+			case 'D':
+				if ed.pos > 0 {
+					ed.pos--
+				}
+			}
+
+		default:
+			if r >= ' ' {
+				ed.line = append(ed.line[:ed.pos], append([]rune{r}, ed.line[ed.pos:]...)...)
+				ed.pos++
+			}
+		}
+
+		ed.lastTab = tab
+		ed.redraw(prompt)
+	}
+}
+
+func (ed *editor) setLine(line string) {
+	ed.line = []rune(line)
+	ed.pos = len(ed.line)
+}
+
+// tab completes the word before the cursor to the longest common prefix of the completions,
+// a second tab lists them
+func (ed *editor) tab(prompt string) {
+	if ed.complete == nil {
+		return
+	}
+
+	before := string(ed.line[:ed.pos])
+	candidates := ed.complete(before)
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	word := before[strings.LastIndexAny(before, " |")+1:]
+	prefix := commonPrefix(candidates)
+
+	if len(candidates) == 1 && !strings.HasSuffix(prefix, "/") {
+		prefix += " "
+	}
+
+	if strings.HasPrefix(prefix, word) && len(prefix) > len(word) {
+		insert := []rune(prefix[len(word):])
+		ed.line = append(ed.line[:ed.pos], append(insert, ed.line[ed.pos:]...)...)
+		ed.pos += len(insert)
+
+		return
+	}
+
+	if ed.lastTab {
+		fmt.Fprintf(ed.out, "\r\n%v\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// redraw prints the line and moves the cursor to its position
+func (ed *editor) redraw(prompt string) {
+	fmt.Fprintf(ed.out, "\r%v%v\x1b[K", prompt, string(ed.line))
+
+	if back := len(ed.line) - ed.pos; back > 0 {
+		fmt.Fprintf(ed.out, "\x1b[%dD", back)
+	}
+}
@@ -0,0 +1,291 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Command gorods is a command-line iRODS client built on the GoRODS library, for the ad-hoc
+// work usually done with icommands:
+//
+//	gorods [global flags] <command> [flags] [args]
+//
+//	ls [-l] [path...]                      list collections (ils)
+//	find [-name pattern] [path]            list collections and data objects recursively (ifind)
+//	stat path                              describe a collection or data object
+//	get [-r] [-f] src [local]              download (iget)
+//	put [-r] [-f] local [dest]             upload (iput)
+//	cp [-r] [-f] src dest                  copy (icp)
+//	mv src dest                            move or rename (imv)
+//	rm [-r] path...                        remove without the trash (irm -f)
+//	mkdir [-p] path...                     create collections (imkdir)
+//	meta add path attr value [units]       attach an AVU (imeta add)
+//	meta ls path                           list AVUs (imeta ls)
+//	meta rm path attr                      remove the AVUs named attr
+//	chmod [-r] level principal path...     set permissions: null, read, write, own, the iRODS 4.3 levels (read_metadata...), inherit, noinherit (ichmod)
+//	query [-z] "select ..."                run a GenQuery (iquest)
+//	policy [-apply] [-pool] policy.json     report or fix the drift from an ACL policy (package aclpolicy)
+//	audit [-min n] [-pool] [-report file] [-query q] [path...]  check replica fixity (package audit)
+//	placement [-apply] policy.json         replicate, move or trim replicas as a policy wants (package replpolicy)
+//	register [-R resc] [-repl] [-K scheme] local [dest]  register files in place (ireg, package register)
+//	unregister path...                     unregister without deleting the files (iunreg)
+//	repl [-R resc] path...                 replicate (irepl)
+//	trim [-N keep] [-S resc] [-age min] path...  trim replicas (itrim)
+//	ticket ticket <command> [args]         run a command with a ticket (-t of icommands)
+//	serve [-addr :8080] [-path coll]       serve collections over HTTP with gorods.FileServer
+//	shell                                  interactive session, see below
+//
+// The connection is read from ~/.irods/irods_environment.json (gorods.EnvironmentDefined)
+// unless -host is given, and the password from $IRODS_PASSWORD or -password. Relative paths
+// are resolved against -cwd, $IRODS_CWD or the home collection. With -json, results are
+// printed as JSON.
+//
+// The shell command reuses one connection for a session with cd, pwd and history builtins,
+// tab completion of paths and AVU attributes, and history saved in ~/.irods/.gorods_history.
+// Arguments with *, ? or [ are globbed against collection listings, and the paths printed by
+// ls, find, stat or query are piped into meta, chmod, rm, repl or trim:
+//
+//	ls *.csv | meta add project apollo
+//
+// Building with -tags gorods_native or CGO_ENABLED=0 produces a static binary using the pure
+// Go client of package native, without register and unregister.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/jjacquay712/GoRODS/connect"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+	"github.com/jjacquay712/GoRODS/native"
+)
+
+// command is a subcommand, run parses its own flags from args
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+// commands holds the subcommands
+var commands = make(map[string]*command)
+
+func init() {
+	for name, cmd := range map[string]*command{
+		"ls":    {"ls [-l] [path...]", runLs},
+		"stat":  {"stat path", runStat},
+		"get":   {"get [-r] [-f] src [local]", runGet},
+		"put":   {"put [-r] [-f] local [dest]", runPut},
+		"cp":    {"cp [-r] [-f] src dest", runCp},
+		"mv":    {"mv src dest", runMv},
+		"rm":    {"rm [-r] path...", runRm},
+		"mkdir": {"mkdir [-p] path...", runMkdir},
+		"meta":  {"meta add path attr value [units] | meta ls path | meta rm path attr", runMeta},
+		"chmod": {"chmod [-r] null|read|write|own|inherit|noinherit principal path...", runChmod},
+		"query": {"query [-z] \"select COL[, COL]... [where COL op 'value' [and ...]]\"", runQuery},
+	} {
+		commands[name] = cmd
+	}
+}
+
+// app holds the global options and the connection of a run
+type app struct {
+	backend
+
+	opts   connect.Options
+	ticket string
+	json   bool
+	cwd    string
+	stdout io.Writer
+	stderr io.Writer
+	fsys   irodsfs.Filesystem
+
+	// interrupt is closed by ctrl-C while a shell command runs, nil otherwise
+	interrupt chan struct{}
+}
+
+func main() {
+	a := &app{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	if err := a.main(os.Args[1:]); err != nil {
+		fmt.Fprintf(a.stderr, "gorods: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (a *app) usage(flags *flag.FlagSet) {
+	fmt.Fprintf(a.stderr, "Usage: gorods [global flags] <command> [flags] [args]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %v\n", commands[name].usage)
+	}
+
+	fmt.Fprintf(a.stderr, "\nGlobal flags:\n")
+	flags.SetOutput(a.stderr)
+	flags.PrintDefaults()
+}
+
+// main parses the global flags and runs the command
+func (a *app) main(args []string) error {
+	flags := flag.NewFlagSet("gorods", flag.ContinueOnError)
+	flags.Usage = func() { a.usage(flags) }
+
+	flags.StringVar(&a.opts.Host, "host", "", "iRODS host, irods_environment.json is used when empty")
+	flags.IntVar(&a.opts.Port, "port", 1247, "iRODS port")
+	flags.StringVar(&a.opts.Zone, "zone", "", "iRODS zone")
+	flags.StringVar(&a.opts.Username, "user", "", "iRODS user")
+	flags.StringVar(&a.opts.Password, "password", os.Getenv("IRODS_PASSWORD"), "iRODS password, defaults to $IRODS_PASSWORD")
+	flags.StringVar(&a.cwd, "cwd", os.Getenv("IRODS_CWD"), "collection of relative paths, defaults to $IRODS_CWD or the home collection")
+	flags.BoolVar(&a.json, "json", false, "print results as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	a.opts.Environment = a.opts.Host == ""
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	defer a.disconnect()
+
+	return a.run(flags.Arg(0), flags.Args()[1:])
+}
+
+// run runs the command name
+func (a *app) run(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return cmd.run(a, args)
+}
+
+// fs returns the connection, opening it on first use. In a shell command, its calls fail after
+// ctrl-C.
+func (a *app) fs() (irodsfs.Filesystem, error) {
+	if a.fsys == nil {
+		fsys, err := a.dial()
+		if err != nil {
+			return nil, err
+		}
+
+		a.fsys = fsys
+	}
+
+	if a.interrupt != nil {
+		return interruptible(a.fsys, a), nil
+	}
+
+	return a.fsys, nil
+}
+
+// abs resolves p against the current collection
+func (a *app) abs(p string) (string, error) {
+	if path.IsAbs(p) {
+		return path.Clean(p), nil
+	}
+
+	if a.cwd == "" {
+		a.session("", "")
+	}
+
+	cwd := a.cwd
+	if cwd == "" {
+		cwd = a.home()
+	}
+
+	if cwd == "" {
+		return "", fmt.Errorf("%q is relative, set -cwd or use an absolute path", p)
+	}
+
+	return path.Join(cwd, p), nil
+}
+
+// home returns the home collection of the user, empty when the zone or user is unknown
+func (a *app) home() string {
+	if a.opts.Zone == "" || a.opts.Username == "" {
+		return ""
+	}
+
+	return "/" + a.opts.Zone + "/home/" + a.opts.Username
+}
+
+// session fills the zone, user and current collection the global flags left empty, with those
+// of the connection, and in environment mode of irods_environment.json: irods_cwd, or
+// irods_home. abs falls back to the home collection of the user.
+func (a *app) session(zone string, username string) {
+	if a.opts.Environment {
+		if env, err := native.LoadEnvironment(""); err == nil {
+			if zone == "" {
+				zone = env.Zone
+			}
+
+			if username == "" {
+				username = env.Username
+			}
+		}
+
+		if home, cwd, err := native.EnvironmentCollections(""); err == nil && a.cwd == "" {
+			a.cwd = cwd
+			if a.cwd == "" {
+				a.cwd = home
+			}
+		}
+	}
+
+	if a.opts.Zone == "" {
+		a.opts.Zone = zone
+	}
+
+	if a.opts.Username == "" {
+		a.opts.Username = username
+	}
+}
+
+// newFlags returns the flag set of a command
+func (a *app) newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: gorods %v\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// print writes v as JSON with -json, or calls text
+func (a *app) print(v interface{}, text func(w io.Writer)) error {
+	if a.json {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	}
+
+	if text != nil {
+		text(a.stdout)
+	}
+
+	return nil
+}
+
+// usageError reports wrong arguments of a command
+func usageError(name string) error {
+	return fmt.Errorf("usage: gorods %v", commands[name].usage)
+}
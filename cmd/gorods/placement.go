@@ -0,0 +1,91 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jjacquay712/GoRODS/replpolicy"
+)
+
+func init() {
+	commands["placement"] = &command{"placement [-apply] [-workers n] [-per-resource n] [-retries n] policy.json", runPlacement}
+}
+
+// runPlacement reports the operations placing replicas as a replication policy wants, and runs
+// them with -apply. Without -apply, planned operations are an error so that the command can
+// check a zone in scripts.
+func runPlacement(a *app, args []string) error {
+	flags := a.newFlags("placement")
+	doApply := flags.Bool("apply", false, "run the replications, moves and trims, instead of reporting them")
+	workers := flags.Int("workers", 4, "objects whose operations run in parallel")
+	perResource := flags.Int("per-resource", 0, "operations running in parallel on a resource, 0 for no limit")
+	retries := flags.Int("retries", 2, "retries of a failed operation")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return usageError("placement")
+	}
+
+	policy, err := replpolicy.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	rfs, ok := fsys.(replpolicy.FS)
+	if !ok {
+		return fmt.Errorf("placement: the connection doesn't support replicas")
+	}
+
+	if *retries == 0 {
+		*retries = -1
+	}
+
+	report, err := replpolicy.Apply(rfs, policy, replpolicy.Options{
+		DryRun:      !*doApply,
+		Workers:     *workers,
+		PerResource: *perResource,
+		Retries:     *retries,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = a.print(report, func(w io.Writer) {
+		for _, op := range report.Operations {
+			if op.Error != "" {
+				fmt.Fprintf(w, "failed %v: %v\n", op, op.Error)
+			} else {
+				fmt.Fprintln(w, op)
+			}
+		}
+
+		for _, failure := range report.Failures {
+			fmt.Fprintf(w, "failed %v: %v\n", failure.Path, failure.Error)
+		}
+
+		fmt.Fprintln(w, report)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch failed := len(report.Failed()) + len(report.Failures); {
+	case failed > 0:
+		return fmt.Errorf("%d failures", failed)
+	case !*doApply && len(report.Operations) > 0:
+		return fmt.Errorf("%d operations needed", len(report.Operations))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jjacquay712/GoRODS/aclpolicy"
+	"github.com/jjacquay712/GoRODS/connect"
+)
+
+func init() {
+	commands["policy"] = &command{"policy [-apply] [-workers n] [-pool] policy.json", runPolicy}
+}
+
+// runPolicy reports the drift from an ACL policy, and fixes it with -apply. Without -apply,
+// drift is an error so that the command can check a zone in scripts.
+func runPolicy(a *app, args []string) error {
+	flags := a.newFlags("policy")
+	doApply := flags.Bool("apply", false, "make the Chmod and SetInheritance calls, instead of reporting them")
+	workers := flags.Int("workers", 8, "collections and objects checked in parallel")
+	pool := flags.Bool("pool", false, "open a connection per worker")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return usageError("policy")
+	}
+
+	policy, err := aclpolicy.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	opts := aclpolicy.Options{DryRun: !*doApply, Workers: *workers}
+
+	if *pool {
+		opts.Connect = func() (aclpolicy.FS, error) {
+			return connect.Dial(a.opts)
+		}
+	}
+
+	report, err := aclpolicy.Apply(fsys, policy, opts)
+	if err != nil {
+		return err
+	}
+
+	err = a.print(report, func(w io.Writer) {
+		for _, change := range report.Changes {
+			fmt.Fprintln(w, change)
+		}
+
+		for _, failure := range report.Failures {
+			fmt.Fprintf(w, "failed %v: %v\n", failure.Path, failure.Error)
+		}
+
+		fmt.Fprintf(w, "%d objects checked, %d drifting, %d calls\n", report.Checked, len(report.Drift), len(report.Changes))
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(report.Failures) > 0:
+		return fmt.Errorf("%d failures", len(report.Failures))
+	case !*doApply && len(report.Drift) > 0:
+		return fmt.Errorf("%d objects drift from the policy", len(report.Drift))
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/register"
+)
+
+func init() {
+	commands["register"] = &command{"register [-R resc] [-repl] [-k] [-K scheme] [-f] [-exclude patterns] [-workers n] [-retries n] local [dest]", runRegister}
+	commands["unregister"] = &command{"unregister path...", runUnregister}
+}
+
+// runRegister registers a file or a directory tree stored on a resource, without copying it.
+// Files already registered are skipped, so that an ingest can be run again.
+func runRegister(a *app, args []string) error {
+	flags := a.newFlags("register")
+	resource := flags.String("R", "", "resource holding the files")
+	replica := flags.Bool("repl", false, "register the files as new replicas of existing data objects")
+	regChecksum := flags.Bool("k", false, "compute and register the checksums on the server")
+	verify := flags.String("K", "", "hash the files with this scheme (md5, sha2...) and verify them on the server")
+	force := flags.Bool("f", false, "register over existing data objects, and files registered with another size")
+	exclude := flags.String("exclude", "", "comma separated patterns of file and directory names not registered")
+	workers := flags.Int("workers", 4, "files registered in parallel")
+	retries := flags.Int("retries", 2, "retries of a failed registration")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError("register")
+	}
+
+	// The server opens the files with this path
+	src, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	destArg := flags.Arg(1)
+	if destArg == "" {
+		destArg = "."
+	}
+
+	dest, err := a.abs(destArg)
+	if err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	rfs, ok := fsys.(register.FS)
+	if !ok {
+		return fmt.Errorf("register: the connection doesn't support registration")
+	}
+
+	if *retries == 0 {
+		*retries = -1
+	}
+
+	opts := register.Options{
+		Resource: *resource,
+		Replica:  *replica,
+		Checksum: *regChecksum,
+		Verify:   *verify,
+		Force:    *force,
+		Workers:  *workers,
+		Retries:  *retries,
+	}
+
+	if *exclude != "" {
+		opts.Exclude = strings.Split(*exclude, ",")
+	}
+
+	st, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	dest = destPath(fsys, filepath.ToSlash(src), dest)
+
+	var report *register.Report
+
+	if st.IsDir() {
+		if report, err = register.Tree(rfs, src, dest, opts); err != nil {
+			return err
+		}
+	} else {
+		report = &register.Report{
+			Results: []register.Result{register.File(rfs, src, dest, opts)},
+			Counts:  make(map[string]int),
+		}
+
+		report.Counts[report.Results[0].Status]++
+	}
+
+	return printRegistrations(a, report)
+}
+
+// runUnregister removes data objects and collections from the catalog, leaving their files
+func runUnregister(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageError("unregister")
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	rfs, ok := fsys.(register.FS)
+	if !ok {
+		return fmt.Errorf("unregister: the connection doesn't support registration")
+	}
+
+	report := &register.Report{Results: []register.Result{}, Counts: make(map[string]int)}
+
+	for _, arg := range args {
+		p, err := a.abs(arg)
+		if err != nil {
+			return err
+		}
+
+		r, err := register.Unregister(rfs, p)
+		if err != nil {
+			return err
+		}
+
+		report.Results = append(report.Results, r.Results...)
+
+		for status, n := range r.Counts {
+			report.Counts[status] += n
+		}
+	}
+
+	return printRegistrations(a, report)
+}
+
+// printRegistrations prints the results of register and unregister, failures are an error
+func printRegistrations(a *app, report *register.Report) error {
+	err := a.print(report, func(w io.Writer) {
+		for _, res := range report.Results {
+			switch {
+			case res.Status == register.Failed:
+				fmt.Fprintf(w, "failed %v: %v\n", res.Path, res.Error)
+			case res.PhysicalPath != "":
+				fmt.Fprintf(w, "%v %v %v\n", res.Status, res.PhysicalPath, res.Path)
+			default:
+				fmt.Fprintf(w, "%v %v\n", res.Status, res.Path)
+			}
+		}
+
+		fmt.Fprintln(w, report)
+	})
+	if err != nil {
+		return err
+	}
+
+	if failed := len(report.Failed()); failed > 0 {
+		return fmt.Errorf("%d failures", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,593 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func init() {
+	commands["shell"] = &command{"shell", runShell}
+	commands["find"] = &command{"find [-name pattern] [path]", runFind}
+}
+
+// shell is an interactive session reusing the connection of the app. The current collection
+// is the cwd of the app, so every command resolves relative paths against it.
+type shell struct {
+	a        *app
+	home     string
+	history  []string
+	histFile string
+	in       io.Reader
+	exit     bool
+}
+
+// shellHelp is printed by the help builtin
+const shellHelp = `Builtins: cd [path], pwd, history, help, exit
+Commands: ls, find, stat, get, put, cp, mv, rm, mkdir, meta, chmod, query, repl, trim and
+ticket, with the flags of the gorods command.
+
+Arguments with *, ? or [ are globbed against the collection listings. The paths printed by
+ls, find, stat and query are piped into meta and chmod:
+
+  ls *.csv | meta add project apollo
+  find -name '*.fastq' raw | chmod read lab
+
+Ctrl-C stops the running command and returns to the prompt.
+`
+
+func runShell(a *app, args []string) error {
+	if len(args) != 0 {
+		return usageError("shell")
+	}
+
+	sh, err := newShell(a, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		sh.histFile = filepath.Join(home, ".irods", ".gorods_history")
+		sh.loadHistory()
+	}
+
+	return sh.loop()
+}
+
+// newShell connects and returns a session reading in. It starts in -cwd or irods_cwd, or the
+// home collection of the user, where cd without argument returns.
+func newShell(a *app, in io.Reader) (*shell, error) {
+	fsys, err := a.fs()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.cwd, err = a.abs("."); err != nil {
+		return nil, err
+	}
+
+	if info, err := fsys.Stat(a.cwd); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("can't use %v as the current collection", a.cwd)
+	}
+
+	sh := &shell{
+		a:    a,
+		home: a.home(),
+		in:   in,
+	}
+
+	if sh.home == "" {
+		sh.home = a.cwd
+	}
+
+	return sh, nil
+}
+
+// loop reads and executes lines until exit or end of input
+func (sh *shell) loop() error {
+	ed := newEditor(sh.in, sh.a.stdout, sh.complete)
+	ed.history = sh.history
+
+	var term *os.File
+	restore := func() {}
+
+	if f, ok := sh.in.(*os.File); ok {
+		if r, err := makeRaw(f); err == nil {
+			ed.raw = true
+			term, restore = f, r
+		}
+	}
+
+	defer func() { restore() }()
+
+	for !sh.exit {
+		line, err := ed.readLine(sh.prompt())
+		if err == io.EOF {
+			fmt.Fprintln(sh.a.stdout)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		sh.addHistory(line)
+		ed.history = sh.history
+
+		// Raw mode clears ISIG, the terminal is restored while the command runs so ctrl-C
+		// raises SIGINT again
+		restore()
+
+		if err := sh.executeInterruptible(line); err != nil {
+			fmt.Fprintf(sh.a.stderr, "gorods: %v\n", err)
+		}
+
+		if term != nil {
+			if r, err := makeRaw(term); err == nil {
+				restore = r
+			} else {
+				ed.raw, restore = false, func() {}
+			}
+		}
+	}
+
+	return nil
+}
+
+// executeInterruptible executes line, ctrl-C stops the command at its next call to iRODS
+// instead of the shell
+func (sh *shell) executeInterruptible(line string) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	interrupt, done := make(chan struct{}), make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-sigs:
+			close(interrupt)
+		case <-done:
+		}
+	}()
+
+	sh.a.interrupt = interrupt
+	defer func() { sh.a.interrupt = nil }()
+
+	err := sh.execute(line)
+	if sh.a.interrupted() {
+		return errInterrupted
+	}
+
+	return err
+}
+
+func (sh *shell) prompt() string {
+	return path.Base(sh.a.cwd) + "> "
+}
+
+func (sh *shell) loadHistory() {
+	data, err := ioutil.ReadFile(sh.histFile)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sh.history = append(sh.history, line)
+		}
+	}
+}
+
+func (sh *shell) addHistory(line string) {
+	if n := len(sh.history); n > 0 && sh.history[n-1] == line {
+		return
+	}
+
+	sh.history = append(sh.history, line)
+
+	if sh.histFile == "" {
+		return
+	}
+
+	if f, err := os.OpenFile(sh.histFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600); err == nil {
+		fmt.Fprintln(f, line)
+		f.Close()
+	}
+}
+
+// execute runs a line: a command, or commands separated by | whose paths are piped
+func (sh *shell) execute(line string) error {
+	stages, err := splitPipeline(line)
+	if err != nil {
+		return err
+	}
+
+	for _, stage := range stages {
+		if len(stage) == 0 {
+			return fmt.Errorf("empty command in pipeline")
+		}
+	}
+
+	if len(stages) > 2 {
+		return fmt.Errorf("only one | is supported")
+	}
+
+	args, err := sh.glob(stages[0][1:])
+	if err != nil {
+		return err
+	}
+
+	if len(stages) == 1 {
+		return sh.run(stages[0][0], args)
+	}
+
+	paths, err := sh.produce(stages[0][0], args)
+	if err != nil {
+		return err
+	}
+
+	if args, err = sh.glob(stages[1][1:]); err != nil {
+		return err
+	}
+
+	return sh.consume(stages[1][0], args, paths)
+}
+
+// run runs a builtin or a command of the gorods tool
+func (sh *shell) run(name string, args []string) error {
+	switch name {
+	case "exit", "quit":
+		sh.exit = true
+		return nil
+
+	case "help":
+		fmt.Fprint(sh.a.stdout, shellHelp)
+		return nil
+
+	case "pwd":
+		fmt.Fprintln(sh.a.stdout, sh.a.cwd)
+		return nil
+
+	case "history":
+		for inx, line := range sh.history {
+			fmt.Fprintf(sh.a.stdout, "%5d  %v\n", inx+1, line)
+		}
+
+		return nil
+
+	case "cd":
+		return sh.cd(args)
+
+	case "shell":
+		return fmt.Errorf("already in the shell")
+	}
+
+	return sh.a.run(name, args)
+}
+
+func (sh *shell) cd(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: cd [path]")
+	}
+
+	target := sh.home
+	if len(args) == 1 {
+		p, err := sh.a.abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		target = p
+	}
+
+	fsys, err := sh.a.fs()
+	if err != nil {
+		return err
+	}
+
+	info, err := fsys.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%v is not a collection", target)
+	}
+
+	sh.a.cwd = target
+
+	return nil
+}
+
+// produce runs a command with JSON output and returns the paths it printed
+func (sh *shell) produce(name string, args []string) ([]string, error) {
+	var out bytes.Buffer
+
+	stdout, asJSON := sh.a.stdout, sh.a.json
+	sh.a.stdout, sh.a.json = &out, true
+
+	err := sh.run(name, args)
+
+	sh.a.stdout, sh.a.json = stdout, asJSON
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonPaths(out.Bytes())
+}
+
+// consume runs a command with the piped paths. meta takes a path after its subcommand, so it
+// runs once per path, the other commands take the paths as trailing arguments.
+func (sh *shell) consume(name string, args []string, paths []string) error {
+	switch name {
+	case "meta":
+		if len(args) == 0 {
+			return usageError("meta")
+		}
+
+		for _, p := range paths {
+			if err := sh.run(name, append([]string{args[0], p}, args[1:]...)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case "chmod", "rm", "stat", "ls", "repl", "trim":
+		if len(paths) == 0 {
+			return nil
+		}
+
+		return sh.run(name, append(args, paths...))
+	}
+
+	return fmt.Errorf("can't pipe into %v", name)
+}
+
+// jsonPaths extracts the paths of the JSON output of ls, find, stat and query
+func jsonPaths(data []byte) ([]string, error) {
+	var items []map[string]interface{}
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		var item map[string]interface{}
+
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("the command doesn't print paths")
+		}
+
+		items = append(items, item)
+	}
+
+	var paths []string
+
+	for _, item := range items {
+		str := func(key string) string {
+			s, _ := item[key].(string)
+			return s
+		}
+
+		switch {
+		case str("path") != "":
+			paths = append(paths, str("path"))
+		case str("COLL_NAME") != "" && str("DATA_NAME") != "":
+			paths = append(paths, path.Join(str("COLL_NAME"), str("DATA_NAME")))
+		case str("COLL_NAME") != "":
+			paths = append(paths, str("COLL_NAME"))
+		default:
+			return nil, fmt.Errorf("the command doesn't print paths")
+		}
+	}
+
+	return paths, nil
+}
+
+// splitPipeline splits a line into the words of each command, honoring quotes
+func splitPipeline(line string) ([][]string, error) {
+	var (
+		stages [][]string
+		words  []string
+		cur    []rune
+		quote  rune
+		inWord bool
+	)
+
+	flush := func() {
+		if inWord {
+			words = append(words, string(cur))
+		}
+
+		cur, inWord = nil, false
+	}
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur = append(cur, r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			flush()
+		case r == '|':
+			flush()
+			stages = append(stages, words)
+			words = nil
+		default:
+			cur, inWord = append(cur, r), true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	flush()
+
+	return append(stages, words), nil
+}
+
+// isGlob reports whether s has glob metacharacters
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// glob expands the arguments with glob metacharacters against the collection listings,
+// flags and arguments without matches are kept as they are
+func (sh *shell) glob(args []string) ([]string, error) {
+	var expanded []string
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || !isGlob(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		matches, err := sh.match(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		expanded = append(expanded, matches...)
+	}
+
+	return expanded, nil
+}
+
+// match returns the paths matching the pattern, each path element can hold metacharacters
+func (sh *shell) match(pattern string) ([]string, error) {
+	p, err := sh.a.abs(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := path.Match(p, ""); err != nil {
+		return nil, err
+	}
+
+	fsys, err := sh.a.fs()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{"/"}
+
+	for _, elem := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		var next []string
+
+		for _, dir := range candidates {
+			if !isGlob(elem) {
+				next = append(next, path.Join(dir, elem))
+				continue
+			}
+
+			list, err := fsys.List(dir)
+			if err != nil {
+				continue
+			}
+
+			for _, info := range list {
+				if ok, _ := path.Match(elem, info.Name); ok {
+					next = append(next, info.Path)
+				}
+			}
+		}
+
+		candidates = next
+	}
+
+	var matches []string
+
+	for _, candidate := range candidates {
+		if _, err := fsys.Stat(candidate); err == nil {
+			matches = append(matches, candidate)
+		}
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+func runFind(a *app, args []string) error {
+	flags := a.newFlags("find")
+	pattern := flags.String("name", "*", "glob matched against the names of collections and data objects")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		return usageError("find")
+	}
+
+	root := flags.Arg(0)
+	if root == "" {
+		root = "."
+	}
+
+	root, err := a.abs(root)
+	if err != nil {
+		return err
+	}
+
+	if _, err := path.Match(*pattern, ""); err != nil {
+		return err
+	}
+
+	fsys, err := a.fs()
+	if err != nil {
+		return err
+	}
+
+	objs := []objJSON{}
+
+	err = irodsfs.Walk(fsys, root, func(p string, info irodsfs.ObjInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ok, _ := path.Match(*pattern, info.Name); ok && p != root {
+			objs = append(objs, newObjJSON(info))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.print(objs, func(w io.Writer) {
+		for _, obj := range objs {
+			fmt.Fprintln(w, obj.Path)
+		}
+	})
+}
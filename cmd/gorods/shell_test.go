@@ -0,0 +1,218 @@
+package main
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func testShell(t *testing.T) *shell {
+	a, _ := testApp(t)
+
+	a.fsys.Mkdir("/tempZone/home/rods/raw", false)
+
+	for _, p := range []string{"a.csv", "b.csv", "notes.txt", "raw/r1.fastq", "raw/r2.fastq"} {
+		f, err := a.fsys.Open("/tempZone/home/rods/"+p, os.O_WRONLY|os.O_CREATE)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f.Close()
+	}
+
+	return &shell{a: a, home: a.cwd}
+}
+
+func TestShellPipes(t *testing.T) {
+	sh := testShell(t)
+
+	if err := sh.execute("ls *.csv | meta add project apollo"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"a.csv", "b.csv"} {
+		meta, _ := sh.a.fsys.Meta("/tempZone/home/rods/" + p)
+
+		if len(meta) != 1 || meta[0].Value != "apollo" {
+			t.Fatalf("Unexpected AVUs of %v: %+v", p, meta)
+		}
+	}
+
+	if meta, _ := sh.a.fsys.Meta("/tempZone/home/rods/notes.txt"); len(meta) != 0 {
+		t.Fatalf("notes.txt was tagged: %+v", meta)
+	}
+
+	if err := sh.execute("find -name '*.fastq' | chmod read public"); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, _ := sh.a.fsys.ACL("/tempZone/home/rods/raw/r2.fastq")
+
+	found := false
+	for _, entry := range acl {
+		found = found || (strings.HasPrefix(entry.Principal, "public") && entry.AccessLevel == irodsfs.Read)
+	}
+
+	if !found {
+		t.Fatalf("Unexpected ACL %+v", acl)
+	}
+
+	if err := sh.execute("ls | get x | meta ls"); err == nil {
+		t.Fatal("Two pipes accepted")
+	}
+
+	if err := sh.execute("mkdir x | meta ls"); err == nil {
+		t.Fatal("Piped a command without paths")
+	}
+}
+
+func TestShellCd(t *testing.T) {
+	sh := testShell(t)
+
+	if err := sh.execute("cd raw"); err != nil || sh.a.cwd != "/tempZone/home/rods/raw" {
+		t.Fatalf("cd raw: %v, cwd %v", err, sh.a.cwd)
+	}
+
+	if err := sh.execute("cd r1.fastq"); err == nil {
+		t.Fatal("cd into a data object succeeded")
+	}
+
+	if got, _ := sh.glob([]string{"-l", "*2.fastq", "none*"}); !reflect.DeepEqual(got, []string{"-l", "/tempZone/home/rods/raw/r2.fastq", "none*"}) {
+		t.Fatalf("Unexpected expansion %v", got)
+	}
+
+	sh.execute("cd")
+
+	if sh.a.cwd != "/tempZone/home/rods" || sh.prompt() != "rods> " {
+		t.Fatalf("cd didn't return home: %v", sh.a.cwd)
+	}
+}
+
+func TestShellEnvironment(t *testing.T) {
+	a, _, cleanup := environmentApp(t, `{"irods_zone_name": "tempZone", "irods_user_name": "rods", "irods_cwd": "/tempZone/home/rods/raw"}`)
+	defer cleanup()
+
+	a.fsys.Mkdir("/tempZone/home/rods/raw", false)
+
+	sh, err := newShell(a, strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sh.a.cwd != "/tempZone/home/rods/raw" || sh.home != "/tempZone/home/rods" {
+		t.Fatalf("Unexpected session: cwd %v, home %v", sh.a.cwd, sh.home)
+	}
+
+	if err := sh.execute("cd"); err != nil || sh.a.cwd != "/tempZone/home/rods" {
+		t.Fatalf("cd didn't return home: %v %v", err, sh.a.cwd)
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	sh := testShell(t)
+
+	sh.a.fsys.AddMeta("/tempZone/home/rods/a.csv", irodsfs.AVU{Attribute: "project", Value: "apollo"})
+	sh.a.fsys.AddMeta("/tempZone/home/rods/a.csv", irodsfs.AVU{Attribute: "phase", Value: "2"})
+
+	for line, want := range map[string][]string{
+		"me":                  {"meta"},
+		"ls ":                 {"a.csv", "b.csv", "notes.txt", "raw/"},
+		"ls raw/r":            {"raw/r1.fastq", "raw/r2.fastq"},
+		"cd ":                 {"raw/"},
+		"ls *.csv | chmod -":  nil,
+		"meta rm a.csv p":     {"phase", "project"},
+		"meta add a.csv pr":   {"project"},
+		"ls /tempZone/home/r": {"/tempZone/home/rods/"},
+	} {
+		if got := sh.complete(line); !reflect.DeepEqual(got, want) {
+			t.Errorf("complete(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestEditor(t *testing.T) {
+	var out strings.Builder
+
+	complete := func(line string) []string {
+		if strings.HasSuffix(line, "ra") {
+			return []string{"raw/"}
+		}
+
+		return []string{"rm", "repl"}
+	}
+
+	// Tab completion, backspace, left arrow with insertion, history recall and ctrl-D
+	in := "ls ra\t\r" + "rx\x7f\t\t\r" + "cd\x1b[Da\r" + "\x1b[A\x1b[A\r" + "\x04"
+
+	ed := newEditor(strings.NewReader(in), &out, complete)
+	ed.raw = true
+	ed.history = []string{"pwd"}
+
+	var lines []string
+
+	for {
+		line, err := ed.readLine("> ")
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, line)
+		ed.history = append(ed.history, line)
+	}
+
+	if want := []string{"ls raw/", "r", "cad", "r"}; !reflect.DeepEqual(lines, want) {
+		t.Fatalf("Read %q, want %q", lines, want)
+	}
+
+	if !strings.Contains(out.String(), "rm  repl") {
+		t.Fatalf("Double tab didn't list the completions: %q", out.String())
+	}
+
+	ed = newEditor(strings.NewReader("pwd\nls"), &out, nil)
+
+	if line, err := ed.readLine("> "); line != "pwd" || err != nil {
+		t.Fatalf("Read %q, %v", line, err)
+	}
+
+	if line, err := ed.readLine("> "); line != "ls" || err != nil {
+		t.Fatalf("Read %q, %v", line, err)
+	}
+
+	if _, err := ed.readLine("> "); err != io.EOF {
+		t.Fatalf("Expected EOF, got %v", err)
+	}
+}
+
+func TestShellInterrupt(t *testing.T) {
+	sh := testShell(t)
+
+	sh.a.interrupt = make(chan struct{})
+	close(sh.a.interrupt)
+
+	if err := sh.execute("ls"); err != errInterrupted {
+		t.Fatalf("Expected errInterrupted, got %v", err)
+	}
+
+	fsys, _ := sh.a.fs()
+	if _, ok := fsys.(irodsfs.ReplicaFS); !ok {
+		t.Fatal("The interruptible connection lost the replica calls")
+	}
+
+	sh.a.interrupt = nil
+
+	if err := sh.execute("cd raw"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sh.a.cwd != "/tempZone/home/rods/raw" {
+		t.Fatalf("Unexpected cwd %v", sh.a.cwd)
+	}
+}
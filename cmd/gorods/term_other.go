@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not supported, the shell reads plain lines
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -3,9 +3,6 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"os"
@@ -14,7 +11,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-	"unsafe"
 )
 
 // Collection structs contain information about single collections in an iRODS zone.
@@ -41,13 +37,14 @@ type Collection struct {
 	createTime time.Time
 	modifyTime time.Time
 
-	opened     bool
-	cColHandle C.collHandle_t
+	opened bool
+	cCollection
 }
 
 // CollectionOptions stores options relating to collection initialization.
 // Path is the full path of the collection you're requesting.
 // Recursive if set to true will load sub collections into memory, until the end of the collection "tree" is found.
+// SkipCache fetches the collection from the server instead of the cache of the connection, the caller closes it.
 type CollectionOptions struct {
 	Path      string
 	Recursive bool
@@ -80,47 +77,6 @@ func (obj *Collection) String() string {
 	return str
 }
 
-// initCollection initializes collection from *C.collEnt_t. This is used internally in the gorods package.
-func initCollection(data *C.collEnt_t, acol *Collection) (*Collection, error) {
-
-	col := new(Collection)
-
-	col.opened = false
-	col.typ = CollectionType
-	col.col = acol
-	col.con = col.col.con
-	col.path = C.GoString(data.collName)
-	col.options = acol.options
-	col.recursive = acol.recursive
-	col.trimRepls = acol.trimRepls
-	col.parent = acol
-
-	col.ownerName = C.GoString(data.ownerName)
-	col.createTime = cTimeToTime(data.createTime)
-	col.modifyTime = cTimeToTime(data.modifyTime)
-
-	col.name = filepath.Base(col.path)
-
-	if usrs, err := col.con.Users(); err != nil {
-		return nil, err
-	} else {
-		if u := usrs.FindByName(col.ownerName, col.con); u != nil {
-			col.owner = u
-		} else {
-			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS initCollection Failed: Unable to locate user in cache"))
-		}
-	}
-
-	if col.recursive {
-
-		if er := col.init(); er != nil {
-			return nil, er
-		}
-	}
-
-	return col, nil
-}
-
 // Stat returns a map (key/value pairs) of the system meta information. The following keys can be used with the map:
 //
 // "objSize"
@@ -139,39 +95,11 @@ func initCollection(data *C.collEnt_t, acol *Collection) (*Collection, error) {
 //
 // "modifyTime"
 func (col *Collection) Stat() (map[string]interface{}, error) {
-
-	var (
-		err        *C.char
-		statResult *C.rodsObjStat_t
-	)
-
-	path := C.CString(col.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := col.con.GetCcon()
-	defer col.con.ReturnCcon(ccon)
-
-	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Stat Failed: %v, %v", col.path, C.GoString(err)))
+	if col.con.fs != nil {
+		return col.fsStat()
 	}
 
-	result := make(map[string]interface{})
-
-	result["objSize"] = int(statResult.objSize)
-	result["dataMode"] = int(statResult.dataMode)
-
-	result["dataId"] = C.GoString(&statResult.dataId[0])
-	result["chksum"] = C.GoString(&statResult.chksum[0])
-	result["ownerName"] = C.GoString(&statResult.ownerName[0])
-	result["ownerZone"] = C.GoString(&statResult.ownerZone[0])
-	result["createTime"] = C.GoString(&statResult.createTime[0])
-	result["modifyTime"] = C.GoString(&statResult.modifyTime[0])
-	//result["rescHier"] = C.GoString(&statResult.rescHier[0])
-
-	C.freeRodsObjStat(statResult)
-
-	return result, nil
+	return col.cStat()
 }
 
 // getCollection initializes specified collection located at startPath using gorods.connection.
@@ -223,13 +151,8 @@ func setupCollection(col *Collection) (*Collection, error) {
 	if info, err := col.Stat(); err == nil {
 		col.ownerName = info["ownerName"].(string)
 
-		cCreateTime := C.CString(info["createTime"].(string))
-		defer C.free(unsafe.Pointer(cCreateTime))
-		cModifyTime := C.CString(info["modifyTime"].(string))
-		defer C.free(unsafe.Pointer(cModifyTime))
-
-		col.createTime = cTimeToTime(cCreateTime)
-		col.modifyTime = cTimeToTime(cModifyTime)
+		col.createTime = timeStringToTime(info["createTime"].(string))
+		col.modifyTime = timeStringToTime(info["modifyTime"].(string))
 
 		if usrs, err := col.con.Users(); err != nil {
 			return nil, err
@@ -249,32 +172,11 @@ func setupCollection(col *Collection) (*Collection, error) {
 
 // CreateCollection creates a collection in the specified collection using provided options. Returns the newly created collection object.
 func CreateCollection(name string, coll *Collection) (*Collection, error) {
-
-	var (
-		errMsg *C.char
-	)
-
-	newColPath := coll.path + "/" + name
-	path := C.CString(newColPath)
-
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := coll.con.GetCcon()
-
-	if status := C.gorods_create_collection(path, ccon, &errMsg); status != 0 {
-		coll.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Create Collection Failed: %v, Does the collection already exist?", C.GoString(errMsg)))
+	if coll.con.fs != nil {
+		return fsCreateCollection(name, coll)
 	}
 
-	coll.con.ReturnCcon(ccon)
-
-	//coll.Refresh()
-	//newCol := coll.Cd(name)
-
-	return coll.Con().Collection(CollectionOptions{
-		Path: newColPath,
-	})
-
+	return cCreateCollection(name, coll)
 }
 
 // init opens and reads collection information from iRODS if it hasn't been init'd already
@@ -417,36 +319,21 @@ func (col *Collection) SetInheritance(inherits bool, recursive bool) error {
 
 // Inheritance returns true or false, depending on the collection's inheritance setting
 func (col *Collection) Inheritance() (bool, error) {
-
-	var (
-		enabled C.int
-		err     *C.char
-	)
-
-	collName := C.CString(col.path)
-	defer C.free(unsafe.Pointer(collName))
-
-	ccon := col.con.GetCcon()
-	defer col.con.ReturnCcon(ccon)
-
-	if status := C.gorods_get_collection_inheritance(ccon, collName, &enabled, &err); status != 0 {
-		return false, newError(Fatal, status, fmt.Sprintf("iRODS Get Collection Inheritance Failed: %v", C.GoString(err)))
-	}
-
-	if int(enabled) > 0 {
-		return true, nil
+	if col.con.fs != nil {
+		return col.fsInheritance()
 	}
 
-	return false, nil
+	return col.cInheritance()
 }
 
 // GrantAccess will add permissions (ACL) to the collection
 func (col *Collection) GrantAccess(userOrGroup AccessObject, accessLevel int, recursive bool) error {
-	return chmod(col, userOrGroup.Name(), accessLevel, recursive, true)
+	return chmod(col, Principal(userOrGroup), accessLevel, recursive, true)
 }
 
 // Chmod changes the permissions/ACL of the collection
-// accessLevel: Null | Read | Write | Own
+// accessLevel: Null | Read | Write | Own, or a granular level of iRODS 4.3 (see AccessLevels)
+// userOrGroup is a name of the local zone, or name#zone for a user or group of a federated zone
 func (col *Collection) Chmod(userOrGroup string, accessLevel int, recursive bool) error {
 	return chmod(col, userOrGroup, accessLevel, recursive, true)
 }
@@ -456,35 +343,11 @@ func (col *Collection) Chmod(userOrGroup string, accessLevel int, recursive bool
 // developers#tempZone:modify object
 // designers#tempZone:read object]
 func (col *Collection) ACL() (ACLs, error) {
-
-	var (
-		result   C.goRodsACLResult_t
-		err      *C.char
-		zoneHint *C.char
-		collName *C.char
-	)
-
-	zone, zErr := col.con.LocalZone()
-	if zErr != nil {
-		return nil, zErr
-	} else {
-		zoneHint = C.CString(zone.Name())
-	}
-
-	collName = C.CString(col.path)
-	defer C.free(unsafe.Pointer(collName))
-	defer C.free(unsafe.Pointer(zoneHint))
-
-	ccon := col.con.GetCcon()
-
-	if status := C.gorods_get_collection_acl(ccon, collName, &result, zoneHint, &err); status != 0 {
-		col.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Collection ACL Failed: %v", C.GoString(err)))
+	if col.con.fs != nil {
+		return fsACL(col)
 	}
 
-	col.con.ReturnCcon(ccon)
-
-	return aclSliceToResponse(&result, col.con)
+	return col.cACL()
 }
 
 // Size returns the total size in bytes of all contained data objects and collections, recursively
@@ -598,6 +461,10 @@ func (col *Collection) Col() *Collection {
 			SkipCache: true,
 		})
 
+		// The parent isn't cached, its handle is opened again when it's read
+		if col.col != nil {
+			col.col.Close()
+		}
 	}
 
 	return col.col
@@ -620,51 +487,20 @@ func (col *Collection) Trash(recursive bool) error {
 
 // Rm is equivalent to irm {-r} {-f}
 func (col *Collection) Rm(recursive bool, force bool) error {
-	var errMsg *C.char
-
-	path := C.CString(col.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	var (
-		cForce     C.int
-		cRecursive C.int
-	)
-
-	if force {
-		cForce = C.int(1)
-	}
-
-	if recursive {
-		cRecursive = C.int(1)
-	}
-
-	ccon := col.con.GetCcon()
-	defer col.con.ReturnCcon(ccon)
-
-	if status := C.gorods_rm(path, C.int(1), cRecursive, cForce, C.int(0), ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Rm Collection Failed: %v", C.GoString(errMsg)))
+	if col.con.fs != nil {
+		return col.con.fsRm(col.path, recursive, force)
 	}
 
-	return nil
+	return col.cRm(recursive, force)
 }
 
 // RmTrash is used (sometimes internally) by GoRODS to delete items in the trash permanently. The collection's path should be in the trash collection.
 func (col *Collection) RmTrash() error {
-	var errMsg *C.char
-
-	path := C.CString(col.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := col.con.GetCcon()
-	defer col.con.ReturnCcon(ccon)
-
-	if status := C.gorods_rm(path, C.int(1), C.int(1), C.int(1), C.int(1), ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS RmTrash Collection Failed: %v", C.GoString(errMsg)))
+	if col.con.fs != nil {
+		return col.con.fsRmTrash(col.path)
 	}
 
-	return nil
+	return col.cRmTrash()
 }
 
 // Attribute gets slice of Meta AVU triples, matching by Attribute name for Collection
@@ -760,58 +596,20 @@ func (col *Collection) DownloadTo(localPath string) error {
 // Open connects to iRODS and sets the handle for Collection.
 // Usually called by Collection.init()
 func (col *Collection) Open() error {
-	if !col.opened {
-		var (
-			errMsg     *C.char
-			cTrimRepls C.int
-		)
-
-		path := C.CString(col.path)
-
-		if col.trimRepls {
-			cTrimRepls = C.int(1)
-		} else {
-			cTrimRepls = C.int(0)
-		}
-
-		defer C.free(unsafe.Pointer(path))
-
-		ccon := col.con.GetCcon()
-		defer col.con.ReturnCcon(ccon)
-
-		if status := C.gorods_open_collection(path, cTrimRepls, &col.cColHandle, ccon, &errMsg); status != 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS Open Collection Failed: %v, %v", col.path, C.GoString(errMsg)))
-		}
-
-		col.opened = true
+	if col.con.fs != nil {
+		return col.fsOpen()
 	}
 
-	return nil
+	return col.cOpen()
 }
 
 // Close closes the Collection connection and resets the handle
 func (col *Collection) Close() error {
-	var errMsg *C.char
-
-	for _, c := range col.dataObjects {
-		if err := c.Close(); err != nil {
-			return err
-		}
-	}
-
-	if col.opened {
-
-		ccon := col.con.GetCcon()
-		defer col.con.ReturnCcon(ccon)
-
-		if status := C.gorods_close_collection(&col.cColHandle, &errMsg); status != 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS Close Collection Failed: %v, %v", col.path, C.GoString(errMsg)))
-		}
-
-		col.opened = false
+	if col.con.fs != nil {
+		return col.fsClose()
 	}
 
-	return nil
+	return col.cClose()
 }
 
 // CopyTo copies all collections and data objects contained withing the collection to the specified collection.
@@ -1039,114 +837,20 @@ func (col *Collection) Backup(targetResource interface{}, opts DataObjOptions) e
 
 // MoveTo moves the collection to the specified collection. Supports Collection struct or string as input. Also refreshes the source and destination collections automatically to maintain correct state. Returns error.
 func (col *Collection) MoveTo(iRODSCollection interface{}) error {
-
-	var (
-		err                         *C.char
-		destination                 string
-		destinationCollectionString string
-		destinationCollection       *Collection
-	)
-
-	switch iRODSCollection.(type) {
-	case string:
-		destinationCollectionString = iRODSCollection.(string)
-
-		// Is this a relative path?
-		if destinationCollectionString[0] != '/' {
-			destinationCollectionString = path.Dir(col.path) + "/" + destinationCollectionString
-		}
-
-		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
-			destinationCollectionString += "/"
-		}
-
-		destination += destinationCollectionString + col.name
-	case *Collection:
-		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
-		destination = destinationCollectionString + col.name
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Move Collection Failed, unknown variable type passed as collection"))
-	}
-
-	path := C.CString(col.path)
-	dest := C.CString(destination)
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(dest))
-
-	ccon := col.con.GetCcon()
-
-	if status := C.gorods_move_dataobject(path, dest, C.RENAME_COLL, ccon, &err); status != 0 {
-		col.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Move Collection Failed: %v, D:%v, %v", col.path, destination, C.GoString(err)))
-	}
-
-	col.con.ReturnCcon(ccon)
-
-	// Reload source collection, we are now detached... buggy?
-	//col.parent.Refresh()
-
-	// Find & reload destination collection
-	switch iRODSCollection.(type) {
-	case string:
-		var colEr error
-
-		// Can't find, load collection into memory
-		destinationCollection, colEr = col.con.Collection(CollectionOptions{
-			Path:      destinationCollectionString,
-			Recursive: false,
-		})
-		if colEr != nil {
-			return colEr
-		}
-	case *Collection:
-		destinationCollection = (iRODSCollection.(*Collection))
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Move Collection Failed, unknown variable type passed as collection"))
+	if col.con.fs != nil {
+		return col.fsMoveTo(iRODSCollection)
 	}
 
-	destinationCollection.Refresh()
-
-	// Reassign obj.col to destination collection
-	col.parent = destinationCollection
-	col.path = destinationCollection.path + "/" + col.name
-
-	col.opened = false
-
-	return nil
+	return col.cMoveTo(iRODSCollection)
 }
 
 // Rename is equivalent to the Linux mv command except that the collection must stay within it's current collection (directory), returns error.
 func (col *Collection) Rename(newFileName string) error {
-
-	if strings.Contains(newFileName, "/") {
-		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
+	if col.con.fs != nil {
+		return col.fsRename(newFileName)
 	}
 
-	var err *C.char
-
-	source := col.path
-	destination := path.Dir(col.path) + "/" + newFileName
-
-	s := C.CString(source)
-	d := C.CString(destination)
-
-	defer C.free(unsafe.Pointer(s))
-	defer C.free(unsafe.Pointer(d))
-
-	ccon := col.con.GetCcon()
-	defer col.con.ReturnCcon(ccon)
-
-	if status := C.gorods_move_dataobject(s, d, C.RENAME_COLL, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Rename Collection Failed: %v, %v", col.path, C.GoString(err)))
-	}
-
-	col.name = newFileName
-	col.path = destination
-
-	col.opened = false
-
-	return nil
+	return col.cRename(newFileName)
 }
 
 // Refresh is an alias of ReadCollection()
@@ -1171,72 +875,11 @@ type CollectionReadInfo struct {
 }
 
 func (col *Collection) ReadCollectionOpts(opts CollectionReadOpts) (CollectionReadInfo, error) {
-	errInfo := CollectionReadInfo{0, 0, 0, 0, 0, 0}
-	if er := col.Open(); er != nil {
-		return errInfo, er
-	}
-
-	var colTotal, objTotal, colCnt, objCnt int
-	var info CollectionReadInfo
-	var cOpts C.goRodsQueryOpts_t
-
-	cOpts.limit = C.int(opts.Limit)
-	cOpts.offset = C.int(opts.Offset)
-
-	var colEnt C.collEnt_t
-
-	col.dataObjects = make([]IRodsObj, 0)
-
-	ccon := col.con.GetCcon()
-	for int(C.gorods_rclReadCollectionCols(ccon, &col.cColHandle, &colEnt, cOpts)) >= 0 {
-		if newCol, er := initCollection(&colEnt, col); er == nil {
-			col.add(newCol)
-		} else {
-			return errInfo, er
-		}
-	}
-
-	colTotal = int(col.cColHandle.collSqlResult.totalRowCount)
-	colCnt = int(col.cColHandle.collSqlResult.rowCnt)
-
-	C.clearCollSqlResult(&col.cColHandle.collSqlResult)
-
-	newLimit := opts.Limit - colCnt
-	newOffset := opts.Offset
-
-	if colCnt == 0 && colTotal > 0 {
-		newOffset = opts.Offset - colTotal
+	if col.con.fs != nil {
+		return col.fsReadCollectionOpts(opts)
 	}
 
-	if newLimit == 0 {
-		// We're done, don't grab any objects
-		info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
-		col.readInfo = &info
-
-		col.con.ReturnCcon(ccon)
-
-		return info, col.Close()
-	} else {
-		cOpts.limit = C.int(newLimit)
-		cOpts.offset = C.int(newOffset)
-	}
-
-	for int(C.gorods_rclReadCollectionObjs(ccon, &col.cColHandle, &colEnt, cOpts)) >= 0 {
-		col.add(initDataObj(&colEnt, col, col.con))
-	}
-
-	objTotal = int(col.cColHandle.dataObjSqlResult.totalRowCount)
-	objCnt = int(col.cColHandle.dataObjSqlResult.rowCnt)
-
-	C.clearDataObjSqlResult(&col.cColHandle.dataObjSqlResult)
-
-	col.con.ReturnCcon(ccon)
-
-	info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
-	col.readInfo = &info
-
-	return info, col.Close()
-
+	return col.cReadCollectionOpts(opts)
 }
 
 func (col *Collection) ReadInfo() *CollectionReadInfo {
@@ -1245,157 +888,21 @@ func (col *Collection) ReadInfo() *CollectionReadInfo {
 
 // ReadCollection reads data (overwrites) into col.dataObjects field.
 func (col *Collection) ReadCollection() error {
-
-	if er := col.Open(); er != nil {
-		return er
+	if col.con.fs != nil {
+		return col.fsReadCollection()
 	}
 
-	var colTotal, objTotal, colCnt, objCnt, limit, offset int
-	var info CollectionReadInfo
-
-	var colEnt C.collEnt_t
-
-	col.dataObjects = make([]IRodsObj, 0)
-
-	if col.readOpts != nil {
-		limit = col.readOpts.Limit
-		offset = col.readOpts.Offset
-	} else {
-		limit = -1
-		offset = -1
-	}
-
-	ccon := col.con.GetCcon()
-
-	col.cColHandle.genQueryInp.options = C.RETURN_TOTAL_ROW_COUNT
-
-	itrInx := 0
-	addCnt := 0
-
-	for int(C.rclReadCollection(ccon, &col.cColHandle, &colEnt)) >= 0 {
-
-		var theObj IRodsObj
-
-		isCollection := (colEnt.objType != C.DATA_OBJ_T)
-
-		if isCollection {
-			if newCol, er := initCollection(&colEnt, col); er == nil {
-				theObj = newCol
-			} else {
-				return er
-			}
-		} else {
-			theObj = initDataObj(&colEnt, col, col.con)
-		}
-
-		if col.readOpts != nil && col.readOpts.Filter != nil {
-			col.con.ReturnCcon(ccon)
-			if !col.readOpts.Filter(theObj) {
-				ccon = col.con.GetCcon()
-				continue
-			} else {
-				ccon = col.con.GetCcon()
-			}
-
-		}
-
-		if colTotal == 0 && objTotal == 0 {
-			colTotal = int(col.cColHandle.collSqlResult.totalRowCount)
-			objTotal = int(col.cColHandle.dataObjSqlResult.totalRowCount)
-		}
-
-		if offset != -1 {
-			if itrInx < offset {
-				itrInx++
-				continue
-			}
-		}
-
-		if limit != -1 {
-			if addCnt == limit {
-				break
-			}
-		}
-
-		col.add(theObj)
-		addCnt++
-
-		if isCollection {
-			colCnt++
-		} else {
-			objCnt++
-		}
-
-		itrInx++
-
-	}
-
-	col.con.ReturnCcon(ccon)
-
-	info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
-	col.readInfo = &info
-
-	return col.Close()
+	return col.cReadCollection()
 }
 
 // Put reads the entire file from localPath and adds it the collection, using the options specified.
+// With opts.ChecksumScheme, the file is hashed locally with that scheme and the server verifies the upload against it.
 func (col *Collection) Put(localPath string, opts DataObjOptions) (*DataObj, error) {
-
-	var (
-		errMsg   *C.char
-		force    int
-		resource *C.char
-	)
-
-	if opts.Force {
-		force = 1
-	} else {
-		force = 0
-	}
-
-	if opts.Resource != nil {
-		switch opts.Resource.(type) {
-		case string:
-			resource = C.CString(opts.Resource.(string))
-		case *Resource:
-			r := opts.Resource.(*Resource)
-			resource = C.CString(r.Name())
-		default:
-			return nil, newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
-		}
-	} else {
-		resource = C.CString("")
-	}
-
-	if opts.Name == "" {
-		opts.Name = filepath.Base(localPath)
-	}
-
-	path := C.CString(col.path + "/" + opts.Name)
-	cLocalPath := C.CString(localPath)
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(resource))
-	defer C.free(unsafe.Pointer(cLocalPath))
-
-	ccon := col.con.GetCcon()
-
-	if status := C.gorods_put_dataobject(cLocalPath, path, C.rodsLong_t(opts.Size), C.int(opts.Mode), C.int(force), resource, ccon, &errMsg); status != 0 {
-		col.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Put DataObject Failed: %v, Does the file already exist?", C.GoString(errMsg)))
-	}
-	col.con.ReturnCcon(ccon)
-
-	if err := col.Refresh(); err != nil {
-		return nil, err
-	}
-
-	if do, err := getDataObj(C.GoString(path), col.con); err != nil {
-		return nil, err
-	} else {
-		return do, nil
+	if col.con.fs != nil {
+		return col.fsPut(localPath, opts)
 	}
 
+	return col.cPut(localPath, opts)
 }
 
 // CreateDataObj creates a data object within the collection using the options specified
@@ -0,0 +1,629 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+)
+
+// cCollection holds the C API handle of an opened collection
+type cCollection struct {
+	cColHandle C.collHandle_t
+}
+
+// initCollection initializes collection from *C.collEnt_t. This is used internally in the gorods package.
+func initCollection(data *C.collEnt_t, acol *Collection) (*Collection, error) {
+
+	col := new(Collection)
+
+	col.opened = false
+	col.typ = CollectionType
+	col.col = acol
+	col.con = col.col.con
+	col.path = C.GoString(data.collName)
+	col.options = acol.options
+	col.recursive = acol.recursive
+	col.trimRepls = acol.trimRepls
+	col.parent = acol
+
+	col.ownerName = C.GoString(data.ownerName)
+	col.createTime = cTimeToTime(data.createTime)
+	col.modifyTime = cTimeToTime(data.modifyTime)
+
+	col.name = filepath.Base(col.path)
+
+	if usrs, err := col.con.Users(); err != nil {
+		return nil, err
+	} else {
+		if u := usrs.FindByName(col.ownerName, col.con); u != nil {
+			col.owner = u
+		} else {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS initCollection Failed: Unable to locate user in cache"))
+		}
+	}
+
+	if col.recursive {
+
+		if er := col.init(); er != nil {
+			return nil, er
+		}
+	}
+
+	return col, nil
+}
+
+func (col *Collection) cStat() (map[string]interface{}, error) {
+	var (
+		err        *C.char
+		statResult *C.rodsObjStat_t
+	)
+
+	path := C.CString(col.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := col.con.GetCcon()
+	defer col.con.ReturnCcon(ccon)
+
+	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Stat Failed: %v, %v", col.path, C.GoString(err)))
+	}
+
+	result := make(map[string]interface{})
+
+	result["objSize"] = int(statResult.objSize)
+	result["dataMode"] = int(statResult.dataMode)
+
+	result["dataId"] = C.GoString(&statResult.dataId[0])
+	result["chksum"] = C.GoString(&statResult.chksum[0])
+	result["ownerName"] = C.GoString(&statResult.ownerName[0])
+	result["ownerZone"] = C.GoString(&statResult.ownerZone[0])
+	result["createTime"] = C.GoString(&statResult.createTime[0])
+	result["modifyTime"] = C.GoString(&statResult.modifyTime[0])
+	//result["rescHier"] = C.GoString(&statResult.rescHier[0])
+
+	C.freeRodsObjStat(statResult)
+
+	return result, nil
+}
+
+func cCreateCollection(name string, coll *Collection) (*Collection, error) {
+	var (
+		errMsg *C.char
+	)
+
+	newColPath := coll.path + "/" + name
+	path := C.CString(newColPath)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := coll.con.GetCcon()
+
+	if status := C.gorods_create_collection(path, ccon, &errMsg); status != 0 {
+		coll.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Create Collection Failed: %v, Does the collection already exist?", C.GoString(errMsg)))
+	}
+
+	coll.con.ReturnCcon(ccon)
+
+	//coll.Refresh()
+	//newCol := coll.Cd(name)
+
+	return coll.Con().Collection(CollectionOptions{
+		Path: newColPath,
+	})
+
+}
+
+func (col *Collection) cInheritance() (bool, error) {
+	var (
+		enabled C.int
+		err     *C.char
+	)
+
+	collName := C.CString(col.path)
+	defer C.free(unsafe.Pointer(collName))
+
+	ccon := col.con.GetCcon()
+	defer col.con.ReturnCcon(ccon)
+
+	if status := C.gorods_get_collection_inheritance(ccon, collName, &enabled, &err); status != 0 {
+		return false, newError(Fatal, status, fmt.Sprintf("iRODS Get Collection Inheritance Failed: %v", C.GoString(err)))
+	}
+
+	if int(enabled) > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (col *Collection) cACL() (ACLs, error) {
+	var (
+		result   C.goRodsACLResult_t
+		err      *C.char
+		zoneHint *C.char
+		collName *C.char
+	)
+
+	zone, zErr := col.con.LocalZone()
+	if zErr != nil {
+		return nil, zErr
+	} else {
+		zoneHint = C.CString(zone.Name())
+	}
+
+	collName = C.CString(col.path)
+	defer C.free(unsafe.Pointer(collName))
+	defer C.free(unsafe.Pointer(zoneHint))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_get_collection_acl(ccon, collName, &result, zoneHint, &err); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Collection ACL Failed: %v", C.GoString(err)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	return aclSliceToResponse(&result, zone, col.con)
+}
+
+func (col *Collection) cRm(recursive bool, force bool) error {
+	var errMsg *C.char
+
+	path := C.CString(col.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	var (
+		cForce     C.int
+		cRecursive C.int
+	)
+
+	if force {
+		cForce = C.int(1)
+	}
+
+	if recursive {
+		cRecursive = C.int(1)
+	}
+
+	ccon := col.con.GetCcon()
+	defer col.con.ReturnCcon(ccon)
+
+	if status := C.gorods_rm(path, C.int(1), cRecursive, cForce, C.int(0), ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Rm Collection Failed: %v", C.GoString(errMsg)))
+	}
+
+	return nil
+}
+
+func (col *Collection) cRmTrash() error {
+	var errMsg *C.char
+
+	path := C.CString(col.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := col.con.GetCcon()
+	defer col.con.ReturnCcon(ccon)
+
+	if status := C.gorods_rm(path, C.int(1), C.int(1), C.int(1), C.int(1), ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS RmTrash Collection Failed: %v", C.GoString(errMsg)))
+	}
+
+	return nil
+}
+
+func (col *Collection) cOpen() error {
+	if !col.opened {
+		var (
+			errMsg     *C.char
+			cTrimRepls C.int
+		)
+
+		path := C.CString(col.path)
+
+		if col.trimRepls {
+			cTrimRepls = C.int(1)
+		} else {
+			cTrimRepls = C.int(0)
+		}
+
+		defer C.free(unsafe.Pointer(path))
+
+		ccon := col.con.GetCcon()
+		defer col.con.ReturnCcon(ccon)
+
+		if status := C.gorods_open_collection(path, cTrimRepls, &col.cColHandle, ccon, &errMsg); status != 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS Open Collection Failed: %v, %v", col.path, C.GoString(errMsg)))
+		}
+
+		col.opened = true
+	}
+
+	return nil
+}
+
+func (col *Collection) cClose() error {
+	var errMsg *C.char
+
+	for _, c := range col.dataObjects {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+
+	if col.opened {
+
+		ccon := col.con.GetCcon()
+		defer col.con.ReturnCcon(ccon)
+
+		if status := C.gorods_close_collection(&col.cColHandle, &errMsg); status != 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS Close Collection Failed: %v, %v", col.path, C.GoString(errMsg)))
+		}
+
+		col.opened = false
+	}
+
+	return nil
+}
+
+func (col *Collection) cMoveTo(iRODSCollection interface{}) error {
+	var (
+		err                         *C.char
+		destination                 string
+		destinationCollectionString string
+		destinationCollection       *Collection
+	)
+
+	switch iRODSCollection.(type) {
+	case string:
+		destinationCollectionString = iRODSCollection.(string)
+
+		// Is this a relative path?
+		if destinationCollectionString[0] != '/' {
+			destinationCollectionString = path.Dir(col.path) + "/" + destinationCollectionString
+		}
+
+		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
+			destinationCollectionString += "/"
+		}
+
+		destination += destinationCollectionString + col.name
+	case *Collection:
+		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
+		destination = destinationCollectionString + col.name
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move Collection Failed, unknown variable type passed as collection"))
+	}
+
+	path := C.CString(col.path)
+	dest := C.CString(destination)
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(dest))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_move_dataobject(path, dest, C.RENAME_COLL, ccon, &err); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Move Collection Failed: %v, D:%v, %v", col.path, destination, C.GoString(err)))
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	// Reload source collection, we are now detached... buggy?
+	//col.parent.Refresh()
+
+	// Find & reload destination collection
+	switch iRODSCollection.(type) {
+	case string:
+		var colEr error
+
+		// Can't find, load collection into memory
+		destinationCollection, colEr = col.con.Collection(CollectionOptions{
+			Path:      destinationCollectionString,
+			Recursive: false,
+		})
+		if colEr != nil {
+			return colEr
+		}
+	case *Collection:
+		destinationCollection = (iRODSCollection.(*Collection))
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move Collection Failed, unknown variable type passed as collection"))
+	}
+
+	destinationCollection.Refresh()
+
+	// Reassign obj.col to destination collection
+	col.parent = destinationCollection
+	col.path = destinationCollection.path + "/" + col.name
+
+	col.opened = false
+
+	return nil
+}
+
+func (col *Collection) cRename(newFileName string) error {
+	if strings.Contains(newFileName, "/") {
+		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
+	}
+
+	var err *C.char
+
+	source := col.path
+	destination := path.Dir(col.path) + "/" + newFileName
+
+	s := C.CString(source)
+	d := C.CString(destination)
+
+	defer C.free(unsafe.Pointer(s))
+	defer C.free(unsafe.Pointer(d))
+
+	ccon := col.con.GetCcon()
+	defer col.con.ReturnCcon(ccon)
+
+	if status := C.gorods_move_dataobject(s, d, C.RENAME_COLL, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Rename Collection Failed: %v, %v", col.path, C.GoString(err)))
+	}
+
+	col.name = newFileName
+	col.path = destination
+
+	col.opened = false
+
+	return nil
+}
+
+func (col *Collection) cReadCollectionOpts(opts CollectionReadOpts) (CollectionReadInfo, error) {
+	errInfo := CollectionReadInfo{0, 0, 0, 0, 0, 0}
+	if er := col.Open(); er != nil {
+		return errInfo, er
+	}
+
+	var colTotal, objTotal, colCnt, objCnt int
+	var info CollectionReadInfo
+	var cOpts C.goRodsQueryOpts_t
+
+	cOpts.limit = C.int(opts.Limit)
+	cOpts.offset = C.int(opts.Offset)
+
+	var colEnt C.collEnt_t
+
+	col.dataObjects = make([]IRodsObj, 0)
+
+	ccon := col.con.GetCcon()
+	for int(C.gorods_rclReadCollectionCols(ccon, &col.cColHandle, &colEnt, cOpts)) >= 0 {
+		if newCol, er := initCollection(&colEnt, col); er == nil {
+			col.add(newCol)
+		} else {
+			return errInfo, er
+		}
+	}
+
+	colTotal = int(col.cColHandle.collSqlResult.totalRowCount)
+	colCnt = int(col.cColHandle.collSqlResult.rowCnt)
+
+	C.clearCollSqlResult(&col.cColHandle.collSqlResult)
+
+	newLimit := opts.Limit - colCnt
+	newOffset := opts.Offset
+
+	if colCnt == 0 && colTotal > 0 {
+		newOffset = opts.Offset - colTotal
+	}
+
+	if newLimit == 0 {
+		// We're done, don't grab any objects
+		info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
+		col.readInfo = &info
+
+		col.con.ReturnCcon(ccon)
+
+		return info, col.Close()
+	} else {
+		cOpts.limit = C.int(newLimit)
+		cOpts.offset = C.int(newOffset)
+	}
+
+	for int(C.gorods_rclReadCollectionObjs(ccon, &col.cColHandle, &colEnt, cOpts)) >= 0 {
+		col.add(initDataObj(&colEnt, col, col.con))
+	}
+
+	objTotal = int(col.cColHandle.dataObjSqlResult.totalRowCount)
+	objCnt = int(col.cColHandle.dataObjSqlResult.rowCnt)
+
+	C.clearDataObjSqlResult(&col.cColHandle.dataObjSqlResult)
+
+	col.con.ReturnCcon(ccon)
+
+	info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
+	col.readInfo = &info
+
+	return info, col.Close()
+
+}
+
+func (col *Collection) cReadCollection() error {
+	if er := col.Open(); er != nil {
+		return er
+	}
+
+	var colTotal, objTotal, colCnt, objCnt, limit, offset int
+	var info CollectionReadInfo
+
+	var colEnt C.collEnt_t
+
+	col.dataObjects = make([]IRodsObj, 0)
+
+	if col.readOpts != nil {
+		limit = col.readOpts.Limit
+		offset = col.readOpts.Offset
+	} else {
+		limit = -1
+		offset = -1
+	}
+
+	ccon := col.con.GetCcon()
+
+	col.cColHandle.genQueryInp.options = C.RETURN_TOTAL_ROW_COUNT
+
+	itrInx := 0
+	addCnt := 0
+
+	for int(C.rclReadCollection(ccon, &col.cColHandle, &colEnt)) >= 0 {
+
+		var theObj IRodsObj
+
+		isCollection := (colEnt.objType != C.DATA_OBJ_T)
+
+		if isCollection {
+			if newCol, er := initCollection(&colEnt, col); er == nil {
+				theObj = newCol
+			} else {
+				return er
+			}
+		} else {
+			theObj = initDataObj(&colEnt, col, col.con)
+		}
+
+		if col.readOpts != nil && col.readOpts.Filter != nil {
+			col.con.ReturnCcon(ccon)
+			if !col.readOpts.Filter(theObj) {
+				ccon = col.con.GetCcon()
+				continue
+			} else {
+				ccon = col.con.GetCcon()
+			}
+
+		}
+
+		if colTotal == 0 && objTotal == 0 {
+			colTotal = int(col.cColHandle.collSqlResult.totalRowCount)
+			objTotal = int(col.cColHandle.dataObjSqlResult.totalRowCount)
+		}
+
+		if offset != -1 {
+			if itrInx < offset {
+				itrInx++
+				continue
+			}
+		}
+
+		if limit != -1 {
+			if addCnt == limit {
+				break
+			}
+		}
+
+		col.add(theObj)
+		addCnt++
+
+		if isCollection {
+			colCnt++
+		} else {
+			objCnt++
+		}
+
+		itrInx++
+
+	}
+
+	col.con.ReturnCcon(ccon)
+
+	info = CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
+	col.readInfo = &info
+
+	return col.Close()
+}
+
+func (col *Collection) cPut(localPath string, opts DataObjOptions) (*DataObj, error) {
+	var (
+		errMsg   *C.char
+		force    int
+		resource *C.char
+		chksum   string
+	)
+
+	if opts.ChecksumScheme != "" {
+		alg, err := checksum.ParseAlgorithm(opts.ChecksumScheme)
+		if err != nil {
+			return nil, newError(Fatal, -1, err.Error())
+		}
+
+		sum, err := checksum.File(alg, localPath)
+		if err != nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v", err))
+		}
+
+		chksum = sum.String()
+	}
+
+	if opts.Force {
+		force = 1
+	} else {
+		force = 0
+	}
+
+	if opts.Resource != nil {
+		switch opts.Resource.(type) {
+		case string:
+			resource = C.CString(opts.Resource.(string))
+		case *Resource:
+			r := opts.Resource.(*Resource)
+			resource = C.CString(r.Name())
+		default:
+			return nil, newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
+		}
+	} else {
+		resource = C.CString("")
+	}
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(localPath)
+	}
+
+	path := C.CString(col.path + "/" + opts.Name)
+	cLocalPath := C.CString(localPath)
+	cChksum := C.CString(chksum)
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(resource))
+	defer C.free(unsafe.Pointer(cLocalPath))
+	defer C.free(unsafe.Pointer(cChksum))
+
+	ccon := col.con.GetCcon()
+
+	if status := C.gorods_put_dataobject(cLocalPath, path, C.rodsLong_t(opts.Size), C.int(opts.Mode), C.int(force), resource, cChksum, ccon, &errMsg); status != 0 {
+		col.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Put DataObject Failed: %v, Does the file already exist?", C.GoString(errMsg)))
+	}
+	col.con.ReturnCcon(ccon)
+
+	if err := col.Refresh(); err != nil {
+		return nil, err
+	}
+
+	if do, err := getDataObj(C.GoString(path), col.con); err != nil {
+		return nil, err
+	} else {
+		return do, nil
+	}
+
+}
@@ -0,0 +1,442 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// statMap returns the keys of Collection.Stat and DataObj.Stat for an irodsfs.ObjInfo
+func statMap(info irodsfs.ObjInfo) map[string]interface{} {
+	ownerName, ownerZone := SplitPrincipal(info.Owner)
+
+	return map[string]interface{}{
+		"objSize":    int(info.Size),
+		"dataMode":   0,
+		"dataId":     strconv.FormatInt(info.Id, 10),
+		"chksum":     info.Checksum,
+		"ownerName":  ownerName,
+		"ownerZone":  ownerZone,
+		"createTime": fmt.Sprintf("%011d", info.CreateTime.Unix()),
+		"modifyTime": fmt.Sprintf("%011d", info.ModifyTime.Unix()),
+	}
+}
+
+// fsOwner resolves the name#zone owner of an irodsfs.ObjInfo
+func fsOwner(con *Connection, owner string) (string, *User, error) {
+	name, zone := SplitPrincipal(owner)
+
+	usrs, err := con.Users()
+	if err != nil {
+		return name, nil, err
+	}
+
+	return name, usrs.FindByNameAndZone(name, zone, con), nil
+}
+
+// fsDestination returns the collection and the path iRODSCollection (a string, relative to base, or a *Collection)
+// designates for name, like the C versions of MoveTo and CopyTo
+func fsDestination(iRODSCollection interface{}, base string, name string, op string) (string, string, error) {
+	switch dest := iRODSCollection.(type) {
+	case string:
+		// Is this a relative path?
+		if dest == "" || dest[0] != '/' {
+			dest = base + "/" + dest
+		}
+
+		if dest[len(dest)-1] != '/' {
+			dest += "/"
+		}
+
+		return dest, dest + name, nil
+	case *Collection:
+		return dest.path + "/", dest.path + "/" + name, nil
+	}
+
+	return "", "", newError(Fatal, -1, fmt.Sprintf("iRODS %v Failed, unknown variable type passed as collection", op))
+}
+
+// fsDestinationCollection returns the destination collection of MoveTo and CopyTo, loading it when it was given
+// as a string
+func fsDestinationCollection(con *Connection, iRODSCollection interface{}, destinationCollectionString string) (*Collection, error) {
+	if destinationCollection, ok := iRODSCollection.(*Collection); ok {
+		return destinationCollection, nil
+	}
+
+	// Can't find, load collection into memory
+	return con.Collection(CollectionOptions{
+		Path:      destinationCollectionString,
+		Recursive: false,
+	})
+}
+
+// fsInitCollection is initCollection for the entries of FilesystemDefined connections
+func fsInitCollection(info irodsfs.ObjInfo, acol *Collection) (*Collection, error) {
+	col := new(Collection)
+
+	col.opened = false
+	col.typ = CollectionType
+	col.col = acol
+	col.con = acol.con
+	col.path = info.Path
+	col.options = acol.options
+	col.recursive = acol.recursive
+	col.trimRepls = acol.trimRepls
+	col.parent = acol
+
+	col.createTime = info.CreateTime
+	col.modifyTime = info.ModifyTime
+
+	col.name = filepath.Base(col.path)
+
+	if name, u, err := fsOwner(col.con, info.Owner); err != nil {
+		return nil, err
+	} else if u == nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS initCollection Failed: Unable to locate user in cache"))
+	} else {
+		col.ownerName = name
+		col.owner = u
+	}
+
+	if col.recursive {
+		if er := col.init(); er != nil {
+			return nil, er
+		}
+	}
+
+	return col, nil
+}
+
+func (col *Collection) fsStat() (map[string]interface{}, error) {
+	info, err := col.con.fs.Stat(col.path)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Stat Failed: %v, %v", col.path, err))
+	}
+
+	return statMap(info), nil
+}
+
+func fsCreateCollection(name string, coll *Collection) (*Collection, error) {
+	newColPath := coll.path + "/" + name
+
+	if err := coll.con.fs.Mkdir(newColPath, false); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Create Collection Failed: %v, Does the collection already exist?", err))
+	}
+
+	return coll.Con().Collection(CollectionOptions{
+		Path: newColPath,
+	})
+}
+
+func (col *Collection) fsInheritance() (bool, error) {
+	inherits, err := col.con.fs.Inheritance(col.path)
+	if err != nil {
+		return false, newError(Fatal, -1, fmt.Sprintf("iRODS Inheritance Failed: %v, %v", col.path, err))
+	}
+
+	return inherits, nil
+}
+
+// fsOpen checks the collection exists, FilesystemDefined connections have no collection handles
+func (col *Collection) fsOpen() error {
+	if !col.opened {
+		info, err := col.con.fs.Stat(col.path)
+		if err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Open Collection Failed: %v, %v", col.path, err))
+		}
+
+		if !info.IsDir() {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Open Collection Failed: %v, not a collection", col.path))
+		}
+
+		col.opened = true
+	}
+
+	return nil
+}
+
+func (col *Collection) fsClose() error {
+	for _, c := range col.dataObjects {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+
+	col.opened = false
+
+	return nil
+}
+
+func (col *Collection) fsMoveTo(iRODSCollection interface{}) error {
+	destinationCollectionString, destination, err := fsDestination(iRODSCollection, path.Dir(col.path), col.name, "Move Collection")
+	if err != nil {
+		return err
+	}
+
+	if err := col.con.fs.Rename(col.path, destination); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move Collection Failed: %v, D:%v, %v", col.path, destination, err))
+	}
+
+	destinationCollection, err := fsDestinationCollection(col.con, iRODSCollection, destinationCollectionString)
+	if err != nil {
+		return err
+	}
+
+	destinationCollection.Refresh()
+
+	// Reassign obj.col to destination collection
+	col.parent = destinationCollection
+	col.path = destinationCollection.path + "/" + col.name
+
+	col.opened = false
+
+	return nil
+}
+
+func (col *Collection) fsRename(newFileName string) error {
+	if strings.Contains(newFileName, "/") {
+		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
+	}
+
+	destination := path.Dir(col.path) + "/" + newFileName
+
+	if err := col.con.fs.Rename(col.path, destination); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rename Collection Failed: %v, %v", col.path, err))
+	}
+
+	col.name = newFileName
+	col.path = destination
+
+	col.opened = false
+
+	return nil
+}
+
+// fsList returns the collections, then the data objects of the collection, like rclReadCollection. Data
+// objects are listed once per replica when the replicas aren't trimmed and the Filesystem has them.
+func (col *Collection) fsList() (objs IRodsObjs, colTotal int, objTotal int, err error) {
+	infos, er := col.con.fs.List(col.path)
+	if er != nil {
+		return nil, 0, 0, newError(Fatal, -1, fmt.Sprintf("iRODS Read Collection Failed: %v, %v", col.path, er))
+	}
+
+	rfs, withRepls := col.con.fs.(irodsfs.ReplicaFS)
+	withRepls = withRepls && !col.trimRepls
+
+	var dataObjs IRodsObjs
+
+	for _, info := range infos {
+		if info.IsDir() {
+			newCol, er := fsInitCollection(info, col)
+			if er != nil {
+				return nil, 0, 0, er
+			}
+
+			objs = append(objs, newCol)
+			colTotal++
+
+			continue
+		}
+
+		if !withRepls {
+			dataObjs = append(dataObjs, fsInitDataObj(info, nil, col, col.con))
+			continue
+		}
+
+		repls, er := rfs.Replicas(info.Path)
+		if er != nil {
+			return nil, 0, 0, newError(Fatal, -1, fmt.Sprintf("iRODS Read Collection Failed: %v, %v", info.Path, er))
+		}
+
+		for inx := range repls {
+			dataObjs = append(dataObjs, fsInitDataObj(info, &repls[inx], col, col.con))
+		}
+	}
+
+	objTotal = len(dataObjs)
+
+	return append(objs, dataObjs...), colTotal, objTotal, nil
+}
+
+func (col *Collection) fsReadCollectionOpts(opts CollectionReadOpts) (CollectionReadInfo, error) {
+	errInfo := CollectionReadInfo{0, 0, 0, 0, 0, 0}
+	if er := col.Open(); er != nil {
+		return errInfo, er
+	}
+
+	objs, colTotal, objTotal, err := col.fsList()
+	if err != nil {
+		return errInfo, err
+	}
+
+	var colCnt, objCnt int
+
+	col.dataObjects = make([]IRodsObj, 0)
+
+	for inx, obj := range objs {
+		if inx < opts.Offset {
+			continue
+		}
+
+		if opts.Limit > 0 && colCnt+objCnt == opts.Limit {
+			break
+		}
+
+		col.add(obj)
+
+		if obj.Type() == CollectionType {
+			colCnt++
+		} else {
+			objCnt++
+		}
+	}
+
+	info := CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
+	col.readInfo = &info
+
+	return info, col.Close()
+}
+
+func (col *Collection) fsReadCollection() error {
+	if er := col.Open(); er != nil {
+		return er
+	}
+
+	objs, colTotal, objTotal, err := col.fsList()
+	if err != nil {
+		return err
+	}
+
+	var colCnt, objCnt, limit, offset int
+
+	col.dataObjects = make([]IRodsObj, 0)
+
+	if col.readOpts != nil {
+		limit = col.readOpts.Limit
+		offset = col.readOpts.Offset
+	} else {
+		limit = -1
+		offset = -1
+	}
+
+	itrInx := 0
+	addCnt := 0
+
+	for _, obj := range objs {
+		if col.readOpts != nil && col.readOpts.Filter != nil && !col.readOpts.Filter(obj) {
+			continue
+		}
+
+		if offset != -1 {
+			if itrInx < offset {
+				itrInx++
+				continue
+			}
+		}
+
+		if limit != -1 {
+			if addCnt == limit {
+				break
+			}
+		}
+
+		col.add(obj)
+		addCnt++
+
+		if obj.Type() == CollectionType {
+			colCnt++
+		} else {
+			objCnt++
+		}
+
+		itrInx++
+	}
+
+	info := CollectionReadInfo{colCnt, objCnt, (colCnt + objCnt), colTotal, objTotal, (colTotal + objTotal)}
+	col.readInfo = &info
+
+	return col.Close()
+}
+
+// fsPut uploads the local file. With opts.ChecksumScheme the upload is read back and hashed with that scheme, a
+// data object that doesn't match the local file is removed.
+func (col *Collection) fsPut(localPath string, opts DataObjOptions) (*DataObj, error) {
+	var (
+		alg    checksum.Algorithm
+		chksum checksum.Checksum
+	)
+
+	if opts.ChecksumScheme != "" {
+		var err error
+
+		alg, err = checksum.ParseAlgorithm(opts.ChecksumScheme)
+		if err != nil {
+			return nil, newError(Fatal, -1, err.Error())
+		}
+
+		chksum, err = checksum.File(alg, localPath)
+		if err != nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v", err))
+		}
+	}
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(localPath)
+	}
+
+	p := col.path + "/" + opts.Name
+
+	local, err := os.Open(localPath)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v", err))
+	}
+
+	defer local.Close()
+
+	if err := col.con.fsCreate(p, local, opts); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v, Does the file already exist?", err))
+	}
+
+	if opts.ChecksumScheme != "" {
+		if err := col.con.fsVerifyUpload(p, alg, chksum); err != nil {
+			col.con.fs.Remove(p, false)
+			return nil, err
+		}
+	}
+
+	if err := col.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return getDataObj(p, col.con)
+}
+
+// fsVerifyUpload reads the data object p back, and compares its alg checksum with sum
+func (con *Connection) fsVerifyUpload(p string, alg checksum.Algorithm, sum checksum.Checksum) error {
+	file, err := con.fs.Open(p, os.O_RDONLY)
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v, %v", p, err))
+	}
+
+	defer file.Close()
+
+	uploaded, err := checksum.Compute(alg, file)
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v, %v", p, err))
+	}
+
+	if !uploaded.Equal(sum) {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Put DataObject Failed: %v, checksum mismatch, %v uploaded as %v", p, sum, uploaded))
+	}
+
+	return nil
+}
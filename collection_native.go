@@ -0,0 +1,62 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// cCollection holds the C API handle of an opened collection in cgo builds
+type cCollection struct{}
+
+func (col *Collection) cStat() (map[string]interface{}, error) {
+	return nil, errNoC
+}
+
+func cCreateCollection(name string, coll *Collection) (*Collection, error) {
+	return nil, errNoC
+}
+
+func (col *Collection) cInheritance() (bool, error) {
+	return false, errNoC
+}
+
+func (col *Collection) cACL() (ACLs, error) {
+	return nil, errNoC
+}
+
+func (col *Collection) cRm(recursive bool, force bool) error {
+	return errNoC
+}
+
+func (col *Collection) cRmTrash() error {
+	return errNoC
+}
+
+func (col *Collection) cOpen() error {
+	return errNoC
+}
+
+func (col *Collection) cClose() error {
+	return errNoC
+}
+
+func (col *Collection) cMoveTo(iRODSCollection interface{}) error {
+	return errNoC
+}
+
+func (col *Collection) cRename(newFileName string) error {
+	return errNoC
+}
+
+func (col *Collection) cReadCollectionOpts(opts CollectionReadOpts) (CollectionReadInfo, error) {
+	return CollectionReadInfo{}, errNoC
+}
+
+func (col *Collection) cReadCollection() error {
+	return errNoC
+}
+
+func (col *Collection) cPut(localPath string, opts DataObjOptions) (*DataObj, error) {
+	return nil, errNoC
+}
@@ -0,0 +1,46 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package connect opens an irodsfs.Filesystem with the client selected at build time: the cgo
+// client of package gorods by default, or the pure Go client of package native when building
+// with the gorods_native tag or without cgo:
+//
+//	go build -tags gorods_native ./...
+//	CGO_ENABLED=0 go build ./...
+//
+// Programs written against irodsfs and connect build either way without changes:
+//
+//	con, err := connect.Dial(connect.Options{Environment: true, Password: os.Getenv("IRODS_PASSWORD")})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer con.Disconnect()
+//
+//	list, err := con.List("/tempZone/home/rods")
+//
+// The tag also selects the client of package gorods: gorods.New and NewConnection connect with
+// the pure Go client in those builds.
+package connect
+
+import "github.com/jjacquay712/GoRODS/irodsfs"
+
+// Options describes the connection. With Environment set, Host, Port, Zone and Username are
+// read from ~/.irods/irods_environment.json, like gorods.EnvironmentDefined. Ticket is used for
+// the requests of the connection when it's set (-t of icommands).
+type Options struct {
+	Environment bool
+	Host        string
+	Port        int
+	Zone        string
+	Username    string
+	Password    string
+	Ticket      string
+}
+
+// Conn is an open connection to an iRODS zone
+type Conn interface {
+	irodsfs.Filesystem
+
+	// Disconnect closes the connection
+	Disconnect() error
+}
@@ -0,0 +1,64 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package connect
+
+import (
+	"github.com/jjacquay712/GoRODS"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Native reports whether Dial uses the pure Go client
+const Native = false
+
+// replicaFilesystem is the irodsfs adapter of a gorods.Connection, which also manages and
+// registers replicas
+type replicaFilesystem interface {
+	irodsfs.Filesystem
+	irodsfs.ReplicaFS
+	irodsfs.RegisterFS
+}
+
+// cgoConn pairs a gorods.Connection with its irodsfs adapter
+type cgoConn struct {
+	replicaFilesystem
+	con *gorods.Connection
+}
+
+func (c *cgoConn) Disconnect() error {
+	return c.con.Disconnect()
+}
+
+// Dial connects with the cgo client of package gorods
+func Dial(opts Options) (Conn, error) {
+	conOpts := &gorods.ConnectionOptions{
+		Type:     gorods.UserDefined,
+		Host:     opts.Host,
+		Port:     opts.Port,
+		Zone:     opts.Zone,
+		Username: opts.Username,
+		Password: opts.Password,
+		Ticket:   opts.Ticket,
+	}
+
+	if opts.Environment {
+		conOpts = &gorods.ConnectionOptions{
+			Type:     gorods.EnvironmentDefined,
+			Password: opts.Password,
+			Ticket:   opts.Ticket,
+		}
+	}
+
+	con, err := gorods.NewConnection(conOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cgoConn{
+		replicaFilesystem: con.Filesystem().(replicaFilesystem),
+		con:               con,
+	}, nil
+}
@@ -0,0 +1,35 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package connect
+
+import "github.com/jjacquay712/GoRODS/native"
+
+// Native reports whether Dial uses the pure Go client
+const Native = true
+
+// Dial connects with the pure Go client of package native
+func Dial(opts Options) (Conn, error) {
+	nativeOpts := native.Options{
+		Host:     opts.Host,
+		Port:     opts.Port,
+		Zone:     opts.Zone,
+		Username: opts.Username,
+	}
+
+	if opts.Environment {
+		var err error
+
+		if nativeOpts, err = native.LoadEnvironment(""); err != nil {
+			return nil, err
+		}
+	}
+
+	nativeOpts.Password = opts.Password
+	nativeOpts.Ticket = opts.Ticket
+
+	return native.Dial(nativeOpts)
+}
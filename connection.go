@@ -2,33 +2,29 @@
  *** For more information please refer to the LICENSE.md file                                   ***/
 
 // Package gorods is a Golang binding for the iRODS C API (iRODS client library).
-// GoRods uses cgo to call iRODS client functions.
+// GoRods uses cgo to call iRODS client functions. Built with the gorods_native tag or without cgo, it
+// connects with the pure Go client of package native instead, see NewConnection.
 package gorods
 
-// #cgo CFLAGS: -ggdb -I/usr/include/irods
-// #cgo LDFLAGS: -Wl,-rpath,"/opt/irods-externals/boost1.60.0-0/lib" -Wl,-rpath,"/opt/irods-externals/clang3.8-0/lib" -L/opt/irods-externals/clang3.8-0/lib -L/opt/irods-externals/boost1.60.0-0/lib /opt/irods-externals/boost1.60.0-0/lib/libboost_system.a /opt/irods-externals/boost1.60.0-0/lib/libboost_chrono.a /opt/irods-externals/jansson2.7-0/lib/libjansson.a -lirods_common -lirods_client -lc++ -lc++abi -lboost_regex -lboost_program_options -lboost_thread -lboost_filesystem -lz -lssl -lcrypto -ldl -lpthread -lm -lrt -lstdc++ -rdynamic -Wno-write-strings -DBOOST_SYSTEM_NO_DEPRECATED
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
-	"math"
 	"os"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
-	"unsafe"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
 )
 
-// EnvironmentDefined and UserDefined constants are used when calling
+// EnvironmentDefined, UserDefined and FilesystemDefined constants are used when calling
 // gorods.New(ConnectionOptions{ Type: ... })
 // When EnvironmentDefined is specified, the options stored in ~/.irods/irods_environment.json will be used.
 // When UserDefined is specified you must also pass Host, Port, Username, and Zone.
 // Password should be set regardless.
+// When FilesystemDefined is specified, the calls are served by the irodsfs.Filesystem passed in Filesystem.
 const (
 	EnvironmentDefined = iota
 	UserDefined
+	FilesystemDefined
 )
 
 // Used when calling Type() on different gorods objects
@@ -55,6 +51,20 @@ const (
 	Remote
 	PAMAuth
 	PasswordAuth
+
+	// Granular access levels of iRODS 4.3, see AccessLevels for their order
+	ReadMetadata
+	CreateMetadata
+	ModifyMetadata
+	DeleteMetadata
+	CreateObject
+	DeleteObject
+)
+
+// Names of the Read and Write access levels in iRODS 4.3
+const (
+	ReadObject   = Read
+	ModifyObject = Write
 )
 
 type MetaObj interface {
@@ -230,17 +240,16 @@ func findRecursiveHelper(objs IRodsObjs, path string) IRodsObj {
 }
 
 func chmod(obj IRodsObj, user string, accessLevel int, recursive bool, includeZone bool) error {
-	var (
-		err        *C.char
-		cRecursive C.int
-		zone       string
-	)
+	var zone string
 
-	if accessLevel != Null && accessLevel != Read && accessLevel != Write && accessLevel != Own && accessLevel != Inherit && accessLevel != NoInherit {
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Chmod DataObject Failed: accessLevel must be Null | Read | Write | Own"))
+	if !isAccessLevel(accessLevel) && accessLevel != Inherit && accessLevel != NoInherit {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Chmod DataObject Failed: accessLevel must be one of AccessLevels(), Inherit or NoInherit"))
 	}
 
-	if includeZone {
+	// Principals of federated zones are given as name#zone
+	user, zone = SplitPrincipal(user)
+
+	if includeZone && zone == "" {
 		if z, err := obj.Con().LocalZone(); err == nil {
 			zone = z.Name()
 		} else {
@@ -248,29 +257,11 @@ func chmod(obj IRodsObj, user string, accessLevel int, recursive bool, includeZo
 		}
 	}
 
-	cUser := C.CString(user)
-	cPath := C.CString(obj.Path())
-	cZone := C.CString(zone)
-	cAccessLevel := C.CString(getTypeString(accessLevel))
-	defer C.free(unsafe.Pointer(cUser))
-	defer C.free(unsafe.Pointer(cPath))
-	defer C.free(unsafe.Pointer(cZone))
-	defer C.free(unsafe.Pointer(cAccessLevel))
-
-	if recursive {
-		cRecursive = C.int(1)
-	} else {
-		cRecursive = C.int(0)
+	if obj.Con().fs != nil {
+		return fsChmod(obj, user, zone, accessLevel, recursive)
 	}
 
-	ccon := obj.Con().GetCcon()
-	defer obj.Con().ReturnCcon(ccon)
-
-	if status := C.gorods_chmod(ccon, cPath, cZone, cUser, cAccessLevel, cRecursive, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Chmod DataObject Failed: %v", C.GoString(err)))
-	}
-
-	return nil
+	return cChmod(obj, user, zone, accessLevel, recursive)
 }
 
 // ConnectionOptions are used when creating iRODS iCAT server connections see gorods.New() docs for more info.
@@ -288,6 +279,10 @@ type ConnectionOptions struct {
 	Ticket        string
 	FastInit      bool
 	Threads       int
+
+	// Filesystem serves the calls of FilesystemDefined connections, like the in-memory zone of
+	// package gorodstest. Username and Zone default to those of its Username() and Zone() methods.
+	Filesystem irodsfs.Filesystem
 }
 
 func (conOpts *ConnectionOptions) String() string {
@@ -296,25 +291,41 @@ func (conOpts *ConnectionOptions) String() string {
 
 // Connection structs hold information about the iRODS iCAT server, and the user who's connecting. It also contains a cache of opened Collections and DataObjs
 type Connection struct {
-	ccon       *C.rcComm_t
-	cconBuffer chan *C.rcComm_t
-	users      Users
-	groups     Groups
-	zones      Zones
-	resources  Resources
+	cConnection
+
+	users     Users
+	groups    Groups
+	zones     Zones
+	resources Resources
 
 	PAMToken   string
 	Connected  bool
 	Init       bool
 	Options    *ConnectionOptions
 	OpenedObjs IRodsObjs
+
+	// objsLock guards OpenedObjs, fsLock serializes the calls of the irodsfs adapters
+	objsLock sync.Mutex
+	fsLock   sync.Mutex
+
+	// fs serves the calls of FilesystemDefined connections, and of all connections in builds without the C API.
+	// The C API is used when it's nil. disconnect closes the pure Go client of those builds.
+	fs         irodsfs.Filesystem
+	disconnect func() error
+	threads    int
 }
 
-// NewConnection creates a connection to an iRODS iCAT server. EnvironmentDefined and UserDefined
+// NewConnection creates a connection to an iRODS iCAT server. EnvironmentDefined, UserDefined and FilesystemDefined
 // constants are used in ConnectionOptions{ Type: ... }).
 // When EnvironmentDefined is specified, the options stored in ~/.irods/irods_environment.json will be used.
 // When UserDefined is specified you must also pass Host, Port, Username, and Zone. Password
 // should be set unless using an anonymous user account with tickets.
+// When FilesystemDefined is specified, Filesystem serves the calls instead of an iCAT server.
+//
+// Built with the gorods_native tag or without cgo, EnvironmentDefined and UserDefined connections use the pure
+// Go client of package native, which serves the calls like a Filesystem. It authenticates with Password only:
+// PAM and the password file of iinit aren't supported. Registration, specific queries, bundles and user and
+// group administration return errors.
 func NewConnection(opts *ConnectionOptions) (*Connection, error) {
 	con := new(Connection)
 
@@ -329,31 +340,11 @@ func NewConnection(opts *ConnectionOptions) (*Connection, error) {
 }
 
 func (con *Connection) UserInfo() (map[string]string, error) {
-
-	var cUsrInfo C.userInfo_t
-	var cErr *C.char
-
-	cName := C.CString(con.Options.Username)
-	defer C.free(unsafe.Pointer(cName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_iuserinfo(ccon, cName, &cUsrInfo, &cErr); status < 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS gorods_iuserinfo Failed: %v", C.GoString(cErr)))
+	if con.fs != nil {
+		return con.fsUserInfo()
 	}
 
-	response := make(map[string]string)
-
-	response["username"] = C.GoString(&cUsrInfo.userName[0])
-	response["zone"] = C.GoString(&cUsrInfo.rodsZone[0])
-	response["type"] = C.GoString(&cUsrInfo.userType[0])
-	response["info"] = C.GoString(&cUsrInfo.userOtherInfo.userInfo[0])
-	response["comments"] = C.GoString(&cUsrInfo.userOtherInfo.userComments[0])
-	response["create"] = C.GoString(&cUsrInfo.userOtherInfo.userCreate[0])
-	response["modify"] = C.GoString(&cUsrInfo.userOtherInfo.userModify[0])
-
-	return response, nil
+	return con.cUserInfo()
 }
 
 func (con *Connection) InitCon() error {
@@ -365,260 +356,20 @@ func (con *Connection) InitCon() error {
 		// Should the con.Options.PAMToken be reset here?
 	}
 
-	var (
-		status    C.int
-		errMsg    *C.char
-		ipassword *C.char
-		opassword *C.char
-	)
-
-	// Are we passing env values?
-	if con.Options.Type == UserDefined {
-		host := C.CString(con.Options.Host)
-		port := C.int(con.Options.Port)
-		username := C.CString(con.Options.Username)
-		zone := C.CString(con.Options.Zone)
-
-		defer C.free(unsafe.Pointer(host))
-		defer C.free(unsafe.Pointer(username))
-		defer C.free(unsafe.Pointer(zone))
-
-		// BUG(jjacquay712): iRODS C API code outputs errors messages, need to implement connect wrapper (gorods_connect_env) from a lower level to suppress this output
-		// https://github.com/irods/irods/blob/master/iRODS/lib/core/src/rcConnect.cpp#L109
-		if status = C.gorods_connect_env(&con.ccon, host, port, username, zone, &errMsg); status != 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
-		}
-	} else {
-
-		var cHost, cUsername, cZone *C.char
-		var cPort C.int
-
-		if status = C.gorods_connect(&con.ccon, &cHost, &cPort, &cUsername, &cZone, &errMsg); status != 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
-		}
-
-		con.Options.Host = C.GoString(cHost)
-		con.Options.Port = int(cPort)
-		con.Options.Username = C.GoString(cUsername)
-		con.Options.Zone = C.GoString(cZone)
-	}
-
-	con.cconBuffer = make(chan *C.rcComm_t, 1)
-	con.cconBuffer <- con.ccon
-
-	con.Connected = true
-
-	ipassword = C.CString(con.Options.Password)
-	defer C.free(unsafe.Pointer(ipassword))
-
-	if con.Options.AuthType == 0 {
-		con.Options.AuthType = PasswordAuth // Options: PasswordAuth PAMAuth
-	}
-
-	if con.Options.PAMPassExpire == 0 {
-		con.Options.PAMPassExpire = 1 // Default expiration: 1 hour
-	}
-
-	var (
-		pamPassFile *os.File
-		pamFileErr  error
-		size        int64
-	)
-
-	if con.Options.AuthType == PAMAuth {
-
-		// Was a PAM token passed?
-		if con.Options.PAMToken != "" {
-
-			// Use it, pass directly to clientLoginWithPassword
-			opassword = C.CString(con.Options.PAMToken)
-			defer C.free(unsafe.Pointer(opassword))
-
-		} else if con.Options.PAMPassFile == "" { // Continue with auth using .Password (ipassword) option, Check to see if PAMPassFile option is not set
-
-			// It's not, fetch password and just keep in memory
-			if status = C.gorods_clientLoginPam(con.ccon, ipassword, C.int(con.Options.PAMPassExpire), &opassword, &errMsg); status != 0 {
-				return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginPam error, invalid password?"))
-			}
-
-			defer C.free(unsafe.Pointer(opassword))
-
-		} else { // There is a PAM file path set, save password to FS for subsequent use
-
-			// Does the file/dir exist?
-			if finfo, err := os.Stat(con.Options.PAMPassFile); err == nil {
-				if !finfo.IsDir() {
-					// Open file here
-					pamPassFile, pamFileErr = os.OpenFile(con.Options.PAMPassFile, os.O_RDWR, 0666)
-					if pamFileErr != nil {
-						return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem opening PAMPassFile at %v", con.Options.PAMPassFile))
-					}
-
-					size = finfo.Size()
-
-				} else {
-					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: PAMPassFile is a directory durp"))
-				}
-			} else {
-				// Create file here
-				pamPassFile, pamFileErr = os.Create(con.Options.PAMPassFile)
-				if pamFileErr != nil {
-					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem creating PAMPassFile at %v", con.Options.PAMPassFile))
-				}
-
-			}
-
-			// Is this an old password file?
-			if size > 0 {
-
-				fileBtz := make([]byte, size)
-				if _, er := pamPassFile.Read(fileBtz); er != nil {
-					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem reading PAMPassFile at %v", con.Options.PAMPassFile))
-				}
-
-				fileStr := string(fileBtz)
-				fileSplit := strings.Split(fileStr, ":")
-				unixTimeStamp, _ := strconv.Atoi(fileSplit[0])
-				pamPassword := fileSplit[1]
-
-				now := int(time.Now().Unix())
-
-				// Check to see if the password has expired
-				if (unixTimeStamp + (con.Options.PAMPassExpire * 60)) <= now {
-					// we're expired, refresh
-					opassword, pamFileErr = con.fetchAndWritePAMPass(pamPassFile, ipassword)
-					if pamFileErr != nil {
-						return pamFileErr
-					}
-				} else {
-					// It's still good, use it
-					opassword = C.CString(pamPassword)
-				}
-				defer C.free(unsafe.Pointer(opassword))
-
-			} else {
-
-				// Nope, it's new. Write to the file
-				opassword, pamFileErr = con.fetchAndWritePAMPass(pamPassFile, ipassword)
-				if pamFileErr != nil {
-					return pamFileErr
-				}
-
-				defer C.free(unsafe.Pointer(opassword))
-			}
-		}
-	} else if con.Options.AuthType == PasswordAuth {
-		opassword = ipassword
-	}
-
-	if status = C.clientLoginWithPassword(con.ccon, opassword); status != 0 {
-
-		// if status == C.CAT_PASSWORD_EXPIRED {
-		// 	fmt.Printf("expired:%v\n", pamPassFile.Name())
-		// }
-
-		if con.Options.AuthType == PAMAuth {
-
-			// TODO
-			// If PAM token is set, and error is password expires, reset token, reinit?
-
-			if pamPassFile != nil {
-
-				// Failure, clear out file for another try.
-				if er := pamPassFile.Truncate(int64(0)); er != nil {
-					return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: Unable to truncate PAMPassFile: %v", er))
-				}
-
-				return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginWithPassword error, expired password?"))
-			}
-		}
-
-		return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginWithPassword error, invalid password?"))
-	}
-
-	if con.Options.AuthType == PAMAuth {
-		con.PAMToken = C.GoString(opassword)
-	}
-
-	if status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
-	}
-
-	con.SetThreads(con.Options.Threads)
-
-	if con.Options.Ticket != "" {
-		if err := con.SetTicket(con.Options.Ticket); err != nil {
-			return err
-		}
+	if con.Options.Type == FilesystemDefined {
+		return con.initFS(con.Options.Filesystem)
 	}
 
-	if !con.Options.FastInit {
-		if err := con.init(); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// fetchAndWritePAMPass attempts to authenticate with the iCAT server using PAM.
-// If PAM authentication is successful, it writes the returned PAM authentication token to a file for subsequent use in connections.
-func (con *Connection) fetchAndWritePAMPass(pamPassFile *os.File, ipassword *C.char) (*C.char, error) {
-
-	var (
-		opassword *C.char
-		errMsg    *C.char
-	)
-
-	if status := C.gorods_clientLoginPam(con.ccon, ipassword, C.int(con.Options.PAMPassExpire), &opassword, &errMsg); status != 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginPam error, invalid password?"))
-	}
-
-	if er := pamPassFile.Truncate(0); er != nil {
-		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Unable to write new password to PAMPassFile"))
-	}
-
-	pamPassFormat := strconv.Itoa(int(time.Now().Unix())) + ":" + C.GoString(opassword)
-
-	if _, er := pamPassFile.WriteString(pamPassFormat); er != nil {
-		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Unable to write new password to PAMPassFile"))
-	}
-
-	return opassword, nil
-}
-
-// GetCcon checks out the connection handle for use in all iRODS operations. Basically a mutex for connections.
-// Other goroutines calling this function will block until the handle is returned with con.ReturnCcon, by the goroutine using it.
-// This prevents errors in the net code since concurrent API calls aren't supported over a single iRODS connection.
-func (con *Connection) GetCcon() *C.rcComm_t {
-	return <-con.cconBuffer
-}
-
-// ReturnCcon returns the connection handle for use in other threads. Unlocks the mutex.
-func (con *Connection) ReturnCcon(ccon *C.rcComm_t) {
-	con.cconBuffer <- ccon
+	return con.connect()
 }
 
 // SetTicket is equivalent to using the -t flag with icommands
 func (con *Connection) SetTicket(t string) error {
-	var (
-		status C.int
-		errMsg *C.char
-	)
-
-	con.Options.Ticket = t
-
-	ticket := C.CString(t)
-	defer C.free(unsafe.Pointer(ticket))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status = C.gorods_set_session_ticket(ccon, ticket, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Set Ticket Failed: %v", C.GoString(errMsg)))
+	if con.fs != nil {
+		return con.fsSetTicket(t)
 	}
 
-	return nil
+	return con.cSetTicket(t)
 }
 
 // EmptyTrash
@@ -648,16 +399,18 @@ func (con *Connection) EmptyTrash() error {
 		GetRepls:  false,
 		SkipCache: true,
 	}); cErr == nil {
+		defer trashCol.Close()
+
 		return trashCol.Each(func(obj IRodsObj) error {
 			return obj.RmTrash()
 		})
 	} else {
 		return cErr
 	}
-
-	return nil
 }
 
+// RegOptions store the options of RegPhysObj. Checksum registers the checksum computed by the server (ireg -k),
+// VerifyChecksum is a checksum the server verifies the file against before registering it (ireg -K).
 type RegOptions struct {
 	PhysicalFilePath string
 	RodsPath         string
@@ -665,141 +418,63 @@ type RegOptions struct {
 	Replica          bool
 	Resource         interface{}
 	ExcludeFiles     string
+	Checksum         bool
+	VerifyChecksum   string
 }
 
 // RegPhysObj is equivalent to the ireg icommand
 func (con *Connection) RegPhysObj(opts RegOptions) error {
-	var (
-		cPhysPath     *C.char
-		cRodsPath     *C.char
-		cForce        C.int
-		cCollection   C.int
-		cReplica      C.int
-		cResourceName *C.char
-		cExcludeFiles *C.char
-	)
-
-	if opts.PhysicalFilePath == "" || opts.RodsPath == "" {
-		return newError(Fatal, -1, fmt.Sprintf("opts.PhysicalFilePath or opts.RodsPath not set"))
-	}
-
-	if physFile, err := os.Stat(opts.PhysicalFilePath); err == nil {
-		if physFile.Mode().IsDir() {
-			cCollection = C.int(1)
-		} else {
-			cCollection = C.int(0)
-		}
-	} else {
-		return newError(Fatal, -1, fmt.Sprintf("opts.PhysicalFilePath doesn't exist or we don't have the correct permissions to access"))
-	}
-
-	cPhysPath = C.CString(opts.PhysicalFilePath)
-	cRodsPath = C.CString(opts.RodsPath)
-	cExcludeFiles = C.CString(opts.ExcludeFiles)
-
-	if opts.Resource != nil {
-		switch v := opts.Resource.(type) {
-		case string:
-			cResourceName = C.CString(v)
-		case *Resource:
-			cResourceName = C.CString(v.Name())
-		default:
-			return newError(Fatal, -1, fmt.Sprintf("opts.Resource type unexpected"))
-		}
+	if con.fs != nil {
+		return con.fsRegPhysObj(opts)
 	}
 
-	defer func() {
-		C.free(unsafe.Pointer(cPhysPath))
-		C.free(unsafe.Pointer(cRodsPath))
-		C.free(unsafe.Pointer(cExcludeFiles))
-		C.free(unsafe.Pointer(cResourceName))
-	}()
-
-	if opts.Force {
-		cForce = C.int(1)
-	} else {
-		cForce = C.int(0)
-	}
-
-	if opts.Replica {
-		cReplica = C.int(1)
-	} else {
-		cReplica = C.int(0)
-	}
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
+	return con.cRegPhysObj(opts)
+}
 
-	if status := C.gorods_phys_path_reg(ccon, cPhysPath, cRodsPath, cForce, cCollection, cReplica, cResourceName, cExcludeFiles); status < 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS rcDisconnect Failed"))
+// UnregPhysObj removes the data object rodsPath from the catalog without deleting its files, like irm -U.
+// With replNum >= 0 only that replica is unregistered.
+func (con *Connection) UnregPhysObj(rodsPath string, replNum int) error {
+	if con.fs != nil {
+		return con.fsUnregPhysObj(rodsPath, replNum)
 	}
 
-	return nil
+	return con.cUnregPhysObj(rodsPath, replNum)
 }
 
 // Disconnect closes connection to iRODS iCAT server, returns error on failure or nil on success
 func (con *Connection) Disconnect() error {
-
-	if con.Connected {
-		for _, obj := range con.OpenedObjs {
-			if er := obj.Close(); er != nil {
-				return er
-			}
-		}
-
-		//con.OpenedObjs = make(IRodsObjs, 0)
-
-		ccon := con.GetCcon()
-		defer con.ReturnCcon(ccon)
-
-		if status := C.rcDisconnect(ccon); status < 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS rcDisconnect Failed"))
-		}
-
-		con.Connected = false
-	}
-
-	return nil
-}
-
-// String provides connection status and options provided during initialization (gorods.New)
-func (obj *Connection) String() string {
-
-	if obj.Options.Type == UserDefined {
-		return fmt.Sprintf("Host: %v@%v:%v/%v, Connected: %v\n", obj.Options.Username, obj.Options.Host, obj.Options.Port, obj.Options.Zone, obj.Connected)
-	}
-
-	var (
-		username *C.char
-		host     *C.char
-		port     C.int
-		zone     *C.char
-	)
-
-	defer C.free(unsafe.Pointer(username))
-	defer C.free(unsafe.Pointer(host))
-	defer C.free(unsafe.Pointer(zone))
-
-	if status := C.irods_env(&username, &host, &port, &zone); status != 0 {
-		panic(newError(Fatal, status, fmt.Sprintf("iRODS getEnv Failed")))
+	if con.fs != nil {
+		return con.fsDisconnect()
 	}
 
-	return fmt.Sprintf("Host: %v@%v:%v/%v, Connected: %v\n", C.GoString(username), C.GoString(host), int(port), C.GoString(zone), obj.Connected)
+	return con.cDisconnect()
 }
 
-// Collection initializes and returns an existing iRODS collection using the specified path
+// Collection initializes and returns an existing iRODS collection using the specified path.
+// With opts.SkipCache the collection isn't added to OpenedObjs, and the caller closes it.
 func (con *Connection) Collection(opts CollectionOptions) (*Collection, error) {
 
 	startPath := opts.Path
 	recursive := opts.Recursive
 
+	var collection IRodsObj
+
 	// Check the cache
-	if collection := con.OpenedObjs.FindRecursive(startPath); collection == nil || opts.SkipCache {
-		//if collection := con.OpenedObjs.FindRecursive(startPath); true {
+	if !opts.SkipCache {
+		con.objsLock.Lock()
+		collection = con.OpenedObjs.FindRecursive(startPath)
+		con.objsLock.Unlock()
+	}
+
+	if collection == nil {
 
 		// Load collection, no cache found
 		if col, err := getCollection(opts, con); err == nil {
-			con.OpenedObjs = append(con.OpenedObjs, col)
+			if !opts.SkipCache {
+				con.objsLock.Lock()
+				con.OpenedObjs = append(con.OpenedObjs, col)
+				con.objsLock.Unlock()
+			}
 
 			return col, nil
 		} else {
@@ -831,190 +506,62 @@ func (con *Connection) CollectionOpts(opts CollectionOptions, readOpts Collectio
 	}
 }
 
-// PathType returns DataObjType, CollectionType, or -1 (error) for the iRODS path specified
-func (con *Connection) PathType(p string) (int, error) {
-	var (
-		err        *C.char
-		statResult *C.rodsObjStat_t
-	)
-
-	path := C.CString(p)
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-	defer C.freeRodsObjStat(statResult)
+// String provides connection status and options provided during initialization (gorods.New)
+func (obj *Connection) String() string {
 
-	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
-		return -1, newError(Fatal, status, fmt.Sprintf("iRODS Stat Failed: %v, %v", p, C.GoString(err)))
+	if obj.Options.Type != EnvironmentDefined || obj.fs != nil {
+		return fmt.Sprintf("Host: %v@%v:%v/%v, Connected: %v\n", obj.Options.Username, obj.Options.Host, obj.Options.Port, obj.Options.Zone, obj.Connected)
 	}
 
-	if statResult.objType == C.DATA_OBJ_T {
-		return DataObjType, nil
-	} else if statResult.objType == C.COLL_OBJ_T {
-		return CollectionType, nil
-	}
+	return obj.cString()
+}
 
-	return -1, newError(Fatal, -1, "Unknown type")
+// PathType returns DataObjType, CollectionType, or -1 (error) for the iRODS path specified
+func (con *Connection) PathType(p string) (int, error) {
+	if con.fs != nil {
+		return con.fsPathType(p)
+	}
 
+	return con.cPathType(p)
 }
 
 // SetThreads changes the ccon.transStat.numThreads value. Not sure if it does anything.
 func (con *Connection) SetThreads(num int) {
-	con.ccon.transStat.numThreads = C.int(num)
+	if con.fs != nil {
+		con.threads = num
+		return
+	}
+
+	con.cSetThreads(num)
 }
 
 // Threads returns ccon.transStat.numThreads
 func (con *Connection) Threads() int {
-	return int(con.ccon.transStat.numThreads)
+	if con.fs != nil {
+		return con.threads
+	}
+
+	return con.cThreads()
 }
 
 // IQuestSQL executes a specific query on the iCAT server and returns a multi-dimensional string slice of results.
 // Equivalent to: "iquest --sql {specificQuery} {queryArgs}..."
 func (con *Connection) IQuestSQL(specificQuery string, queryArgs ...string) ([][]string, error) {
-	var (
-		result C.goRodsGenQueryResult_t
-		err    *C.char
-	)
-
-	result.rowSize = C.int(0)
-	result.attrSize = C.int(0)
-
-	z, zErr := con.LocalZone()
-	if zErr != nil {
-		return nil, zErr
+	if con.fs != nil {
+		return nil, unsupported("iquest --sql")
 	}
 
-	cQueryString := C.CString(specificQuery)
-	cZoneName := C.CString(z.Name())
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cQueryString))
-
-	queryArgsLen := len(queryArgs)
-	cQueryArgs := make([]*C.char, queryArgsLen)
-
-	for i := range queryArgs {
-		qa := C.CString(queryArgs[i])
-		cQueryArgs[i] = qa
-		defer C.free(unsafe.Pointer(qa))
-	}
-
-	// Solve index out of range issue for 0 args
-	if queryArgsLen == 0 {
-		blankStr := C.CString("")
-		defer C.free(unsafe.Pointer(blankStr))
-
-		cQueryArgs = append(cQueryArgs, blankStr)
-	}
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_exec_specific_query(ccon, cQueryString, (**C.char)(unsafe.Pointer(&cQueryArgs[0])), C.int(queryArgsLen), cZoneName, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		if status == C.CAT_NO_ROWS_FOUND {
-			return make([][]string, 0), nil
-		} else {
-			return nil, newError(Fatal, status, fmt.Sprintf("iRODS iquest Failed: %v", C.GoString(err)))
-		}
-	}
-
-	con.ReturnCcon(ccon)
-	defer C.gorods_free_gen_query_result(&result)
-
-	unsafeRows := unsafe.Pointer(result.result)
-	rowsLen := int(result.rowSize)
-	attrLen := int(result.attrSize)
-
-	response := make([][]string, 0)
-
-	// Convert C array to slice
-	rowSlice := (*[1 << 30]**C.char)(unsafeRows)[:rowsLen:rowsLen]
-
-	for _, val := range rowSlice {
-
-		row := make([]string, attrLen)
-		attrSlice := (*[1 << 30]*C.char)(unsafe.Pointer(val))[:attrLen:attrLen]
-
-		for i, attr := range attrSlice {
-			row[i] = C.GoString(attr)
-		}
-
-		response = append(response, row)
-	}
-
-	return response, nil
+	return con.cIQuestSQL(specificQuery, queryArgs...)
 }
 
 // IQuest accepts a SQL query fragment, returns results in slice of maps
 // If upperCase is true, all records will be matched using their uppercase representation.
 func (con *Connection) IQuest(query string, upperCase bool) ([]map[string]string, error) {
-	var (
-		result C.goRodsHashResult_t
-		err    *C.char
-		upper  int
-	)
-
-	result.size = C.int(0)
-
-	z, zErr := con.LocalZone()
-	if zErr != nil {
-		return nil, zErr
-	}
-
-	if upperCase {
-		upper = 1
+	if con.fs != nil {
+		return con.fsIQuest(query, upperCase)
 	}
 
-	cQueryString := C.CString(query)
-	cZoneName := C.CString(z.Name())
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cQueryString))
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_iquest_general(ccon, cQueryString, C.int(0), C.int(upper), cZoneName, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		if status == C.CAT_NO_ROWS_FOUND {
-			return make([]map[string]string, 0), nil
-		} else {
-			return nil, newError(Fatal, status, fmt.Sprintf("iRODS iquest Failed: %v", C.GoString(err)))
-		}
-	}
-
-	con.ReturnCcon(ccon)
-	defer C.gorods_free_map_result(&result)
-
-	unsafeKeyArr := unsafe.Pointer(result.hashKeys)
-	keyArrLen := int(result.keySize)
-
-	unsafeValArr := unsafe.Pointer(result.hashValues)
-	valArrLen := int(result.size) * keyArrLen
-
-	response := make([]map[string]string, int(result.size))
-
-	// Convert C array to slice
-	keySlice := (*[1 << 30]*C.char)(unsafeKeyArr)[:keyArrLen:keyArrLen]
-	valSlice := (*[1 << 30]*C.char)(unsafeValArr)[:valArrLen:valArrLen]
-
-	for n, val := range valSlice {
-		mapInx := n / keyArrLen
-
-		var key string
-
-		if n == 0 {
-			key = C.GoString(keySlice[0])
-		} else {
-			key = C.GoString(keySlice[int(math.Mod(float64(n), float64(keyArrLen)))])
-		}
-
-		if response[mapInx] == nil {
-			response[mapInx] = make(map[string]string)
-		}
-
-		response[mapInx][key] = C.GoString(val)
-	}
-
-	return response, nil
+	return con.cIQuest(query, upperCase)
 }
 
 // DataObject directly returns a specific DataObj without the need to traverse collections. Must pass full path of data object.
@@ -1027,71 +574,11 @@ func (con *Connection) DataObject(dataObjPath string) (dataobj *DataObj, err err
 
 // QueryMeta queries both data objects and collections for matching metadata. Returns IRodsObjs.
 func (con *Connection) QueryMeta(qString string) (response IRodsObjs, err error) {
-
-	var errMsg *C.char
-	var query *C.char = C.CString(qString)
-	var colresult C.goRodsPathResult_t
-	var dresult C.goRodsPathResult_t
-	var ccon *C.rcComm_t
-
-	defer C.free(unsafe.Pointer(query))
-	defer C.freeGoRodsPathResult(&colresult)
-	defer C.freeGoRodsPathResult(&dresult)
-
-	ccon = con.GetCcon()
-	if status := C.gorods_query_collection(ccon, query, &colresult, &errMsg); status != 0 {
-		con.ReturnCcon(ccon)
-		err = newError(Fatal, status, fmt.Sprintf(C.GoString(errMsg)))
-		return
-	}
-	con.ReturnCcon(ccon)
-
-	size := int(colresult.size)
-
-	if size > 0 {
-		slice := (*[1 << 30]*C.char)(unsafe.Pointer(colresult.pathArr))[:size:size]
-
-		for _, colString := range slice {
-
-			opts := CollectionOptions{
-				Path:      C.GoString(colString),
-				Recursive: false,
-			}
-
-			if c, er := con.Collection(opts); er == nil {
-				response = append(response, c)
-			} else {
-				err = er
-				return
-			}
-		}
-	}
-
-	ccon = con.GetCcon()
-	if status := C.gorods_query_dataobj(ccon, query, &dresult, &errMsg); status != 0 {
-		con.ReturnCcon(ccon)
-		err = newError(Fatal, status, fmt.Sprintf(C.GoString(errMsg)))
-		return
-	}
-	con.ReturnCcon(ccon)
-
-	size = int(dresult.size)
-
-	if size > 0 {
-		slice := (*[1 << 30]*C.char)(unsafe.Pointer(dresult.pathArr))[:size:size]
-
-		for _, colString := range slice {
-
-			if c, er := con.DataObject(C.GoString(colString)); er == nil {
-				response = append(response, c)
-			} else {
-				err = er
-				return
-			}
-		}
+	if con.fs != nil {
+		return con.fsQueryMeta(qString)
 	}
 
-	return
+	return con.cQueryMeta(qString)
 }
 
 func (con *Connection) init() error {
@@ -1269,230 +756,45 @@ func (con *Connection) RefreshGroups() error {
 
 // FetchGroups returns a slice of *Group, fresh from the iCAT server.
 func (con *Connection) FetchGroups() (Groups, error) {
-
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_get_groups(ccon, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Groups Failed: %v", C.GoString(err)))
-	}
-
-	con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(Groups, 0)
-
-	for _, groupName := range slice {
-		if grp, er := initGroup(C.GoString(groupName), con); er == nil {
-			response = append(response, grp)
-		} else {
-			return nil, er
-		}
-
+	if con.fs != nil {
+		return con.fsFetchGroups()
 	}
 
-	return response, nil
-
+	return con.cFetchGroups()
 }
 
 // FetchUsers returns a slice of *User, fresh from the iCAT server.
 func (con *Connection) FetchUsers() (Users, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_get_users(ccon, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Users Failed: %v", C.GoString(err)))
+	if con.fs != nil {
+		return con.fsFetchUsers()
 	}
 
-	con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(Users, 0)
-
-	for _, userNames := range slice {
-
-		nameZone := strings.Split(strings.Trim(C.GoString(userNames), " \n"), "\n")
-
-		for _, name := range nameZone {
-
-			split := strings.Split(name, "#")
-
-			user := split[0]
-			zonename := split[1]
-			var zone *Zone
-
-			if zones, err := con.Zones(); err != nil {
-				return nil, err
-			} else {
-				if zne := zones.FindByName(zonename, con); zne != nil {
-					zone = zne
-				} else {
-					return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Fetch Users Failed: Unable to locate zone in cache"))
-				}
-			}
-
-			if usr, err := initUser(user, zone, con); err == nil {
-				response = append(response, usr)
-			} else {
-				return nil, err
-			}
-
-		}
-
-	}
-
-	return response, nil
+	return con.cFetchUsers()
 }
 
 // FetchResources returns a slice of *Resource, fresh from the iCAT server.
 func (con *Connection) FetchResources() (Resources, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_get_resources_new(ccon, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Resources Failed: %v", C.GoString(err)))
+	if con.fs != nil {
+		return con.fsFetchResources()
 	}
 
-	con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(Resources, 0)
-
-	for _, cResourceName := range slice {
-
-		name := C.GoString(cResourceName)
-
-		if resc, err := initResource(name, con); err == nil {
-			response = append(response, resc)
-		} else {
-			return nil, err
-		}
-
-	}
-
-	return response, nil
+	return con.cFetchResources()
 }
 
 // FetchZones returns a slice of *Zone, fresh from the iCAT server.
 func (con *Connection) FetchZones() (Zones, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	ccon := con.GetCcon()
-
-	if status := C.gorods_get_zones(ccon, &result, &err); status != 0 {
-		con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Zones Failed: %v", C.GoString(err)))
-	}
-
-	con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(Zones, 0)
-
-	for _, cZoneName := range slice {
-
-		zoneNames := strings.Split(strings.Trim(C.GoString(cZoneName), " \n"), "\n")
-
-		for _, name := range zoneNames {
-
-			if zne, err := initZone(name, con); err == nil {
-				response = append(response, zne)
-			} else {
-				return nil, err
-			}
-
-		}
-
+	if con.fs != nil {
+		return con.fsFetchZones()
 	}
 
-	return response, nil
+	return con.cFetchZones()
 }
 
 // LocalZone returns the *Zone. First it checks the ConnectionOptions.Zone and uses that, otherwise it pulls it fresh from the iCAT server.
 func (con *Connection) LocalZone() (*Zone, error) {
-
-	var (
-		cZoneName *C.char
-		err       *C.char
-	)
-
-	if con.Options.Zone == "" {
-		ccon := con.GetCcon()
-		if status := C.gorods_get_local_zone(ccon, &cZoneName, &err); status != 0 {
-			con.ReturnCcon(ccon)
-			return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Local Zone Failed: %v", C.GoString(err)))
-		}
-		con.ReturnCcon(ccon)
-	} else {
-		cZoneName = C.CString(con.Options.Zone)
-	}
-
-	defer C.free(unsafe.Pointer(cZoneName))
-
-	zoneName := strings.Trim(C.GoString(cZoneName), " \n")
-
-	if znes, err := con.Zones(); err != nil {
-		return nil, err
-	} else {
-		if zne := znes.FindByName(zoneName, con); zne == nil {
-			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Local Zone Failed: Local zone not found in cache"))
-		} else {
-			return zne, nil
-		}
+	if con.fs != nil {
+		return con.fsLocalZone()
 	}
 
+	return con.cLocalZone()
 }
@@ -0,0 +1,1001 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #cgo CFLAGS: -ggdb -I/usr/include/irods
+// #cgo LDFLAGS: -Wl,-rpath,"/opt/irods-externals/boost1.60.0-0/lib" -Wl,-rpath,"/opt/irods-externals/clang3.8-0/lib" -L/opt/irods-externals/clang3.8-0/lib -L/opt/irods-externals/boost1.60.0-0/lib /opt/irods-externals/boost1.60.0-0/lib/libboost_system.a /opt/irods-externals/boost1.60.0-0/lib/libboost_chrono.a /opt/irods-externals/jansson2.7-0/lib/libjansson.a -lirods_common -lirods_client -lc++ -lc++abi -lboost_regex -lboost_program_options -lboost_thread -lboost_filesystem -lz -lssl -lcrypto -ldl -lpthread -lm -lrt -lstdc++ -rdynamic -Wno-write-strings -DBOOST_SYSTEM_NO_DEPRECATED
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// cConnection holds the C API connection, calls borrow it with GetCcon
+type cConnection struct {
+	ccon       *C.rcComm_t
+	cconBuffer chan *C.rcComm_t
+}
+
+func cChmod(obj IRodsObj, user string, zone string, accessLevel int, recursive bool) error {
+	var (
+		err        *C.char
+		cRecursive C.int
+	)
+
+	cUser := C.CString(user)
+	cPath := C.CString(obj.Path())
+	cZone := C.CString(zone)
+	cAccessLevel := C.CString(getTypeString(accessLevel))
+	defer C.free(unsafe.Pointer(cUser))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cZone))
+	defer C.free(unsafe.Pointer(cAccessLevel))
+
+	if recursive {
+		cRecursive = C.int(1)
+	} else {
+		cRecursive = C.int(0)
+	}
+
+	ccon := obj.Con().GetCcon()
+	defer obj.Con().ReturnCcon(ccon)
+
+	if status := C.gorods_chmod(ccon, cPath, cZone, cUser, cAccessLevel, cRecursive, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Chmod DataObject Failed: %v", C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (con *Connection) cUserInfo() (map[string]string, error) {
+	var cUsrInfo C.userInfo_t
+	var cErr *C.char
+
+	cName := C.CString(con.Options.Username)
+	defer C.free(unsafe.Pointer(cName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_iuserinfo(ccon, cName, &cUsrInfo, &cErr); status < 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS gorods_iuserinfo Failed: %v", C.GoString(cErr)))
+	}
+
+	response := make(map[string]string)
+
+	response["username"] = C.GoString(&cUsrInfo.userName[0])
+	response["zone"] = C.GoString(&cUsrInfo.rodsZone[0])
+	response["type"] = C.GoString(&cUsrInfo.userType[0])
+	response["info"] = C.GoString(&cUsrInfo.userOtherInfo.userInfo[0])
+	response["comments"] = C.GoString(&cUsrInfo.userOtherInfo.userComments[0])
+	response["create"] = C.GoString(&cUsrInfo.userOtherInfo.userCreate[0])
+	response["modify"] = C.GoString(&cUsrInfo.userOtherInfo.userModify[0])
+
+	return response, nil
+}
+
+// connect opens the C API connection of EnvironmentDefined and UserDefined connections, and authenticates
+func (con *Connection) connect() error {
+	var (
+		status    C.int
+		errMsg    *C.char
+		ipassword *C.char
+		opassword *C.char
+	)
+
+	// Are we passing env values?
+	if con.Options.Type == UserDefined {
+		host := C.CString(con.Options.Host)
+		port := C.int(con.Options.Port)
+		username := C.CString(con.Options.Username)
+		zone := C.CString(con.Options.Zone)
+
+		defer C.free(unsafe.Pointer(host))
+		defer C.free(unsafe.Pointer(username))
+		defer C.free(unsafe.Pointer(zone))
+
+		// BUG(jjacquay712): iRODS C API code outputs errors messages, need to implement connect wrapper (gorods_connect_env) from a lower level to suppress this output
+		// https://github.com/irods/irods/blob/master/iRODS/lib/core/src/rcConnect.cpp#L109
+		if status = C.gorods_connect_env(&con.ccon, host, port, username, zone, &errMsg); status != 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
+		}
+	} else {
+
+		var cHost, cUsername, cZone *C.char
+		var cPort C.int
+
+		if status = C.gorods_connect(&con.ccon, &cHost, &cPort, &cUsername, &cZone, &errMsg); status != 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
+		}
+
+		con.Options.Host = C.GoString(cHost)
+		con.Options.Port = int(cPort)
+		con.Options.Username = C.GoString(cUsername)
+		con.Options.Zone = C.GoString(cZone)
+	}
+
+	con.cconBuffer = make(chan *C.rcComm_t, 1)
+	con.cconBuffer <- con.ccon
+
+	con.Connected = true
+
+	ipassword = C.CString(con.Options.Password)
+	defer C.free(unsafe.Pointer(ipassword))
+
+	if con.Options.AuthType == 0 {
+		con.Options.AuthType = PasswordAuth // Options: PasswordAuth PAMAuth
+	}
+
+	if con.Options.PAMPassExpire == 0 {
+		con.Options.PAMPassExpire = 1 // Default expiration: 1 hour
+	}
+
+	var (
+		pamPassFile *os.File
+		pamFileErr  error
+		size        int64
+	)
+
+	if con.Options.AuthType == PAMAuth {
+
+		// Was a PAM token passed?
+		if con.Options.PAMToken != "" {
+
+			// Use it, pass directly to clientLoginWithPassword
+			opassword = C.CString(con.Options.PAMToken)
+			defer C.free(unsafe.Pointer(opassword))
+
+		} else if con.Options.PAMPassFile == "" { // Continue with auth using .Password (ipassword) option, Check to see if PAMPassFile option is not set
+
+			// It's not, fetch password and just keep in memory
+			if status = C.gorods_clientLoginPam(con.ccon, ipassword, C.int(con.Options.PAMPassExpire), &opassword, &errMsg); status != 0 {
+				return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginPam error, invalid password?"))
+			}
+
+			defer C.free(unsafe.Pointer(opassword))
+
+		} else { // There is a PAM file path set, save password to FS for subsequent use
+
+			// Does the file/dir exist?
+			if finfo, err := os.Stat(con.Options.PAMPassFile); err == nil {
+				if !finfo.IsDir() {
+					// Open file here
+					pamPassFile, pamFileErr = os.OpenFile(con.Options.PAMPassFile, os.O_RDWR, 0666)
+					if pamFileErr != nil {
+						return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem opening PAMPassFile at %v", con.Options.PAMPassFile))
+					}
+
+					size = finfo.Size()
+
+				} else {
+					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: PAMPassFile is a directory durp"))
+				}
+			} else {
+				// Create file here
+				pamPassFile, pamFileErr = os.Create(con.Options.PAMPassFile)
+				if pamFileErr != nil {
+					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem creating PAMPassFile at %v", con.Options.PAMPassFile))
+				}
+
+			}
+
+			// Is this an old password file?
+			if size > 0 {
+
+				fileBtz := make([]byte, size)
+				if _, er := pamPassFile.Read(fileBtz); er != nil {
+					return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Problem reading PAMPassFile at %v", con.Options.PAMPassFile))
+				}
+
+				fileStr := string(fileBtz)
+				fileSplit := strings.Split(fileStr, ":")
+				unixTimeStamp, _ := strconv.Atoi(fileSplit[0])
+				pamPassword := fileSplit[1]
+
+				now := int(time.Now().Unix())
+
+				// Check to see if the password has expired
+				if (unixTimeStamp + (con.Options.PAMPassExpire * 60)) <= now {
+					// we're expired, refresh
+					opassword, pamFileErr = con.fetchAndWritePAMPass(pamPassFile, ipassword)
+					if pamFileErr != nil {
+						return pamFileErr
+					}
+				} else {
+					// It's still good, use it
+					opassword = C.CString(pamPassword)
+				}
+				defer C.free(unsafe.Pointer(opassword))
+
+			} else {
+
+				// Nope, it's new. Write to the file
+				opassword, pamFileErr = con.fetchAndWritePAMPass(pamPassFile, ipassword)
+				if pamFileErr != nil {
+					return pamFileErr
+				}
+
+				defer C.free(unsafe.Pointer(opassword))
+			}
+		}
+	} else if con.Options.AuthType == PasswordAuth {
+		opassword = ipassword
+	}
+
+	if status = C.clientLoginWithPassword(con.ccon, opassword); status != 0 {
+
+		// if status == C.CAT_PASSWORD_EXPIRED {
+		// 	fmt.Printf("expired:%v\n", pamPassFile.Name())
+		// }
+
+		if con.Options.AuthType == PAMAuth {
+
+			// TODO
+			// If PAM token is set, and error is password expires, reset token, reinit?
+
+			if pamPassFile != nil {
+
+				// Failure, clear out file for another try.
+				if er := pamPassFile.Truncate(int64(0)); er != nil {
+					return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: Unable to truncate PAMPassFile: %v", er))
+				}
+
+				return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginWithPassword error, expired password?"))
+			}
+		}
+
+		return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginWithPassword error, invalid password?"))
+	}
+
+	if con.Options.AuthType == PAMAuth {
+		con.PAMToken = C.GoString(opassword)
+	}
+
+	if status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: %v", C.GoString(errMsg)))
+	}
+
+	con.SetThreads(con.Options.Threads)
+
+	if con.Options.Ticket != "" {
+		if err := con.SetTicket(con.Options.Ticket); err != nil {
+			return err
+		}
+	}
+
+	if !con.Options.FastInit {
+		if err := con.init(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchAndWritePAMPass attempts to authenticate with the iCAT server using PAM.
+// If PAM authentication is successful, it writes the returned PAM authentication token to a file for subsequent use in connections.
+func (con *Connection) fetchAndWritePAMPass(pamPassFile *os.File, ipassword *C.char) (*C.char, error) {
+
+	var (
+		opassword *C.char
+		errMsg    *C.char
+	)
+
+	if status := C.gorods_clientLoginPam(con.ccon, ipassword, C.int(con.Options.PAMPassExpire), &opassword, &errMsg); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Connect Failed: clientLoginPam error, invalid password?"))
+	}
+
+	if er := pamPassFile.Truncate(0); er != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Unable to write new password to PAMPassFile"))
+	}
+
+	pamPassFormat := strconv.Itoa(int(time.Now().Unix())) + ":" + C.GoString(opassword)
+
+	if _, er := pamPassFile.WriteString(pamPassFormat); er != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Unable to write new password to PAMPassFile"))
+	}
+
+	return opassword, nil
+}
+
+// GetCcon checks out the connection handle for use in all iRODS operations. Basically a mutex for connections.
+// Other goroutines calling this function will block until the handle is returned with con.ReturnCcon, by the goroutine using it.
+// This prevents errors in the net code since concurrent API calls aren't supported over a single iRODS connection.
+func (con *Connection) GetCcon() *C.rcComm_t {
+	return <-con.cconBuffer
+}
+
+// ReturnCcon returns the connection handle for use in other threads. Unlocks the mutex.
+func (con *Connection) ReturnCcon(ccon *C.rcComm_t) {
+	con.cconBuffer <- ccon
+}
+
+func (con *Connection) cSetTicket(t string) error {
+	var (
+		status C.int
+		errMsg *C.char
+	)
+
+	con.Options.Ticket = t
+
+	ticket := C.CString(t)
+	defer C.free(unsafe.Pointer(ticket))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status = C.gorods_set_session_ticket(ccon, ticket, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Set Ticket Failed: %v", C.GoString(errMsg)))
+	}
+
+	return nil
+}
+
+func (con *Connection) cRegPhysObj(opts RegOptions) error {
+	var (
+		cPhysPath     *C.char
+		cRodsPath     *C.char
+		cForce        C.int
+		cCollection   C.int
+		cReplica      C.int
+		cResourceName *C.char
+		cExcludeFiles *C.char
+		cChecksum     C.int
+	)
+
+	if opts.PhysicalFilePath == "" || opts.RodsPath == "" {
+		return newError(Fatal, -1, fmt.Sprintf("opts.PhysicalFilePath or opts.RodsPath not set"))
+	}
+
+	if physFile, err := os.Stat(opts.PhysicalFilePath); err == nil {
+		if physFile.Mode().IsDir() {
+			cCollection = C.int(1)
+		} else {
+			cCollection = C.int(0)
+		}
+	} else {
+		return newError(Fatal, -1, fmt.Sprintf("opts.PhysicalFilePath doesn't exist or we don't have the correct permissions to access"))
+	}
+
+	cPhysPath = C.CString(opts.PhysicalFilePath)
+	cRodsPath = C.CString(opts.RodsPath)
+	cExcludeFiles = C.CString(opts.ExcludeFiles)
+
+	if opts.Resource != nil {
+		switch v := opts.Resource.(type) {
+		case string:
+			cResourceName = C.CString(v)
+		case *Resource:
+			cResourceName = C.CString(v.Name())
+		default:
+			return newError(Fatal, -1, fmt.Sprintf("opts.Resource type unexpected"))
+		}
+	}
+
+	cVerifyChecksum := C.CString(opts.VerifyChecksum)
+
+	defer func() {
+		C.free(unsafe.Pointer(cPhysPath))
+		C.free(unsafe.Pointer(cRodsPath))
+		C.free(unsafe.Pointer(cExcludeFiles))
+		C.free(unsafe.Pointer(cResourceName))
+		C.free(unsafe.Pointer(cVerifyChecksum))
+	}()
+
+	if opts.Checksum {
+		cChecksum = C.int(1)
+	}
+
+	if opts.Force {
+		cForce = C.int(1)
+	} else {
+		cForce = C.int(0)
+	}
+
+	if opts.Replica {
+		cReplica = C.int(1)
+	} else {
+		cReplica = C.int(0)
+	}
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_phys_path_reg(ccon, cPhysPath, cRodsPath, cForce, cCollection, cReplica, cResourceName, cExcludeFiles, cChecksum, cVerifyChecksum); status < 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS RegPhysObj Failed: %v as %v", opts.PhysicalFilePath, opts.RodsPath))
+	}
+
+	return nil
+}
+
+func (con *Connection) cUnregPhysObj(rodsPath string, replNum int) error {
+	var (
+		err      *C.char
+		cReplNum *C.char
+	)
+
+	if replNum >= 0 {
+		cReplNum = C.CString(strconv.Itoa(replNum))
+	} else {
+		cReplNum = C.CString("")
+	}
+
+	cPath := C.CString(rodsPath)
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cReplNum))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_unreg_dataobject(cPath, cReplNum, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS UnregPhysObj Failed: %v, %v", rodsPath, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (con *Connection) cDisconnect() error {
+	if con.Connected {
+		con.objsLock.Lock()
+		defer con.objsLock.Unlock()
+
+		for _, obj := range con.OpenedObjs {
+			if er := obj.Close(); er != nil {
+				return er
+			}
+		}
+
+		//con.OpenedObjs = make(IRodsObjs, 0)
+
+		ccon := con.GetCcon()
+		defer con.ReturnCcon(ccon)
+
+		if status := C.rcDisconnect(ccon); status < 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS rcDisconnect Failed"))
+		}
+
+		con.Connected = false
+	}
+
+	return nil
+}
+
+func (obj *Connection) cString() string {
+	var (
+		username *C.char
+		host     *C.char
+		port     C.int
+		zone     *C.char
+	)
+
+	defer C.free(unsafe.Pointer(username))
+	defer C.free(unsafe.Pointer(host))
+	defer C.free(unsafe.Pointer(zone))
+
+	if status := C.irods_env(&username, &host, &port, &zone); status != 0 {
+		panic(newError(Fatal, status, fmt.Sprintf("iRODS getEnv Failed")))
+	}
+
+	return fmt.Sprintf("Host: %v@%v:%v/%v, Connected: %v\n", C.GoString(username), C.GoString(host), int(port), C.GoString(zone), obj.Connected)
+}
+
+func (con *Connection) cPathType(p string) (int, error) {
+	var (
+		err        *C.char
+		statResult *C.rodsObjStat_t
+	)
+
+	path := C.CString(p)
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+	defer C.freeRodsObjStat(statResult)
+
+	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
+		return -1, newError(Fatal, status, fmt.Sprintf("iRODS Stat Failed: %v, %v", p, C.GoString(err)))
+	}
+
+	if statResult.objType == C.DATA_OBJ_T {
+		return DataObjType, nil
+	} else if statResult.objType == C.COLL_OBJ_T {
+		return CollectionType, nil
+	}
+
+	return -1, newError(Fatal, -1, "Unknown type")
+
+}
+
+func (con *Connection) cSetThreads(num int) {
+	con.ccon.transStat.numThreads = C.int(num)
+}
+
+func (con *Connection) cThreads() int {
+	return int(con.ccon.transStat.numThreads)
+}
+
+func (con *Connection) cIQuestSQL(specificQuery string, queryArgs ...string) ([][]string, error) {
+	var (
+		result C.goRodsGenQueryResult_t
+		err    *C.char
+	)
+
+	result.rowSize = C.int(0)
+	result.attrSize = C.int(0)
+
+	z, zErr := con.LocalZone()
+	if zErr != nil {
+		return nil, zErr
+	}
+
+	cQueryString := C.CString(specificQuery)
+	cZoneName := C.CString(z.Name())
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cQueryString))
+
+	queryArgsLen := len(queryArgs)
+	cQueryArgs := make([]*C.char, queryArgsLen)
+
+	for i := range queryArgs {
+		qa := C.CString(queryArgs[i])
+		cQueryArgs[i] = qa
+		defer C.free(unsafe.Pointer(qa))
+	}
+
+	// Solve index out of range issue for 0 args
+	if queryArgsLen == 0 {
+		blankStr := C.CString("")
+		defer C.free(unsafe.Pointer(blankStr))
+
+		cQueryArgs = append(cQueryArgs, blankStr)
+	}
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_exec_specific_query(ccon, cQueryString, (**C.char)(unsafe.Pointer(&cQueryArgs[0])), C.int(queryArgsLen), cZoneName, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		if status == C.CAT_NO_ROWS_FOUND {
+			return make([][]string, 0), nil
+		} else {
+			return nil, newError(Fatal, status, fmt.Sprintf("iRODS iquest Failed: %v", C.GoString(err)))
+		}
+	}
+
+	con.ReturnCcon(ccon)
+	defer C.gorods_free_gen_query_result(&result)
+
+	unsafeRows := unsafe.Pointer(result.result)
+	rowsLen := int(result.rowSize)
+	attrLen := int(result.attrSize)
+
+	response := make([][]string, 0)
+
+	// Convert C array to slice
+	rowSlice := (*[1 << 30]**C.char)(unsafeRows)[:rowsLen:rowsLen]
+
+	for _, val := range rowSlice {
+
+		row := make([]string, attrLen)
+		attrSlice := (*[1 << 30]*C.char)(unsafe.Pointer(val))[:attrLen:attrLen]
+
+		for i, attr := range attrSlice {
+			row[i] = C.GoString(attr)
+		}
+
+		response = append(response, row)
+	}
+
+	return response, nil
+}
+
+func (con *Connection) cIQuest(query string, upperCase bool) ([]map[string]string, error) {
+	var (
+		result C.goRodsHashResult_t
+		err    *C.char
+		upper  int
+	)
+
+	result.size = C.int(0)
+
+	z, zErr := con.LocalZone()
+	if zErr != nil {
+		return nil, zErr
+	}
+
+	if upperCase {
+		upper = 1
+	}
+
+	cQueryString := C.CString(query)
+	cZoneName := C.CString(z.Name())
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cQueryString))
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_iquest_general(ccon, cQueryString, C.int(0), C.int(upper), cZoneName, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		if status == C.CAT_NO_ROWS_FOUND {
+			return make([]map[string]string, 0), nil
+		} else {
+			return nil, newError(Fatal, status, fmt.Sprintf("iRODS iquest Failed: %v", C.GoString(err)))
+		}
+	}
+
+	con.ReturnCcon(ccon)
+	defer C.gorods_free_map_result(&result)
+
+	return hashResultRows(&result), nil
+}
+
+func (con *Connection) cIQuestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	var (
+		result C.goRodsHashResult_t
+		err    *C.char
+		total  C.int
+	)
+
+	result.size = C.int(0)
+
+	z, zErr := con.LocalZone()
+	if zErr != nil {
+		return nil, 0, zErr
+	}
+
+	cQueryString := C.CString(query)
+	cZoneName := C.CString(z.Name())
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cQueryString))
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_iquest_page(ccon, cQueryString, C.int(offset), C.int(limit), cZoneName, &result, &total, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		if status == C.CAT_NO_ROWS_FOUND {
+			return make([]map[string]string, 0), 0, nil
+		} else {
+			return nil, 0, newError(Fatal, status, fmt.Sprintf("iRODS iquest Failed: %v", C.GoString(err)))
+		}
+	}
+
+	con.ReturnCcon(ccon)
+	defer C.gorods_free_map_result(&result)
+
+	return hashResultRows(&result), int(total), nil
+}
+
+// hashResultRows converts the rows of an iquest result to maps keyed by column name
+func hashResultRows(result *C.goRodsHashResult_t) []map[string]string {
+	unsafeKeyArr := unsafe.Pointer(result.hashKeys)
+	keyArrLen := int(result.keySize)
+
+	unsafeValArr := unsafe.Pointer(result.hashValues)
+	valArrLen := int(result.size) * keyArrLen
+
+	response := make([]map[string]string, int(result.size))
+
+	// Convert C array to slice
+	keySlice := (*[1 << 30]*C.char)(unsafeKeyArr)[:keyArrLen:keyArrLen]
+	valSlice := (*[1 << 30]*C.char)(unsafeValArr)[:valArrLen:valArrLen]
+
+	for n, val := range valSlice {
+		mapInx := n / keyArrLen
+
+		var key string
+
+		if n == 0 {
+			key = C.GoString(keySlice[0])
+		} else {
+			key = C.GoString(keySlice[int(math.Mod(float64(n), float64(keyArrLen)))])
+		}
+
+		if response[mapInx] == nil {
+			response[mapInx] = make(map[string]string)
+		}
+
+		response[mapInx][key] = C.GoString(val)
+	}
+
+	return response
+}
+
+func (con *Connection) cQueryMeta(qString string) (response IRodsObjs, err error) {
+	var errMsg *C.char
+	var query *C.char = C.CString(qString)
+	var colresult C.goRodsPathResult_t
+	var dresult C.goRodsPathResult_t
+	var ccon *C.rcComm_t
+
+	defer C.free(unsafe.Pointer(query))
+	defer C.freeGoRodsPathResult(&colresult)
+	defer C.freeGoRodsPathResult(&dresult)
+
+	ccon = con.GetCcon()
+	if status := C.gorods_query_collection(ccon, query, &colresult, &errMsg); status != 0 {
+		con.ReturnCcon(ccon)
+		err = newError(Fatal, status, fmt.Sprintf(C.GoString(errMsg)))
+		return
+	}
+	con.ReturnCcon(ccon)
+
+	size := int(colresult.size)
+
+	if size > 0 {
+		slice := (*[1 << 30]*C.char)(unsafe.Pointer(colresult.pathArr))[:size:size]
+
+		for _, colString := range slice {
+
+			opts := CollectionOptions{
+				Path:      C.GoString(colString),
+				Recursive: false,
+			}
+
+			if c, er := con.Collection(opts); er == nil {
+				response = append(response, c)
+			} else {
+				err = er
+				return
+			}
+		}
+	}
+
+	ccon = con.GetCcon()
+	if status := C.gorods_query_dataobj(ccon, query, &dresult, &errMsg); status != 0 {
+		con.ReturnCcon(ccon)
+		err = newError(Fatal, status, fmt.Sprintf(C.GoString(errMsg)))
+		return
+	}
+	con.ReturnCcon(ccon)
+
+	size = int(dresult.size)
+
+	if size > 0 {
+		slice := (*[1 << 30]*C.char)(unsafe.Pointer(dresult.pathArr))[:size:size]
+
+		for _, colString := range slice {
+
+			if c, er := con.DataObject(C.GoString(colString)); er == nil {
+				response = append(response, c)
+			} else {
+				err = er
+				return
+			}
+		}
+	}
+
+	return
+}
+
+func (con *Connection) cFetchGroups() (Groups, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_get_groups(ccon, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Groups Failed: %v", C.GoString(err)))
+	}
+
+	con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(Groups, 0)
+
+	for _, groupName := range slice {
+		if grp, er := initGroup(C.GoString(groupName), con); er == nil {
+			response = append(response, grp)
+		} else {
+			return nil, er
+		}
+
+	}
+
+	return response, nil
+
+}
+
+func (con *Connection) cFetchUsers() (Users, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_get_users(ccon, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Users Failed: %v", C.GoString(err)))
+	}
+
+	con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(Users, 0)
+
+	for _, userNames := range slice {
+
+		nameZone := strings.Split(strings.Trim(C.GoString(userNames), " \n"), "\n")
+
+		for _, name := range nameZone {
+
+			split := strings.Split(name, "#")
+
+			user := split[0]
+			zonename := split[1]
+			var zone *Zone
+
+			if zones, err := con.Zones(); err != nil {
+				return nil, err
+			} else {
+				if zne := zones.FindByName(zonename, con); zne != nil {
+					zone = zne
+				} else {
+					return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Fetch Users Failed: Unable to locate zone in cache"))
+				}
+			}
+
+			if usr, err := initUser(user, zone, con); err == nil {
+				response = append(response, usr)
+			} else {
+				return nil, err
+			}
+
+		}
+
+	}
+
+	return response, nil
+}
+
+func (con *Connection) cFetchResources() (Resources, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_get_resources_new(ccon, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Resources Failed: %v", C.GoString(err)))
+	}
+
+	con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(Resources, 0)
+
+	for _, cResourceName := range slice {
+
+		name := C.GoString(cResourceName)
+
+		if resc, err := initResource(name, con); err == nil {
+			response = append(response, resc)
+		} else {
+			return nil, err
+		}
+
+	}
+
+	return response, nil
+}
+
+func (con *Connection) cFetchZones() (Zones, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	ccon := con.GetCcon()
+
+	if status := C.gorods_get_zones(ccon, &result, &err); status != 0 {
+		con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Zones Failed: %v", C.GoString(err)))
+	}
+
+	con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(Zones, 0)
+
+	for _, cZoneName := range slice {
+
+		zoneNames := strings.Split(strings.Trim(C.GoString(cZoneName), " \n"), "\n")
+
+		for _, name := range zoneNames {
+
+			if zne, err := initZone(name, con); err == nil {
+				response = append(response, zne)
+			} else {
+				return nil, err
+			}
+
+		}
+
+	}
+
+	return response, nil
+}
+
+func (con *Connection) cLocalZone() (*Zone, error) {
+	var (
+		cZoneName *C.char
+		err       *C.char
+	)
+
+	if con.Options.Zone == "" {
+		ccon := con.GetCcon()
+		if status := C.gorods_get_local_zone(ccon, &cZoneName, &err); status != 0 {
+			con.ReturnCcon(ccon)
+			return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Local Zone Failed: %v", C.GoString(err)))
+		}
+		con.ReturnCcon(ccon)
+	} else {
+		cZoneName = C.CString(con.Options.Zone)
+	}
+
+	defer C.free(unsafe.Pointer(cZoneName))
+
+	zoneName := strings.Trim(C.GoString(cZoneName), " \n")
+
+	if znes, err := con.Zones(); err != nil {
+		return nil, err
+	} else {
+		if zne := znes.FindByName(zoneName, con); zne == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Local Zone Failed: Local zone not found in cache"))
+		} else {
+			return zne, nil
+		}
+	}
+
+}
@@ -0,0 +1,624 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// unsupported is the error of the calls the Filesystem of a connection can't serve
+func unsupported(op string) error {
+	return newError(Fatal, -1, fmt.Sprintf("iRODS %v Failed: not supported by the Filesystem of the connection", op))
+}
+
+// initFS sets up a connection served by fsys: the Filesystem of FilesystemDefined connections, or the pure Go
+// client in builds without the C API. Username and Zone default to those of the Username() and Zone() methods
+// of the Filesystem, Username() may return name#zone.
+func (con *Connection) initFS(fsys irodsfs.Filesystem) error {
+	if fsys == nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Filesystem must be set for FilesystemDefined connections"))
+	}
+
+	con.fs = fsys
+
+	if named, ok := con.fs.(interface{ Username() string }); ok && con.Options.Username == "" {
+		name, zone := SplitPrincipal(named.Username())
+
+		con.Options.Username = name
+		if con.Options.Zone == "" {
+			con.Options.Zone = zone
+		}
+	}
+
+	if zoned, ok := con.fs.(interface{ Zone() string }); ok && con.Options.Zone == "" {
+		con.Options.Zone = zoned.Zone()
+	}
+
+	if con.Options.Username == "" || con.Options.Zone == "" {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: Username and Zone must be set for FilesystemDefined connections"))
+	}
+
+	con.Connected = true
+
+	con.SetThreads(con.Options.Threads)
+
+	if con.Options.Ticket != "" {
+		if err := con.SetTicket(con.Options.Ticket); err != nil {
+			return err
+		}
+	}
+
+	if !con.Options.FastInit {
+		if err := con.init(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fsDisconnect closes the opened objects. The Filesystem of FilesystemDefined connections belongs to the caller,
+// it's left open, the connections of the pure Go client are closed.
+func (con *Connection) fsDisconnect() error {
+	if con.Connected {
+		con.objsLock.Lock()
+		defer con.objsLock.Unlock()
+
+		for _, obj := range con.OpenedObjs {
+			if er := obj.Close(); er != nil {
+				return er
+			}
+		}
+
+		if con.disconnect != nil {
+			if er := con.disconnect(); er != nil {
+				return newError(Fatal, -1, fmt.Sprintf("iRODS Disconnect Failed: %v", er))
+			}
+
+			con.disconnect = nil
+		}
+
+		con.Connected = false
+	}
+
+	return nil
+}
+
+// fsUserInfo returns the keys of UserInfo, from the catalog of the Filesystem
+func (con *Connection) fsUserInfo() (map[string]string, error) {
+	info, err := con.fsPrincipalInfo(con.Options.Username, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"username": info["user_name"],
+		"zone":     info["zone_name"],
+		"type":     info["user_type_name"],
+	}, nil
+}
+
+// fsPrincipalInfo returns the keys of User.FetchInfo and Group.FetchInfo the catalog of the Filesystem has,
+// a nil zone is the local zone
+func (con *Connection) fsPrincipalInfo(name string, zone *Zone) (map[string]string, error) {
+	zoneName := con.Options.Zone
+	if zone != nil && zone.Name() != "" {
+		zoneName = zone.Name()
+	}
+
+	qName, err := genQueryString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	qZone, err := genQueryString(zoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := con.IQuest("select USER_NAME, USER_ZONE, USER_TYPE where USER_NAME = "+qName+" and USER_ZONE = "+qZone, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Users Failed: %v#%v not found", name, zoneName))
+	}
+
+	return map[string]string{
+		"user_name":      rows[0]["USER_NAME"],
+		"zone_name":      rows[0]["USER_ZONE"],
+		"user_type_name": rows[0]["USER_TYPE"],
+	}, nil
+}
+
+// fsSetTicket passes the ticket to Filesystems with a SetTicket method
+func (con *Connection) fsSetTicket(t string) error {
+	ticketed, ok := con.fs.(interface{ SetTicket(string) error })
+	if !ok {
+		return unsupported("Set Ticket")
+	}
+
+	con.Options.Ticket = t
+
+	if err := ticketed.SetTicket(t); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Set Ticket Failed: %v", err))
+	}
+
+	return nil
+}
+
+// fsRegPhysObj registers the file with an irodsfs.RegisterFS. The physical path is a path on the server of the
+// resource, it isn't checked locally.
+func (con *Connection) fsRegPhysObj(opts RegOptions) error {
+	reg, ok := con.fs.(irodsfs.RegisterFS)
+	if !ok {
+		return unsupported("RegPhysObj")
+	}
+
+	if opts.PhysicalFilePath == "" || opts.RodsPath == "" {
+		return newError(Fatal, -1, fmt.Sprintf("opts.PhysicalFilePath or opts.RodsPath not set"))
+	}
+
+	var resource string
+
+	if opts.Resource != nil {
+		switch v := opts.Resource.(type) {
+		case string:
+			resource = v
+		case *Resource:
+			resource = v.Name()
+		default:
+			return newError(Fatal, -1, fmt.Sprintf("opts.Resource type unexpected"))
+		}
+	}
+
+	if err := reg.Register(opts.PhysicalFilePath, opts.RodsPath, irodsfs.RegisterOptions{
+		Resource: resource,
+		Replica:  opts.Replica,
+		Checksum: opts.Checksum,
+		Verify:   opts.VerifyChecksum,
+		Force:    opts.Force,
+	}); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS RegPhysObj Failed: %v as %v, %v", opts.PhysicalFilePath, opts.RodsPath, err))
+	}
+
+	return nil
+}
+
+// fsUnregPhysObj unregisters the data object with an irodsfs.RegisterFS
+func (con *Connection) fsUnregPhysObj(rodsPath string, replNum int) error {
+	reg, ok := con.fs.(irodsfs.RegisterFS)
+	if !ok {
+		return unsupported("UnregPhysObj")
+	}
+
+	if replNum < 0 {
+		replNum = -1
+	}
+
+	if err := reg.Unregister(rodsPath, replNum); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS UnregPhysObj Failed: %v, %v", rodsPath, err))
+	}
+
+	return nil
+}
+
+func (con *Connection) fsPathType(p string) (int, error) {
+	info, err := con.fs.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return -1, newError(Fatal, userFileDoesNotExist, fmt.Sprintf("iRODS Stat Failed: %v, %v", p, err))
+	} else if err != nil {
+		return -1, newError(Fatal, -1, fmt.Sprintf("iRODS Stat Failed: %v, %v", p, err))
+	}
+
+	return info.Type, nil
+}
+
+func (con *Connection) fsIQuest(query string, upperCase bool) ([]map[string]string, error) {
+	rows, err := con.fs.IQuest(query, upperCase)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS iquest Failed: %v", err))
+	}
+
+	return rows, nil
+}
+
+// fsIQuestPage uses the IQuestPage(query string, offset int, limit int) ([]map[string]string, int, error)
+// method of the Filesystem when it has one, like native.Conn. The rows are paged here otherwise.
+func (con *Connection) fsIQuestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	if pager, ok := con.fs.(interface {
+		IQuestPage(string, int, int) ([]map[string]string, int, error)
+	}); ok {
+		rows, total, err := pager.IQuestPage(query, offset, limit)
+		if err != nil {
+			return nil, 0, newError(Fatal, -1, fmt.Sprintf("iRODS iquest Failed: %v", err))
+		}
+
+		return rows, total, nil
+	}
+
+	rows, err := con.fsIQuest(query, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(rows)
+
+	if offset > total {
+		offset = total
+	}
+
+	if limit > total-offset {
+		limit = total - offset
+	}
+
+	return rows[offset : offset+limit], total, nil
+}
+
+func (con *Connection) fsQueryMeta(qString string) (response IRodsObjs, err error) {
+	infos, er := con.fs.QueryMeta(qString)
+	if er != nil {
+		err = newError(Fatal, -1, fmt.Sprintf("iRODS QueryMeta Failed: %v", er))
+		return
+	}
+
+	for _, info := range infos {
+		if info.IsDir() {
+			c, er := con.Collection(CollectionOptions{
+				Path:      info.Path,
+				Recursive: false,
+			})
+			if er != nil {
+				err = er
+				return
+			}
+
+			response = append(response, c)
+		} else {
+			d, er := con.DataObject(info.Path)
+			if er != nil {
+				err = er
+				return
+			}
+
+			response = append(response, d)
+		}
+	}
+
+	return
+}
+
+func (con *Connection) fsFetchGroups() (Groups, error) {
+	rows, err := con.IQuest("select USER_NAME where USER_TYPE = 'rodsgroup'", false)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Groups, 0)
+
+	for _, row := range rows {
+		if grp, er := initGroup(row["USER_NAME"], con); er == nil {
+			response = append(response, grp)
+		} else {
+			return nil, er
+		}
+	}
+
+	return response, nil
+}
+
+func (con *Connection) fsFetchUsers() (Users, error) {
+	rows, err := con.IQuest("select USER_NAME, USER_ZONE, USER_TYPE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := con.Zones()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Users, 0)
+
+	for _, row := range rows {
+		if row["USER_TYPE"] == "rodsgroup" {
+			continue
+		}
+
+		zone := zones.FindByName(row["USER_ZONE"], con)
+		if zone == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Fetch Users Failed: Unable to locate zone in cache"))
+		}
+
+		if usr, err := initUser(row["USER_NAME"], zone, con); err == nil {
+			response = append(response, usr)
+		} else {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func (con *Connection) fsFetchResources() (Resources, error) {
+	rows, err := con.IQuest("select RESC_NAME", false)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Resources, 0)
+
+	for _, row := range rows {
+		if resc, err := initResource(row["RESC_NAME"], con); err == nil {
+			response = append(response, resc)
+		} else {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func (con *Connection) fsFetchZones() (Zones, error) {
+	rows, err := con.IQuest("select ZONE_NAME", false)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Zones, 0)
+
+	for _, row := range rows {
+		if zne, err := initZone(row["ZONE_NAME"], con); err == nil {
+			response = append(response, zne)
+		} else {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// fsLocalZone returns the zone of ConnectionOptions.Zone, which initFS sets
+func (con *Connection) fsLocalZone() (*Zone, error) {
+	if znes, err := con.Zones(); err != nil {
+		return nil, err
+	} else {
+		if zne := znes.FindByName(con.Options.Zone, con); zne == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Local Zone Failed: Local zone not found in cache"))
+		} else {
+			return zne, nil
+		}
+	}
+}
+
+// fsChmod is chmod for FilesystemDefined connections, once the access level is checked and the zone resolved
+func fsChmod(obj IRodsObj, user string, zone string, accessLevel int, recursive bool) error {
+	fs := obj.Con().fs
+
+	if accessLevel == Inherit || accessLevel == NoInherit {
+		if err := fs.SetInheritance(obj.Path(), accessLevel == Inherit, recursive); err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Chmod DataObject Failed: %v", err))
+		}
+
+		return nil
+	}
+
+	principal := user
+	if zone != "" {
+		principal += "#" + zone
+	}
+
+	if err := fs.Chmod(obj.Path(), principal, accessLevel, recursive); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Chmod DataObject Failed: %v", err))
+	}
+
+	return nil
+}
+
+// fsACL builds the ACL of obj from the entries of the Filesystem
+func fsACL(obj IRodsObj) (ACLs, error) {
+	con := obj.Con()
+
+	entries, err := con.fs.ACL(obj.Path())
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS GetACL Failed: %v, %v", obj.Path(), err))
+	}
+
+	local, err := con.LocalZone()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(ACLs, 0, len(entries))
+
+	for _, entry := range entries {
+		aclType := entry.Type
+		if aclType != UserType && aclType != AdminType && aclType != GroupAdminType && aclType != GroupType {
+			aclType = UnknownType
+		}
+
+		name, zoneName := SplitPrincipal(entry.Principal)
+
+		acl, err := newACL(name, zoneName, aclType, entry.AccessLevel, local, con)
+		if err != nil {
+			return nil, err
+		}
+
+		response = append(response, acl)
+	}
+
+	return response, nil
+}
+
+// fsRm is irm {-r} {-f}. Without force, p is moved to the trash collection of the zone, under the same path
+// relative to the zone, with a timestamp suffix when that path is taken. Items already in the trash are removed.
+func (con *Connection) fsRm(p string, recursive bool, force bool) error {
+	info, err := con.fs.Stat(p)
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rm Failed: %v, %v", p, err))
+	}
+
+	if info.IsDir() && !recursive {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rm Failed: %v is a collection, recursive isn't set", p))
+	}
+
+	zonePath := "/" + con.Options.Zone
+	trashPath := zonePath + "/trash"
+
+	if force || strings.HasPrefix(p, trashPath+"/") {
+		if err := con.fs.Remove(p, recursive); err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Rm Failed: %v, %v", p, err))
+		}
+
+		return nil
+	}
+
+	dest := trashPath + strings.TrimPrefix(p, zonePath)
+
+	if _, err := con.fs.Stat(dest); err == nil {
+		dest = fmt.Sprintf("%v.%d", dest, time.Now().UnixNano())
+	}
+
+	if err := con.fs.Mkdir(path.Dir(dest), true); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rm Failed: %v, %v", p, err))
+	}
+
+	if err := con.fs.Rename(p, dest); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rm Failed: %v, %v", p, err))
+	}
+
+	return nil
+}
+
+// fsRmTrash removes p without moving it to the trash
+func (con *Connection) fsRmTrash(p string) error {
+	if err := con.fs.Remove(p, true); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS RmTrash Failed: %v, %v", p, err))
+	}
+
+	return nil
+}
+
+func (usr *User) fsFetchGroups() (Groups, error) {
+	zoneName := usr.con.Options.Zone
+	if usr.zone != nil {
+		zoneName = usr.zone.Name()
+	}
+
+	qName, err := genQueryString(usr.name)
+	if err != nil {
+		return nil, err
+	}
+
+	qZone, err := genQueryString(zoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := usr.con.IQuest("select USER_GROUP_NAME where USER_NAME = "+qName+" and USER_ZONE = "+qZone, false)
+	if err != nil {
+		return nil, err
+	}
+
+	grps, err := usr.con.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Groups, 0)
+
+	for _, row := range rows {
+		if gName := row["USER_GROUP_NAME"]; gName != usr.name {
+			grp := grps.FindByName(gName, usr.con)
+			if grp == nil {
+				return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchGroups Failed: Group in response not found in cache"))
+			}
+
+			response = append(response, grp)
+		}
+	}
+
+	return response, nil
+}
+
+func (grp *Group) fsFetchUsers() (Users, error) {
+	qName, err := genQueryString(grp.name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := grp.con.IQuest("select USER_NAME, USER_ZONE where USER_GROUP_NAME = "+qName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	usrs, err := grp.con.Users()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(Users, 0)
+
+	for _, row := range rows {
+		usr := usrs.FindByNameAndZone(row["USER_NAME"], row["USER_ZONE"], grp.con)
+		if usr == nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchUsers Failed: User in response not found in cache"))
+		}
+
+		response = append(response, usr)
+	}
+
+	return response, nil
+}
+
+func (resc *Resource) fsFetchInfo() (map[string]string, error) {
+	qName, err := genQueryString(resc.name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := resc.con.IQuest("select RESC_NAME, RESC_VAULT_PATH where RESC_NAME = "+qName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Resource Info Failed: %v not found", resc.name))
+	}
+
+	return map[string]string{
+		"resc_name":     rows[0]["RESC_NAME"],
+		"resc_def_path": rows[0]["RESC_VAULT_PATH"],
+		"zone_name":     resc.con.Options.Zone,
+	}, nil
+}
+
+func (zne *Zone) fsFetchInfo() (map[string]string, error) {
+	qName, err := genQueryString(zne.name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := zne.con.IQuest("select ZONE_NAME, ZONE_TYPE where ZONE_NAME = "+qName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Get Zone Info Failed: %v not found", zne.name))
+	}
+
+	return map[string]string{
+		"zone_name":      rows[0]["ZONE_NAME"],
+		"zone_type_name": rows[0]["ZONE_TYPE"],
+	}, nil
+}
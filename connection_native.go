@@ -0,0 +1,140 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"fmt"
+
+	"github.com/jjacquay712/GoRODS/native"
+)
+
+// cConnection holds the C API connection in cgo builds
+type cConnection struct{}
+
+// connect dials EnvironmentDefined and UserDefined connections with the pure Go client of package native, which
+// serves their calls as the Filesystem of the connection. It authenticates with Password: PAM and the scrambled
+// password of iinit (.irodsA) aren't supported.
+func (con *Connection) connect() error {
+	if con.Options.AuthType == PAMAuth {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: PAM authentication requires the C API"))
+	}
+
+	opts := native.Options{
+		Host:     con.Options.Host,
+		Port:     con.Options.Port,
+		Zone:     con.Options.Zone,
+		Username: con.Options.Username,
+	}
+
+	if con.Options.Type == EnvironmentDefined {
+		env, err := native.LoadEnvironment("")
+		if err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: %v", err))
+		}
+
+		opts = env
+
+		con.Options.Host = env.Host
+		con.Options.Port = env.Port
+		con.Options.Username = env.Username
+		con.Options.Zone = env.Zone
+	}
+
+	opts.Password = con.Options.Password
+	opts.Ticket = con.Options.Ticket
+
+	client, err := native.Dial(opts)
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Connect Failed: %v", err))
+	}
+
+	con.disconnect = client.Disconnect
+
+	if err := con.initFS(client); err != nil {
+		client.Disconnect()
+		con.disconnect = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (obj *Connection) cString() string {
+	return ""
+}
+
+func cChmod(obj IRodsObj, user string, zone string, accessLevel int, recursive bool) error {
+	return errNoC
+}
+
+func (con *Connection) cUserInfo() (map[string]string, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cSetTicket(t string) error {
+	return errNoC
+}
+
+func (con *Connection) cRegPhysObj(opts RegOptions) error {
+	return errNoC
+}
+
+func (con *Connection) cUnregPhysObj(rodsPath string, replNum int) error {
+	return errNoC
+}
+
+func (con *Connection) cDisconnect() error {
+	return errNoC
+}
+
+func (con *Connection) cPathType(p string) (int, error) {
+	return 0, errNoC
+}
+
+func (con *Connection) cSetThreads(num int) {
+}
+
+func (con *Connection) cThreads() int {
+	return 0
+}
+
+func (con *Connection) cIQuestSQL(specificQuery string, queryArgs ...string) ([][]string, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cIQuest(query string, upperCase bool) ([]map[string]string, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cIQuestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	return nil, 0, errNoC
+}
+
+func (con *Connection) cQueryMeta(qString string) (response IRodsObjs, err error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cFetchGroups() (Groups, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cFetchUsers() (Users, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cFetchResources() (Resources, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cFetchZones() (Zones, error) {
+	return nil, errNoC
+}
+
+func (con *Connection) cLocalZone() (*Zone, error) {
+	return nil, errNoC
+}
@@ -6,7 +6,10 @@ package gorods
 import (
 	"flag"
 	"fmt"
+	"os"
 	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
 )
 
 var testCreds = ConnectionOptions{
@@ -15,22 +18,68 @@ var testCreds = ConnectionOptions{
 
 var shouldTestPAM = false
 
+// testFake is set when the tests run against an in-memory zone, without -irods.host
+var testFake = false
+
 func init() {
-	flag.StringVar(&testCreds.Host, "irods.host", "localhost", "Hostname of iRODS server, e.g. localhost")
+	flag.StringVar(&testCreds.Host, "irods.host", "", "Hostname of iRODS server, e.g. localhost. The tests use an in-memory zone when it's empty")
 	flag.IntVar(&testCreds.Port, "irods.port", 1247, "Port of iRODS server, e.g. 1247")
 	flag.StringVar(&testCreds.Zone, "irods.zone", "tempZone", "Zone to use in connection to iRODS server, e.g. tempZone")
 	flag.StringVar(&testCreds.Username, "irods.username", "rods", "Username to use in connection to iRODS server, e.g. rods")
 	flag.StringVar(&testCreds.Password, "irods.password", "testpassword", "Password to use in connection to iRODS server, e.g. testpassword")
 
 	flag.BoolVar(&shouldTestPAM, "irods.testpam", false, "Should try to connect with PAM")
+}
 
+func TestMain(m *testing.M) {
 	flag.Parse()
 
 	if shouldTestPAM {
 		testCreds.AuthType = PAMAuth
 	}
 
+	if testCreds.Host == "" {
+		if err := fakeTestCreds(); err != nil {
+			panic(err)
+		}
+	}
+
 	fmt.Printf("Setup testing with params: %v\n", testCreds.String())
+
+	os.Exit(m.Run())
+}
+
+// fakeTestCreds points testCreds to a gorodstest zone, with the hello.txt data object the tests expect
+// in the home collection of the user
+func fakeTestCreds() error {
+	srv := gorodstest.NewServer(testCreds.Zone)
+
+	if testCreds.Username != "rods" {
+		if _, err := srv.CreateUser(testCreds.Username, gorodstest.UserType); err != nil {
+			return err
+		}
+	}
+
+	fsys, err := srv.Connect(testCreds.Username)
+	if err != nil {
+		return err
+	}
+
+	hello := fmt.Sprintf("/%v/home/%v/hello.txt", testCreds.Zone, testCreds.Username)
+
+	if err := fsys.Put(hello, []byte("Hello, World!\n"), gorodstest.PutOptions{}); err != nil {
+		return err
+	}
+
+	if err := fsys.AddMeta(hello, gorodstest.AVU{Attribute: "test", Value: "test"}); err != nil {
+		return err
+	}
+
+	testCreds.Type = FilesystemDefined
+	testCreds.Filesystem = fsys
+	testFake = true
+
+	return nil
 }
 
 func TestUserDefinedConnection(t *testing.T) {
@@ -46,6 +95,10 @@ func TestUserDefinedConnection(t *testing.T) {
 }
 
 func TestIQuestSQL(t *testing.T) {
+	if testFake {
+		t.Skip("IQuestSQL runs specific queries, the in-memory zone has none")
+	}
+
 	irods, conErr := NewConnection(&testCreds)
 	if conErr != nil {
 		t.Fatal(conErr)
@@ -3,19 +3,17 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 	"unsafe"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
 )
 
 // DataObj structs contain information about single data objects in an iRODS zone.
@@ -35,8 +33,6 @@ type DataObj struct {
 	resource *Resource
 	phyPath  string
 
-	openedAs C.int
-
 	ownerName string
 	owner     *User
 
@@ -51,7 +47,11 @@ type DataObj struct {
 	// Col field is a pointer to the Collection containing the data object
 	col *Collection
 
-	chandle C.int
+	cDataObj
+
+	// file and fileFlag are the handle of data objects of FilesystemDefined connections
+	file     irodsfs.File
+	fileFlag int
 }
 
 // Reader provides an io.Reader interface for *gorods.DataObj
@@ -95,13 +95,18 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 	return
 }
 
-// DataObjOptions is used for passing options to the CreateDataObj and DataObj.Copy function
+// DataObjOptions is used for passing options to the CreateDataObj and DataObj.Copy function.
+// ChecksumScheme (md5, sha2, sha1, sha512 or adler32, see package checksum) makes Collection.Put hash the local file
+// with that scheme and the server verify the upload against it, like iput -K. It makes DataObj.Replicate and Backup
+// verify the new replica on the server, like irepl -K: the server checksums the replicas in the scheme of the checksum
+// registered for the source, another scheme is an error.
 type DataObjOptions struct {
-	Name     string
-	Size     int64
-	Mode     int
-	Force    bool
-	Resource interface{}
+	Name           string
+	Size           int64
+	Mode           int
+	Force          bool
+	Resource       interface{}
+	ChecksumScheme string
 }
 
 // String returns path of data object
@@ -109,52 +114,6 @@ func (obj *DataObj) String() string {
 	return "DataObject: " + obj.path
 }
 
-// init function called from Collection.ReadCollection()
-// We don't init() here or return errors here because it takes forever. Lazy loading is better in this case.
-func initDataObj(data *C.collEnt_t, col *Collection, con *Connection) *DataObj {
-
-	dataObj := new(DataObj)
-
-	dataObj.typ = DataObjType
-	dataObj.col = col
-	dataObj.con = con
-	dataObj.offset = 0
-	dataObj.name = C.GoString(data.dataName)
-	dataObj.path = C.GoString(data.collName) + "/" + dataObj.name
-	dataObj.size = int64(data.dataSize)
-	dataObj.chandle = C.int(-1)
-	dataObj.checksum = C.GoString(data.chksum)
-	dataObj.dataId = C.GoString(data.dataId)
-	dataObj.phyPath = C.GoString(data.phyPath)
-	dataObj.openedAs = C.int(-1)
-
-	dataObj.replNum = int(data.replNum)
-	dataObj.rescHier = C.GoString(data.resc_hier)
-	dataObj.replStatus = int(data.replStatus)
-
-	dataObj.ownerName = C.GoString(data.ownerName)
-	dataObj.createTime = cTimeToTime(data.createTime)
-	dataObj.modifyTime = cTimeToTime(data.modifyTime)
-
-	if rsrcs, err := dataObj.con.Resources(); err != nil {
-		return nil
-	} else {
-		if r := rsrcs.FindByName(C.GoString(data.resource)); r != nil {
-			dataObj.resource = r
-		}
-	}
-
-	if usrs, err := dataObj.con.Users(); err != nil {
-		return nil
-	} else {
-		if u := usrs.FindByName(dataObj.ownerName, dataObj.con); u != nil {
-			dataObj.owner = u
-		}
-	}
-
-	return dataObj
-}
-
 // getDataObjByParentCollection initializes specified data object located at startPath using gorods.connection.
 // Unused at the moment
 func getDataObjByParentCollection(startPath string, con *Connection) (*DataObj, error) {
@@ -186,94 +145,30 @@ func getDataObjByParentCollection(startPath string, con *Connection) (*DataObj,
 // getDataObj initializes specified data object located at startPath using gorods.connection.
 // Called by Connection.DataObject()
 func getDataObj(startPath string, con *Connection) (*DataObj, error) {
+	return fetchDataObj(startPath, con, false)
+}
 
-	var cObjData C.collEnt_t
-
-	ccon := con.GetCcon()
-
-	cPath := C.CString(startPath)
-	defer C.free(unsafe.Pointer(cPath))
-
-	if status := C.gorods_get_dataobject(ccon, cPath, &cObjData); status < 0 {
-		con.ReturnCcon(ccon)
-		return nil, newError(Fatal, -1, fmt.Sprintf("Error getting data object at %v", startPath))
-	}
-
-	con.ReturnCcon(ccon)
-
-	collectionDir := filepath.Dir(startPath)
-
-	opts := CollectionOptions{
-		Path:      collectionDir,
-		Recursive: false,
-	}
-
-	if col, err := con.Collection(opts); err == nil {
-		return initDataObj(&cObjData, col, con), nil
-	} else {
-		// Couldn't open the parent collection...
-		return initDataObj(&cObjData, nil, con), nil
+// fetchDataObj is getDataObj, with skipCache the parent collection is fetched without the cache
+// of the connection and closed, it's opened again when it's read
+func fetchDataObj(startPath string, con *Connection, skipCache bool) (*DataObj, error) {
+	if con.fs != nil {
+		return fsFetchDataObj(startPath, con, skipCache)
 	}
 
+	return cFetchDataObj(startPath, con, skipCache)
 }
 
 // CreateDataObj creates and adds a data object to the specified collection using provided options. Returns the newly created data object.
 func CreateDataObj(opts DataObjOptions, coll *Collection) (*DataObj, error) {
-
-	var (
-		errMsg   *C.char
-		handle   C.int
-		force    int
-		resource *C.char
-	)
-
-	if opts.Force {
-		force = 1
-	} else {
-		force = 0
-	}
-
-	if opts.Resource != nil {
-		switch opts.Resource.(type) {
-		case string:
-			resource = C.CString(opts.Resource.(string))
-		case *Resource:
-			r := opts.Resource.(*Resource)
-			resource = C.CString(r.Name())
-		default:
-			return nil, newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
-		}
-	} else {
-		resource = C.CString("")
-	}
-
-	path := C.CString(coll.path + "/" + opts.Name)
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(resource))
-
-	ccon := coll.con.GetCcon()
-
-	if status := C.gorods_create_dataobject(path, C.rodsLong_t(opts.Size), C.int(opts.Mode), C.int(force), resource, &handle, ccon, &errMsg); status != 0 {
-		coll.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Create DataObject Failed: %v, Does the file already exist?", C.GoString(errMsg)))
-	}
-	coll.con.ReturnCcon(ccon)
-
-	// if err := coll.Refresh(); err != nil {
-	// 	return nil, err
-	// }
-
-	if do, err := getDataObj(C.GoString(path), coll.con); err != nil {
-		return nil, err
-	} else {
-		return do, nil
+	if coll.con.fs != nil {
+		return fsCreateDataObj(opts, coll)
 	}
 
+	return cCreateDataObj(opts, coll)
 }
 
 func (obj *DataObj) init() error {
-	if int(obj.chandle) < 0 {
+	if !obj.isOpen() {
 		return obj.Open()
 	}
 
@@ -281,13 +176,22 @@ func (obj *DataObj) init() error {
 }
 
 func (obj *DataObj) initRW() error {
-	if int(obj.chandle) < 0 {
+	if !obj.isOpen() {
 		return obj.OpenRW()
 	}
 
 	return nil
 }
 
+// isOpen reports whether the data object has a handle
+func (obj *DataObj) isOpen() bool {
+	if obj.con.fs != nil {
+		return obj.file != nil
+	}
+
+	return obj.cIsOpen()
+}
+
 // Reader returns *gorods.Reader whuch implements io.Reader interface
 func (obj *DataObj) Reader() *Reader {
 	return &Reader{obj, int64(0)}
@@ -303,51 +207,36 @@ func (obj *DataObj) Writer() *Writer {
 // developers#tempZone:modify object
 // designers#tempZone:read object]
 func (obj *DataObj) ACL() (ACLs, error) {
-
-	var (
-		result   C.goRodsACLResult_t
-		err      *C.char
-		zoneHint *C.char
-	)
-
-	zone, zErr := obj.con.LocalZone()
-	if zErr != nil {
-		return nil, zErr
-	} else {
-		zoneHint = C.CString(zone.Name())
+	if obj.con.fs != nil {
+		return fsACL(obj)
 	}
 
-	cDataId := C.CString(obj.dataId)
-	defer C.free(unsafe.Pointer(cDataId))
-	defer C.free(unsafe.Pointer(zoneHint))
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_get_dataobject_acl(ccon, cDataId, &result, zoneHint, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Data Object ACL Failed: %v", C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	return aclSliceToResponse(&result, obj.con)
-
+	return obj.cACL()
 }
 
 // Chmod changes the permissions/ACL of a data object.
-// accessLevel: Null | Read | Write | Own
+// accessLevel: Null | Read | Write | Own, or a granular level of iRODS 4.3 (see AccessLevels)
+// userOrGroup is a name of the local zone, or name#zone for a user or group of a federated zone
 func (obj *DataObj) Chmod(userOrGroup string, accessLevel int, recursive bool) error {
 	return chmod(obj, userOrGroup, accessLevel, false, true)
 }
 
 // GrantAccess will add permissions (ACL) to the data object.
 func (obj *DataObj) GrantAccess(userOrGroup AccessObject, accessLevel int, recursive bool) error {
-	return chmod(obj, userOrGroup.Name(), accessLevel, false, true)
+	return chmod(obj, Principal(userOrGroup), accessLevel, false, true)
 }
 
 // Handle returns the internal handle index
 func (obj *DataObj) Handle() int {
-	return int(obj.chandle)
+	if obj.con.fs != nil {
+		if obj.file == nil {
+			return -1
+		}
+
+		return 0
+	}
+
+	return obj.cHandle()
 }
 
 // Type gets the type (DataObjType), used in interfaces
@@ -470,149 +359,56 @@ func (obj *DataObj) Trash(recursive bool) error {
 
 // Rm is equivalent to irm {-r} {-f}
 func (obj *DataObj) Rm(recursive bool, force bool) error {
-	var errMsg *C.char
-
-	path := C.CString(obj.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	var (
-		cForce     C.int
-		cRecursive C.int
-	)
-
-	if force {
-		cForce = C.int(1)
-	}
-
-	if recursive {
-		cRecursive = C.int(1)
-	}
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_rm(path, 0, cRecursive, cForce, C.int(0), ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Rm DataObject Failed: %v", C.GoString(errMsg)))
+	if obj.con.fs != nil {
+		return obj.con.fsRm(obj.path, recursive, force)
 	}
 
-	return nil
+	return obj.cRm(recursive, force)
 }
 
 // RmTrash is used (sometimes internally) by GoRODS to delete items in the trash permanently. The data object's path should be in the trash collection.
 func (obj *DataObj) RmTrash() error {
-	var errMsg *C.char
-
-	path := C.CString(obj.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_rm(path, C.int(0), C.int(1), C.int(1), C.int(1), ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS RmTrash DataObject Failed: %v", C.GoString(errMsg)))
+	if obj.con.fs != nil {
+		return obj.con.fsRmTrash(obj.path)
 	}
 
-	return nil
+	return obj.cRmTrash()
 }
 
 // Open opens a connection to iRODS and sets the data object handle
 func (obj *DataObj) Open() error {
-	var errMsg *C.char
-
-	path := C.CString(obj.path)
-	resourceName := C.CString(obj.resource.Name())
-	replNum := C.CString(strconv.Itoa(obj.replNum))
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(resourceName))
-	defer C.free(unsafe.Pointer(replNum))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_open_dataobject(path, resourceName, replNum, C.O_RDONLY, &obj.chandle, ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Open DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
+	if obj.con.fs != nil {
+		return obj.fsOpen(os.O_RDONLY)
 	}
 
-	obj.openedAs = C.O_RDONLY
-
-	return nil
+	return obj.cOpen()
 }
 
 // OpenRW opens a connection to iRODS and sets the data object handle for read/write access
 func (obj *DataObj) OpenRW() error {
-	var errMsg *C.char
-
-	path := C.CString(obj.path)
-	resourceName := C.CString(obj.resource.Name())
-	replNum := C.CString(strconv.Itoa(obj.replNum))
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(resourceName))
-	defer C.free(unsafe.Pointer(replNum))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_open_dataobject(path, resourceName, replNum, C.O_RDWR, &obj.chandle, ccon, &errMsg); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS OpenRW DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
+	if obj.con.fs != nil {
+		return obj.fsOpen(os.O_RDWR)
 	}
 
-	obj.openedAs = C.O_RDWR
-
-	return nil
+	return obj.cOpenRW()
 }
 
 // Close closes the data object, resets handler
 func (obj *DataObj) Close() error {
-	var errMsg *C.char
-
-	if int(obj.chandle) > -1 {
-
-		ccon := obj.con.GetCcon()
-		defer obj.con.ReturnCcon(ccon)
-
-		if status := C.gorods_close_dataobject(obj.chandle, ccon, &errMsg); status != 0 {
-			return newError(Fatal, status, fmt.Sprintf("iRODS Close DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
-		}
-
-		obj.chandle = C.int(-1)
+	if obj.con.fs != nil {
+		return obj.fsClose()
 	}
 
-	return nil
+	return obj.cClose()
 }
 
 // Read reads the entire data object into memory and returns a []byte slice. Don't use this for large files.
 func (obj *DataObj) Read() ([]byte, error) {
-	if er := obj.init(); er != nil {
-		return nil, er
-	}
-
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(0); er != nil {
-		return nil, er
+	if obj.con.fs != nil {
+		return obj.fsRead()
 	}
 
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(obj.size), &buffer, &bytesRead, ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	buf := unsafe.Pointer(buffer.buf)
-	defer C.free(buf)
-
-	data := C.GoBytes(buf, bytesRead)
-
-	return data, obj.Close()
+	return obj.cRead()
 }
 
 type ByteArr struct {
@@ -620,225 +416,59 @@ type ByteArr struct {
 	Ptr      unsafe.Pointer
 }
 
-func (br ByteArr) Free() {
-	C.free(br.Ptr)
-}
-
 // ReadChunkFree is similar to ReadChunk, except it doesn't copy bytes into a new byte slice, making the process more efficient. It uses the existing C byte array and casts it as a go []byte. You must explicitally call ByteArr.Free on the returned struct or there will be a memory leak.
 func (obj *DataObj) ReadChunkFree(size int64, callback func(*ByteArr)) error {
-	if er := obj.init(); er != nil {
-		return er
-	}
-
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(0); er != nil {
-		return er
-	}
-
-	for obj.offset < obj.size {
-
-		ccon := obj.con.GetCcon()
-
-		if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(size), &buffer, &bytesRead, ccon, &err); status != 0 {
-			obj.con.ReturnCcon(ccon)
-			return newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
-		}
-
-		obj.con.ReturnCcon(ccon)
-
-		buf := unsafe.Pointer(buffer.buf)
-
-		bufLen := int(buffer.len)
-		data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
-
-		callback(&ByteArr{
-			Contents: data,
-			Ptr:      buf,
-		})
-
-		if er := obj.LSeek(obj.offset + size); er != nil {
-			return er
-		}
+	if obj.con.fs != nil {
+		return obj.fsReadChunk(size, func(chunk []byte) { callback(&ByteArr{Contents: chunk}) })
 	}
 
-	if er := obj.LSeek(0); er != nil {
-		return er
-	}
-
-	return obj.Close()
+	return obj.cReadChunkFree(size, callback)
 }
 
 // FastReadFree is similar to ReadBytes, except it doesn't copy bytes into a new byte slice, making the process more efficient. It uses the existing C byte array and casts it as a go []byte. You must explicitally call ByteArr.Free on the returned struct or there will be a memory leak.
 func (obj *DataObj) FastReadFree(pos int64, length int) (*ByteArr, error) {
-	if er := obj.init(); er != nil {
-		return nil, er
+	if obj.con.fs != nil {
+		return obj.fsFastReadFree(pos, length)
 	}
 
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(pos); er != nil {
-		return nil, er
-	}
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
-	}
-
-	buf := unsafe.Pointer(buffer.buf)
-
-	bufLen := int(buffer.len)
-	data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
-
-	return &ByteArr{
-		Contents: data,
-		Ptr:      buf,
-	}, nil
+	return obj.cFastReadFree(pos, length)
 }
 
 // FastRead is similar to ReadBytes, except it doesn't copy bytes into a new byte slice, making the process more efficient. It uses the existing C byte array and casts it as a go []byte. Once your call back is run, the allocated memory is freed automatically.
 // This function will block until bytes are received and your callback has been run.
 func (obj *DataObj) FastRead(pos int64, length int, callback func([]byte) error) error {
-	if er := obj.init(); er != nil {
-		return er
-	}
-
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(pos); er != nil {
-		return er
-	}
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsFastRead(pos, length, callback)
 	}
 
-	buf := unsafe.Pointer(buffer.buf)
-	defer C.free(buf)
-
-	bufLen := int(buffer.len)
-
-	data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
-
-	return callback(data)
+	return obj.cFastRead(pos, length, callback)
 }
 
 // ReadBytes reads bytes from a data object at the specified position and length, returns []byte slice and error.
 func (obj *DataObj) ReadBytes(pos int64, length int) ([]byte, error) {
-	if er := obj.init(); er != nil {
-		return nil, er
-	}
-
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(pos); er != nil {
-		return nil, er
-	}
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsReadBytes(pos, length)
 	}
 
-	buf := unsafe.Pointer(buffer.buf)
-	defer C.free(buf)
-
-	data := C.GoBytes(buf, bytesRead)
-
-	return data, nil
+	return obj.cReadBytes(pos, length)
 }
 
 // LSeek sets the read/write offset pointer of a data object, returns error
 func (obj *DataObj) LSeek(offset int64) error {
-	if er := obj.init(); er != nil {
-		return er
-	}
-
-	var (
-		err *C.char
-	)
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_lseek_dataobject(obj.chandle, C.rodsLong_t(offset), ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS LSeek DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsLSeek(offset)
 	}
 
-	obj.offset = offset
-
-	return nil
+	return obj.cLSeek(offset)
 }
 
 // ReadChunk reads the entire data object in chunks (size of chunk specified by size parameter), passing the data into a callback function for each chunk. Use this to read/write large files.
 func (obj *DataObj) ReadChunk(size int64, callback func([]byte)) error {
-	if er := obj.init(); er != nil {
-		return er
+	if obj.con.fs != nil {
+		return obj.fsReadChunk(size, callback)
 	}
 
-	var (
-		buffer    C.bytesBuf_t
-		err       *C.char
-		bytesRead C.int
-	)
-
-	if er := obj.LSeek(0); er != nil {
-		return er
-	}
-
-	for obj.offset < obj.size {
-
-		ccon := obj.con.GetCcon()
-
-		if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(size), &buffer, &bytesRead, ccon, &err); status != 0 {
-			obj.con.ReturnCcon(ccon)
-			return newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
-		}
-
-		obj.con.ReturnCcon(ccon)
-
-		buf := unsafe.Pointer(buffer.buf)
-
-		chunk := C.GoBytes(buf, bytesRead)
-
-		C.free(buf)
-
-		callback(chunk)
-
-		if er := obj.LSeek(obj.offset + size); er != nil {
-			return er
-		}
-	}
-
-	if er := obj.LSeek(0); er != nil {
-		return er
-	}
-
-	return obj.Close()
+	return obj.cReadChunk(size, callback)
 }
 
 // DownloadTo downloads and writes the entire data object to the provided path. Don't use this with large files unless you have RAM to spare, use ReadChunk() instead. Returns error.
@@ -860,68 +490,20 @@ func (obj *DataObj) DownloadTo(localPath string) error {
 
 // Write writes the data to the data object, starting from the beginning. Returns error.
 func (obj *DataObj) Write(data []byte) error {
-	if er := obj.initRW(); er != nil {
-		return er
-	}
-
-	if !(obj.openedAs == C.O_RDWR || obj.openedAs == C.O_WRONLY) {
-		obj.Close()
-		obj.OpenRW()
-	}
-
-	if er := obj.LSeek(0); er != nil {
-		return er
-	}
-
-	size := int64(len(data))
-
-	dataPointer := unsafe.Pointer(&data[0]) // Do I need to free this? It might be done by go
-
-	var err *C.char
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_write_dataobject(obj.chandle, dataPointer, C.int(size), ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsWrite(data)
 	}
 
-	obj.con.ReturnCcon(ccon)
-
-	obj.size = size
-
-	return obj.Close()
+	return obj.cWrite(data)
 }
 
 // WriteBytes writes to the data object wherever the object's offset pointer is currently set to. It advances the pointer to the end of the written data for supporting subsequent writes. Be sure to call obj.LSeek(0) before hand if you wish to write from the beginning. Returns error.
 func (obj *DataObj) WriteBytes(data []byte) error {
-	if er := obj.initRW(); er != nil {
-		return er
-	}
-
-	if !(obj.openedAs == C.O_RDWR || obj.openedAs == C.O_WRONLY) {
-		obj.Close()
-		obj.OpenRW()
+	if obj.con.fs != nil {
+		return obj.fsWriteBytes(data)
 	}
 
-	size := int64(len(data))
-
-	dataPointer := unsafe.Pointer(&data[0]) // Do I need to free this? It might be done by go
-
-	var err *C.char
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_write_dataobject(obj.chandle, dataPointer, C.int(size), ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	obj.size = size + obj.offset
-
-	return obj.LSeek(obj.size)
+	return obj.cWriteBytes(data)
 }
 
 // Stat returns a map (key/value pairs) of the system meta information. The following keys can be used with the map:
@@ -942,38 +524,11 @@ func (obj *DataObj) WriteBytes(data []byte) error {
 //
 // "modifyTime"
 func (obj *DataObj) Stat() (map[string]interface{}, error) {
-
-	var (
-		err        *C.char
-		statResult *C.rodsObjStat_t
-	)
-
-	path := C.CString(obj.path)
-
-	defer C.free(unsafe.Pointer(path))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Close Stat Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsStat()
 	}
 
-	result := make(map[string]interface{})
-
-	result["objSize"] = int(statResult.objSize)
-	result["dataMode"] = int(statResult.dataMode)
-
-	result["dataId"] = C.GoString(&statResult.dataId[0])
-	result["chksum"] = C.GoString(&statResult.chksum[0])
-	result["ownerName"] = C.GoString(&statResult.ownerName[0])
-	result["ownerZone"] = C.GoString(&statResult.ownerZone[0])
-	result["createTime"] = C.GoString(&statResult.createTime[0])
-	result["modifyTime"] = C.GoString(&statResult.modifyTime[0])
-
-	C.freeRodsObjStat(statResult)
-
-	return result, nil
+	return obj.cStat()
 }
 
 // Attribute gets slice of Meta AVU triples, matching by Attribute name for DataObj
@@ -1023,281 +578,38 @@ func (obj *DataObj) Meta() (*MetaCollection, error) {
 
 // CopyTo copies the data object to the specified collection. Supports Collection struct or string as input. Also refreshes the destination collection automatically to maintain correct state. Returns error.
 func (obj *DataObj) CopyTo(iRODSCollection interface{}) error {
-
-	var (
-		err                         *C.char
-		destination                 string
-		destinationCollectionString string
-		destinationCollection       *Collection
-	)
-
-	switch iRODSCollection.(type) {
-	case string:
-		destinationCollectionString = iRODSCollection.(string)
-
-		// Is this a relative path?
-		if destinationCollectionString[0] != '/' {
-			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
-		}
-
-		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
-			destinationCollectionString += "/"
-		}
-
-		destination += destinationCollectionString + obj.name
-	case *Collection:
-		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
-		destination = destinationCollectionString + obj.name
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
-	}
-
-	path := C.CString(obj.path)
-	dest := C.CString(destination)
-	resource := C.CString("")
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(dest))
-	defer C.free(unsafe.Pointer(resource))
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_copy_dataobject(path, dest, C.int(0), resource, ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	// Find & reload destination collection
-	switch iRODSCollection.(type) {
-	case string:
-		var colEr error
-
-		// Can't find, load collection into memory
-		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
-			Path:      destinationCollectionString,
-			Recursive: false,
-		})
-		if colEr != nil {
-			return colEr
-		}
-
-	case *Collection:
-		destinationCollection = (iRODSCollection.(*Collection))
-
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
+	if obj.con.fs != nil {
+		return obj.fsCopyTo(iRODSCollection, DataObjOptions{})
 	}
 
-	destinationCollection.Refresh()
-
-	return nil
+	return obj.cCopyTo(iRODSCollection)
 }
 
 // CopyTo copies the data object to the specified collection. Supports Collection struct or string as input. Also refreshes the destination collection automatically to maintain correct state. Returns error.
 func (obj *DataObj) CopyToOpts(iRODSCollection interface{}, opts DataObjOptions) error {
-
-	var (
-		err                         *C.char
-		resource                    *C.char
-		force                       int
-		destination                 string
-		destinationCollectionString string
-		destinationCollection       *Collection
-	)
-
-	switch iRODSCollection.(type) {
-	case string:
-		destinationCollectionString = iRODSCollection.(string)
-
-		// Is this a relative path?
-		if destinationCollectionString[0] != '/' {
-			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
-		}
-
-		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
-			destinationCollectionString += "/"
-		}
-
-		destination += destinationCollectionString + obj.name
-	case *Collection:
-		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
-		destination = destinationCollectionString + obj.name
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
-	}
-
-	if opts.Force {
-		force = 1
-	} else {
-		force = 0
-	}
-
-	switch opts.Resource.(type) {
-	case string:
-		resource = C.CString(opts.Resource.(string))
-	case *Resource:
-		r := opts.Resource.(*Resource)
-		resource = C.CString(r.Name())
-	default:
-		newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
-	}
-
-	path := C.CString(obj.path)
-	dest := C.CString(destination)
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(dest))
-	defer C.free(unsafe.Pointer(resource))
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_copy_dataobject(path, dest, C.int(force), resource, ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	// Find & reload destination collection
-	switch iRODSCollection.(type) {
-	case string:
-		var colEr error
-
-		// Can't find, load collection into memory
-		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
-			Path:      destinationCollectionString,
-			Recursive: false,
-		})
-		if colEr != nil {
-			return colEr
-		}
-
-	case *Collection:
-		destinationCollection = (iRODSCollection.(*Collection))
-
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
+	if obj.con.fs != nil {
+		return obj.fsCopyTo(iRODSCollection, opts)
 	}
 
-	destinationCollection.Refresh()
-
-	return nil
+	return obj.cCopyToOpts(iRODSCollection, opts)
 }
 
 // MoveTo moves the data object to the specified collection. Supports Collection struct or string as input. Also refreshes the source and destination collections automatically to maintain correct state. Returns error.
 func (obj *DataObj) MoveTo(iRODSCollection interface{}) error {
-
-	var (
-		err                         *C.char
-		destination                 string
-		destinationCollectionString string
-		destinationCollection       *Collection
-	)
-
-	switch iRODSCollection.(type) {
-	case string:
-		destinationCollectionString = iRODSCollection.(string)
-
-		// Is this a relative path?
-		if destinationCollectionString[0] != '/' {
-			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
-		}
-
-		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
-			destinationCollectionString += "/"
-		}
-
-		destination += destinationCollectionString + obj.name
-	case *Collection:
-		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
-		destination = destinationCollectionString + obj.name
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
-	}
-
-	path := C.CString(obj.path)
-	dest := C.CString(destination)
-
-	defer C.free(unsafe.Pointer(path))
-	defer C.free(unsafe.Pointer(dest))
-
-	ccon := obj.con.GetCcon()
-
-	if status := C.gorods_move_dataobject(path, dest, C.RENAME_DATA_OBJ, ccon, &err); status != 0 {
-		obj.con.ReturnCcon(ccon)
-		return newError(Fatal, status, fmt.Sprintf("iRODS Move DataObject Failed S:%v, D:%v, %v", obj.path, destination, C.GoString(err)))
-	}
-
-	obj.con.ReturnCcon(ccon)
-
-	// Reload source collection, we are now detached
-	obj.col.Refresh()
-
-	// Find & reload destination collection
-	switch iRODSCollection.(type) {
-	case string:
-		var colEr error
-
-		// Can't find, load collection into memory
-		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
-			Path:      destinationCollectionString,
-			Recursive: false,
-		})
-		if colEr != nil {
-			return colEr
-		}
-
-	case *Collection:
-		destinationCollection = (iRODSCollection.(*Collection))
-
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
+	if obj.con.fs != nil {
+		return obj.fsMoveTo(iRODSCollection)
 	}
 
-	destinationCollection.Refresh()
-
-	// Reassign obj.col to destination collection
-	obj.col = destinationCollection
-	obj.path = destinationCollection.path + "/" + obj.name
-
-	obj.chandle = C.int(-1)
-
-	return nil
+	return obj.cMoveTo(iRODSCollection)
 }
 
 // Rename is equivalent to the Linux mv command except that the data object must stay within the current collection (directory), returns error.
 func (obj *DataObj) Rename(newFileName string) error {
-
-	if strings.Contains(newFileName, "/") {
-		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
-	}
-
-	var err *C.char
-
-	source := obj.path
-	destination := obj.col.path + "/" + newFileName
-
-	s := C.CString(source)
-	d := C.CString(destination)
-
-	defer C.free(unsafe.Pointer(s))
-	defer C.free(unsafe.Pointer(d))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_move_dataobject(s, d, C.RENAME_DATA_OBJ, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Rename DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsRename(newFileName)
 	}
 
-	obj.name = newFileName
-	obj.path = destination
-
-	obj.chandle = C.int(-1)
-
-	return nil
+	return obj.cRename(newFileName)
 }
 
 // Unlink deletes the data object from the iRODS server, no force flag is used
@@ -1307,38 +619,69 @@ func (obj *DataObj) Unlink() error {
 
 // Chksum returns md5 hash string of data object
 func (obj *DataObj) Chksum() (string, error) {
+	if obj.con.fs != nil {
+		return obj.fsChksum()
+	}
 
-	var (
-		err       *C.char
-		chksumOut *C.char
-	)
-
-	path := C.CString(obj.path)
+	return obj.cChksum()
+}
 
-	defer C.free(unsafe.Pointer(path))
+// Verify returns true or false depending on whether the checksum string matches. Its algorithm is detected from its
+// format: md5 hex, sha2:, sha1:, sha512: or adler32:. The checksum computed by the server is compared when it has the
+// same algorithm, otherwise the data is read and hashed locally.
+func (obj *DataObj) Verify(sum string) bool {
+	want, err := checksum.Parse(sum)
+	if err != nil {
+		return false
+	}
 
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
+	chksum, err := obj.Chksum()
+	if err != nil {
+		return false
+	}
 
-	if status := C.gorods_checksum_dataobject(path, &chksumOut, ccon, &err); status != 0 {
-		return "", newError(Fatal, status, fmt.Sprintf("iRODS Chksum DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	if got, err := checksum.Parse(chksum); err == nil && got.Algorithm == want.Algorithm {
+		return got.Equal(want)
 	}
 
-	obj.checksum = C.GoString(chksumOut)
+	got, err := obj.ComputeChecksum(want.Algorithm)
+
+	return err == nil && got.Equal(want)
+}
+
+// ComputeChecksum reads the data object and hashes it locally with alg, whatever the hash scheme of the zone.
+// The catalog isn't changed. A handle opened by ComputeChecksum is closed, one opened by the caller is left open
+// at the end of the data.
+func (obj *DataObj) ComputeChecksum(alg checksum.Algorithm) (checksum.Checksum, error) {
+	opened := obj.isOpen()
 
-	C.free(unsafe.Pointer(chksumOut))
+	sum, err := checksum.Compute(alg, obj.Reader())
 
-	return obj.checksum, nil
+	if !opened {
+		obj.Close()
+	}
+
+	return sum, err
 }
 
-// Verify returns true or false depending on whether the checksum md5 string matches
-func (obj *DataObj) Verify(md5Checksum string) bool {
-	if chksum, err := obj.Chksum(); err == nil {
-		chksumSplit := strings.Split(chksum, ":")
-		return (md5Checksum == chksumSplit[1])
+// verifyChecksum reports whether the server verifies a replication of obj, for DataObjOptions.ChecksumScheme.
+// The server checksums the replicas in the scheme of the checksum registered for the source, so another scheme
+// is an error.
+func (obj *DataObj) verifyChecksum(opts DataObjOptions) (bool, error) {
+	if opts.ChecksumScheme == "" {
+		return false, nil
 	}
 
-	return false
+	alg, err := checksum.ParseAlgorithm(opts.ChecksumScheme)
+	if err != nil {
+		return false, newError(Fatal, -1, err.Error())
+	}
+
+	if registered, err := checksum.Parse(obj.checksum); err == nil && registered.Algorithm != alg {
+		return false, newError(Fatal, -1, fmt.Sprintf("iRODS Replicate Failed: %v has a %v checksum, the replicas can't be verified with %v", obj.path, registered.Algorithm, alg))
+	}
+
+	return true, nil
 }
 
 // TrimOptions store the options for trim operations.
@@ -1353,139 +696,38 @@ type TrimOptions struct {
 
 // TrimRepls trims data object replicas (removes from resource servers), using the rules defined in opts.
 func (obj *DataObj) TrimRepls(opts TrimOptions) error {
-	var (
-		err         *C.char
-		resourceStr string
-	)
-
-	switch opts.TargetResource.(type) {
-	case string:
-		resourceStr = opts.TargetResource.(string)
-	case *Resource:
-		resourceStr = (opts.TargetResource.(*Resource)).Name()
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
-
+	if obj.con.fs != nil {
+		return obj.fsTrimRepls(opts)
 	}
 
-	cNumCopies := C.CString(strconv.Itoa(opts.NumCopiesKeep))
-	cAgeStr := C.CString(strconv.Itoa(opts.MinAgeMins))
-	cPath := C.CString(obj.Path())
-	cResource := C.CString(resourceStr)
-	defer C.free(unsafe.Pointer(cNumCopies))
-	defer C.free(unsafe.Pointer(cAgeStr))
-	defer C.free(unsafe.Pointer(cPath))
-	defer C.free(unsafe.Pointer(cResource))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_trimrepls_dataobject(ccon, cPath, cAgeStr, cResource, cNumCopies, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS TrimRepls Failed: %v, %v", obj.path, C.GoString(err)))
-	}
-
-	return nil
+	return obj.cTrimRepls(opts)
 }
 
 // MoveToResource moves data object to the specified resource.
 // Accepts string or *Resource type.
 func (obj *DataObj) MoveToResource(targetResource interface{}) error {
-
-	var (
-		err         *C.char
-		resourceStr string
-	)
-
-	switch targetResource.(type) {
-	case string:
-		resourceStr = targetResource.(string)
-	case *Resource:
-		resourceStr = (targetResource.(*Resource)).Name()
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
-
-	}
-
-	cSourceResource := C.CString(obj.resource.name)
-	cPath := C.CString(obj.Path())
-	cResource := C.CString(resourceStr)
-	defer C.free(unsafe.Pointer(cSourceResource))
-	defer C.free(unsafe.Pointer(cPath))
-	defer C.free(unsafe.Pointer(cResource))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_phymv_dataobject(ccon, cPath, cSourceResource, cResource, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS MoveToResource Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsMoveToResource(targetResource)
 	}
 
-	return nil
+	return obj.cMoveToResource(targetResource)
 }
 
 // Replicate copies the data object to the specified resource.
 // Accepts string or *Resource type for targetResource parameter.
 func (obj *DataObj) Replicate(targetResource interface{}, opts DataObjOptions) error {
-
-	var (
-		err         *C.char
-		resourceStr string
-	)
-
-	switch targetResource.(type) {
-	case string:
-		resourceStr = targetResource.(string)
-	case *Resource:
-		resourceStr = (targetResource.(*Resource)).Name()
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
-
-	}
-
-	cPath := C.CString(obj.Path())
-	cResource := C.CString(resourceStr)
-	defer C.free(unsafe.Pointer(cPath))
-	defer C.free(unsafe.Pointer(cResource))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_repl_dataobject(ccon, cPath, cResource, C.int(0), C.int(opts.Mode), C.rodsLong_t(opts.Size), &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS ReplicateOpts Failed: %v, %v", obj.path, C.GoString(err)))
+	if obj.con.fs != nil {
+		return obj.fsReplicate(targetResource, opts, false)
 	}
 
-	return nil
+	return obj.cReplicate(targetResource, opts)
 }
 
 // Backup is similar to Replicate. In backup mode, if a good copy already exists in this resource group or resource, don't make another one.
 func (obj *DataObj) Backup(targetResource interface{}, opts DataObjOptions) error {
-
-	var (
-		err         *C.char
-		resourceStr string
-	)
-
-	switch targetResource.(type) {
-	case string:
-		resourceStr = targetResource.(string)
-	case *Resource:
-		resourceStr = (targetResource.(*Resource)).Name()
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
-
+	if obj.con.fs != nil {
+		return obj.fsReplicate(targetResource, opts, true)
 	}
 
-	cPath := C.CString(obj.Path())
-	cResource := C.CString(resourceStr)
-	defer C.free(unsafe.Pointer(cPath))
-	defer C.free(unsafe.Pointer(cResource))
-
-	ccon := obj.con.GetCcon()
-	defer obj.con.ReturnCcon(ccon)
-
-	if status := C.gorods_repl_dataobject(ccon, cPath, cResource, C.int(1), C.int(opts.Mode), C.rodsLong_t(opts.Size), &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS Backup Failed: %v, %v", obj.path, C.GoString(err)))
-	}
-
-	return nil
+	return obj.cBackup(targetResource, opts)
 }
@@ -0,0 +1,1096 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// cDataObj holds the C API handle of an opened data object
+type cDataObj struct {
+	openedAs C.int
+	chandle  C.int
+}
+
+// init function called from Collection.ReadCollection()
+// We don't init() here or return errors here because it takes forever. Lazy loading is better in this case.
+func initDataObj(data *C.collEnt_t, col *Collection, con *Connection) *DataObj {
+
+	dataObj := new(DataObj)
+
+	dataObj.typ = DataObjType
+	dataObj.col = col
+	dataObj.con = con
+	dataObj.offset = 0
+	dataObj.name = C.GoString(data.dataName)
+	dataObj.path = C.GoString(data.collName) + "/" + dataObj.name
+	dataObj.size = int64(data.dataSize)
+	dataObj.chandle = C.int(-1)
+	dataObj.checksum = C.GoString(data.chksum)
+	dataObj.dataId = C.GoString(data.dataId)
+	dataObj.phyPath = C.GoString(data.phyPath)
+	dataObj.openedAs = C.int(-1)
+
+	dataObj.replNum = int(data.replNum)
+	dataObj.rescHier = C.GoString(data.resc_hier)
+	dataObj.replStatus = int(data.replStatus)
+
+	dataObj.ownerName = C.GoString(data.ownerName)
+	dataObj.createTime = cTimeToTime(data.createTime)
+	dataObj.modifyTime = cTimeToTime(data.modifyTime)
+
+	if rsrcs, err := dataObj.con.Resources(); err != nil {
+		return nil
+	} else {
+		if r := rsrcs.FindByName(C.GoString(data.resource)); r != nil {
+			dataObj.resource = r
+		}
+	}
+
+	if usrs, err := dataObj.con.Users(); err != nil {
+		return nil
+	} else {
+		if u := usrs.FindByName(dataObj.ownerName, dataObj.con); u != nil {
+			dataObj.owner = u
+		}
+	}
+
+	return dataObj
+}
+
+func cFetchDataObj(startPath string, con *Connection, skipCache bool) (*DataObj, error) {
+	var cObjData C.collEnt_t
+
+	ccon := con.GetCcon()
+
+	cPath := C.CString(startPath)
+	defer C.free(unsafe.Pointer(cPath))
+
+	if status := C.gorods_get_dataobject(ccon, cPath, &cObjData); status < 0 {
+		con.ReturnCcon(ccon)
+		return nil, newError(Fatal, -1, fmt.Sprintf("Error getting data object at %v", startPath))
+	}
+
+	con.ReturnCcon(ccon)
+
+	collectionDir := filepath.Dir(startPath)
+
+	opts := CollectionOptions{
+		Path:      collectionDir,
+		Recursive: false,
+		SkipCache: skipCache,
+	}
+
+	if col, err := con.Collection(opts); err == nil {
+		if skipCache {
+			col.Close()
+		}
+
+		return initDataObj(&cObjData, col, con), nil
+	} else {
+		// Couldn't open the parent collection...
+		return initDataObj(&cObjData, nil, con), nil
+	}
+
+}
+
+func cCreateDataObj(opts DataObjOptions, coll *Collection) (*DataObj, error) {
+	var (
+		errMsg   *C.char
+		handle   C.int
+		force    int
+		resource *C.char
+	)
+
+	if opts.Force {
+		force = 1
+	} else {
+		force = 0
+	}
+
+	if opts.Resource != nil {
+		switch opts.Resource.(type) {
+		case string:
+			resource = C.CString(opts.Resource.(string))
+		case *Resource:
+			r := opts.Resource.(*Resource)
+			resource = C.CString(r.Name())
+		default:
+			return nil, newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
+		}
+	} else {
+		resource = C.CString("")
+	}
+
+	path := C.CString(coll.path + "/" + opts.Name)
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(resource))
+
+	ccon := coll.con.GetCcon()
+
+	if status := C.gorods_create_dataobject(path, C.rodsLong_t(opts.Size), C.int(opts.Mode), C.int(force), resource, &handle, ccon, &errMsg); status != 0 {
+		coll.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Create DataObject Failed: %v, Does the file already exist?", C.GoString(errMsg)))
+	}
+	coll.con.ReturnCcon(ccon)
+
+	// if err := coll.Refresh(); err != nil {
+	// 	return nil, err
+	// }
+
+	if do, err := getDataObj(C.GoString(path), coll.con); err != nil {
+		return nil, err
+	} else {
+		return do, nil
+	}
+
+}
+
+func (obj *DataObj) cIsOpen() bool {
+	return int(obj.chandle) > -1
+}
+
+func (obj *DataObj) cACL() (ACLs, error) {
+	var (
+		result   C.goRodsACLResult_t
+		err      *C.char
+		zoneHint *C.char
+	)
+
+	zone, zErr := obj.con.LocalZone()
+	if zErr != nil {
+		return nil, zErr
+	} else {
+		zoneHint = C.CString(zone.Name())
+	}
+
+	cDataId := C.CString(obj.dataId)
+	defer C.free(unsafe.Pointer(cDataId))
+	defer C.free(unsafe.Pointer(zoneHint))
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_get_dataobject_acl(ccon, cDataId, &result, zoneHint, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Data Object ACL Failed: %v", C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	return aclSliceToResponse(&result, zone, obj.con)
+
+}
+
+func (obj *DataObj) cHandle() int {
+	return int(obj.chandle)
+}
+
+func (obj *DataObj) cRm(recursive bool, force bool) error {
+	var errMsg *C.char
+
+	path := C.CString(obj.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	var (
+		cForce     C.int
+		cRecursive C.int
+	)
+
+	if force {
+		cForce = C.int(1)
+	}
+
+	if recursive {
+		cRecursive = C.int(1)
+	}
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_rm(path, 0, cRecursive, cForce, C.int(0), ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Rm DataObject Failed: %v", C.GoString(errMsg)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cRmTrash() error {
+	var errMsg *C.char
+
+	path := C.CString(obj.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_rm(path, C.int(0), C.int(1), C.int(1), C.int(1), ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS RmTrash DataObject Failed: %v", C.GoString(errMsg)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cOpen() error {
+	var errMsg *C.char
+
+	path := C.CString(obj.path)
+	resourceName := C.CString(obj.resource.Name())
+	replNum := C.CString(strconv.Itoa(obj.replNum))
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(resourceName))
+	defer C.free(unsafe.Pointer(replNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_open_dataobject(path, resourceName, replNum, C.O_RDONLY, &obj.chandle, ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Open DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
+	}
+
+	obj.openedAs = C.O_RDONLY
+
+	return nil
+}
+
+func (obj *DataObj) cOpenRW() error {
+	var errMsg *C.char
+
+	path := C.CString(obj.path)
+	resourceName := C.CString(obj.resource.Name())
+	replNum := C.CString(strconv.Itoa(obj.replNum))
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(resourceName))
+	defer C.free(unsafe.Pointer(replNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_open_dataobject(path, resourceName, replNum, C.O_RDWR, &obj.chandle, ccon, &errMsg); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS OpenRW DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
+	}
+
+	obj.openedAs = C.O_RDWR
+
+	return nil
+}
+
+func (obj *DataObj) cClose() error {
+	var errMsg *C.char
+
+	if int(obj.chandle) > -1 {
+
+		ccon := obj.con.GetCcon()
+		defer obj.con.ReturnCcon(ccon)
+
+		if status := C.gorods_close_dataobject(obj.chandle, ccon, &errMsg); status != 0 {
+			return newError(Fatal, status, fmt.Sprintf("iRODS Close DataObject Failed: %v, %v", obj.path, C.GoString(errMsg)))
+		}
+
+		obj.chandle = C.int(-1)
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cRead() ([]byte, error) {
+	if er := obj.init(); er != nil {
+		return nil, er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(0); er != nil {
+		return nil, er
+	}
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(obj.size), &buffer, &bytesRead, ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	buf := unsafe.Pointer(buffer.buf)
+	defer C.free(buf)
+
+	data := C.GoBytes(buf, bytesRead)
+
+	return data, obj.Close()
+}
+
+func (br ByteArr) Free() {
+	C.free(br.Ptr)
+}
+
+func (obj *DataObj) cReadChunkFree(size int64, callback func(*ByteArr)) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	for obj.offset < obj.size {
+
+		ccon := obj.con.GetCcon()
+
+		if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(size), &buffer, &bytesRead, ccon, &err); status != 0 {
+			obj.con.ReturnCcon(ccon)
+			return newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+		}
+
+		obj.con.ReturnCcon(ccon)
+
+		buf := unsafe.Pointer(buffer.buf)
+
+		bufLen := int(buffer.len)
+		data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
+
+		callback(&ByteArr{
+			Contents: data,
+			Ptr:      buf,
+		})
+
+		if er := obj.LSeek(obj.offset + size); er != nil {
+			return er
+		}
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	return obj.Close()
+}
+
+func (obj *DataObj) cFastReadFree(pos int64, length int) (*ByteArr, error) {
+	if er := obj.init(); er != nil {
+		return nil, er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(pos); er != nil {
+		return nil, er
+	}
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	buf := unsafe.Pointer(buffer.buf)
+
+	bufLen := int(buffer.len)
+	data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
+
+	return &ByteArr{
+		Contents: data,
+		Ptr:      buf,
+	}, nil
+}
+
+func (obj *DataObj) cFastRead(pos int64, length int, callback func([]byte) error) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(pos); er != nil {
+		return er
+	}
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	buf := unsafe.Pointer(buffer.buf)
+	defer C.free(buf)
+
+	bufLen := int(buffer.len)
+
+	data := (*[1 << 30]byte)(unsafe.Pointer(buf))[:bufLen:bufLen]
+
+	return callback(data)
+}
+
+func (obj *DataObj) cReadBytes(pos int64, length int) ([]byte, error) {
+	if er := obj.init(); er != nil {
+		return nil, er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(pos); er != nil {
+		return nil, er
+	}
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(length), &buffer, &bytesRead, ccon, &err); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	buf := unsafe.Pointer(buffer.buf)
+	defer C.free(buf)
+
+	data := C.GoBytes(buf, bytesRead)
+
+	return data, nil
+}
+
+func (obj *DataObj) cLSeek(offset int64) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	var (
+		err *C.char
+	)
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_lseek_dataobject(obj.chandle, C.rodsLong_t(offset), ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS LSeek DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.offset = offset
+
+	return nil
+}
+
+func (obj *DataObj) cReadChunk(size int64, callback func([]byte)) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	var (
+		buffer    C.bytesBuf_t
+		err       *C.char
+		bytesRead C.int
+	)
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	for obj.offset < obj.size {
+
+		ccon := obj.con.GetCcon()
+
+		if status := C.gorods_read_dataobject(obj.chandle, C.rodsLong_t(size), &buffer, &bytesRead, ccon, &err); status != 0 {
+			obj.con.ReturnCcon(ccon)
+			return newError(Fatal, status, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+		}
+
+		obj.con.ReturnCcon(ccon)
+
+		buf := unsafe.Pointer(buffer.buf)
+
+		chunk := C.GoBytes(buf, bytesRead)
+
+		C.free(buf)
+
+		callback(chunk)
+
+		if er := obj.LSeek(obj.offset + size); er != nil {
+			return er
+		}
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	return obj.Close()
+}
+
+func (obj *DataObj) cWrite(data []byte) error {
+	if er := obj.initRW(); er != nil {
+		return er
+	}
+
+	if !(obj.openedAs == C.O_RDWR || obj.openedAs == C.O_WRONLY) {
+		obj.Close()
+		obj.OpenRW()
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	size := int64(len(data))
+
+	dataPointer := unsafe.Pointer(&data[0]) // Do I need to free this? It might be done by go
+
+	var err *C.char
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_write_dataobject(obj.chandle, dataPointer, C.int(size), ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	obj.size = size
+
+	return obj.Close()
+}
+
+func (obj *DataObj) cWriteBytes(data []byte) error {
+	if er := obj.initRW(); er != nil {
+		return er
+	}
+
+	if !(obj.openedAs == C.O_RDWR || obj.openedAs == C.O_WRONLY) {
+		obj.Close()
+		obj.OpenRW()
+	}
+
+	size := int64(len(data))
+
+	dataPointer := unsafe.Pointer(&data[0]) // Do I need to free this? It might be done by go
+
+	var err *C.char
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_write_dataobject(obj.chandle, dataPointer, C.int(size), ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	obj.size = size + obj.offset
+
+	return obj.LSeek(obj.size)
+}
+
+func (obj *DataObj) cStat() (map[string]interface{}, error) {
+	var (
+		err        *C.char
+		statResult *C.rodsObjStat_t
+	)
+
+	path := C.CString(obj.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_stat_dataobject(path, &statResult, ccon, &err); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Close Stat Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	result := make(map[string]interface{})
+
+	result["objSize"] = int(statResult.objSize)
+	result["dataMode"] = int(statResult.dataMode)
+
+	result["dataId"] = C.GoString(&statResult.dataId[0])
+	result["chksum"] = C.GoString(&statResult.chksum[0])
+	result["ownerName"] = C.GoString(&statResult.ownerName[0])
+	result["ownerZone"] = C.GoString(&statResult.ownerZone[0])
+	result["createTime"] = C.GoString(&statResult.createTime[0])
+	result["modifyTime"] = C.GoString(&statResult.modifyTime[0])
+
+	C.freeRodsObjStat(statResult)
+
+	return result, nil
+}
+
+func (obj *DataObj) cCopyTo(iRODSCollection interface{}) error {
+	var (
+		err                         *C.char
+		destination                 string
+		destinationCollectionString string
+		destinationCollection       *Collection
+	)
+
+	switch iRODSCollection.(type) {
+	case string:
+		destinationCollectionString = iRODSCollection.(string)
+
+		// Is this a relative path?
+		if destinationCollectionString[0] != '/' {
+			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
+		}
+
+		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
+			destinationCollectionString += "/"
+		}
+
+		destination += destinationCollectionString + obj.name
+	case *Collection:
+		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
+		destination = destinationCollectionString + obj.name
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	path := C.CString(obj.path)
+	dest := C.CString(destination)
+	resource := C.CString("")
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(dest))
+	defer C.free(unsafe.Pointer(resource))
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_copy_dataobject(path, dest, C.int(0), resource, ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	// Find & reload destination collection
+	switch iRODSCollection.(type) {
+	case string:
+		var colEr error
+
+		// Can't find, load collection into memory
+		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
+			Path:      destinationCollectionString,
+			Recursive: false,
+		})
+		if colEr != nil {
+			return colEr
+		}
+
+	case *Collection:
+		destinationCollection = (iRODSCollection.(*Collection))
+
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	destinationCollection.Refresh()
+
+	return nil
+}
+
+func (obj *DataObj) cCopyToOpts(iRODSCollection interface{}, opts DataObjOptions) error {
+	var (
+		err                         *C.char
+		resource                    *C.char
+		force                       int
+		destination                 string
+		destinationCollectionString string
+		destinationCollection       *Collection
+	)
+
+	switch iRODSCollection.(type) {
+	case string:
+		destinationCollectionString = iRODSCollection.(string)
+
+		// Is this a relative path?
+		if destinationCollectionString[0] != '/' {
+			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
+		}
+
+		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
+			destinationCollectionString += "/"
+		}
+
+		destination += destinationCollectionString + obj.name
+	case *Collection:
+		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
+		destination = destinationCollectionString + obj.name
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	if opts.Force {
+		force = 1
+	} else {
+		force = 0
+	}
+
+	switch opts.Resource.(type) {
+	case string:
+		resource = C.CString(opts.Resource.(string))
+	case *Resource:
+		r := opts.Resource.(*Resource)
+		resource = C.CString(r.Name())
+	default:
+		newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
+	}
+
+	path := C.CString(obj.path)
+	dest := C.CString(destination)
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(dest))
+	defer C.free(unsafe.Pointer(resource))
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_copy_dataobject(path, dest, C.int(force), resource, ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	// Find & reload destination collection
+	switch iRODSCollection.(type) {
+	case string:
+		var colEr error
+
+		// Can't find, load collection into memory
+		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
+			Path:      destinationCollectionString,
+			Recursive: false,
+		})
+		if colEr != nil {
+			return colEr
+		}
+
+	case *Collection:
+		destinationCollection = (iRODSCollection.(*Collection))
+
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	destinationCollection.Refresh()
+
+	return nil
+}
+
+func (obj *DataObj) cMoveTo(iRODSCollection interface{}) error {
+	var (
+		err                         *C.char
+		destination                 string
+		destinationCollectionString string
+		destinationCollection       *Collection
+	)
+
+	switch iRODSCollection.(type) {
+	case string:
+		destinationCollectionString = iRODSCollection.(string)
+
+		// Is this a relative path?
+		if destinationCollectionString[0] != '/' {
+			destinationCollectionString = obj.col.path + "/" + destinationCollectionString
+		}
+
+		if destinationCollectionString[len(destinationCollectionString)-1] != '/' {
+			destinationCollectionString += "/"
+		}
+
+		destination += destinationCollectionString + obj.name
+	case *Collection:
+		destinationCollectionString = (iRODSCollection.(*Collection)).path + "/"
+		destination = destinationCollectionString + obj.name
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	path := C.CString(obj.path)
+	dest := C.CString(destination)
+
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(dest))
+
+	ccon := obj.con.GetCcon()
+
+	if status := C.gorods_move_dataobject(path, dest, C.RENAME_DATA_OBJ, ccon, &err); status != 0 {
+		obj.con.ReturnCcon(ccon)
+		return newError(Fatal, status, fmt.Sprintf("iRODS Move DataObject Failed S:%v, D:%v, %v", obj.path, destination, C.GoString(err)))
+	}
+
+	obj.con.ReturnCcon(ccon)
+
+	// Reload source collection, we are now detached
+	obj.col.Refresh()
+
+	// Find & reload destination collection
+	switch iRODSCollection.(type) {
+	case string:
+		var colEr error
+
+		// Can't find, load collection into memory
+		destinationCollection, colEr = obj.con.Collection(CollectionOptions{
+			Path:      destinationCollectionString,
+			Recursive: false,
+		})
+		if colEr != nil {
+			return colEr
+		}
+
+	case *Collection:
+		destinationCollection = (iRODSCollection.(*Collection))
+
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed, unknown variable type passed as collection"))
+	}
+
+	destinationCollection.Refresh()
+
+	// Reassign obj.col to destination collection
+	obj.col = destinationCollection
+	obj.path = destinationCollection.path + "/" + obj.name
+
+	obj.chandle = C.int(-1)
+
+	return nil
+}
+
+func (obj *DataObj) cRename(newFileName string) error {
+	if strings.Contains(newFileName, "/") {
+		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
+	}
+
+	var err *C.char
+
+	source := obj.path
+	destination := obj.col.path + "/" + newFileName
+
+	s := C.CString(source)
+	d := C.CString(destination)
+
+	defer C.free(unsafe.Pointer(s))
+	defer C.free(unsafe.Pointer(d))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_move_dataobject(s, d, C.RENAME_DATA_OBJ, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Rename DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.name = newFileName
+	obj.path = destination
+
+	obj.chandle = C.int(-1)
+
+	return nil
+}
+
+func (obj *DataObj) cChksum() (string, error) {
+	var (
+		err       *C.char
+		chksumOut *C.char
+	)
+
+	path := C.CString(obj.path)
+
+	defer C.free(unsafe.Pointer(path))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_checksum_dataobject(path, &chksumOut, ccon, &err); status != 0 {
+		return "", newError(Fatal, status, fmt.Sprintf("iRODS Chksum DataObject Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	obj.checksum = C.GoString(chksumOut)
+
+	C.free(unsafe.Pointer(chksumOut))
+
+	return obj.checksum, nil
+}
+
+func (obj *DataObj) cTrimRepls(opts TrimOptions) error {
+	var (
+		err         *C.char
+		resourceStr string
+	)
+
+	switch opts.TargetResource.(type) {
+	case string:
+		resourceStr = opts.TargetResource.(string)
+	case *Resource:
+		resourceStr = (opts.TargetResource.(*Resource)).Name()
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+
+	}
+
+	cNumCopies := C.CString(strconv.Itoa(opts.NumCopiesKeep))
+	cAgeStr := C.CString(strconv.Itoa(opts.MinAgeMins))
+	cPath := C.CString(obj.Path())
+	cResource := C.CString(resourceStr)
+	defer C.free(unsafe.Pointer(cNumCopies))
+	defer C.free(unsafe.Pointer(cAgeStr))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_trimrepls_dataobject(ccon, cPath, cAgeStr, cResource, cNumCopies, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS TrimRepls Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cMoveToResource(targetResource interface{}) error {
+	var (
+		err         *C.char
+		resourceStr string
+	)
+
+	switch targetResource.(type) {
+	case string:
+		resourceStr = targetResource.(string)
+	case *Resource:
+		resourceStr = (targetResource.(*Resource)).Name()
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+
+	}
+
+	cSourceResource := C.CString(obj.resource.name)
+	cPath := C.CString(obj.Path())
+	cResource := C.CString(resourceStr)
+	defer C.free(unsafe.Pointer(cSourceResource))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_phymv_dataobject(ccon, cPath, cSourceResource, cResource, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS MoveToResource Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cReplicate(targetResource interface{}, opts DataObjOptions) error {
+	var (
+		err         *C.char
+		resourceStr string
+	)
+
+	switch targetResource.(type) {
+	case string:
+		resourceStr = targetResource.(string)
+	case *Resource:
+		resourceStr = (targetResource.(*Resource)).Name()
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+
+	}
+
+	verify, er := obj.verifyChecksum(opts)
+	if er != nil {
+		return er
+	}
+
+	var cVerify C.int
+	if verify {
+		cVerify = C.int(1)
+	}
+
+	cPath := C.CString(obj.Path())
+	cResource := C.CString(resourceStr)
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_repl_dataobject(ccon, cPath, cResource, C.int(0), C.int(opts.Mode), C.rodsLong_t(opts.Size), cVerify, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS ReplicateOpts Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cBackup(targetResource interface{}, opts DataObjOptions) error {
+	var (
+		err         *C.char
+		resourceStr string
+	)
+
+	switch targetResource.(type) {
+	case string:
+		resourceStr = targetResource.(string)
+	case *Resource:
+		resourceStr = (targetResource.(*Resource)).Name()
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+
+	}
+
+	verify, er := obj.verifyChecksum(opts)
+	if er != nil {
+		return er
+	}
+
+	var cVerify C.int
+	if verify {
+		cVerify = C.int(1)
+	}
+
+	cPath := C.CString(obj.Path())
+	cResource := C.CString(resourceStr)
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cResource))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_repl_dataobject(ccon, cPath, cResource, C.int(1), C.int(opts.Mode), C.rodsLong_t(opts.Size), cVerify, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS Backup Failed: %v, %v", obj.path, C.GoString(err)))
+	}
+
+	return nil
+}
@@ -0,0 +1,788 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// resourceName returns the name of a string or *Resource, empty for the default resource when nil
+func resourceName(resource interface{}) (string, error) {
+	switch r := resource.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return r, nil
+	case *Resource:
+		return r.Name(), nil
+	}
+
+	return "", newError(Fatal, -1, fmt.Sprintf("Wrong variable type passed in Resource field"))
+}
+
+// fsInitDataObj is initDataObj for the entries of FilesystemDefined connections. repl, when set, is the replica
+// the data object describes, the replica isn't known otherwise.
+func fsInitDataObj(info irodsfs.ObjInfo, repl *irodsfs.Replica, col *Collection, con *Connection) *DataObj {
+	dataObj := new(DataObj)
+
+	dataObj.typ = DataObjType
+	dataObj.col = col
+	dataObj.con = con
+	dataObj.offset = 0
+	dataObj.name = info.Name
+	dataObj.path = info.Path
+	dataObj.size = info.Size
+	dataObj.checksum = info.Checksum
+	dataObj.dataId = strconv.FormatInt(info.Id, 10)
+
+	dataObj.createTime = info.CreateTime
+	dataObj.modifyTime = info.ModifyTime
+
+	if repl != nil {
+		dataObj.replNum = repl.Num
+		dataObj.rescHier = repl.RescHier
+		dataObj.replStatus = repl.Status
+		dataObj.size = repl.Size
+		dataObj.checksum = repl.Checksum
+		dataObj.phyPath = repl.PhysicalPath
+		dataObj.modifyTime = repl.ModifyTime
+
+		if rsrcs, err := con.Resources(); err != nil {
+			return nil
+		} else {
+			if r := rsrcs.FindByName(repl.Resource); r != nil {
+				dataObj.resource = r
+			}
+		}
+	}
+
+	if name, u, err := fsOwner(con, info.Owner); err != nil {
+		return nil
+	} else {
+		dataObj.ownerName = name
+		dataObj.owner = u
+	}
+
+	return dataObj
+}
+
+// fsFetchDataObj is fetchDataObj for FilesystemDefined connections. The data object describes its first good
+// replica when the Filesystem has replicas.
+func fsFetchDataObj(startPath string, con *Connection, skipCache bool) (*DataObj, error) {
+	info, err := con.fs.Stat(startPath)
+	if err != nil || info.IsDir() {
+		return nil, newError(Fatal, -1, fmt.Sprintf("Error getting data object at %v", startPath))
+	}
+
+	var repl *irodsfs.Replica
+
+	if rfs, ok := con.fs.(irodsfs.ReplicaFS); ok {
+		repls, err := rfs.Replicas(startPath)
+		if err != nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("Error getting data object at %v, %v", startPath, err))
+		}
+
+		for inx := range repls {
+			if repl == nil || (repls[inx].Status == irodsfs.Good && repl.Status != irodsfs.Good) {
+				repl = &repls[inx]
+			}
+		}
+	}
+
+	collectionDir := filepath.Dir(startPath)
+
+	opts := CollectionOptions{
+		Path:      collectionDir,
+		Recursive: false,
+		SkipCache: skipCache,
+	}
+
+	if col, err := con.Collection(opts); err == nil {
+		if skipCache {
+			col.Close()
+		}
+
+		return fsInitDataObj(info, repl, col, con), nil
+	} else {
+		// Couldn't open the parent collection...
+		return fsInitDataObj(info, repl, nil, con), nil
+	}
+}
+
+// fsCreate creates the data object p with the content of r, which may be nil, on opts.Resource. The Filesystem
+// creates data objects on its default resource, they're moved to opts.Resource with an irodsfs.ReplicaFS.
+func (con *Connection) fsCreate(p string, r io.Reader, opts DataObjOptions) error {
+	resource, err := resourceName(opts.Resource)
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.Force {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := con.fs.Open(p, flag)
+	if err != nil {
+		return err
+	}
+
+	if r != nil {
+		if _, err := io.Copy(file, r); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if resource == "" {
+		return nil
+	}
+
+	rfs, ok := con.fs.(irodsfs.ReplicaFS)
+	if !ok {
+		return unsupported("Create DataObject on a resource")
+	}
+
+	repls, err := rfs.Replicas(p)
+	if err != nil {
+		return err
+	}
+
+	if len(repls) == 1 && repls[0].Resource != resource {
+		return rfs.PhysicalMove(p, repls[0].Resource, resource)
+	}
+
+	return nil
+}
+
+func fsCreateDataObj(opts DataObjOptions, coll *Collection) (*DataObj, error) {
+	p := coll.path + "/" + opts.Name
+
+	if err := coll.con.fsCreate(p, nil, opts); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Create DataObject Failed: %v, Does the file already exist?", err))
+	}
+
+	return getDataObj(p, coll.con)
+}
+
+// fsOpen opens the data object with the flag of os.OpenFile, closing the handle it had
+func (obj *DataObj) fsOpen(flag int) error {
+	if err := obj.fsClose(); err != nil {
+		return err
+	}
+
+	file, err := obj.con.fs.Open(obj.path, flag)
+	if err != nil {
+		op := "Open"
+		if flag != os.O_RDONLY {
+			op = "OpenRW"
+		}
+
+		return newError(Fatal, -1, fmt.Sprintf("iRODS %v DataObject Failed: %v, %v", op, obj.path, err))
+	}
+
+	obj.file = file
+	obj.fileFlag = flag
+	obj.offset = 0
+
+	return nil
+}
+
+func (obj *DataObj) fsClose() error {
+	if obj.file != nil {
+		err := obj.file.Close()
+		obj.file = nil
+
+		if err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Close DataObject Failed: %v, %v", obj.path, err))
+		}
+	}
+
+	return nil
+}
+
+func (obj *DataObj) fsRead() ([]byte, error) {
+	if er := obj.init(); er != nil {
+		return nil, er
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return nil, er
+	}
+
+	data, err := ioutil.ReadAll(obj.file)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	return data, obj.Close()
+}
+
+// fsReadAt reads up to length bytes at pos, fewer at the end of the data object
+func (obj *DataObj) fsReadAt(pos int64, length int) ([]byte, error) {
+	if er := obj.init(); er != nil {
+		return nil, er
+	}
+
+	if er := obj.LSeek(pos); er != nil {
+		return nil, er
+	}
+
+	buf := make([]byte, length)
+
+	n, err := io.ReadFull(obj.file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS ReadBytes DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	return buf[:n], nil
+}
+
+func (obj *DataObj) fsReadBytes(pos int64, length int) ([]byte, error) {
+	return obj.fsReadAt(pos, length)
+}
+
+// fsFastReadFree returns the bytes in a ByteArr without C memory, its Free is a no-op
+func (obj *DataObj) fsFastReadFree(pos int64, length int) (*ByteArr, error) {
+	data, err := obj.fsReadAt(pos, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ByteArr{Contents: data}, nil
+}
+
+func (obj *DataObj) fsFastRead(pos int64, length int, callback func([]byte) error) error {
+	data, err := obj.fsReadAt(pos, length)
+	if err != nil {
+		return err
+	}
+
+	return callback(data)
+}
+
+func (obj *DataObj) fsLSeek(offset int64) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	if _, err := obj.file.Seek(offset, io.SeekStart); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS LSeek DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	obj.offset = offset
+
+	return nil
+}
+
+func (obj *DataObj) fsReadChunk(size int64, callback func([]byte)) error {
+	if er := obj.init(); er != nil {
+		return er
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	for obj.offset < obj.size {
+		chunk := make([]byte, size)
+
+		n, err := io.ReadFull(obj.file, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS Read DataObject Failed: %v, %v", obj.path, err))
+		}
+
+		callback(chunk[:n])
+
+		if er := obj.LSeek(obj.offset + size); er != nil {
+			return er
+		}
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	return obj.Close()
+}
+
+// fsWritable reopens the data object for reading and writing when it was opened read only
+func (obj *DataObj) fsWritable() error {
+	if er := obj.initRW(); er != nil {
+		return er
+	}
+
+	if obj.fileFlag&(os.O_RDWR|os.O_WRONLY) == 0 {
+		return obj.fsOpen(os.O_RDWR)
+	}
+
+	return nil
+}
+
+func (obj *DataObj) fsWrite(data []byte) error {
+	if er := obj.fsWritable(); er != nil {
+		return er
+	}
+
+	if er := obj.LSeek(0); er != nil {
+		return er
+	}
+
+	if _, err := obj.file.Write(data); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	obj.size = int64(len(data))
+
+	return obj.Close()
+}
+
+func (obj *DataObj) fsWriteBytes(data []byte) error {
+	if er := obj.fsWritable(); er != nil {
+		return er
+	}
+
+	if _, err := obj.file.Write(data); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Write DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	obj.size = int64(len(data)) + obj.offset
+
+	return obj.LSeek(obj.size)
+}
+
+func (obj *DataObj) fsStat() (map[string]interface{}, error) {
+	info, err := obj.con.fs.Stat(obj.path)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Close Stat Failed: %v, %v", obj.path, err))
+	}
+
+	return statMap(info), nil
+}
+
+// fsCopyTo copies the data object with the Copy(src, dest string) error method of the Filesystem when it has
+// one, the data is read and written through the connection otherwise
+func (obj *DataObj) fsCopyTo(iRODSCollection interface{}, opts DataObjOptions) error {
+	destinationCollectionString, destination, err := fsDestination(iRODSCollection, obj.col.path, obj.name, "Copy DataObject")
+	if err != nil {
+		return err
+	}
+
+	resource, err := resourceName(opts.Resource)
+	if err != nil {
+		return err
+	}
+
+	if opts.Force {
+		if _, err := obj.con.fs.Stat(destination); err == nil {
+			if err := obj.con.fs.Remove(destination, false); err != nil {
+				return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, err))
+			}
+		}
+	}
+
+	if copier, ok := obj.con.fs.(interface{ Copy(string, string) error }); ok && resource == "" {
+		err = copier.Copy(obj.path, destination)
+	} else {
+		var src irodsfs.File
+
+		if src, err = obj.con.fs.Open(obj.path, os.O_RDONLY); err == nil {
+			err = obj.con.fsCreate(destination, src, DataObjOptions{Resource: resource})
+			src.Close()
+		}
+	}
+
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Copy DataObject Failed: %v, %v", destination, err))
+	}
+
+	destinationCollection, err := fsDestinationCollection(obj.con, iRODSCollection, destinationCollectionString)
+	if err != nil {
+		return err
+	}
+
+	destinationCollection.Refresh()
+
+	return nil
+}
+
+func (obj *DataObj) fsMoveTo(iRODSCollection interface{}) error {
+	destinationCollectionString, destination, err := fsDestination(iRODSCollection, obj.col.path, obj.name, "Move DataObject")
+	if err != nil {
+		return err
+	}
+
+	if err := obj.fsClose(); err != nil {
+		return err
+	}
+
+	if err := obj.con.fs.Rename(obj.path, destination); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Move DataObject Failed S:%v, D:%v, %v", obj.path, destination, err))
+	}
+
+	// Reload source collection, we are now detached
+	obj.col.Refresh()
+
+	destinationCollection, err := fsDestinationCollection(obj.con, iRODSCollection, destinationCollectionString)
+	if err != nil {
+		return err
+	}
+
+	destinationCollection.Refresh()
+
+	// Reassign obj.col to destination collection
+	obj.col = destinationCollection
+	obj.path = destinationCollection.path + "/" + obj.name
+
+	return nil
+}
+
+func (obj *DataObj) fsRename(newFileName string) error {
+	if strings.Contains(newFileName, "/") {
+		return newError(Fatal, -1, fmt.Sprintf("Can't Rename DataObject, path detected in: %v", newFileName))
+	}
+
+	destination := obj.col.path + "/" + newFileName
+
+	if err := obj.fsClose(); err != nil {
+		return err
+	}
+
+	if err := obj.con.fs.Rename(obj.path, destination); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Rename DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	obj.name = newFileName
+	obj.path = destination
+
+	return nil
+}
+
+// fsChksum uses the Checksum(p string) (string, error) method of the Filesystem when it has one, and the
+// replica of the data object otherwise
+func (obj *DataObj) fsChksum() (string, error) {
+	var (
+		sum string
+		err error
+	)
+
+	if summer, ok := obj.con.fs.(interface{ Checksum(string) (string, error) }); ok {
+		sum, err = summer.Checksum(obj.path)
+	} else if rfs, ok := obj.con.fs.(irodsfs.ReplicaFS); ok {
+		sum, err = rfs.ChecksumReplica(obj.path, obj.replNum)
+	} else {
+		return "", unsupported("Chksum DataObject")
+	}
+
+	if err != nil {
+		return "", newError(Fatal, -1, fmt.Sprintf("iRODS Chksum DataObject Failed: %v, %v", obj.path, err))
+	}
+
+	obj.checksum = sum
+
+	return obj.checksum, nil
+}
+
+// replicaFS returns the irodsfs.ReplicaFS of the connection, op names the call it doesn't support otherwise
+func (obj *DataObj) replicaFS(op string) (irodsfs.ReplicaFS, error) {
+	rfs, ok := obj.con.fs.(irodsfs.ReplicaFS)
+	if !ok {
+		return nil, unsupported(op)
+	}
+
+	return rfs, nil
+}
+
+func (obj *DataObj) fsTrimRepls(opts TrimOptions) error {
+	resourceStr, err := resourceName(opts.TargetResource)
+	if err != nil || opts.TargetResource == nil {
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+	}
+
+	repls, err := obj.Replicas()
+	if err != nil {
+		return err
+	}
+
+	rfs, err := obj.replicaFS("TrimRepls")
+	if err != nil {
+		return err
+	}
+
+	remaining := len(repls)
+
+	// Like itrim, the replicas with the highest numbers are trimmed first
+	for inx := len(repls) - 1; inx >= 0 && remaining > opts.NumCopiesKeep; inx-- {
+		repl := repls[inx]
+
+		if resourceStr != "" && repl.Resource != resourceStr {
+			continue
+		}
+
+		if time.Since(repl.ModifyTime) < time.Duration(opts.MinAgeMins)*time.Minute {
+			continue
+		}
+
+		if err := rfs.Trim(obj.path, repl.Num); err != nil {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS TrimRepls Failed: %v, %v", obj.path, err))
+		}
+
+		remaining--
+	}
+
+	return nil
+}
+
+// fsMoveToResource moves the replica the data object describes, its first replica when it describes none
+func (obj *DataObj) fsMoveToResource(targetResource interface{}) error {
+	resourceStr, err := resourceName(targetResource)
+	if err != nil || targetResource == nil {
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+	}
+
+	var source string
+
+	if obj.resource != nil {
+		source = obj.resource.Name()
+	} else {
+		repls, err := obj.Replicas()
+		if err != nil {
+			return err
+		}
+
+		source = repls[0].Resource
+	}
+
+	rfs, err := obj.replicaFS("MoveToResource")
+	if err != nil {
+		return err
+	}
+
+	if err := rfs.PhysicalMove(obj.path, source, resourceStr); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS MoveToResource Failed: %v, %v", obj.path, err))
+	}
+
+	return nil
+}
+
+// fsReplicate is Replicate, and Backup when backup is set. With opts.ChecksumScheme the new replica is verified
+// on the server.
+func (obj *DataObj) fsReplicate(targetResource interface{}, opts DataObjOptions, backup bool) error {
+	op := "ReplicateOpts"
+	if backup {
+		op = "Backup"
+	}
+
+	resourceStr, err := resourceName(targetResource)
+	if err != nil || targetResource == nil {
+		return newError(Fatal, -1, fmt.Sprintf("Unknown type passed as targetResource"))
+	}
+
+	verify, er := obj.verifyChecksum(opts)
+	if er != nil {
+		return er
+	}
+
+	rfs, err := obj.replicaFS(op)
+	if err != nil {
+		return err
+	}
+
+	if backup {
+		repls, err := obj.Replicas()
+		if err != nil {
+			return err
+		}
+
+		for _, repl := range repls {
+			if repl.Resource == resourceStr && repl.Good() {
+				return nil
+			}
+		}
+	}
+
+	if err := rfs.Replicate(obj.path, resourceStr); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS %v Failed: %v, %v", op, obj.path, err))
+	}
+
+	if !verify {
+		return nil
+	}
+
+	repls, err := obj.Replicas()
+	if err != nil {
+		return err
+	}
+
+	for inx := len(repls) - 1; inx >= 0; inx-- {
+		if repls[inx].Resource != resourceStr {
+			continue
+		}
+
+		if _, ok, err := obj.VerifyReplica(repls[inx].Num); err != nil {
+			return err
+		} else if !ok {
+			return newError(Fatal, -1, fmt.Sprintf("iRODS %v Failed: %v, replica %v doesn't match its checksum", op, obj.path, repls[inx].Num))
+		}
+
+		return nil
+	}
+
+	return newError(Fatal, -1, fmt.Sprintf("iRODS %v Failed: %v, no replica on %v", op, obj.path, resourceStr))
+}
+
+func (obj *DataObj) fsReplicas() ([]*Replica, error) {
+	rfs, err := obj.replicaFS("Replicas")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := rfs.Replicas(obj.path)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Replicas Failed: %v, %v", obj.path, err))
+	}
+
+	if len(list) == 0 {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Replicas Failed: %v does not exist or user lacks access permission", obj.path))
+	}
+
+	repls := make([]*Replica, len(list))
+
+	for inx, repl := range list {
+		repls[inx] = &Replica{
+			Num:          repl.Num,
+			Resource:     repl.Resource,
+			RescHier:     repl.RescHier,
+			Status:       repl.Status,
+			Size:         repl.Size,
+			Checksum:     repl.Checksum,
+			PhysicalPath: repl.PhysicalPath,
+			ModifyTime:   repl.ModifyTime,
+			obj:          obj,
+		}
+	}
+
+	return repls, nil
+}
+
+func (obj *DataObj) fsOpenReplica(replNum int, write bool) (*DataObj, error) {
+	rfs, err := obj.replicaFS("OpenReplica")
+	if err != nil {
+		return nil, err
+	}
+
+	repl, err := obj.Replica(replNum)
+	if err != nil {
+		return nil, err
+	}
+
+	r := *obj
+	r.replNum = repl.Num
+	r.rescHier = repl.RescHier
+	r.replStatus = repl.Status
+	r.size = repl.Size
+	r.checksum = repl.Checksum
+	r.phyPath = repl.PhysicalPath
+	r.modifyTime = repl.ModifyTime
+	r.offset = 0
+
+	if rsrcs, err := obj.con.Resources(); err == nil {
+		if rsrc := rsrcs.FindByName(repl.Resource); rsrc != nil {
+			r.resource = rsrc
+		}
+	}
+
+	flag := os.O_RDONLY
+	if write {
+		flag = os.O_RDWR
+	}
+
+	file, err := rfs.OpenReplica(obj.path, replNum, flag)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS OpenReplica Failed: %v replica %v, %v", obj.path, replNum, err))
+	}
+
+	r.file = file
+	r.fileFlag = flag
+
+	return &r, nil
+}
+
+func (obj *DataObj) fsChksumReplica(replNum int) (string, error) {
+	rfs, err := obj.replicaFS("ChksumReplica")
+	if err != nil {
+		return "", err
+	}
+
+	chksum, err := rfs.ChecksumReplica(obj.path, replNum)
+	if err != nil {
+		return "", newError(Fatal, -1, fmt.Sprintf("iRODS ChksumReplica Failed: %v replica %v, %v", obj.path, replNum, err))
+	}
+
+	if obj.replNum == replNum {
+		obj.checksum = chksum
+	}
+
+	return chksum, nil
+}
+
+func (obj *DataObj) fsVerifyReplica(replNum int) (size int64, ok bool, err error) {
+	rfs, err := obj.replicaFS("VerifyReplica")
+	if err != nil {
+		return 0, false, err
+	}
+
+	repl, err := obj.Replica(replNum)
+	if err != nil {
+		return 0, false, err
+	}
+
+	fixity, er := rfs.VerifyReplica(obj.path, replNum)
+	if er == irodsfs.ErrChecksumMismatch {
+		return repl.Size, false, nil
+	} else if er != nil {
+		return 0, false, newError(Fatal, -1, fmt.Sprintf("iRODS VerifyReplica Failed: %v replica %v, %v", obj.path, replNum, er))
+	}
+
+	return fixity.Size, repl.Checksum == "" || fixity.Checksum == "" || fixity.Checksum == repl.Checksum, nil
+}
+
+func (obj *DataObj) fsTrimReplica(replNum int) error {
+	rfs, err := obj.replicaFS("TrimReplica")
+	if err != nil {
+		return err
+	}
+
+	if err := rfs.Trim(obj.path, replNum); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS TrimReplica Failed: %v replica %v, %v", obj.path, replNum, err))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) fsPhysicalMove(src string, dest string) error {
+	rfs, err := obj.replicaFS("PhysicalMove")
+	if err != nil {
+		return err
+	}
+
+	if err := rfs.PhysicalMove(obj.path, src, dest); err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS PhysicalMove Failed: %v from %v to %v, %v", obj.path, src, dest, err))
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// cDataObj holds the C API handle of an opened data object in cgo builds
+type cDataObj struct{}
+
+// Free is a no-op, the bytes of ByteArr are Go memory in builds without the C API
+func (br ByteArr) Free() {
+}
+
+func cFetchDataObj(startPath string, con *Connection, skipCache bool) (*DataObj, error) {
+	return nil, errNoC
+}
+
+func cCreateDataObj(opts DataObjOptions, coll *Collection) (*DataObj, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cIsOpen() bool {
+	return false
+}
+
+func (obj *DataObj) cACL() (ACLs, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cHandle() int {
+	return 0
+}
+
+func (obj *DataObj) cRm(recursive bool, force bool) error {
+	return errNoC
+}
+
+func (obj *DataObj) cRmTrash() error {
+	return errNoC
+}
+
+func (obj *DataObj) cOpen() error {
+	return errNoC
+}
+
+func (obj *DataObj) cOpenRW() error {
+	return errNoC
+}
+
+func (obj *DataObj) cClose() error {
+	return errNoC
+}
+
+func (obj *DataObj) cRead() ([]byte, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cReadChunkFree(size int64, callback func(*ByteArr)) error {
+	return errNoC
+}
+
+func (obj *DataObj) cFastReadFree(pos int64, length int) (*ByteArr, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cFastRead(pos int64, length int, callback func([]byte) error) error {
+	return errNoC
+}
+
+func (obj *DataObj) cReadBytes(pos int64, length int) ([]byte, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cLSeek(offset int64) error {
+	return errNoC
+}
+
+func (obj *DataObj) cReadChunk(size int64, callback func([]byte)) error {
+	return errNoC
+}
+
+func (obj *DataObj) cWrite(data []byte) error {
+	return errNoC
+}
+
+func (obj *DataObj) cWriteBytes(data []byte) error {
+	return errNoC
+}
+
+func (obj *DataObj) cStat() (map[string]interface{}, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cCopyTo(iRODSCollection interface{}) error {
+	return errNoC
+}
+
+func (obj *DataObj) cCopyToOpts(iRODSCollection interface{}, opts DataObjOptions) error {
+	return errNoC
+}
+
+func (obj *DataObj) cMoveTo(iRODSCollection interface{}) error {
+	return errNoC
+}
+
+func (obj *DataObj) cRename(newFileName string) error {
+	return errNoC
+}
+
+func (obj *DataObj) cChksum() (string, error) {
+	return "", errNoC
+}
+
+func (obj *DataObj) cTrimRepls(opts TrimOptions) error {
+	return errNoC
+}
+
+func (obj *DataObj) cMoveToResource(targetResource interface{}) error {
+	return errNoC
+}
+
+func (obj *DataObj) cReplicate(targetResource interface{}, opts DataObjOptions) error {
+	return errNoC
+}
+
+func (obj *DataObj) cBackup(targetResource interface{}, opts DataObjOptions) error {
+	return errNoC
+}
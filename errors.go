@@ -3,13 +3,10 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
+	"os"
 	"time"
-	"unsafe"
 )
 
 // Log level constants
@@ -19,12 +16,23 @@ const (
 	Fatal
 )
 
+// iRODS error codes of missing collections and data objects, from rodsErrorTable.h
+const (
+	userFileDoesNotExist = -310000
+	objPathDoesNotExist  = -358000
+	catUnknownCollection = -814000
+	catUnknownFile       = -817000
+)
+
 // GoRodsError stores information about errors
 type GoRodsError struct {
 	LogLevel  int
 	Message   string
 	IRODSCode string
 	Time      time.Time
+
+	// status is the iRODS error code, -1 when there's none
+	status int
 }
 
 // Error returns error string, alias of String(). Sample output:
@@ -41,6 +49,22 @@ func (err *GoRodsError) String() string {
 	return fmt.Sprintf("%v: %v - %v%v", err.Time, err.lookupError(err.LogLevel), err.Message, err.IRODSCode)
 }
 
+// Is reports whether the error matches target: errors.Is(err, os.ErrNotExist) is true for the errors of
+// missing collections and data objects
+func (err *GoRodsError) Is(target error) bool {
+	if target != os.ErrNotExist {
+		return false
+	}
+
+	// The errno of a failed system call is added to the iRODS error code
+	switch err.status / 1000 * 1000 {
+	case userFileDoesNotExist, objPathDoesNotExist, catUnknownCollection, catUnknownFile:
+		return true
+	}
+
+	return false
+}
+
 func (err *GoRodsError) lookupError(code int) string {
 	var constLookup = map[int]string{
 		Info:  "Info",
@@ -50,27 +74,3 @@ func (err *GoRodsError) lookupError(code int) string {
 
 	return constLookup[code]
 }
-
-func newError(logLevel int, status C.int, message string) *GoRodsError {
-	var (
-		errStr    *C.char
-		subErrStr *C.char
-	)
-
-	err := new(GoRodsError)
-
-	err.LogLevel = logLevel
-	err.Message = message
-	err.Time = time.Now()
-
-	if status != -1 {
-		defer C.free(unsafe.Pointer(errStr))
-		defer C.free(unsafe.Pointer(subErrStr))
-
-		errStr = C.rodsErrorName(status, &subErrStr)
-
-		err.IRODSCode = " " + C.GoString(errStr) + " " + C.GoString(subErrStr)
-	}
-
-	return err
-}
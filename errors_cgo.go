@@ -0,0 +1,40 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+func newError(logLevel int, status C.int, message string) *GoRodsError {
+	var (
+		errStr    *C.char
+		subErrStr *C.char
+	)
+
+	err := new(GoRodsError)
+
+	err.LogLevel = logLevel
+	err.Message = message
+	err.Time = time.Now()
+	err.status = int(status)
+
+	if status != -1 {
+		defer C.free(unsafe.Pointer(errStr))
+		defer C.free(unsafe.Pointer(subErrStr))
+
+		errStr = C.rodsErrorName(status, &subErrStr)
+
+		err.IRODSCode = " " + C.GoString(errStr) + " " + C.GoString(subErrStr)
+	}
+
+	return err
+}
@@ -0,0 +1,31 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"strconv"
+	"time"
+)
+
+// errNoC is returned by the C API calls, which aren't built with the gorods_native tag or without cgo.
+// Connections of those builds are served by the pure Go client through their Filesystem, they don't reach them.
+var errNoC = newError(Fatal, -1, "iRODS C API not available: built with the gorods_native tag or without cgo")
+
+func newError(logLevel int, status int, message string) *GoRodsError {
+	err := new(GoRodsError)
+
+	err.LogLevel = logLevel
+	err.Message = message
+	err.Time = time.Now()
+	err.status = status
+
+	if status != -1 {
+		err.IRODSCode = " " + strconv.Itoa(status)
+	}
+
+	return err
+}
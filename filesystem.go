@@ -0,0 +1,747 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Filesystem returns the connection as an irodsfs.Filesystem, the interface set shared with
+// the in-memory zone of package gorodstest and other implementations. Code written against
+// irodsfs can be unit tested with a fake zone, and run against iRODS with this adapter:
+//
+//	func archive(fsys irodsfs.Filesystem, p string) error {
+//		return fsys.AddMeta(p, irodsfs.AVU{Attribute: "archived", Value: "true"})
+//	}
+//
+//	archive(con.Filesystem(), "/tempZone/home/rods/results.csv")
+//
+// The calls of the adapters of a connection are serialized, so they can be shared by goroutines,
+// but don't run in parallel. Workers that need parallel requests open a connection each.
+// FilesystemDefined connections return their Options.Filesystem.
+func (con *Connection) Filesystem() irodsfs.Filesystem {
+	if con.fs != nil {
+		return con.fs
+	}
+
+	return &connectionFS{con: con}
+}
+
+// connectionFS implements irodsfs.Filesystem with the Collection and DataObj types
+type connectionFS struct {
+	con *Connection
+}
+
+var (
+	_ irodsfs.Filesystem = (*connectionFS)(nil)
+	_ irodsfs.ReplicaFS  = (*connectionFS)(nil)
+	_ irodsfs.RegisterFS = (*connectionFS)(nil)
+)
+
+// lock serializes the calls of the adapters of the connection, its collection cache and the
+// Go side of its handles aren't safe for concurrent use
+func (fsys *connectionFS) lock() {
+	fsys.con.fsLock.Lock()
+}
+
+func (fsys *connectionFS) unlock() {
+	fsys.con.fsLock.Unlock()
+}
+
+// Serial reports that the calls of the adapter run one at a time. Packages running parallel
+// workers, like audit, need a connection per worker.
+func (fsys *connectionFS) Serial() bool {
+	return true
+}
+
+// obj fetches the collection or data object at p, bypassing the collection cache. The caller
+// closes it.
+func (fsys *connectionFS) obj(p string) (IRodsObj, error) {
+	typ, err := fsys.con.PathType(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == CollectionType {
+		return fsys.col(p)
+	}
+
+	return fsys.dataObj(p)
+}
+
+// dataObj fetches the data object at p, bypassing the collection cache
+func (fsys *connectionFS) dataObj(p string) (*DataObj, error) {
+	return fetchDataObj(p, fsys.con, true)
+}
+
+// col fetches the collection p, bypassing the collection cache. The caller closes it.
+func (fsys *connectionFS) col(p string) (*Collection, error) {
+	return fsys.con.Collection(CollectionOptions{
+		Path:      p,
+		Recursive: false,
+		SkipCache: true,
+	})
+}
+
+// principalName returns name#zone for a user, or name when the user or its zone isn't known
+func principalName(usr *User, name string) string {
+	if usr != nil && usr.Zone() != nil {
+		return usr.Name() + "#" + usr.Zone().Name()
+	}
+
+	return name
+}
+
+func objInfo(obj IRodsObj) irodsfs.ObjInfo {
+	info := irodsfs.ObjInfo{
+		Path:       obj.Path(),
+		Name:       obj.Name(),
+		Type:       obj.Type(),
+		Owner:      principalName(obj.Owner(), obj.OwnerName()),
+		CreateTime: obj.CreateTime(),
+		ModifyTime: obj.ModifyTime(),
+	}
+
+	if do, ok := obj.(*DataObj); ok {
+		info.Size = do.Size()
+		info.Checksum = do.Checksum()
+		info.Id, _ = strconv.ParseInt(do.DataId(), 10, 64)
+	}
+
+	return info
+}
+
+// Stat describes the collection or data object at p
+func (fsys *connectionFS) Stat(p string) (irodsfs.ObjInfo, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return irodsfs.ObjInfo{}, err
+	}
+
+	defer obj.Close()
+
+	return objInfo(obj), nil
+}
+
+// List describes the collections and data objects in the collection p
+func (fsys *connectionFS) List(p string) ([]irodsfs.ObjInfo, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	col, err := fsys.col(p)
+	if err != nil {
+		return nil, err
+	}
+
+	defer col.Close()
+
+	objs, err := col.All()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]irodsfs.ObjInfo, len(objs))
+	for inx, obj := range objs {
+		infos[inx] = objInfo(obj)
+	}
+
+	return infos, nil
+}
+
+// Open opens the data object p, see irodsfs.FS
+func (fsys *connectionFS) Open(p string, flag int) (irodsfs.File, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	write := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	var (
+		obj *DataObj
+		err error
+	)
+
+	_, statErr := fsys.con.PathType(p)
+	exists := statErr == nil
+
+	switch {
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, statErr
+
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Open DataObject Failed: %v already exists", p))
+
+	case !exists || (write && flag&os.O_TRUNC != 0):
+		col, colErr := fsys.col(path.Dir(p))
+		if colErr != nil {
+			return nil, colErr
+		}
+
+		obj, err = CreateDataObj(DataObjOptions{
+			Name:  path.Base(p),
+			Mode:  0644,
+			Force: exists,
+		}, col)
+
+		if cErr := col.Close(); err == nil {
+			err = cErr
+		}
+
+	default:
+		obj, err = fsys.dataObj(p)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if write {
+		err = obj.OpenRW()
+	} else {
+		err = obj.Open()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataObjFile{
+		fsys: fsys,
+		obj:  obj,
+		flag: flag,
+	}, nil
+}
+
+// Mkdir creates the collection p, and its missing parents when recursive is set
+func (fsys *connectionFS) Mkdir(p string, recursive bool) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	return fsys.mkdir(p, recursive)
+}
+
+func (fsys *connectionFS) mkdir(p string, recursive bool) error {
+	parentPath := path.Dir(p)
+
+	if recursive {
+		if _, err := fsys.con.PathType(p); err == nil {
+			return nil
+		}
+
+		if _, err := fsys.con.PathType(parentPath); err != nil {
+			if err := fsys.mkdir(parentPath, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	parent, err := fsys.col(parentPath)
+	if err != nil {
+		return err
+	}
+
+	defer parent.Close()
+
+	col, err := CreateCollection(path.Base(p), parent)
+	if err != nil {
+		return err
+	}
+
+	return col.Close()
+}
+
+// Remove deletes the data object or collection p without moving it to the trash
+func (fsys *connectionFS) Remove(p string, recursive bool) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return err
+	}
+
+	defer obj.Close()
+
+	return obj.Delete(recursive)
+}
+
+// Rename moves the data object or collection src to dest
+func (fsys *connectionFS) Rename(src string, dest string) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(src)
+	if err != nil {
+		return err
+	}
+
+	defer obj.Close()
+
+	if path.Dir(src) != path.Dir(dest) {
+		if err := obj.MoveTo(path.Dir(dest)); err != nil {
+			return err
+		}
+	}
+
+	if path.Base(src) != path.Base(dest) {
+		return obj.Rename(path.Base(dest))
+	}
+
+	return nil
+}
+
+// Meta returns the AVUs of p
+func (fsys *connectionFS) Meta(p string) ([]irodsfs.AVU, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return nil, err
+	}
+
+	defer obj.Close()
+
+	mc, err := obj.Meta()
+	if err != nil {
+		return nil, err
+	}
+
+	metas, err := mc.All()
+	if err != nil {
+		return nil, err
+	}
+
+	avus := make([]irodsfs.AVU, len(metas))
+	for inx, m := range metas {
+		avus[inx] = irodsfs.AVU{
+			Attribute: m.Attribute,
+			Value:     m.Value,
+			Units:     m.Units,
+		}
+	}
+
+	return avus, nil
+}
+
+// AddMeta attaches an AVU to p
+func (fsys *connectionFS) AddMeta(p string, avu irodsfs.AVU) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return err
+	}
+
+	defer obj.Close()
+
+	_, err = obj.AddMeta(Meta{
+		Attribute: avu.Attribute,
+		Value:     avu.Value,
+		Units:     avu.Units,
+	})
+
+	return err
+}
+
+// DeleteMeta removes the AVUs of p with the attribute attr
+func (fsys *connectionFS) DeleteMeta(p string, attr string) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return err
+	}
+
+	defer obj.Close()
+
+	_, err = obj.DeleteMeta(attr)
+
+	return err
+}
+
+// ACL returns the access control list of p
+func (fsys *connectionFS) ACL(p string) ([]irodsfs.ACL, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return nil, err
+	}
+
+	defer obj.Close()
+
+	acls, err := obj.ACL()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]irodsfs.ACL, len(acls))
+	for inx, acl := range acls {
+		list[inx] = irodsfs.ACL{
+			Principal:   Principal(acl.AccessObject),
+			Type:        acl.Type,
+			AccessLevel: acl.AccessLevel,
+		}
+	}
+
+	return list, nil
+}
+
+// Chmod sets the access level of a user or group on p
+func (fsys *connectionFS) Chmod(p string, principal string, accessLevel int, recursive bool) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.obj(p)
+	if err != nil {
+		return err
+	}
+
+	defer obj.Close()
+
+	return obj.Chmod(principal, accessLevel, recursive)
+}
+
+// Inheritance reports whether ACL inheritance is enabled on the collection p
+func (fsys *connectionFS) Inheritance(p string) (bool, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	col, err := fsys.col(p)
+	if err != nil {
+		return false, err
+	}
+
+	defer col.Close()
+
+	return col.Inheritance()
+}
+
+// SetInheritance enables or disables ACL inheritance on the collection p
+func (fsys *connectionFS) SetInheritance(p string, inherit bool, recursive bool) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	col, err := fsys.col(p)
+	if err != nil {
+		return err
+	}
+
+	defer col.Close()
+
+	return col.SetInheritance(inherit, recursive)
+}
+
+// QueryMeta returns the collections and data objects with AVUs matching qString
+func (fsys *connectionFS) QueryMeta(qString string) ([]irodsfs.ObjInfo, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	objs, err := fsys.con.QueryMeta(qString)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]irodsfs.ObjInfo, len(objs))
+	for inx, obj := range objs {
+		infos[inx] = objInfo(obj)
+	}
+
+	return infos, nil
+}
+
+// IQuest runs a GenQuery written in the iquest language
+func (fsys *connectionFS) IQuest(query string, upperCase bool) ([]map[string]string, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	return fsys.con.IQuest(query, upperCase)
+}
+
+// Replicas returns the replicas of the data object p, ordered by number
+func (fsys *connectionFS) Replicas(p string) ([]irodsfs.Replica, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return nil, err
+	}
+
+	repls, err := obj.Replicas()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]irodsfs.Replica, len(repls))
+	for inx, repl := range repls {
+		infos[inx] = irodsfs.Replica{
+			Num:          repl.Num,
+			Resource:     repl.Resource,
+			RescHier:     repl.RescHier,
+			Status:       repl.Status,
+			Size:         repl.Size,
+			Checksum:     repl.Checksum,
+			PhysicalPath: repl.PhysicalPath,
+			ModifyTime:   repl.ModifyTime,
+		}
+	}
+
+	return infos, nil
+}
+
+// OpenReplica opens the replica numbered replNum of the data object p. Only the access mode
+// of flag is used, replicas can't be created or truncated.
+func (fsys *connectionFS) OpenReplica(p string, replNum int, flag int) (irodsfs.File, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return nil, err
+	}
+
+	repl, err := obj.openReplica(replNum, flag&(os.O_WRONLY|os.O_RDWR) != 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataObjFile{
+		fsys: fsys,
+		obj:  repl,
+		flag: flag,
+	}, nil
+}
+
+// ChecksumReplica computes and registers the checksum of the replica numbered replNum of p
+func (fsys *connectionFS) ChecksumReplica(p string, replNum int) (string, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return "", err
+	}
+
+	return obj.ChksumReplica(replNum)
+}
+
+// Replicate copies the data object p to resource
+func (fsys *connectionFS) Replicate(p string, resource string) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return err
+	}
+
+	return obj.Replicate(resource, DataObjOptions{})
+}
+
+// Trim removes the replica numbered replNum of p
+func (fsys *connectionFS) Trim(p string, replNum int) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return err
+	}
+
+	return obj.TrimReplica(replNum)
+}
+
+// PhysicalMove moves the replica of p on the resource src to the resource dest
+func (fsys *connectionFS) PhysicalMove(p string, src string, dest string) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return err
+	}
+
+	return obj.PhysicalMove(src, dest)
+}
+
+// VerifyReplica checks the replica numbered replNum of p without changing the catalog. The
+// server only compares checksums, the registered checksum is returned when it matches and
+// irodsfs.ErrChecksumMismatch when it doesn't.
+func (fsys *connectionFS) VerifyReplica(p string, replNum int) (irodsfs.Fixity, error) {
+	fsys.lock()
+	defer fsys.unlock()
+
+	obj, err := fsys.dataObj(p)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	repl, err := obj.Replica(replNum)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	size, ok, err := obj.VerifyReplica(replNum)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	if !ok {
+		return irodsfs.Fixity{Size: size}, irodsfs.ErrChecksumMismatch
+	}
+
+	return irodsfs.Fixity{Size: size, Checksum: repl.Checksum}, nil
+}
+
+// dataObjFile implements irodsfs.File with the offset based DataObj functions
+type dataObjFile struct {
+	fsys *connectionFS
+	obj  *DataObj
+	flag int
+	pos  int64
+}
+
+// Read reads up to len(p) bytes at the current offset
+func (file *dataObjFile) Read(p []byte) (int, error) {
+	file.fsys.lock()
+	defer file.fsys.unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	data, err := file.obj.ReadBytes(file.pos, len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, data)
+	file.pos += int64(n)
+
+	return n, nil
+}
+
+// ReadAt reads len(p) bytes at offset, without moving the offset used by Read and Write
+func (file *dataObjFile) ReadAt(p []byte, offset int64) (int, error) {
+	file.fsys.lock()
+	defer file.fsys.unlock()
+
+	n := 0
+
+	for n < len(p) {
+		data, err := file.obj.ReadBytes(offset+int64(n), len(p)-n)
+		if err != nil {
+			return n, err
+		}
+
+		if len(data) == 0 {
+			return n, io.EOF
+		}
+
+		n += copy(p[n:], data)
+	}
+
+	return n, nil
+}
+
+// Write writes p at the current offset, or at the end of the data object with os.O_APPEND
+func (file *dataObjFile) Write(p []byte) (int, error) {
+	file.fsys.lock()
+	defer file.fsys.unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if file.flag&os.O_APPEND != 0 {
+		file.pos = file.obj.Size()
+	}
+
+	if err := file.obj.LSeek(file.pos); err != nil {
+		return 0, err
+	}
+
+	if err := file.obj.WriteBytes(p); err != nil {
+		return 0, err
+	}
+
+	file.pos += int64(len(p))
+
+	return len(p), nil
+}
+
+// Seek sets the offset of the next Read or Write
+func (file *dataObjFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += file.pos
+	case io.SeekEnd:
+		offset += file.obj.Size()
+	default:
+		return 0, newError(Fatal, -1, fmt.Sprintf("Invalid whence %v", whence))
+	}
+
+	if offset < 0 {
+		return 0, newError(Fatal, -1, fmt.Sprintf("Negative offset %v", offset))
+	}
+
+	file.pos = offset
+
+	return offset, nil
+}
+
+// Close closes the data object handle
+func (file *dataObjFile) Close() error {
+	file.fsys.lock()
+	defer file.fsys.unlock()
+
+	return file.obj.Close()
+}
+
+// Register registers the file physPath as the data object p, see Connection.RegPhysObj
+func (fsys *connectionFS) Register(physPath string, p string, opts irodsfs.RegisterOptions) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	regOpts := RegOptions{
+		PhysicalFilePath: physPath,
+		RodsPath:         p,
+		Force:            opts.Force,
+		Replica:          opts.Replica,
+		Checksum:         opts.Checksum,
+		VerifyChecksum:   opts.Verify,
+	}
+
+	if opts.Resource != "" {
+		regOpts.Resource = opts.Resource
+	}
+
+	return fsys.con.RegPhysObj(regOpts)
+}
+
+// Unregister removes the replica numbered replNum of p, or p when replNum is -1, from the catalog
+func (fsys *connectionFS) Unregister(p string, replNum int) error {
+	fsys.lock()
+	defer fsys.unlock()
+
+	return fsys.con.UnregPhysObj(p, replNum)
+}
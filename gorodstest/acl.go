@@ -0,0 +1,163 @@
+package gorodstest
+
+import "github.com/jjacquay712/GoRODS/irodsfs"
+
+// aclOf returns the ACL of the collection or data object p, which the user must have level
+// access to. srv.mu must be held.
+func (con *Connection) aclOf(p string, level int) (map[string]int, error) {
+	if _, ok := con.srv.colls[p]; ok {
+		coll, err := con.lookupColl(p, level)
+		if err != nil {
+			return nil, err
+		}
+
+		return coll.acl, nil
+	}
+
+	obj, err := con.lookupObj(p, level)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj.acl, nil
+}
+
+// ACL returns the access control list of the collection or data object p (ils -A)
+func (con *Connection) ACL(p string) ([]ACL, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	acl, err := con.aclOf(p, Read)
+	if err != nil {
+		return nil, err
+	}
+
+	return con.srv.aclList(acl), nil
+}
+
+// Chmod sets the access level of a user or group (name or name#zone) on p (ichmod).
+// Null removes the access. recursive applies the change to everything below a collection.
+// The user must own every item changed.
+func (con *Connection) Chmod(p string, principal string, accessLevel int, recursive bool) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	if !irodsfs.IsAccessLevel(accessLevel) {
+		return newError(SYS_INVALID_INPUT_PARAM, "Invalid access level %v", accessLevel)
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	key := con.srv.qualify(principal)
+
+	if _, isUser := con.srv.users[key]; !isUser {
+		if _, isGroup := con.srv.groups[key]; !isGroup {
+			return newError(CAT_INVALID_USER, "Unknown user or group %v", principal)
+		}
+	}
+
+	acls := []map[string]int{}
+
+	acl, err := con.aclOf(p, Own)
+	if err != nil {
+		return err
+	}
+
+	acls = append(acls, acl)
+
+	if _, isColl := con.srv.colls[p]; isColl && recursive {
+		colls, objs := con.srv.descendants(p)
+
+		for _, cp := range colls {
+			coll, err := con.lookupColl(cp, Own)
+			if err != nil {
+				return err
+			}
+
+			acls = append(acls, coll.acl)
+		}
+
+		for _, op := range objs {
+			obj, err := con.lookupObj(op, Own)
+			if err != nil {
+				return err
+			}
+
+			acls = append(acls, obj.acl)
+		}
+	}
+
+	for _, acl := range acls {
+		if accessLevel == Null {
+			delete(acl, key)
+		} else {
+			acl[key] = accessLevel
+		}
+	}
+
+	return nil
+}
+
+// Inheritance reports whether ACL inheritance is enabled on the collection p
+func (con *Connection) Inheritance(p string) (bool, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return false, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	coll, err := con.lookupColl(p, Read)
+	if err != nil {
+		return false, err
+	}
+
+	return coll.inherit, nil
+}
+
+// SetInheritance enables or disables ACL inheritance on the collection p (ichmod inherit),
+// and on its sub collections when recursive is set
+func (con *Connection) SetInheritance(p string, inherit bool, recursive bool) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	coll, err := con.lookupColl(p, Own)
+	if err != nil {
+		return err
+	}
+
+	colls := []*collection{coll}
+
+	if recursive {
+		subColls, _ := con.srv.descendants(p)
+
+		for _, cp := range subColls {
+			sub, err := con.lookupColl(cp, Own)
+			if err != nil {
+				return err
+			}
+
+			colls = append(colls, sub)
+		}
+	}
+
+	for _, c := range colls {
+		c.inherit = inherit
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package gorodstest
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// AVU is an attribute, value, units triple, see irodsfs.AVU
+type AVU = irodsfs.AVU
+
+// ACL is the access level of a user or group, see irodsfs.ACL
+type ACL = irodsfs.ACL
+
+// Replica describes one copy of a data object on a resource, see irodsfs.Replica
+type Replica = irodsfs.Replica
+
+// ObjInfo describes a collection or data object, see irodsfs.ObjInfo
+type ObjInfo = irodsfs.ObjInfo
+
+type collection struct {
+	id       int64
+	path     string
+	owner    string
+	created  time.Time
+	modified time.Time
+	acl      map[string]int
+	inherit  bool
+	meta     []AVU
+}
+
+type replica struct {
+	num      int
+	resource string
+	status   int
+	data     []byte
+	checksum string
+	modified time.Time
+
+	// disk holds the data on the resource when it was changed behind the back of iRODS, the
+	// catalog still describes data
+	disk []byte
+
+	// phyPath is the path of a registered file, replicas written through iRODS are in the
+	// vault of their resource
+	phyPath string
+}
+
+// physical returns the data stored on the resource
+func (repl *replica) physical() []byte {
+	if repl.disk != nil {
+		return repl.disk
+	}
+
+	return repl.data
+}
+
+type dataObj struct {
+	id       int64
+	path     string
+	owner    string
+	created  time.Time
+	modified time.Time
+	acl      map[string]int
+	meta     []AVU
+	replicas []*replica
+}
+
+// goodReplica returns the newest good replica, or the first replica if none is good
+func (obj *dataObj) goodReplica() *replica {
+	var best *replica
+
+	for _, repl := range obj.replicas {
+		if repl.status == Good && (best == nil || repl.modified.After(best.modified)) {
+			best = repl
+		}
+	}
+
+	if best == nil && len(obj.replicas) > 0 {
+		best = obj.replicas[0]
+	}
+
+	return best
+}
+
+// replica returns the replica numbered num, or nil
+func (obj *dataObj) replica(num int) *replica {
+	for _, repl := range obj.replicas {
+		if repl.num == num {
+			return repl
+		}
+	}
+
+	return nil
+}
+
+// nextReplNum returns the number of a new replica
+func (obj *dataObj) nextReplNum() int {
+	num := 0
+
+	for _, repl := range obj.replicas {
+		if repl.num >= num {
+			num = repl.num + 1
+		}
+	}
+
+	return num
+}
+
+// checksum computes the checksum of data with the scheme of the server, in iRODS format
+func (srv *Server) checksum(data []byte) string {
+	alg, err := checksum.ParseAlgorithm(srv.ChecksumScheme)
+	if err != nil {
+		alg = checksum.MD5
+	}
+
+	return checksum.Bytes(alg, data).String()
+}
+
+// checksumAs computes the checksum of data with the scheme of the registered checksum sum, or
+// of the server when sum is empty
+func (srv *Server) checksumAs(data []byte, sum string) string {
+	if registered, err := checksum.Parse(sum); err == nil {
+		return checksum.Bytes(registered.Algorithm, data).String()
+	}
+
+	return srv.checksum(data)
+}
+
+// physicalPath returns the vault path of a replica
+func (srv *Server) physicalPath(resource string, objPath string) string {
+	vault := "/var/lib/irods/Vault"
+	if resc, ok := srv.resources[resource]; ok {
+		vault = resc.VaultPath
+	}
+
+	return vault + strings.TrimPrefix(objPath, "/"+srv.zone)
+}
+
+// replicaPath returns the physical path of a replica
+func (srv *Server) replicaPath(obj *dataObj, repl *replica) string {
+	if repl.phyPath != "" {
+		return repl.phyPath
+	}
+
+	return srv.physicalPath(repl.resource, obj.path)
+}
+
+func (srv *Server) replicaInfo(obj *dataObj, repl *replica) Replica {
+	return Replica{
+		Num:          repl.num,
+		Resource:     repl.resource,
+		RescHier:     repl.resource,
+		Status:       repl.status,
+		Size:         int64(len(repl.data)),
+		Checksum:     repl.checksum,
+		PhysicalPath: srv.replicaPath(obj, repl),
+		ModifyTime:   repl.modified,
+	}
+}
+
+func (srv *Server) objInfo(obj *dataObj) ObjInfo {
+	info := ObjInfo{
+		Id:         obj.id,
+		Path:       obj.path,
+		Name:       path.Base(obj.path),
+		Type:       DataObjType,
+		Owner:      obj.owner,
+		CreateTime: obj.created,
+		ModifyTime: obj.modified,
+	}
+
+	if repl := obj.goodReplica(); repl != nil {
+		info.Size = int64(len(repl.data))
+		info.Checksum = repl.checksum
+	}
+
+	return info
+}
+
+func (srv *Server) collInfo(coll *collection) ObjInfo {
+	return ObjInfo{
+		Id:         coll.id,
+		Path:       coll.path,
+		Name:       path.Base(coll.path),
+		Type:       CollectionType,
+		Owner:      coll.owner,
+		CreateTime: coll.created,
+		ModifyTime: coll.modified,
+	}
+}
+
+// isAdmin reports whether the qualified user is a rodsadmin of the zone
+func (srv *Server) isAdmin(userKey string) bool {
+	usr, ok := srv.users[userKey]
+	return ok && usr.Type == AdminType && usr.Zone == srv.zone
+}
+
+// access returns the access level of the qualified user for an ACL, granted directly or
+// through groups. rodsadmins have Own on everything.
+func (srv *Server) access(userKey string, acl map[string]int) int {
+	if srv.isAdmin(userKey) {
+		return Own
+	}
+
+	level := Null
+	if l, ok := acl[userKey]; ok {
+		level = l
+	}
+
+	for _, grp := range srv.userGroups(userKey) {
+		if l, ok := acl[srv.qualify(grp)]; ok && irodsfs.CompareAccess(l, level) > 0 {
+			level = l
+		}
+	}
+
+	return level
+}
+
+// principalType returns UserType, AdminType, GroupAdminType or GroupType for a principal
+func (srv *Server) principalType(key string) int {
+	if usr, ok := srv.users[key]; ok {
+		return usr.Type
+	}
+
+	return GroupType
+}
+
+// aclList returns the sorted entries of an ACL
+func (srv *Server) aclList(acl map[string]int) []ACL {
+	list := make([]ACL, 0, len(acl))
+
+	for principal, level := range acl {
+		list = append(list, ACL{
+			Principal:   principal,
+			Type:        srv.principalType(principal),
+			AccessLevel: level,
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Principal < list[j].Principal
+	})
+
+	return list
+}
+
+// children returns the paths of the collections and data objects directly in the collection p
+func (srv *Server) children(p string) (colls []string, objs []string) {
+	prefix := strings.TrimSuffix(p, "/") + "/"
+
+	for cp := range srv.colls {
+		if cp != p && strings.HasPrefix(cp, prefix) && !strings.Contains(cp[len(prefix):], "/") {
+			colls = append(colls, cp)
+		}
+	}
+
+	for op := range srv.objs {
+		if strings.HasPrefix(op, prefix) && !strings.Contains(op[len(prefix):], "/") {
+			objs = append(objs, op)
+		}
+	}
+
+	sort.Strings(colls)
+	sort.Strings(objs)
+
+	return
+}
+
+// descendants returns the paths of every collection and data object below the collection p
+func (srv *Server) descendants(p string) (colls []string, objs []string) {
+	prefix := strings.TrimSuffix(p, "/") + "/"
+
+	for cp := range srv.colls {
+		if cp != p && strings.HasPrefix(cp, prefix) {
+			colls = append(colls, cp)
+		}
+	}
+
+	for op := range srv.objs {
+		if strings.HasPrefix(op, prefix) {
+			objs = append(objs, op)
+		}
+	}
+
+	sort.Strings(colls)
+	sort.Strings(objs)
+
+	return
+}
+
+// copyACL returns a copy of an ACL
+func copyACL(acl map[string]int) map[string]int {
+	cp := make(map[string]int, len(acl))
+	for k, v := range acl {
+		cp[k] = v
+	}
+
+	return cp
+}
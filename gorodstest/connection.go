@@ -0,0 +1,619 @@
+package gorodstest
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Connection is a session of a user with a fake Server. Its methods mirror the operations
+// of gorods.Connection, Collection and DataObj on paths, and implement irodsfs.Filesystem.
+type Connection struct {
+	srv  *Server
+	user string
+}
+
+var (
+	_ irodsfs.Filesystem = (*Connection)(nil)
+	_ irodsfs.ReplicaFS  = (*Connection)(nil)
+	_ irodsfs.RegisterFS = (*Connection)(nil)
+)
+
+// PutOptions are the options of Connection.Put
+type PutOptions struct {
+	// Resource is the resource of the new replica, DefaultResource if empty
+	Resource string
+	// Force overwrites an existing data object
+	Force bool
+	// Checksum registers the checksum of the data object
+	Checksum bool
+	// ChecksumScheme registers a checksum of that scheme instead of the server's, like
+	// gorods.DataObjOptions.ChecksumScheme
+	ChecksumScheme string
+}
+
+// Server returns the fake server of the connection
+func (con *Connection) Server() *Server {
+	return con.srv
+}
+
+// Username returns the name of the connected user, as name#zone
+func (con *Connection) Username() string {
+	return con.user
+}
+
+// Disconnect closes the connection. It's a no-op, for symmetry with gorods.Connection.
+func (con *Connection) Disconnect() error {
+	return nil
+}
+
+// lookupColl returns the collection p, which the user must have level access to. srv.mu must be held.
+func (con *Connection) lookupColl(p string, level int) (*collection, error) {
+	coll, ok := con.srv.colls[p]
+	if !ok {
+		return nil, newError(CAT_UNKNOWN_COLLECTION, "Collection %v does not exist", p)
+	}
+
+	if irodsfs.CompareAccess(con.srv.access(con.user, coll.acl), level) < 0 {
+		return nil, newError(CAT_NO_ACCESS_PERMISSION, "%v does not have access to %v", con.user, p)
+	}
+
+	return coll, nil
+}
+
+// lookupObj returns the data object p, which the user must have level access to. srv.mu must be held.
+func (con *Connection) lookupObj(p string, level int) (*dataObj, error) {
+	obj, ok := con.srv.objs[p]
+	if !ok {
+		return nil, newError(USER_FILE_DOES_NOT_EXIST, "Data object %v does not exist", p)
+	}
+
+	if irodsfs.CompareAccess(con.srv.access(con.user, obj.acl), level) < 0 {
+		return nil, newError(CAT_NO_ACCESS_PERMISSION, "%v does not have access to %v", con.user, p)
+	}
+
+	return obj, nil
+}
+
+// newACL returns the ACL of an item created by the user in parent, with the ACL of the
+// parent added when it has inheritance enabled
+func (con *Connection) newACL(parent *collection) map[string]int {
+	acl := make(map[string]int)
+
+	if parent.inherit {
+		acl = copyACL(parent.acl)
+	}
+
+	acl[con.user] = Own
+
+	return acl
+}
+
+// PathType returns DataObjType or CollectionType for p
+func (con *Connection) PathType(p string) (int, error) {
+	info, err := con.Stat(p)
+	if err != nil {
+		return -1, err
+	}
+
+	return info.Type, nil
+}
+
+// Stat describes the collection or data object at p
+func (con *Connection) Stat(p string) (ObjInfo, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return ObjInfo{}, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	if _, ok := con.srv.colls[p]; ok {
+		coll, err := con.lookupColl(p, Read)
+		if err != nil {
+			return ObjInfo{}, err
+		}
+
+		return con.srv.collInfo(coll), nil
+	}
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return ObjInfo{}, err
+	}
+
+	return con.srv.objInfo(obj), nil
+}
+
+// List returns the collections and data objects in the collection p that the user can read
+func (con *Connection) List(p string) ([]ObjInfo, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	if _, err := con.lookupColl(p, Read); err != nil {
+		return nil, err
+	}
+
+	colls, objs := con.srv.children(p)
+
+	var infos []ObjInfo
+
+	for _, cp := range colls {
+		if coll, err := con.lookupColl(cp, Read); err == nil {
+			infos = append(infos, con.srv.collInfo(coll))
+		}
+	}
+
+	for _, op := range objs {
+		if obj, err := con.lookupObj(op, Read); err == nil {
+			infos = append(infos, con.srv.objInfo(obj))
+		}
+	}
+
+	return infos, nil
+}
+
+// Mkdir creates the collection p, and its missing parents when recursive is set (imkdir -p)
+func (con *Connection) Mkdir(p string, recursive bool) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	return con.mkdir(p, recursive)
+}
+
+func (con *Connection) mkdir(p string, recursive bool) error {
+	if _, ok := con.srv.colls[p]; ok {
+		if recursive {
+			return nil
+		}
+
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "Collection %v already exists", p)
+	}
+
+	if _, ok := con.srv.objs[p]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v is a data object", p)
+	}
+
+	parentPath := path.Dir(p)
+
+	if _, ok := con.srv.colls[parentPath]; !ok && recursive {
+		if err := con.mkdir(parentPath, true); err != nil {
+			return err
+		}
+	}
+
+	parent, err := con.lookupColl(parentPath, Write)
+	if err != nil {
+		return err
+	}
+
+	coll := con.srv.newCollection(p, con.user)
+	coll.acl = con.newACL(parent)
+	coll.inherit = parent.inherit
+
+	return nil
+}
+
+// Put stores data as the data object p (iput). Existing data objects are only overwritten
+// with opts.Force, which replaces the data of the replica on the resource (or of the newest
+// good replica) and marks the other replicas stale.
+func (con *Connection) Put(p string, data []byte, opts PutOptions) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	alg := checksum.MD5
+	if opts.ChecksumScheme != "" {
+		if alg, err = checksum.ParseAlgorithm(opts.ChecksumScheme); err != nil {
+			return newError(SYS_INVALID_INPUT_PARAM, "%v", err)
+		}
+	}
+
+	resource := opts.Resource
+	if resource == "" {
+		resource = DefaultResource
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.resources[resource]; !ok {
+		return newError(CAT_INVALID_RESOURCE, "Resource %v does not exist", resource)
+	}
+
+	if _, ok := con.srv.colls[p]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v is a collection", p)
+	}
+
+	var repl *replica
+
+	if obj, ok := con.srv.objs[p]; ok {
+		if !opts.Force {
+			return newError(OVERWRITE_WITHOUT_FORCE_FLAG, "Data object %v already exists", p)
+		}
+
+		if _, err := con.lookupObj(p, Write); err != nil {
+			return err
+		}
+
+		repl = obj.goodReplica()
+		for _, r := range obj.replicas {
+			if r.resource == resource {
+				repl = r
+			}
+		}
+
+		con.srv.writeReplica(obj, repl, append([]byte(nil), data...))
+	} else {
+		obj, err := con.create(p, resource)
+		if err != nil {
+			return err
+		}
+
+		repl = obj.replicas[0]
+		repl.data = append([]byte(nil), data...)
+	}
+
+	switch {
+	case opts.ChecksumScheme != "":
+		repl.checksum = checksum.Bytes(alg, repl.data).String()
+	case opts.Checksum:
+		repl.checksum = con.srv.checksum(repl.data)
+	}
+
+	return nil
+}
+
+// create registers an empty data object with one replica on resource. srv.mu must be held.
+func (con *Connection) create(p string, resource string) (*dataObj, error) {
+	parent, err := con.lookupColl(path.Dir(p), Write)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	con.srv.nextId++
+
+	obj := &dataObj{
+		id:       con.srv.nextId,
+		path:     p,
+		owner:    con.user,
+		created:  now,
+		modified: now,
+		acl:      con.newACL(parent),
+		replicas: []*replica{{
+			num:      0,
+			resource: resource,
+			status:   Good,
+			modified: now,
+		}},
+	}
+
+	con.srv.objs[p] = obj
+	parent.modified = now
+
+	return obj, nil
+}
+
+// writeReplica replaces the data of a replica, which becomes the only good replica.
+// srv.mu must be held.
+func (srv *Server) writeReplica(obj *dataObj, repl *replica, data []byte) {
+	now := time.Now()
+
+	repl.data = data
+	repl.disk = nil
+	repl.checksum = ""
+	repl.status = Good
+	repl.modified = now
+
+	for _, other := range obj.replicas {
+		if other != repl {
+			other.status = Stale
+		}
+	}
+
+	obj.modified = now
+}
+
+// ReadFile returns the content of the data object p, read from its newest good replica
+func (con *Connection) ReadFile(p string) ([]byte, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), obj.goodReplica().data...), nil
+}
+
+// Remove deletes the data object or collection p (irm -f). Collections that aren't empty are
+// only removed when recursive is set. The user must own everything removed.
+func (con *Connection) Remove(p string, recursive bool) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.objs[p]; ok {
+		if _, err := con.lookupObj(p, Own); err != nil {
+			return err
+		}
+
+		delete(con.srv.objs, p)
+		return nil
+	}
+
+	if _, err := con.lookupColl(p, Own); err != nil {
+		return err
+	}
+
+	colls, objs := con.srv.descendants(p)
+
+	if !recursive && len(colls)+len(objs) > 0 {
+		return newError(CAT_COLLECTION_NOT_EMPTY, "Collection %v is not empty", p)
+	}
+
+	// Check every permission before removing anything
+	for _, cp := range colls {
+		if _, err := con.lookupColl(cp, Own); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range objs {
+		if _, err := con.lookupObj(op, Own); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range objs {
+		delete(con.srv.objs, op)
+	}
+
+	for _, cp := range colls {
+		delete(con.srv.colls, cp)
+	}
+
+	delete(con.srv.colls, p)
+
+	return nil
+}
+
+// Rename moves the data object or collection src to dest (imv)
+func (con *Connection) Rename(src string, dest string) error {
+	src, err := cleanPath(src)
+	if err != nil {
+		return err
+	}
+
+	if dest, err = cleanPath(dest); err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.colls[dest]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v already exists", dest)
+	}
+
+	if _, ok := con.srv.objs[dest]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v already exists", dest)
+	}
+
+	if _, err := con.lookupColl(path.Dir(dest), Write); err != nil {
+		return err
+	}
+
+	if obj, ok := con.srv.objs[src]; ok {
+		if _, err := con.lookupObj(src, Own); err != nil {
+			return err
+		}
+
+		delete(con.srv.objs, src)
+		obj.path = dest
+		con.srv.objs[dest] = obj
+
+		return nil
+	}
+
+	coll, err := con.lookupColl(src, Own)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(dest, src+"/") {
+		return newError(SYS_INVALID_INPUT_PARAM, "Unable to move %v into itself", src)
+	}
+
+	colls, objs := con.srv.descendants(src)
+
+	for _, cp := range colls {
+		c := con.srv.colls[cp]
+		delete(con.srv.colls, cp)
+		c.path = dest + strings.TrimPrefix(cp, src)
+		con.srv.colls[c.path] = c
+	}
+
+	for _, op := range objs {
+		o := con.srv.objs[op]
+		delete(con.srv.objs, op)
+		o.path = dest + strings.TrimPrefix(op, src)
+		con.srv.objs[o.path] = o
+	}
+
+	delete(con.srv.colls, src)
+	coll.path = dest
+	con.srv.colls[dest] = coll
+
+	return nil
+}
+
+// Copy copies the data object or collection src to dest (icp -r). Like icp, AVUs and ACLs
+// aren't copied, and the copy has a single replica on DefaultResource.
+func (con *Connection) Copy(src string, dest string) error {
+	src, err := cleanPath(src)
+	if err != nil {
+		return err
+	}
+
+	if dest, err = cleanPath(dest); err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	return con.copy(src, dest)
+}
+
+func (con *Connection) copy(src string, dest string) error {
+	if _, ok := con.srv.colls[dest]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v already exists", dest)
+	}
+
+	if _, ok := con.srv.objs[dest]; ok {
+		return newError(OVERWRITE_WITHOUT_FORCE_FLAG, "%v already exists", dest)
+	}
+
+	if srcObj, ok := con.srv.objs[src]; ok {
+		if _, err := con.lookupObj(src, Read); err != nil {
+			return err
+		}
+
+		obj, err := con.create(dest, DefaultResource)
+		if err != nil {
+			return err
+		}
+
+		obj.replicas[0].data = append([]byte(nil), srcObj.goodReplica().physical()...)
+
+		return nil
+	}
+
+	if _, err := con.lookupColl(src, Read); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(dest, src+"/") {
+		return newError(SYS_INVALID_INPUT_PARAM, "Unable to copy %v into itself", src)
+	}
+
+	if err := con.mkdir(dest, false); err != nil {
+		return err
+	}
+
+	colls, objs := con.srv.children(src)
+
+	for _, p := range append(colls, objs...) {
+		if err := con.copy(p, dest+"/"+path.Base(p)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Open opens the newest good replica of the data object p. flag takes the access modes and
+// flags of os.OpenFile, os.O_CREATE creates the data object on DefaultResource.
+func (con *Connection) Open(p string, flag int) (irodsfs.File, error) {
+	file, err := con.open(p, -1, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// OpenReplica opens the replica numbered replNum of the data object p, see Open
+func (con *Connection) OpenReplica(p string, replNum int, flag int) (irodsfs.File, error) {
+	file, err := con.open(p, replNum, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (con *Connection) open(p string, replNum int, flag int) (*File, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	level := Read
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		level = Write
+	}
+
+	obj, exists := con.srv.objs[p]
+
+	switch {
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, newError(USER_FILE_DOES_NOT_EXIST, "Data object %v does not exist", p)
+
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, newError(OVERWRITE_WITHOUT_FORCE_FLAG, "Data object %v already exists", p)
+
+	case !exists:
+		if obj, err = con.create(p, DefaultResource); err != nil {
+			return nil, err
+		}
+
+	default:
+		if _, err := con.lookupObj(p, level); err != nil {
+			return nil, err
+		}
+	}
+
+	repl := obj.goodReplica()
+	if replNum >= 0 {
+		if repl = obj.replica(replNum); repl == nil {
+			return nil, newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+		}
+	}
+
+	file := &File{
+		con:  con,
+		obj:  obj,
+		repl: repl,
+		flag: flag,
+		data: append([]byte(nil), repl.physical()...),
+	}
+
+	if flag&os.O_TRUNC != 0 && level == Write {
+		file.data = file.data[:0]
+		file.dirty = true
+	}
+
+	return file, nil
+}
@@ -0,0 +1,132 @@
+package gorodstest
+
+import (
+	"io"
+	"os"
+)
+
+// File is a replica of a data object opened with Connection.Open. Writes are stored when
+// the file is closed, like iRODS updates the catalog on close.
+type File struct {
+	con    *Connection
+	obj    *dataObj
+	repl   *replica
+	flag   int
+	data   []byte
+	pos    int64
+	dirty  bool
+	closed bool
+}
+
+// Replica returns the number of the opened replica
+func (file *File) Replica() int {
+	return file.repl.num
+}
+
+// Read reads from the replica. It satisfies the io.Reader interface.
+func (file *File) Read(p []byte) (int, error) {
+	n, err := file.ReadAt(p, file.pos)
+	file.pos += int64(n)
+
+	return n, err
+}
+
+// ReadAt reads len(p) bytes at offset. It satisfies the io.ReaderAt interface.
+func (file *File) ReadAt(p []byte, offset int64) (int, error) {
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	if file.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, newError(SYS_INVALID_INPUT_PARAM, "%v is open write only", file.obj.path)
+	}
+
+	if offset >= int64(len(file.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, file.data[offset:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Write writes to the replica. It satisfies the io.Writer interface.
+func (file *File) Write(p []byte) (int, error) {
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	if file.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, newError(SYS_INVALID_INPUT_PARAM, "%v is open read only", file.obj.path)
+	}
+
+	if file.flag&os.O_APPEND != 0 {
+		file.pos = int64(len(file.data))
+	}
+
+	if end := file.pos + int64(len(p)); end > int64(len(file.data)) {
+		grown := make([]byte, end)
+		copy(grown, file.data)
+		file.data = grown
+	}
+
+	copy(file.data[file.pos:], p)
+	file.pos += int64(len(p))
+	file.dirty = true
+
+	return len(p), nil
+}
+
+// Seek sets the offset of the next Read or Write. It satisfies the io.Seeker interface.
+func (file *File) Seek(offset int64, whence int) (int64, error) {
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += file.pos
+	case io.SeekEnd:
+		offset += int64(len(file.data))
+	default:
+		return 0, newError(SYS_INVALID_INPUT_PARAM, "Invalid whence %v", whence)
+	}
+
+	if offset < 0 {
+		return 0, newError(SYS_INVALID_INPUT_PARAM, "Negative offset %v", offset)
+	}
+
+	file.pos = offset
+
+	return offset, nil
+}
+
+// Close stores the data written to the replica, which becomes the only good replica
+func (file *File) Close() error {
+	if file.closed {
+		return os.ErrClosed
+	}
+
+	file.closed = true
+
+	if !file.dirty {
+		return nil
+	}
+
+	srv := file.con.srv
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.objs[file.obj.path] != file.obj {
+		return newError(USER_FILE_DOES_NOT_EXIST, "%v was removed while open", file.obj.path)
+	}
+
+	srv.writeReplica(file.obj, file.repl, file.data)
+
+	return nil
+}
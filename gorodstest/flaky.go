@@ -0,0 +1,209 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorodstest
+
+import "sync"
+
+// ErrConnectionReset is the error of the calls failed by a FlakyConnection
+var ErrConnectionReset = newError(SYS_HEADER_READ_LEN_ERR, "connection reset")
+
+// FlakyConnection is a Connection for testing retries and parallel workers: it counts the calls
+// of the operations changing the zone, and fails those set up with Fail before making them.
+// The operations are named after their methods, like "Replicate" or "Chmod".
+type FlakyConnection struct {
+	*Connection
+
+	mu       sync.Mutex
+	calls    map[string]int
+	failures map[string]int
+}
+
+// NewFlakyConnection wraps con, whose calls don't fail until Fail is called
+func NewFlakyConnection(con *Connection) *FlakyConnection {
+	return &FlakyConnection{
+		Connection: con,
+		calls:      make(map[string]int),
+		failures:   make(map[string]int),
+	}
+}
+
+// Fail makes the next n calls of the operation op on the path p return ErrConnectionReset.
+// The source is the path of Rename and Copy.
+func (con *FlakyConnection) Fail(op string, p string, n int) {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	con.failures[op+" "+p] = n
+}
+
+// Calls returns the number of calls made to the operations ops, failed ones included, or to
+// all the operations when ops is empty
+func (con *FlakyConnection) Calls(ops ...string) int {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	n := 0
+
+	if len(ops) == 0 {
+		for _, c := range con.calls {
+			n += c
+		}
+	}
+
+	for _, op := range ops {
+		n += con.calls[op]
+	}
+
+	return n
+}
+
+// call counts a call of op on p, and returns ErrConnectionReset when it has to fail
+func (con *FlakyConnection) call(op string, p string) error {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	con.calls[op]++
+
+	if key := op + " " + p; con.failures[key] > 0 {
+		con.failures[key]--
+		return ErrConnectionReset
+	}
+
+	return nil
+}
+
+// Mkdir counts and runs Connection.Mkdir, unless it has to fail
+func (con *FlakyConnection) Mkdir(p string, recursive bool) error {
+	if err := con.call("Mkdir", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Mkdir(p, recursive)
+}
+
+// Put counts and runs Connection.Put, unless it has to fail
+func (con *FlakyConnection) Put(p string, data []byte, opts PutOptions) error {
+	if err := con.call("Put", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Put(p, data, opts)
+}
+
+// Remove counts and runs Connection.Remove, unless it has to fail
+func (con *FlakyConnection) Remove(p string, recursive bool) error {
+	if err := con.call("Remove", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Remove(p, recursive)
+}
+
+// Rename counts and runs Connection.Rename, unless it has to fail
+func (con *FlakyConnection) Rename(src string, dest string) error {
+	if err := con.call("Rename", src); err != nil {
+		return err
+	}
+
+	return con.Connection.Rename(src, dest)
+}
+
+// Copy counts and runs Connection.Copy, unless it has to fail
+func (con *FlakyConnection) Copy(src string, dest string) error {
+	if err := con.call("Copy", src); err != nil {
+		return err
+	}
+
+	return con.Connection.Copy(src, dest)
+}
+
+// Chmod counts and runs Connection.Chmod, unless it has to fail
+func (con *FlakyConnection) Chmod(p string, principal string, accessLevel int, recursive bool) error {
+	if err := con.call("Chmod", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Chmod(p, principal, accessLevel, recursive)
+}
+
+// SetInheritance counts and runs Connection.SetInheritance, unless it has to fail
+func (con *FlakyConnection) SetInheritance(p string, inherit bool, recursive bool) error {
+	if err := con.call("SetInheritance", p); err != nil {
+		return err
+	}
+
+	return con.Connection.SetInheritance(p, inherit, recursive)
+}
+
+// AddMeta counts and runs Connection.AddMeta, unless it has to fail
+func (con *FlakyConnection) AddMeta(p string, avu AVU) error {
+	if err := con.call("AddMeta", p); err != nil {
+		return err
+	}
+
+	return con.Connection.AddMeta(p, avu)
+}
+
+// DeleteMeta counts and runs Connection.DeleteMeta, unless it has to fail
+func (con *FlakyConnection) DeleteMeta(p string, attr string) error {
+	if err := con.call("DeleteMeta", p); err != nil {
+		return err
+	}
+
+	return con.Connection.DeleteMeta(p, attr)
+}
+
+// RemoveMeta counts and runs Connection.RemoveMeta, unless it has to fail
+func (con *FlakyConnection) RemoveMeta(p string, avu AVU) error {
+	if err := con.call("RemoveMeta", p); err != nil {
+		return err
+	}
+
+	return con.Connection.RemoveMeta(p, avu)
+}
+
+// Register counts and runs Connection.Register, unless it has to fail. p is the data object.
+func (con *FlakyConnection) Register(physPath string, p string, opts RegisterOptions) error {
+	if err := con.call("Register", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Register(physPath, p, opts)
+}
+
+// Unregister counts and runs Connection.Unregister, unless it has to fail
+func (con *FlakyConnection) Unregister(p string, replNum int) error {
+	if err := con.call("Unregister", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Unregister(p, replNum)
+}
+
+// Replicate counts and runs Connection.Replicate, unless it has to fail
+func (con *FlakyConnection) Replicate(p string, resource string) error {
+	if err := con.call("Replicate", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Replicate(p, resource)
+}
+
+// Trim counts and runs Connection.Trim, unless it has to fail
+func (con *FlakyConnection) Trim(p string, replNum int) error {
+	if err := con.call("Trim", p); err != nil {
+		return err
+	}
+
+	return con.Connection.Trim(p, replNum)
+}
+
+// PhysicalMove counts and runs Connection.PhysicalMove, unless it has to fail
+func (con *FlakyConnection) PhysicalMove(p string, src string, dest string) error {
+	if err := con.call("PhysicalMove", p); err != nil {
+		return err
+	}
+
+	return con.Connection.PhysicalMove(p, src, dest)
+}
@@ -0,0 +1,413 @@
+package gorodstest
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metaOf returns the AVUs of the collection or data object p, which the user must have level
+// access to, and a function storing modified AVUs. srv.mu must be held.
+func (con *Connection) metaOf(p string, level int) ([]AVU, func([]AVU), error) {
+	if _, ok := con.srv.colls[p]; ok {
+		coll, err := con.lookupColl(p, level)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return coll.meta, func(meta []AVU) { coll.meta = meta; coll.modified = time.Now() }, nil
+	}
+
+	obj, err := con.lookupObj(p, level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return obj.meta, func(meta []AVU) { obj.meta = meta; obj.modified = time.Now() }, nil
+}
+
+// Meta returns the AVUs of the collection or data object p (imeta ls)
+func (con *Connection) Meta(p string) ([]AVU, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	meta, _, err := con.metaOf(p, Read)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]AVU(nil), meta...), nil
+}
+
+// AddMeta attaches an AVU to the collection or data object p (imeta add). Adding an AVU
+// that's already attached fails, like it does in iRODS.
+func (con *Connection) AddMeta(p string, avu AVU) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	if avu.Attribute == "" || avu.Value == "" {
+		return newError(CAT_INVALID_ARGUMENT, "AVUs must have an attribute and a value")
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	meta, store, err := con.metaOf(p, Write)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range meta {
+		if existing == avu {
+			return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v already has the AVU %v", p, avu)
+		}
+	}
+
+	store(append(append([]AVU(nil), meta...), avu))
+
+	return nil
+}
+
+// DeleteMeta removes every AVU with the attribute attr from the collection or data object p
+// (imeta rmw p attr %)
+func (con *Connection) DeleteMeta(p string, attr string) error {
+	return con.deleteMeta(p, func(avu AVU) bool {
+		return avu.Attribute == attr
+	})
+}
+
+// RemoveMeta removes an AVU from the collection or data object p (imeta rm)
+func (con *Connection) RemoveMeta(p string, avu AVU) error {
+	return con.deleteMeta(p, func(existing AVU) bool {
+		return existing == avu
+	})
+}
+
+func (con *Connection) deleteMeta(p string, match func(AVU) bool) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	meta, store, err := con.metaOf(p, Write)
+	if err != nil {
+		return err
+	}
+
+	var kept []AVU
+	for _, avu := range meta {
+		if !match(avu) {
+			kept = append(kept, avu)
+		}
+	}
+
+	if len(kept) == len(meta) {
+		return newError(CAT_NO_ROWS_FOUND, "No matching AVU on %v", p)
+	}
+
+	store(kept)
+
+	return nil
+}
+
+// metaCondition is a condition of a QueryMeta query
+type metaCondition struct {
+	attr  string
+	op    string
+	value string
+
+	// or holds the alternatives of the GenQuery form "attr op value || op value"
+	or []metaCondition
+}
+
+// match reports whether value satisfies the condition or one of its alternatives
+func (cond metaCondition) match(value string, ignoreCase bool) bool {
+	if compare(value, cond.op, cond.value, ignoreCase) {
+		return true
+	}
+
+	for _, alt := range cond.or {
+		if compare(value, alt.op, alt.value, ignoreCase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// QueryMeta returns the collections and data objects the user can read that have AVUs
+// matching qString, using the syntax of imeta qu: "attr op value [and attr op value]...",
+// with op one of =, <>, !=, <, >, <=, >=, like and "not like". Values may be quoted, and
+// are compared as numbers when both sides are numeric. Collections are returned first.
+func (con *Connection) QueryMeta(qString string) ([]ObjInfo, error) {
+	conds, err := parseMetaQuery(qString)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	var collPaths, objPaths []string
+
+	for p, coll := range con.srv.colls {
+		if matchMeta(coll.meta, conds) {
+			collPaths = append(collPaths, p)
+		}
+	}
+
+	for p, obj := range con.srv.objs {
+		if matchMeta(obj.meta, conds) {
+			objPaths = append(objPaths, p)
+		}
+	}
+
+	sort.Strings(collPaths)
+	sort.Strings(objPaths)
+
+	var results []ObjInfo
+
+	for _, p := range collPaths {
+		if coll, err := con.lookupColl(p, Read); err == nil {
+			results = append(results, con.srv.collInfo(coll))
+		}
+	}
+
+	for _, p := range objPaths {
+		if obj, err := con.lookupObj(p, Read); err == nil {
+			results = append(results, con.srv.objInfo(obj))
+		}
+	}
+
+	return results, nil
+}
+
+func parseMetaQuery(qString string) ([]metaCondition, error) {
+	tokens := tokenize(qString)
+
+	var conds []metaCondition
+
+	for len(tokens) > 0 {
+		if len(tokens) < 3 {
+			return nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid query %q", qString)
+		}
+
+		cond := metaCondition{attr: tokens[0]}
+		tokens = tokens[1:]
+
+		for {
+			alt, rest, err := parseComparison(tokens, qString)
+			if err != nil {
+				return nil, err
+			}
+
+			if cond.op == "" {
+				cond.op, cond.value = alt.op, alt.value
+			} else {
+				cond.or = append(cond.or, alt)
+			}
+
+			if tokens = rest; len(tokens) == 0 || tokens[0] != "||" {
+				break
+			}
+
+			tokens = tokens[1:]
+		}
+
+		conds = append(conds, cond)
+
+		if len(tokens) > 0 {
+			if strings.ToLower(tokens[0]) != "and" {
+				return nil, newError(SYS_INVALID_INPUT_PARAM, "Expected \"and\" in query %q", qString)
+			}
+
+			tokens = tokens[1:]
+		}
+	}
+
+	if len(conds) == 0 {
+		return nil, newError(SYS_INVALID_INPUT_PARAM, "Empty query")
+	}
+
+	return conds, nil
+}
+
+// parseComparison parses the "op value" of a condition at the start of tokens
+func parseComparison(tokens []string, qString string) (metaCondition, []string, error) {
+	if len(tokens) < 2 {
+		return metaCondition{}, nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid query %q", qString)
+	}
+
+	cond := metaCondition{op: strings.ToLower(tokens[0])}
+	tokens = tokens[1:]
+
+	if cond.op == "not" && len(tokens) > 1 && strings.ToLower(tokens[0]) == "like" {
+		cond.op = "not like"
+		tokens = tokens[1:]
+	}
+
+	cond.value = tokens[0]
+
+	if !validOp(cond.op) {
+		return metaCondition{}, nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid operator %q in query %q", cond.op, qString)
+	}
+
+	return cond, tokens[1:], nil
+}
+
+// tokenize splits s on spaces, keeping single or double quoted strings together
+func tokenize(s string) []string {
+	var (
+		tokens []string
+		cur    strings.Builder
+		quote  rune
+		inTok  bool
+	)
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inTok = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inTok {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inTok = false
+			}
+		default:
+			cur.WriteRune(r)
+			inTok = true
+		}
+	}
+
+	if inTok {
+		tokens = append(tokens, cur.String())
+	}
+
+	return tokens
+}
+
+func validOp(op string) bool {
+	switch op {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "like", "not like":
+		return true
+	}
+
+	return false
+}
+
+// matchMeta reports whether every condition is satisfied by one of the AVUs
+func matchMeta(meta []AVU, conds []metaCondition) bool {
+	for _, cond := range conds {
+		matched := false
+
+		for _, avu := range meta {
+			if avu.Attribute == cond.attr && cond.match(avu.Value, false) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compare evaluates "value op operand", numerically when both sides are numbers
+func compare(value string, op string, operand string, ignoreCase bool) bool {
+	if ignoreCase {
+		value = strings.ToUpper(value)
+		operand = strings.ToUpper(operand)
+	}
+
+	switch op {
+	case "like":
+		return likePattern(operand).MatchString(value)
+	case "not like":
+		return !likePattern(operand).MatchString(value)
+	}
+
+	var cmp int
+
+	a, aErr := strconv.ParseFloat(value, 64)
+	b, bErr := strconv.ParseFloat(operand, 64)
+
+	switch {
+	case aErr == nil && bErr == nil && a < b:
+		cmp = -1
+	case aErr == nil && bErr == nil && a > b:
+		cmp = 1
+	case aErr == nil && bErr == nil:
+		cmp = 0
+	default:
+		cmp = strings.Compare(value, operand)
+	}
+
+	switch op {
+	case "=":
+		return cmp == 0
+	case "<>", "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case ">=":
+		return cmp >= 0
+	}
+
+	return false
+}
+
+// likePattern converts a SQL LIKE pattern to a regular expression, a backslash escapes the
+// next character
+func likePattern(pattern string) *regexp.Regexp {
+	var (
+		expr    strings.Builder
+		escaped bool
+	)
+
+	expr.WriteString("^")
+
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			expr.WriteString(".*")
+		case r == '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,519 @@
+package gorodstest
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryCondition is a condition of an IQuest where clause
+type queryCondition struct {
+	column string
+	metaCondition
+}
+
+// IQuest runs a subset of the iquest GenQuery language:
+//
+//	select COL[, COL]... [where COL op 'value' [|| op 'value']... [and COL op 'value']...]
+//
+// with the operators of QueryMeta. Rows are distinct, and keyed by column name. The supported
+// columns are those of data objects and their replicas (COLL_NAME, DATA_NAME, DATA_ID,
+// DATA_SIZE, DATA_CHECKSUM, DATA_REPL_NUM, DATA_REPL_STATUS, DATA_RESC_NAME, DATA_RESC_HIER,
+// DATA_PATH, DATA_OWNER_NAME, DATA_OWNER_ZONE, DATA_CREATE_TIME, DATA_MODIFY_TIME,
+// META_DATA_ATTR_NAME, META_DATA_ATTR_VALUE, META_DATA_ATTR_UNITS, DATA_ACCESS_NAME), of
+// collections (COLL_NAME, COLL_PARENT_NAME, COLL_ID, COLL_OWNER_NAME, COLL_OWNER_ZONE,
+// COLL_INHERITANCE, COLL_CREATE_TIME, COLL_MODIFY_TIME, META_COLL_ATTR_NAME,
+// META_COLL_ATTR_VALUE, META_COLL_ATTR_UNITS, COLL_ACCESS_NAME), of users (USER_NAME,
+// USER_ZONE, USER_TYPE, USER_GROUP_NAME), of resources (RESC_NAME, RESC_VAULT_PATH) and of
+// zones (ZONE_NAME, ZONE_TYPE), the zones of federated users being remote.
+// A query can't mix columns of different kinds, except COLL_NAME with data object columns,
+// and USER_NAME, USER_ZONE and USER_TYPE with DATA_ACCESS_NAME or COLL_ACCESS_NAME, which
+// describe the principal of each ACL entry. upperCase compares the where clause case
+// insensitively, like iquest -z.
+func (con *Connection) IQuest(query string, upperCase bool) ([]map[string]string, error) {
+	cols, conds, err := parseGenQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append([]string(nil), cols...)
+	for _, cond := range conds {
+		all = append(all, cond.column)
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	var rows []map[string]string
+
+	switch kind := queryKind(all); kind {
+	case "data":
+		rows = con.dataRows(hasPrefix(all, "META_DATA_"), hasPrefix(all, "DATA_ACCESS_"))
+	case "coll":
+		rows = con.collRows(hasPrefix(all, "META_COLL_"), hasPrefix(all, "COLL_ACCESS_"))
+	case "user":
+		rows = con.userRows(hasPrefix(all, "USER_GROUP_"))
+	case "resc":
+		rows = con.rescRows()
+	case "zone":
+		rows = con.zoneRows()
+	default:
+		return nil, newError(SYS_INVALID_INPUT_PARAM, "Unsupported combination of columns in %q", query)
+	}
+
+	seen := make(map[string]bool)
+	results := make([]map[string]string, 0)
+
+	for _, row := range rows {
+		if !matchRow(row, conds, upperCase) {
+			continue
+		}
+
+		result := make(map[string]string, len(cols))
+		values := make([]string, len(cols))
+
+		for inx, col := range cols {
+			val, ok := row[col]
+			if !ok {
+				return nil, newError(SYS_INVALID_INPUT_PARAM, "Unsupported column %v", col)
+			}
+
+			result[col] = val
+			values[inx] = val
+		}
+
+		key := strings.Join(values, "\x00")
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// IQuestPage runs query like IQuest, and returns limit rows starting at row offset with the
+// number of rows matched by the query
+func (con *Connection) IQuestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	rows, err := con.IQuest(query, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(rows)
+
+	if offset > total {
+		offset = total
+	}
+
+	if limit > total-offset {
+		limit = total - offset
+	}
+
+	return rows[offset : offset+limit], total, nil
+}
+
+func parseGenQuery(query string) ([]string, []queryCondition, error) {
+	tokens := tokenize(strings.Replace(query, ",", " , ", -1))
+
+	if len(tokens) < 2 || strings.ToLower(tokens[0]) != "select" {
+		return nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid query %q, expected select", query)
+	}
+
+	tokens = tokens[1:]
+
+	var cols []string
+
+	for len(tokens) > 0 && strings.ToLower(tokens[0]) != "where" {
+		if tokens[0] != "," {
+			cols = append(cols, strings.ToUpper(tokens[0]))
+		}
+
+		tokens = tokens[1:]
+	}
+
+	if len(cols) == 0 {
+		return nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid query %q, no columns selected", query)
+	}
+
+	var conds []queryCondition
+
+	if len(tokens) > 0 {
+		metaConds, err := parseMetaQuery(strings.Join(quoteTokens(tokens[1:]), " "))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, cond := range metaConds {
+			conds = append(conds, queryCondition{
+				column:        strings.ToUpper(cond.attr),
+				metaCondition: cond,
+			})
+		}
+	}
+
+	return cols, conds, nil
+}
+
+// quoteTokens quotes tokens again after tokenize, so they can be parsed by parseMetaQuery
+func quoteTokens(tokens []string) []string {
+	quoted := make([]string, len(tokens))
+
+	for inx, tok := range tokens {
+		if strings.ContainsAny(tok, " '\t") || tok == "" {
+			tok = `"` + tok + `"`
+		}
+
+		quoted[inx] = tok
+	}
+
+	return quoted
+}
+
+func hasPrefix(cols []string, prefix string) bool {
+	for _, col := range cols {
+		if strings.HasPrefix(col, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryKind returns the kind of rows the columns describe: data, coll, user, resc or zone
+func queryKind(cols []string) string {
+	kinds := make(map[string]bool)
+
+	for _, col := range cols {
+		switch {
+		case strings.HasPrefix(col, "DATA_"), strings.HasPrefix(col, "META_DATA_"):
+			kinds["data"] = true
+		case strings.HasPrefix(col, "COLL_"), strings.HasPrefix(col, "META_COLL_"):
+			kinds["coll"] = true
+		case strings.HasPrefix(col, "USER_"):
+			kinds["user"] = true
+		case strings.HasPrefix(col, "RESC_"):
+			kinds["resc"] = true
+		case strings.HasPrefix(col, "ZONE_"):
+			kinds["zone"] = true
+		default:
+			return ""
+		}
+	}
+
+	// COLL_NAME is the collection of data objects in data queries
+	if kinds["data"] && kinds["coll"] && !hasPrefix(cols, "META_COLL_") && !hasPrefix(cols, "COLL_ACCESS_") {
+		delete(kinds, "coll")
+	}
+
+	// USER_ columns describe the principals of ACL entries in access queries
+	if kinds["user"] && (hasPrefix(cols, "DATA_ACCESS_") || hasPrefix(cols, "COLL_ACCESS_")) && !hasPrefix(cols, "USER_GROUP_") {
+		delete(kinds, "user")
+	}
+
+	if len(kinds) != 1 {
+		return ""
+	}
+
+	for kind := range kinds {
+		return kind
+	}
+
+	return ""
+}
+
+// queryTime formats a time like the iCAT does
+func queryTime(t time.Time) string {
+	return fmt.Sprintf("%011d", t.Unix())
+}
+
+func splitPrincipal(key string) (string, string) {
+	split := strings.SplitN(key, "#", 2)
+	if len(split) == 1 {
+		return split[0], ""
+	}
+
+	return split[0], split[1]
+}
+
+// accessNames are the names of the access levels in the catalog, the granular levels of
+// iRODS 4.3 are named like its catalog
+var accessNames = map[int]string{
+	ReadMetadata:   "read_metadata",
+	Read:           "read object",
+	CreateMetadata: "create_metadata",
+	ModifyMetadata: "modify_metadata",
+	DeleteMetadata: "delete_metadata",
+	CreateObject:   "create_object",
+	Write:          "modify object",
+	DeleteObject:   "delete_object",
+	Own:            "own",
+}
+
+// userTypeNames are the names of the user types in the catalog
+var userTypeNames = map[int]string{
+	UserType:       "rodsuser",
+	AdminType:      "rodsadmin",
+	GroupAdminType: "groupadmin",
+	GroupType:      "rodsgroup",
+}
+
+// expandRow returns row once per AVU with withMeta, once per ACL entry with withAccess, or as is.
+// prefix is META_DATA_ or META_COLL_, access DATA_ACCESS_NAME or COLL_ACCESS_NAME.
+func (con *Connection) expandRow(row map[string]string, meta []AVU, acl map[string]int, withMeta bool, withAccess bool, prefix string, access string) []map[string]string {
+	rows := []map[string]string{row}
+
+	if withMeta {
+		var metaRows []map[string]string
+
+		for _, r := range rows {
+			for _, avu := range meta {
+				metaRow := copyRow(r)
+				metaRow[prefix+"ATTR_NAME"] = avu.Attribute
+				metaRow[prefix+"ATTR_VALUE"] = avu.Value
+				metaRow[prefix+"ATTR_UNITS"] = avu.Units
+
+				metaRows = append(metaRows, metaRow)
+			}
+		}
+
+		rows = metaRows
+	}
+
+	if withAccess {
+		var aclRows []map[string]string
+
+		for _, r := range rows {
+			for _, entry := range con.srv.aclList(acl) {
+				name, zone := splitPrincipal(entry.Principal)
+
+				aclRow := copyRow(r)
+				aclRow[access] = accessNames[entry.AccessLevel]
+				aclRow["USER_NAME"] = name
+				aclRow["USER_ZONE"] = zone
+				aclRow["USER_TYPE"] = userTypeNames[entry.Type]
+
+				aclRows = append(aclRows, aclRow)
+			}
+		}
+
+		rows = aclRows
+	}
+
+	return rows
+}
+
+// dataRows returns a row per readable replica, and per AVU or ACL entry when withMeta or
+// withAccess is set. srv.mu must be held.
+func (con *Connection) dataRows(withMeta bool, withAccess bool) []map[string]string {
+	var rows []map[string]string
+
+	for _, p := range sortedKeys(con.srv.objs) {
+		obj, err := con.lookupObj(p, Read)
+		if err != nil {
+			continue
+		}
+
+		ownerName, ownerZone := splitPrincipal(obj.owner)
+
+		for _, repl := range obj.replicas {
+			row := map[string]string{
+				"COLL_NAME":        path.Dir(obj.path),
+				"DATA_NAME":        path.Base(obj.path),
+				"DATA_ID":          strconv.FormatInt(obj.id, 10),
+				"DATA_SIZE":        strconv.Itoa(len(repl.data)),
+				"DATA_CHECKSUM":    repl.checksum,
+				"DATA_REPL_NUM":    strconv.Itoa(repl.num),
+				"DATA_REPL_STATUS": strconv.Itoa(repl.status),
+				"DATA_RESC_NAME":   repl.resource,
+				"DATA_RESC_HIER":   repl.resource,
+				"DATA_PATH":        con.srv.replicaPath(obj, repl),
+				"DATA_OWNER_NAME":  ownerName,
+				"DATA_OWNER_ZONE":  ownerZone,
+				"DATA_CREATE_TIME": queryTime(obj.created),
+				"DATA_MODIFY_TIME": queryTime(repl.modified),
+			}
+
+			rows = append(rows, con.expandRow(row, obj.meta, obj.acl, withMeta, withAccess, "META_DATA_", "DATA_ACCESS_NAME")...)
+		}
+	}
+
+	return rows
+}
+
+// collRows returns a row per readable collection, and per AVU or ACL entry when withMeta or
+// withAccess is set. srv.mu must be held.
+func (con *Connection) collRows(withMeta bool, withAccess bool) []map[string]string {
+	var rows []map[string]string
+
+	for _, p := range sortedKeys(con.srv.colls) {
+		coll, err := con.lookupColl(p, Read)
+		if err != nil {
+			continue
+		}
+
+		ownerName, ownerZone := splitPrincipal(coll.owner)
+
+		inheritance := "0"
+		if coll.inherit {
+			inheritance = "1"
+		}
+
+		row := map[string]string{
+			"COLL_NAME":        coll.path,
+			"COLL_PARENT_NAME": path.Dir(coll.path),
+			"COLL_ID":          strconv.FormatInt(coll.id, 10),
+			"COLL_OWNER_NAME":  ownerName,
+			"COLL_OWNER_ZONE":  ownerZone,
+			"COLL_INHERITANCE": inheritance,
+			"COLL_CREATE_TIME": queryTime(coll.created),
+			"COLL_MODIFY_TIME": queryTime(coll.modified),
+		}
+
+		rows = append(rows, con.expandRow(row, coll.meta, coll.acl, withMeta, withAccess, "META_COLL_", "COLL_ACCESS_NAME")...)
+	}
+
+	return rows
+}
+
+// userRows returns a row per user and group, and per group membership when withGroups is set.
+// srv.mu must be held.
+func (con *Connection) userRows(withGroups bool) []map[string]string {
+	var rows []map[string]string
+
+	for _, key := range sortedKeys(con.srv.users) {
+		usr := con.srv.users[key]
+
+		row := map[string]string{
+			"USER_NAME": usr.Name,
+			"USER_ZONE": usr.Zone,
+			"USER_TYPE": userTypeNames[usr.Type],
+		}
+
+		if !withGroups {
+			rows = append(rows, row)
+			continue
+		}
+
+		for _, grp := range con.srv.userGroups(key) {
+			groupRow := copyRow(row)
+			groupRow["USER_GROUP_NAME"] = grp
+
+			rows = append(rows, groupRow)
+		}
+	}
+
+	if !withGroups {
+		for _, key := range sortedKeys(con.srv.groups) {
+			grp := con.srv.groups[key]
+
+			rows = append(rows, map[string]string{
+				"USER_NAME": grp.Name,
+				"USER_ZONE": grp.Zone,
+				"USER_TYPE": userTypeNames[GroupType],
+			})
+		}
+	}
+
+	return rows
+}
+
+// rescRows returns a row per resource. srv.mu must be held.
+func (con *Connection) rescRows() []map[string]string {
+	var rows []map[string]string
+
+	for _, name := range sortedKeys(con.srv.resources) {
+		rows = append(rows, map[string]string{
+			"RESC_NAME":       name,
+			"RESC_VAULT_PATH": con.srv.resources[name].VaultPath,
+		})
+	}
+
+	return rows
+}
+
+// zoneRows returns a row for the zone of the server, and one per zone of its federated users.
+// srv.mu must be held.
+func (con *Connection) zoneRows() []map[string]string {
+	rows := []map[string]string{{
+		"ZONE_NAME": con.srv.zone,
+		"ZONE_TYPE": "local",
+	}}
+
+	remote := make(map[string]bool)
+	for _, usr := range con.srv.users {
+		if usr.Zone != con.srv.zone {
+			remote[usr.Zone] = true
+		}
+	}
+
+	for _, name := range sortedKeys(remote) {
+		rows = append(rows, map[string]string{
+			"ZONE_NAME": name,
+			"ZONE_TYPE": "remote",
+		})
+	}
+
+	return rows
+}
+
+func matchRow(row map[string]string, conds []queryCondition, ignoreCase bool) bool {
+	for _, cond := range conds {
+		val, ok := row[cond.column]
+		if !ok || !cond.match(val, ignoreCase) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyRow(row map[string]string) map[string]string {
+	cp := make(map[string]string, len(row)+3)
+	for k, v := range row {
+		cp[k] = v
+	}
+
+	return cp
+}
+
+// sortedKeys returns the sorted keys of the catalog maps
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	switch typed := m.(type) {
+	case map[string]*dataObj:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]*collection:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]*User:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]*Group:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]*Resource:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]bool:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,150 @@
+package gorodstest
+
+import (
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// RegisterOptions tune Register, see irodsfs.RegisterOptions
+type RegisterOptions = irodsfs.RegisterOptions
+
+// Register registers the local file physPath as the data object p, or as a new replica of p
+// with opts.Replica (ireg). The fake server reads the file when it's registered, later changes
+// to it aren't seen.
+func (con *Connection) Register(physPath string, p string, opts RegisterOptions) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(physPath)
+	if err != nil {
+		return newError(UNIX_FILE_OPEN_ERR, "Unable to read %v: %v", physPath, err)
+	}
+
+	sum := ""
+
+	switch {
+	case opts.Verify != "":
+		want, err := checksum.Parse(opts.Verify)
+		if err != nil {
+			return newError(SYS_INVALID_INPUT_PARAM, "%v", err)
+		}
+
+		if got := checksum.Bytes(want.Algorithm, data); !got.Equal(want) {
+			return newError(USER_CHKSUM_MISMATCH, "Checksum of %v is %v, expected %v", physPath, got, want)
+		}
+
+		sum = want.String()
+	case opts.Checksum:
+		sum = con.srv.checksum(data)
+	}
+
+	resource := opts.Resource
+	if resource == "" {
+		resource = DefaultResource
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.resources[resource]; !ok {
+		return newError(CAT_INVALID_RESOURCE, "Resource %v does not exist", resource)
+	}
+
+	if _, ok := con.srv.colls[p]; ok {
+		return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v is a collection", p)
+	}
+
+	repl := &replica{
+		resource: resource,
+		status:   Good,
+		data:     data,
+		checksum: sum,
+		modified: time.Now(),
+		phyPath:  path.Clean(physPath),
+	}
+
+	obj, exists := con.srv.objs[p]
+
+	switch {
+	case opts.Replica:
+		if !exists {
+			return newError(USER_FILE_DOES_NOT_EXIST, "Data object %v does not exist", p)
+		}
+
+		if _, err := con.lookupObj(p, Write); err != nil {
+			return err
+		}
+
+		for _, r := range obj.replicas {
+			if r.resource == resource {
+				return newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v already has a replica on %v", p, resource)
+			}
+		}
+
+		repl.num = obj.nextReplNum()
+		obj.replicas = append(obj.replicas, repl)
+	case exists:
+		if !opts.Force {
+			return newError(OVERWRITE_WITHOUT_FORCE_FLAG, "Data object %v already exists", p)
+		}
+
+		if _, err := con.lookupObj(p, Write); err != nil {
+			return err
+		}
+
+		obj.replicas = []*replica{repl}
+		obj.modified = repl.modified
+	default:
+		if obj, err = con.create(p, resource); err != nil {
+			return err
+		}
+
+		obj.replicas = []*replica{repl}
+	}
+
+	return nil
+}
+
+// Unregister removes the replica numbered replNum of p from the catalog, or p when replNum is
+// -1 or the replica is its last (iunreg)
+func (con *Connection) Unregister(p string, replNum int) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	obj, err := con.lookupObj(p, Own)
+	if err != nil {
+		return err
+	}
+
+	if replNum >= 0 {
+		if obj.replica(replNum) == nil {
+			return newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+		}
+
+		if len(obj.replicas) > 1 {
+			for inx, repl := range obj.replicas {
+				if repl.num == replNum {
+					obj.replicas = append(obj.replicas[:inx], obj.replicas[inx+1:]...)
+					break
+				}
+			}
+
+			return nil
+		}
+	}
+
+	delete(con.srv.objs, p)
+
+	return nil
+}
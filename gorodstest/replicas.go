@@ -0,0 +1,276 @@
+package gorodstest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Replicas returns the replicas of the data object p, ordered by number
+func (con *Connection) Replicas(p string) ([]Replica, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return nil, err
+	}
+
+	repls := make([]Replica, len(obj.replicas))
+	for inx, repl := range obj.replicas {
+		repls[inx] = con.srv.replicaInfo(obj, repl)
+	}
+
+	sort.Slice(repls, func(i, j int) bool {
+		return repls[i].Num < repls[j].Num
+	})
+
+	return repls, nil
+}
+
+// Replicate copies the newest good replica of p to resource (irepl). A stale replica on
+// resource is updated, a good one is left as is.
+func (con *Connection) Replicate(p string, resource string) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.resources[resource]; !ok {
+		return newError(CAT_INVALID_RESOURCE, "Resource %v does not exist", resource)
+	}
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return err
+	}
+
+	src := obj.goodReplica()
+
+	for _, repl := range obj.replicas {
+		if repl.resource == resource {
+			if repl.status != Good {
+				repl.data = append([]byte(nil), src.physical()...)
+				repl.disk = nil
+				repl.checksum = src.checksum
+				repl.status = Good
+				repl.modified = time.Now()
+			}
+
+			return nil
+		}
+	}
+
+	obj.replicas = append(obj.replicas, &replica{
+		num:      obj.nextReplNum(),
+		resource: resource,
+		status:   Good,
+		data:     append([]byte(nil), src.physical()...),
+		checksum: src.checksum,
+		modified: time.Now(),
+	})
+
+	return nil
+}
+
+// Trim removes the replica numbered replNum of p (itrim -N 1 -n). The last good replica can't
+// be trimmed.
+func (con *Connection) Trim(p string, replNum int) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	obj, err := con.lookupObj(p, Own)
+	if err != nil {
+		return err
+	}
+
+	good := 0
+	found := -1
+
+	for inx, repl := range obj.replicas {
+		if repl.status == Good {
+			good++
+		}
+
+		if repl.num == replNum {
+			found = inx
+		}
+	}
+
+	if found < 0 {
+		return newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+	}
+
+	if obj.replicas[found].status == Good && good == 1 {
+		return newError(SYS_INVALID_INPUT_PARAM, "Unable to trim the last good replica of %v", p)
+	}
+
+	obj.replicas = append(obj.replicas[:found], obj.replicas[found+1:]...)
+
+	return nil
+}
+
+// PhysicalMove moves the replica of p on the resource src to the resource dest (iphymv). The
+// data object can't already have a replica on dest.
+func (con *Connection) PhysicalMove(p string, src string, dest string) error {
+	p, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	if _, ok := con.srv.resources[dest]; !ok {
+		return newError(CAT_INVALID_RESOURCE, "Resource %v does not exist", dest)
+	}
+
+	obj, err := con.lookupObj(p, Write)
+	if err != nil {
+		return err
+	}
+
+	var moved, existing *replica
+
+	for _, repl := range obj.replicas {
+		switch repl.resource {
+		case src:
+			moved = repl
+		case dest:
+			existing = repl
+		}
+	}
+
+	switch {
+	case moved == nil:
+		return newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica on %v", p, src)
+	case existing != nil:
+		return newError(SYS_INVALID_INPUT_PARAM, "%v already has a replica on %v", p, dest)
+	}
+
+	moved.resource = dest
+	moved.modified = time.Now()
+
+	return nil
+}
+
+// Checksum computes and registers the checksum of the newest good replica of p (ichksum)
+func (con *Connection) Checksum(p string) (string, error) {
+	return con.checksumReplica(p, -1)
+}
+
+// ChecksumReplica computes and registers the checksum of the replica numbered replNum of p
+func (con *Connection) ChecksumReplica(p string, replNum int) (string, error) {
+	return con.checksumReplica(p, replNum)
+}
+
+func (con *Connection) checksumReplica(p string, replNum int) (string, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+
+	con.srv.mu.Lock()
+	defer con.srv.mu.Unlock()
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return "", err
+	}
+
+	repl := obj.goodReplica()
+	if replNum >= 0 {
+		if repl = obj.replica(replNum); repl == nil {
+			return "", newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+		}
+	}
+
+	repl.checksum = con.srv.checksum(repl.physical())
+
+	return repl.checksum, nil
+}
+
+// VerifyReplica returns the size and checksum of the data of the replica numbered replNum of
+// p, without changing the catalog (ichksum -K). The checksum has the scheme of the registered
+// one.
+func (con *Connection) VerifyReplica(p string, replNum int) (irodsfs.Fixity, error) {
+	p, err := cleanPath(p)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	con.srv.mu.RLock()
+	defer con.srv.mu.RUnlock()
+
+	obj, err := con.lookupObj(p, Read)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	repl := obj.replica(replNum)
+	if repl == nil {
+		return irodsfs.Fixity{}, newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+	}
+
+	data := repl.physical()
+
+	return irodsfs.Fixity{
+		Size:     int64(len(data)),
+		Checksum: con.srv.checksumAs(data, repl.checksum),
+	}, nil
+}
+
+// CorruptReplica replaces the data of a replica without updating the catalog, to simulate
+// bit rot or a physical file changed behind the back of iRODS
+func (srv *Server) CorruptReplica(p string, replNum int, data []byte) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	obj, ok := srv.objs[p]
+	if !ok {
+		return newError(USER_FILE_DOES_NOT_EXIST, "Data object %v does not exist", p)
+	}
+
+	repl := obj.replica(replNum)
+	if repl == nil {
+		return newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+	}
+
+	repl.disk = append([]byte(nil), data...)
+
+	return nil
+}
+
+// SetReplicaStatus sets the status of a replica, Good or Stale
+func (srv *Server) SetReplicaStatus(p string, replNum int, status int) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	obj, ok := srv.objs[p]
+	if !ok {
+		return newError(USER_FILE_DOES_NOT_EXIST, "Data object %v does not exist", p)
+	}
+
+	repl := obj.replica(replNum)
+	if repl == nil {
+		return newError(USER_FILE_DOES_NOT_EXIST, "%v has no replica %v", p, replNum)
+	}
+
+	repl.status = status
+
+	return nil
+}
@@ -0,0 +1,474 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package gorodstest provides an in-memory iRODS zone for unit testing code built on GoRODS,
+// without an iCAT server or the iRODS client libraries.
+//
+// A Server holds the catalog: collections, data objects with their replicas and checksums,
+// AVUs, ACLs, users, groups and resources. Connections are opened as a user of the zone, and
+// enforce the same permissions as iRODS:
+//
+//	srv := gorodstest.NewServer("tempZone")
+//	srv.CreateUser("alice", gorodstest.UserType)
+//
+//	con, _ := srv.Connect("alice")
+//	con.Put("/tempZone/home/alice/hello.txt", []byte("hello"), gorodstest.PutOptions{})
+//
+//	objs, _ := con.QueryMeta("project = apollo")
+//
+// Connections implement irodsfs.Filesystem, so code written against the irodsfs interfaces can
+// be tested with a fake zone and run with gorods.Connection.Filesystem() in production.
+// Code using gorods.Connection, Collection and DataObj directly is tested with a
+// FilesystemDefined connection served by the fake zone:
+//
+//	con, _ := gorods.NewConnection(&gorods.ConnectionOptions{
+//		Type:       gorods.FilesystemDefined,
+//		Filesystem: fsys,
+//	})
+//
+// NewFlakyConnection wraps a connection to test retries: it fails the calls set up with Fail,
+// and counts the calls changing the zone.
+//
+// The package doesn't depend on cgo.
+package gorodstest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// Object and principal types, see package irodsfs
+const (
+	DataObjType    = irodsfs.DataObjType
+	CollectionType = irodsfs.CollectionType
+	UserType       = irodsfs.UserType
+	AdminType      = irodsfs.AdminType
+	GroupAdminType = irodsfs.GroupAdminType
+	GroupType      = irodsfs.GroupType
+)
+
+// Access levels, see package irodsfs
+const (
+	Null  = irodsfs.Null
+	Read  = irodsfs.Read
+	Write = irodsfs.Write
+	Own   = irodsfs.Own
+
+	ReadMetadata   = irodsfs.ReadMetadata
+	CreateMetadata = irodsfs.CreateMetadata
+	ModifyMetadata = irodsfs.ModifyMetadata
+	DeleteMetadata = irodsfs.DeleteMetadata
+	CreateObject   = irodsfs.CreateObject
+	DeleteObject   = irodsfs.DeleteObject
+)
+
+// Replica statuses, as reported by DATA_REPL_STATUS
+const (
+	Stale = irodsfs.Stale
+	Good  = irodsfs.Good
+)
+
+// DefaultResource is the resource data objects are stored on when no resource is specified
+const DefaultResource = "demoResc"
+
+// iRODS error codes returned by the fake server
+const (
+	SYS_HEADER_READ_LEN_ERR               = -4000
+	SYS_INVALID_INPUT_PARAM               = -130000
+	USER_FILE_DOES_NOT_EXIST              = -310000
+	OVERWRITE_WITHOUT_FORCE_FLAG          = -312000
+	USER_CHKSUM_MISMATCH                  = -314000
+	UNIX_FILE_OPEN_ERR                    = -510000
+	CAT_NO_ROWS_FOUND                     = -808000
+	CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME = -809000
+	CAT_UNKNOWN_COLLECTION                = -814000
+	CAT_INVALID_ARGUMENT                  = -816000
+	CAT_UNKNOWN_FILE                      = -817000
+	CAT_NO_ACCESS_PERMISSION              = -818000
+	CAT_COLLECTION_NOT_EMPTY              = -821000
+	CAT_INVALID_USER                      = -827000
+	CAT_INVALID_RESOURCE                  = -831000
+)
+
+// Error is returned by the fake server, Code is the iRODS error code of the failure
+type Error struct {
+	Code    int
+	Message string
+}
+
+// Error returns the message and code of the error
+func (err *Error) Error() string {
+	return fmt.Sprintf("%v (iRODS error %v)", err.Message, err.Code)
+}
+
+// Is reports whether the error matches target: errors.Is(err, os.ErrNotExist) is true for the errors of
+// missing collections and data objects
+func (err *Error) Is(target error) bool {
+	return target == os.ErrNotExist && (err.Code == USER_FILE_DOES_NOT_EXIST || err.Code == CAT_UNKNOWN_COLLECTION || err.Code == CAT_UNKNOWN_FILE)
+}
+
+func newError(code int, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// IsCode reports whether err is an *Error with the given iRODS error code
+func IsCode(err error, code int) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == code
+}
+
+// User is a user of the fake zone
+type User struct {
+	Name string
+	Zone string
+	// Type is UserType, AdminType or GroupAdminType
+	Type       int
+	CreateTime time.Time
+}
+
+// Group is a group of the fake zone
+type Group struct {
+	Name       string
+	Zone       string
+	Members    []string
+	CreateTime time.Time
+}
+
+// Resource is a storage resource of the fake zone
+type Resource struct {
+	Name      string
+	VaultPath string
+}
+
+// Server is an in-memory iRODS zone. It's safe for concurrent use by multiple Connections.
+type Server struct {
+	// ChecksumScheme is the algorithm of the checksums computed by the server: md5, sha2,
+	// sha1, sha512 or adler32, see package checksum
+	ChecksumScheme string
+
+	mu        sync.RWMutex
+	zone      string
+	users     map[string]*User
+	groups    map[string]*Group
+	resources map[string]*Resource
+	colls     map[string]*collection
+	objs      map[string]*dataObj
+	nextId    int64
+}
+
+// NewServer creates a fake zone named zone, with the collections /zone, /zone/home,
+// /zone/trash, /zone/trash/home and /zone/home/public, the "public" group, the rodsadmin
+// "rods" and the resource DefaultResource
+func NewServer(zone string) *Server {
+	srv := &Server{
+		ChecksumScheme: "md5",
+		zone:           zone,
+		users:          make(map[string]*User),
+		groups:         make(map[string]*Group),
+		resources:      make(map[string]*Resource),
+		colls:          make(map[string]*collection),
+		objs:           make(map[string]*dataObj),
+		nextId:         10000,
+	}
+
+	root := srv.newCollection("/", "rods")
+	root.acl["rods#"+zone] = Own
+
+	for _, p := range []string{"/" + zone, "/" + zone + "/home", "/" + zone + "/trash", "/" + zone + "/trash/home"} {
+		coll := srv.newCollection(p, "rods")
+		coll.acl["rods#"+zone] = Own
+	}
+
+	srv.groups["public#"+zone] = &Group{
+		Name:       "public",
+		Zone:       zone,
+		CreateTime: time.Now(),
+	}
+
+	public := srv.newCollection("/"+zone+"/home/public", "rods")
+	public.acl["rods#"+zone] = Own
+	public.acl["public#"+zone] = Read
+
+	srv.CreateUser("rods", AdminType)
+	srv.CreateResource(DefaultResource)
+
+	return srv
+}
+
+// Zone returns the name of the zone
+func (srv *Server) Zone() string {
+	return srv.zone
+}
+
+// qualify returns name#zone, with the zone of the server if name has none
+func (srv *Server) qualify(name string) string {
+	if strings.Contains(name, "#") {
+		return name
+	}
+
+	return name + "#" + srv.zone
+}
+
+func (srv *Server) newCollection(p string, owner string) *collection {
+	srv.nextId++
+
+	coll := &collection{
+		id:      srv.nextId,
+		path:    p,
+		owner:   srv.qualify(owner),
+		created: time.Now(),
+		acl:     make(map[string]int),
+	}
+	coll.modified = coll.created
+
+	srv.colls[p] = coll
+
+	return coll
+}
+
+// CreateUser adds a user to the zone, with home and trash collections it owns. typ is one
+// of UserType, AdminType or GroupAdminType. Users of other zones are created as name#zone,
+// and don't get home collections.
+func (srv *Server) CreateUser(name string, typ int) (*User, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	key := srv.qualify(name)
+
+	if _, ok := srv.users[key]; ok {
+		return nil, newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "User %v already exists", key)
+	}
+
+	if _, ok := srv.groups[key]; ok {
+		return nil, newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v is a group", key)
+	}
+
+	split := strings.SplitN(key, "#", 2)
+
+	usr := &User{
+		Name:       split[0],
+		Zone:       split[1],
+		Type:       typ,
+		CreateTime: time.Now(),
+	}
+
+	srv.users[key] = usr
+
+	if usr.Zone == srv.zone {
+		for _, home := range []string{"/" + srv.zone + "/home/" + usr.Name, "/" + srv.zone + "/trash/home/" + usr.Name} {
+			if _, ok := srv.colls[home]; !ok {
+				srv.newCollection(home, key).acl[key] = Own
+			}
+		}
+
+		srv.groups["public#"+srv.zone].Members = append(srv.groups["public#"+srv.zone].Members, key)
+	}
+
+	return usr, nil
+}
+
+// CreateGroup adds a group to the zone
+func (srv *Server) CreateGroup(name string) (*Group, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	key := srv.qualify(name)
+
+	if _, ok := srv.groups[key]; ok {
+		return nil, newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "Group %v already exists", key)
+	}
+
+	if _, ok := srv.users[key]; ok {
+		return nil, newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "%v is a user", key)
+	}
+
+	grp := &Group{
+		Name:       strings.SplitN(key, "#", 2)[0],
+		Zone:       srv.zone,
+		CreateTime: time.Now(),
+	}
+
+	srv.groups[key] = grp
+
+	return grp, nil
+}
+
+// AddToGroup adds the user to the group
+func (srv *Server) AddToGroup(group string, user string) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	grp, ok := srv.groups[srv.qualify(group)]
+	if !ok {
+		return newError(CAT_INVALID_USER, "Group %v does not exist", group)
+	}
+
+	userKey := srv.qualify(user)
+
+	if _, ok := srv.users[userKey]; !ok {
+		return newError(CAT_INVALID_USER, "User %v does not exist", user)
+	}
+
+	for _, member := range grp.Members {
+		if member == userKey {
+			return nil
+		}
+	}
+
+	grp.Members = append(grp.Members, userKey)
+
+	return nil
+}
+
+// RemoveFromGroup removes the user from the group
+func (srv *Server) RemoveFromGroup(group string, user string) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	grp, ok := srv.groups[srv.qualify(group)]
+	if !ok {
+		return newError(CAT_INVALID_USER, "Group %v does not exist", group)
+	}
+
+	userKey := srv.qualify(user)
+
+	for inx, member := range grp.Members {
+		if member == userKey {
+			grp.Members = append(grp.Members[:inx], grp.Members[inx+1:]...)
+			return nil
+		}
+	}
+
+	return newError(CAT_INVALID_USER, "User %v is not a member of %v", user, group)
+}
+
+// Users returns the users of the zone, sorted by name
+func (srv *Server) Users() []User {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	users := make([]User, 0, len(srv.users))
+	for _, usr := range srv.users {
+		users = append(users, *usr)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name+"#"+users[i].Zone < users[j].Name+"#"+users[j].Zone
+	})
+
+	return users
+}
+
+// Groups returns the groups of the zone, sorted by name
+func (srv *Server) Groups() []Group {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	groups := make([]Group, 0, len(srv.groups))
+	for _, grp := range srv.groups {
+		g := *grp
+		g.Members = append([]string(nil), grp.Members...)
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
+
+// UserGroups returns the names of the groups user is a member of
+func (srv *Server) UserGroups(user string) []string {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	return srv.userGroups(srv.qualify(user))
+}
+
+// userGroups returns the groups of the qualified user name, srv.mu must be held
+func (srv *Server) userGroups(userKey string) []string {
+	var names []string
+
+	for _, grp := range srv.groups {
+		for _, member := range grp.Members {
+			if member == userKey {
+				names = append(names, grp.Name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// CreateResource adds a storage resource to the zone
+func (srv *Server) CreateResource(name string) (*Resource, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if _, ok := srv.resources[name]; ok {
+		return nil, newError(CATALOG_ALREADY_HAS_ITEM_BY_THAT_NAME, "Resource %v already exists", name)
+	}
+
+	resc := &Resource{
+		Name:      name,
+		VaultPath: "/var/lib/irods/" + name + "Vault",
+	}
+
+	srv.resources[name] = resc
+
+	return resc, nil
+}
+
+// Resources returns the names of the resources of the zone
+func (srv *Server) Resources() []string {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	names := make([]string, 0, len(srv.resources))
+	for name := range srv.resources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Connect opens a connection to the zone as user, which may be name or name#zone
+func (srv *Server) Connect(user string) (*Connection, error) {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+
+	key := srv.qualify(user)
+
+	if _, ok := srv.users[key]; !ok {
+		return nil, newError(CAT_INVALID_USER, "User %v does not exist", user)
+	}
+
+	return &Connection{
+		srv:  srv,
+		user: key,
+	}, nil
+}
+
+// cleanPath returns the absolute, cleaned form of p
+func cleanPath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", newError(SYS_INVALID_INPUT_PARAM, "%v is not an absolute path", p)
+	}
+
+	return path.Clean(p), nil
+}
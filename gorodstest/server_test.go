@@ -0,0 +1,330 @@
+package gorodstest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func setup(t *testing.T) (*Server, *Connection) {
+	srv := NewServer("tempZone")
+
+	if _, err := srv.CreateUser("alice", UserType); err != nil {
+		t.Fatal(err)
+	}
+
+	con, err := srv.Connect("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, con
+}
+
+func TestPutAndRead(t *testing.T) {
+	_, con := setup(t)
+
+	p := "/tempZone/home/alice/hello.txt"
+
+	if err := con.Put(p, []byte("hello"), PutOptions{Checksum: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := con.Put(p, []byte("again"), PutOptions{}); !IsCode(err, OVERWRITE_WITHOUT_FORCE_FLAG) {
+		t.Fatalf("Expected OVERWRITE_WITHOUT_FORCE_FLAG, got %v", err)
+	}
+
+	info, err := con.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size != 5 || info.Checksum != "5d41402abc4b2a76b9719d911017c592" || info.Owner != "alice#tempZone" {
+		t.Fatalf("Unexpected stat %+v", info)
+	}
+
+	f, err := con.Open(p, os.O_RDWR|os.O_APPEND)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Write([]byte(" world"))
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ = con.Open(p, os.O_RDONLY)
+	data, _ := ioutil.ReadAll(f)
+	f.Close()
+
+	if string(data) != "hello world" {
+		t.Fatalf("Read %q", data)
+	}
+
+	if info, _ := con.Stat(p); info.Checksum != "" {
+		t.Fatal("Checksum wasn't cleared by the write")
+	}
+
+	if err := con.Put(p, []byte("hello world"), PutOptions{Force: true, ChecksumScheme: "sha2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, _ := con.Stat(p); info.Checksum != "sha2:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=" {
+		t.Fatalf("Unexpected checksum %v", info.Checksum)
+	}
+
+	if err := con.Put(p, nil, PutOptions{Force: true, ChecksumScheme: "crc32"}); !IsCode(err, SYS_INVALID_INPUT_PARAM) {
+		t.Fatalf("Expected SYS_INVALID_INPUT_PARAM, got %v", err)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	_, con := setup(t)
+
+	if err := con.Mkdir("/tempZone/home/alice/a/b", true); err != nil {
+		t.Fatal(err)
+	}
+
+	con.Put("/tempZone/home/alice/a/b/c.txt", []byte("c"), PutOptions{})
+
+	if err := con.Remove("/tempZone/home/alice/a", false); !IsCode(err, CAT_COLLECTION_NOT_EMPTY) {
+		t.Fatalf("Expected CAT_COLLECTION_NOT_EMPTY, got %v", err)
+	}
+
+	if err := con.Copy("/tempZone/home/alice/a", "/tempZone/home/alice/copy"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := con.Rename("/tempZone/home/alice/a", "/tempZone/home/alice/moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/tempZone/home/alice/copy/b/c.txt", "/tempZone/home/alice/moved/b/c.txt"} {
+		if typ, err := con.PathType(p); err != nil || typ != DataObjType {
+			t.Fatalf("%v: %v %v", p, typ, err)
+		}
+	}
+
+	if err := con.Remove("/tempZone/home/alice/moved", true); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := con.List("/tempZone/home/alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].Name != "copy" || !list[0].IsDir() {
+		t.Fatalf("Unexpected listing %+v", list)
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	srv, con := setup(t)
+	srv.CreateResource("archiveResc")
+
+	p := "/tempZone/home/alice/data.bin"
+	con.Put(p, []byte("data"), PutOptions{})
+
+	if err := con.Replicate(p, "archiveResc"); err != nil {
+		t.Fatal(err)
+	}
+
+	con.Put(p, []byte("new data"), PutOptions{Force: true})
+
+	repls, _ := con.Replicas(p)
+	if len(repls) != 2 || repls[0].Status != Good || repls[1].Status != Stale || repls[1].Size != 4 {
+		t.Fatalf("Unexpected replicas %+v", repls)
+	}
+
+	stale, err := con.OpenReplica(p, 1, os.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := ioutil.ReadAll(stale); string(data) != "data" {
+		t.Fatalf("Read %q from the stale replica", data)
+	}
+
+	stale.Close()
+
+	if err := con.Trim(p, 0); err == nil {
+		t.Fatal("Trimmed the last good replica")
+	}
+
+	if err := con.Trim(p, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := con.PhysicalMove(p, DefaultResource, "archiveResc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if repls, _ := con.Replicas(p); len(repls) != 1 || repls[0].Resource != "archiveResc" || repls[0].Size != 8 {
+		t.Fatalf("Unexpected replicas after the move %+v", repls)
+	}
+
+	if err := con.PhysicalMove(p, DefaultResource, "archiveResc"); !IsCode(err, USER_FILE_DOES_NOT_EXIST) {
+		t.Fatalf("Expected USER_FILE_DOES_NOT_EXIST, got %v", err)
+	}
+
+	srv.CorruptReplica(p, 0, []byte("rotten"))
+
+	if sum, _ := con.ChecksumReplica(p, 0); sum == srv.checksum([]byte("new data")) {
+		t.Fatal("Checksum of the corrupted replica matches")
+	}
+}
+
+func TestFlakyConnection(t *testing.T) {
+	srv, alice := setup(t)
+	srv.CreateResource("archiveResc")
+
+	con := NewFlakyConnection(alice)
+
+	p := "/tempZone/home/alice/data.bin"
+	if err := con.Put(p, []byte("data"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	con.Fail("Replicate", p, 2)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := con.Replicate(p, "archiveResc"); err != ErrConnectionReset {
+			t.Fatalf("Attempt %v: expected ErrConnectionReset, got %v", attempt, err)
+		}
+	}
+
+	if repls, _ := con.Replicas(p); len(repls) != 1 {
+		t.Fatalf("Failed replication made replicas %+v", repls)
+	}
+
+	if err := con.Replicate(p, "archiveResc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if con.Calls("Replicate") != 3 || con.Calls() != 4 {
+		t.Fatalf("Counted %v replications and %v calls", con.Calls("Replicate"), con.Calls())
+	}
+}
+
+func TestACL(t *testing.T) {
+	srv, alice := setup(t)
+	srv.CreateUser("bob", UserType)
+	srv.CreateGroup("lab")
+	srv.AddToGroup("lab", "bob")
+
+	bob, _ := srv.Connect("bob")
+
+	p := "/tempZone/home/alice/shared.txt"
+	alice.Put(p, []byte("secret"), PutOptions{})
+
+	if _, err := bob.ReadFile(p); !IsCode(err, CAT_NO_ACCESS_PERMISSION) {
+		t.Fatalf("Expected CAT_NO_ACCESS_PERMISSION, got %v", err)
+	}
+
+	if err := alice.Chmod(p, "lab", Read, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.ReadFile(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bob.Put(p, []byte("mine"), PutOptions{Force: true}); !IsCode(err, CAT_NO_ACCESS_PERMISSION) {
+		t.Fatalf("Expected CAT_NO_ACCESS_PERMISSION, got %v", err)
+	}
+
+	alice.Mkdir("/tempZone/home/alice/inherit", false)
+	alice.Chmod("/tempZone/home/alice/inherit", "bob", Write, false)
+	alice.SetInheritance("/tempZone/home/alice/inherit", true, false)
+
+	if err := bob.Put("/tempZone/home/alice/inherit/from-bob.txt", []byte("hi"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, _ := alice.ACL("/tempZone/home/alice/inherit/from-bob.txt")
+	if len(acl) != 2 || acl[0].Principal != "alice#tempZone" || acl[1].AccessLevel != Own {
+		t.Fatalf("Unexpected ACL %+v", acl)
+	}
+
+	// Granular levels are ordered, read_metadata doesn't grant read and delete_object does
+	if err := alice.Chmod(p, "bob", ReadMetadata, false); err != nil {
+		t.Fatal(err)
+	}
+
+	alice.Chmod(p, "lab", Null, false)
+
+	if _, err := bob.ReadFile(p); !IsCode(err, CAT_NO_ACCESS_PERMISSION) {
+		t.Fatalf("Expected CAT_NO_ACCESS_PERMISSION, got %v", err)
+	}
+
+	alice.Chmod(p, "bob", DeleteObject, false)
+
+	if err := bob.Put(p, []byte("mine"), PutOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := alice.Chmod(p, "bob", GroupType, false); !IsCode(err, SYS_INVALID_INPUT_PARAM) {
+		t.Fatalf("Expected SYS_INVALID_INPUT_PARAM, got %v", err)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	_, con := setup(t)
+
+	con.Put("/tempZone/home/alice/a.txt", []byte("a"), PutOptions{})
+	con.Put("/tempZone/home/alice/b.txt", []byte("bb"), PutOptions{})
+	con.AddMeta("/tempZone/home/alice/a.txt", AVU{Attribute: "project", Value: "apollo"})
+	con.AddMeta("/tempZone/home/alice/a.txt", AVU{Attribute: "wordCount", Value: "12"})
+	con.AddMeta("/tempZone/home/alice/b.txt", AVU{Attribute: "wordCount", Value: "2"})
+	con.AddMeta("/tempZone/home/alice", AVU{Attribute: "project", Value: "apollo"})
+
+	objs, err := con.QueryMeta("wordCount > 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objs) != 1 || objs[0].Name != "a.txt" {
+		t.Fatalf("Unexpected results %+v", objs)
+	}
+
+	objs, _ = con.QueryMeta("project like 'apo%'")
+	if len(objs) != 2 || !objs[0].IsDir() {
+		t.Fatalf("Unexpected results %+v", objs)
+	}
+
+	rows, err := con.IQuest("select DATA_NAME, DATA_SIZE where COLL_NAME = '/tempZone/home/alice' and DATA_NAME like '%.txt'", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 || rows[1]["DATA_NAME"] != "b.txt" || rows[1]["DATA_SIZE"] != "2" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	rows, _ = con.IQuest("select DATA_NAME where META_DATA_ATTR_NAME = 'PROJECT'", true)
+	if len(rows) != 1 || rows[0]["DATA_NAME"] != "a.txt" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	rows, _ = con.IQuest("select USER_GROUP_NAME where USER_NAME = 'alice'", false)
+	if len(rows) != 1 || rows[0]["USER_GROUP_NAME"] != "public" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	rows, _ = con.IQuest("select ZONE_NAME, ZONE_TYPE", false)
+	if len(rows) != 1 || rows[0]["ZONE_NAME"] != "tempZone" || rows[0]["ZONE_TYPE"] != "local" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	rows, _ = con.IQuest("select DATA_NAME where COLL_NAME = '/tempZone/home' || like '/tempZone/home/al\\_ce%' and DATA_NAME = 'a.txt'", false)
+	if len(rows) != 0 {
+		t.Fatalf("Expected the escaped _ to only match itself, got %v", rows)
+	}
+
+	rows, total, err := con.IQuestPage("select DATA_NAME where COLL_NAME = '/tempZone/home' || like '/tempZone/home/ali%'", 1, 5)
+	if err != nil || total != 2 || len(rows) != 1 || rows[0]["DATA_NAME"] != "b.txt" {
+		t.Fatalf("Unexpected page %v of %v rows: %v", rows, total, err)
+	}
+}
@@ -3,15 +3,10 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
-	"unsafe"
 )
 
 // Group holds info about iRODS groups
@@ -159,18 +154,47 @@ func (grp *Group) init() error {
 // FindByName searches the slice (itself) and attempts to return a match based on name.
 // If no match is found, a new group with that name is created and returned.
 // This was designed to resolve issues of casting resources for DataObjects and Collections, even though the cache was empty due to permissions.
+// A name#zone name selects a group of a federated zone.
 func (grps Groups) FindByName(name string, con *Connection) *Group {
+	name, zone := SplitPrincipal(name)
+
+	return grps.FindByNameAndZone(name, zone, con)
+}
+
+// FindByNameAndZone searches the slice for a group by name and zone, an empty zone is the local zone.
+// If no match is found, a new group with that name and zone is created and returned.
+func (grps Groups) FindByNameAndZone(name string, zone string, con *Connection) *Group {
+	zne, err := findZone(zone, con)
+	if err != nil {
+		return nil
+	}
+
+	return grps.findByNameAndZone(name, zne, con)
+}
+
+// findByNameAndZone is FindByNameAndZone with the zone already resolved
+func (grps Groups) findByNameAndZone(name string, zne *Zone, con *Connection) *Group {
 	for _, grp := range grps {
-		if grp.name == name {
+		if grp.name == name && (grp.zone == nil || grp.zone.Name() == zne.Name()) {
 			return grp
 		}
 	}
 
-	grp, _ := initGroup(name, con)
+	grp, err := initGroup(name, con)
+	if err != nil {
+		return nil
+	}
+
+	grp.zone = zne
 
 	return grp
 }
 
+// Principal returns the group in name#zone form
+func (grp *Group) Principal() string {
+	return Principal(grp)
+}
+
 func (grps *Groups) Remove(index int) {
 	*grps = append((*grps)[:index], (*grps)[index+1:]...)
 }
@@ -240,109 +264,20 @@ func (grp *Group) RefreshUsers() error {
 
 // FetchInfo returns a map of fresh group info from the iCAT server
 func (grp *Group) FetchInfo() (map[string]string, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	cGroup := C.CString(grp.name)
-	defer C.free(unsafe.Pointer(cGroup))
-
-	ccon := grp.con.GetCcon()
-
-	if status := C.gorods_get_user(cGroup, ccon, &result, &err); status != 0 {
-		grp.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Group Info Failed: %v", C.GoString(err)))
-	}
-
-	grp.con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(map[string]string)
-
-	for _, groupInfo := range slice {
-
-		groupAttributes := strings.Split(strings.Trim(C.GoString(groupInfo), " \n"), "\n")
-
-		for _, attr := range groupAttributes {
-
-			split := strings.Split(attr, ": ")
-
-			attrName := split[0]
-			attrVal := split[1]
-
-			response[attrName] = attrVal
-
-		}
+	if grp.con.fs != nil {
+		return grp.con.fsPrincipalInfo(grp.name, grp.zone)
 	}
 
-	return response, nil
+	return grp.cFetchInfo()
 }
 
 // FetchUsers returns a slice of fresh *User from the iCAT server
 func (grp *Group) FetchUsers() (Users, error) {
-
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	cGroupName := C.CString(grp.name)
-	defer C.free(unsafe.Pointer(cGroupName))
-
-	ccon := grp.con.GetCcon()
-
-	if status := C.gorods_get_group(ccon, &result, cGroupName, &err); status != 0 {
-		grp.con.ReturnCcon(ccon)
-		if status == C.CAT_NO_ROWS_FOUND {
-			return make(Users, 0), nil
-		} else {
-
-			return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Group %v Failed: %v", grp.name, C.GoString(err)))
-		}
-
-	}
-
-	grp.con.ReturnCcon(ccon)
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	if usrs, err := grp.con.Users(); err == nil {
-		response := make(Users, 0)
-
-		for _, userNames := range slice {
-
-			usrFrags := strings.Split(C.GoString(userNames), "#")
-
-			if usr := usrs.FindByName(usrFrags[0], grp.con); usr != nil {
-				response = append(response, usr)
-			} else {
-				return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchUsers Failed: User in response not found in cache"))
-			}
-
-		}
-
-		return response, nil
-	} else {
-		return nil, err
+	if grp.con.fs != nil {
+		return grp.fsFetchUsers()
 	}
 
+	return grp.cFetchUsers()
 }
 
 // AddUser adds an iRODS uset to the group. Accepts a string or *User struct.
@@ -356,8 +291,7 @@ func (grp *Group) AddUser(usr interface{}) error {
 			usrName := usr.(string)
 
 			if existingUsr := usrs.FindByName(usrName, grp.con); existingUsr != nil {
-				zoneName := existingUsr.zone
-				return addToGroup(usrName, zoneName, grp.name, grp.con)
+				return addToGroup(existingUsr.name, existingUsr.zone, grp.name, grp.con)
 			} else {
 				return newError(Fatal, -1, fmt.Sprintf("iRODS AddUser Failed: can't find iRODS user by string"))
 			}
@@ -384,8 +318,7 @@ func (grp *Group) RemoveUser(usr interface{}) error {
 			usrName := usr.(string)
 
 			if existingUsr := usrs.FindByName(usrName, grp.con); existingUsr != nil {
-				zoneName := existingUsr.zone
-				return removeFromGroup(usrName, zoneName, grp.name, grp.con)
+				return removeFromGroup(existingUsr.name, existingUsr.zone, grp.name, grp.con)
 			} else {
 				return newError(Fatal, -1, fmt.Sprintf("iRODS RemoveUser Failed: can't find iRODS user by string"))
 			}
@@ -448,86 +381,33 @@ func (grp *Group) Meta() (*MetaCollection, error) {
 }
 
 func addToGroup(userName string, zone *Zone, groupName string, con *Connection) error {
-
-	var (
-		err *C.char
-	)
-
-	cUserName := C.CString(userName)
-	cZoneName := C.CString(zone.Name())
-	cGroupName := C.CString(groupName)
-	defer C.free(unsafe.Pointer(cUserName))
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cGroupName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_add_user_to_group(cUserName, cZoneName, cGroupName, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS AddToGroup %v Failed: %v", groupName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("AddToGroup")
 	}
 
-	return nil
+	return cAddToGroup(userName, zone, groupName, con)
 }
 
 func removeFromGroup(userName string, zone *Zone, groupName string, con *Connection) error {
-	var (
-		err *C.char
-	)
-
-	cUserName := C.CString(userName)
-	cZoneName := C.CString(zone.Name())
-	cGroupName := C.CString(groupName)
-	defer C.free(unsafe.Pointer(cUserName))
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cGroupName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_remove_user_from_group(cUserName, cZoneName, cGroupName, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS AddToGroup %v Failed: %v", groupName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("RemoveFromGroup")
 	}
 
-	return nil
+	return cRemoveFromGroup(userName, zone, groupName, con)
 }
 
 func deleteGroup(groupName string, zone *Zone, con *Connection) error {
-	var (
-		err *C.char
-	)
-
-	cZoneName := C.CString(zone.Name())
-	cGroupName := C.CString(groupName)
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cGroupName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_delete_group(cGroupName, cZoneName, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS DeleteGroup %v Failed: %v", groupName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("DeleteGroup")
 	}
 
-	return nil
+	return cDeleteGroup(groupName, zone, con)
 }
 
 func createGroup(groupName string, zone *Zone, con *Connection) error {
-	var (
-		err *C.char
-	)
-
-	cZoneName := C.CString(zone.Name())
-	cGroupName := C.CString(groupName)
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cGroupName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_create_group(cGroupName, cZoneName, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS CreateGroup %v Failed: %v", groupName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("CreateGroup")
 	}
 
-	return nil
+	return cCreateGroup(groupName, zone, con)
 }
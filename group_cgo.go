@@ -0,0 +1,210 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+func (grp *Group) cFetchInfo() (map[string]string, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	var zoneName string
+	if grp.zone != nil {
+		zoneName = grp.zone.Name()
+	}
+
+	cGroup := C.CString(grp.name)
+	cZone := C.CString(zoneName)
+	defer C.free(unsafe.Pointer(cGroup))
+	defer C.free(unsafe.Pointer(cZone))
+
+	ccon := grp.con.GetCcon()
+
+	if status := C.gorods_get_user(cGroup, cZone, ccon, &result, &err); status != 0 {
+		grp.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Group Info Failed: %v", C.GoString(err)))
+	}
+
+	grp.con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(map[string]string)
+
+	for _, groupInfo := range slice {
+
+		groupAttributes := strings.Split(strings.Trim(C.GoString(groupInfo), " \n"), "\n")
+
+		for _, attr := range groupAttributes {
+
+			split := strings.Split(attr, ": ")
+
+			attrName := split[0]
+			attrVal := split[1]
+
+			response[attrName] = attrVal
+
+		}
+	}
+
+	return response, nil
+}
+
+func (grp *Group) cFetchUsers() (Users, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	cGroupName := C.CString(grp.name)
+	defer C.free(unsafe.Pointer(cGroupName))
+
+	ccon := grp.con.GetCcon()
+
+	if status := C.gorods_get_group(ccon, &result, cGroupName, &err); status != 0 {
+		grp.con.ReturnCcon(ccon)
+		if status == C.CAT_NO_ROWS_FOUND {
+			return make(Users, 0), nil
+		} else {
+
+			return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Group %v Failed: %v", grp.name, C.GoString(err)))
+		}
+
+	}
+
+	grp.con.ReturnCcon(ccon)
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	if usrs, err := grp.con.Users(); err == nil {
+		response := make(Users, 0)
+
+		for _, userNames := range slice {
+
+			// Members are listed as name#zone, members of federated zones keep their zone
+			if usr := usrs.FindByName(strings.TrimSpace(C.GoString(userNames)), grp.con); usr != nil {
+				response = append(response, usr)
+			} else {
+				return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchUsers Failed: User in response not found in cache"))
+			}
+
+		}
+
+		return response, nil
+	} else {
+		return nil, err
+	}
+
+}
+
+func cAddToGroup(userName string, zone *Zone, groupName string, con *Connection) error {
+	var (
+		err *C.char
+	)
+
+	cUserName := C.CString(userName)
+	cZoneName := C.CString(zone.Name())
+	cGroupName := C.CString(groupName)
+	defer C.free(unsafe.Pointer(cUserName))
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cGroupName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_add_user_to_group(cUserName, cZoneName, cGroupName, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS AddToGroup %v Failed: %v", groupName, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func cRemoveFromGroup(userName string, zone *Zone, groupName string, con *Connection) error {
+	var (
+		err *C.char
+	)
+
+	cUserName := C.CString(userName)
+	cZoneName := C.CString(zone.Name())
+	cGroupName := C.CString(groupName)
+	defer C.free(unsafe.Pointer(cUserName))
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cGroupName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_remove_user_from_group(cUserName, cZoneName, cGroupName, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS AddToGroup %v Failed: %v", groupName, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func cDeleteGroup(groupName string, zone *Zone, con *Connection) error {
+	var (
+		err *C.char
+	)
+
+	cZoneName := C.CString(zone.Name())
+	cGroupName := C.CString(groupName)
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cGroupName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_delete_group(cGroupName, cZoneName, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS DeleteGroup %v Failed: %v", groupName, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func cCreateGroup(groupName string, zone *Zone, con *Connection) error {
+	var (
+		err *C.char
+	)
+
+	cZoneName := C.CString(zone.Name())
+	cGroupName := C.CString(groupName)
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cGroupName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_create_group(cGroupName, cZoneName, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS CreateGroup %v Failed: %v", groupName, C.GoString(err)))
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+func (grp *Group) cFetchInfo() (map[string]string, error) {
+	return nil, errNoC
+}
+
+func (grp *Group) cFetchUsers() (Users, error) {
+	return nil, errNoC
+}
+
+func cAddToGroup(userName string, zone *Zone, groupName string, con *Connection) error {
+	return errNoC
+}
+
+func cRemoveFromGroup(userName string, zone *Zone, groupName string, con *Connection) error {
+	return errNoC
+}
+
+func cDeleteGroup(groupName string, zone *Zone, con *Connection) error {
+	return errNoC
+}
+
+func cCreateGroup(groupName string, zone *Zone, con *Connection) error {
+	return errNoC
+}
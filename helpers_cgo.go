@@ -0,0 +1,65 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+func cTimeToTime(cTime *C.char) time.Time {
+	unixStamp, _ := strconv.ParseInt(C.GoString(cTime), 10, 64)
+	return time.Unix(unixStamp, 0)
+}
+
+func aclSliceToResponse(result *C.goRodsACLResult_t, local *Zone, con *Connection) (ACLs, error) {
+	defer C.gorods_free_acl_result(result)
+
+	unsafeArr := unsafe.Pointer(result.aclArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.goRodsACL_t
+	slice := (*[1 << 30]C.goRodsACL_t)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(ACLs, 0)
+
+	for _, acl := range slice {
+
+		typeString := C.GoString(acl.acltype)
+
+		typeMap := map[string]int{
+			"rodsgroup":  GroupType,
+			"rodsuser":   UserType,
+			"rodsadmin":  AdminType,
+			"groupadmin": GroupAdminType,
+		}
+		var aclType int = typeMap[typeString]
+		if aclType == 0 {
+			aclType = UnknownType
+		}
+
+		// Catalogs before iRODS 4.3 name levels "read object", 4.3 "read_object"
+		accessLevel, err := ParseAccessLevel(C.GoString(acl.dataAccess))
+		if err != nil {
+			accessLevel = Null
+		}
+
+		entry, err := newACL(C.GoString(acl.name), C.GoString(acl.zone), aclType, accessLevel, local, con)
+		if err != nil {
+			return nil, err
+		}
+
+		response = append(response, entry)
+
+	}
+
+	return response, nil
+}
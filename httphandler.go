@@ -3,11 +3,9 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -20,25 +18,86 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// FileServer returns a handler that serves the iRODS collection at opts.Path. If opts.CollectionView
+// is set and fails to parse, the error is logged and every request is answered with a 500 error, use
+// NewFileServer to get the error instead.
 func FileServer(opts FSOptions) http.Handler {
+	h, err := NewFileServer(opts)
+	if err != nil {
+		log.Print(err)
+
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			http.Error(response, err.Error(), http.StatusInternalServerError)
+		})
+	}
+
+	return h
+}
+
+// NewFileServer returns a handler that serves the iRODS collection at opts.Path, or the error of
+// parsing opts.CollectionView
+func NewFileServer(opts FSOptions) (http.Handler, error) {
 	h := new(HandlerFactory)
+
+	if opts.UploadStore == nil {
+		opts.UploadStore = NewMemoryUploadStore()
+	}
+
+	if opts.ThumbnailCache == nil {
+		opts.ThumbnailCache = NewMemoryThumbnailCache(1024)
+	}
+
+	switch {
+	case opts.Template != nil:
+		h.tpl = opts.Template
+	case opts.CollectionView != "":
+		tpl, err := template.New("collection.html").Funcs(TemplateFuncs()).Parse(opts.CollectionView)
+		if err != nil {
+			return nil, fmt.Errorf("gorods: parsing FSOptions.CollectionView: %v", err)
+		}
+
+		h.tpl = tpl
+	default:
+		h.tpl = DefaultCollectionTemplate()
+	}
+
 	h.opts = opts
-	return h
+	return h, nil
 }
 
+// FSOptions configures the FileServer. UploadStore holds the state of resumable uploads,
+// an in-memory store is used if it's nil.
+//
+// Template renders collection listings, it's executed with a *CollectionView. When it's nil
+// CollectionView is parsed as the template source instead, and when both are empty the
+// built-in template is used. PageSize is the number of entries per page of a listing.
+//
+// PreviewBytes is how much of a text data object is previewed. Thumbnails are cached in
+// ThumbnailCache (in memory if it's nil), and also stored in iRODS next to their images when
+// StoreThumbnails is set. Images larger than MaxThumbnailSource bytes get no thumbnail.
 type FSOptions struct {
-	Client         *Client
-	Connection     *Connection
-	Path           string
-	Download       bool
-	StripPrefix    string
-	CollectionView string
+	Client             *Client
+	Connection         *Connection
+	Path               string
+	Download           bool
+	StripPrefix        string
+	CollectionView     string
+	Template           *template.Template
+	PageSize           int
+	UploadStore        UploadStore
+	PreviewBytes       int
+	ThumbnailCache     ThumbnailCache
+	StoreThumbnails    bool
+	MaxThumbnailSource int64
 }
 
 type HandlerFactory struct {
-	opts FSOptions
+	opts        FSOptions
+	tpl         *template.Template
+	uploadLocks uploadLocks
 }
 
 func (hf *HandlerFactory) ServeHTTP(response http.ResponseWriter, request *http.Request) {
@@ -49,10 +108,9 @@ func (hf *HandlerFactory) ServeHTTP(response http.ResponseWriter, request *http.
 	handler.connection = hf.opts.Connection
 	handler.path = strings.TrimRight(hf.opts.Path, "/")
 	handler.opts = hf.opts
-
-	if handler.opts.CollectionView != "" {
-		tpl = string(handler.opts.CollectionView)
-	}
+	handler.uploads = hf.opts.UploadStore
+	handler.uploadLocks = &hf.uploadLocks
+	handler.tpl = hf.tpl
 
 	handler.ServeHTTP(response, request)
 
@@ -64,6 +122,10 @@ type HttpHandler struct {
 	path       string
 	opts       FSOptions
 
+	tpl         *template.Template
+	uploads     UploadStore
+	uploadLocks *uploadLocks
+
 	response    http.ResponseWriter
 	request     *http.Request
 	handlerPath string
@@ -77,848 +139,6 @@ var check func(error) = func(err error) {
 	}
 }
 
-var tpl = `
-<!DOCTYPE html>
-<html lang="en">
-<head>
-	<meta charset="utf-8">
-	<title>Collection: {{.Path}}</title>
-	
-	<!-- Latest compiled and minified CSS -->
-	<link rel="stylesheet" href="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.7/css/bootstrap.min.css" integrity="sha384-BVYiiSIFeK1dGmJRAkycuHAHRg32OmUcww7on3RYdg4Va+PmSTsz/K68vbdEjh4u" crossorigin="anonymous">
-
-	<!-- Optional theme -->
-	<link rel="stylesheet" href="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.7/css/bootstrap-theme.min.css" integrity="sha384-rHyoN1iRsVXV4nD0JutlnGaslCJuC7uwjduW9SVrLvRYooPp2bWYgmgJQIXwl/Sp" crossorigin="anonymous">
-
-	<script src="https://code.jquery.com/jquery-3.1.1.min.js" integrity="sha256-hVVnYaiADRTO2PzUGmuLJr8BLUSjGIZsDYGmIJLv2b8=" crossorigin="anonymous"></script>
-
-	<!-- Latest compiled and minified JavaScript -->
-	<script src="https://maxcdn.bootstrapcdn.com/bootstrap/3.3.7/js/bootstrap.min.js" integrity="sha384-Tc5IQib027qvyjSMfHjOMaLkfuWVxZxUPnCJA7l2mCWNIpG9mGCD8wGNIcPD7Txa" crossorigin="anonymous"></script>
-
-	<style type="text/css">
-	.table td.fit, 
-	.table th.fit {
-	    white-space: nowrap;
-	    width: 1%;
-	}
-	a {
-		cursor: pointer;
-	}
-	.modal-dialog {
-		margin-top: 100px;
-	}
-	.meta-del {
-		color: red;
-		cursor: pointer;
-		font-size: 18px;
-	}
-	.li-sep {
-		line-height: 50px;
-		color:#777;
-	}
-	.li-sep:first-child {
-		display: none;
-	}
-
-	.prog-bar {
-		width: 0%;
-	    height: 3px;
-	    background-color: #337ab7;
-	    position: absolute;
-	    top: 0px;
-	    z-index: 9999;
-	}
-	</style>
-
-	<script type="text/javascript">
-
-	var me = {{ .Con.Options.Username }};
-	var users = {{ usersJSON }};
-	var groups = {{ groupsJSON }};
-
-	function escapeHtml(text) {
-	    'use strict';
-	    return text.replace(/[\"&<>]/g, function (a) {
-	        return { '"': '&quot;', '&': '&amp;', '<': '&lt;', '>': '&gt;' }[a];
-	    });
-	}
-
-	$(function() {
-
-		var chmod = function(objName, formData, inctx) {
-
-			var ajaxPath = document.location.pathname + objName + "?createacl=1";
-
-			$.post(ajaxPath, formData, function(response, status) {
-				if ( response.Success == true ) {
-					var ctx = inctx.parent();
-
-					$(".acl-name", inctx).val("");
-					$(".acl-access", inctx).val("");
-
-					refreshMetas(objName, ctx);
-				} else {
-					alert("An error has occured: " + response.Message);
-				}
-			});
-		};
-
-
-		var createCollectionHandler = function() {
-
-			var colInput = $('.collection-name', $(this).parent().parent());
-
-			var colName = colInput.val();
-
-			var ajaxPath = document.location.pathname + "?createcol=1";
-
-			$.post(ajaxPath, {colname: colName}, function(response, status) {
-				if ( response.Success == true ) {
-					document.location.reload(true);
-				} else {
-					alert("An error has occured: " + response.Message);
-				}
-			});
-			
-		};
-
-		$('[data-toggle="popover"]').popover({
-			content: function(){
-				var cont = $($(this).data('contentwrapper'));
-
-		        return cont.html();
-		    },
-			html: true
-		}).on('shown.bs.popover', function() {
-			var pID = $(this).attr("aria-describedby");
-
-			$('.create-collection', $("#" + pID)).on('click', createCollectionHandler);
-		});
-		
-
-		var deleteMetaHandler = function(objname) {
-			return function() {	
-				var self = $(this);
-
-				var tr = self.parent().parent();
-
-				var tds = $("td", tr);
-
-				var a = tds.eq(0).text();
-				var v = tds.eq(1).text();
-				var u = tds.eq(2).text();
-
-				var ajaxPath = document.location.pathname + objname + "?deletemeta=1";
-
-				var formData = {
-					"attribute": a,
-					"value": v,
-					"units": u
-				};
-
-				$.post(ajaxPath, formData, function(response, status) {
-					if ( response.Success == true ) {
-						var ctx = tr.parent().parent().parent();
-
-						refreshMetas(objname, ctx);
-					} else {
-						alert("An error has occured: " + response.Message);
-					}
-				});
-
-			};
-		};
-
-		var refreshMetas = function(objname, ctx, done) {
-			var ajaxPath = document.location.pathname + objname + "?meta=1";
-
-			$.ajax({
-				"url": ajaxPath,
-				"complete": function(response, status) {
-					var metaTbl = $(".meta-tbl tbody", ctx).html("");
-					var aclTbl = $(".acl-tbl tbody", ctx).html("");
-
-
-					if ( Object.keys(response.responseJSON).length != 0 ) {
-
-						var metaData = response.responseJSON.metadata;
-						var aclData = response.responseJSON.acl;
-						var statData = response.responseJSON.stat[0];
-
-						$('.chksum', ctx).text(statData.chksum);
-						$('.createTime', ctx).text((new Date(parseInt(statData.createTime) * 1000)).toLocaleString());
-						$('.modifyTime', ctx).text((new Date(parseInt(statData.modifyTime) * 1000)).toLocaleString());
-						$('.dataId', ctx).text(statData.dataId);
-						$('.dataMode', ctx).text(statData.dataMode);
-						$('.objSize', ctx).text(statData.objSize);
-						$('.ownerName', ctx).text(statData.ownerName);
-						$('.ownerZone', ctx).text(statData.ownerZone);
-
-
-						if ( metaData.length > 0 ) {
-							for ( var n = 0; metaData.length > n; n++ ) {
-								metaTbl.append('<tr><td>' + escapeHtml(metaData[n].attribute) + '</td><td>' + escapeHtml(metaData[n].value) + '</td><td>' + escapeHtml(metaData[n].units) + '</td><td style="text-align:right;"><span class="meta-del glyphicon glyphicon-remove-circle"></span></td></tr>');
-							}
-						} else {
-							metaTbl.append('<tr><td colspan="4" style="text-align:center;">No Metadata Found</td></tr>');
-						}
-						$(".meta-del", metaTbl).click(deleteMetaHandler(objname));
-
-						for ( var n = 0; aclData.length > n; n++ ) {
-							var userGroupSelect;
-
-							if ( users.length == 0 ) {
-								userGroupSelect = $('<input class="acl-name form-control" disabled value="' + escapeHtml(aclData[n].name) + '">');
-							} else {
-								userGroupSelect = $('<select class="acl-name form-control" data-prev="' + escapeHtml(aclData[n].name) + '"></select>');
-								for ( var i in users ) {
-									if ( users[i] == aclData[n].name ) {
-										userGroupSelect.append('<option selected value="' + escapeHtml(users[i]) + '">User: ' + escapeHtml(users[i]) + '</option>');
-									} else {
-										userGroupSelect.append('<option value="' + escapeHtml(users[i]) + '">User: ' + escapeHtml(users[i]) + '</option>');
-									}
-								}
-								for ( var i in groups ) {
-									if ( groups[i] == aclData[n].name ) {
-										userGroupSelect.append('<option selected value="' + escapeHtml(groups[i]) + '">Group: ' + escapeHtml(groups[i]) + '</option>');
-									} else {
-										userGroupSelect.append('<option value="' + escapeHtml(groups[i]) + '">Group: ' + escapeHtml(groups[i]) + '</option>');
-									}
-								}
-							}
-							
-
-							var accessLevels = ['read', 'write', 'own', 'null'];
-							var accessLevelSelect = $('<select class="access-level form-control"></select>');
-							for ( var i in accessLevels ) {
-								if ( accessLevels[i] == aclData[n].accessLevel ) {
-									accessLevelSelect.append('<option selected value="' + escapeHtml(accessLevels[i]) + '">' + escapeHtml(accessLevels[i]) + '</option>');
-								} else {
-									accessLevelSelect.append('<option value="' + escapeHtml(accessLevels[i]) + '">' + escapeHtml(accessLevels[i]) + '</option>');
-								}
-							}
-
-							aclTbl.append('<tr><td>' + userGroupSelect[0].outerHTML + '</td><td>' + escapeHtml(aclData[n].type) + '</td><td>' + accessLevelSelect[0].outerHTML+ '</td></tr>');
-
-
-							$(".access-level", aclTbl).change(function() {
-								var self = $(this);
-
-								var tr = self.parent().parent();
-
-								var formData = {
-									name: $(".acl-name", tr).val(),
-									access: self.val()
-								};
-
-								var f = $("form", tr.parent().parent().parent().parent());
-
-								chmod(objname, formData, f);
-
-							});
-
-							$("select.acl-name", aclTbl).change(function() {
-								var self = $(this);
-
-								var tr = self.parent().parent();
-
-								var formData = {
-									name: self.val(),
-									access: $(".access-level", tr).val()
-								};
-
-								var f = $("form", tr.parent().parent().parent().parent());
-
-								chmod(objname, formData, f);
-
-								if ( self.attr("data-prev") != self.val() ) {
-									formData = {
-										name: self.attr("data-prev"),
-										access: "null"
-									};
-
-									chmod(objname, formData, f);
-								}
-							});
-						}
-					} else {
-						aclTbl.append('<tr><td colspan="3" style="text-align:center;">Error Fetching ACLs</td></tr>');
-						metaTbl.append('<tr><td colspan="4" style="text-align:center;">Error Fetching Metadata</td></tr>');
-					}
-
-					if ( done ) done();
-
-					
-				}
-			});
-		};
-
-		$('.upload-btn').click(function() {
-
-			var form = $(document.createElement('form'));
-			var input = $(document.createElement('input'));
-
-	        input.attr("type", "file").attr("name", "data");
-
-	        form.append(input);
-
-	        input.trigger('click');
-
-	        input.change(function() {
-	        	var ajaxPath = document.location.pathname + "?upload=1";
-
-	        	var formData = new FormData(form[0]);
-
-	        	var progBar = $(".prog-bar");
-	        	progBar.show();
-
-	        	$.ajax({
-	                url: ajaxPath,
-	                type: 'POST',
-	                xhr: function() {
-	                    myXhr = $.ajaxSettings.xhr();
-	                    if ( myXhr.upload ) {
-	                        myXhr.upload.addEventListener('progress', function(ev) {
-	                        	var percentComplete = (ev.loaded / ev.total) * 100;
-
-	                        	progBar.animate({
-	                        		width: percentComplete + "%"
-	                        	}, 100);
-
-	                        }, false);
-	                    }
-	                    return myXhr;
-	                },
-	                success: function(response) {
-	                    document.location.reload(true);
-	                },
-	                error: function(response) {
-	                   	alert("An error has occured: " + response.Message);
-	                },
-	                data: formData,
-	                cache: false,
-	                contentType: false,
-	                processData: false
-            	}, 'json');
-
-	        });
-	        
-		});
-
-		$('.show-meta-modal').click(function() {
-			var self = $(this);
-
-			var objName = self.attr("data-objname");
-			var ctx = self.parent().parent();
-
-			refreshMetas(objName, ctx, function() {
-				$('.modal', ctx).modal('show');
-			});
-			
-			
-		});
-
-		$('.delete-obj').click(function() {
-			var self = $(this);
-
-			if ( !confirm("Are you sure you want to delete this iRODS object? This action cannot be undone.") ) {
-				return;
-			}
-
-			var objName = self.attr("data-objname");
-
-			var ajaxPath = document.location.pathname + objName + "?delete=1";
-
-			$.post(ajaxPath, {}, function(response, status) {
-				if ( response.Success == true ) {
-					document.location.reload(true);
-				} else {
-					alert("An error has occured: " + response.Message);
-				}
-			});
-		});
-
-		$('.acl-form').submit(function(e) {
-			var self = $(this);
-
-			e.preventDefault();
-
-			var objName = self.attr("data-objname");
-
-			var formData = {
-				name: $(".acl-name", self).val(),
-				access: $(".acl-access", self).val()
-			};
-
-			chmod(objName, formData, self);
-		});
-
-		$('.avu-form').submit(function(e) {
-			
-			e.preventDefault();
-
-			var self = $(this);
-
-			var objName = self.attr("data-objname");
-			var ajaxPath = document.location.pathname + objName + "?meta=1";
-
-			var formData = {
-				"attribute": $(".avu-attribute", self).val(),
-				"value": $(".avu-value", self).val(),
-				"units": $(".avu-units", self).val()
-			};
-
-			$.post(ajaxPath, formData, function(response, status) {
-				if ( response.Success == true ) {
-					var ctx = self.parent();
-
-					$(".avu-attribute", self).val("");
-					$(".avu-value", self).val("");
-					$(".avu-units", self).val("");
-
-					refreshMetas(objName, ctx);
-				} else {
-					alert("An error has occured: " + response.Message);
-				}
-			});
-
-		});
-		
-	});
-
-	</script>
-
-</head>
-<body>
-	<div class="prog-bar" style="display: none;"></div>
-	<nav class="navbar navbar-default navbar-fixed-top">
-		<div class="container">
-			<div class="navbar-header">
-				<a class="navbar-brand" href="#">GoRODS HTTP File Server</a>
-			</div>
-			<div id="navbar" class="navbar-collapse collapse">
-				<ul class="nav navbar-nav navbar-right">
-					{{range headerLinks}}
-						<li class="li-sep">&gt;</li>
-						<li><a href="{{ index . "url" }}">{{ index . "name" }}</a></li>
-					{{end}}
-				</ul>
-			</div><!--/.nav-collapse -->
-		</div>
-	</nav>
-
-	<div class="container">
-		<br /><br /><br />
-
-		<div style="float:right;margin-top: 8px;" class="btn-group" role="group">
-			<button type="button" class="btn btn-default" data-container="body" data-contentwrapper="#create-collection-cont" data-toggle="popover" data-placement="bottom">Create Collection</button>
-			<button type="button" class="btn btn-default upload-btn">Upload Data Object</button>
-		</div>
-
-		<h4 style="margin-top:15px;">{{.Path}}</h4>
-
-		<table class="table table-hover">
-			<thead>
-				<tr>
-					<th>Name</th>
-					<th>Size</th>
-					<th>Type</th>
-					<th style="width:8%;"></th>
-				</tr>
-			</thead>
-			<tbody>
-				{{ $length := len headerLinks }}{{ if ne $length 0 }}
-					<tr>
-						<th><a href="..">..</a></th>
-						<td></td>
-						<td>Collection</td>
-						<td></td>
-					</tr>
-				{{ end }}
-				{{range .Collections}}
-					<tr>
-						<th><a href="{{.Name}}/">{{.Name}}</a></th>
-						<td>{{prettySize .Size}}</td>
-						<td>Collection</td>
-						<td>
-							<div style="text-align:right;">
-								<span style="cursor:pointer;color:#337ab7;margin-right:10px;" data-objname="{{.Name}}/" class="glyphicon glyphicon-th-list show-meta-modal"></span>
-								<span class="glyphicon glyphicon-remove delete-obj" style="color:red;cursor:pointer;" data-objname="{{.Name}}/"></span>
-							</div>
-
-							<!-- Modal -->
-							<div class="modal fade" tabindex="-1" role="dialog" aria-labelledby="myModalLabel">
-								<div class="modal-dialog" role="document">
-									<div class="modal-content">
-										<div class="modal-header">
-											<button type="button" class="close" data-dismiss="modal" aria-label="Close"><span aria-hidden="true">&times;</span></button>
-											<h4 class="modal-title" class="myModalLabel">Collection "{{.Name}}"</h4>
-										</div>
-										<div class="modal-body">
-
-											<ul class="nav nav-tabs">
-												<li class="active"><a data-toggle="tab" data-target=".stat-cont">Stat</a></li>
-												<li><a data-toggle="tab" data-target=".metadata-cont">Metadata</a></li>
-												<li><a data-toggle="tab" data-target=".acl-cont">ACL</a></li>
-											</ul>
-
-											<div class="tab-content">
-												<div class="stat-cont tab-pane fade in active">
-													<br />
-													<table class="table table-hover">
-													<tbody>
-														<tr>
-															<td>
-																Checksum:
-															</td>
-															<td class="chksum">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Created At:
-															</td>
-															<td class="createTime">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Modified At:
-															</td>
-															<td class="modifyTime">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Data ID:
-															</td>
-															<td class="dataId">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Data Mode:
-															</td>
-															<td class="dataMode">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Object Size (bytes):
-															</td>
-															<td class="objSize">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Owner:
-															</td>
-															<td class="ownerName">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Zone:
-															</td>
-															<td class="ownerZone">
-																
-															</td>
-														</tr>
-													</tbody>
-													</table>
-												</div>
-												<div class="metadata-cont tab-pane fade">
-													<br />
-													<table class="table table-hover meta-tbl">
-													<thead>
-														<tr>
-															<th>Attribute</th>
-															<th>Value</th>
-															<th>Units</th>
-															<th style="width:1%;"></th>
-														</tr>
-													</thead>
-													<tbody>
-													</tbody>
-													</table>
-													<form class="form-inline avu-form" data-objname="{{.Name}}/">
-														<div class="form-group">
-															<div class="input-group" style="width: 84%;">
-																<div class="input-group-addon">A:</div>
-																<input type="text" class="form-control avu-attribute" placeholder="Attribute" style="border-right: 0;">
-																<div class="input-group-addon">V:</div>
-																<input type="text" class="form-control avu-value" placeholder="Value" style="border-left: 0; border-right:0;">
-																<div class="input-group-addon">U:</div>
-																<input type="text" class="form-control avu-units" placeholder="Units" style="border-left: 0;">
-															</div>
-															<button type="submit" class="add-avu btn btn-primary">Add AVU</button>
-														</div>
-													</form>
-												</div>
-												<div class="acl-cont tab-pane fade">
-													<br />
-													<table class="table table-hover acl-tbl">
-													<thead>
-														<tr>
-															<th>Name</th>
-															<th>Type</th>
-															<th style="min-width:16%;">Access Level</th>
-														</tr>
-													</thead>
-													<tbody>
-														
-													</tbody>
-													</table>
-													<form class="form-inline acl-form" data-objname="{{.Name}}/">
-														<div class="form-group" style="width:100%;">
-															<div class="input-group" style="width: 77%;">
-																<div class="input-group-addon">User/Group:</div>
-																{{ if ne (len .Con.Users) 0 }}
-																	<select class="form-control acl-name" style="margin-left:-3px;border-radius: 4px;margin-right:5px;">
-																		<option></option>
-																		
-																		{{ range .Con.Users }}
-																			<option value="{{ .Name }}">User: {{ .Name }}</option>
-																		{{ end }}
-																		{{ range .Con.Groups }}
-																			<option value="{{ .Name }}">Group: {{ .Name }}</option>
-																		{{ end }}
-																		
-																	</select>
-																{{ else }}
-																	<input type="text" class="form-control acl-name" style="margin-left:-3px;border-radius: 4px;margin-right:5px;">
-																{{ end }}
-																<div class="input-group-addon" style="border-radius: 4px;">Access:</div>
-																<select class="form-control acl-access" style="margin-left:-6px;">
-																	<option></option>
-																	<option value="read">read</option>
-																	<option value="write">write</option>
-																	<option value="own">own</option>
-																	<option value="null">revoke</option>
-																</select>
-															</div>
-															<button type="submit" class="add-acl btn btn-primary">Modify Access</button>
-														</div>
-													</form>
-												</div>
-											</div>
-										</div>
-										<div class="modal-footer">
-											<button type="button" class="btn btn-default" data-dismiss="modal">Close</button>
-										</div>
-									</div>
-								</div>
-							</div>
-						</td>
-					</tr>
-				{{end}}
-				{{range .DataObjs}}
-					<tr>
-						<th><a href="{{.Name}}">{{.Name}}</a></th>
-						<td>{{prettySize .Size}}</td>
-						<td>Data Object</td>
-						<td>
-
-							<div style="text-align:right;">
-								<a href="{{.Name}}?download=1"><span style="margin-right:10px;" class="glyphicon glyphicon-download-alt"></span></a>
-								<span style="cursor:pointer;color:#337ab7;margin-right:10px;" data-objname="{{.Name}}" class="glyphicon glyphicon-th-list show-meta-modal"></span>
-								<span class="glyphicon glyphicon-remove delete-obj" style="color:red;cursor:pointer;" data-objname="{{.Name}}"></span>
-							</div>
-
-							<!-- Modal -->
-							<div class="modal fade" tabindex="-1" role="dialog" aria-labelledby="myModalLabel">
-								<div class="modal-dialog" role="document">
-									<div class="modal-content">
-										<div class="modal-header">
-											<button type="button" class="close" data-dismiss="modal" aria-label="Close"><span aria-hidden="true">&times;</span></button>
-											<h4 class="modal-title" id="myModalLabel">Data Object "{{.Name}}"</h4>
-										</div>
-										<div class="modal-body">
-											<ul class="nav nav-tabs">
-												<li class="active"><a data-toggle="tab" data-target=".stat-cont">Stat</a></li>
-												<li><a data-toggle="tab" data-target=".metadata-cont">Metadata</a></li>
-												<li><a data-toggle="tab" data-target=".acl-cont">ACL</a></li>
-											</ul>
-
-											<div class="tab-content">
-												<div class="stat-cont tab-pane fade in active">
-													<br />
-													<table class="table table-hover">
-													<tbody>
-														<tr>
-															<td>
-																Checksum:
-															</td>
-															<td class="chksum">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Created At:
-															</td>
-															<td class="createTime">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Modified At:
-															</td>
-															<td class="modifyTime">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Data ID:
-															</td>
-															<td class="dataId">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Data Mode:
-															</td>
-															<td class="dataMode">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Object Size (bytes):
-															</td>
-															<td class="objSize">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Owner:
-															</td>
-															<td class="ownerName">
-																
-															</td>
-														</tr>
-														<tr>
-															<td>
-																Zone:
-															</td>
-															<td class="ownerZone">
-																
-															</td>
-														</tr>
-													</tbody>
-													</table>
-												</div>
-												<div class="metadata-cont tab-pane fade">
-													<br />
-													<table class="table table-hover meta-tbl">
-													<thead>
-														<tr>
-															<th>Attribute</th>
-															<th>Value</th>
-															<th>Units</th>
-															<th style="width:1%;"></th>
-														</tr>
-													</thead>
-													<tbody>
-														
-													</tbody>
-													</table>
-													<form class="form-inline avu-form" data-objname="{{.Name}}">
-														<div class="form-group">
-															<div class="input-group" style="width: 84%;">
-																<div class="input-group-addon">A:</div>
-																<input type="text" class="form-control avu-attribute" placeholder="Attribute" style="border-right: 0;">
-																<div class="input-group-addon">V:</div>
-																<input type="text" class="form-control avu-value" placeholder="Value" style="border-left: 0; border-right:0;">
-																<div class="input-group-addon">U:</div>
-																<input type="text" class="form-control avu-units" placeholder="Units" style="border-left: 0;">
-															</div>
-															<button type="submit" class="add-avu btn btn-primary">Add AVU</button>
-														</div>
-													</form>
-												</div>
-												<div class="acl-cont tab-pane fade">
-													<br />
-													<table class="table table-hover acl-tbl">
-													<thead>
-														<tr>
-															<th>Name</th>
-															<th>Type</th>
-															<th style="min-width:16%;">Access Level</th>
-														</tr>
-													</thead>
-													<tbody>
-														
-													</tbody>
-													</table>
-													<form class="form-inline acl-form" data-objname="{{.Name}}">
-														<div class="form-group" style="width:100%;">
-															<div class="input-group" style="width: 77%;">
-																<div class="input-group-addon">User/Group:</div>
-																{{ if ne (len .Con.Users) 0 }}
-																	<select class="form-control acl-name" style="margin-left:-3px;border-radius: 4px;margin-right:5px;">
-																		<option></option>
-																		
-																		{{ range .Con.Users }}
-																			<option value="{{ .Name }}">User: {{ .Name }}</option>
-																		{{ end }}
-																		{{ range .Con.Groups }}
-																			<option value="{{ .Name }}">Group: {{ .Name }}</option>
-																		{{ end }}
-																		
-																	</select>
-																{{ else }}
-																	<input type="text" class="form-control acl-name" style="margin-left:-3px;border-radius: 4px;margin-right:5px;">
-																{{ end }}
-																<div class="input-group-addon" style="border-radius: 4px;">Access:</div>
-																<select class="form-control acl-access" style="margin-left:-6px;">
-																	<option></option>
-																	<option value="read">read</option>
-																	<option value="write">write</option>
-																	<option value="own">own</option>
-																	<option value="null">revoke</option>
-																</select>
-															</div>
-															<button type="submit" class="add-acl btn btn-primary">Modify Access</button>
-														</div>
-													</form>	
-												</div>
-											</div>
-										</div>
-										<div class="modal-footer">
-											<button type="button" class="btn btn-default" data-dismiss="modal">Close</button>
-										</div>
-									</div>
-								</div>
-							</div>
-						</td></td>
-					</tr>
-				{{end}}
-			</tbody>
-		</table>
-	</div>
-	<div id="create-collection-cont" style="display:none;">
-		<strong>Create Collection</strong>
-		<div style='margin-top:10px;' class='input-group'>
-			<input type='text' class='form-control collection-name' placeholder='Collection name...'>
-			<span class='input-group-btn'><button class='btn create-collection btn-success' type='button'>Create</button></span>
-		</div>
-	</div>
-</body>
-</html>
-`
-
 type JSONMap map[string]string
 type JSONArr []JSONMap
 
@@ -944,6 +164,7 @@ func (handler *HttpHandler) ServeJSONMeta(obj IRodsObj) {
 		for _, acl := range acls {
 			aclResponse = append(aclResponse, JSONMap{
 				"name":        acl.AccessObject.Name(),
+				"principal":   Principal(acl.AccessObject),
 				"accessLevel": getTypeString(acl.AccessLevel),
 				"type":        getTypeString(acl.Type),
 			})
@@ -969,170 +190,349 @@ func (handler *HttpHandler) ServeJSONMeta(obj IRodsObj) {
 	handler.response.Write(jsonBytes)
 }
 
-type RangeSegmentOutput struct {
-	ContentRange string
-	ContentType  string
-	ByteContent  []byte
+// httpRange describes a single satisfiable byte range of a data object
+type httpRange struct {
+	start  int64
+	length int64
 }
 
-type RangeOutput []RangeSegmentOutput
+// contentRange returns the Content-Range header value for the range
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// mimeHeader returns the part headers used when the range is served inside a multipart/byteranges response
+func (r httpRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.contentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+// errNoOverlap is returned by parseRange when none of the requested ranges overlap the data object
+var errNoOverlap = errors.New("invalid range: failed to overlap")
+
+// rangeReadSize is the maximum number of bytes requested from iRODS per read when streaming a data object
+const rangeReadSize = 4194304 // 4 MiB
+
+// parseRange parses a Range header string as per RFC 7233.
+// errNoOverlap is returned if none of the ranges overlap an object of the given size.
+func parseRange(s string, size int64) ([]httpRange, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	const b = "bytes="
+	if !strings.HasPrefix(s, b) {
+		return nil, errors.New("invalid range")
+	}
+
+	var (
+		ranges    []httpRange
+		noOverlap bool
+	)
+
+	for _, ra := range strings.Split(s[len(b):], ",") {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+
+		i := strings.Index(ra, "-")
+		if i < 0 {
+			return nil, errors.New("invalid range")
+		}
+
+		start, end := strings.TrimSpace(ra[:i]), strings.TrimSpace(ra[i+1:])
+
+		var r httpRange
+
+		if start == "" {
+			// If no start is specified, end specifies the
+			// range start relative to the end of the object
+			i, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || i < 0 {
+				return nil, errors.New("invalid range")
+			}
+			if i == 0 {
+				noOverlap = true
+				continue
+			}
+			if i > size {
+				i = size
+			}
+			r.start = size - i
+			r.length = size - r.start
+		} else {
+			i, err := strconv.ParseInt(start, 10, 64)
+			if err != nil || i < 0 {
+				return nil, errors.New("invalid range")
+			}
+			if i >= size {
+				// If the range begins after the size of the object,
+				// there is no overlap
+				noOverlap = true
+				continue
+			}
+			r.start = i
+
+			if end == "" {
+				// If no end is specified, range extends to end of the object
+				r.length = size - r.start
+			} else {
+				i, err := strconv.ParseInt(end, 10, 64)
+				if err != nil || r.start > i {
+					return nil, errors.New("invalid range")
+				}
+				if i >= size {
+					i = size - 1
+				}
+				r.length = i - r.start + 1
+			}
+		}
 
-func (ro RangeOutput) TotalLength() string {
-	var sum int
+		ranges = append(ranges, r)
+	}
 
-	for _, seg := range ro {
-		sum += len(seg.ByteContent)
+	if noOverlap && len(ranges) == 0 {
+		return nil, errNoOverlap
 	}
 
-	return strconv.Itoa(sum)
+	return ranges, nil
 }
 
-func (handler *HttpHandler) ServeDataObj(obj *DataObj) {
+// countingWriter counts the bytes written to it, used to calculate multipart response lengths
+type countingWriter int64
 
-	rng := handler.request.Header["Range"]
-	objMime := handler.getObjMime(obj)
+func (w *countingWriter) Write(p []byte) (n int, err error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
 
-	lenStr := strconv.FormatInt(obj.Size(), 10)
+// multipartRangesSize returns the total Content-Length of a multipart/byteranges response
+func multipartRangesSize(ranges []httpRange, contentType string, size int64, boundary string) (encSize int64) {
+	var w countingWriter
 
-	if len(rng) > 0 {
-		rangeHeader := rng[0]
+	mw := multipart.NewWriter(&w)
+	mw.SetBoundary(boundary)
 
-		byteRange := strings.Split(rangeHeader, "bytes=")
+	for _, ra := range ranges {
+		mw.CreatePart(ra.mimeHeader(contentType, size))
+		encSize += ra.length
+	}
 
-		if len(byteRange) == 2 && byteRange[0] == "" && byteRange[1] != "" {
+	mw.Close()
 
-			var outputBuffer RangeOutput
+	encSize += int64(w)
 
-			byteRangeSplit := strings.Split(byteRange[1], ",")
+	return
+}
 
-			for _, rangeStr := range byteRangeSplit {
+// objETag returns a strong entity tag for the data object built from its iRODS checksum,
+// or an empty string if the data object has no registered checksum
+func objETag(obj *DataObj) string {
+	if obj.Checksum() == "" {
+		return ""
+	}
 
-				rangeStrSplit := strings.Split(rangeStr, "-")
-				if len(rangeStrSplit) == 2 {
+	return `"` + obj.Checksum() + `"`
+}
 
-					var convErr error
-					var firstByteN int64
-					var lastByteN int64
+// etagMatch reports whether the comma separated list of entity tags in header contains etag.
+// Weak comparison is used, as required for If-None-Match.
+func etagMatch(header string, etag string) bool {
+	if etag == "" {
+		return false
+	}
 
-					firstByte := rangeStrSplit[0]
-					lastByte := rangeStrSplit[1]
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
 
-					// should we only get last bytes
-					if firstByte == "" {
-						if lastByteN, convErr = strconv.ParseInt(lastByte, 10, 64); convErr != nil {
-							log.Print("Error parsing byte range")
-							return
-						}
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
 
-						firstByteN = obj.Size() - lastByteN
-						lastByteN = obj.Size() - 1
-					} else if lastByte == "" {
-						if firstByteN, convErr = strconv.ParseInt(firstByte, 10, 64); convErr != nil {
-							log.Print("Error parsing byte range")
-							return
-						}
+	return false
+}
 
-						lastByteN = obj.Size() - 1
-					} else {
-						if firstByteN, convErr = strconv.ParseInt(firstByte, 10, 64); convErr != nil {
-							log.Print("Error parsing byte range")
-							return
-						}
+// notModified evaluates If-None-Match and If-Modified-Since against the data object
+func (handler *HttpHandler) notModified(etag string, modTime time.Time) bool {
+	req := handler.request
 
-						if lastByteN, convErr = strconv.ParseInt(lastByte, 10, 64); convErr != nil {
-							log.Print("Error parsing byte range")
-							return
-						}
-					}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
 
-					if byteData, err := obj.FastReadFree(firstByteN, int(lastByteN-firstByteN)+1); err == nil {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, etag)
+	}
 
-						defer byteData.Free()
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !modTime.Truncate(time.Second).After(t)
+		}
+	}
 
-						outputBuffer = append(outputBuffer, RangeSegmentOutput{
-							ContentRange: "bytes " + strconv.FormatInt(firstByteN, 10) + "-" + strconv.FormatInt(lastByteN, 10) + "/" + lenStr,
-							ContentType:  objMime,
-							ByteContent:  byteData.Contents,
-						})
+	return false
+}
 
-					} else {
-						log.Print(err)
-					}
+// rangeApplies evaluates If-Range, returning false if the range request should be ignored and the full object served
+func (handler *HttpHandler) rangeApplies(etag string, modTime time.Time) bool {
+	ir := handler.request.Header.Get("If-Range")
+	if ir == "" {
+		return true
+	}
 
-				} else {
-					log.Print("Error parsing byte range")
-				}
-			}
+	// If-Range requires a strong comparison
+	if strings.HasPrefix(ir, `"`) {
+		return etag != "" && ir == etag
+	}
 
-			if len(outputBuffer) > 1 {
+	if t, err := http.ParseTime(ir); err == nil && !modTime.IsZero() {
+		return modTime.Truncate(time.Second).Equal(t)
+	}
 
-				handler.response.Header().Set("Accept-Ranges", "bytes")
-				//handler.response.Header().Set("Content-Length", outputBuffer.TotalLength())
+	return false
+}
 
-				mpWriter := multipart.NewWriter(handler.response)
+// writeRange streams length bytes of the data object, starting at start, to w.
+// Bytes are copied straight out of the C buffer returned by iRODS, nothing is held in memory between reads.
+func writeRange(w io.Writer, obj *DataObj, start int64, length int64) error {
+	for length > 0 {
+		chunk := length
+		if chunk > rangeReadSize {
+			chunk = rangeReadSize
+		}
 
-				handler.response.Header().Set("Content-Type", "multipart/byteranges; boundary="+mpWriter.Boundary())
+		var n int
 
-				handler.response.WriteHeader(http.StatusPartialContent)
+		if err := obj.FastRead(start, int(chunk), func(b []byte) error {
+			n = len(b)
+			_, wErr := w.Write(b)
+			return wErr
+		}); err != nil {
+			return err
+		}
 
-				for _, outputSegment := range outputBuffer {
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
 
-					var headers textproto.MIMEHeader = make(textproto.MIMEHeader)
+		start += int64(n)
+		length -= int64(n)
+	}
 
-					headers.Add("Content-Type", outputSegment.ContentType)
-					headers.Add("Content-Range", outputSegment.ContentRange)
+	return nil
+}
 
-					if writer, err := mpWriter.CreatePart(headers); err != nil {
-						log.Print(err)
-						continue
-					} else {
-						writer.Write(outputSegment.ByteContent)
-					}
-				}
+// ServeDataObj writes the contents of the data object to the response. Single and multiple
+// byte ranges (RFC 7233) are streamed straight from iRODS, and conditional requests are answered
+// using the data object's checksum (ETag) and modify time (Last-Modified). HEAD requests receive headers only.
+func (handler *HttpHandler) ServeDataObj(obj *DataObj) {
+
+	w := handler.response
+	req := handler.request
+
+	size := obj.Size()
+	objMime := handler.getObjMime(obj)
+	etag := objETag(obj)
+	modTime := obj.ModifyTime()
+
+	w.Header().Set("Accept-Ranges", "bytes")
 
-			} else if len(outputBuffer) == 1 {
-				handler.response.Header().Set("Content-Range", outputBuffer[0].ContentRange)
-				handler.response.Header().Set("Accept-Ranges", "bytes")
-				handler.response.Header().Set("Content-Length", strconv.Itoa(len(outputBuffer[0].ByteContent)))
-				handler.response.Header().Set("Content-Type", outputBuffer[0].ContentType)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
 
-				handler.response.WriteHeader(http.StatusPartialContent)
+	if !modTime.IsZero() && modTime.Unix() > 0 {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
 
-				handler.response.Write(outputBuffer[0].ByteContent)
+	if handler.notModified(etag, modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	contentType := objMime
+
+	if handler.opts.Download || handler.query.Get("download") != "" {
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(obj.Name()))
+		contentType = "application/octet-stream"
+	}
+
+	var (
+		ranges []httpRange
+		err    error
+	)
+
+	if rangeHeader := req.Header.Get("Range"); rangeHeader != "" && handler.rangeApplies(etag, modTime) {
+		if ranges, err = parseRange(rangeHeader, size); err != nil {
+			if err == errNoOverlap {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
 			}
+
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
 		}
+	}
 
-	} else {
+	switch {
+	case len(ranges) == 1:
+		ra := ranges[0]
 
-		if handler.opts.Download || handler.query.Get("download") != "" {
-			handler.response.Header().Set("Content-Disposition", "attachment; filename="+obj.Name())
-			handler.response.Header().Set("Content-type", "application/octet-stream")
-		} else {
-			handler.response.Header().Set("Content-type", objMime)
+		w.Header().Set("Content-Range", ra.contentRange(size))
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(ra.length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+
+		if req.Method == "HEAD" {
+			return
 		}
 
-		handler.response.Header().Set("Accept-Ranges", "bytes")
-		handler.response.Header().Set("Content-Length", lenStr)
+		if er := writeRange(w, obj, ra.start, ra.length); er != nil {
+			log.Print(er)
+		}
 
-		outBuff := make(chan *ByteArr, 100)
+	case len(ranges) > 1:
+		mpWriter := multipart.NewWriter(w)
 
-		go func() {
-			if readEr := obj.ReadChunkFree(10240000, func(chunk *ByteArr) {
-				outBuff <- chunk
-			}); readEr != nil {
-				log.Print(readEr)
+		w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mpWriter.Boundary())
+		w.Header().Set("Content-Length", strconv.FormatInt(multipartRangesSize(ranges, objMime, size, mpWriter.Boundary()), 10))
+		w.WriteHeader(http.StatusPartialContent)
 
-				handler.response.WriteHeader(http.StatusInternalServerError)
-				handler.response.Write([]byte("Error: " + readEr.Error()))
+		if req.Method == "HEAD" {
+			return
+		}
+
+		for _, ra := range ranges {
+			part, er := mpWriter.CreatePart(ra.mimeHeader(objMime, size))
+			if er != nil {
+				log.Print(er)
+				return
+			}
 
+			if er := writeRange(part, obj, ra.start, ra.length); er != nil {
+				log.Print(er)
+				return
 			}
+		}
+
+		if er := mpWriter.Close(); er != nil {
+			log.Print(er)
+		}
+
+	default:
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		w.WriteHeader(http.StatusOK)
 
-			close(outBuff)
-		}()
+		if req.Method == "HEAD" {
+			return
+		}
 
-		for b := range outBuff {
-			handler.response.Write(b.Contents)
-			b.Free()
+		if er := writeRange(w, obj, 0, size); er != nil {
+			log.Print(er)
 		}
 	}
 
@@ -1164,81 +564,38 @@ func (handler *HttpHandler) Serve404() {
 	handler.response.Write([]byte("<h3>404 Not Found: " + handler.openPath + "</h3>"))
 }
 
-func (handler *HttpHandler) ServeCollectionView(col *Collection) {
-
-	handler.response.Header().Set("Content-Type", "text/html")
-
-	t, err := template.New("collectionList").Funcs(template.FuncMap{
-		"prettySize": func(size int64) string {
-			if size < 1024 {
-				return fmt.Sprintf("%v bytes", size)
-			} else if size < 1048576 { // 1 MiB
-				return fmt.Sprintf("%.1f KiB", float64(size)/1024.0)
-			} else if size < 1073741824 { // 1 GiB
-				return fmt.Sprintf("%.1f MiB", float64(size)/1048576.0)
-			} else if size < 1099511627776 { // 1 TiB
-				return fmt.Sprintf("%.1f GiB", float64(size)/1073741824.0)
-			} else {
-				return fmt.Sprintf("%.1f TiB", float64(size)/1099511627776.0)
-			}
-		},
-		"headerLinks": func() []map[string]string {
-			headerLinks := make([]map[string]string, 0)
-
-			if handler.openPath == handler.handlerPath {
-				return headerLinks
-			}
-
-			p := strings.TrimPrefix(handler.openPath, handler.handlerPath+"/")
-
-			frags := strings.Split(p, "/")
-
-			for i := range frags {
-				var path string
-
-				if i > 0 {
-					path = strings.Join(frags[0:i], "/") + "/"
-				} else {
-					path = ""
-				}
-
-				headerLinks = append(headerLinks, map[string]string{
-					"name": frags[i],
-					"url":  (handler.opts.StripPrefix + path + frags[i] + "/"),
-				})
-
-			}
-
-			return headerLinks
-		},
-		"usersJSON": func() []string {
-			usrs := make([]string, 0)
-
-			conUsers, err := col.Con().Users()
-			check(err)
+func prettySize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%v bytes", size)
+	} else if size < 1048576 { // 1 MiB
+		return fmt.Sprintf("%.1f KiB", float64(size)/1024.0)
+	} else if size < 1073741824 { // 1 GiB
+		return fmt.Sprintf("%.1f MiB", float64(size)/1048576.0)
+	} else if size < 1099511627776 { // 1 TiB
+		return fmt.Sprintf("%.1f GiB", float64(size)/1073741824.0)
+	}
 
-			for _, u := range conUsers {
-				usrs = append(usrs, u.Name())
-			}
+	return fmt.Sprintf("%.1f TiB", float64(size)/1099511627776.0)
+}
 
-			return usrs
-		},
-		"groupsJSON": func() []string {
-			grps := make([]string, 0)
+func (handler *HttpHandler) ServeCollectionView(col *Collection) {
 
-			conGroups, err := col.Con().Groups()
-			check(err)
+	view, err := handler.collectionView(col)
+	if err != nil {
+		log.Print(err)
+		http.Error(handler.response, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-			for _, g := range conGroups {
-				grps = append(grps, g.Name())
-			}
+	handler.response.Header().Set("Content-Type", "text/html")
 
-			return grps
-		},
-	}).Parse(tpl)
-	check(err)
+	// Bind the deprecated functions of the templates of earlier versions to this listing
+	tpl := handler.tpl
+	if clone, err := tpl.Clone(); err == nil {
+		tpl = clone.Funcs(view.legacyFuncs())
+	}
 
-	err = t.Execute(handler.response, col)
+	err = tpl.Execute(handler.response, view)
 	check(err)
 }
 
@@ -1376,19 +733,9 @@ func (handler *HttpHandler) AddACL(obj IRodsObj) {
 	name := strings.TrimSpace(req.PostForm.Get("name"))
 	accessString := strings.TrimSpace(req.PostForm.Get("access"))
 
-	var accessLevel int
-	switch accessString {
-	case "own":
-		accessLevel = Own
-	case "write":
-		accessLevel = Write
-	case "read":
-		accessLevel = Read
-	case "null":
-		accessLevel = Null
-	}
-
-	if err := obj.Chmod(name, accessLevel, true); err == nil {
+	if accessLevel, err := ParseAccessLevel(accessString); err != nil {
+		response.Message = err.Error()
+	} else if err := obj.Chmod(name, accessLevel, true); err == nil {
 		response.Success = true
 		response.Message = "Added metadata successfully"
 	} else {
@@ -1429,7 +776,7 @@ func (handler *HttpHandler) Upload(col *Collection) {
 				Name: part.FileName(),
 			}); cEr == nil {
 
-				contents := make([]byte, 1024*200000)
+				contents := make([]byte, uploadChunkSize)
 
 				response.Success = true
 				response.Message = "File upload success"
@@ -1437,22 +784,28 @@ func (handler *HttpHandler) Upload(col *Collection) {
 			ReadLoop:
 				for {
 
-					n, fErr := part.Read(contents)
+					n, fErr := io.ReadFull(part, contents)
 
-					if fErr != nil && fErr != io.EOF {
-						log.Print(fErr.Error())
-						panic(fErr)
+					if n > 0 {
+						if wEr := obj.WriteBytes(contents[:n]); wEr != nil {
+							response.Message = wEr.Error()
+							response.Success = false
+
+							log.Print(wEr)
+
+							break ReadLoop
+						}
 					}
 
-					if n == 0 && io.EOF == fErr {
+					if fErr == io.EOF || fErr == io.ErrUnexpectedEOF {
 						break ReadLoop
 					}
 
-					if wEr := obj.WriteBytes(contents[:n]); wEr != nil {
-						response.Message = wEr.Error()
+					if fErr != nil {
+						response.Message = fErr.Error()
 						response.Success = false
 
-						log.Print(wEr)
+						log.Print(fErr)
 
 						break ReadLoop
 					}
@@ -1491,6 +844,11 @@ func (handler *HttpHandler) ServeHTTP(response http.ResponseWriter, request *htt
 
 	handler.query = request.URL.Query()
 
+	if urlPath == "" && handler.query.Get("asset") != "" {
+		handler.ServeAsset(handler.query.Get("asset"))
+		return
+	}
+
 	var handlerMain = func(con *Connection) {
 		if objType, err := con.PathType(handler.openPath); err == nil {
 
@@ -1516,6 +874,10 @@ func (handler *HttpHandler) ServeHTTP(response http.ResponseWriter, request *htt
 						if request.Method == "POST" {
 							handler.DeleteObj(obj)
 						}
+					case q.Get("thumbnail") != "":
+						handler.ServeThumbnail(obj)
+					case q.Get("preview") != "":
+						handler.ServePreview(obj)
 					default:
 						handler.ServeDataObj(obj)
 					}
@@ -1562,6 +924,12 @@ func (handler *HttpHandler) ServeHTTP(response http.ResponseWriter, request *htt
 						if request.Method == "POST" {
 							handler.AddACL(col)
 						}
+					case q.Get("search") != "":
+						if request.Method == "GET" {
+							handler.Search(col)
+						}
+					case q.Get("tus") != "":
+						handler.TusUpload(col)
 					case q.Get("upload") != "":
 						if request.Method == "POST" {
 							handler.Upload(col)
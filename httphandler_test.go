@@ -0,0 +1,87 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+
+	var size int64 = 1000
+
+	tests := []struct {
+		header string
+		ranges []httpRange
+		err    bool
+	}{
+		{"", nil, false},
+		{"bytes=0-499", []httpRange{{0, 500}}, false},
+		{"bytes=500-", []httpRange{{500, 500}}, false},
+		{"bytes=-200", []httpRange{{800, 200}}, false},
+		{"bytes=-5000", []httpRange{{0, 1000}}, false},
+		{"bytes=900-5000", []httpRange{{900, 100}}, false},
+		{"bytes=0-0, 10-19", []httpRange{{0, 1}, {10, 10}}, false},
+		{"bytes=0-1,2000-3000", []httpRange{{0, 2}}, false},
+		{"bytes=1000-", nil, true},
+		{"bytes=20-10", nil, true},
+		{"bytes=a-b", nil, true},
+		{"items=0-10", nil, true},
+	}
+
+	for _, test := range tests {
+		ranges, err := parseRange(test.header, size)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error for %q, got %v", test.header, ranges)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", test.header, err)
+			continue
+		}
+
+		if len(ranges) != len(test.ranges) {
+			t.Errorf("Expected %v ranges for %q, got %v", len(test.ranges), test.header, len(ranges))
+			continue
+		}
+
+		for i := range ranges {
+			if ranges[i] != test.ranges[i] {
+				t.Errorf("Expected range %v for %q, got %v", test.ranges[i], test.header, ranges[i])
+			}
+		}
+	}
+
+	if _, err := parseRange("bytes=5000-6000", size); err != errNoOverlap {
+		t.Errorf("Expected errNoOverlap, got %v", err)
+	}
+}
+
+func TestETagMatch(t *testing.T) {
+	etag := `"sha2:abc="`
+
+	if !etagMatch(`"sha2:abc="`, etag) {
+		t.Errorf("Expected exact etag to match")
+	}
+
+	if !etagMatch(`"foo", W/"sha2:abc="`, etag) {
+		t.Errorf("Expected weak etag in list to match")
+	}
+
+	if !etagMatch("*", etag) {
+		t.Errorf("Expected wildcard to match")
+	}
+
+	if etagMatch(`"foo"`, etag) {
+		t.Errorf("Expected non-matching etag not to match")
+	}
+
+	if etagMatch("*", "") {
+		t.Errorf("Expected empty etag never to match")
+	}
+}
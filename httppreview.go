@@ -0,0 +1,397 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultPreviewBytes is how much of a text data object is shown when FSOptions.PreviewBytes is not set
+	DefaultPreviewBytes = 64 * 1024
+
+	// DefaultMaxThumbnailSource is the largest image a thumbnail is made of when FSOptions.MaxThumbnailSource is not set
+	DefaultMaxThumbnailSource = 32 * 1024 * 1024
+
+	// maxReadmeBytes limits how much of a README is rendered in collection listings
+	maxReadmeBytes = 256 * 1024
+
+	// maxPreviewRows limits the rows shown in CSV previews
+	maxPreviewRows = 1000
+)
+
+// Preview kinds, see previewKind
+const (
+	previewImage    = "image"
+	previewText     = "text"
+	previewCSV      = "csv"
+	previewJSON     = "json"
+	previewMarkdown = "markdown"
+)
+
+var textExtensions = map[string]bool{
+	".txt": true, ".log": true, ".yaml": true, ".yml": true, ".xml": true, ".ini": true, ".cfg": true,
+	".conf": true, ".toml": true, ".sh": true, ".py": true, ".go": true, ".r": true, ".pl": true,
+	".c": true, ".h": true, ".java": true, ".js": true, ".sql": true, ".fa": true, ".fasta": true,
+	".fastq": true, ".fq": true, ".sam": true, ".vcf": true, ".bed": true, ".gff": true, ".gtf": true,
+}
+
+// previewKind classifies a data object by its name, "" means it can't be previewed
+func previewKind(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return previewImage
+	case ".csv", ".tsv":
+		return previewCSV
+	case ".json":
+		return previewJSON
+	case ".md", ".markdown":
+		return previewMarkdown
+	}
+
+	if textExtensions[ext] || strings.HasPrefix(mime.TypeByExtension(ext), "text/") {
+		return previewText
+	}
+
+	return ""
+}
+
+// isReadme reports whether name is rendered below collection listings
+func isReadme(name string) bool {
+	switch strings.ToLower(name) {
+	case "readme.md", "readme.markdown":
+		return true
+	}
+
+	return false
+}
+
+// previewView is the view model of templates/preview.html
+type previewView struct {
+	Name      string
+	Path      string
+	URL       string
+	ParentURL string
+	RootURL   string
+	AssetURL  string
+	Size      int64
+
+	Kind         string
+	ThumbnailURL string
+	Text         string
+	Rows         [][]string
+	HTML         template.HTML
+	Truncated    bool
+}
+
+// PrettySize formats the size of the data object for display
+func (v previewView) PrettySize() string {
+	return prettySize(v.Size)
+}
+
+var previewTemplate = template.Must(template.New("preview.html").Funcs(TemplateFuncs()).ParseFS(templateFS, "templates/preview.html"))
+
+// readHead reads up to n bytes from the start of obj, reporting whether the object is longer
+func readHead(obj *DataObj, n int64) ([]byte, bool, error) {
+	size := obj.Size()
+	truncated := size > n
+
+	if truncated {
+		size = n
+	}
+
+	var buf bytes.Buffer
+
+	if size > 0 {
+		if err := writeRange(&buf, obj, 0, size); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return buf.Bytes(), truncated, nil
+}
+
+// previewString converts the head of a text data object to a string. Truncated text is cut
+// at the last complete line, and invalid UTF-8 sequences are replaced.
+func previewString(b []byte, truncated bool) string {
+	if truncated {
+		if i := bytes.LastIndexByte(b, '\n'); i > 0 {
+			b = b[:i+1]
+		}
+	}
+
+	if !utf8.Valid(b) {
+		b = bytes.ToValidUTF8(b, []byte("�"))
+	}
+
+	return string(b)
+}
+
+// looksLikeText reports whether the head of an object with an unknown extension is text
+func looksLikeText(b []byte) bool {
+	return len(b) > 0 && !bytes.ContainsRune(b, 0) && strings.HasPrefix(http.DetectContentType(b), "text/")
+}
+
+// parseCSVPreview parses the rows of a (possibly truncated) CSV or TSV document
+func parseCSVPreview(text string, comma rune) ([][]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = comma
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	var rows [][]string
+
+	for len(rows) < maxPreviewRows {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ServePreview renders an HTML preview of a data object: a large thumbnail for images, the
+// first FSOptions.PreviewBytes of text, CSV and JSON, and rendered Markdown
+func (handler *HttpHandler) ServePreview(obj *DataObj) {
+
+	view := previewView{
+		Name:      obj.Name(),
+		Path:      obj.Path(),
+		URL:       handler.entryURL(obj.Path(), false),
+		ParentURL: handler.entryURL(path.Dir(obj.Path()), true),
+		RootURL:   handler.entryURL(handler.handlerPath, true),
+		AssetURL:  handler.assetURL(),
+		Size:      obj.Size(),
+		Kind:      previewKind(obj.Name()),
+	}
+
+	limit := int64(handler.opts.PreviewBytes)
+	if limit <= 0 {
+		limit = DefaultPreviewBytes
+	}
+
+	if view.Kind == previewImage {
+		view.ThumbnailURL = view.URL + "?thumbnail=" + strconv.Itoa(ThumbnailSizes[len(ThumbnailSizes)-1])
+	} else {
+		if view.Kind == previewMarkdown {
+			limit = maxReadmeBytes
+		}
+
+		head, truncated, err := readHead(obj, limit)
+		if err != nil {
+			log.Print(err)
+			http.Error(handler.response, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if view.Kind == "" && looksLikeText(head) {
+			view.Kind = previewText
+		}
+
+		view.Truncated = truncated
+		text := previewString(head, truncated)
+
+		switch view.Kind {
+		case previewCSV:
+			comma := ','
+			if strings.ToLower(filepath.Ext(obj.Name())) == ".tsv" {
+				comma = '\t'
+			}
+
+			if view.Rows, err = parseCSVPreview(text, comma); err != nil {
+				view.Kind = previewText
+			}
+		case previewJSON:
+			var indented bytes.Buffer
+
+			if !truncated && json.Indent(&indented, head, "", "  ") == nil {
+				text = indented.String()
+			}
+		case previewMarkdown:
+			view.HTML = renderMarkdown([]byte(text))
+		}
+
+		view.Text = text
+	}
+
+	handler.response.Header().Set("Content-Type", "text/html")
+
+	err := previewTemplate.Execute(handler.response, view)
+	check(err)
+}
+
+// thumbnailCaches returns the caches thumbnails of obj are looked up in, in order
+func (handler *HttpHandler) thumbnailCaches(obj *DataObj) []ThumbnailCache {
+	var caches []ThumbnailCache
+
+	if handler.opts.ThumbnailCache != nil {
+		caches = append(caches, handler.opts.ThumbnailCache)
+	}
+
+	if handler.opts.StoreThumbnails && obj.Col() != nil {
+		caches = append(caches, &ObjectThumbnailCache{Collection: obj.Col()})
+	}
+
+	return caches
+}
+
+// thumbnailVersion identifies the content of obj in thumbnail cache keys and entity tags: its
+// registered checksum, or a hash of its path, size and modify time when it has none
+func thumbnailVersion(obj *DataObj) string {
+	if obj.Checksum() != "" {
+		return obj.Checksum()
+	}
+
+	if obj.ModifyTime().IsZero() {
+		return ""
+	}
+
+	sum := sha1.Sum([]byte(obj.Path() + "\x00" + strconv.FormatInt(obj.Size(), 10) + "\x00" + strconv.FormatInt(obj.ModifyTime().UnixNano(), 10)))
+
+	return "mtime-" + hex.EncodeToString(sum[:])
+}
+
+// ServeThumbnail serves a PNG or JPEG thumbnail of an image data object. The size is taken from
+// the thumbnail query parameter and rounded up to one of ThumbnailSizes. Thumbnails are cached
+// by the registered checksum of the data object, or by its path, size and modify time when it
+// has none. Requests never compute checksums on the server.
+func (handler *HttpHandler) ServeThumbnail(obj *DataObj) {
+
+	if previewKind(obj.Name()) != previewImage {
+		http.Error(handler.response, "Thumbnails are only available for PNG, JPEG and GIF images", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	size, _ := strconv.Atoi(handler.query.Get("thumbnail"))
+	size = thumbnailSize(size)
+
+	var (
+		key    string
+		etag   string
+		thumb  []byte
+		caches = handler.thumbnailCaches(obj)
+	)
+
+	if version := thumbnailVersion(obj); version != "" {
+		key = version + ":" + strconv.Itoa(size)
+		etag = `"thumb-` + strconv.Itoa(size) + "-" + version + `"`
+
+		if etagMatch(handler.request.Header.Get("If-None-Match"), etag) {
+			handler.response.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		for i, cache := range caches {
+			if cached, ok := cache.Get(key); ok {
+				thumb = cached
+
+				// Fill the caches in front of the one that had it
+				for _, c := range caches[:i] {
+					if err := c.Put(key, thumb); err != nil {
+						log.Print(err)
+					}
+				}
+
+				break
+			}
+		}
+	}
+
+	if thumb == nil {
+		maxSource := handler.opts.MaxThumbnailSource
+		if maxSource <= 0 {
+			maxSource = DefaultMaxThumbnailSource
+		}
+
+		if obj.Size() > maxSource {
+			http.Error(handler.response, "Image is too large to make a thumbnail", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		src, _, err := readHead(obj, maxSource)
+		if err != nil {
+			log.Print(err)
+			http.Error(handler.response, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if thumb, _, err = Thumbnail(bytes.NewReader(src), size); err != nil {
+			http.Error(handler.response, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if key != "" {
+			for _, cache := range caches {
+				if err := cache.Put(key, thumb); err != nil {
+					log.Print(err)
+				}
+			}
+		}
+	}
+
+	handler.response.Header().Set("Content-Type", http.DetectContentType(thumb))
+	handler.response.Header().Set("Content-Length", strconv.Itoa(len(thumb)))
+	handler.response.Header().Set("Cache-Control", "private, max-age=86400")
+
+	if etag != "" {
+		handler.response.Header().Set("ETag", etag)
+	}
+
+	if handler.request.Method != "HEAD" {
+		handler.response.Write(thumb)
+	}
+}
+
+// readme finds a README among the entries of view and renders it
+func (handler *HttpHandler) readme(col *Collection, view *CollectionView) {
+	for _, entry := range view.Entries {
+		if entry.IsCollection || !isReadme(entry.Name) {
+			continue
+		}
+
+		obj, err := col.Con().DataObject(entry.Path)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		head, truncated, err := readHead(obj, maxReadmeBytes)
+		if cErr := obj.Close(); cErr != nil {
+			log.Print(cErr)
+		}
+
+		if err != nil {
+			log.Print(err)
+			return
+		}
+
+		view.ReadmeName = entry.Name
+		view.Readme = renderMarkdown([]byte(previewString(head, truncated)))
+
+		return
+	}
+}
@@ -0,0 +1,82 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPreviewKind(t *testing.T) {
+	tests := map[string]string{
+		"photo.JPG":   previewImage,
+		"anim.gif":    previewImage,
+		"table.tsv":   previewCSV,
+		"data.json":   previewJSON,
+		"README.md":   previewMarkdown,
+		"reads.fastq": previewText,
+		"notes.txt":   previewText,
+		"archive.tar": "",
+		"noext":       "",
+	}
+
+	for name, kind := range tests {
+		if k := previewKind(name); k != kind {
+			t.Errorf("Expected kind %q for %v, got %q", kind, name, k)
+		}
+	}
+
+	if !isReadme("readme.MD") || isReadme("README.txt") {
+		t.Errorf("Unexpected README detection")
+	}
+}
+
+func TestPreviewString(t *testing.T) {
+	if s := previewString([]byte("one\ntwo\nthr"), true); s != "one\ntwo\n" {
+		t.Errorf("Expected truncated text to end at the last line, got %q", s)
+	}
+
+	if s := previewString([]byte("one\ntwo"), false); s != "one\ntwo" {
+		t.Errorf("Expected complete text to be kept, got %q", s)
+	}
+
+	if s := previewString([]byte{'a', 0xff, 'b'}, false); s != "a�b" {
+		t.Errorf("Expected invalid UTF-8 to be replaced, got %q", s)
+	}
+
+	if !looksLikeText([]byte("plain text\n")) || looksLikeText([]byte{0x89, 'P', 'N', 'G', 0}) {
+		t.Errorf("Unexpected text detection")
+	}
+}
+
+func TestParseCSVPreview(t *testing.T) {
+	rows, err := parseCSVPreview("name\tsize\na\t1\nb\t2\tx\n", '\t')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 || rows[2][2] != "x" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	var out bytes.Buffer
+
+	view := previewView{
+		Name: "data.csv",
+		Path: "/tempZone/home/rods/data.csv",
+		Kind: previewCSV,
+		Rows: [][]string{{"name", "size"}, {"<a>", "1"}},
+	}
+
+	if err := previewTemplate.Execute(&out, view); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "<th>name</th>") || !strings.Contains(out.String(), "<td>&lt;a&gt;</td>") {
+		t.Errorf("Unexpected CSV preview: %v", out.String())
+	}
+}
@@ -0,0 +1,189 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchDateFormat is the format of the "after" and "before" search parameters, as sent by <input type="date">
+const searchDateFormat = "2006-01-02"
+
+// searchView is the view model of templates/search.html
+type searchView struct {
+	Path     string
+	URL      string
+	RootURL  string
+	AssetURL string
+	Query    url.Values
+	Results  *SearchResults
+	Entries  []ViewEntry
+	PrevURL  string
+	NextURL  string
+	Error    string
+}
+
+// parseSearchOptions reads search criteria from the query string of a search request:
+// name, attr, value, units, minsize, maxsize, after, before, page and perpage.
+func parseSearchOptions(q url.Values) (SearchOptions, error) {
+	var (
+		opts SearchOptions
+		err  error
+	)
+
+	opts.Name = strings.TrimSpace(q.Get("name"))
+	opts.Attribute = strings.TrimSpace(q.Get("attr"))
+	opts.Value = strings.TrimSpace(q.Get("value"))
+	opts.Units = strings.TrimSpace(q.Get("units"))
+
+	for param, dst := range map[string]*int64{"minsize": &opts.MinSize, "maxsize": &opts.MaxSize} {
+		if v := q.Get(param); v != "" {
+			if *dst, err = strconv.ParseInt(v, 10, 64); err != nil || *dst < 0 {
+				return opts, newError(Fatal, -1, "Invalid "+param+": "+v)
+			}
+		}
+	}
+
+	if v := q.Get("after"); v != "" {
+		if opts.ModifiedAfter, err = time.Parse(searchDateFormat, v); err != nil {
+			return opts, newError(Fatal, -1, "Invalid after date: "+v)
+		}
+	}
+
+	if v := q.Get("before"); v != "" {
+		if opts.ModifiedBefore, err = time.Parse(searchDateFormat, v); err != nil {
+			return opts, newError(Fatal, -1, "Invalid before date: "+v)
+		}
+
+		// Include the whole day
+		opts.ModifiedBefore = opts.ModifiedBefore.Add(24*time.Hour - time.Second)
+	}
+
+	opts.Page, _ = strconv.Atoi(q.Get("page"))
+
+	if opts.PerPage, _ = strconv.Atoi(q.Get("perpage")); opts.PerPage > 500 {
+		opts.PerPage = 500
+	}
+
+	return opts, nil
+}
+
+// searchPageURL returns the URL of the current search with page replaced
+func (handler *HttpHandler) searchPageURL(page int) string {
+	q := url.Values{}
+
+	for k, v := range handler.query {
+		q[k] = v
+	}
+
+	q.Set("page", strconv.Itoa(page))
+
+	return "?" + q.Encode()
+}
+
+// Search runs a metadata/name/size/date search scoped to col and serves the results as HTML,
+// or as JSON when format=json is requested
+func (handler *HttpHandler) Search(col *Collection) {
+
+	view := searchView{
+		Path:     col.Path(),
+		URL:      handler.entryURL(col.Path(), true),
+		RootURL:  handler.entryURL(handler.handlerPath, true),
+		AssetURL: handler.assetURL(),
+		Query:    handler.query,
+	}
+
+	opts, err := parseSearchOptions(handler.query)
+	if err == nil && !opts.IsEmpty() {
+		view.Results, err = col.Con().Search(col.Path(), opts)
+	}
+
+	if err != nil {
+		view.Error = err.Error()
+	} else if view.Results != nil {
+		for _, res := range view.Results.Results {
+			isCol := res.Type == CollectionType
+
+			view.Entries = append(view.Entries, ViewEntry{
+				Name:         res.Name,
+				Path:         res.Path,
+				URL:          handler.entryURL(res.Path, isCol),
+				IsCollection: isCol,
+				Size:         res.Size,
+				ModifyTime:   res.ModifyTime,
+			})
+		}
+
+		if view.Results.HasPrev() {
+			view.PrevURL = handler.searchPageURL(view.Results.Page - 1)
+		}
+
+		if view.Results.HasNext() {
+			view.NextURL = handler.searchPageURL(view.Results.Page + 1)
+		}
+	}
+
+	if handler.query.Get("format") == "json" {
+		handler.serveSearchJSON(view)
+		return
+	}
+
+	handler.response.Header().Set("Content-Type", "text/html")
+
+	if view.Error != "" {
+		handler.response.WriteHeader(http.StatusBadRequest)
+	}
+
+	err = searchTemplate.Execute(handler.response, view)
+	check(err)
+}
+
+func (handler *HttpHandler) serveSearchJSON(view searchView) {
+	handler.response.Header().Set("Content-Type", "application/json")
+
+	var response struct {
+		Success bool
+		Message string
+		Total   int
+		Page    int
+		Pages   int
+		Results JSONArr
+	}
+
+	response.Results = make(JSONArr, 0)
+
+	if view.Error != "" {
+		response.Message = view.Error
+		handler.response.WriteHeader(http.StatusBadRequest)
+	} else {
+		response.Success = true
+
+		if view.Results != nil {
+			response.Total = view.Results.Total
+			response.Page = view.Results.Page
+			response.Pages = view.Results.Pages()
+
+			for _, res := range view.Results.Results {
+				response.Results = append(response.Results, JSONMap{
+					"path":       res.Path,
+					"name":       res.Name,
+					"type":       getTypeString(res.Type),
+					"size":       strconv.FormatInt(res.Size, 10),
+					"modifyTime": res.ModifyTime.UTC().Format(time.RFC3339),
+					"url":        handler.entryURL(res.Path, res.Type == CollectionType),
+				})
+			}
+		}
+	}
+
+	out, err := json.Marshal(response)
+	check(err)
+
+	handler.response.Write(out)
+}
@@ -0,0 +1,684 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+)
+
+// Tus protocol constants, see https://tus.io/protocols/resumable-upload.html
+const (
+	TusVersion    = "1.0.0"
+	TusExtensions = "creation,termination,checksum"
+	TusChecksums  = "md5,sha1,sha256"
+
+	// StatusChecksumMismatch is returned when a PATCH request body doesn't match its Upload-Checksum header
+	StatusChecksumMismatch = 460
+
+	// uploadChunkSize is the size of the buffer used when copying request bodies into iRODS
+	uploadChunkSize = 4194304 // 4 MiB
+)
+
+// Upload stores the state of a single resumable (tus) upload. The data is written to a
+// hidden partial data object (PartPath) inside the target collection, which is renamed
+// to Name once Offset reaches Length and the checksum (if any) has been verified.
+type Upload struct {
+	ID         string
+	Collection string
+	Name       string
+	PartPath   string
+	Length     int64
+	Offset     int64
+	Checksum   string
+	Metadata   map[string]string
+	CreateTime time.Time
+	ModifyTime time.Time
+}
+
+// Done returns true when all bytes of the upload have been received
+func (up *Upload) Done() bool {
+	return up.Offset >= up.Length
+}
+
+// Percent returns the upload progress as a percentage
+func (up *Upload) Percent() float64 {
+	if up.Length == 0 {
+		return 100
+	}
+
+	return float64(up.Offset) / float64(up.Length) * 100
+}
+
+// UploadStore persists Upload state between requests, so dropped uploads can be resumed.
+// Implementations must be safe for concurrent use.
+type UploadStore interface {
+	Create(*Upload) error
+	Get(id string) (*Upload, error)
+	Update(*Upload) error
+	Delete(id string) error
+}
+
+// ErrUploadNotFound is returned by UploadStore implementations when the upload ID is unknown
+var ErrUploadNotFound = errors.New("Upload not found")
+
+// MemoryUploadStore is an UploadStore that keeps upload state in memory.
+// Uploads can be resumed as long as the process keeps running.
+type MemoryUploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]Upload
+}
+
+// NewMemoryUploadStore creates an empty *MemoryUploadStore
+func NewMemoryUploadStore() *MemoryUploadStore {
+	return &MemoryUploadStore{
+		uploads: make(map[string]Upload),
+	}
+}
+
+// Create stores a new upload
+func (s *MemoryUploadStore) Create(up *Upload) error {
+	return s.Update(up)
+}
+
+// Get returns a copy of the stored upload
+func (s *MemoryUploadStore) Get(id string) (*Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if up, ok := s.uploads[id]; ok {
+		return &up, nil
+	}
+
+	return nil, ErrUploadNotFound
+}
+
+// Update overwrites the stored upload state
+func (s *MemoryUploadStore) Update(up *Upload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.uploads[up.ID] = *up
+
+	return nil
+}
+
+// Delete removes the upload state
+func (s *MemoryUploadStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.uploads, id)
+
+	return nil
+}
+
+// FileUploadStore is an UploadStore that writes upload state as JSON files to a local directory,
+// so uploads can be resumed across restarts of the HTTP server.
+type FileUploadStore struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewFileUploadStore creates a *FileUploadStore, creating dir if it doesn't exist
+func NewFileUploadStore(dir string) (*FileUploadStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("Unable to create upload store directory %v: %v", dir, err))
+	}
+
+	return &FileUploadStore{Dir: dir}, nil
+}
+
+func (s *FileUploadStore) file(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id)+".json")
+}
+
+// Create stores a new upload
+func (s *FileUploadStore) Create(up *Upload) error {
+	return s.Update(up)
+}
+
+// Get reads the upload state from disk
+func (s *FileUploadStore) Get(id string) (*Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := ioutil.ReadFile(s.file(id))
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	up := new(Upload)
+
+	if err := json.Unmarshal(data, up); err != nil {
+		return nil, err
+	}
+
+	return up, nil
+}
+
+// Update writes the upload state to disk
+func (s *FileUploadStore) Update(up *Upload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(up)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.file(up.ID) + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.file(up.ID))
+}
+
+// Delete removes the upload state from disk
+func (s *FileUploadStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.file(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// uploadLocks serializes the requests for the same upload. The lock of an upload is removed
+// once no request holds it or waits for it.
+type uploadLocks struct {
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+// uploadLock is the lock of an upload, refs counts the requests holding it or waiting for it
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (ul *uploadLocks) lock(id string) func() {
+	ul.mu.Lock()
+
+	if ul.locks == nil {
+		ul.locks = make(map[string]*uploadLock)
+	}
+
+	l, ok := ul.locks[id]
+	if !ok {
+		l = new(uploadLock)
+		ul.locks[id] = l
+	}
+
+	l.refs++
+
+	ul.mu.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+
+		ul.mu.Lock()
+		defer ul.mu.Unlock()
+
+		if l.refs--; l.refs == 0 {
+			delete(ul.locks, id)
+		}
+	}
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma separated "key base64value" pairs
+func parseUploadMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, " ", 2)
+
+		if len(kv) == 1 {
+			meta[kv[0]] = ""
+			continue
+		}
+
+		val, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Upload-Metadata value for key %v", kv[0])
+		}
+
+		meta[kv[0]] = string(val)
+	}
+
+	return meta, nil
+}
+
+// parseUploadChecksum parses the tus Upload-Checksum header, "algorithm base64digest"
+func parseUploadChecksum(header string) (hash.Hash, []byte, error) {
+	split := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(split) != 2 {
+		return nil, nil, fmt.Errorf("Invalid Upload-Checksum header")
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(split[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid Upload-Checksum digest")
+	}
+
+	switch strings.ToLower(split[0]) {
+	case "md5":
+		return md5.New(), digest, nil
+	case "sha1":
+		return sha1.New(), digest, nil
+	case "sha256":
+		return sha256.New(), digest, nil
+	}
+
+	return nil, nil, fmt.Errorf("Unsupported Upload-Checksum algorithm %v", split[0])
+}
+
+// checksumMatches compares an expected checksum, in any of the forms accepted by the upload
+// endpoint ("md5:<hex>", "<hex>", "sha2:<base64>"...), to the checksum iRODS computed for the object
+func checksumMatches(expected string, actual string) bool {
+	want, err := checksum.Parse(expected)
+	if err != nil {
+		return false
+	}
+
+	got, err := checksum.Parse(actual)
+
+	return err == nil && got.Equal(want)
+}
+
+func (handler *HttpHandler) tusHeaders() {
+	h := handler.response.Header()
+
+	h.Set("Tus-Resumable", TusVersion)
+	h.Set("Cache-Control", "no-store")
+}
+
+func (handler *HttpHandler) tusError(status int, msg string) {
+	handler.tusHeaders()
+
+	if status >= 500 {
+		log.Print(msg)
+	}
+
+	http.Error(handler.response, msg, status)
+}
+
+// TusUpload is the entry point for resumable uploads into col. The tus protocol is used:
+//
+// OPTIONS ?tus=1 - discover the supported protocol version and extensions
+//
+// POST ?tus=1 - create an upload. Upload-Length is required, Upload-Metadata may contain "filename" and "checksum"
+//
+// HEAD ?tus={id} - fetch Upload-Offset, used by clients to resume
+//
+// PATCH ?tus={id} - append a chunk at Upload-Offset
+//
+// GET ?tus={id} - JSON progress of the upload
+//
+// DELETE ?tus={id} - cancel the upload, removing the partial data object
+func (handler *HttpHandler) TusUpload(col *Collection) {
+	req := handler.request
+	id := handler.query.Get("tus")
+
+	if req.Method == "OPTIONS" {
+		handler.tusHeaders()
+		handler.response.Header().Set("Tus-Version", TusVersion)
+		handler.response.Header().Set("Tus-Extension", TusExtensions)
+		handler.response.Header().Set("Tus-Checksum-Algorithm", TusChecksums)
+		handler.response.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r := req.Header.Get("Tus-Resumable"); r != "" && r != TusVersion {
+		handler.response.Header().Set("Tus-Version", TusVersion)
+		handler.tusError(http.StatusPreconditionFailed, "Unsupported Tus-Resumable version")
+		return
+	}
+
+	if req.Method == "POST" {
+		handler.createUpload(col)
+		return
+	}
+
+	// Unknown uploads are rejected before taking a lock
+	if up, err := handler.uploads.Get(id); err != nil || up.Collection != col.Path() {
+		handler.tusError(http.StatusNotFound, "Upload not found")
+		return
+	}
+
+	unlock := handler.uploadLocks.lock(id)
+	defer unlock()
+
+	// The upload is read again, the request holding the lock before may have changed or finished it
+	up, err := handler.uploads.Get(id)
+	if err != nil {
+		handler.tusError(http.StatusNotFound, "Upload not found")
+		return
+	}
+
+	switch req.Method {
+	case "HEAD":
+		handler.tusHeaders()
+		handler.response.Header().Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+		handler.response.Header().Set("Upload-Length", strconv.FormatInt(up.Length, 10))
+		handler.response.WriteHeader(http.StatusOK)
+	case "GET":
+		handler.serveUploadProgress(up)
+	case "PATCH":
+		handler.patchUpload(col, up)
+	case "DELETE":
+		handler.cancelUpload(col, up)
+	default:
+		handler.tusError(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (handler *HttpHandler) createUpload(col *Collection) {
+	req := handler.request
+
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		handler.tusError(http.StatusBadRequest, "Invalid or missing Upload-Length header")
+		return
+	}
+
+	meta, err := parseUploadMetadata(req.Header.Get("Upload-Metadata"))
+	if err != nil {
+		handler.tusError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := meta["filename"]
+	if name == "" {
+		name = meta["name"]
+	}
+
+	if name == "" || strings.Contains(name, "/") {
+		handler.tusError(http.StatusBadRequest, "Upload-Metadata must contain a valid filename")
+		return
+	}
+
+	if col.Exists(name) {
+		handler.tusError(http.StatusConflict, fmt.Sprintf("%v already exists in %v", name, col.Path()))
+		return
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	up := &Upload{
+		ID:         id,
+		Collection: col.Path(),
+		Name:       name,
+		PartPath:   col.Path() + "/." + name + "." + id + ".part",
+		Length:     length,
+		Checksum:   meta["checksum"],
+		Metadata:   meta,
+		CreateTime: time.Now(),
+		ModifyTime: time.Now(),
+	}
+
+	if _, err := col.CreateDataObj(DataObjOptions{
+		Name: filepath.Base(up.PartPath),
+		Size: length,
+	}); err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := handler.uploads.Create(up); err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handler.tusHeaders()
+	handler.response.Header().Set("Location", handler.entryURL(col.Path(), true)+"?tus="+id)
+	handler.response.Header().Set("Upload-Offset", "0")
+	handler.response.WriteHeader(http.StatusCreated)
+
+	// Zero length uploads are complete as soon as they're created
+	if up.Done() {
+		if err := handler.completeUpload(col, up); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+func (handler *HttpHandler) patchUpload(col *Collection, up *Upload) {
+	req := handler.request
+
+	if req.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		handler.tusError(http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+		return
+	}
+
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset != up.Offset {
+		handler.tusError(http.StatusConflict, fmt.Sprintf("Upload-Offset mismatch, expected %v", up.Offset))
+		return
+	}
+
+	if req.ContentLength > 0 && offset+req.ContentLength > up.Length {
+		handler.tusError(http.StatusRequestEntityTooLarge, "Chunk exceeds Upload-Length")
+		return
+	}
+
+	var (
+		body     io.Reader = io.LimitReader(req.Body, up.Length-offset)
+		chkHash  hash.Hash
+		expected []byte
+	)
+
+	if h := req.Header.Get("Upload-Checksum"); h != "" {
+		if chkHash, expected, err = parseUploadChecksum(h); err != nil {
+			handler.tusError(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		body = io.TeeReader(body, chkHash)
+	}
+
+	obj, err := col.Con().DataObject(up.PartPath)
+	if err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer obj.Close()
+
+	if err := obj.OpenRW(); err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := obj.LSeek(offset); err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	buf := make([]byte, uploadChunkSize)
+	written := offset
+
+	for {
+		n, rErr := io.ReadFull(body, buf)
+
+		if n > 0 {
+			if wErr := obj.WriteBytes(buf[:n]); wErr != nil {
+				handler.tusError(http.StatusInternalServerError, wErr.Error())
+				return
+			}
+
+			written += int64(n)
+
+			// Without a checksum, record progress as we go so an interrupted chunk can be resumed
+			if chkHash == nil {
+				up.Offset = written
+				up.ModifyTime = time.Now()
+
+				if err := handler.uploads.Update(up); err != nil {
+					handler.tusError(http.StatusInternalServerError, err.Error())
+					return
+				}
+			}
+		}
+
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if rErr != nil {
+			// The client went away, keep whatever made it to iRODS
+			log.Print(rErr)
+			return
+		}
+	}
+
+	if chkHash != nil {
+		if string(chkHash.Sum(nil)) != string(expected) {
+			// Offset isn't advanced, the chunk will be overwritten on retry
+			handler.tusError(StatusChecksumMismatch, "Checksum mismatch")
+			return
+		}
+
+		up.Offset = written
+		up.ModifyTime = time.Now()
+
+		if err := handler.uploads.Update(up); err != nil {
+			handler.tusError(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if up.Done() {
+		if err := obj.Close(); err != nil {
+			handler.tusError(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if err := handler.completeUpload(col, up); err != nil {
+			handler.tusError(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	handler.tusHeaders()
+	handler.response.Header().Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	handler.response.WriteHeader(http.StatusNoContent)
+}
+
+// completeUpload verifies the checksum of the partial data object and moves it into place
+func (handler *HttpHandler) completeUpload(col *Collection, up *Upload) error {
+	obj, err := col.Con().DataObject(up.PartPath)
+	if err != nil {
+		return err
+	}
+
+	if up.Checksum != "" {
+		chksum, err := obj.Chksum()
+		if err != nil {
+			return err
+		}
+
+		if !checksumMatches(up.Checksum, chksum) {
+			if dErr := obj.Delete(false); dErr != nil {
+				log.Print(dErr)
+			}
+
+			if dErr := handler.uploads.Delete(up.ID); dErr != nil {
+				log.Print(dErr)
+			}
+
+			return newError(Fatal, -1, fmt.Sprintf("Upload %v checksum mismatch: expected %v, got %v", up.Name, up.Checksum, chksum))
+		}
+	}
+
+	if err := obj.Rename(up.Name); err != nil {
+		return err
+	}
+
+	if err := col.Refresh(); err != nil {
+		log.Print(err)
+	}
+
+	return handler.uploads.Delete(up.ID)
+}
+
+func (handler *HttpHandler) cancelUpload(col *Collection, up *Upload) {
+	if obj, err := col.Con().DataObject(up.PartPath); err == nil {
+		if er := obj.Delete(false); er != nil {
+			handler.tusError(http.StatusInternalServerError, er.Error())
+			return
+		}
+	}
+
+	if err := handler.uploads.Delete(up.ID); err != nil {
+		handler.tusError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handler.tusHeaders()
+	handler.response.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *HttpHandler) serveUploadProgress(up *Upload) {
+	handler.tusHeaders()
+	handler.response.Header().Set("Content-type", "application/json")
+
+	jsonBytes, _ := json.Marshal(map[string]interface{}{
+		"id":      up.ID,
+		"name":    up.Name,
+		"offset":  up.Offset,
+		"length":  up.Length,
+		"percent": up.Percent(),
+		"done":    up.Done(),
+	})
+
+	handler.response.Write(jsonBytes)
+}
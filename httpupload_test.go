@@ -0,0 +1,142 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseUploadMetadata(t *testing.T) {
+	meta, err := parseUploadMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==,is_confidential, checksum bWQ1OmFiYw==")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta["filename"] != "world_domination_plan.pdf" {
+		t.Errorf("Expected string 'world_domination_plan.pdf', got '%s'", meta["filename"])
+	}
+
+	if v, ok := meta["is_confidential"]; !ok || v != "" {
+		t.Errorf("Expected empty is_confidential key, got '%s'", v)
+	}
+
+	if meta["checksum"] != "md5:abc" {
+		t.Errorf("Expected string 'md5:abc', got '%s'", meta["checksum"])
+	}
+
+	if _, err := parseUploadMetadata("filename !!!"); err == nil {
+		t.Errorf("Expected error for invalid base64 value")
+	}
+}
+
+func TestParseUploadChecksum(t *testing.T) {
+	sum := sha1.Sum([]byte("hello"))
+
+	h, digest, err := parseUploadChecksum("sha1 " + base64.StdEncoding.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Write([]byte("hello"))
+
+	if string(h.Sum(nil)) != string(digest) {
+		t.Errorf("Expected sha1 digest to match")
+	}
+
+	if _, _, err := parseUploadChecksum("crc32 AAAA"); err == nil {
+		t.Errorf("Expected error for unsupported algorithm")
+	}
+}
+
+func TestChecksumMatches(t *testing.T) {
+	md5Sum := "5eb63bbbe01eeed093cb22bb8f5acdc3"
+	sha2Sum := "sha2:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="
+
+	if !checksumMatches("md5:5EB63BBBE01EEED093CB22BB8F5ACDC3", md5Sum) || !checksumMatches(sha2Sum, sha2Sum) {
+		t.Errorf("Expected checksums to match")
+	}
+
+	if checksumMatches(md5Sum, sha2Sum) || checksumMatches("md5:abc", md5Sum) {
+		t.Errorf("Expected checksums not to match")
+	}
+}
+
+func TestUploadStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorods-uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore, err := NewFileUploadStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, store := range []UploadStore{NewMemoryUploadStore(), fileStore} {
+		if err := store.Create(&Upload{ID: "abc", Name: "test.txt", Length: 10}); err != nil {
+			t.Fatal(err)
+		}
+
+		up, err := store.Get("abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		up.Offset = 5
+
+		if err := store.Update(up); err != nil {
+			t.Fatal(err)
+		}
+
+		if up, _ = store.Get("abc"); up.Offset != 5 || up.Percent() != 50 {
+			t.Errorf("Expected offset 5, got %v", up.Offset)
+		}
+
+		if err := store.Delete("abc"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.Get("abc"); err != ErrUploadNotFound {
+			t.Errorf("Expected ErrUploadNotFound, got %v", err)
+		}
+	}
+}
+
+func TestUploadLocks(t *testing.T) {
+	var (
+		ul      uploadLocks
+		wg      sync.WaitGroup
+		holders int32
+	)
+
+	for inx := 0; inx < 8; inx++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			unlock := ul.lock("abc")
+			defer unlock()
+
+			if n := atomic.AddInt32(&holders, 1); n != 1 {
+				t.Errorf("%v requests hold the lock", n)
+			}
+
+			atomic.AddInt32(&holders, -1)
+		}()
+	}
+
+	wg.Wait()
+
+	if len(ul.locks) != 0 {
+		t.Errorf("Locks left after the requests: %v", ul.locks)
+	}
+}
@@ -0,0 +1,383 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize is the number of entries shown per page of a collection listing when FSOptions.PageSize is not set
+const DefaultPageSize = 100
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed assets
+var assetFS embed.FS
+
+// assetModTime is reported as the Last-Modified time of embedded assets, which carry no timestamp of their own
+var assetModTime = time.Now()
+
+// CollectionView is the view model passed to the collection template of the FileServer.
+// Custom templates set with FSOptions.Template are executed with a *CollectionView as dot.
+type CollectionView struct {
+	// Path is the iRODS path of the collection, Name its last segment
+	Path string
+	Name string
+
+	// URL is the file server URL of the collection, ParentURL that of its parent
+	// collection and RootURL that of the served root. ParentURL is empty at the root.
+	URL       string
+	ParentURL string
+	RootURL   string
+
+	// Breadcrumbs links every collection from the root of the file server down to this one
+	Breadcrumbs []Breadcrumb
+
+	// Entries holds the current page of sub collections followed by data objects
+	Entries []ViewEntry
+
+	// Readme is the rendered README.md found among Entries, ReadmeName its name
+	Readme     template.HTML
+	ReadmeName string
+
+	// Permissions is the access the connected user has on the collection
+	Permissions ViewPermissions
+
+	// AccessLevels are the names of the levels offered in the ACL form, from the lowest to the highest, see AccessLevels()
+	AccessLevels []string
+
+	// Username is the connected user, Users and Groups the principals offered in the ACL form
+	Username string
+	Users    []string
+	Groups   []string
+
+	Pagination Pagination
+
+	// AssetURL is the URL prefix of the bundled assets, e.g. {{.AssetURL}}gorods.css
+	AssetURL string
+
+	// SearchURL is the URL of the search page for this collection
+	SearchURL string
+
+	// Download is true when data objects are served as attachments
+	Download bool
+
+	col *Collection
+}
+
+// Con returns the connection of the collection.
+//
+// Deprecated: it keeps the templates of earlier versions, executed with the *Collection, working.
+// Use Username, Users and Groups.
+func (view *CollectionView) Con() *Connection {
+	return view.col.Con()
+}
+
+// Collections returns the sub collections of the current page.
+//
+// Deprecated: it keeps the templates of earlier versions, executed with the *Collection, working.
+// Use Entries.
+func (view *CollectionView) Collections() (IRodsObjs, error) {
+	return view.col.Collections()
+}
+
+// DataObjs returns the data objects of the current page.
+//
+// Deprecated: it keeps the templates of earlier versions, executed with the *Collection, working.
+// Use Entries.
+func (view *CollectionView) DataObjs() (IRodsObjs, error) {
+	return view.col.DataObjs()
+}
+
+// legacyFuncs returns the headerLinks, usersJSON and groupsJSON functions of the templates of
+// earlier versions, bound to the view
+func (view *CollectionView) legacyFuncs() template.FuncMap {
+	return template.FuncMap{
+		"headerLinks": func() []map[string]string {
+			links := make([]map[string]string, 0, len(view.Breadcrumbs))
+
+			for _, crumb := range view.Breadcrumbs {
+				links = append(links, map[string]string{"name": crumb.Name, "url": crumb.URL})
+			}
+
+			return links
+		},
+		"usersJSON": func() []string {
+			return append([]string{}, view.Users...)
+		},
+		"groupsJSON": func() []string {
+			return append([]string{}, view.Groups...)
+		},
+	}
+}
+
+// Breadcrumb is a single link in CollectionView.Breadcrumbs
+type Breadcrumb struct {
+	Name string
+	URL  string
+}
+
+// ViewEntry is a sub collection or data object listed in a CollectionView. PreviewURL is set
+// for data objects that can be previewed, ThumbnailURL for images.
+type ViewEntry struct {
+	Name         string
+	Path         string
+	URL          string
+	PreviewURL   string
+	ThumbnailURL string
+	IsCollection bool
+	Size         int64
+	ModifyTime   time.Time
+}
+
+// PrettySize formats the size of the entry for display, e.g. "1.5 MiB"
+func (e ViewEntry) PrettySize() string {
+	return prettySize(e.Size)
+}
+
+// ViewPermissions describes the access level the connected user has on a collection.
+// Read, Write and Own are cumulative: Own implies Write, which implies Read.
+type ViewPermissions struct {
+	AccessLevel string
+	Read        bool
+	Write       bool
+	Own         bool
+
+	// Explanation tells what grants the access level, see Connection.EffectiveAccess
+	Explanation string
+}
+
+// Pagination describes the page of a collection listing shown by a CollectionView.
+// Page is 1-based. PrevURL and NextURL are empty on the first and last page.
+type Pagination struct {
+	Page    int
+	Pages   int
+	PerPage int
+	Total   int
+	PrevURL string
+	NextURL string
+}
+
+// TemplateFuncs returns the functions available to the built-in templates. Add them to custom
+// templates that want to use them, with template.New(name).Funcs(gorods.TemplateFuncs()).
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"prettySize": prettySize,
+		"add": func(a, b int) int {
+			return a + b
+		},
+
+		// Deprecated: use .Breadcrumbs, .Users and .Groups. The functions of the templates of
+		// earlier versions are bound to the listing when it's rendered.
+		"headerLinks": func() []map[string]string { return nil },
+		"usersJSON":   func() []string { return nil },
+		"groupsJSON":  func() []string { return nil },
+	}
+}
+
+// DefaultCollectionTemplate returns a new copy of the built-in collection template
+func DefaultCollectionTemplate() *template.Template {
+	return template.Must(template.New("collection.html").Funcs(TemplateFuncs()).ParseFS(templateFS, "templates/collection.html"))
+}
+
+var searchTemplate = template.Must(template.New("search.html").Funcs(TemplateFuncs()).ParseFS(templateFS, "templates/search.html"))
+
+// assetURL returns the URL prefix that serves the bundled assets
+func (handler *HttpHandler) assetURL() string {
+	return handler.opts.StripPrefix + "?asset="
+}
+
+// ServeAsset serves a file bundled in the assets directory. Assets are requested from the
+// root of the file server with ?asset=<name>.
+func (handler *HttpHandler) ServeAsset(name string) {
+	name = path.Clean("/" + name)
+
+	b, err := fs.ReadFile(assetFS, "assets"+name)
+	if err != nil {
+		handler.Serve404()
+		return
+	}
+
+	handler.response.Header().Set("Cache-Control", "public, max-age=86400")
+
+	http.ServeContent(handler.response, handler.request, name, assetModTime, bytes.NewReader(b))
+}
+
+// entryURL maps an iRODS path below the served root to its URL in the file server
+func (handler *HttpHandler) entryURL(p string, isCollection bool) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(p, handler.handlerPath), "/")
+
+	u := handler.opts.StripPrefix + (&url.URL{Path: rel}).EscapedPath()
+
+	if isCollection && rel != "" {
+		u += "/"
+	}
+
+	return u
+}
+
+// viewPermissions returns the effective access of the connected user on col, granted directly, through one of their
+// groups, by ownership or by the rodsadmin status
+func viewPermissions(col *Collection) ViewPermissions {
+	var perms ViewPermissions
+
+	con := col.Con()
+
+	access, err := con.EffectiveAccess(col.Path(), con.Options.Username)
+	if err != nil {
+		return perms
+	}
+
+	perms.AccessLevel = getTypeString(access.Level)
+	perms.Read = access.Allows(Read)
+	perms.Write = access.Allows(Write)
+	perms.Own = access.Allows(Own)
+	perms.Explanation = strings.Join(access.Explanation, "; ")
+
+	return perms
+}
+
+// collectionView builds the view model of col, reading the page requested by the "page" query parameter
+func (handler *HttpHandler) collectionView(col *Collection) (*CollectionView, error) {
+	con := col.Con()
+
+	view := &CollectionView{
+		Path:      col.Path(),
+		Name:      col.Name(),
+		URL:       handler.entryURL(col.Path(), true),
+		RootURL:   handler.entryURL(handler.handlerPath, true),
+		Username:  con.Options.Username,
+		AssetURL:  handler.assetURL(),
+		SearchURL: "?search=1",
+		Download:  handler.opts.Download,
+		col:       col,
+	}
+
+	if handler.openPath != handler.handlerPath {
+		view.ParentURL = handler.entryURL(path.Dir(handler.openPath), true)
+
+		p := strings.TrimPrefix(handler.openPath, handler.handlerPath+"/")
+		frags := strings.Split(p, "/")
+
+		for i := range frags {
+			view.Breadcrumbs = append(view.Breadcrumbs, Breadcrumb{
+				Name: frags[i],
+				URL:  handler.entryURL(handler.handlerPath+"/"+strings.Join(frags[0:i+1], "/"), true),
+			})
+		}
+	}
+
+	perPage := handler.opts.PageSize
+	if perPage <= 0 {
+		perPage = DefaultPageSize
+	}
+
+	page, _ := strconv.Atoi(handler.query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	info, err := col.ReadCollectionOpts(CollectionReadOpts{
+		Limit:  perPage,
+		Offset: (page - 1) * perPage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view.Pagination = Pagination{
+		Page:    page,
+		PerPage: perPage,
+		Total:   info.Total,
+		Pages:   (info.Total + perPage - 1) / perPage,
+	}
+
+	if page > 1 {
+		view.Pagination.PrevURL = "?page=" + strconv.Itoa(page-1)
+	}
+
+	if page < view.Pagination.Pages {
+		view.Pagination.NextURL = "?page=" + strconv.Itoa(page+1)
+	}
+
+	objs, err := col.All()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		if obj.Type() == CollectionType && obj.Name() == ThumbnailCollection {
+			continue
+		}
+
+		entry := ViewEntry{
+			Name:         obj.Name(),
+			Path:         obj.Path(),
+			IsCollection: obj.Type() == CollectionType,
+			ModifyTime:   obj.ModifyTime(),
+		}
+
+		entry.URL = handler.entryURL(entry.Path, entry.IsCollection)
+
+		if !entry.IsCollection {
+			entry.Size = obj.Size()
+
+			if kind := previewKind(entry.Name); kind != "" {
+				entry.PreviewURL = entry.URL + "?preview=1"
+
+				if kind == previewImage {
+					entry.ThumbnailURL = entry.URL + "?thumbnail=" + strconv.Itoa(ThumbnailSizes[0])
+				}
+			}
+		}
+
+		view.Entries = append(view.Entries, entry)
+	}
+
+	// Collections first, both in name order
+	sort.SliceStable(view.Entries, func(i, j int) bool {
+		a, b := view.Entries[i], view.Entries[j]
+
+		if a.IsCollection != b.IsCollection {
+			return a.IsCollection
+		}
+
+		return a.Name < b.Name
+	})
+
+	handler.readme(col, view)
+
+	view.Permissions = viewPermissions(col)
+
+	for _, level := range AccessLevels() {
+		if level != Null {
+			view.AccessLevels = append(view.AccessLevels, getTypeString(level))
+		}
+	}
+
+	if usrs, err := con.Users(); err == nil {
+		for _, u := range usrs {
+			view.Users = append(view.Users, u.Name())
+		}
+	}
+
+	if grps, err := con.Groups(); err == nil {
+		for _, g := range grps {
+			view.Groups = append(view.Groups, g.Name())
+		}
+	}
+
+	return view, nil
+}
@@ -0,0 +1,137 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultCollectionTemplate(t *testing.T) {
+	view := &CollectionView{
+		Path:        "/tempZone/home/rods/gorods",
+		URL:         "/irods/gorods/",
+		RootURL:     "/irods/",
+		ParentURL:   "/irods/",
+		AssetURL:    "/irods/?asset=",
+		Username:    "rods",
+		Breadcrumbs: []Breadcrumb{{"gorods", "/irods/gorods/"}},
+		Entries: []ViewEntry{
+			{Name: "sub", URL: "/irods/gorods/sub/", IsCollection: true},
+			{Name: "<b>.txt", URL: "/irods/gorods/%3Cb%3E.txt", Size: 2048, ModifyTime: time.Now()},
+		},
+		Permissions: ViewPermissions{AccessLevel: "own", Read: true, Write: true, Own: true},
+		Pagination:  Pagination{Page: 2, Pages: 3, PrevURL: "?page=1", NextURL: "?page=3"},
+	}
+
+	var out bytes.Buffer
+
+	if err := DefaultCollectionTemplate().Execute(&out, view); err != nil {
+		t.Fatal(err)
+	}
+
+	html := out.String()
+
+	for _, expected := range []string{"2.0 KiB", "Page 2 of 3", "/irods/?asset=gorods.css", "upload-btn", "&lt;b&gt;.txt"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected collection view to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(html, "https://") {
+		t.Errorf("Expected collection view not to load remote assets")
+	}
+
+	view.Permissions = ViewPermissions{AccessLevel: "read", Read: true}
+	out.Reset()
+
+	if err := DefaultCollectionTemplate().Execute(&out, view); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out.String(), "upload-btn") {
+		t.Errorf("Expected upload button to be hidden without write access")
+	}
+}
+
+func TestCollectionViewTemplate(t *testing.T) {
+	if _, err := NewFileServer(FSOptions{CollectionView: "{{ .Name "}); err == nil {
+		t.Fatal("Expected an invalid CollectionView to fail")
+	}
+
+	rec := httptest.NewRecorder()
+	FileServer(FSOptions{CollectionView: "{{ .Name "}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for an invalid CollectionView, got %v", rec.Code)
+	}
+
+	// A template of earlier versions, with the deprecated functions
+	h, err := NewFileServer(FSOptions{CollectionView: `{{range headerLinks}}<a href="{{ index . "url" }}">{{ index . "name" }}</a>{{end}} {{ usersJSON }}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := &CollectionView{
+		Breadcrumbs: []Breadcrumb{{"gorods", "/irods/gorods/"}},
+		Users:       []string{"rods"},
+	}
+
+	var out bytes.Buffer
+
+	tpl, _ := h.(*HandlerFactory).tpl.Clone()
+	if err := tpl.Funcs(view.legacyFuncs()).Execute(&out, view); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), `<a href="/irods/gorods/">gorods</a>`) || !strings.Contains(out.String(), "rods") {
+		t.Errorf("Unexpected legacy rendering %q", out.String())
+	}
+}
+
+func TestServeAsset(t *testing.T) {
+	for name, code := range map[string]int{"gorods.css": 200, "gorods.js": 200, "../httpview.go": 404, "missing.js": 404} {
+		rec := httptest.NewRecorder()
+
+		handler := &HttpHandler{
+			response: rec,
+			request:  httptest.NewRequest("GET", "/?asset="+name, nil),
+		}
+
+		handler.ServeAsset(name)
+
+		if rec.Code != code {
+			t.Errorf("Expected %v for asset %q, got %v", code, name, rec.Code)
+		}
+
+		if code == http.StatusOK && rec.Body.Len() == 0 {
+			t.Errorf("Expected asset %q to have content", name)
+		}
+	}
+}
+
+func TestEntryURL(t *testing.T) {
+	handler := &HttpHandler{
+		opts:        FSOptions{StripPrefix: "/irods/"},
+		handlerPath: "/tempZone/home/rods",
+	}
+
+	tests := map[string]string{
+		"/tempZone/home/rods":                "/irods/",
+		"/tempZone/home/rods/a b/c#d.txt":    "/irods/a%20b/c%23d.txt",
+		"/tempZone/home/rods/sub/collection": "/irods/sub/collection/",
+	}
+
+	for p, expected := range tests {
+		isCol := !strings.HasSuffix(p, ".txt")
+
+		if u := handler.entryURL(p, isCol); u != expected {
+			t.Errorf("Expected %v for %v, got %v", expected, p, u)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package policyfile reads the policies of packages aclpolicy and replpolicy: JSON, or YAML with
+// gopkg.in/yaml.v2 in builds with the gorods_yaml tag.
+package policyfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Policy is a decoded policy, checked by Parse once decoded
+type Policy interface {
+	Validate() error
+}
+
+// unmarshalYAML decodes YAML data in builds with the gorods_yaml tag, it's nil otherwise
+var unmarshalYAML func(data []byte, v interface{}) error
+
+// Parse decodes data into policy and validates it. Data starting with '{' is JSON, anything
+// else YAML. Unknown fields are an error.
+func Parse(data []byte, policy Policy) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(policy); err != nil {
+			return err
+		}
+	} else if unmarshalYAML == nil {
+		return fmt.Errorf("not a JSON policy, YAML policies need the gorods_yaml build tag")
+	} else if err := unmarshalYAML(data, policy); err != nil {
+		return err
+	}
+
+	return policy.Validate()
+}
+
+// Load reads the file name and parses it into policy, errors are prefixed with name
+func Load(name string, policy Policy) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err := Parse(data, policy); err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package policyfile
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testPolicy struct {
+	Rules []struct {
+		Path string `yaml:"path" json:"path"`
+	} `yaml:"rules" json:"rules"`
+}
+
+func (policy *testPolicy) Validate() error {
+	for _, rule := range policy.Rules {
+		if !strings.HasPrefix(rule.Path, "/") {
+			return errors.New("relative path " + rule.Path)
+		}
+	}
+
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	var policy testPolicy
+
+	if err := Parse([]byte(` {"rules": [{"path": "/tempZone/a"}]}`), &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(policy.Rules) != 1 || policy.Rules[0].Path != "/tempZone/a" {
+		t.Fatalf("Unexpected policy %+v", policy)
+	}
+
+	for _, bad := range []string{
+		`{"rules": [{"path": "a"}]}`,
+		`{"rules": [{"path": "/tempZone/a", "recurse": true}]}`,
+		`{"rules": [`,
+	} {
+		if err := Parse([]byte(bad), new(testPolicy)); err == nil {
+			t.Errorf("Parsed invalid policy %q", bad)
+		}
+	}
+
+	if err := Parse([]byte("rules: []\n"), new(testPolicy)); unmarshalYAML == nil && err == nil {
+		t.Error("Parsed a YAML policy without the gorods_yaml tag")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "policy.json")
+
+	if err := ioutil.WriteFile(name, []byte(`{"rules": [{"path": "a"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Load(name, new(testPolicy)); err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+		t.Fatalf("Expected an error prefixed with the file name, got %v", err)
+	}
+
+	if err := Load(filepath.Join(dir, "missing.json"), new(testPolicy)); !os.IsNotExist(err) {
+		t.Fatalf("Expected a missing file, got %v", err)
+	}
+}
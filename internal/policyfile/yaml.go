@@ -0,0 +1,13 @@
+//go:build gorods_yaml
+// +build gorods_yaml
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package policyfile
+
+import "gopkg.in/yaml.v2"
+
+func init() {
+	unmarshalYAML = yaml.UnmarshalStrict
+}
@@ -0,0 +1,22 @@
+//go:build gorods_yaml
+// +build gorods_yaml
+
+package policyfile
+
+import "testing"
+
+func TestParseYAML(t *testing.T) {
+	var policy testPolicy
+
+	if err := Parse([]byte("rules:\n- path: /tempZone/a\n- {path: /tempZone/b}\n"), &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(policy.Rules) != 2 || policy.Rules[1].Path != "/tempZone/b" {
+		t.Fatalf("Unexpected policy %+v", policy)
+	}
+
+	if err := Parse([]byte("rules:\n- path: /tempZone/a\n  recurse: true\n"), new(testPolicy)); err == nil {
+		t.Fatal("Parsed a policy with an unknown field")
+	}
+}
@@ -0,0 +1,113 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package irodsfs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Granular access levels of iRODS 4.3. Read and Write are read_object and modify_object. The
+// values are those of the gorods constants with the same names.
+const (
+	ReadMetadata   = 22
+	CreateMetadata = 23
+	ModifyMetadata = 24
+	DeleteMetadata = 25
+	CreateObject   = 26
+	DeleteObject   = 27
+)
+
+// AccessLevels lists the access levels from the lowest to the highest, each grants the levels
+// before it. Servers before iRODS 4.3 only know Null, Read, Write and Own.
+var AccessLevels = []int{
+	Null,
+	ReadMetadata,
+	Read,
+	CreateMetadata,
+	ModifyMetadata,
+	DeleteMetadata,
+	CreateObject,
+	Write,
+	DeleteObject,
+	Own,
+}
+
+// accessNames are the names of the access levels in iRODS 4.3
+var accessNames = map[int]string{
+	Null:           "null",
+	ReadMetadata:   "read_metadata",
+	Read:           "read_object",
+	CreateMetadata: "create_metadata",
+	ModifyMetadata: "modify_metadata",
+	DeleteMetadata: "delete_metadata",
+	CreateObject:   "create_object",
+	Write:          "modify_object",
+	DeleteObject:   "delete_object",
+	Own:            "own",
+}
+
+// accessRank returns the position of level in AccessLevels, or -1
+func accessRank(level int) int {
+	for rank, l := range AccessLevels {
+		if l == level {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+// IsAccessLevel reports whether level is one of AccessLevels
+func IsAccessLevel(level int) bool {
+	return accessRank(level) >= 0
+}
+
+// CompareAccess returns -1, 0 or 1 when a grants less than, the same as, or more than b
+func CompareAccess(a int, b int) int {
+	ra, rb := accessRank(a), accessRank(b)
+
+	switch {
+	case ra < rb:
+		return -1
+	case ra > rb:
+		return 1
+	}
+
+	return 0
+}
+
+// AccessName returns the iRODS 4.3 name of an access level: null, read_metadata, read_object,
+// create_metadata, modify_metadata, delete_metadata, create_object, modify_object,
+// delete_object or own
+func AccessName(level int) string {
+	if name, ok := accessNames[level]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", level)
+}
+
+// ParseAccess returns the access level named name. It accepts the iRODS 4.3 names, those of
+// the catalog before 4.3 ("read object", "modify object") and the ichmod names read and write.
+func ParseAccess(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case "read":
+		return Read, nil
+	case "write":
+		return Write, nil
+	}
+
+	name = strings.Replace(name, " ", "_", -1)
+
+	for level, n := range accessNames {
+		if n == name {
+			return level, nil
+		}
+	}
+
+	return Null, fmt.Errorf("unknown access level %q", name)
+}
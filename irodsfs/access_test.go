@@ -0,0 +1,59 @@
+package irodsfs_test
+
+import (
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func TestParseAccess(t *testing.T) {
+	tests := map[string]int{
+		"null":            irodsfs.Null,
+		"read":            irodsfs.Read,
+		"read object":     irodsfs.Read,
+		"read_object":     irodsfs.Read,
+		"Modify Object":   irodsfs.Write,
+		"write":           irodsfs.Write,
+		"read_metadata":   irodsfs.ReadMetadata,
+		"delete metadata": irodsfs.DeleteMetadata,
+		"create_object":   irodsfs.CreateObject,
+		" own ":           irodsfs.Own,
+	}
+
+	for name, want := range tests {
+		if level, err := irodsfs.ParseAccess(name); err != nil || level != want {
+			t.Errorf("ParseAccess(%q) = %v, %v, want %v", name, level, err, want)
+		}
+	}
+
+	if _, err := irodsfs.ParseAccess("admin"); err == nil {
+		t.Error("Parsed an unknown level")
+	}
+
+	for _, level := range irodsfs.AccessLevels {
+		if parsed, err := irodsfs.ParseAccess(irodsfs.AccessName(level)); err != nil || parsed != level {
+			t.Errorf("AccessName(%v) = %q doesn't parse back", level, irodsfs.AccessName(level))
+		}
+	}
+}
+
+func TestCompareAccess(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want int
+	}{
+		{irodsfs.Own, irodsfs.Write, 1},
+		{irodsfs.ReadMetadata, irodsfs.Read, -1},
+		{irodsfs.CreateObject, irodsfs.Write, -1},
+		{irodsfs.DeleteObject, irodsfs.Write, 1},
+		{irodsfs.DeleteObject, irodsfs.Own, -1},
+		{irodsfs.ModifyMetadata, irodsfs.ModifyMetadata, 0},
+		{irodsfs.Null, irodsfs.ReadMetadata, -1},
+	}
+
+	for _, test := range tests {
+		if got := irodsfs.CompareAccess(test.a, test.b); got != test.want {
+			t.Errorf("CompareAccess(%v, %v) = %v, want %v", irodsfs.AccessName(test.a), irodsfs.AccessName(test.b), got, test.want)
+		}
+	}
+}
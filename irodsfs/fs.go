@@ -0,0 +1,239 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package irodsfs defines interfaces for the operations of an iRODS zone on paths: stat, list,
+// open, read/write, mkdir, remove, rename, metadata, ACLs, queries and replicas. Code written against
+// these interfaces works with any implementation:
+//
+//	gorods.Connection.Filesystem() // the iRODS C API, through cgo
+//	native.Conn                    // the iRODS wire protocol, in pure Go
+//	gorodstest.Connection          // an in-memory zone, for unit tests
+package irodsfs
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Object and principal types. The values are those of the gorods constants with the same names.
+const (
+	DataObjType    = 0
+	CollectionType = 1
+	UserType       = 5
+	AdminType      = 6
+	GroupAdminType = 7
+	GroupType      = 8
+)
+
+// Access levels, ordered so that a level grants every level below it. The values are those of
+// the gorods constants with the same names. iRODS 4.3 adds the granular levels between them,
+// compare levels with CompareAccess.
+const (
+	Null  = 12
+	Read  = 13
+	Write = 14
+	Own   = 15
+)
+
+// ObjInfo describes a collection or data object. Size and Checksum are those of the newest
+// good replica of data objects. Owner is name#zone.
+type ObjInfo struct {
+	Id         int64
+	Path       string
+	Name       string
+	Type       int
+	Size       int64
+	Checksum   string
+	Owner      string
+	CreateTime time.Time
+	ModifyTime time.Time
+}
+
+// IsDir reports whether the ObjInfo describes a collection
+func (info ObjInfo) IsDir() bool {
+	return info.Type == CollectionType
+}
+
+// Replica statuses, as reported by DATA_REPL_STATUS. The values are those of the gorods
+// constants StaleReplica, GoodReplica...
+const (
+	Stale        = 0
+	Good         = 1
+	Intermediate = 2
+	ReadLocked   = 3
+	WriteLocked  = 4
+)
+
+// Replica describes one copy of a data object on a resource
+type Replica struct {
+	Num          int
+	Resource     string
+	RescHier     string
+	Status       int
+	Size         int64
+	Checksum     string
+	PhysicalPath string
+	ModifyTime   time.Time
+}
+
+// AVU is an attribute, value, units triple attached to a collection or data object
+type AVU struct {
+	Attribute string
+	Value     string
+	Units     string
+}
+
+// ACL is the access level of a user or group on a collection or data object. Principal is
+// name#zone, Type is UserType, AdminType, GroupAdminType or GroupType.
+type ACL struct {
+	Principal   string
+	Type        int
+	AccessLevel int
+}
+
+// File is an opened data object
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Seeker
+	io.Closer
+}
+
+// FS is the file system view of an iRODS zone, paths are absolute iRODS paths
+type FS interface {
+	// Stat describes the collection or data object at p
+	Stat(p string) (ObjInfo, error)
+
+	// List describes the collections and data objects in the collection p
+	List(p string) ([]ObjInfo, error)
+
+	// Open opens the data object p. flag takes the access modes and flags of os.OpenFile:
+	// os.O_RDONLY, os.O_WRONLY or os.O_RDWR combined with os.O_CREATE, os.O_EXCL,
+	// os.O_TRUNC and os.O_APPEND.
+	Open(p string, flag int) (File, error)
+
+	// Mkdir creates the collection p, and its missing parents when recursive is set (imkdir -p)
+	Mkdir(p string, recursive bool) error
+
+	// Remove deletes the data object or collection p without moving it to the trash (irm -f {-r})
+	Remove(p string, recursive bool) error
+
+	// Rename moves the data object or collection src to dest (imv)
+	Rename(src string, dest string) error
+}
+
+// MetaFS manages the AVUs of collections and data objects
+type MetaFS interface {
+	// Meta returns the AVUs of p (imeta ls)
+	Meta(p string) ([]AVU, error)
+
+	// AddMeta attaches an AVU to p (imeta add)
+	AddMeta(p string, avu AVU) error
+
+	// DeleteMeta removes the AVUs of p with the attribute attr
+	DeleteMeta(p string, attr string) error
+}
+
+// ACLFS manages the permissions of collections and data objects
+type ACLFS interface {
+	// ACL returns the access control list of p (ils -A)
+	ACL(p string) ([]ACL, error)
+
+	// Chmod sets the access level of a user or group on p, Null removes its access (ichmod)
+	Chmod(p string, principal string, accessLevel int, recursive bool) error
+
+	// Inheritance reports whether ACL inheritance is enabled on the collection p
+	Inheritance(p string) (bool, error)
+
+	// SetInheritance enables or disables ACL inheritance on the collection p (ichmod inherit)
+	SetInheritance(p string, inherit bool, recursive bool) error
+}
+
+// QueryFS searches the catalog
+type QueryFS interface {
+	// QueryMeta returns the collections and data objects with AVUs matching qString,
+	// using the syntax of imeta qu: "attr op value [and attr op value]..."
+	QueryMeta(qString string) ([]ObjInfo, error)
+
+	// IQuest runs a GenQuery written in the iquest language, rows are keyed by column name
+	IQuest(query string, upperCase bool) ([]map[string]string, error)
+}
+
+// ReplicaFS manages the replicas of data objects. It isn't part of Filesystem, implementations
+// that support it can be found with a type assertion.
+type ReplicaFS interface {
+	// Replicas returns the replicas of the data object p, ordered by number (ils -L)
+	Replicas(p string) ([]Replica, error)
+
+	// OpenReplica opens the replica numbered replNum of p, see FS.Open
+	OpenReplica(p string, replNum int, flag int) (File, error)
+
+	// ChecksumReplica computes and registers the checksum of a replica (ichksum -f -n)
+	ChecksumReplica(p string, replNum int) (string, error)
+
+	// Replicate copies p to resource (irepl -R)
+	Replicate(p string, resource string) error
+
+	// Trim removes the replica numbered replNum of p (itrim -N 1 -n)
+	Trim(p string, replNum int) error
+
+	// PhysicalMove moves the replica of p on the resource src to the resource dest (iphymv)
+	PhysicalMove(p string, src string, dest string) error
+
+	// VerifyReplica reads the data of the replica numbered replNum of p on its resource,
+	// without changing the catalog (ichksum -K)
+	VerifyReplica(p string, replNum int) (Fixity, error)
+}
+
+// ErrChecksumMismatch is returned by ReplicaFS.VerifyReplica when the server only reports
+// that the data of a replica doesn't match its registered checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Fixity is the state of the data of a replica on its resource. Checksum is computed with the
+// scheme of the registered checksum, it is empty when the implementation can only compare
+// checksums on the server and the replica has none.
+type Fixity struct {
+	Size     int64
+	Checksum string
+}
+
+// RegisterFS registers files already stored on a resource, without copying them. Like
+// ReplicaFS, it isn't part of Filesystem.
+type RegisterFS interface {
+	// Register registers the file physPath, on the server of the resource, as the data object
+	// p or as a new replica of it (ireg)
+	Register(physPath string, p string, opts RegisterOptions) error
+
+	// Unregister removes the replica numbered replNum of p from the catalog, or p with all its
+	// replicas when replNum is -1, leaving the files in place (iunreg)
+	Unregister(p string, replNum int) error
+}
+
+// RegisterOptions tune RegisterFS.Register
+type RegisterOptions struct {
+	// Resource holds the file, the default resource when empty
+	Resource string
+
+	// Replica registers the file as a new replica of the existing data object (ireg --repl)
+	Replica bool
+
+	// Checksum computes and registers the checksum of the file on the server (ireg -k)
+	Checksum bool
+
+	// Verify is a checksum the server verifies the file against before registering it, with
+	// its scheme (ireg -K)
+	Verify string
+
+	// Force registers the file over an existing data object (ireg -f)
+	Force bool
+}
+
+// Filesystem is the complete set of operations on an iRODS zone
+type Filesystem interface {
+	FS
+	MetaFS
+	ACLFS
+	QueryFS
+}
@@ -0,0 +1,108 @@
+package irodsfs
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// SkipDir is returned by a WalkFunc to skip the collection it was called with
+var SkipDir = errors.New("skip this collection")
+
+// WalkFunc is called by Walk for every collection and data object. err is the error listing
+// the collection p, in which case the function decides whether the walk continues.
+type WalkFunc func(p string, info ObjInfo, err error) error
+
+// Walk calls fn for root and everything below it, in lexical order of paths within a
+// collection. Collections are visited before their content.
+func Walk(fsys FS, root string, fn WalkFunc) error {
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, info, err)
+	} else {
+		err = walk(fsys, root, info, fn)
+	}
+
+	if err == SkipDir {
+		return nil
+	}
+
+	return err
+}
+
+func walk(fsys FS, p string, info ObjInfo, fn WalkFunc) error {
+	if !info.IsDir() {
+		return fn(p, info, nil)
+	}
+
+	children, err := fsys.List(p)
+
+	if fnErr := fn(p, info, err); fnErr != nil || err != nil {
+		return fnErr
+	}
+
+	for _, child := range children {
+		childPath := path.Join(p, child.Name)
+
+		if err := walk(fsys, childPath, child, fn); err != nil {
+			if err == SkipDir && child.IsDir() {
+				continue
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadFile returns the content of the data object p
+func ReadFile(fsys FS, p string) ([]byte, error) {
+	file, err := fsys.Open(p, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
+
+// WriteFile stores data as the data object p, creating or truncating it
+func WriteFile(fsys FS, p string, data []byte) error {
+	file, err := fsys.Open(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Copy copies the content of the data object src of srcFS to dest of destFS, which may be
+// different zones or implementations
+func Copy(destFS FS, dest string, srcFS FS, src string) (int64, error) {
+	in, err := srcFS.Open(src, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := destFS.Open(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+
+	return n, out.Close()
+}
@@ -0,0 +1,80 @@
+package irodsfs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func testFS(t *testing.T) irodsfs.Filesystem {
+	srv := gorodstest.NewServer("tempZone")
+
+	con, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return con
+}
+
+func TestWalk(t *testing.T) {
+	fsys := testFS(t)
+
+	fsys.Mkdir("/tempZone/home/rods/a/b", true)
+	fsys.Mkdir("/tempZone/home/rods/skip", true)
+
+	for _, p := range []string{"/tempZone/home/rods/a/1.txt", "/tempZone/home/rods/a/b/2.txt", "/tempZone/home/rods/skip/3.txt"} {
+		if err := irodsfs.WriteFile(fsys, p, []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var visited []string
+
+	err := irodsfs.Walk(fsys, "/tempZone/home/rods", func(p string, info irodsfs.ObjInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Name == "skip" {
+			return irodsfs.SkipDir
+		}
+
+		visited = append(visited, p)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"/tempZone/home/rods",
+		"/tempZone/home/rods/a",
+		"/tempZone/home/rods/a/b",
+		"/tempZone/home/rods/a/b/2.txt",
+		"/tempZone/home/rods/a/1.txt",
+	}
+
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("Visited %v", visited)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := testFS(t)
+	dest := testFS(t)
+
+	irodsfs.WriteFile(src, "/tempZone/home/rods/in.txt", []byte("content"))
+
+	if n, err := irodsfs.Copy(dest, "/tempZone/home/rods/out.txt", src, "/tempZone/home/rods/in.txt"); err != nil || n != 7 {
+		t.Fatalf("Copied %v bytes: %v", n, err)
+	}
+
+	data, err := irodsfs.ReadFile(dest, "/tempZone/home/rods/out.txt")
+	if err != nil || string(data) != "content" {
+		t.Fatalf("Read %q: %v", data, err)
+	}
+}
@@ -0,0 +1,353 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// renderMarkdown converts a Markdown document to HTML. It supports the common subset used in
+// READMEs: ATX and setext headings, paragraphs, block quotes, ordered and unordered lists, fenced
+// and indented code blocks, horizontal rules, emphasis, code spans, links and images. Raw HTML
+// in the source is escaped, and links with schemes other than http, https and mailto are dropped.
+func renderMarkdown(src []byte) template.HTML {
+	text := strings.Replace(string(src), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\x00", "", -1)
+	text = strings.Replace(text, "\t", "    ", -1)
+
+	var out bytes.Buffer
+
+	renderMarkdownBlocks(&out, strings.Split(text, "\n"))
+
+	return template.HTML(out.String())
+}
+
+var (
+	mdHeading     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	mdRule        = regexp.MustCompile(`^ {0,3}((\*\s*){3,}|(-\s*){3,}|(_\s*){3,})$`)
+	mdFence       = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([^`\\s]*)")
+	mdBullet      = regexp.MustCompile(`^ {0,3}([-*+])\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	mdSetextLine  = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	mdIndentCode  = regexp.MustCompile(`^    (.*)$`)
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]*)\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]*)\)`)
+	mdAutoLink    = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	mdStrong      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	mdEmphasis    = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*|(?:^|\b)_(\S(?:[^_]*?\S)?)_(?:\b|$)`)
+	mdCodeSpan    = regexp.MustCompile("(`+)(.+?)(`+)")
+	mdEscape      = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!>])")
+	mdPlaceholder = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// startsBlock reports whether line interrupts a paragraph
+func startsBlock(line string) bool {
+	return mdHeading.MatchString(line) || mdRule.MatchString(line) || mdFence.MatchString(line) ||
+		mdBullet.MatchString(line) || mdOrdered.MatchString(line) || mdQuote.MatchString(line)
+}
+
+func renderMarkdownBlocks(out *bytes.Buffer, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+
+		case mdFence.MatchString(line):
+			m := mdFence.FindStringSubmatch(line)
+			fence := m[1]
+
+			i++
+			start := i
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				i++
+			}
+
+			if m[2] != "" {
+				out.WriteString(`<pre><code class="language-` + html.EscapeString(m[2]) + `">`)
+			} else {
+				out.WriteString("<pre><code>")
+			}
+			out.WriteString(html.EscapeString(strings.Join(lines[start:i], "\n")))
+			out.WriteString("</code></pre>\n")
+
+			// Skip the closing fence
+			i++
+
+		case mdIndentCode.MatchString(line):
+			var code []string
+
+			for i < len(lines) && (mdIndentCode.MatchString(lines[i]) || isBlank(lines[i])) {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+				i++
+			}
+
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+
+			out.WriteString("<h" + level + ">" + renderMarkdownInline(m[2]) + "</h" + level + ">\n")
+			i++
+
+		case mdRule.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case mdQuote.MatchString(line):
+			var quoted []string
+
+			for i < len(lines) && mdQuote.MatchString(lines[i]) {
+				quoted = append(quoted, mdQuote.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+
+			out.WriteString("<blockquote>\n")
+			renderMarkdownBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+
+		case mdBullet.MatchString(line) || mdOrdered.MatchString(line):
+			i = renderMarkdownList(out, lines, i)
+
+		default:
+			para := []string{strings.TrimSpace(line)}
+			i++
+
+			for i < len(lines) && !isBlank(lines[i]) {
+				if m := mdSetextLine.FindStringSubmatch(lines[i]); m != nil {
+					tag := "h2"
+					if m[1][0] == '=' {
+						tag = "h1"
+					}
+
+					out.WriteString("<" + tag + ">" + renderMarkdownInline(strings.Join(para, " ")) + "</" + tag + ">\n")
+					para = nil
+					i++
+					break
+				}
+
+				if startsBlock(lines[i]) {
+					break
+				}
+
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+
+			if para != nil {
+				out.WriteString("<p>" + renderMarkdownInline(strings.Join(para, "\n")) + "</p>\n")
+			}
+		}
+	}
+}
+
+// renderMarkdownList renders the list starting at lines[i] and returns the index of the first line after it
+func renderMarkdownList(out *bytes.Buffer, lines []string, i int) int {
+	ordered := mdOrdered.MatchString(lines[i])
+
+	item := mdBullet
+	tag := "ul"
+
+	if ordered {
+		item = mdOrdered
+		tag = "ol"
+
+		if n, _ := strconv.Atoi(mdOrdered.FindStringSubmatch(lines[i])[1]); n != 1 {
+			out.WriteString(`<ol start="` + strconv.Itoa(n) + `">` + "\n")
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+
+	// Items indented by two or more spaces belong to a nested list
+	isItem := func(line string) bool {
+		return item.MatchString(line) && !strings.HasPrefix(line, "  ")
+	}
+
+	for i < len(lines) && isItem(lines[i]) {
+		content := []string{item.FindStringSubmatch(lines[i])[2]}
+		i++
+
+		// Continuation lines are indented, or lazy paragraph continuations
+		for i < len(lines) && !isItem(lines[i]) {
+			if isBlank(lines[i]) {
+				if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "  ") {
+					content = append(content, "")
+					i++
+					continue
+				}
+				break
+			}
+
+			if !strings.HasPrefix(lines[i], "  ") && startsBlock(lines[i]) {
+				break
+			}
+
+			content = append(content, dedent(lines[i]))
+			i++
+		}
+
+		out.WriteString("<li>")
+
+		if len(content) == 1 {
+			out.WriteString(renderMarkdownInline(content[0]))
+		} else {
+			var inner bytes.Buffer
+			renderMarkdownBlocks(&inner, content)
+
+			// Items without blank lines are tight, their leading paragraph isn't wrapped in <p>
+			s := inner.String()
+
+			tight := true
+			for _, c := range content {
+				if c == "" {
+					tight = false
+				}
+			}
+
+			if end := strings.Index(s, "</p>\n"); tight && strings.HasPrefix(s, "<p>") && end >= 0 {
+				s = s[len("<p>"):end] + s[end+len("</p>\n"):]
+			}
+
+			out.WriteString(s)
+		}
+
+		out.WriteString("</li>\n")
+
+		// Blank lines between items keep the list going
+		for i+1 < len(lines) && isBlank(lines[i]) && isItem(lines[i+1]) {
+			i++
+		}
+	}
+
+	out.WriteString("</" + tag + ">\n")
+
+	return i
+}
+
+// dedent removes up to four leading spaces
+func dedent(line string) string {
+	for n := 0; n < 4 && strings.HasPrefix(line, " "); n++ {
+		line = line[1:]
+	}
+
+	return line
+}
+
+// safeURL returns the escaped url if it's relative or uses an allowed scheme, "" otherwise
+func safeURL(u string) string {
+	u = html.UnescapeString(u)
+
+	if i := strings.IndexAny(u, ":/?#"); i >= 0 && u[i] == ':' {
+		scheme := strings.ToLower(u[:i])
+
+		if scheme != "http" && scheme != "https" && scheme != "mailto" {
+			return ""
+		}
+	}
+
+	return html.EscapeString(u)
+}
+
+// renderMarkdownInline renders emphasis, code spans, links and images in a single block of text
+func renderMarkdownInline(text string) string {
+	var held []string
+
+	// NUL bytes delimit the placeholders of held fragments
+	text = strings.Replace(text, "\x00", "", -1)
+
+	hold := func(s string) string {
+		held = append(held, s)
+		return "\x00" + strconv.Itoa(len(held)-1) + "\x00"
+	}
+
+	// Code spans and escaped characters are taken literally
+	text = mdCodeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		sm := mdCodeSpan.FindStringSubmatch(m)
+		if sm[1] != sm[3] {
+			return m
+		}
+
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(sm[2])) + "</code>")
+	})
+
+	text = mdEscape.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(html.EscapeString(m[1:]))
+	})
+
+	text = html.EscapeString(text)
+
+	text = mdImage.ReplaceAllStringFunc(text, func(m string) string {
+		sm := mdImage.FindStringSubmatch(m)
+
+		if u := safeURL(sm[2]); u != "" {
+			return hold(`<img src="` + u + `" alt="` + sm[1] + `">`)
+		}
+
+		return sm[1]
+	})
+
+	text = mdLink.ReplaceAllStringFunc(text, func(m string) string {
+		sm := mdLink.FindStringSubmatch(m)
+
+		if u := safeURL(sm[2]); u != "" {
+			return `<a href="` + hold(u) + `">` + sm[1] + "</a>"
+		}
+
+		return sm[1]
+	})
+
+	text = mdAutoLink.ReplaceAllStringFunc(text, func(m string) string {
+		u := mdAutoLink.FindStringSubmatch(m)[1]
+
+		return `<a href="` + hold(u) + `">` + hold(u) + "</a>"
+	})
+
+	text = mdStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = mdEmphasis.ReplaceAllStringFunc(text, func(m string) string {
+		sm := mdEmphasis.FindStringSubmatch(m)
+		inner := sm[1] + sm[2]
+
+		// Keep the word boundaries matched around _emphasis_
+		prefix := m[:strings.IndexAny(m, "*_")]
+		suffix := m[strings.LastIndexAny(m, "*_")+1:]
+
+		return prefix + "<em>" + inner + "</em>" + suffix
+	})
+
+	// Restore held fragments in one pass. A fragment only holds placeholders of the fragments held
+	// before it, restored text is never scanned again.
+	var restore func(s string, limit int) string
+
+	restore = func(s string, limit int) string {
+		return mdPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+			n, err := strconv.Atoi(strings.Trim(m, "\x00"))
+			if err != nil || n >= limit {
+				return ""
+			}
+
+			return restore(held[n], n)
+		})
+	}
+
+	return restore(text, len(held))
+}
@@ -0,0 +1,61 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"# Title #", "<h1>Title</h1>\n"},
+		{"Title\n=====", "<h1>Title</h1>\n"},
+		{"Sub\n---", "<h2>Sub</h2>\n"},
+		{"one\ntwo\n\nthree", "<p>one\ntwo</p>\n<p>three</p>\n"},
+		{"**bold** and *em* and _em_ and `a*b*`", "<p><strong>bold</strong> and <em>em</em> and <em>em</em> and <code>a*b*</code></p>\n"},
+		{"snake_case_name", "<p>snake_case_name</p>\n"},
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"[docs](docs/index.md) ![logo](logo.png)", `<p><a href="docs/index.md">docs</a> <img src="logo.png" alt="logo"></p>` + "\n"},
+		{"[x](javascript:alert)", "<p>x</p>\n"},
+		{"<https://irods.org>", `<p><a href="https://irods.org">https://irods.org</a></p>` + "\n"},
+		{"\\*not em\\*", "<p>*not em*</p>\n"},
+		{"```go\nfmt.Println(\"<hi>\")\n```", `<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>` + "\n"},
+		{"    indented\n    code", "<pre><code>indented\ncode</code></pre>\n"},
+		{"- a\n- b\n  - c\n- d", "<ul>\n<li>a</li>\n<li>b<ul>\n<li>c</li>\n</ul>\n</li>\n<li>d</li>\n</ul>\n"},
+		{"3. x\n4. y", "<ol start=\"3\">\n<li>x</li>\n<li>y</li>\n</ol>\n"},
+		{"> quoted\n> text", "<blockquote>\n<p>quoted\ntext</p>\n</blockquote>\n"},
+		{"***", "<hr>\n"},
+		{"hello \x009\x00 world", "<p>hello 9 world</p>\n"},
+		{"`\x000\x00`", "<p><code>0</code></p>\n"},
+	}
+
+	for _, test := range tests {
+		if out := string(renderMarkdown([]byte(test.src))); out != test.expected {
+			t.Errorf("Rendering %q:\nexpected %q\ngot      %q", test.src, test.expected, out)
+		}
+	}
+
+	// Unterminated fences run to the end of the document
+	if out := string(renderMarkdown([]byte("```\ncode"))); !strings.Contains(out, "code") {
+		t.Errorf("Expected unterminated fence to render its contents, got %q", out)
+	}
+}
+
+func TestRenderMarkdownInlinePlaceholders(t *testing.T) {
+	// NUL bytes of the source can't forge placeholders
+	for src, expected := range map[string]string{
+		"hello \x009\x00 world":       "hello 9 world",
+		"`\x000\x00`":                 "<code>0</code>",
+		"`a` \x000\x00 `b`":           "<code>a</code> 0 <code>b</code>",
+		"![`x`](a.png) [`y`](b.html)": `<img src="a.png" alt="<code>x</code>"> <a href="b.html"><code>y</code></a>`,
+	} {
+		if out := renderMarkdownInline(src); out != expected {
+			t.Errorf("Rendering %q:\nexpected %q\ngot      %q", src, expected, out)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"path/filepath"
+	"unsafe"
+)
+
+func (m *Meta) cDelete() (*MetaCollection, error) {
+	mT := C.CString(m.getTypeRodsString())
+	path := C.CString(m.Parent.Obj.Path())
+	oa := C.CString(m.Attribute)
+	ov := C.CString(m.Value)
+	ou := C.CString(m.Units)
+
+	defer C.free(unsafe.Pointer(mT))
+	defer C.free(unsafe.Pointer(path))
+	defer C.free(unsafe.Pointer(oa))
+	defer C.free(unsafe.Pointer(ov))
+	defer C.free(unsafe.Pointer(ou))
+
+	var err *C.char
+
+	ccon := m.Parent.Con.GetCcon()
+
+	if status := C.gorods_rm_meta(mT, path, oa, ov, ou, ccon, &err); status < 0 {
+		m.Parent.Con.ReturnCcon(ccon)
+
+		return m.Parent, newError(Fatal, status, fmt.Sprintf("iRODS rm Meta Failed: %v, %v", m.Parent.Obj.Path(), C.GoString(err)))
+	}
+
+	m.Parent.Con.ReturnCcon(ccon)
+
+	m.Parent.Refresh()
+
+	return m.Parent, nil
+}
+
+func (m *Meta) cSetAll(attributeName string, value string, units string) (newMeta *Meta, e error) {
+	if attributeName != m.Attribute || value != m.Value || units != m.Units {
+		mT := C.CString(m.getTypeRodsString())
+		path := C.CString(m.Parent.Obj.Path())
+		oa := C.CString(m.Attribute)
+		ov := C.CString(m.Value)
+		ou := C.CString(m.Units)
+		na := C.CString(attributeName)
+		nv := C.CString(value)
+		nu := C.CString(units)
+
+		defer C.free(unsafe.Pointer(mT))
+		defer C.free(unsafe.Pointer(path))
+		defer C.free(unsafe.Pointer(oa))
+		defer C.free(unsafe.Pointer(ov))
+		defer C.free(unsafe.Pointer(ou))
+		defer C.free(unsafe.Pointer(na))
+		defer C.free(unsafe.Pointer(nv))
+		defer C.free(unsafe.Pointer(nu))
+
+		var err *C.char
+
+		ccon := m.Parent.Con.GetCcon()
+
+		if status := C.gorods_mod_meta(mT, path, oa, ov, ou, na, nv, nu, ccon, &err); status < 0 {
+			m.Parent.Con.ReturnCcon(ccon)
+			e = newError(Fatal, status, fmt.Sprintf("iRODS Set Meta Failed: %v, %v", m.Parent.Obj.Path(), C.GoString(err)))
+			return
+		}
+
+		m.Parent.Con.ReturnCcon(ccon)
+
+		m.Attribute = attributeName
+		m.Value = value
+		m.Units = units
+
+	}
+
+	newMeta = m
+
+	return
+}
+
+func (mc *MetaCollection) cReadMeta() error {
+	var (
+		err        *C.char
+		metaResult C.goRodsMetaResult_t
+	)
+
+	mc.Metas = make(Metas, 0)
+
+	name := C.CString(mc.Obj.Name())
+
+	defer C.free(unsafe.Pointer(name))
+
+	switch mc.Obj.Type() {
+	case DataObjType:
+
+		ccon := mc.Con.GetCcon()
+		defer mc.Con.ReturnCcon(ccon)
+
+		cwdGo := (mc.Obj.(*DataObj)).Col().Path()
+		cwd := C.CString(cwdGo)
+
+		defer C.free(unsafe.Pointer(cwd))
+
+		if status := C.gorods_meta_dataobj(name, cwd, &metaResult, ccon, &err); status != 0 {
+			if status == C.CAT_NO_ROWS_FOUND {
+				return nil
+			} else {
+				return newError(Fatal, status, fmt.Sprintf("iRODS Get Meta Failed: %v, %v, %v", cwdGo, C.GoString(err), status))
+			}
+		}
+	case CollectionType:
+
+		ccon := mc.Con.GetCcon()
+		defer mc.Con.ReturnCcon(ccon)
+
+		cwdGo := filepath.Dir(mc.Obj.Path())
+		cwd := C.CString(cwdGo)
+
+		defer C.free(unsafe.Pointer(cwd))
+
+		if status := C.gorods_meta_collection(name, cwd, &metaResult, ccon, &err); status != 0 {
+			if status == C.CAT_NO_ROWS_FOUND {
+				return nil
+			} else {
+				return newError(Fatal, status, fmt.Sprintf("iRODS Get Meta Failed: %v, %v, %v", cwdGo, C.GoString(err), status))
+			}
+		}
+	case ResourceType:
+
+	case ResourceGroupType:
+
+	case UserType, GroupType, AdminType, GroupAdminType:
+
+		gZone, zErr := mc.Con.LocalZone()
+		if zErr != nil {
+			return zErr
+		}
+
+		ccon := mc.Con.GetCcon()
+		defer mc.Con.ReturnCcon(ccon)
+
+		zone := C.CString(gZone.Name())
+		name := C.CString(mc.Obj.Name())
+		defer C.free(unsafe.Pointer(name))
+		defer C.free(unsafe.Pointer(zone))
+
+		if status := C.gorods_meta_user(name, zone, &metaResult, ccon, &err); status != 0 {
+			if status == C.CAT_NO_ROWS_FOUND {
+				return nil
+			} else {
+				return newError(Fatal, status, fmt.Sprintf("iRODS Get Meta Failed: %v, %v, %v", C.GoString(name), C.GoString(err), status))
+			}
+		}
+
+	default:
+		return newError(Fatal, -1, "unrecognized meta type constant")
+	}
+
+	size := int(metaResult.size)
+
+	slice := (*[1 << 30]C.goRodsMeta_t)(unsafe.Pointer(metaResult.metaArr))[:size:size]
+
+	for _, meta := range slice {
+
+		m := new(Meta)
+
+		m.Attribute = C.GoString(meta.name)
+		m.Value = C.GoString(meta.value)
+		m.Units = C.GoString(meta.units)
+		m.Parent = mc
+
+		mc.Metas = append(mc.Metas, m)
+	}
+
+	C.freeGoRodsMetaResult(&metaResult)
+
+	return nil
+}
+
+func (mc *MetaCollection) cAdd(m Meta) (*Meta, error) {
+	if er := mc.init(); er != nil {
+		return nil, er
+	}
+
+	if existingMeta, er := mc.Get(m.Attribute); er == nil {
+		if len(existingMeta) > 0 {
+			for _, am := range existingMeta {
+				if m.Value == am.Value {
+					return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Failed: Attribute + Value already exists"))
+				}
+			}
+		}
+	}
+
+	if m.Attribute != "" && m.Value != "" {
+		m.Parent = mc
+
+		mT := C.CString(m.getTypeRodsString())
+		path := C.CString(m.Parent.Obj.Path())
+		na := C.CString(m.Attribute)
+		nv := C.CString(m.Value)
+		nu := C.CString(m.Units)
+
+		defer C.free(unsafe.Pointer(mT))
+		defer C.free(unsafe.Pointer(path))
+		defer C.free(unsafe.Pointer(na))
+		defer C.free(unsafe.Pointer(nv))
+		defer C.free(unsafe.Pointer(nu))
+
+		var err *C.char
+
+		ccon := m.Parent.Con.GetCcon()
+
+		if status := C.gorods_add_meta(mT, path, na, nv, nu, ccon, &err); status < 0 {
+			m.Parent.Con.ReturnCcon(ccon)
+			return nil, newError(Fatal, status, fmt.Sprintf("iRODS Add Meta Failed: %v, %v", m.Parent.Obj.Path(), C.GoString(err)))
+		}
+
+		m.Parent.Con.ReturnCcon(ccon)
+
+		m.Parent.Refresh()
+
+	} else {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Failed: Please specify Attribute and Value fields"))
+	}
+
+	if attrs, er := m.Parent.Get(m.Attribute); er == nil {
+		if am := attrs.MatchOne(&m); am != nil {
+			return am, nil
+		}
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Error: Unable to locate added meta triple"))
+	} else {
+		return nil, er
+	}
+
+}
@@ -0,0 +1,139 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+import (
+	"fmt"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+func (mc *MetaCollection) fsReadMeta() error {
+	mc.Metas = make(Metas, 0)
+
+	switch mc.Obj.Type() {
+	case DataObjType, CollectionType:
+	case ResourceType, ResourceGroupType:
+		return nil
+	case UserType, GroupType, AdminType, GroupAdminType:
+		return unsupported("Get Meta of a user or group")
+	default:
+		return newError(Fatal, -1, "unrecognized meta type constant")
+	}
+
+	avus, err := mc.Con.fs.Meta(mc.Obj.Path())
+	if err != nil {
+		return newError(Fatal, -1, fmt.Sprintf("iRODS Get Meta Failed: %v, %v", mc.Obj.Path(), err))
+	}
+
+	for _, avu := range avus {
+		m := new(Meta)
+
+		m.Attribute = avu.Attribute
+		m.Value = avu.Value
+		m.Units = avu.Units
+		m.Parent = mc
+
+		mc.Metas = append(mc.Metas, m)
+	}
+
+	return nil
+}
+
+func (mc *MetaCollection) fsAdd(m Meta) (*Meta, error) {
+	if er := mc.init(); er != nil {
+		return nil, er
+	}
+
+	if existingMeta, er := mc.Get(m.Attribute); er == nil {
+		for _, am := range existingMeta {
+			if m.Value == am.Value {
+				return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Failed: Attribute + Value already exists"))
+			}
+		}
+	}
+
+	if m.Attribute == "" || m.Value == "" {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Failed: Please specify Attribute and Value fields"))
+	}
+
+	m.Parent = mc
+
+	if err := mc.Con.fs.AddMeta(mc.Obj.Path(), irodsfs.AVU{Attribute: m.Attribute, Value: m.Value, Units: m.Units}); err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Failed: %v, %v", mc.Obj.Path(), err))
+	}
+
+	mc.Refresh()
+
+	if attrs, er := mc.Get(m.Attribute); er == nil {
+		if am := attrs.MatchOne(&m); am != nil {
+			return am, nil
+		}
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Add Meta Error: Unable to locate added meta triple"))
+	} else {
+		return nil, er
+	}
+}
+
+// fsRemove removes the AVU of m with the RemoveMeta(p string, avu irodsfs.AVU) error method of the Filesystem
+// when it has one. MetaFS only deletes attributes, the other AVUs of the attribute are added back otherwise.
+func (m *Meta) fsRemove() error {
+	p := m.Parent.Obj.Path()
+	avu := irodsfs.AVU{Attribute: m.Attribute, Value: m.Value, Units: m.Units}
+
+	if remover, ok := m.Parent.Con.fs.(interface {
+		RemoveMeta(string, irodsfs.AVU) error
+	}); ok {
+		return remover.RemoveMeta(p, avu)
+	}
+
+	avus, err := m.Parent.Con.fs.Meta(p)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Parent.Con.fs.DeleteMeta(p, m.Attribute); err != nil {
+		return err
+	}
+
+	for _, other := range avus {
+		if other.Attribute == avu.Attribute && other != avu {
+			if err := m.Parent.Con.fs.AddMeta(p, other); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Meta) fsDelete() (*MetaCollection, error) {
+	if err := m.fsRemove(); err != nil {
+		return m.Parent, newError(Fatal, -1, fmt.Sprintf("iRODS rm Meta Failed: %v, %v", m.Parent.Obj.Path(), err))
+	}
+
+	m.Parent.Refresh()
+
+	return m.Parent, nil
+}
+
+func (m *Meta) fsSetAll(attributeName string, value string, units string) (*Meta, error) {
+	if attributeName != m.Attribute || value != m.Value || units != m.Units {
+		avu := irodsfs.AVU{Attribute: attributeName, Value: value, Units: units}
+
+		if err := m.fsRemove(); err != nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Set Meta Failed: %v, %v", m.Parent.Obj.Path(), err))
+		}
+
+		if err := m.Parent.Con.fs.AddMeta(m.Parent.Obj.Path(), avu); err != nil {
+			return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Set Meta Failed: %v, %v", m.Parent.Obj.Path(), err))
+		}
+
+		m.Attribute = attributeName
+		m.Value = value
+		m.Units = units
+	}
+
+	return m, nil
+}
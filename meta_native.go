@@ -0,0 +1,23 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package gorods
+
+func (m *Meta) cDelete() (*MetaCollection, error) {
+	return nil, errNoC
+}
+
+func (m *Meta) cSetAll(attributeName string, value string, units string) (newMeta *Meta, e error) {
+	return nil, errNoC
+}
+
+func (mc *MetaCollection) cReadMeta() error {
+	return errNoC
+}
+
+func (mc *MetaCollection) cAdd(m Meta) (*Meta, error) {
+	return nil, errNoC
+}
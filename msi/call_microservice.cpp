@@ -1,6 +1,12 @@
+//go:build cgo && !msifake
+// +build cgo,!msifake
+
 #include "rsModAVUMetadata.hpp"
 #include "irods_re_structs.hpp"
 #include "irods_ms_plugin.hpp"
+#include "rodsLog.h"
+#include "rcMisc.h"
+#include "fileLseek.h"
 
 int actionTableLookUp(irods::ms_table_entry&, char *action);
 
@@ -41,6 +47,38 @@ int call_microservice(msiCallInfo_t* callInfo, char** errStr) {
 	return status;
 }
 
+char* GetReiUsername(void* rei) {
+	ruleExecInfo_t* r = (ruleExecInfo_t*)rei;
+
+	if ( r == NULL || r->uoic == NULL ) {
+		return NULL;
+	}
+
+	return r->uoic->userName;
+}
+
+void ReportError(void* rei, int status, char* msg) {
+	ruleExecInfo_t* r = (ruleExecInfo_t*)rei;
+
+	rodsLog(LOG_ERROR, "%s", msg);
+
+	if ( r != NULL && r->rsComm != NULL ) {
+		addRErrorMsg(&r->rsComm->rError, status, msg);
+	}
+}
+
+// MoveParam hands the data of src over to dst, leaving src empty
+void MoveParam(msParam_t* dst, msParam_t* src) {
+	fillMsParam(dst, NULL, src->type, src->inOutStruct, src->inpOutBuf);
+
+	src->inOutStruct = NULL;
+	src->inpOutBuf = NULL;
+}
+
+msParam_t* GetMsParamListItem(msParam_t** list, int inx) {
+	return list[inx];
+}
+
 msParam_t** NewParamList(int len) {
 	msParam_t** paramArr;
 
@@ -51,53 +89,63 @@ msParam_t** NewParamList(int len) {
 
 msParam_t* NewParam(char* type) {
 	msParam_t* param = (msParam_t*)malloc(sizeof(msParam_t));
+	memset(param, 0, sizeof(msParam_t));
 
 	SetupParam(type, param);
 
 	return param;
 }
 
-char* GetKVPStr(msParam_t* param) {
-	char* str = (char*)malloc(sizeof(char) * MAX_NAME_LEN);
-
-	keyValPair_t* kvp = (keyValPair_t*)param->inOutStruct;
-
-	int i;
-	for ( i = 0; i < kvp->len; i++ ) {
-
-		strcat(str, kvp->keyWord[i]);
-		strcat(str, " = ");
-		strcat(str, kvp->value[i]);
-		strcat(str, "\n");
-	}
-
-	return str;
-
-}
-
 void ConvertParam(char* type, msParam_t** param) {
 	if ( !(*param) ) {
 		*param = (msParam_t*)malloc(sizeof(msParam_t));
-	}	
+		memset(*param, 0, sizeof(msParam_t));
+	}
 
 	SetupParam(type, *param);
 }
 
+// NewBlankParam allocates an untyped parameter, to be filled by a microservice
+msParam_t* NewBlankParam() {
+	msParam_t* param = (msParam_t*)malloc(sizeof(msParam_t));
+	memset(param, 0, sizeof(msParam_t));
+
+	return param;
+}
+
+// ParamStructSize returns the size of the inOutStruct allocated for params of the given type,
+// or 0 for types that don't have one (STR_MS_T is set directly, BUF_LEN_MS_T by fillBufLenInMsParam)
+size_t ParamStructSize(const char* type) {
+	if ( strcmp(type, KeyValPair_MS_T) == 0 ) return sizeof(keyValPair_t);
+	if ( strcmp(type, INT_MS_T) == 0 ) return sizeof(int);
+	if ( strcmp(type, BOOL_MS_T) == 0 ) return sizeof(int);
+	if ( strcmp(type, INT16_MS_T) == 0 ) return sizeof(short);
+	if ( strcmp(type, CHAR_MS_T) == 0 ) return sizeof(char);
+	if ( strcmp(type, DOUBLE_MS_T) == 0 ) return sizeof(rodsLong_t);
+	if ( strcmp(type, FLOAT_MS_T) == 0 ) return sizeof(float);
+	if ( strcmp(type, DataObjInp_MS_T) == 0 ) return sizeof(dataObjInp_t);
+	if ( strcmp(type, DataObjCopyInp_MS_T) == 0 ) return sizeof(dataObjCopyInp_t);
+	if ( strcmp(type, CollInp_MS_T) == 0 ) return sizeof(collInp_t);
+	if ( strcmp(type, ExecCmd_MS_T) == 0 ) return sizeof(execCmd_t);
+	if ( strcmp(type, ExecCmdOut_MS_T) == 0 ) return sizeof(execCmdOut_t);
+	if ( strcmp(type, RodsObjStat_MS_T) == 0 ) return sizeof(rodsObjStat_t);
+	if ( strcmp(type, StrArray_MS_T) == 0 ) return sizeof(strArray_t);
+	if ( strcmp(type, IntArray_MS_T) == 0 ) return sizeof(intArray_t);
+	if ( strcmp(type, GenQueryInp_MS_T) == 0 ) return sizeof(genQueryInp_t);
+	if ( strcmp(type, GenQueryOut_MS_T) == 0 ) return sizeof(genQueryOut_t);
+	if ( strcmp(type, DataObjInfo_MS_T) == 0 ) return sizeof(dataObjInfo_t);
+	if ( strcmp(type, DataObjLseekOut_MS_T) == 0 ) return sizeof(fileLseekOut_t);
+
+	return 0;
+}
+
 void SetupParam(char* type, msParam_t* param) {
-	if ( strcmp(type, KeyValPair_MS_T) == 0 ) {
-		keyValPair_t* data = (keyValPair_t*)malloc(sizeof(*data));
-		memset(data, 0, sizeof(*data));
-		
-		fillMsParam(param, NULL, type, data, NULL);
-	} else if ( strcmp(type, INT_MS_T) == 0 ) {
-		int* data = (int*)malloc(sizeof(int));
-		memset(data, 0, sizeof(int));
-		
-		fillMsParam(param, NULL, type, data, NULL);
-	} else if ( strcmp(type, DataObjInp_MS_T) == 0 ) {
-		dataObjInp_t* data = (dataObjInp_t*)malloc(sizeof(dataObjInp_t));
-		memset(data, 0, sizeof(dataObjInp_t));
-		
+	size_t size = ParamStructSize(type);
+
+	if ( size > 0 ) {
+		void* data = malloc(size);
+		memset(data, 0, size);
+
 		fillMsParam(param, NULL, type, data, NULL);
 	} else {
 		fillMsParam(param, NULL, type, NULL, NULL);
@@ -105,9 +153,16 @@ void SetupParam(char* type, msParam_t* param) {
 }
 
 void FreeMsParam(msParam_t* msParam) {
+	char* type = msParam->type;
+	void* data = msParam->inOutStruct;
 
-	if ( strcmp(msParam->type, KeyValPair_MS_T) == 0 ) {
-		keyValPair_t* kvp = (keyValPair_t*)msParam->inOutStruct;
+	if ( type == NULL || data == NULL ) {
+		free(msParam);
+		return;
+	}
+
+	if ( strcmp(type, KeyValPair_MS_T) == 0 ) {
+		keyValPair_t* kvp = (keyValPair_t*)data;
 
 		for ( int n = 0; n < kvp->len; n++ ) {
 			free(kvp->keyWord[n]);
@@ -117,12 +172,34 @@ void FreeMsParam(msParam_t* msParam) {
 		free(kvp->keyWord);
 		free(kvp->value);
 
-		free(kvp);
+	} else if ( strcmp(type, DataObjInp_MS_T) == 0 ) {
+		clearKeyVal(&((dataObjInp_t*)data)->condInput);
+	} else if ( strcmp(type, DataObjCopyInp_MS_T) == 0 ) {
+		clearKeyVal(&((dataObjCopyInp_t*)data)->srcDataObjInp.condInput);
+		clearKeyVal(&((dataObjCopyInp_t*)data)->destDataObjInp.condInput);
+	} else if ( strcmp(type, CollInp_MS_T) == 0 ) {
+		clearKeyVal(&((collInp_t*)data)->condInput);
+	} else if ( strcmp(type, ExecCmd_MS_T) == 0 ) {
+		clearKeyVal(&((execCmd_t*)data)->condInput);
+	} else if ( strcmp(type, ExecCmdOut_MS_T) == 0 ) {
+		free(((execCmdOut_t*)data)->stdoutBuf.buf);
+		free(((execCmdOut_t*)data)->stderrBuf.buf);
+	} else if ( strcmp(type, StrArray_MS_T) == 0 ) {
+		free(((strArray_t*)data)->value);
+	} else if ( strcmp(type, IntArray_MS_T) == 0 ) {
+		free(((intArray_t*)data)->value);
+	} else if ( strcmp(type, GenQueryInp_MS_T) == 0 ) {
+		clearGenQueryInp((genQueryInp_t*)data);
+	} else if ( strcmp(type, GenQueryOut_MS_T) == 0 ) {
+		clearGenQueryOut((genQueryOut_t*)data);
+	} else if ( strcmp(type, DataObjInfo_MS_T) == 0 ) {
+		clearKeyVal(&((dataObjInfo_t*)data)->condInput);
+	} else if ( strcmp(type, RodsObjStat_MS_T) == 0 ) {
+		free(((rodsObjStat_t*)data)->specColl);
+	}
 
-	} else if ( strcmp(msParam->type, STR_MS_T) == 0 ) {
-		if ( msParam->inOutStruct != NULL ) {
-			free(msParam->inOutStruct);
-		}
+	if ( strcmp(type, STR_MS_T) == 0 || ParamStructSize(type) > 0 ) {
+		free(data);
 	}
 
 	free(msParam);
@@ -10,10 +10,15 @@ typedef struct msiCallInfo_t {
 int call_microservice(msiCallInfo_t*, char**);
 msParam_t** NewParamList(int);
 msParam_t* NewParam(char* type);
+msParam_t* NewBlankParam();
 void SetMsParamListItem(msParam_t**, int, msParam_t*);
+msParam_t* GetMsParamListItem(msParam_t**, int);
+void MoveParam(msParam_t*, msParam_t*);
+char* GetReiUsername(void*);
+void ReportError(void*, int, char*);
 void FreeMsParam(msParam_t* msParam);
 char* GetMSParamType(msParam_t*);
 void ConvertParam(char*, msParam_t**);
 void SetupParam(char*, msParam_t*);
-char* GetKVPStr(msParam_t*);
+size_t ParamStructSize(const char*);
 bytesBuf_t* NewBytesBuff(int, void*);
@@ -0,0 +1,42 @@
+package msi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error is an error carrying an iRODS status code. Go microservices registered with msi.Register
+// return it to choose the status reported to the rule engine, other errors are reported as
+// SYS_INTERNAL_ERR.
+type Error struct {
+	Code    int
+	Message string
+}
+
+// Error returns the message of the error and its status code
+func (err *Error) Error() string {
+	return fmt.Sprintf("%v (status %v)", err.Message, err.Code)
+}
+
+// NewError creates an *Error with the given iRODS status code and a formatted message
+func NewError(code int, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// StatusCode returns the iRODS status code for err: SUCCESS for nil, the code of an
+// *Error (which may be wrapped), and SYS_INTERNAL_ERR otherwise
+func StatusCode(err error) int {
+	if err == nil {
+		return SUCCESS
+	}
+
+	var msiErr *Error
+	if errors.As(err, &msiErr) {
+		return msiErr.Code
+	}
+
+	return SYS_INTERNAL_ERR
+}
@@ -0,0 +1,443 @@
+package msi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultBufferSize is the size of the read and write buffers of an ObjFile when BufferSize is not set
+const DefaultBufferSize = 1024 * 1024
+
+// ObjFile provides buffered, seekable access to an iRODS data object through the msiDataObj*
+// microservices. It satisfies io.Reader, io.Writer, io.Seeker, io.ReaderAt and io.Closer, so it
+// can be used with bufio, archive/zip, image.Decode and friends.
+type ObjFile struct {
+	// BufferSize is the size of the read and write buffers, DefaultBufferSize if not set.
+	// Reads of at least BufferSize bytes bypass the buffer.
+	BufferSize int
+
+	path   string
+	fd     int
+	append bool
+	call   func(string, ...interface{}) error
+
+	mu sync.Mutex
+
+	// pos is the offset seen by the caller, serverPos the offset of the descriptor in iRODS.
+	// When rbuf holds data, serverPos is pos + len(rbuf). wbuf holds the data written at
+	// pos - len(wbuf) that wasn't sent yet. size is -1 until it's known.
+	pos       int64
+	serverPos int64
+	size      int64
+	rbuf      []byte
+	wbuf      []byte
+	closed    bool
+}
+
+// ObjReader is the former name of ObjFile
+type ObjReader = ObjFile
+
+// OpenObjFile opens the data object at irodsPath using msiDataObjOpen. The flag argument takes
+// the access mode and flags of os.OpenFile: os.O_RDONLY, os.O_WRONLY or os.O_RDWR combined with
+// os.O_CREATE, os.O_EXCL, os.O_TRUNC and os.O_APPEND. With os.O_APPEND, every write goes to
+// the end of the data object.
+func OpenObjFile(irodsPath string, flag int) (*ObjFile, error) {
+	return openObjFile(Call, irodsPath, flag)
+}
+
+// CreateObjFile creates or truncates the data object at irodsPath and opens it for reading and writing
+func CreateObjFile(irodsPath string) (*ObjFile, error) {
+	return OpenObjFile(irodsPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+// OpenObjFile opens a data object with the ruleExecInfo_t* of the running rule, see msi.OpenObjFile
+func (ctx *Context) OpenObjFile(irodsPath string, flag int) (*ObjFile, error) {
+	return openObjFile(ctx.Call, irodsPath, flag)
+}
+
+func openObjFile(call func(string, ...interface{}) error, irodsPath string, flag int) (*ObjFile, error) {
+	file := &ObjFile{
+		path:   irodsPath,
+		append: flag&os.O_APPEND != 0,
+		call:   call,
+		size:   -1,
+	}
+
+	inp := DataObjInp{
+		ObjPath:    irodsPath,
+		CreateMode: 0644,
+		OpenFlags:  flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR | os.O_CREATE | os.O_EXCL | os.O_TRUNC),
+	}
+
+	if err := call("msiDataObjOpen", inp, &file.fd); err != nil {
+		return nil, err
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		file.size = 0
+	}
+
+	return file, nil
+}
+
+// NewObjReader accepts an iRODS data object path string, opens a reference to the
+// object using msiDataObjOpen, and returns a read only *ObjFile
+func NewObjReader(irodsPath string) (*ObjReader, error) {
+	return OpenObjFile(irodsPath, os.O_RDONLY)
+}
+
+// NewObjReaderFromDesc creates a new *ObjFile from an existing INT_MS_T descriptor
+func NewObjReaderFromDesc(desc *Param) *ObjReader {
+	return &ObjFile{
+		fd:   desc.Int(),
+		call: Call,
+		size: -1,
+	}
+}
+
+// Path returns the path of the data object, which is empty for files created from a descriptor
+func (file *ObjFile) Path() string {
+	return file.path
+}
+
+// Desc returns the iRODS descriptor of the opened data object
+func (file *ObjFile) Desc() int {
+	return file.fd
+}
+
+func (file *ObjFile) bufferSize() int {
+	if file.BufferSize > 0 {
+		return file.BufferSize
+	}
+
+	return DefaultBufferSize
+}
+
+// lseek moves the descriptor with msiDataObjLseek, whence is one of io.SeekStart, io.SeekCurrent or io.SeekEnd
+func (file *ObjFile) lseek(offset int64, whence int) (int64, error) {
+	whenceStr := map[int]string{
+		io.SeekStart:   "SEEK_SET",
+		io.SeekCurrent: "SEEK_CUR",
+		io.SeekEnd:     "SEEK_END",
+	}[whence]
+
+	var newPos int64
+
+	if err := file.call("msiDataObjLseek", file.fd, strconv.FormatInt(offset, 10), whenceStr, &newPos); err != nil {
+		return 0, err
+	}
+
+	file.serverPos = newPos
+
+	return newPos, nil
+}
+
+// seekServer moves the descriptor to offset, if it isn't there already
+func (file *ObjFile) seekServer(offset int64) error {
+	if file.serverPos == offset {
+		return nil
+	}
+
+	_, err := file.lseek(offset, io.SeekStart)
+	return err
+}
+
+// flush writes the buffered data
+func (file *ObjFile) flush() error {
+	if len(file.wbuf) == 0 {
+		return nil
+	}
+
+	start := file.pos - int64(len(file.wbuf))
+
+	if file.append {
+		end, err := file.lseek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		start = end
+	} else if err := file.seekServer(start); err != nil {
+		return err
+	}
+
+	var written int
+
+	if err := file.call("msiDataObjWrite", file.fd, file.wbuf, &written); err != nil {
+		return err
+	}
+
+	file.serverPos = start + int64(written)
+	file.pos = file.serverPos
+
+	if file.size >= 0 && file.serverPos > file.size {
+		file.size = file.serverPos
+	}
+
+	provided := len(file.wbuf)
+	file.wbuf = file.wbuf[:0]
+
+	if written < provided {
+		return fmt.Errorf("Byte length mismatch, %v bytes provided but only %v written", provided, written)
+	}
+
+	return nil
+}
+
+// readServer reads up to n bytes at pos with msiDataObjRead
+func (file *ObjFile) readServer(n int) ([]byte, error) {
+	if err := file.seekServer(file.pos); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	if err := file.call("msiDataObjRead", file.fd, n, &data); err != nil {
+		return nil, err
+	}
+
+	file.serverPos += int64(len(data))
+
+	return data, nil
+}
+
+// Read reads up to len(data) bytes from the data object.
+// It satisfies the io.Reader interface.
+func (file *ObjFile) Read(data []byte) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	return file.read(data)
+}
+
+func (file *ObjFile) read(data []byte) (int, error) {
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	if err := file.flush(); err != nil {
+		return 0, err
+	}
+
+	if len(file.rbuf) == 0 {
+		size := file.bufferSize()
+		if len(data) >= size {
+			size = len(data)
+		}
+
+		read, err := file.readServer(size)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(read) == 0 {
+			return 0, io.EOF
+		}
+
+		file.rbuf = read
+	}
+
+	n := copy(data, file.rbuf)
+	file.rbuf = file.rbuf[n:]
+	file.pos += int64(n)
+
+	return n, nil
+}
+
+// ReadAt reads len(data) bytes at offset, without moving the offset used by Read and Write.
+// It satisfies the io.ReaderAt interface.
+func (file *ObjFile) ReadAt(data []byte, offset int64) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if offset < 0 {
+		return 0, fmt.Errorf("Negative offset %v", offset)
+	}
+
+	if err := file.flush(); err != nil {
+		return 0, err
+	}
+
+	saved := file.pos
+	defer func() {
+		file.pos = saved
+		file.rbuf = nil
+	}()
+
+	file.pos = offset
+	file.rbuf = nil
+
+	n := 0
+	for n < len(data) {
+		m, err := file.read(data[n:])
+		n += m
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Write writes len(data) bytes to the data object. Data is buffered, and sent to iRODS when
+// the buffer is full, on Flush, Seek, Read and Close.
+// It satisfies the io.Writer interface.
+func (file *ObjFile) Write(data []byte) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	// The read buffer is ahead of the caller, the next read starts at pos again
+	file.rbuf = nil
+
+	file.wbuf = append(file.wbuf, data...)
+	file.pos += int64(len(data))
+
+	if len(file.wbuf) >= file.bufferSize() {
+		if err := file.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+// Flush sends buffered writes to iRODS
+func (file *ObjFile) Flush() error {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	return file.flush()
+}
+
+// Seek sets the offset of the next Read or Write, interpreted according to whence: io.SeekStart,
+// io.SeekCurrent or io.SeekEnd. It returns the new offset.
+// It satisfies the io.Seeker interface.
+func (file *ObjFile) Seek(offset int64, whence int) (int64, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	if err := file.flush(); err != nil {
+		return 0, err
+	}
+
+	var abs int64
+
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = file.pos + offset
+	case io.SeekEnd:
+		size, err := file.sizeLocked()
+		if err != nil {
+			return 0, err
+		}
+		abs = size + offset
+	default:
+		return 0, fmt.Errorf("Invalid whence %v", whence)
+	}
+
+	if abs < 0 {
+		return 0, fmt.Errorf("Negative offset %v", abs)
+	}
+
+	// Keep reading from the buffer when seeking within it
+	if buffered := int64(len(file.rbuf)); abs >= file.pos && abs <= file.pos+buffered {
+		file.rbuf = file.rbuf[abs-file.pos:]
+	} else {
+		file.rbuf = nil
+	}
+
+	file.pos = abs
+
+	return abs, nil
+}
+
+// Size returns the size of the data object, including buffered writes
+func (file *ObjFile) Size() (int64, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if file.closed {
+		return 0, os.ErrClosed
+	}
+
+	if err := file.flush(); err != nil {
+		return 0, err
+	}
+
+	return file.sizeLocked()
+}
+
+func (file *ObjFile) sizeLocked() (int64, error) {
+	if file.size < 0 {
+		size, err := file.lseek(0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+
+		file.size = size
+	}
+
+	return file.size, nil
+}
+
+// Stat returns the catalog information of the data object using msiObjStat. Buffered writes are
+// sent first, but note that iRODS updates the size in the catalog when the object is closed.
+func (file *ObjFile) Stat() (RodsObjStat, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	var stat RodsObjStat
+
+	if file.path == "" {
+		return stat, fmt.Errorf("Unable to stat a data object opened from a descriptor")
+	}
+
+	if err := file.flush(); err != nil {
+		return stat, err
+	}
+
+	err := file.call("msiObjStat", file.path, &stat)
+
+	return stat, err
+}
+
+// Close sends buffered writes and calls msiDataObjClose on the opened data object handle.
+func (file *ObjFile) Close() error {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if file.closed {
+		return os.ErrClosed
+	}
+
+	flushErr := file.flush()
+
+	var status int
+	err := file.call("msiDataObjClose", file.fd, &status)
+
+	file.closed = true
+	file.rbuf = nil
+
+	if flushErr != nil {
+		return flushErr
+	}
+
+	return err
+}
@@ -0,0 +1,572 @@
+package msi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParamTyper is implemented by structs that marshal to a specific ParamType. The tagged fields
+// of the struct are mapped to the fields of the matching golang representation (DataObjInp,
+// CollInp, ...) by their msi tag, which holds the field name used by iRODS, e.g. `msi:"objPath"`.
+type ParamTyper interface {
+	ParamType() ParamType
+}
+
+// structTypes maps ParamTypes to their golang representation
+var structTypes = map[ParamType]reflect.Type{
+	DataObjInp_MS_T:     reflect.TypeOf(DataObjInp{}),
+	DataObjCopyInp_MS_T: reflect.TypeOf(DataObjCopyInp{}),
+	CollInp_MS_T:        reflect.TypeOf(CollInp{}),
+	ExecCmd_MS_T:        reflect.TypeOf(ExecCmd{}),
+	ExecCmdOut_MS_T:     reflect.TypeOf(ExecCmdOut{}),
+	RodsObjStat_MS_T:    reflect.TypeOf(RodsObjStat{}),
+	GenQueryInp_MS_T:    reflect.TypeOf(GenQueryInp{}),
+	GenQueryOut_MS_T:    reflect.TypeOf(GenQueryOut{}),
+	DataObjInfo_MS_T:    reflect.TypeOf(DataObjInfo{}),
+}
+
+// Marshal converts a golang value to a new *Param of the matching ParamType:
+//
+//	string                               STR_MS_T
+//	int, int32, uint32                   INT_MS_T
+//	int16                                INT16_MS_T
+//	int64                                DOUBLE_MS_T (rodsLong_t)
+//	byte                                 CHAR_MS_T
+//	float32, float64                     FLOAT_MS_T
+//	bool                                 BOOL_MS_T
+//	[]byte                               BUF_LEN_MS_T
+//	[]string                             StrArray_MS_T
+//	[]int                                IntArray_MS_T
+//	map[string]T                         KeyValPair_MS_T
+//	DataObjInp, CollInp, ...             DataObjInp_MS_T, CollInp_MS_T, ...
+//	structs implementing ParamTyper      the returned ParamType
+//	other structs                        KeyValPair_MS_T, keyed by msi tag or field name
+//
+// Pointers are dereferenced, and named types are converted by their underlying kind. Struct
+// fields tagged `msi:"-"` are skipped, and `msi:"name,omitempty"` skips zero values.
+func Marshal(v interface{}) (*Param, error) {
+	if p, ok := v.(*Param); ok {
+		if p == nil {
+			return nil, fmt.Errorf("msi: can't marshal a nil *Param")
+		}
+		return p, nil
+	}
+
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("msi: can't marshal a nil %v", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("msi: can't marshal nil")
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return NewParam(STR_MS_T).SetString(rv.String()), nil
+	case reflect.Int, reflect.Int32, reflect.Uint32:
+		return NewParam(INT_MS_T).SetInt(int(intValue(rv))), nil
+	case reflect.Int16:
+		return NewParam(INT16_MS_T).SetInt16(int16(rv.Int())), nil
+	case reflect.Int64:
+		return NewParam(DOUBLE_MS_T).SetInt64(rv.Int()), nil
+	case reflect.Uint8:
+		return NewParam(CHAR_MS_T).SetChar(byte(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewParam(FLOAT_MS_T).SetFloat(float32(rv.Float())), nil
+	case reflect.Bool:
+		return NewParam(BOOL_MS_T).SetBool(rv.Bool()), nil
+	case reflect.Slice:
+		return marshalSlice(rv)
+	case reflect.Map:
+		m, err := toStringMap(rv)
+		if err != nil {
+			return nil, err
+		}
+		return NewParam(KeyValPair_MS_T).SetKVP(m), nil
+	case reflect.Struct:
+		return marshalStruct(rv)
+	}
+
+	return nil, fmt.Errorf("msi: can't marshal %v", rv.Type())
+}
+
+func marshalSlice(rv reflect.Value) (*Param, error) {
+	switch rv.Type().Elem().Kind() {
+	case reflect.Uint8:
+		return NewParam(BUF_LEN_MS_T).SetBytes(rv.Bytes()), nil
+	case reflect.String:
+		strs := make([]string, rv.Len())
+		for i := range strs {
+			strs[i] = rv.Index(i).String()
+		}
+		return NewParam(StrArray_MS_T).SetStrArray(strs), nil
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint16, reflect.Uint32:
+		ints := make([]int, rv.Len())
+		for i := range ints {
+			ints[i] = int(intValue(rv.Index(i)))
+		}
+		return NewParam(IntArray_MS_T).SetIntArray(ints), nil
+	}
+
+	return nil, fmt.Errorf("msi: can't marshal %v", rv.Type())
+}
+
+func marshalStruct(rv reflect.Value) (*Param, error) {
+	t := ParamType(UNDEFINED_T)
+
+	for typ, st := range structTypes {
+		if rv.Type() == st {
+			t = typ
+		}
+	}
+
+	if typer, ok := rv.Interface().(ParamTyper); ok {
+		t = typer.ParamType()
+	} else if rv.CanAddr() {
+		if typer, ok := rv.Addr().Interface().(ParamTyper); ok {
+			t = typer.ParamType()
+		}
+	}
+
+	if t == UNDEFINED_T {
+		m, err := structToStringMap(rv)
+		if err != nil {
+			return nil, err
+		}
+		return NewParam(KeyValPair_MS_T).SetKVP(m), nil
+	}
+
+	st, ok := structTypes[t]
+	if !ok {
+		return nil, fmt.Errorf("msi: can't marshal structs to %v", t)
+	}
+
+	// Copy the tagged fields into the golang representation of the type
+	val := reflect.New(st).Elem()
+	if err := assign(val, rv); err != nil {
+		return nil, err
+	}
+
+	param := NewParam(t)
+
+	switch s := val.Interface().(type) {
+	case DataObjInp:
+		param.SetDataObjInpStruct(s)
+	case DataObjCopyInp:
+		param.SetDataObjCopyInp(s)
+	case CollInp:
+		param.SetCollInp(s)
+	case ExecCmd:
+		param.SetExecCmd(s)
+	case ExecCmdOut:
+		param.SetExecCmdOut(s)
+	case RodsObjStat:
+		param.SetRodsObjStat(s)
+	case GenQueryInp:
+		param.SetGenQueryInp(s)
+	case GenQueryOut:
+		param.SetGenQueryOut(s)
+	case DataObjInfo:
+		param.SetDataObjInfo(s)
+	}
+
+	return param, nil
+}
+
+// Unmarshal stores the value of param in the value pointed to by v. The golang representation
+// of the ParamType (see Marshal) is converted to the type of v: numbers and strings convert to
+// each other, maps and structs are matched by key and msi tag, and a slice of DataObjInfo can
+// be stored in a single DataObjInfo (the first replica).
+func Unmarshal(param *Param, v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("msi: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+
+	val, err := paramValue(param)
+	if err != nil {
+		return err
+	}
+
+	return assign(rv.Elem(), reflect.ValueOf(val))
+}
+
+// Value returns the golang representation of the value of the parameter, the same value
+// Unmarshal would assign, for example a string for STR_MS_T or a RodsObjStat for RodsObjStat_MS_T
+func (param *Param) Value() (interface{}, error) {
+	return paramValue(param)
+}
+
+// paramValue returns the golang representation of the value of param
+func paramValue(param *Param) (interface{}, error) {
+	if param == nil || param.data() == nil {
+		return nil, fmt.Errorf("msi: can't unmarshal an empty parameter")
+	}
+
+	switch param.Type() {
+	case STR_MS_T:
+		return param.String(), nil
+	case INT_MS_T:
+		return param.Int(), nil
+	case INT16_MS_T:
+		return param.Int16(), nil
+	case CHAR_MS_T:
+		return param.Char(), nil
+	case DOUBLE_MS_T:
+		return param.Int64(), nil
+	case FLOAT_MS_T:
+		return param.Float(), nil
+	case BOOL_MS_T:
+		return param.Bool(), nil
+	case BUF_LEN_MS_T:
+		return append([]byte(nil), param.Bytes()...), nil
+	case StrArray_MS_T:
+		return param.StrArray(), nil
+	case IntArray_MS_T:
+		return param.IntArray(), nil
+	case KeyValPair_MS_T:
+		return param.KVP(), nil
+	case DataObjInp_MS_T:
+		return param.DataObjInp(), nil
+	case DataObjCopyInp_MS_T:
+		return param.DataObjCopyInp(), nil
+	case CollInp_MS_T:
+		return param.CollInp(), nil
+	case ExecCmd_MS_T:
+		return param.ExecCmd(), nil
+	case ExecCmdOut_MS_T:
+		return param.ExecCmdOut(), nil
+	case RodsObjStat_MS_T:
+		return param.RodsObjStat(), nil
+	case GenQueryInp_MS_T:
+		return param.GenQueryInp(), nil
+	case GenQueryOut_MS_T:
+		return param.GenQueryOut(), nil
+	case DataObjInfo_MS_T:
+		return param.DataObjInfo(), nil
+	case DataObjLseekOut_MS_T:
+		return param.DataObjLseekOut(), nil
+	}
+
+	return nil, fmt.Errorf("msi: can't unmarshal %v", param.Type())
+}
+
+// fieldName returns the msi name of a struct field and whether it's tagged omitempty
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("msi")
+	opts := strings.Split(tag, ",")
+
+	name := opts[0]
+	if name == "" {
+		name = f.Name
+	}
+
+	omitEmpty := false
+	for _, opt := range opts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty
+}
+
+// fieldsByName indexes the exported fields of a struct value by their lower cased msi name,
+// and by their lower cased golang name if that differs
+func fieldsByName(rv reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name, _ := fieldName(f); name != "-" {
+			if _, ok := fields[strings.ToLower(f.Name)]; !ok {
+				fields[strings.ToLower(f.Name)] = rv.Field(i)
+			}
+
+			fields[strings.ToLower(name)] = rv.Field(i)
+		}
+	}
+
+	return fields
+}
+
+// assign stores src in dst, converting between compatible types
+func assign(dst, src reflect.Value) error {
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return nil
+		}
+		src = src.Elem()
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src)
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if src.Type().Implements(dst.Type()) {
+			dst.Set(src)
+			return nil
+		}
+
+	case reflect.String:
+		switch src.Kind() {
+		case reflect.String:
+			dst.SetString(src.String())
+			return nil
+		case reflect.Slice:
+			if src.Type().Elem().Kind() == reflect.Uint8 {
+				dst.SetString(string(src.Bytes()))
+				return nil
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+			dst.SetString(fmt.Sprint(src.Interface()))
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return assignScalar(dst, src)
+
+	case reflect.Struct:
+		switch src.Kind() {
+		case reflect.Struct:
+			srcFields := fieldsByName(src)
+
+			for name, field := range fieldsByName(dst) {
+				if s, ok := srcFields[name]; ok {
+					if err := assign(field, s); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+
+		case reflect.Map:
+			for name, field := range fieldsByName(dst) {
+				for _, key := range src.MapKeys() {
+					if strings.ToLower(fmt.Sprint(key.Interface())) == name {
+						if err := assign(field, src.MapIndex(key)); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
+
+		case reflect.Slice:
+			// e.g. the replicas of a DataObjInfo_MS_T stored in a single struct
+			if src.Len() > 0 {
+				return assign(dst, src.Index(0))
+			}
+			return nil
+		}
+
+	case reflect.Map:
+		var m map[string]string
+		var err error
+
+		switch src.Kind() {
+		case reflect.Map:
+			m, err = toStringMap(src)
+		case reflect.Struct:
+			m, err = structToStringMap(src)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if m == nil {
+			break
+		}
+
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+
+		for k, v := range m {
+			key := reflect.New(dst.Type().Key()).Elem()
+			val := reflect.New(dst.Type().Elem()).Elem()
+
+			if err := assign(key, reflect.ValueOf(k)); err != nil {
+				return err
+			}
+
+			if err := assign(val, reflect.ValueOf(v)); err != nil {
+				return err
+			}
+
+			dst.SetMapIndex(key, val)
+		}
+		return nil
+
+	case reflect.Slice:
+		if src.Kind() == reflect.String && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(src.String()))
+			return nil
+		}
+
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			break
+		}
+
+		slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(slice.Index(i), src.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		dst.Set(slice)
+		return nil
+	}
+
+	return fmt.Errorf("msi: can't store %v in %v", src.Type(), dst.Type())
+}
+
+// assignScalar stores a number, bool or string in a number or bool
+func assignScalar(dst, src reflect.Value) error {
+	if src.Kind() == reflect.String {
+		var err error
+
+		switch dst.Kind() {
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(src.String()); err == nil {
+				dst.SetBool(b)
+			}
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(src.String(), 64); err == nil {
+				dst.SetFloat(f)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var u uint64
+			if u, err = strconv.ParseUint(src.String(), 10, 64); err == nil {
+				dst.SetUint(u)
+			}
+		default:
+			var i int64
+			if i, err = strconv.ParseInt(src.String(), 10, 64); err == nil {
+				dst.SetInt(i)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("msi: can't store %q in %v: %v", src.String(), dst.Type(), err)
+		}
+		return nil
+	}
+
+	switch src.Kind() {
+	case reflect.Bool:
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(src.Bool())
+			return nil
+		}
+
+		var i int64
+		if src.Bool() {
+			i = 1
+		}
+		return assignScalar(dst, reflect.ValueOf(i))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(!src.IsZero())
+			return nil
+		}
+
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("msi: can't store %v in %v", src.Type(), dst.Type())
+}
+
+// intValue returns the value of a signed or unsigned integer
+func intValue(rv reflect.Value) int64 {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	}
+
+	return rv.Int()
+}
+
+// toStringMap converts a map with string keys to a map[string]string
+func toStringMap(rv reflect.Value) (map[string]string, error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("msi: can't marshal %v, map keys must be strings", rv.Type())
+	}
+
+	m := make(map[string]string, rv.Len())
+
+	for _, key := range rv.MapKeys() {
+		var val string
+		if err := assign(reflect.ValueOf(&val).Elem(), rv.MapIndex(key)); err != nil {
+			return nil, err
+		}
+
+		m[key.String()] = val
+	}
+
+	return m, nil
+}
+
+// structToStringMap converts the fields of a struct to a map[string]string keyed by msi name
+func structToStringMap(rv reflect.Value) (map[string]string, error) {
+	m := make(map[string]string)
+
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, omitEmpty := fieldName(f)
+		if name == "-" || (omitEmpty && rv.Field(i).IsZero()) {
+			continue
+		}
+
+		var val string
+		if err := assign(reflect.ValueOf(&val).Elem(), rv.Field(i)); err != nil {
+			return nil, err
+		}
+
+		m[name] = val
+	}
+
+	return m, nil
+}
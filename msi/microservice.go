@@ -1,19 +1,17 @@
 // Package msi is package that contains utilities for use in microservices written in Golang.
 // GoRODS/msi provides a binding to the msParam_t type, and its various subtypes. In addition,
-// it provides an interface for calling other microservices.
+// it provides an interface for calling other microservices, and msi.Register for writing
+// microservice plugins in Go.
+//
+// The package needs the iRODS headers and libraries to build. When built without cgo, or
+// with the msifake build tag, parameters are plain golang values and microservices can only
+// be invoked through a CallHandler, see the msitest package.
 package msi
 
-// #cgo CFLAGS: -I/usr/include/irods
-// #cgo CXXFLAGS: -I/usr/include/irods -I/opt/irods-externals/boost1.60.0-0/include -I/opt/irods-externals/clang3.8-0/include/c++/v1 -nostdinc++ -std=c++14
-// #cgo LDFLAGS: -Wl,-rpath,"/opt/irods-externals/boost1.60.0-0/lib" -Wl,-rpath,"/opt/irods-externals/clang3.8-0/lib" -lirods_server -lirods_common -lpthread -lc++ -lc++abi
-/*
-#include <stdlib.h>
-#include "call_microservice.h"
-*/
-import "C"
-
 import (
 	"fmt"
+	"reflect"
+	"sync"
 	"unsafe"
 )
 
@@ -26,31 +24,65 @@ func Configure(ruleExecInfo unsafe.Pointer) {
 	rei = ruleExecInfo
 }
 
-// Call invokes a microservice by name. The variadic arguments can be *msi.Param,
-// string, int, or int64.
+// CallHandler runs the microservices invoked with msi.Call in place of the iRODS rule engine.
+// ctx.Name is the name of the microservice.
+type CallHandler func(ctx *Context, params []*Param) error
+
+var (
+	callHandlerLock sync.RWMutex
+	callHandler     CallHandler
+)
+
+// SetCallHandler routes all microservice invocations made with msi.Call and Context.Call to handler,
+// and returns the previous handler. Calls go to iRODS again when handler is nil. This is used by
+// the msitest package to run microservice code without an iRODS server.
+func SetCallHandler(handler CallHandler) CallHandler {
+	callHandlerLock.Lock()
+	defer callHandlerLock.Unlock()
+
+	prev := callHandler
+	callHandler = handler
+
+	return prev
+}
+
+func getCallHandler() CallHandler {
+	callHandlerLock.RLock()
+	defer callHandlerLock.RUnlock()
+
+	return callHandler
+}
+
+// output is a pointer passed to Call, which receives the value of its parameter
+type output struct {
+	param *Param
+	dest  interface{}
+}
+
+// Call invokes a microservice by name. The variadic arguments can be *msi.Param, nil (a
+// blank parameter), or any value accepted by msi.Marshal. Pointers to other values are
+// output parameters: a blank parameter is passed when the pointed to value is zero, and the
+// value of the parameter is stored back with msi.Unmarshal after the microservice returns.
 func Call(msiName string, params ...interface{}) error {
-	if rei == nil {
+	return callWith(rei, msiName, params...)
+}
+
+// callWith invokes a microservice with the given ruleExecInfo_t*, see Call
+func callWith(rei unsafe.Pointer, msiName string, params ...interface{}) error {
+	if rei == nil && getCallHandler() == nil {
 		return fmt.Errorf("Unable to call %v, ruleExecInfo is nil, please set using msi.Configure", msiName)
 	}
 
-	numParams := C.int(len(params))
+	msParams := make([]*Param, len(params))
 
-	cParams := C.NewParamList(numParams)
-	defer C.free(unsafe.Pointer(cParams))
+	var outputs []output
 
 	for inx, param := range params {
 		var msParam *Param
 
 		switch p := param.(type) {
-		case string:
-			msParam = NewParam(STR_MS_T)
-
-			msParam.SetString(p)
-		case int:
-			msParam = NewParam(INT_MS_T)
-
-			msParam.SetInt(p)
 		case int64:
+			// Kept as INT_MS_T for compatibility, use msi.Marshal for DOUBLE_MS_T
 			msParam = NewParam(INT_MS_T)
 
 			msParam.SetInt(int(p))
@@ -59,29 +91,45 @@ func Call(msiName string, params ...interface{}) error {
 		case nil:
 			msParam = new(Param)
 		default:
-			return fmt.Errorf("Parameter with unknown type passed in params")
+			rv := reflect.ValueOf(p)
+			isOutput := rv.Kind() == reflect.Ptr && !rv.IsNil()
+
+			if isOutput && rv.Elem().IsZero() {
+				msParam = newBlankParam()
+			} else if converted, err := Marshal(p); err == nil {
+				msParam = converted
+			} else {
+				return fmt.Errorf("Unable to convert parameter %v of %v: %v", inx, msiName, err)
+			}
+
+			if isOutput {
+				outputs = append(outputs, output{msParam, p})
+			}
 		}
 
-		C.SetMsParamListItem(cParams, C.int(inx), msParam.ptr)
+		msParams[inx] = msParam
 	}
 
-	var callInfo C.msiCallInfo_t
-	size := unsafe.Sizeof(callInfo)
-	C.bzero(unsafe.Pointer(&callInfo), C.size_t(size))
+	if handler := getCallHandler(); handler != nil {
+		if err := handler(&Context{Name: msiName, rei: rei}, msParams); err != nil {
+			return err
+		}
+	} else if err := callMicroservice(rei, msiName, msParams); err != nil {
+		return err
+	}
 
-	cMsiName := C.CString(msiName)
-	defer C.free(unsafe.Pointer(cMsiName))
+	for _, out := range outputs {
+		// The microservice may have changed the type of the parameter
+		out.param.refreshType()
 
-	callInfo.microserviceName = cMsiName
-	callInfo.params = cParams
-	callInfo.paramsLen = numParams
-	callInfo.rei = rei
+		if out.param.rodsType == UNDEFINED_T || out.param.data() == nil {
+			continue
+		}
 
-	var errStr *C.char
-	if status := C.call_microservice(&callInfo, &errStr); status < 0 {
-		return fmt.Errorf("Error in call_microservice: %v", C.GoString(errStr))
+		if err := Unmarshal(out.param, out.dest); err != nil {
+			return fmt.Errorf("Unable to read output of %v: %v", msiName, err)
+		}
 	}
 
 	return nil
-
 }
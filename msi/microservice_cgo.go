@@ -0,0 +1,48 @@
+//go:build cgo && !msifake
+// +build cgo,!msifake
+
+package msi
+
+// #cgo CFLAGS: -I/usr/include/irods
+// #cgo CXXFLAGS: -I/usr/include/irods -I/opt/irods-externals/boost1.60.0-0/include -I/opt/irods-externals/clang3.8-0/include/c++/v1 -nostdinc++ -std=c++14
+// #cgo LDFLAGS: -Wl,-rpath,"/opt/irods-externals/boost1.60.0-0/lib" -Wl,-rpath,"/opt/irods-externals/clang3.8-0/lib" -lirods_server -lirods_common -lpthread -lc++ -lc++abi
+/*
+#include <stdlib.h>
+#include "call_microservice.h"
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// callMicroservice invokes a microservice through the iRODS rule engine
+func callMicroservice(rei unsafe.Pointer, msiName string, params []*Param) error {
+	numParams := C.int(len(params))
+
+	cParams := C.NewParamList(numParams)
+	defer C.free(unsafe.Pointer(cParams))
+
+	for inx, param := range params {
+		C.SetMsParamListItem(cParams, C.int(inx), param.ptr)
+	}
+
+	var callInfo C.msiCallInfo_t
+	size := unsafe.Sizeof(callInfo)
+	C.bzero(unsafe.Pointer(&callInfo), C.size_t(size))
+
+	cMsiName := C.CString(msiName)
+	defer C.free(unsafe.Pointer(cMsiName))
+
+	callInfo.microserviceName = cMsiName
+	callInfo.params = cParams
+	callInfo.paramsLen = numParams
+	callInfo.rei = rei
+
+	var errStr *C.char
+	if status := C.call_microservice(&callInfo, &errStr); status < 0 {
+		return NewError(int(status), "Error in call_microservice: %v", C.GoString(errStr))
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !cgo || msifake
+// +build !cgo msifake
+
+package msi
+
+import (
+	"unsafe"
+)
+
+// callMicroservice fails, there is no rule engine to invoke microservices without cgo
+func callMicroservice(rei unsafe.Pointer, msiName string, params []*Param) error {
+	return NewError(SYS_INTERNAL_ERR, "Unable to call %v, msi was built without cgo, use msi.SetCallHandler", msiName)
+}
@@ -0,0 +1,208 @@
+// Package msitest runs code written with GoRODS/msi under go test, without an iRODS server.
+//
+// A Harness replaces the rule engine behind msi.Call: calls are recorded, and dispatched to
+// fake microservices registered on the harness, then to microservices registered with
+// msi.Register. The harness comes with fakes of the msiDataObj* microservices backed by an
+// in-memory data object store, so msi.ObjFile works as usual:
+//
+//	h := msitest.New()
+//	defer h.Close()
+//
+//	h.PutObject("/tempZone/home/rods/in.txt", []byte("hello"))
+//
+//	r, err := msi.NewObjReader("/tempZone/home/rods/in.txt")
+//	...
+//
+//	fmt.Println(h.CallsTo("msiDataObjRead"))
+//
+// Build tests with CGO_ENABLED=0 or the msifake build tag, so the msi package doesn't need the
+// iRODS headers and libraries.
+package msitest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jjacquay712/GoRODS/msi"
+)
+
+// Call is a microservice invocation recorded by a Harness. Args holds the value of each
+// parameter when the microservice was invoked (see msi.Param.Value), nil for blank parameters.
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+// Harness intercepts the microservices invoked with msi.Call while it's installed
+type Harness struct {
+	// Username is reported by Context.Username in microservices invoked through the harness
+	Username string
+
+	mu       sync.Mutex
+	prev     msi.CallHandler
+	fakes    map[string]*msi.Microservice
+	calls    []Call
+	objects  map[string]*object
+	descs    map[int]*desc
+	nextDesc int
+}
+
+// New creates a Harness with the data object fakes, and installs it with msi.SetCallHandler.
+// Call Close to restore the previous handler.
+func New() *Harness {
+	h := &Harness{
+		Username: "rods",
+		fakes:    make(map[string]*msi.Microservice),
+		objects:  make(map[string]*object),
+		descs:    make(map[int]*desc),
+		nextDesc: 3,
+	}
+
+	h.registerObjectFakes()
+
+	h.prev = msi.SetCallHandler(h.handle)
+
+	return h
+}
+
+// Close uninstalls the harness, restoring the previous msi.CallHandler
+func (h *Harness) Close() {
+	msi.SetCallHandler(h.prev)
+}
+
+// Register adds a fake microservice to the harness, replacing any fake with the same name.
+// fn has the signature required by msi.Register.
+func (h *Harness) Register(name string, fn interface{}) error {
+	ms, err := msi.NewMicroservice(name, fn)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.fakes[name] = ms
+
+	return nil
+}
+
+// mustRegister registers the built in fakes, which have valid signatures
+func (h *Harness) mustRegister(name string, fn interface{}) {
+	if err := h.Register(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// Context returns a *msi.Context for calling microservice functions directly, with the
+// Username of the harness
+func (h *Harness) Context() *msi.Context {
+	ctx := msi.NewContext("", nil)
+	ctx.SetUsername(h.Username)
+
+	return ctx
+}
+
+// Calls returns the microservices invoked since the harness was created or Reset
+func (h *Harness) Calls() []Call {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]Call(nil), h.calls...)
+}
+
+// CallsTo returns the recorded invocations of the microservice name
+func (h *Harness) CallsTo(name string) []Call {
+	var calls []Call
+
+	for _, call := range h.Calls() {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the recorded calls
+func (h *Harness) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.calls = nil
+}
+
+// handle is the msi.CallHandler of the harness
+func (h *Harness) handle(ctx *msi.Context, params []*msi.Param) error {
+	call := Call{
+		Name: ctx.Name,
+		Args: make([]interface{}, len(params)),
+	}
+
+	for inx, param := range params {
+		if val, err := param.Value(); err == nil {
+			call.Args[inx] = val
+		}
+	}
+
+	h.mu.Lock()
+	h.calls = append(h.calls, call)
+	ms := h.fakes[ctx.Name]
+	username := h.Username
+	h.mu.Unlock()
+
+	if ms == nil {
+		ms = msi.Lookup(ctx.Name)
+	}
+
+	if ms == nil {
+		return msi.NewError(msi.NO_MICROSERVICE_FOUND_ERR, "%v is not registered with the test harness or msi.Register", ctx.Name)
+	}
+
+	ctx.SetUsername(username)
+
+	return ms.Invoke(ctx, params)
+}
+
+// PutObject stores a data object in the in-memory store, replacing any existing object at path
+func (h *Harness) PutObject(path string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.objects[path] = newObject(append([]byte(nil), data...), h.Username)
+}
+
+// Object returns the content of the data object at path, and whether it exists
+func (h *Harness) Object(path string) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	obj, ok := h.objects[path]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]byte(nil), obj.data...), true
+}
+
+// Objects returns the sorted paths of the data objects in the in-memory store
+func (h *Harness) Objects() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	paths := make([]string, 0, len(h.objects))
+	for path := range h.objects {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// OpenDescs returns the number of data object descriptors that were opened and not closed
+func (h *Harness) OpenDescs() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.descs)
+}
@@ -0,0 +1,153 @@
+package msitest
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/msi"
+)
+
+func TestObjFile(t *testing.T) {
+	h := New()
+	defer h.Close()
+
+	h.PutObject("/tempZone/home/rods/in.txt", []byte("hello world"))
+
+	r, err := msi.NewObjReader("/tempZone/home/rods/in.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "hello world" {
+		t.Fatalf("Read %q", data)
+	}
+
+	if _, err := r.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	rest, _ := ioutil.ReadAll(r)
+	if string(rest) != "world" {
+		t.Fatalf("Read %q after seek", rest)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if h.OpenDescs() != 0 {
+		t.Fatal("Descriptor left open")
+	}
+
+	if len(h.CallsTo("msiDataObjOpen")) != 1 || len(h.CallsTo("msiDataObjClose")) != 1 {
+		t.Fatalf("Unexpected calls %v", h.Calls())
+	}
+}
+
+func TestObjFileWrite(t *testing.T) {
+	h := New()
+	defer h.Close()
+
+	w, err := msi.CreateObjFile("/tempZone/home/rods/out.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte("hello "))
+	w.Write([]byte("world"))
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, ok := h.Object("/tempZone/home/rods/out.txt")
+	if !ok || string(data) != "hello world" {
+		t.Fatalf("Stored %q", data)
+	}
+
+	if _, err := msi.OpenObjFile("/tempZone/home/rods/out.txt", os.O_WRONLY|os.O_CREATE|os.O_EXCL); msi.StatusCode(err) != msi.OVERWRITE_WITHOUT_FORCE_FLAG {
+		t.Fatalf("Expected OVERWRITE_WITHOUT_FORCE_FLAG, got %v", err)
+	}
+
+	if _, err := msi.NewObjReader("/tempZone/home/rods/missing.txt"); msi.StatusCode(err) != msi.USER_FILE_DOES_NOT_EXIST {
+		t.Fatalf("Expected USER_FILE_DOES_NOT_EXIST, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	h := New()
+	defer h.Close()
+
+	h.Username = "alice"
+
+	h.Register("msiGetValue", func(ctx *msi.Context, key string, value *string) error {
+		*value = ctx.Username() + ":" + key
+		return nil
+	})
+
+	var value string
+	if err := msi.Call("msiGetValue", "color", &value); err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "alice:color" {
+		t.Fatalf("Got %q", value)
+	}
+
+	calls := h.CallsTo("msiGetValue")
+	if len(calls) != 1 || calls[0].Args[0] != "color" || calls[0].Args[1] != nil {
+		t.Fatalf("Recorded %#v", calls)
+	}
+
+	if err := msi.Call("msiUnknown"); msi.StatusCode(err) != msi.NO_MICROSERVICE_FOUND_ERR {
+		t.Fatalf("Expected NO_MICROSERVICE_FOUND_ERR, got %v", err)
+	}
+}
+
+func TestCallsMicroservice(t *testing.T) {
+	h := New()
+	defer h.Close()
+
+	h.PutObject("/tempZone/home/rods/a.txt", []byte("abc"))
+
+	// Code under test calling other microservices through its *msi.Context
+	copyObj := func(ctx *msi.Context, src, dest string) error {
+		r, err := ctx.OpenObjFile(src, os.O_RDONLY)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		w, err := ctx.OpenObjFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(w, r); err != nil {
+			w.Close()
+			return err
+		}
+
+		return w.Close()
+	}
+
+	if err := copyObj(h.Context(), "/tempZone/home/rods/a.txt", "/tempZone/home/rods/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := h.Object("/tempZone/home/rods/b.txt"); !bytes.Equal(data, []byte("abc")) {
+		t.Fatalf("Copied %q", data)
+	}
+
+	if paths := h.Objects(); len(paths) != 2 {
+		t.Fatalf("Objects %v", paths)
+	}
+}
@@ -0,0 +1,325 @@
+package msitest
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/msi"
+)
+
+// object is a data object of the in-memory store
+type object struct {
+	data     []byte
+	owner    string
+	created  time.Time
+	modified time.Time
+}
+
+func newObject(data []byte, owner string) *object {
+	now := time.Now()
+
+	return &object{
+		data:     data,
+		owner:    owner,
+		created:  now,
+		modified: now,
+	}
+}
+
+// desc is a data object opened with msiDataObjOpen or msiDataObjCreate
+type desc struct {
+	path  string
+	pos   int64
+	flags int
+}
+
+// registerObjectFakes registers the fakes of the data object microservices used by msi.ObjFile
+func (h *Harness) registerObjectFakes() {
+	h.mustRegister("msiDataObjOpen", h.dataObjOpen)
+	h.mustRegister("msiDataObjCreate", h.dataObjCreate)
+	h.mustRegister("msiDataObjRead", h.dataObjRead)
+	h.mustRegister("msiDataObjWrite", h.dataObjWrite)
+	h.mustRegister("msiDataObjLseek", h.dataObjLseek)
+	h.mustRegister("msiDataObjClose", h.dataObjClose)
+	h.mustRegister("msiDataObjUnlink", h.dataObjUnlink)
+	h.mustRegister("msiObjStat", h.objStat)
+}
+
+// dataObjInp reads a DataObjInp_MS_T parameter, or a STR_MS_T parameter holding either
+// a path or "key=value" pairs separated by "++++", like "objPath=/tempZone/x++++openFlags=O_RDWR"
+func dataObjInp(param *msi.Param) (msi.DataObjInp, error) {
+	if param.Type() == msi.DataObjInp_MS_T {
+		return param.DataObjInp(), nil
+	}
+
+	var inp msi.DataObjInp
+
+	if param.Type() != msi.STR_MS_T {
+		return inp, msi.NewError(msi.USER_PARAM_TYPE_ERR, "Expected a DataObjInp_MS_T or STR_MS_T parameter, got %v", param.Type())
+	}
+
+	str := param.String()
+
+	if !strings.Contains(str, "=") {
+		inp.ObjPath = str
+		return inp, nil
+	}
+
+	openFlags := map[string]int{
+		"O_RDONLY":       os.O_RDONLY,
+		"O_WRONLY":       os.O_WRONLY,
+		"O_RDWR":         os.O_RDWR,
+		"O_RDWRTRUNC":    os.O_RDWR | os.O_TRUNC,
+		"O_WRONLYTRUNC":  os.O_WRONLY | os.O_TRUNC,
+		"O_RDWR|O_TRUNC": os.O_RDWR | os.O_TRUNC,
+	}
+
+	for _, kv := range strings.Split(str, "++++") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "objPath":
+			inp.ObjPath = parts[1]
+		case "openFlags":
+			inp.OpenFlags = openFlags[parts[1]]
+		}
+	}
+
+	return inp, nil
+}
+
+// open returns a new descriptor for path, h.mu must be held
+func (h *Harness) open(path string, flags int) int {
+	fd := h.nextDesc
+	h.nextDesc++
+
+	h.descs[fd] = &desc{
+		path:  path,
+		flags: flags,
+	}
+
+	return fd
+}
+
+// lookupDesc returns the descriptor fd and its data object, h.mu must be held
+func (h *Harness) lookupDesc(fd int) (*desc, *object, error) {
+	d, ok := h.descs[fd]
+	if !ok {
+		return nil, nil, msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Descriptor %v is not open", fd)
+	}
+
+	obj, ok := h.objects[d.path]
+	if !ok {
+		return nil, nil, msi.NewError(msi.USER_FILE_DOES_NOT_EXIST, "%v was removed", d.path)
+	}
+
+	return d, obj, nil
+}
+
+func (h *Harness) dataObjOpen(ctx *msi.Context, inpParam *msi.Param, fd *int) error {
+	inp, err := dataObjInp(inpParam)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	obj, exists := h.objects[inp.ObjPath]
+
+	switch {
+	case !exists && inp.OpenFlags&os.O_CREATE == 0:
+		return msi.NewError(msi.USER_FILE_DOES_NOT_EXIST, "%v does not exist", inp.ObjPath)
+
+	case exists && inp.OpenFlags&os.O_CREATE != 0 && inp.OpenFlags&os.O_EXCL != 0:
+		return msi.NewError(msi.OVERWRITE_WITHOUT_FORCE_FLAG, "%v already exists", inp.ObjPath)
+
+	case !exists:
+		h.objects[inp.ObjPath] = newObject(nil, ctx.Username())
+
+	case inp.OpenFlags&os.O_TRUNC != 0:
+		obj.data = nil
+		obj.modified = time.Now()
+	}
+
+	*fd = h.open(inp.ObjPath, inp.OpenFlags)
+
+	return nil
+}
+
+func (h *Harness) dataObjCreate(ctx *msi.Context, inpParam *msi.Param, opts string, fd *int) error {
+	inp, err := dataObjInp(inpParam)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.objects[inp.ObjPath]; exists && !strings.Contains(opts, "forceFlag") {
+		if _, force := inp.CondInput["forceFlag"]; !force {
+			return msi.NewError(msi.OVERWRITE_WITHOUT_FORCE_FLAG, "%v already exists", inp.ObjPath)
+		}
+	}
+
+	h.objects[inp.ObjPath] = newObject(nil, ctx.Username())
+
+	*fd = h.open(inp.ObjPath, os.O_RDWR)
+
+	return nil
+}
+
+func (h *Harness) dataObjRead(ctx *msi.Context, fd int, n int, out *[]byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, obj, err := h.lookupDesc(fd)
+	if err != nil {
+		return err
+	}
+
+	if d.flags&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Descriptor %v is write only", fd)
+	}
+
+	start := d.pos
+	if start > int64(len(obj.data)) {
+		start = int64(len(obj.data))
+	}
+
+	end := start + int64(n)
+	if end > int64(len(obj.data)) {
+		end = int64(len(obj.data))
+	}
+
+	*out = append([]byte{}, obj.data[start:end]...)
+	d.pos = end
+
+	return nil
+}
+
+func (h *Harness) dataObjWrite(ctx *msi.Context, fd int, data []byte, written *int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, obj, err := h.lookupDesc(fd)
+	if err != nil {
+		return err
+	}
+
+	if d.flags&(os.O_WRONLY|os.O_RDWR) == os.O_RDONLY {
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Descriptor %v is read only", fd)
+	}
+
+	end := d.pos + int64(len(data))
+	if end > int64(len(obj.data)) {
+		grown := make([]byte, end)
+		copy(grown, obj.data)
+		obj.data = grown
+	}
+
+	copy(obj.data[d.pos:], data)
+	obj.modified = time.Now()
+
+	d.pos = end
+	*written = len(data)
+
+	return nil
+}
+
+func (h *Harness) dataObjLseek(ctx *msi.Context, fd int, offset string, whence string, newPos *int64) error {
+	off, err := strconv.ParseInt(offset, 10, 64)
+	if err != nil {
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Invalid offset %q", offset)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, obj, err := h.lookupDesc(fd)
+	if err != nil {
+		return err
+	}
+
+	var pos int64
+
+	switch whence {
+	case "SEEK_SET", strconv.Itoa(io.SeekStart):
+		pos = off
+	case "SEEK_CUR", strconv.Itoa(io.SeekCurrent):
+		pos = d.pos + off
+	case "SEEK_END", strconv.Itoa(io.SeekEnd):
+		pos = int64(len(obj.data)) + off
+	default:
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Invalid whence %q", whence)
+	}
+
+	if pos < 0 {
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Negative offset %v", pos)
+	}
+
+	d.pos = pos
+	*newPos = pos
+
+	return nil
+}
+
+func (h *Harness) dataObjClose(ctx *msi.Context, fd int, status *int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.descs[fd]; !ok {
+		return msi.NewError(msi.SYS_INVALID_INPUT_PARAM, "Descriptor %v is not open", fd)
+	}
+
+	delete(h.descs, fd)
+	*status = 0
+
+	return nil
+}
+
+func (h *Harness) dataObjUnlink(ctx *msi.Context, inpParam *msi.Param, status *int) error {
+	inp, err := dataObjInp(inpParam)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.objects[inp.ObjPath]; !exists {
+		return msi.NewError(msi.USER_FILE_DOES_NOT_EXIST, "%v does not exist", inp.ObjPath)
+	}
+
+	delete(h.objects, inp.ObjPath)
+	*status = 0
+
+	return nil
+}
+
+func (h *Harness) objStat(ctx *msi.Context, path string, stat *msi.RodsObjStat) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	obj, ok := h.objects[path]
+	if !ok {
+		return msi.NewError(msi.USER_FILE_DOES_NOT_EXIST, "%v does not exist", path)
+	}
+
+	*stat = msi.RodsObjStat{
+		ObjSize:    int64(len(obj.data)),
+		ObjType:    1,
+		DataMode:   0644,
+		OwnerName:  obj.owner,
+		CreateTime: strconv.FormatInt(obj.created.Unix(), 10),
+		ModifyTime: strconv.FormatInt(obj.modified.Unix(), 10),
+	}
+
+	return nil
+}
@@ -0,0 +1,562 @@
+//go:build cgo && !msifake
+// +build cgo,!msifake
+
+package msi
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include "call_microservice.h"
+#include "rcMisc.h"
+#include "fileLseek.h"
+*/
+import "C"
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// Param is the golang abstraction for *C.msParam_t types
+type Param struct {
+	ptr      *C.msParam_t
+	rodsType ParamType
+}
+
+// NewParam creates a new *Param, with the provided type string
+func NewParam(paramType ParamType) *Param {
+	p := new(Param)
+
+	p.rodsType = paramType
+
+	cTypeStr := C.CString(string(paramType))
+	defer C.free(unsafe.Pointer(cTypeStr))
+
+	p.ptr = C.NewParam(cTypeStr)
+
+	runtime.SetFinalizer(p, paramDestructor)
+
+	return p
+}
+
+// newBlankParam creates a new untyped *Param, for output parameters set by microservices
+func newBlankParam() *Param {
+	p := new(Param)
+
+	p.ptr = C.NewBlankParam()
+
+	runtime.SetFinalizer(p, paramDestructor)
+
+	return p
+}
+
+// Ptr returns an unsafe.Pointer of the underlying *C.msParam_t
+func (param *Param) Ptr() unsafe.Pointer {
+	return unsafe.Pointer(param.ptr)
+}
+
+// str returns the value of STR_MS_T parameters
+func (param *Param) str() string {
+	return C.GoString((*C.char)(param.ptr.inOutStruct))
+}
+
+// canStore reports whether a value can be moved into param with moveFrom
+func (param *Param) canStore() bool {
+	return param.ptr != nil
+}
+
+// moveFrom hands the value of src over to param, leaving src empty
+func (param *Param) moveFrom(src *Param) {
+	C.MoveParam(param.ptr, src.ptr)
+	param.rodsType = src.rodsType
+}
+
+// refreshType reads the type of the underlying *C.msParam_t, which microservices may have changed
+func (param *Param) refreshType() {
+	if param.ptr != nil {
+		param.rodsType = ParamType(C.GoString(C.GetMSParamType(param.ptr)))
+	}
+}
+
+// data returns the inOutStruct of the underlying *C.msParam_t, or nil if it isn't set
+func (param *Param) data() unsafe.Pointer {
+	if param.ptr == nil {
+		return nil
+	}
+
+	return param.ptr.inOutStruct
+}
+
+// Bytes returns the []byte of BUF_LEN_MS_T type parameters
+func (param *Param) Bytes() []byte {
+	var bytes []byte
+
+	if param.rodsType == BUF_LEN_MS_T && param.ptr != nil && param.ptr.inpOutBuf != nil {
+
+		internalBuff := param.ptr.inpOutBuf
+
+		outBuff := unsafe.Pointer(internalBuff.buf)
+
+		bufLen := int(internalBuff.len)
+
+		bytes = (*[1 << 30]byte)(outBuff)[:bufLen:bufLen]
+	}
+
+	return bytes
+}
+
+// SetBytes sets the underlying BUF_LEN_MS_T struct to the provided byte slice
+func (param *Param) SetBytes(bytes []byte) *Param {
+
+	if param.rodsType == BUF_LEN_MS_T && len(bytes) > 0 {
+		length := C.int(len(bytes))
+
+		cBuff := C.NewBytesBuff(length, unsafe.Pointer(&bytes[0]))
+
+		C.fillBufLenInMsParam(param.ptr, length, cBuff)
+	}
+
+	return param
+}
+
+// KVP returns the key-value pairs of the underlying KeyValPair_MS_T parameter
+func (param *Param) KVP() map[string]string {
+	if param.rodsType == KeyValPair_MS_T && param.data() != nil {
+		return kvpToMap((*C.keyValPair_t)(param.data()))
+	}
+	return nil
+}
+
+// SetKVP adds key-value pairs to the underlying KeyValPair_MS_T parameter
+func (param *Param) SetKVP(data map[string]string) *Param {
+	if param.rodsType == KeyValPair_MS_T {
+		addKVP((*C.keyValPair_t)(param.ptr.inOutStruct), data)
+	}
+	return param
+}
+
+// Int returns the integer value of the underlying INT_MS_T parameter
+func (param *Param) Int() int {
+	if param.rodsType == INT_MS_T {
+		return int(*((*C.int)(param.ptr.inOutStruct)))
+	}
+	return -1
+}
+
+// SetInt sets the integer value of the underlying INT_MS_T parameter
+func (param *Param) SetInt(val int) *Param {
+	if param.rodsType == INT_MS_T {
+		*((*C.int)(param.ptr.inOutStruct)) = C.int(val)
+	}
+	return param
+}
+
+// SetString sets the string value of the underlying STR_MS_T parameter
+func (param *Param) SetString(val string) *Param {
+	if param.rodsType == STR_MS_T {
+		param.ptr.inOutStruct = unsafe.Pointer(C.CString(val))
+	}
+	return param
+}
+
+// Int16 returns the value of the underlying INT16_MS_T parameter
+func (param *Param) Int16() int16 {
+	if param.rodsType == INT16_MS_T && param.data() != nil {
+		return int16(*((*C.short)(param.data())))
+	}
+	return -1
+}
+
+// SetInt16 sets the value of the underlying INT16_MS_T parameter
+func (param *Param) SetInt16(val int16) *Param {
+	if param.rodsType == INT16_MS_T {
+		*((*C.short)(param.ptr.inOutStruct)) = C.short(val)
+	}
+	return param
+}
+
+// Char returns the value of the underlying CHAR_MS_T parameter
+func (param *Param) Char() byte {
+	if param.rodsType == CHAR_MS_T && param.data() != nil {
+		return byte(*((*C.char)(param.data())))
+	}
+	return 0
+}
+
+// SetChar sets the value of the underlying CHAR_MS_T parameter
+func (param *Param) SetChar(val byte) *Param {
+	if param.rodsType == CHAR_MS_T {
+		*((*C.char)(param.ptr.inOutStruct)) = C.char(val)
+	}
+	return param
+}
+
+// Int64 returns the value of the underlying DOUBLE_MS_T parameter. Despite its name,
+// iRODS stores DOUBLE_MS_T values as a 64 bit integer (rodsLong_t).
+func (param *Param) Int64() int64 {
+	if param.rodsType == DOUBLE_MS_T && param.data() != nil {
+		return int64(*((*C.rodsLong_t)(param.data())))
+	}
+	return -1
+}
+
+// SetInt64 sets the value of the underlying DOUBLE_MS_T parameter
+func (param *Param) SetInt64(val int64) *Param {
+	if param.rodsType == DOUBLE_MS_T {
+		*((*C.rodsLong_t)(param.ptr.inOutStruct)) = C.rodsLong_t(val)
+	}
+	return param
+}
+
+// Float returns the value of the underlying FLOAT_MS_T parameter
+func (param *Param) Float() float32 {
+	if param.rodsType == FLOAT_MS_T && param.data() != nil {
+		return float32(*((*C.float)(param.data())))
+	}
+	return 0
+}
+
+// SetFloat sets the value of the underlying FLOAT_MS_T parameter
+func (param *Param) SetFloat(val float32) *Param {
+	if param.rodsType == FLOAT_MS_T {
+		*((*C.float)(param.ptr.inOutStruct)) = C.float(val)
+	}
+	return param
+}
+
+// Bool returns the value of the underlying BOOL_MS_T parameter
+func (param *Param) Bool() bool {
+	if param.rodsType == BOOL_MS_T && param.data() != nil {
+		return *((*C.int)(param.data())) != 0
+	}
+	return false
+}
+
+// SetBool sets the value of the underlying BOOL_MS_T parameter
+func (param *Param) SetBool(val bool) *Param {
+	if param.rodsType == BOOL_MS_T {
+		var i C.int
+		if val {
+			i = 1
+		}
+		*((*C.int)(param.ptr.inOutStruct)) = i
+	}
+	return param
+}
+
+// StrArray returns the strings of the underlying StrArray_MS_T parameter
+func (param *Param) StrArray() []string {
+	if param.rodsType != StrArray_MS_T || param.data() == nil {
+		return nil
+	}
+
+	arr := (*C.strArray_t)(param.data())
+	strs := make([]string, 0, int(arr.len))
+
+	for i := 0; i < int(arr.len) && arr.value != nil; i++ {
+		str := (*C.char)(unsafe.Pointer(uintptr(unsafe.Pointer(arr.value)) + uintptr(i*int(arr.size))))
+		strs = append(strs, C.GoString(str))
+	}
+
+	return strs
+}
+
+// SetStrArray sets the strings of the underlying StrArray_MS_T parameter
+func (param *Param) SetStrArray(strs []string) *Param {
+	if param.rodsType == StrArray_MS_T {
+		arr := (*C.strArray_t)(param.ptr.inOutStruct)
+
+		// strArray_t stores its strings in a single block of len fixed size entries
+		size := 1
+		for _, s := range strs {
+			if len(s)+1 > size {
+				size = len(s) + 1
+			}
+		}
+
+		C.free(unsafe.Pointer(arr.value))
+
+		block := C.malloc(C.size_t(size*len(strs) + 1))
+		C.memset(block, 0, C.size_t(size*len(strs)+1))
+
+		for i, s := range strs {
+			copy((*[1 << 30]byte)(block)[i*size:i*size+size:i*size+size], s)
+		}
+
+		arr.value = (*C.char)(block)
+		arr.len = C.int(len(strs))
+		arr.size = C.int(size)
+	}
+	return param
+}
+
+// IntArray returns the integers of the underlying IntArray_MS_T parameter
+func (param *Param) IntArray() []int {
+	if param.rodsType != IntArray_MS_T || param.data() == nil {
+		return nil
+	}
+
+	arr := (*C.intArray_t)(param.data())
+	vals := cInts(arr.value, int(arr.len))
+	ints := make([]int, len(vals))
+
+	for i, v := range vals {
+		ints[i] = int(v)
+	}
+
+	return ints
+}
+
+// SetIntArray sets the integers of the underlying IntArray_MS_T parameter
+func (param *Param) SetIntArray(ints []int) *Param {
+	if param.rodsType == IntArray_MS_T {
+		arr := (*C.intArray_t)(param.ptr.inOutStruct)
+
+		C.free(unsafe.Pointer(arr.value))
+
+		arr.value = (*C.int)(C.malloc(C.size_t(len(ints)+1) * C.sizeof_int))
+		arr.len = C.int(len(ints))
+
+		vals := cInts(arr.value, len(ints))
+		for i, v := range ints {
+			vals[i] = C.int(v)
+		}
+	}
+	return param
+}
+
+// DataObjInp returns the fields of the underlying DataObjInp_MS_T parameter
+func (param *Param) DataObjInp() DataObjInp {
+	if param.rodsType == DataObjInp_MS_T && param.data() != nil {
+		return dataObjInpFromC((*C.dataObjInp_t)(param.data()))
+	}
+	return DataObjInp{}
+}
+
+// SetDataObjInpStruct sets the fields of the underlying DataObjInp_MS_T parameter
+func (param *Param) SetDataObjInpStruct(inp DataObjInp) *Param {
+	if param.rodsType == DataObjInp_MS_T {
+		inp.toC((*C.dataObjInp_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// DataObjCopyInp returns the fields of the underlying DataObjCopyInp_MS_T parameter
+func (param *Param) DataObjCopyInp() DataObjCopyInp {
+	if param.rodsType == DataObjCopyInp_MS_T && param.data() != nil {
+		c := (*C.dataObjCopyInp_t)(param.data())
+
+		return DataObjCopyInp{
+			Src:  dataObjInpFromC(&c.srcDataObjInp),
+			Dest: dataObjInpFromC(&c.destDataObjInp),
+		}
+	}
+	return DataObjCopyInp{}
+}
+
+// SetDataObjCopyInp sets the fields of the underlying DataObjCopyInp_MS_T parameter
+func (param *Param) SetDataObjCopyInp(inp DataObjCopyInp) *Param {
+	if param.rodsType == DataObjCopyInp_MS_T {
+		c := (*C.dataObjCopyInp_t)(param.ptr.inOutStruct)
+
+		inp.Src.toC(&c.srcDataObjInp)
+		inp.Dest.toC(&c.destDataObjInp)
+	}
+	return param
+}
+
+// CollInp returns the fields of the underlying CollInp_MS_T parameter
+func (param *Param) CollInp() CollInp {
+	if param.rodsType == CollInp_MS_T && param.data() != nil {
+		return collInpFromC((*C.collInp_t)(param.data()))
+	}
+	return CollInp{}
+}
+
+// SetCollInp sets the fields of the underlying CollInp_MS_T parameter
+func (param *Param) SetCollInp(inp CollInp) *Param {
+	if param.rodsType == CollInp_MS_T {
+		inp.toC((*C.collInp_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// ExecCmd returns the fields of the underlying ExecCmd_MS_T parameter
+func (param *Param) ExecCmd() ExecCmd {
+	if param.rodsType == ExecCmd_MS_T && param.data() != nil {
+		return execCmdFromC((*C.execCmd_t)(param.data()))
+	}
+	return ExecCmd{}
+}
+
+// SetExecCmd sets the fields of the underlying ExecCmd_MS_T parameter
+func (param *Param) SetExecCmd(cmd ExecCmd) *Param {
+	if param.rodsType == ExecCmd_MS_T {
+		cmd.toC((*C.execCmd_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// ExecCmdOut returns the output and status of the underlying ExecCmdOut_MS_T parameter
+func (param *Param) ExecCmdOut() ExecCmdOut {
+	if param.rodsType == ExecCmdOut_MS_T && param.data() != nil {
+		c := (*C.execCmdOut_t)(param.data())
+
+		return ExecCmdOut{
+			Stdout: bytesBufToGo(&c.stdoutBuf),
+			Stderr: bytesBufToGo(&c.stderrBuf),
+			Status: int(c.status),
+		}
+	}
+	return ExecCmdOut{}
+}
+
+// SetExecCmdOut sets the output and status of the underlying ExecCmdOut_MS_T parameter
+func (param *Param) SetExecCmdOut(out ExecCmdOut) *Param {
+	if param.rodsType == ExecCmdOut_MS_T {
+		c := (*C.execCmdOut_t)(param.ptr.inOutStruct)
+
+		setBytesBuf(&c.stdoutBuf, out.Stdout)
+		setBytesBuf(&c.stderrBuf, out.Stderr)
+		c.status = C.int(out.Status)
+	}
+	return param
+}
+
+// RodsObjStat returns the fields of the underlying RodsObjStat_MS_T parameter
+func (param *Param) RodsObjStat() RodsObjStat {
+	if param.rodsType == RodsObjStat_MS_T && param.data() != nil {
+		return rodsObjStatFromC((*C.rodsObjStat_t)(param.data()))
+	}
+	return RodsObjStat{}
+}
+
+// SetRodsObjStat sets the fields of the underlying RodsObjStat_MS_T parameter
+func (param *Param) SetRodsObjStat(stat RodsObjStat) *Param {
+	if param.rodsType == RodsObjStat_MS_T {
+		stat.toC((*C.rodsObjStat_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// GenQueryInp returns the query described by the underlying GenQueryInp_MS_T parameter
+func (param *Param) GenQueryInp() GenQueryInp {
+	if param.rodsType == GenQueryInp_MS_T && param.data() != nil {
+		return genQueryInpFromC((*C.genQueryInp_t)(param.data()))
+	}
+	return GenQueryInp{}
+}
+
+// SetGenQueryInp sets the query of the underlying GenQueryInp_MS_T parameter
+func (param *Param) SetGenQueryInp(inp GenQueryInp) *Param {
+	if param.rodsType == GenQueryInp_MS_T {
+		inp.toC((*C.genQueryInp_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// GenQueryOut returns the results held by the underlying GenQueryOut_MS_T parameter
+func (param *Param) GenQueryOut() GenQueryOut {
+	if param.rodsType == GenQueryOut_MS_T && param.data() != nil {
+		return genQueryOutFromC((*C.genQueryOut_t)(param.data()))
+	}
+	return GenQueryOut{}
+}
+
+// SetGenQueryOut sets the results of the underlying GenQueryOut_MS_T parameter
+func (param *Param) SetGenQueryOut(out GenQueryOut) *Param {
+	if param.rodsType == GenQueryOut_MS_T {
+		out.toC((*C.genQueryOut_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// DataObjLseekOut returns the offset of the underlying DataObjLseekOut_MS_T parameter, set by msiDataObjLseek
+func (param *Param) DataObjLseekOut() int64 {
+	if param.rodsType == DataObjLseekOut_MS_T && param.data() != nil {
+		return int64((*C.fileLseekOut_t)(param.data()).offset)
+	}
+	return -1
+}
+
+// DataObjInfo returns the replicas described by the underlying DataObjInfo_MS_T parameter,
+// following the linked list of dataObjInfo_t structs
+func (param *Param) DataObjInfo() []DataObjInfo {
+	if param.rodsType != DataObjInfo_MS_T || param.data() == nil {
+		return nil
+	}
+
+	var infos []DataObjInfo
+
+	for c := (*C.dataObjInfo_t)(param.data()); c != nil; c = c.next {
+		infos = append(infos, dataObjInfoFromC(c))
+	}
+
+	return infos
+}
+
+// SetDataObjInfo sets the fields of the first dataObjInfo_t of the underlying DataObjInfo_MS_T parameter
+func (param *Param) SetDataObjInfo(info DataObjInfo) *Param {
+	if param.rodsType == DataObjInfo_MS_T {
+		info.toC((*C.dataObjInfo_t)(param.ptr.inOutStruct))
+	}
+	return param
+}
+
+// SetDataObjInp sets the underlying DataObjInp_MS_T struct fields from a map
+// Valid keys and values are: {"objPath": string, "createMode": int, "openFlags": int}.
+// Missing keys and values of other types are ignored, see SetDataObjInpStruct and Marshal
+// for a type safe alternative.
+func (param *Param) SetDataObjInp(input map[string]interface{}) *Param {
+	if param.rodsType == DataObjInp_MS_T {
+		var cInput *C.dataObjInp_t = (*C.dataObjInp_t)(param.ptr.inOutStruct)
+
+		if objPath, ok := input["objPath"].(string); ok {
+			setCharArr(cInput.objPath[:], objPath)
+		}
+
+		if createMode, ok := input["createMode"].(int); ok {
+			cInput.createMode = C.int(createMode)
+		}
+
+		if openFlags, ok := input["openFlags"].(int); ok {
+			cInput.openFlags = C.int(openFlags)
+		}
+
+	}
+
+	return param
+}
+
+// ConvertTo rebuilds the underlying data of msParam_t*, to the given ParamType.
+// This is useful for setting the types of output parameters, since they are blank
+// when passed to the microservice. If msParam_t* is nil, it is set to a newly
+// allocated structure.
+func (param *Param) ConvertTo(t ParamType) *Param {
+	cType := C.CString(string(t))
+	defer C.free(unsafe.Pointer(cType))
+
+	C.ConvertParam(cType, &param.ptr)
+	param.rodsType = t
+
+	return param
+}
+
+func paramDestructor(param *Param) {
+	C.FreeMsParam(param.ptr)
+}
+
+// ToParam creates a new *msi.Param from an existing *C.msParam_t
+func ToParam(gParam unsafe.Pointer) *Param {
+	param := (*C.msParam_t)(gParam)
+
+	typeStr := C.GoString(C.GetMSParamType(param))
+
+	// Go won't let me access param->type directly
+	typ := ParamType(typeStr)
+
+	return &Param{
+		param,
+		typ,
+	}
+}
@@ -0,0 +1,407 @@
+//go:build !cgo || msifake
+// +build !cgo msifake
+
+package msi
+
+import (
+	"unsafe"
+)
+
+// Param is the golang abstraction for *C.msParam_t types. Without cgo, it holds the golang
+// representation of its value (see Marshal) instead of a msParam_t.
+type Param struct {
+	rodsType ParamType
+	value    interface{}
+}
+
+// zeroValues are the values of new parameters, like the zeroed structs allocated by the C implementation
+var zeroValues = map[ParamType]func() interface{}{
+	INT_MS_T:             func() interface{} { return 0 },
+	INT16_MS_T:           func() interface{} { return int16(0) },
+	CHAR_MS_T:            func() interface{} { return byte(0) },
+	DOUBLE_MS_T:          func() interface{} { return int64(0) },
+	FLOAT_MS_T:           func() interface{} { return float32(0) },
+	BOOL_MS_T:            func() interface{} { return false },
+	KeyValPair_MS_T:      func() interface{} { return map[string]string{} },
+	StrArray_MS_T:        func() interface{} { return []string{} },
+	IntArray_MS_T:        func() interface{} { return []int{} },
+	DataObjInp_MS_T:      func() interface{} { return DataObjInp{} },
+	DataObjCopyInp_MS_T:  func() interface{} { return DataObjCopyInp{} },
+	CollInp_MS_T:         func() interface{} { return CollInp{} },
+	ExecCmd_MS_T:         func() interface{} { return ExecCmd{} },
+	ExecCmdOut_MS_T:      func() interface{} { return ExecCmdOut{} },
+	RodsObjStat_MS_T:     func() interface{} { return RodsObjStat{} },
+	GenQueryInp_MS_T:     func() interface{} { return GenQueryInp{} },
+	GenQueryOut_MS_T:     func() interface{} { return GenQueryOut{} },
+	DataObjInfo_MS_T:     func() interface{} { return []DataObjInfo{{}} },
+	DataObjLseekOut_MS_T: func() interface{} { return int64(0) },
+}
+
+// NewParam creates a new *Param, with the provided type string
+func NewParam(paramType ParamType) *Param {
+	p := &Param{rodsType: paramType}
+
+	if zero, ok := zeroValues[paramType]; ok {
+		p.value = zero()
+	}
+
+	return p
+}
+
+// newBlankParam creates a new untyped *Param, for output parameters set by microservices
+func newBlankParam() *Param {
+	return new(Param)
+}
+
+// Ptr returns an unsafe.Pointer to the *Param itself, which ToParam converts back
+func (param *Param) Ptr() unsafe.Pointer {
+	return unsafe.Pointer(param)
+}
+
+// ToParam converts a pointer returned by Param.Ptr back to a *Param
+func ToParam(gParam unsafe.Pointer) *Param {
+	return (*Param)(gParam)
+}
+
+// ConvertTo resets the parameter to the zero value of the given ParamType
+func (param *Param) ConvertTo(t ParamType) *Param {
+	*param = *NewParam(t)
+
+	return param
+}
+
+func (param *Param) str() string {
+	s, _ := param.value.(string)
+	return s
+}
+
+func (param *Param) canStore() bool {
+	return true
+}
+
+func (param *Param) moveFrom(src *Param) {
+	param.rodsType = src.rodsType
+	param.value = src.value
+
+	src.value = nil
+}
+
+func (param *Param) refreshType() {}
+
+func (param *Param) data() interface{} {
+	return param.value
+}
+
+// get returns the value of the parameter if it has type t
+func (param *Param) get(t ParamType) (interface{}, bool) {
+	if param.rodsType != t || param.value == nil {
+		return nil, false
+	}
+
+	return param.value, true
+}
+
+// set stores the value of the parameter if it has type t
+func (param *Param) set(t ParamType, val interface{}) *Param {
+	if param.rodsType == t {
+		param.value = val
+	}
+
+	return param
+}
+
+// Bytes returns the []byte of BUF_LEN_MS_T type parameters
+func (param *Param) Bytes() []byte {
+	v, _ := param.get(BUF_LEN_MS_T)
+	b, _ := v.([]byte)
+	return b
+}
+
+// SetBytes sets the underlying BUF_LEN_MS_T struct to the provided byte slice
+func (param *Param) SetBytes(bytes []byte) *Param {
+	return param.set(BUF_LEN_MS_T, append([]byte{}, bytes...))
+}
+
+// KVP returns the key-value pairs of the underlying KeyValPair_MS_T parameter
+func (param *Param) KVP() map[string]string {
+	v, ok := param.get(KeyValPair_MS_T)
+	if !ok {
+		return nil
+	}
+
+	kvp := make(map[string]string)
+	for key, val := range v.(map[string]string) {
+		kvp[key] = val
+	}
+
+	return kvp
+}
+
+// SetKVP adds key-value pairs to the underlying KeyValPair_MS_T parameter
+func (param *Param) SetKVP(data map[string]string) *Param {
+	if param.rodsType == KeyValPair_MS_T {
+		kvp := param.KVP()
+		if kvp == nil {
+			kvp = make(map[string]string)
+		}
+
+		for key, val := range data {
+			kvp[key] = val
+		}
+
+		param.value = kvp
+	}
+	return param
+}
+
+// Int returns the integer value of the underlying INT_MS_T parameter
+func (param *Param) Int() int {
+	if v, ok := param.get(INT_MS_T); ok {
+		return v.(int)
+	}
+	return -1
+}
+
+// SetInt sets the integer value of the underlying INT_MS_T parameter
+func (param *Param) SetInt(val int) *Param {
+	return param.set(INT_MS_T, val)
+}
+
+// SetString sets the string value of the underlying STR_MS_T parameter
+func (param *Param) SetString(val string) *Param {
+	return param.set(STR_MS_T, val)
+}
+
+// Int16 returns the value of the underlying INT16_MS_T parameter
+func (param *Param) Int16() int16 {
+	if v, ok := param.get(INT16_MS_T); ok {
+		return v.(int16)
+	}
+	return -1
+}
+
+// SetInt16 sets the value of the underlying INT16_MS_T parameter
+func (param *Param) SetInt16(val int16) *Param {
+	return param.set(INT16_MS_T, val)
+}
+
+// Char returns the value of the underlying CHAR_MS_T parameter
+func (param *Param) Char() byte {
+	v, _ := param.get(CHAR_MS_T)
+	c, _ := v.(byte)
+	return c
+}
+
+// SetChar sets the value of the underlying CHAR_MS_T parameter
+func (param *Param) SetChar(val byte) *Param {
+	return param.set(CHAR_MS_T, val)
+}
+
+// Int64 returns the value of the underlying DOUBLE_MS_T parameter. Despite its name,
+// iRODS stores DOUBLE_MS_T values as a 64 bit integer (rodsLong_t).
+func (param *Param) Int64() int64 {
+	if v, ok := param.get(DOUBLE_MS_T); ok {
+		return v.(int64)
+	}
+	return -1
+}
+
+// SetInt64 sets the value of the underlying DOUBLE_MS_T parameter
+func (param *Param) SetInt64(val int64) *Param {
+	return param.set(DOUBLE_MS_T, val)
+}
+
+// Float returns the value of the underlying FLOAT_MS_T parameter
+func (param *Param) Float() float32 {
+	v, _ := param.get(FLOAT_MS_T)
+	f, _ := v.(float32)
+	return f
+}
+
+// SetFloat sets the value of the underlying FLOAT_MS_T parameter
+func (param *Param) SetFloat(val float32) *Param {
+	return param.set(FLOAT_MS_T, val)
+}
+
+// Bool returns the value of the underlying BOOL_MS_T parameter
+func (param *Param) Bool() bool {
+	v, _ := param.get(BOOL_MS_T)
+	b, _ := v.(bool)
+	return b
+}
+
+// SetBool sets the value of the underlying BOOL_MS_T parameter
+func (param *Param) SetBool(val bool) *Param {
+	return param.set(BOOL_MS_T, val)
+}
+
+// StrArray returns the strings of the underlying StrArray_MS_T parameter
+func (param *Param) StrArray() []string {
+	v, _ := param.get(StrArray_MS_T)
+	strs, _ := v.([]string)
+	return append([]string{}, strs...)
+}
+
+// SetStrArray sets the strings of the underlying StrArray_MS_T parameter
+func (param *Param) SetStrArray(strs []string) *Param {
+	return param.set(StrArray_MS_T, append([]string{}, strs...))
+}
+
+// IntArray returns the integers of the underlying IntArray_MS_T parameter
+func (param *Param) IntArray() []int {
+	v, _ := param.get(IntArray_MS_T)
+	ints, _ := v.([]int)
+	return append([]int{}, ints...)
+}
+
+// SetIntArray sets the integers of the underlying IntArray_MS_T parameter
+func (param *Param) SetIntArray(ints []int) *Param {
+	return param.set(IntArray_MS_T, append([]int{}, ints...))
+}
+
+// DataObjInp returns the fields of the underlying DataObjInp_MS_T parameter
+func (param *Param) DataObjInp() DataObjInp {
+	v, _ := param.get(DataObjInp_MS_T)
+	inp, _ := v.(DataObjInp)
+	return inp
+}
+
+// SetDataObjInpStruct sets the fields of the underlying DataObjInp_MS_T parameter
+func (param *Param) SetDataObjInpStruct(inp DataObjInp) *Param {
+	return param.set(DataObjInp_MS_T, inp)
+}
+
+// SetDataObjInp sets the underlying DataObjInp_MS_T struct fields from a map
+// Valid keys and values are: {"objPath": string, "createMode": int, "openFlags": int}.
+// Missing keys and values of other types are ignored, see SetDataObjInpStruct and Marshal
+// for a type safe alternative.
+func (param *Param) SetDataObjInp(input map[string]interface{}) *Param {
+	inp := param.DataObjInp()
+
+	if objPath, ok := input["objPath"].(string); ok {
+		inp.ObjPath = objPath
+	}
+
+	if createMode, ok := input["createMode"].(int); ok {
+		inp.CreateMode = createMode
+	}
+
+	if openFlags, ok := input["openFlags"].(int); ok {
+		inp.OpenFlags = openFlags
+	}
+
+	return param.set(DataObjInp_MS_T, inp)
+}
+
+// DataObjCopyInp returns the fields of the underlying DataObjCopyInp_MS_T parameter
+func (param *Param) DataObjCopyInp() DataObjCopyInp {
+	v, _ := param.get(DataObjCopyInp_MS_T)
+	inp, _ := v.(DataObjCopyInp)
+	return inp
+}
+
+// SetDataObjCopyInp sets the fields of the underlying DataObjCopyInp_MS_T parameter
+func (param *Param) SetDataObjCopyInp(inp DataObjCopyInp) *Param {
+	return param.set(DataObjCopyInp_MS_T, inp)
+}
+
+// CollInp returns the fields of the underlying CollInp_MS_T parameter
+func (param *Param) CollInp() CollInp {
+	v, _ := param.get(CollInp_MS_T)
+	inp, _ := v.(CollInp)
+	return inp
+}
+
+// SetCollInp sets the fields of the underlying CollInp_MS_T parameter
+func (param *Param) SetCollInp(inp CollInp) *Param {
+	return param.set(CollInp_MS_T, inp)
+}
+
+// ExecCmd returns the fields of the underlying ExecCmd_MS_T parameter
+func (param *Param) ExecCmd() ExecCmd {
+	v, _ := param.get(ExecCmd_MS_T)
+	cmd, _ := v.(ExecCmd)
+	return cmd
+}
+
+// SetExecCmd sets the fields of the underlying ExecCmd_MS_T parameter
+func (param *Param) SetExecCmd(cmd ExecCmd) *Param {
+	return param.set(ExecCmd_MS_T, cmd)
+}
+
+// ExecCmdOut returns the output and status of the underlying ExecCmdOut_MS_T parameter
+func (param *Param) ExecCmdOut() ExecCmdOut {
+	v, _ := param.get(ExecCmdOut_MS_T)
+	out, _ := v.(ExecCmdOut)
+	return out
+}
+
+// SetExecCmdOut sets the output and status of the underlying ExecCmdOut_MS_T parameter
+func (param *Param) SetExecCmdOut(out ExecCmdOut) *Param {
+	return param.set(ExecCmdOut_MS_T, out)
+}
+
+// RodsObjStat returns the fields of the underlying RodsObjStat_MS_T parameter
+func (param *Param) RodsObjStat() RodsObjStat {
+	v, _ := param.get(RodsObjStat_MS_T)
+	stat, _ := v.(RodsObjStat)
+	return stat
+}
+
+// SetRodsObjStat sets the fields of the underlying RodsObjStat_MS_T parameter
+func (param *Param) SetRodsObjStat(stat RodsObjStat) *Param {
+	return param.set(RodsObjStat_MS_T, stat)
+}
+
+// GenQueryInp returns the query described by the underlying GenQueryInp_MS_T parameter
+func (param *Param) GenQueryInp() GenQueryInp {
+	v, _ := param.get(GenQueryInp_MS_T)
+	inp, _ := v.(GenQueryInp)
+	return inp
+}
+
+// SetGenQueryInp sets the query of the underlying GenQueryInp_MS_T parameter
+func (param *Param) SetGenQueryInp(inp GenQueryInp) *Param {
+	return param.set(GenQueryInp_MS_T, inp)
+}
+
+// GenQueryOut returns the results held by the underlying GenQueryOut_MS_T parameter
+func (param *Param) GenQueryOut() GenQueryOut {
+	v, _ := param.get(GenQueryOut_MS_T)
+	out, _ := v.(GenQueryOut)
+	return out
+}
+
+// SetGenQueryOut sets the results of the underlying GenQueryOut_MS_T parameter
+func (param *Param) SetGenQueryOut(out GenQueryOut) *Param {
+	return param.set(GenQueryOut_MS_T, out)
+}
+
+// DataObjLseekOut returns the offset of the underlying DataObjLseekOut_MS_T parameter, set by msiDataObjLseek
+func (param *Param) DataObjLseekOut() int64 {
+	if v, ok := param.get(DataObjLseekOut_MS_T); ok {
+		return v.(int64)
+	}
+	return -1
+}
+
+// DataObjInfo returns the replicas described by the underlying DataObjInfo_MS_T parameter
+func (param *Param) DataObjInfo() []DataObjInfo {
+	v, _ := param.get(DataObjInfo_MS_T)
+	infos, _ := v.([]DataObjInfo)
+	return append([]DataObjInfo(nil), infos...)
+}
+
+// SetDataObjInfo sets the fields of the first replica of the underlying DataObjInfo_MS_T parameter
+func (param *Param) SetDataObjInfo(info DataObjInfo) *Param {
+	if param.rodsType == DataObjInfo_MS_T {
+		infos := param.DataObjInfo()
+		if len(infos) == 0 {
+			infos = make([]DataObjInfo, 1)
+		}
+
+		infos[0] = info
+		param.value = infos
+	}
+	return param
+}
@@ -0,0 +1,224 @@
+package msi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Context is passed as the first argument to microservices registered with msi.Register.
+// It carries the ruleExecInfo_t* of the running rule.
+type Context struct {
+	// Name is the name the microservice was invoked as
+	Name string
+
+	rei      unsafe.Pointer
+	username string
+}
+
+// NewContext creates a *Context for a ruleExecInfo_t*, cast as an unsafe.Pointer
+func NewContext(name string, ruleExecInfo unsafe.Pointer) *Context {
+	return &Context{
+		Name: name,
+		rei:  ruleExecInfo,
+	}
+}
+
+// RuleExecInfo returns the ruleExecInfo_t* of the running rule
+func (ctx *Context) RuleExecInfo() unsafe.Pointer {
+	return ctx.rei
+}
+
+// Username returns the name of the user running the rule
+func (ctx *Context) Username() string {
+	if ctx.username != "" || ctx.rei == nil {
+		return ctx.username
+	}
+
+	return reiUsername(ctx.rei)
+}
+
+// SetUsername overrides the user reported by Username, for testing
+func (ctx *Context) SetUsername(username string) {
+	ctx.username = username
+}
+
+// Call invokes another microservice with the ruleExecInfo_t* of the running rule, see msi.Call
+func (ctx *Context) Call(msiName string, params ...interface{}) error {
+	return callWith(ctx.rei, msiName, params...)
+}
+
+// Microservice is a Go function that implements a microservice, see msi.Register
+type Microservice struct {
+	name string
+	fn   reflect.Value
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]*Microservice)
+
+	contextType = reflect.TypeOf((*Context)(nil))
+	paramType   = reflect.TypeOf((*Param)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Register makes a Go function available as the microservice name. The function must take a
+// *msi.Context followed by one argument per microservice parameter, and return an error:
+//
+//	msi.Register("msiMyThing", func(ctx *msi.Context, path string, opts MyOpts, out *int) error {
+//		...
+//	})
+//
+// Arguments of type *msi.Param receive the msParam_t* as is. Pointers to other types are output
+// parameters: they start out with the value of the parameter if it has one, and their value is
+// written back with msi.Marshal when the function returns without error. Arguments of any other
+// type are converted with msi.Unmarshal; a parameter that can't be converted fails the call with
+// SYS_INVALID_INPUT_PARAM. The status reported to the rule engine is derived from the returned
+// error with msi.StatusCode, and panics are reported as SYS_INTERNAL_ERR.
+//
+// Registered functions are invoked through the C++ plugin written by WritePluginShim, linked
+// against the Go code built with -buildmode=c-shared. Register is usually called from init.
+func Register(name string, fn interface{}) error {
+	ms, err := NewMicroservice(name, fn)
+	if err != nil {
+		return err
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("Unable to register %v, a microservice with that name is already registered", name)
+	}
+
+	registry[name] = ms
+
+	return nil
+}
+
+// NewMicroservice checks that fn has the signature required by msi.Register, and wraps it as
+// the microservice name without registering it
+func NewMicroservice(name string, fn interface{}) (*Microservice, error) {
+	fnVal := reflect.ValueOf(fn)
+
+	if fnVal.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Unable to register %v, %T is not a function", name, fn)
+	}
+
+	fnType := fnVal.Type()
+
+	if fnType.NumIn() < 1 || fnType.In(0) != contextType {
+		return nil, fmt.Errorf("Unable to register %v, the first argument must be a *msi.Context", name)
+	}
+
+	if fnType.NumOut() != 1 || fnType.Out(0) != errorType {
+		return nil, fmt.Errorf("Unable to register %v, the function must return a single error", name)
+	}
+
+	if fnType.IsVariadic() {
+		return nil, fmt.Errorf("Unable to register %v, variadic functions are not supported", name)
+	}
+
+	return &Microservice{
+		name: name,
+		fn:   fnVal,
+	}, nil
+}
+
+// Name returns the name of the microservice
+func (ms *Microservice) Name() string {
+	return ms.name
+}
+
+// NumParams returns the number of microservice parameters taken by the function
+func (ms *Microservice) NumParams() int {
+	return ms.fn.Type().NumIn() - 1
+}
+
+// Lookup returns the microservice registered as name, or nil
+func Lookup(name string) *Microservice {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	return registry[name]
+}
+
+// NumParams returns the number of microservice parameters taken by the function registered as name,
+// or -1 if no function is registered with that name
+func NumParams(name string) int {
+	if ms := Lookup(name); ms != nil {
+		return ms.NumParams()
+	}
+
+	return -1
+}
+
+// Invoke converts the params to the argument types of the function, calls it and writes
+// the output parameters back. See msi.Register for the conversion rules.
+func (ms *Microservice) Invoke(ctx *Context, params []*Param) (err error) {
+	fnType := ms.fn.Type()
+
+	if len(params) != fnType.NumIn()-1 {
+		return NewError(SYS_INVALID_INPUT_PARAM, "%v expects %v parameters, got %v", ms.name, fnType.NumIn()-1, len(params))
+	}
+
+	args := make([]reflect.Value, fnType.NumIn())
+	args[0] = reflect.ValueOf(ctx)
+
+	for inx, param := range params {
+		argType := fnType.In(inx + 1)
+
+		switch {
+		case argType == paramType:
+			args[inx+1] = reflect.ValueOf(param)
+
+		case argType.Kind() == reflect.Ptr:
+			arg := reflect.New(argType.Elem())
+
+			// Output parameters may also carry input
+			if param.data() != nil {
+				if uErr := Unmarshal(param, arg.Interface()); uErr != nil {
+					return NewError(SYS_INVALID_INPUT_PARAM, "%v: parameter %v: %v", ms.name, inx+1, uErr)
+				}
+			}
+
+			args[inx+1] = arg
+
+		default:
+			arg := reflect.New(argType)
+
+			if uErr := Unmarshal(param, arg.Interface()); uErr != nil {
+				return NewError(SYS_INVALID_INPUT_PARAM, "%v: parameter %v: %v", ms.name, inx+1, uErr)
+			}
+
+			args[inx+1] = arg.Elem()
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewError(SYS_INTERNAL_ERR, "%v panicked: %v", ms.name, r)
+		}
+	}()
+
+	if ret := ms.fn.Call(args)[0]; !ret.IsNil() {
+		return ret.Interface().(error)
+	}
+
+	for inx, param := range params {
+		if fnType.In(inx+1) == paramType || fnType.In(inx+1).Kind() != reflect.Ptr || !param.canStore() {
+			continue
+		}
+
+		out, mErr := Marshal(args[inx+1].Interface())
+		if mErr != nil {
+			return NewError(SYS_INTERNAL_ERR, "%v: output parameter %v: %v", ms.name, inx+1, mErr)
+		}
+
+		param.moveFrom(out)
+	}
+
+	return nil
+}
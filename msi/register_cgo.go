@@ -0,0 +1,57 @@
+//go:build cgo && !msifake
+// +build cgo,!msifake
+
+package msi
+
+/*
+#include <stdlib.h>
+#include "call_microservice.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// reiUsername reads the name of the user running the rule from a ruleExecInfo_t*
+func reiUsername(rei unsafe.Pointer) string {
+	return C.GoString(C.GetReiUsername(rei))
+}
+
+// GoRodsCallMicroservice is the entry point of the C++ plugin shim, it invokes the microservice
+// registered as name and returns an iRODS status code
+//
+//export GoRodsCallMicroservice
+func GoRodsCallMicroservice(name *C.char, ruleExecInfo unsafe.Pointer, cParams **C.msParam_t, numParams C.int) C.int {
+	msiName := C.GoString(name)
+	ctx := NewContext(msiName, ruleExecInfo)
+
+	params := make([]*Param, int(numParams))
+	for inx := range params {
+		if cParam := C.GetMsParamListItem(cParams, C.int(inx)); cParam != nil {
+			params[inx] = ToParam(unsafe.Pointer(cParam))
+		} else {
+			params[inx] = new(Param)
+		}
+	}
+
+	var err error
+
+	if ms := Lookup(msiName); ms != nil {
+		err = ms.Invoke(ctx, params)
+	} else {
+		err = NewError(SYS_INVALID_INPUT_PARAM, "No Go microservice is registered as %v", msiName)
+	}
+
+	status := StatusCode(err)
+
+	if err != nil {
+		msg := C.CString(fmt.Sprintf("%v: %v", msiName, err))
+		defer C.free(unsafe.Pointer(msg))
+
+		C.ReportError(ruleExecInfo, C.int(status), msg)
+	}
+
+	return C.int(status)
+}
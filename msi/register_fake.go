@@ -0,0 +1,13 @@
+//go:build !cgo || msifake
+// +build !cgo msifake
+
+package msi
+
+import (
+	"unsafe"
+)
+
+// reiUsername has no ruleExecInfo_t to read without cgo, use Context.SetUsername
+func reiUsername(rei unsafe.Pointer) string {
+	return ""
+}
@@ -0,0 +1,86 @@
+package msi
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// pluginShim is the C++ source of an iRODS 4.2 microservice plugin that forwards to a Go microservice
+var pluginShim = template.Must(template.New("shim").Funcs(template.FuncMap{"join": strings.Join}).Parse(`// Code generated by msi.WritePluginShim. DO NOT EDIT.
+//
+// iRODS microservice plugin for {{.Name}}, implemented in Go. Build the Go package with
+// -buildmode=c-shared and link this file against it, e.g.
+//
+//   go build -buildmode=c-shared -o libgo{{.Name}}.so .
+//   clang++ -std=c++14 -fPIC -shared -I/usr/include/irods -o lib{{.Name}}.so {{.Name}}.cpp -L. -lgo{{.Name}}
+
+#include "irods_ms_plugin.hpp"
+#include "irods_re_structs.hpp"
+#include "msParam.h"
+
+extern "C" int GoRodsCallMicroservice(char* name, void* rei, msParam_t** params, int numParams);
+
+int {{.Name}}({{range .Params}}msParam_t* {{.}}, {{end}}ruleExecInfo_t* rei) {
+{{- if .Params}}
+	msParam_t* params[] = { {{join .Params ", "}} };
+{{- else}}
+	msParam_t** params = NULL;
+{{- end}}
+
+	return GoRodsCallMicroservice((char*)"{{.Name}}", rei, params, {{len .Params}});
+}
+
+extern "C" irods::ms_table_entry* plugin_factory() {
+	irods::ms_table_entry* msvc = new irods::ms_table_entry({{len .Params}});
+
+	msvc->add_operation<{{range .Params}}msParam_t*, {{end}}ruleExecInfo_t*>(
+		"{{.Name}}",
+		std::function<int({{range .Params}}msParam_t*, {{end}}ruleExecInfo_t*)>({{.Name}}));
+
+	return msvc;
+}
+`))
+
+// WritePluginShim writes the C++ source of the iRODS plugin for the Go microservice registered
+// as name. iRODS loads microservice plugins by file name, so each registered microservice
+// needs its own plugin, named lib<name>.so.
+func WritePluginShim(w io.Writer, name string) error {
+	numParams := NumParams(name)
+	if numParams < 0 {
+		return fmt.Errorf("Unable to write plugin shim, no Go microservice is registered as %v", name)
+	}
+
+	if !isIdentifier(name) {
+		return fmt.Errorf("Unable to write plugin shim, %v is not a valid C identifier", name)
+	}
+
+	params := make([]string, numParams)
+	for inx := range params {
+		params[inx] = fmt.Sprintf("param%v", inx+1)
+	}
+
+	return pluginShim.Execute(w, struct {
+		Name   string
+		Params []string
+	}{name, params})
+}
+
+// isIdentifier reports whether name can be used as a C function name
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
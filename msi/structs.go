@@ -0,0 +1,151 @@
+package msi
+
+// DataObjInp is the golang representation of dataObjInp_t, used by DataObjInp_MS_T parameters
+type DataObjInp struct {
+	ObjPath    string            `msi:"objPath"`
+	CreateMode int               `msi:"createMode"`
+	OpenFlags  int               `msi:"openFlags"`
+	Offset     int64             `msi:"offset"`
+	DataSize   int64             `msi:"dataSize"`
+	NumThreads int               `msi:"numThreads"`
+	OprType    int               `msi:"oprType"`
+	CondInput  map[string]string `msi:"condInput"`
+}
+
+// DataObjCopyInp is the golang representation of dataObjCopyInp_t, used by DataObjCopyInp_MS_T parameters
+type DataObjCopyInp struct {
+	Src  DataObjInp `msi:"srcDataObjInp"`
+	Dest DataObjInp `msi:"destDataObjInp"`
+}
+
+// CollInp is the golang representation of collInp_t, used by CollInp_MS_T parameters
+type CollInp struct {
+	CollName  string            `msi:"collName"`
+	Flags     int               `msi:"flags"`
+	OprType   int               `msi:"oprType"`
+	CondInput map[string]string `msi:"condInput"`
+}
+
+// ExecCmd is the golang representation of execCmd_t, used by ExecCmd_MS_T parameters
+type ExecCmd struct {
+	Cmd           string            `msi:"cmd"`
+	CmdArgv       string            `msi:"cmdArgv"`
+	ExecAddr      string            `msi:"execAddr"`
+	HintPath      string            `msi:"hintPath"`
+	AddPathToArgv bool              `msi:"addPathToArgv"`
+	CondInput     map[string]string `msi:"condInput"`
+}
+
+// ExecCmdOut is the golang representation of execCmdOut_t, used by ExecCmdOut_MS_T parameters
+type ExecCmdOut struct {
+	Stdout []byte `msi:"stdoutBuf"`
+	Stderr []byte `msi:"stderrBuf"`
+	Status int    `msi:"status"`
+}
+
+// RodsObjStat is the golang representation of rodsObjStat_t, used by RodsObjStat_MS_T parameters.
+// ObjType is one of the iRODS objType_t values (1 for data objects, 2 for collections).
+type RodsObjStat struct {
+	ObjSize    int64  `msi:"objSize"`
+	ObjType    int    `msi:"objType"`
+	DataMode   uint32 `msi:"dataMode"`
+	DataId     string `msi:"dataId"`
+	Chksum     string `msi:"chksum"`
+	OwnerName  string `msi:"ownerName"`
+	OwnerZone  string `msi:"ownerZone"`
+	CreateTime string `msi:"createTime"`
+	ModifyTime string `msi:"modifyTime"`
+	RescHier   string `msi:"rescHier"`
+}
+
+// GenQuerySelect is a column selected by a GenQueryInp, Option holds flags like ORDER_BY or SELECT_COUNT
+type GenQuerySelect struct {
+	Column int
+	Option int
+}
+
+// GenQueryCondition is a condition of a GenQueryInp, for example {COL_COLL_NAME, "= '/tempZone/home'"}
+type GenQueryCondition struct {
+	Column    int
+	Condition string
+}
+
+// GenQueryInp is the golang representation of genQueryInp_t, used by GenQueryInp_MS_T parameters.
+// Columns are the numeric COL_* identifiers of the iRODS catalog.
+type GenQueryInp struct {
+	MaxRows     int                 `msi:"maxRows"`
+	ContinueInx int                 `msi:"continueInx"`
+	RowOffset   int                 `msi:"rowOffset"`
+	Options     int                 `msi:"options"`
+	CondInput   map[string]string   `msi:"condInput"`
+	Select      []GenQuerySelect    `msi:"selectInp"`
+	Conditions  []GenQueryCondition `msi:"sqlCondInp"`
+}
+
+// GenQueryColumn holds the values of a single column of a GenQueryOut
+type GenQueryColumn struct {
+	AttriInx int
+	Values   []string
+}
+
+// GenQueryOut is the golang representation of genQueryOut_t, used by GenQueryOut_MS_T parameters
+type GenQueryOut struct {
+	RowCnt        int              `msi:"rowCnt"`
+	ContinueInx   int              `msi:"continueInx"`
+	TotalRowCount int              `msi:"totalRowCount"`
+	Columns       []GenQueryColumn `msi:"sqlResult"`
+}
+
+// Rows returns the results of the query row by row, each row keyed by column (AttriInx)
+func (out GenQueryOut) Rows() []map[int]string {
+	rows := make([]map[int]string, out.RowCnt)
+
+	for r := range rows {
+		rows[r] = make(map[int]string, len(out.Columns))
+
+		for _, col := range out.Columns {
+			if r < len(col.Values) {
+				rows[r][col.AttriInx] = col.Values[r]
+			}
+		}
+	}
+
+	return rows
+}
+
+// DataObjInfo is the golang representation of dataObjInfo_t, used by DataObjInfo_MS_T parameters.
+// Each replica of a data object is described by its own DataObjInfo.
+type DataObjInfo struct {
+	ObjPath        string            `msi:"objPath"`
+	RescName       string            `msi:"rescName"`
+	RescHier       string            `msi:"rescHier"`
+	DataType       string            `msi:"dataType"`
+	DataSize       int64             `msi:"dataSize"`
+	Chksum         string            `msi:"chksum"`
+	Version        string            `msi:"version"`
+	FilePath       string            `msi:"filePath"`
+	DataOwnerName  string            `msi:"dataOwnerName"`
+	DataOwnerZone  string            `msi:"dataOwnerZone"`
+	ReplNum        int               `msi:"replNum"`
+	ReplStatus     int               `msi:"replStatus"`
+	StatusString   string            `msi:"statusString"`
+	DataId         int64             `msi:"dataId"`
+	CollId         int64             `msi:"collId"`
+	DataMapId      int               `msi:"dataMapId"`
+	Flags          int               `msi:"flags"`
+	DataComments   string            `msi:"dataComments"`
+	DataMode       string            `msi:"dataMode"`
+	DataExpiry     string            `msi:"dataExpiry"`
+	DataCreate     string            `msi:"dataCreate"`
+	DataModify     string            `msi:"dataModify"`
+	DataAccess     string            `msi:"dataAccess"`
+	DataAccessInx  int               `msi:"dataAccessInx"`
+	WriteFlag      int               `msi:"writeFlag"`
+	DestRescName   string            `msi:"destRescName"`
+	BackupRescName string            `msi:"backupRescName"`
+	SubPath        string            `msi:"subPath"`
+	RegUid         int               `msi:"regUid"`
+	OtherFlags     int               `msi:"otherFlags"`
+	CondInput      map[string]string `msi:"condInput"`
+	RescId         int64             `msi:"rescId"`
+}
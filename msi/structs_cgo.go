@@ -0,0 +1,396 @@
+//go:build cgo && !msifake
+// +build cgo,!msifake
+
+package msi
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include "call_microservice.h"
+#include "rcMisc.h"
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// charArrString converts a fixed size, NUL terminated C char array to a string
+func charArrString(arr []C.char) string {
+	if len(arr) == 0 {
+		return ""
+	}
+
+	return C.GoString(&arr[0])
+}
+
+// setCharArr copies val into a fixed size C char array, truncating it to fit the terminating NUL
+func setCharArr(arr []C.char, val string) {
+	if len(arr) == 0 {
+		return
+	}
+
+	n := len(val)
+	if n > len(arr)-1 {
+		n = len(arr) - 1
+	}
+
+	for i := 0; i < n; i++ {
+		arr[i] = C.char(val[i])
+	}
+
+	arr[n] = 0
+}
+
+// cStrings views a C char** array as a slice
+func cStrings(ptr **C.char, n int) []*C.char {
+	if ptr == nil || n <= 0 {
+		return nil
+	}
+
+	return (*[1 << 28]*C.char)(unsafe.Pointer(ptr))[:n:n]
+}
+
+// cInts views a C int* array as a slice
+func cInts(ptr *C.int, n int) []C.int {
+	if ptr == nil || n <= 0 {
+		return nil
+	}
+
+	return (*[1 << 28]C.int)(unsafe.Pointer(ptr))[:n:n]
+}
+
+// kvpToMap converts a keyValPair_t to a map
+func kvpToMap(kvp *C.keyValPair_t) map[string]string {
+	m := make(map[string]string)
+
+	keys := cStrings(kvp.keyWord, int(kvp.len))
+	vals := cStrings(kvp.value, int(kvp.len))
+
+	for i := range keys {
+		m[C.GoString(keys[i])] = C.GoString(vals[i])
+	}
+
+	return m
+}
+
+// addKVP adds the entries of m to a keyValPair_t
+func addKVP(kvp *C.keyValPair_t, m map[string]string) {
+	for key, value := range m {
+		cKey := C.CString(key)
+		cValue := C.CString(value)
+
+		C.addKeyVal(kvp, cKey, cValue)
+
+		C.free(unsafe.Pointer(cKey))
+		C.free(unsafe.Pointer(cValue))
+	}
+}
+
+// setKVP replaces the entries of a keyValPair_t with those of m
+func setKVP(kvp *C.keyValPair_t, m map[string]string) {
+	C.clearKeyVal(kvp)
+	addKVP(kvp, m)
+}
+
+func dataObjInpFromC(c *C.dataObjInp_t) DataObjInp {
+	return DataObjInp{
+		ObjPath:    charArrString(c.objPath[:]),
+		CreateMode: int(c.createMode),
+		OpenFlags:  int(c.openFlags),
+		Offset:     int64(c.offset),
+		DataSize:   int64(c.dataSize),
+		NumThreads: int(c.numThreads),
+		OprType:    int(c.oprType),
+		CondInput:  kvpToMap(&c.condInput),
+	}
+}
+
+func (inp DataObjInp) toC(c *C.dataObjInp_t) {
+	setCharArr(c.objPath[:], inp.ObjPath)
+
+	c.createMode = C.int(inp.CreateMode)
+	c.openFlags = C.int(inp.OpenFlags)
+	c.offset = C.rodsLong_t(inp.Offset)
+	c.dataSize = C.rodsLong_t(inp.DataSize)
+	c.numThreads = C.int(inp.NumThreads)
+	c.oprType = C.int(inp.OprType)
+
+	setKVP(&c.condInput, inp.CondInput)
+}
+
+func collInpFromC(c *C.collInp_t) CollInp {
+	return CollInp{
+		CollName:  charArrString(c.collName[:]),
+		Flags:     int(c.flags),
+		OprType:   int(c.oprType),
+		CondInput: kvpToMap(&c.condInput),
+	}
+}
+
+func (inp CollInp) toC(c *C.collInp_t) {
+	setCharArr(c.collName[:], inp.CollName)
+
+	c.flags = C.int(inp.Flags)
+	c.oprType = C.int(inp.OprType)
+
+	setKVP(&c.condInput, inp.CondInput)
+}
+
+func execCmdFromC(c *C.execCmd_t) ExecCmd {
+	return ExecCmd{
+		Cmd:           charArrString(c.cmd[:]),
+		CmdArgv:       charArrString(c.cmdArgv[:]),
+		ExecAddr:      charArrString(c.execAddr[:]),
+		HintPath:      charArrString(c.hintPath[:]),
+		AddPathToArgv: c.addPathToArgv != 0,
+		CondInput:     kvpToMap(&c.condInput),
+	}
+}
+
+func (cmd ExecCmd) toC(c *C.execCmd_t) {
+	setCharArr(c.cmd[:], cmd.Cmd)
+	setCharArr(c.cmdArgv[:], cmd.CmdArgv)
+	setCharArr(c.execAddr[:], cmd.ExecAddr)
+	setCharArr(c.hintPath[:], cmd.HintPath)
+
+	c.addPathToArgv = 0
+	if cmd.AddPathToArgv {
+		c.addPathToArgv = 1
+	}
+
+	setKVP(&c.condInput, cmd.CondInput)
+}
+
+// bytesBufToGo copies the contents of a bytesBuf_t
+func bytesBufToGo(buf *C.bytesBuf_t) []byte {
+	if buf.buf == nil || buf.len <= 0 {
+		return nil
+	}
+
+	return C.GoBytes(buf.buf, buf.len)
+}
+
+// setBytesBuf replaces the contents of a bytesBuf_t with a malloc'd copy of b
+func setBytesBuf(buf *C.bytesBuf_t, b []byte) {
+	C.free(buf.buf)
+
+	buf.buf = nil
+	buf.len = 0
+
+	if len(b) > 0 {
+		buf.buf = C.CBytes(b)
+		buf.len = C.int(len(b))
+	}
+}
+
+func rodsObjStatFromC(c *C.rodsObjStat_t) RodsObjStat {
+	return RodsObjStat{
+		ObjSize:    int64(c.objSize),
+		ObjType:    int(c.objType),
+		DataMode:   uint32(c.dataMode),
+		DataId:     charArrString(c.dataId[:]),
+		Chksum:     charArrString(c.chksum[:]),
+		OwnerName:  charArrString(c.ownerName[:]),
+		OwnerZone:  charArrString(c.ownerZone[:]),
+		CreateTime: charArrString(c.createTime[:]),
+		ModifyTime: charArrString(c.modifyTime[:]),
+		RescHier:   charArrString(c.rescHier[:]),
+	}
+}
+
+func (stat RodsObjStat) toC(c *C.rodsObjStat_t) {
+	c.objSize = C.rodsLong_t(stat.ObjSize)
+	c.objType = C.objType_t(stat.ObjType)
+	c.dataMode = C.uint(stat.DataMode)
+
+	setCharArr(c.dataId[:], stat.DataId)
+	setCharArr(c.chksum[:], stat.Chksum)
+	setCharArr(c.ownerName[:], stat.OwnerName)
+	setCharArr(c.ownerZone[:], stat.OwnerZone)
+	setCharArr(c.createTime[:], stat.CreateTime)
+	setCharArr(c.modifyTime[:], stat.ModifyTime)
+	setCharArr(c.rescHier[:], stat.RescHier)
+}
+
+func genQueryInpFromC(c *C.genQueryInp_t) GenQueryInp {
+	inp := GenQueryInp{
+		MaxRows:     int(c.maxRows),
+		ContinueInx: int(c.continueInx),
+		RowOffset:   int(c.rowOffset),
+		Options:     int(c.options),
+		CondInput:   kvpToMap(&c.condInput),
+	}
+
+	selInx := cInts(c.selectInp.inx, int(c.selectInp.len))
+	selVal := cInts(c.selectInp.value, int(c.selectInp.len))
+
+	for i := range selInx {
+		inp.Select = append(inp.Select, GenQuerySelect{int(selInx[i]), int(selVal[i])})
+	}
+
+	condInx := cInts(c.sqlCondInp.inx, int(c.sqlCondInp.len))
+	condVal := cStrings(c.sqlCondInp.value, int(c.sqlCondInp.len))
+
+	for i := range condInx {
+		inp.Conditions = append(inp.Conditions, GenQueryCondition{int(condInx[i]), C.GoString(condVal[i])})
+	}
+
+	return inp
+}
+
+func (inp GenQueryInp) toC(c *C.genQueryInp_t) {
+	C.clearGenQueryInp(c)
+
+	c.maxRows = C.int(inp.MaxRows)
+	c.continueInx = C.int(inp.ContinueInx)
+	c.rowOffset = C.int(inp.RowOffset)
+	c.options = C.int(inp.Options)
+
+	addKVP(&c.condInput, inp.CondInput)
+
+	for _, sel := range inp.Select {
+		C.addInxIval(&c.selectInp, C.int(sel.Column), C.int(sel.Option))
+	}
+
+	for _, cond := range inp.Conditions {
+		cCond := C.CString(cond.Condition)
+		C.addInxVal(&c.sqlCondInp, C.int(cond.Column), cCond)
+		C.free(unsafe.Pointer(cCond))
+	}
+}
+
+func genQueryOutFromC(c *C.genQueryOut_t) GenQueryOut {
+	out := GenQueryOut{
+		RowCnt:        int(c.rowCnt),
+		ContinueInx:   int(c.continueInx),
+		TotalRowCount: int(c.totalRowCount),
+	}
+
+	for a := 0; a < int(c.attriCnt) && a < len(c.sqlResult); a++ {
+		result := &c.sqlResult[a]
+		col := GenQueryColumn{AttriInx: int(result.attriInx)}
+
+		for r := 0; r < out.RowCnt && result.value != nil; r++ {
+			val := (*C.char)(unsafe.Pointer(uintptr(unsafe.Pointer(result.value)) + uintptr(r*int(result.len))))
+			col.Values = append(col.Values, C.GoString(val))
+		}
+
+		out.Columns = append(out.Columns, col)
+	}
+
+	return out
+}
+
+func (out GenQueryOut) toC(c *C.genQueryOut_t) {
+	C.clearGenQueryOut(c)
+
+	c.rowCnt = C.int(out.RowCnt)
+	c.continueInx = C.int(out.ContinueInx)
+	c.totalRowCount = C.int(out.TotalRowCount)
+	c.attriCnt = 0
+
+	for a, col := range out.Columns {
+		if a >= len(c.sqlResult) {
+			break
+		}
+
+		// Values of a column are stored in a single block of rowCnt fixed size strings
+		width := 1
+		for _, v := range col.Values {
+			if len(v)+1 > width {
+				width = len(v) + 1
+			}
+		}
+
+		size := C.size_t(width * out.RowCnt)
+		block := C.malloc(size + 1)
+		C.memset(block, 0, size+1)
+
+		for r, v := range col.Values {
+			if r >= out.RowCnt {
+				break
+			}
+
+			dst := (*[1 << 30]byte)(block)[r*width : r*width+width : r*width+width]
+			copy(dst, v)
+		}
+
+		c.sqlResult[a].attriInx = C.int(col.AttriInx)
+		c.sqlResult[a].len = C.int(width)
+		c.sqlResult[a].value = (*C.char)(block)
+		c.attriCnt++
+	}
+}
+
+func dataObjInfoFromC(c *C.dataObjInfo_t) DataObjInfo {
+	return DataObjInfo{
+		ObjPath:        charArrString(c.objPath[:]),
+		RescName:       charArrString(c.rescName[:]),
+		RescHier:       charArrString(c.rescHier[:]),
+		DataType:       charArrString(c.dataType[:]),
+		DataSize:       int64(c.dataSize),
+		Chksum:         charArrString(c.chksum[:]),
+		Version:        charArrString(c.version[:]),
+		FilePath:       charArrString(c.filePath[:]),
+		DataOwnerName:  charArrString(c.dataOwnerName[:]),
+		DataOwnerZone:  charArrString(c.dataOwnerZone[:]),
+		ReplNum:        int(c.replNum),
+		ReplStatus:     int(c.replStatus),
+		StatusString:   charArrString(c.statusString[:]),
+		DataId:         int64(c.dataId),
+		CollId:         int64(c.collId),
+		DataMapId:      int(c.dataMapId),
+		Flags:          int(c.flags),
+		DataComments:   charArrString(c.dataComments[:]),
+		DataMode:       charArrString(c.dataMode[:]),
+		DataExpiry:     charArrString(c.dataExpiry[:]),
+		DataCreate:     charArrString(c.dataCreate[:]),
+		DataModify:     charArrString(c.dataModify[:]),
+		DataAccess:     charArrString(c.dataAccess[:]),
+		DataAccessInx:  int(c.dataAccessInx),
+		WriteFlag:      int(c.writeFlag),
+		DestRescName:   charArrString(c.destRescName[:]),
+		BackupRescName: charArrString(c.backupRescName[:]),
+		SubPath:        charArrString(c.subPath[:]),
+		RegUid:         int(c.regUid),
+		OtherFlags:     int(c.otherFlags),
+		CondInput:      kvpToMap(&c.condInput),
+		RescId:         int64(c.rescId),
+	}
+}
+
+func (info DataObjInfo) toC(c *C.dataObjInfo_t) {
+	setCharArr(c.objPath[:], info.ObjPath)
+	setCharArr(c.rescName[:], info.RescName)
+	setCharArr(c.rescHier[:], info.RescHier)
+	setCharArr(c.dataType[:], info.DataType)
+	c.dataSize = C.rodsLong_t(info.DataSize)
+	setCharArr(c.chksum[:], info.Chksum)
+	setCharArr(c.version[:], info.Version)
+	setCharArr(c.filePath[:], info.FilePath)
+	setCharArr(c.dataOwnerName[:], info.DataOwnerName)
+	setCharArr(c.dataOwnerZone[:], info.DataOwnerZone)
+	c.replNum = C.int(info.ReplNum)
+	c.replStatus = C.int(info.ReplStatus)
+	setCharArr(c.statusString[:], info.StatusString)
+	c.dataId = C.rodsLong_t(info.DataId)
+	c.collId = C.rodsLong_t(info.CollId)
+	c.dataMapId = C.int(info.DataMapId)
+	c.flags = C.int(info.Flags)
+	setCharArr(c.dataComments[:], info.DataComments)
+	setCharArr(c.dataMode[:], info.DataMode)
+	setCharArr(c.dataExpiry[:], info.DataExpiry)
+	setCharArr(c.dataCreate[:], info.DataCreate)
+	setCharArr(c.dataModify[:], info.DataModify)
+	setCharArr(c.dataAccess[:], info.DataAccess)
+	c.dataAccessInx = C.int(info.DataAccessInx)
+	c.writeFlag = C.int(info.WriteFlag)
+	setCharArr(c.destRescName[:], info.DestRescName)
+	setCharArr(c.backupRescName[:], info.BackupRescName)
+	setCharArr(c.subPath[:], info.SubPath)
+	c.regUid = C.int(info.RegUid)
+	c.otherFlags = C.int(info.OtherFlags)
+	setKVP(&c.condInput, info.CondInput)
+	c.rescId = C.rodsLong_t(info.RescId)
+}
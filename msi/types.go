@@ -70,7 +70,12 @@ const (
 
 // iRODS constants for use in microservice return value
 const (
-	SUCCESS                 = 0
-	SYS_INTERNAL_ERR        = -154000
-	SYS_INVALID_INPUT_PARAM = -130000
+	SUCCESS                      = 0
+	SYS_INTERNAL_ERR             = -154000
+	SYS_INVALID_INPUT_PARAM      = -130000
+	NO_MICROSERVICE_FOUND_ERR    = -160000
+	USER_FILE_DOES_NOT_EXIST     = -310000
+	OVERWRITE_WITHOUT_FORCE_FLAG = -312000
+	USER__NULL_INPUT_ERR         = -316000
+	USER_PARAM_TYPE_ERR          = -322000
 )
@@ -0,0 +1,233 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import "encoding/xml"
+
+// API numbers of the requests, from apiNumber.h
+const (
+	DATA_OBJ_CREATE_AN             = 601
+	DATA_OBJ_OPEN_AN               = 602
+	DATA_OBJ_REPL_AN               = 610
+	DATA_OBJ_UNLINK_AN             = 615
+	DATA_OBJ_RENAME_AN             = 627
+	DATA_OBJ_CHKSUM_AN             = 629
+	DATA_OBJ_PHYMV_AN              = 631
+	DATA_OBJ_TRIM_AN               = 632
+	OBJ_STAT_AN                    = 633
+	DATA_OBJ_CLOSE_AN              = 673
+	DATA_OBJ_LSEEK_AN              = 674
+	DATA_OBJ_READ_AN               = 675
+	DATA_OBJ_WRITE_AN              = 676
+	RM_COLL_AN                     = 679
+	COLL_CREATE_AN                 = 681
+	GEN_QUERY_AN                   = 702
+	AUTH_REQUEST_AN                = 703
+	AUTH_RESPONSE_AN               = 704
+	MOD_AVU_METADATA_AN            = 706
+	MOD_ACCESS_CONTROL_AN          = 707
+	TICKET_ADMIN_AN                = 723
+	SYS_SVR_TO_CLI_COLL_STAT       = 99999996
+	SYS_CLI_TO_SVR_COLL_STAT_REPLY = 99999997
+)
+
+// Object types of RodsObjStat_PI
+const (
+	DATA_OBJ_T = 1
+	COLL_OBJ_T = 2
+)
+
+// Operation types of DataObjCopyInp_PI
+const (
+	RENAME_DATA_OBJ = 11
+	RENAME_COLL     = 12
+)
+
+// Error codes returned by the server, from rodsErrorTable.h
+const (
+	SYS_INVALID_INPUT_PARAM    = -130000
+	USER_FILE_DOES_NOT_EXIST   = -310000
+	USER_CHKSUM_MISMATCH       = -314000
+	OBJ_PATH_DOES_NOT_EXIST    = -358000
+	CAT_NO_ROWS_FOUND          = -808000
+	CAT_UNKNOWN_COLLECTION     = -814000
+	CAT_UNKNOWN_FILE           = -817000
+	CAT_INVALID_AUTHENTICATION = -826000
+	CAT_INVALID_USER           = -827000
+)
+
+// KeyValPair is the KeyValPair_PI of requests, see NewKeyValPair
+type KeyValPair struct {
+	XMLName xml.Name `xml:"KeyValPair_PI"`
+	Len     int      `xml:"ssLen"`
+	Keys    []string `xml:"keyWord"`
+	Values  []string `xml:"svalue"`
+}
+
+// NewKeyValPair builds a KeyValPair_PI from alternating keys and values
+func NewKeyValPair(kv ...string) KeyValPair {
+	var pair KeyValPair
+
+	for inx := 0; inx+1 < len(kv); inx += 2 {
+		pair.Keys = append(pair.Keys, kv[inx])
+		pair.Values = append(pair.Values, kv[inx+1])
+	}
+
+	pair.Len = len(pair.Keys)
+
+	return pair
+}
+
+// Get returns the value of key, and whether it is set
+func (pair KeyValPair) Get(key string) (string, bool) {
+	for inx, k := range pair.Keys {
+		if k == key && inx < len(pair.Values) {
+			return pair.Values[inx], true
+		}
+	}
+
+	return "", false
+}
+
+// StartupPack is the StartupPack_PI sent with RODS_CONNECT
+type StartupPack struct {
+	XMLName        xml.Name `xml:"StartupPack_PI"`
+	IrodsProt      int      `xml:"irodsProt"`
+	ReconnFlag     int      `xml:"reconnFlag"`
+	ConnectCnt     int      `xml:"connectCnt"`
+	ProxyUser      string   `xml:"proxyUser"`
+	ProxyRcatZone  string   `xml:"proxyRcatZone"`
+	ClientUser     string   `xml:"clientUser"`
+	ClientRcatZone string   `xml:"clientRcatZone"`
+	RelVersion     string   `xml:"relVersion"`
+	APIVersion     string   `xml:"apiVersion"`
+	Option         string   `xml:"option"`
+}
+
+// Version is the Version_PI replied to RODS_CONNECT
+type Version struct {
+	XMLName    xml.Name `xml:"Version_PI"`
+	Status     int      `xml:"status"`
+	RelVersion string   `xml:"relVersion"`
+	APIVersion string   `xml:"apiVersion"`
+	ReconnPort int      `xml:"reconnPort"`
+	ReconnAddr string   `xml:"reconnAddr"`
+	Cookie     int      `xml:"cookie"`
+}
+
+// AuthRequestOut carries the base64 encoded challenge of native authentication
+type AuthRequestOut struct {
+	XMLName   xml.Name `xml:"authRequestOut_PI"`
+	Challenge string   `xml:"challenge"`
+}
+
+// AuthResponseInp carries the base64 encoded response to the challenge
+type AuthResponseInp struct {
+	XMLName  xml.Name `xml:"authResponseInp_PI"`
+	Response string   `xml:"response"`
+	Username string   `xml:"username"`
+}
+
+// DataObjInp is the DataObjInp_PI of open, create, unlink and stat requests
+type DataObjInp struct {
+	XMLName    xml.Name   `xml:"DataObjInp_PI"`
+	ObjPath    string     `xml:"objPath"`
+	CreateMode int        `xml:"createMode"`
+	OpenFlags  int        `xml:"openFlags"`
+	Offset     int64      `xml:"offset"`
+	DataSize   int64      `xml:"dataSize"`
+	NumThreads int        `xml:"numThreads"`
+	OprType    int        `xml:"oprType"`
+	CondInput  KeyValPair `xml:"KeyValPair_PI"`
+}
+
+// OpenedDataObjInp is the OpenedDataObjInp_PI of read, write, lseek and close requests
+type OpenedDataObjInp struct {
+	XMLName      xml.Name   `xml:"OpenedDataObjInp_PI"`
+	L1descInx    int        `xml:"l1descInx"`
+	Len          int        `xml:"len"`
+	Whence       int        `xml:"whence"`
+	OprType      int        `xml:"oprType"`
+	Offset       int64      `xml:"offset"`
+	BytesWritten int64      `xml:"bytesWritten"`
+	CondInput    KeyValPair `xml:"KeyValPair_PI"`
+}
+
+// FileLseekOut is the fileLseekOut_PI replied to lseek requests
+type FileLseekOut struct {
+	XMLName xml.Name `xml:"fileLseekOut_PI"`
+	Offset  int64    `xml:"offset"`
+}
+
+// CollInp is the CollInpNew_PI of collection create and remove requests
+type CollInp struct {
+	XMLName   xml.Name   `xml:"CollInpNew_PI"`
+	CollName  string     `xml:"collName"`
+	Flags     int        `xml:"flags"`
+	OprType   int        `xml:"oprType"`
+	CondInput KeyValPair `xml:"KeyValPair_PI"`
+}
+
+// DataObjCopyInp is the DataObjCopyInp_PI of rename requests, Inp holds the source and the
+// destination
+type DataObjCopyInp struct {
+	XMLName xml.Name     `xml:"DataObjCopyInp_PI"`
+	Inp     []DataObjInp `xml:"DataObjInp_PI"`
+}
+
+// RodsObjStat is the RodsObjStat_PI replied to stat requests
+type RodsObjStat struct {
+	XMLName    xml.Name `xml:"RodsObjStat_PI"`
+	ObjSize    int64    `xml:"objSize"`
+	ObjType    int      `xml:"objType"`
+	DataMode   int      `xml:"dataMode"`
+	DataId     string   `xml:"dataId"`
+	Chksum     string   `xml:"chksum"`
+	OwnerName  string   `xml:"ownerName"`
+	OwnerZone  string   `xml:"ownerZone"`
+	CreateTime string   `xml:"createTime"`
+	ModifyTime string   `xml:"modifyTime"`
+}
+
+// ModAVUMetadataInp is the ModAVUMetadataInp_PI of imeta requests, the arguments are those of imeta
+type ModAVUMetadataInp struct {
+	XMLName xml.Name `xml:"ModAVUMetadataInp_PI"`
+	Arg0    string   `xml:"arg0"`
+	Arg1    string   `xml:"arg1"`
+	Arg2    string   `xml:"arg2"`
+	Arg3    string   `xml:"arg3"`
+	Arg4    string   `xml:"arg4"`
+	Arg5    string   `xml:"arg5"`
+	Arg6    string   `xml:"arg6"`
+	Arg7    string   `xml:"arg7"`
+	Arg8    string   `xml:"arg8"`
+	Arg9    string   `xml:"arg9"`
+}
+
+// ModAccessControlInp is the modAccessControlInp_PI of ichmod requests
+type ModAccessControlInp struct {
+	XMLName       xml.Name `xml:"modAccessControlInp_PI"`
+	RecursiveFlag int      `xml:"recursiveFlag"`
+	AccessLevel   string   `xml:"accessLevel"`
+	UserName      string   `xml:"userName"`
+	Zone          string   `xml:"zone"`
+	Path          string   `xml:"path"`
+}
+
+// STR is the STR_PI replied to checksum requests
+type STR struct {
+	XMLName xml.Name `xml:"STR_PI"`
+	MyStr   string   `xml:"myStr"`
+}
+
+// TicketAdminInp is the ticketAdminInp_PI of iticket requests, the arguments are those of iticket
+type TicketAdminInp struct {
+	XMLName xml.Name `xml:"ticketAdminInp_PI"`
+	Arg1    string   `xml:"arg1"`
+	Arg2    string   `xml:"arg2"`
+	Arg3    string   `xml:"arg3"`
+	Arg4    string   `xml:"arg4"`
+	Arg5    string   `xml:"arg5"`
+	Arg6    string   `xml:"arg6"`
+}
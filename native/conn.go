@@ -0,0 +1,421 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package native is a pure Go client of the iRODS wire protocol, for builds without cgo and the
+// iRODS C libraries (static binaries, cross compilation, Alpine containers). It speaks the XML
+// protocol, authenticates with the native (password) scheme, and implements
+// irodsfs.Filesystem with GenQuery, data object open/read/write/close and metadata requests, and
+// irodsfs.ReplicaFS with the replication, trim and checksum requests:
+//
+//	con, err := native.Dial(native.Options{
+//		Host:     "localhost",
+//		Port:     1247,
+//		Zone:     "tempZone",
+//		Username: "rods",
+//		Password: "password",
+//	})
+//
+//	data, err := irodsfs.ReadFile(con, "/tempZone/home/rods/hello.txt")
+//
+// Package connect picks this client or the cgo one with the gorods_native build tag, and package
+// gorods connects with it in those builds. SSL, PAM and parallel transfers aren't supported.
+package native
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Protocol versions sent in the startup pack
+const (
+	RelVersion = "rods4.2.8"
+	APIVersion = "d"
+
+	// XML_PROT selects the XML packing instructions
+	XML_PROT = 1
+
+	// maxPasswordLen is the length the password is padded to in the challenge response
+	maxPasswordLen = 50
+)
+
+// Options describes how to reach and authenticate to an iRODS server. ClientUser and
+// ClientZone default to Username and Zone, they differ when a rodsadmin acts for another user.
+// Ticket is used for the requests of the session when it's set, see Conn.SetTicket.
+type Options struct {
+	Host       string
+	Port       int
+	Zone       string
+	Username   string
+	Password   string
+	ClientUser string
+	ClientZone string
+	Ticket     string
+	Timeout    time.Duration
+	AppName    string
+}
+
+// Error is an error status returned by the server
+type Error struct {
+	Code    int
+	Message string
+}
+
+func newError(code int, msg string) *Error {
+	return &Error{
+		Code:    code,
+		Message: msg,
+	}
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("iRODS error %v: %v", err.Code, err.Message)
+}
+
+// Is reports whether the error matches target: errors.Is(err, os.ErrNotExist) is true for the errors of
+// missing collections and data objects
+func (err *Error) Is(target error) bool {
+	if target != os.ErrNotExist {
+		return false
+	}
+
+	// The errno of a failed system call is added to the status
+	switch err.Code / 1000 * 1000 {
+	case USER_FILE_DOES_NOT_EXIST, OBJ_PATH_DOES_NOT_EXIST, CAT_UNKNOWN_COLLECTION, CAT_UNKNOWN_FILE:
+		return true
+	}
+
+	return false
+}
+
+// IsCode reports whether err is an *Error with the status code
+func IsCode(err error, code int) bool {
+	if e, ok := err.(*Error); ok {
+		return e.Code == code
+	}
+
+	return false
+}
+
+// Conn is a connection to an iRODS server. Requests are serialized, a Conn can be shared by
+// goroutines.
+type Conn struct {
+	opts    Options
+	conn    net.Conn
+	mu      sync.Mutex
+	version Version
+	closed  bool
+}
+
+// Dial connects to the server described by opts and authenticates
+func Dial(opts Options) (*Conn, error) {
+	if opts.Port == 0 {
+		opts.Port = 1247
+	}
+
+	netConn, err := net.DialTimeout("tcp", net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)), opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	con, err := NewConn(netConn, opts)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return con, nil
+}
+
+// NewConn runs the startup and authentication exchanges over an established net.Conn
+func NewConn(netConn net.Conn, opts Options) (*Conn, error) {
+	if opts.ClientUser == "" {
+		opts.ClientUser = opts.Username
+	}
+
+	if opts.ClientZone == "" {
+		opts.ClientZone = opts.Zone
+	}
+
+	if opts.AppName == "" {
+		opts.AppName = "gorods"
+	}
+
+	con := &Conn{
+		opts: opts,
+		conn: netConn,
+	}
+
+	if err := con.startup(); err != nil {
+		return nil, err
+	}
+
+	if err := con.authenticate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Ticket != "" {
+		if err := con.SetTicket(opts.Ticket); err != nil {
+			return nil, err
+		}
+	}
+
+	return con, nil
+}
+
+// Version returns the release version reported by the server, like rods4.2.8
+func (con *Conn) Version() string {
+	return con.version.RelVersion
+}
+
+// Username returns the name of the authenticated user
+func (con *Conn) Username() string {
+	return con.opts.ClientUser
+}
+
+// Zone returns the zone of the authenticated user
+func (con *Conn) Zone() string {
+	return con.opts.ClientZone
+}
+
+func (con *Conn) startup() error {
+	msg, err := NewMessage(RODS_CONNECT, 0, &StartupPack{
+		IrodsProt:      XML_PROT,
+		ProxyUser:      con.opts.Username,
+		ProxyRcatZone:  con.opts.Zone,
+		ClientUser:     con.opts.ClientUser,
+		ClientRcatZone: con.opts.ClientZone,
+		RelVersion:     RelVersion,
+		APIVersion:     APIVersion,
+		Option:         con.opts.AppName,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	reply, err := con.exchange(msg)
+	if err != nil {
+		return err
+	}
+
+	if reply.Header.Type != RODS_VERSION {
+		return fmt.Errorf("Expected %v, got %v", RODS_VERSION, reply.Header.Type)
+	}
+
+	if err := Unpack(reply.Body, &con.version); err != nil {
+		return err
+	}
+
+	if con.version.Status < 0 {
+		return newError(con.version.Status, "Connection refused")
+	}
+
+	return nil
+}
+
+// ChallengeResponse computes the native authentication response to a challenge
+func ChallengeResponse(challenge []byte, password string) []byte {
+	buf := make([]byte, len(challenge)+maxPasswordLen)
+
+	copy(buf, challenge)
+	copy(buf[len(challenge):], password)
+
+	sum := md5.Sum(buf)
+
+	// The server treats the response as a C string, so zero bytes are replaced
+	for inx := range sum {
+		if sum[inx] == 0 {
+			sum[inx] = 1
+		}
+	}
+
+	return sum[:]
+}
+
+func (con *Conn) authenticate() error {
+	var challenge AuthRequestOut
+
+	if _, err := con.Request(AUTH_REQUEST_AN, nil, nil, &challenge); err != nil {
+		return err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(challenge.Challenge)
+	if err != nil {
+		return fmt.Errorf("Invalid authentication challenge: %v", err)
+	}
+
+	_, err = con.Request(AUTH_RESPONSE_AN, &AuthResponseInp{
+		Response: base64.StdEncoding.EncodeToString(ChallengeResponse(raw, con.opts.Password)),
+		Username: con.opts.Username + "#" + con.opts.Zone,
+	}, nil, nil)
+
+	return err
+}
+
+// exchange writes msg and reads the reply
+func (con *Conn) exchange(msg *Message) (*Message, error) {
+	if err := WriteMessage(con.conn, msg); err != nil {
+		return nil, err
+	}
+
+	return ReadMessage(con.conn)
+}
+
+// Request sends the API request apiNumber with the packing instruction struct inp and the byte
+// stream bs, and unpacks the reply body into out when it isn't nil. It returns the reply, whose
+// IntInfo holds the status (file descriptors and byte counts), or an *Error with the negative status.
+func (con *Conn) Request(apiNumber int, inp interface{}, bs []byte, out interface{}) (*Message, error) {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	if con.closed {
+		return nil, fmt.Errorf("Connection closed")
+	}
+
+	msg, err := NewMessage(RODS_API_REQ, apiNumber, inp, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := con.exchange(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collection operations report progress, each report has to be acknowledged
+	for reply.Header.IntInfo == SYS_SVR_TO_CLI_COLL_STAT {
+		if err := binary.Write(con.conn, binary.BigEndian, int32(SYS_CLI_TO_SVR_COLL_STAT_REPLY)); err != nil {
+			return nil, err
+		}
+
+		if reply, err = ReadMessage(con.conn); err != nil {
+			return nil, err
+		}
+	}
+
+	if reply.Header.IntInfo < 0 {
+		return reply, newError(reply.Header.IntInfo, fmt.Sprintf("API request %v failed %s", apiNumber, reply.Error))
+	}
+
+	if out != nil && len(reply.Body) > 0 {
+		if err := Unpack(reply.Body, out); err != nil {
+			return reply, err
+		}
+	}
+
+	return reply, nil
+}
+
+// Query runs a GenQuery and returns all its rows, fetching them MaxRows at a time
+func (con *Conn) Query(query GenQuery) ([]map[string]string, error) {
+	inp, err := query.Input()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0)
+
+	for {
+		var out GenQueryOut
+
+		if _, err := con.Request(GEN_QUERY_AN, inp, nil, &out); err != nil {
+			if IsCode(err, CAT_NO_ROWS_FOUND) {
+				return rows, nil
+			}
+
+			return nil, err
+		}
+
+		rows = append(rows, out.Rows()...)
+
+		if out.ContinueInx <= 0 {
+			return rows, nil
+		}
+
+		inp.ContinueInx = out.ContinueInx
+	}
+}
+
+// QueryPage runs query and returns limit rows starting at row offset, with the number of rows
+// matched by the query
+func (con *Conn) QueryPage(query GenQuery, offset int, limit int) ([]map[string]string, int, error) {
+	inp, err := query.Input()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	inp.Options |= RETURN_TOTAL_ROW_COUNT
+	inp.PartialStartIndex = offset
+
+	rows := make([]map[string]string, 0, limit)
+	total := 0
+
+	for len(rows) < limit {
+		var out GenQueryOut
+
+		if inp.MaxRows = limit - len(rows); inp.MaxRows > MaxRows {
+			inp.MaxRows = MaxRows
+		}
+
+		if _, err := con.Request(GEN_QUERY_AN, inp, nil, &out); err != nil {
+			if IsCode(err, CAT_NO_ROWS_FOUND) {
+				return rows, total, nil
+			}
+
+			return nil, 0, err
+		}
+
+		if inp.ContinueInx == 0 {
+			total = out.TotalRowCount
+		}
+
+		rows = append(rows, out.Rows()...)
+
+		if out.ContinueInx <= 0 {
+			return rows, total, nil
+		}
+
+		inp.ContinueInx = out.ContinueInx
+	}
+
+	// Close the statement, the rows after the page aren't fetched
+	inp.MaxRows = 0
+
+	if _, err := con.Request(GEN_QUERY_AN, inp, nil, &GenQueryOut{}); err != nil && !IsCode(err, CAT_NO_ROWS_FOUND) {
+		return nil, 0, err
+	}
+
+	return rows, total, nil
+}
+
+// Disconnect ends the session and closes the connection
+func (con *Conn) Disconnect() error {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	if con.closed {
+		return nil
+	}
+
+	con.closed = true
+
+	msg, _ := NewMessage(RODS_DISCONNECT, 0, nil, nil)
+	writeErr := WriteMessage(con.conn, msg)
+
+	if err := con.conn.Close(); err != nil {
+		return err
+	}
+
+	if writeErr != nil && writeErr != io.EOF {
+		return writeErr
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// environment holds the irods_environment.json settings used by the client
+type environment struct {
+	Host     string `json:"irods_host"`
+	Port     int    `json:"irods_port"`
+	Zone     string `json:"irods_zone_name"`
+	Username string `json:"irods_user_name"`
+	Home     string `json:"irods_home"`
+	Cwd      string `json:"irods_cwd"`
+}
+
+// EnvironmentFile returns the path of irods_environment.json: $IRODS_ENVIRONMENT_FILE, or
+// ~/.irods/irods_environment.json
+func EnvironmentFile() string {
+	if p := os.Getenv("IRODS_ENVIRONMENT_FILE"); p != "" {
+		return p
+	}
+
+	home, _ := os.UserHomeDir()
+
+	return filepath.Join(home, ".irods", "irods_environment.json")
+}
+
+// LoadEnvironment reads the host, port, zone and user of an irods_environment.json file, the
+// EnvironmentFile when p is empty. The scrambled password of iinit (.irodsA) isn't read, set
+// Options.Password before calling Dial.
+func LoadEnvironment(p string) (Options, error) {
+	env, err := readEnvironment(p)
+	if err != nil {
+		return Options{}, err
+	}
+
+	return Options{
+		Host:     env.Host,
+		Port:     env.Port,
+		Zone:     env.Zone,
+		Username: env.Username,
+	}, nil
+}
+
+// EnvironmentCollections reads the home and current collections of an irods_environment.json file,
+// irods_home and irods_cwd, the EnvironmentFile when p is empty. They're empty when the file
+// doesn't set them.
+func EnvironmentCollections(p string) (home string, cwd string, err error) {
+	env, err := readEnvironment(p)
+	if err != nil {
+		return "", "", err
+	}
+
+	return env.Home, env.Cwd, nil
+}
+
+func readEnvironment(p string) (environment, error) {
+	if p == "" {
+		p = EnvironmentFile()
+	}
+
+	var env environment
+
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return env, err
+	}
+
+	err = json.Unmarshal(data, &env)
+
+	return env, err
+}
@@ -0,0 +1,184 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxChunk is the largest read or write request, larger buffers are split
+const maxChunk = 4 * 1024 * 1024
+
+// File is a data object opened with Conn.OpenFile, it implements irodsfs.File
+type File struct {
+	con  *Conn
+	path string
+	desc int
+	flag int
+	mu   sync.Mutex
+	pos  int64
+}
+
+// Path returns the path of the data object
+func (file *File) Path() string {
+	return file.path
+}
+
+// Descriptor returns the L1 descriptor of the server
+func (file *File) Descriptor() int {
+	return file.desc
+}
+
+// Read reads up to len(p) bytes at the current offset
+func (file *File) Read(p []byte) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(p) > maxChunk {
+		p = p[:maxChunk]
+	}
+
+	if err := file.seek(file.pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	reply, err := file.con.Request(DATA_OBJ_READ_AN, &OpenedDataObjInp{
+		L1descInx: file.desc,
+		Len:       len(p),
+	}, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	n := copy(p, reply.Bs)
+	file.pos += int64(n)
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+// ReadAt reads len(p) bytes at offset, without moving the offset used by Read and Write
+func (file *File) ReadAt(p []byte, offset int64) (int, error) {
+	file.mu.Lock()
+	pos := file.pos
+	file.pos = offset
+	file.mu.Unlock()
+
+	n := 0
+
+	defer func() {
+		file.mu.Lock()
+		file.pos = pos
+		file.mu.Unlock()
+	}()
+
+	for n < len(p) {
+		m, err := file.Read(p[n:])
+		n += m
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Write writes p at the current offset, or at the end of the data object with os.O_APPEND
+func (file *File) Write(p []byte) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	written := 0
+
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > maxChunk {
+			chunk = chunk[:maxChunk]
+		}
+
+		if err := file.seek(file.pos, io.SeekStart); err != nil {
+			return written, err
+		}
+
+		reply, err := file.con.Request(DATA_OBJ_WRITE_AN, &OpenedDataObjInp{
+			L1descInx: file.desc,
+			Len:       len(chunk),
+		}, chunk, nil)
+		if err != nil {
+			return written, err
+		}
+
+		n := reply.Header.IntInfo
+		written += n
+		file.pos += int64(n)
+
+		if n < len(chunk) {
+			return written, io.ErrShortWrite
+		}
+	}
+
+	return written, nil
+}
+
+// seek moves the offset of the server, file.mu must be held
+func (file *File) seek(offset int64, whence int) error {
+	var out FileLseekOut
+
+	if _, err := file.con.Request(DATA_OBJ_LSEEK_AN, &OpenedDataObjInp{
+		L1descInx: file.desc,
+		Offset:    offset,
+		Whence:    whence,
+	}, nil, &out); err != nil {
+		return err
+	}
+
+	file.pos = out.Offset
+
+	return nil
+}
+
+// Seek sets the offset of the next Read or Write
+func (file *File) Seek(offset int64, whence int) (int64, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset, whence = offset+file.pos, io.SeekStart
+	case io.SeekEnd:
+		if err := file.seek(offset, io.SeekEnd); err != nil {
+			return 0, err
+		}
+
+		return file.pos, nil
+	default:
+		return 0, fmt.Errorf("Invalid whence %v", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("Negative offset %v", offset)
+	}
+
+	file.pos = offset
+
+	return offset, nil
+}
+
+// Close closes the data object on the server, which registers the new size and replica status
+func (file *File) Close() error {
+	_, err := file.con.Request(DATA_OBJ_CLOSE_AN, &OpenedDataObjInp{L1descInx: file.desc}, nil, nil)
+
+	return err
+}
@@ -0,0 +1,616 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+var _ irodsfs.Filesystem = (*Conn)(nil)
+
+// Keywords of the KeyValPair_PI condInput, from rodsKeyWdDef.h
+const (
+	forceFlagKw    = "forceFlag"
+	recursiveOprKw = "recursiveOpr"
+	dataTypeKw     = "dataType"
+)
+
+// parseTime parses the unix seconds of the catalog
+func parseTime(s string) time.Time {
+	sec, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
+}
+
+// accessLevel converts an access name of the catalog, "read object" before iRODS 4.3 and
+// "read_object" since, to an irodsfs access level
+func accessLevel(name string) int {
+	level, err := irodsfs.ParseAccess(name)
+	if err != nil {
+		return irodsfs.Null
+	}
+
+	return level
+}
+
+// accessName converts an irodsfs access level to the access name of ichmod. Read and Write
+// keep their names from before iRODS 4.3, the granular levels need a 4.3 server.
+func accessName(level int) (string, error) {
+	switch {
+	case level == irodsfs.Read:
+		return "read", nil
+	case level == irodsfs.Write:
+		return "write", nil
+	case irodsfs.IsAccessLevel(level):
+		return irodsfs.AccessName(level), nil
+	}
+
+	return "", newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Invalid access level %v", level))
+}
+
+// userType converts a user type of the catalog to irodsfs.UserType, AdminType,
+// GroupAdminType or GroupType
+func userType(name string) int {
+	switch name {
+	case "rodsadmin":
+		return irodsfs.AdminType
+	case "groupadmin":
+		return irodsfs.GroupAdminType
+	case "rodsgroup":
+		return irodsfs.GroupType
+	}
+
+	return irodsfs.UserType
+}
+
+// Stat describes the collection or data object at p
+func (con *Conn) Stat(p string) (irodsfs.ObjInfo, error) {
+	var stat RodsObjStat
+
+	if _, err := con.Request(OBJ_STAT_AN, &DataObjInp{ObjPath: p}, nil, &stat); err != nil {
+		return irodsfs.ObjInfo{}, err
+	}
+
+	info := irodsfs.ObjInfo{
+		Path:       p,
+		Name:       path.Base(p),
+		Size:       stat.ObjSize,
+		Checksum:   stat.Chksum,
+		Owner:      stat.OwnerName + "#" + stat.OwnerZone,
+		CreateTime: parseTime(stat.CreateTime),
+		ModifyTime: parseTime(stat.ModifyTime),
+	}
+
+	info.Id, _ = strconv.ParseInt(stat.DataId, 10, 64)
+
+	switch stat.ObjType {
+	case DATA_OBJ_T:
+		info.Type = irodsfs.DataObjType
+	case COLL_OBJ_T:
+		info.Type = irodsfs.CollectionType
+	default:
+		return irodsfs.ObjInfo{}, newError(USER_FILE_DOES_NOT_EXIST, fmt.Sprintf("%v does not exist", p))
+	}
+
+	return info, nil
+}
+
+// collInfos describes the collections matching conds
+func (con *Conn) collInfos(conds ...Condition) ([]irodsfs.ObjInfo, error) {
+	rows, err := con.Query(GenQuery{
+		Columns:    []string{"COLL_ID", "COLL_NAME", "COLL_OWNER_NAME", "COLL_OWNER_ZONE", "COLL_CREATE_TIME", "COLL_MODIFY_TIME"},
+		Conditions: conds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]irodsfs.ObjInfo, 0, len(rows))
+
+	for _, row := range rows {
+		info := irodsfs.ObjInfo{
+			Path:       row["COLL_NAME"],
+			Name:       path.Base(row["COLL_NAME"]),
+			Type:       irodsfs.CollectionType,
+			Owner:      row["COLL_OWNER_NAME"] + "#" + row["COLL_OWNER_ZONE"],
+			CreateTime: parseTime(row["COLL_CREATE_TIME"]),
+			ModifyTime: parseTime(row["COLL_MODIFY_TIME"]),
+		}
+
+		info.Id, _ = strconv.ParseInt(row["COLL_ID"], 10, 64)
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+
+	return infos, nil
+}
+
+// dataInfos describes the data objects matching conds, with the size and checksum of their
+// newest good replica
+func (con *Conn) dataInfos(conds ...Condition) ([]irodsfs.ObjInfo, error) {
+	rows, err := con.Query(GenQuery{
+		Columns: []string{
+			"DATA_ID", "COLL_NAME", "DATA_NAME", "DATA_REPL_NUM", "DATA_REPL_STATUS", "DATA_SIZE", "DATA_CHECKSUM",
+			"DATA_OWNER_NAME", "DATA_OWNER_ZONE", "DATA_CREATE_TIME", "DATA_MODIFY_TIME",
+		},
+		Conditions: conds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]irodsfs.ObjInfo)
+	good := make(map[string]bool)
+
+	for _, row := range rows {
+		p := path.Join(row["COLL_NAME"], row["DATA_NAME"])
+		isGood := row["DATA_REPL_STATUS"] == "1"
+
+		info := irodsfs.ObjInfo{
+			Path:       p,
+			Name:       row["DATA_NAME"],
+			Type:       irodsfs.DataObjType,
+			Checksum:   row["DATA_CHECKSUM"],
+			Owner:      row["DATA_OWNER_NAME"] + "#" + row["DATA_OWNER_ZONE"],
+			CreateTime: parseTime(row["DATA_CREATE_TIME"]),
+			ModifyTime: parseTime(row["DATA_MODIFY_TIME"]),
+		}
+
+		info.Id, _ = strconv.ParseInt(row["DATA_ID"], 10, 64)
+		info.Size, _ = strconv.ParseInt(row["DATA_SIZE"], 10, 64)
+
+		prev, seen := byPath[p]
+
+		switch {
+		case !seen:
+		case isGood && !good[p]:
+		case isGood == good[p] && info.ModifyTime.After(prev.ModifyTime):
+		default:
+			continue
+		}
+
+		byPath[p] = info
+		good[p] = isGood
+	}
+
+	infos := make([]irodsfs.ObjInfo, 0, len(byPath))
+	for _, info := range byPath {
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+
+	return infos, nil
+}
+
+// List describes the collections and data objects in the collection p
+func (con *Conn) List(p string) ([]irodsfs.ObjInfo, error) {
+	info, err := con.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("%v is not a collection", p))
+	}
+
+	colls, err := con.collInfos(Equal("COLL_PARENT_NAME", p))
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := con.dataInfos(Equal("COLL_NAME", p))
+	if err != nil {
+		return nil, err
+	}
+
+	// The root collection is its own parent
+	list := make([]irodsfs.ObjInfo, 0, len(colls)+len(objs))
+	for _, coll := range colls {
+		if coll.Path != p {
+			list = append(list, coll)
+		}
+	}
+
+	return append(list, objs...), nil
+}
+
+// Open opens the data object p, see irodsfs.FS
+func (con *Conn) Open(p string, flag int) (irodsfs.File, error) {
+	return con.OpenFile(p, flag)
+}
+
+// OpenFile is Open returning the concrete *File
+func (con *Conn) OpenFile(p string, flag int) (*File, error) {
+	return con.openFile(p, flag)
+}
+
+// openFile opens or creates p, kv are added to the condInput of the open request
+func (con *Conn) openFile(p string, flag int, kv ...string) (*File, error) {
+	_, statErr := con.Stat(p)
+	exists := statErr == nil
+
+	inp := &DataObjInp{
+		ObjPath:    p,
+		CreateMode: 0644,
+		OpenFlags:  flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR | os.O_TRUNC),
+		CondInput:  NewKeyValPair(kv...),
+	}
+
+	apiNumber := DATA_OBJ_OPEN_AN
+
+	switch {
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, statErr
+
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("%v already exists", p))
+
+	case !exists:
+		apiNumber = DATA_OBJ_CREATE_AN
+		inp.CondInput = NewKeyValPair(append(kv, dataTypeKw, "generic")...)
+	}
+
+	reply, err := con.Request(apiNumber, inp, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{
+		con:  con,
+		path: p,
+		desc: reply.Header.IntInfo,
+		flag: flag,
+	}
+
+	if flag&os.O_APPEND != 0 {
+		if _, err := file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+// Mkdir creates the collection p, and its missing parents when recursive is set
+func (con *Conn) Mkdir(p string, recursive bool) error {
+	inp := &CollInp{CollName: p}
+
+	if recursive {
+		inp.CondInput = NewKeyValPair(recursiveOprKw, "")
+	}
+
+	_, err := con.Request(COLL_CREATE_AN, inp, nil, nil)
+
+	return err
+}
+
+// Remove deletes the data object or collection p without moving it to the trash
+func (con *Conn) Remove(p string, recursive bool) error {
+	info, err := con.Stat(p)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		_, err = con.Request(DATA_OBJ_UNLINK_AN, &DataObjInp{
+			ObjPath:   p,
+			CondInput: NewKeyValPair(forceFlagKw, ""),
+		}, nil, nil)
+
+		return err
+	}
+
+	kv := []string{forceFlagKw, ""}
+	if recursive {
+		kv = append(kv, recursiveOprKw, "")
+	}
+
+	_, err = con.Request(RM_COLL_AN, &CollInp{
+		CollName:  p,
+		CondInput: NewKeyValPair(kv...),
+	}, nil, nil)
+
+	return err
+}
+
+// Rename moves the data object or collection src to dest
+func (con *Conn) Rename(src string, dest string) error {
+	info, err := con.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	oprType := RENAME_DATA_OBJ
+	if info.IsDir() {
+		oprType = RENAME_COLL
+	}
+
+	_, err = con.Request(DATA_OBJ_RENAME_AN, &DataObjCopyInp{
+		Inp: []DataObjInp{
+			{ObjPath: src, OprType: oprType},
+			{ObjPath: dest, OprType: oprType},
+		},
+	}, nil, nil)
+
+	return err
+}
+
+// metaArgs returns the imeta object type flag and the AVU columns of p
+func (con *Conn) metaArgs(p string) (string, GenQuery, error) {
+	info, err := con.Stat(p)
+	if err != nil {
+		return "", GenQuery{}, err
+	}
+
+	if info.IsDir() {
+		return "-C", GenQuery{
+			Columns:    []string{"META_COLL_ATTR_NAME", "META_COLL_ATTR_VALUE", "META_COLL_ATTR_UNITS"},
+			Conditions: []Condition{Equal("COLL_NAME", p)},
+		}, nil
+	}
+
+	return "-d", GenQuery{
+		Columns:    []string{"META_DATA_ATTR_NAME", "META_DATA_ATTR_VALUE", "META_DATA_ATTR_UNITS"},
+		Conditions: []Condition{Equal("COLL_NAME", path.Dir(p)), Equal("DATA_NAME", path.Base(p))},
+	}, nil
+}
+
+// Meta returns the AVUs of p
+func (con *Conn) Meta(p string) ([]irodsfs.AVU, error) {
+	_, query, err := con.metaArgs(p)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := con.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	avus := make([]irodsfs.AVU, len(rows))
+	for inx, row := range rows {
+		avus[inx] = irodsfs.AVU{
+			Attribute: row[query.Columns[0]],
+			Value:     row[query.Columns[1]],
+			Units:     row[query.Columns[2]],
+		}
+	}
+
+	return avus, nil
+}
+
+// AddMeta attaches an AVU to p
+func (con *Conn) AddMeta(p string, avu irodsfs.AVU) error {
+	typ, _, err := con.metaArgs(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = con.Request(MOD_AVU_METADATA_AN, &ModAVUMetadataInp{
+		Arg0: "add",
+		Arg1: typ,
+		Arg2: p,
+		Arg3: avu.Attribute,
+		Arg4: avu.Value,
+		Arg5: avu.Units,
+	}, nil, nil)
+
+	return err
+}
+
+// DeleteMeta removes the AVUs of p with the attribute attr
+func (con *Conn) DeleteMeta(p string, attr string) error {
+	typ, _, err := con.metaArgs(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = con.Request(MOD_AVU_METADATA_AN, &ModAVUMetadataInp{
+		Arg0: "rmw",
+		Arg1: typ,
+		Arg2: p,
+		Arg3: attr,
+		Arg4: "%",
+	}, nil, nil)
+
+	return err
+}
+
+// ACL returns the access control list of p
+func (con *Conn) ACL(p string) ([]irodsfs.ACL, error) {
+	info, err := con.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	query := GenQuery{
+		Columns:    []string{"USER_NAME", "USER_ZONE", "USER_TYPE", "DATA_ACCESS_NAME"},
+		Conditions: []Condition{Equal("COLL_NAME", path.Dir(p)), Equal("DATA_NAME", path.Base(p))},
+	}
+
+	if info.IsDir() {
+		query = GenQuery{
+			Columns:    []string{"USER_NAME", "USER_ZONE", "USER_TYPE", "COLL_ACCESS_NAME"},
+			Conditions: []Condition{Equal("COLL_NAME", p)},
+		}
+	}
+
+	rows, err := con.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	acl := make([]irodsfs.ACL, len(rows))
+	for inx, row := range rows {
+		acl[inx] = irodsfs.ACL{
+			Principal:   row["USER_NAME"] + "#" + row["USER_ZONE"],
+			Type:        userType(row["USER_TYPE"]),
+			AccessLevel: accessLevel(row[query.Columns[3]]),
+		}
+	}
+
+	return acl, nil
+}
+
+// modAccess sends an ichmod request
+func (con *Conn) modAccess(p string, principal string, level string, recursive bool) error {
+	inp := &ModAccessControlInp{
+		AccessLevel: level,
+		Path:        p,
+	}
+
+	if recursive {
+		inp.RecursiveFlag = 1
+	}
+
+	split := strings.SplitN(principal, "#", 2)
+	inp.UserName = split[0]
+
+	if len(split) == 2 {
+		inp.Zone = split[1]
+	}
+
+	_, err := con.Request(MOD_ACCESS_CONTROL_AN, inp, nil, nil)
+
+	return err
+}
+
+// Chmod sets the access level of a user or group on p
+func (con *Conn) Chmod(p string, principal string, accessLevel int, recursive bool) error {
+	level, err := accessName(accessLevel)
+	if err != nil {
+		return err
+	}
+
+	return con.modAccess(p, principal, level, recursive)
+}
+
+// Inheritance reports whether ACL inheritance is enabled on the collection p
+func (con *Conn) Inheritance(p string) (bool, error) {
+	rows, err := con.Query(GenQuery{
+		Columns:    []string{"COLL_INHERITANCE"},
+		Conditions: []Condition{Equal("COLL_NAME", p)},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(rows) == 0 {
+		return false, newError(USER_FILE_DOES_NOT_EXIST, fmt.Sprintf("Collection %v does not exist", p))
+	}
+
+	return rows[0]["COLL_INHERITANCE"] == "1", nil
+}
+
+// SetInheritance enables or disables ACL inheritance on the collection p
+func (con *Conn) SetInheritance(p string, inherit bool, recursive bool) error {
+	level := "noinherit"
+	if inherit {
+		level = "inherit"
+	}
+
+	return con.modAccess(p, "", level, recursive)
+}
+
+// QueryMeta returns the collections and data objects with AVUs matching qString. Each
+// condition is a GenQuery of its own, an object matches when it matches all of them.
+func (con *Conn) QueryMeta(qString string) ([]irodsfs.ObjInfo, error) {
+	tokens, err := tokenize(qString)
+	if err != nil {
+		return nil, err
+	}
+
+	conds, err := parseConditions(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conds) == 0 {
+		return nil, newError(SYS_INVALID_INPUT_PARAM, "Empty query")
+	}
+
+	var results []irodsfs.ObjInfo
+
+	kinds := []struct {
+		prefix string
+		fetch  func(...Condition) ([]irodsfs.ObjInfo, error)
+	}{
+		{"META_COLL_ATTR_", con.collInfos},
+		{"META_DATA_ATTR_", con.dataInfos},
+	}
+
+	for _, kind := range kinds {
+		var matched map[string]irodsfs.ObjInfo
+
+		for _, cond := range conds {
+			infos, err := kind.fetch(
+				Equal(kind.prefix+"NAME", cond[0]),
+				Condition{Column: kind.prefix + "VALUE", Value: cond[1]},
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			found := make(map[string]irodsfs.ObjInfo, len(infos))
+			for _, info := range infos {
+				if _, ok := matched[info.Path]; matched == nil || ok {
+					found[info.Path] = info
+				}
+			}
+
+			if matched = found; len(matched) == 0 {
+				break
+			}
+		}
+
+		paths := make([]string, 0, len(matched))
+		for p := range matched {
+			paths = append(paths, p)
+		}
+
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			results = append(results, matched[p])
+		}
+	}
+
+	return results, nil
+}
+
+// IQuest runs a GenQuery written in the iquest language
+func (con *Conn) IQuest(query string, upperCase bool) ([]map[string]string, error) {
+	q, err := ParseIQuest(query, upperCase)
+	if err != nil {
+		return nil, err
+	}
+
+	return con.Query(q)
+}
+
+// IQuestPage runs a GenQuery written in the iquest language, and returns limit rows starting
+// at row offset with the number of rows matched by the query
+func (con *Conn) IQuestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	q, err := ParseIQuest(query, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return con.QueryPage(q, offset, limit)
+}
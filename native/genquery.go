@@ -0,0 +1,365 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Columns maps the iquest column names to their GenQuery numbers, from rodsGenQuery.h
+var Columns = map[string]int{
+	"ZONE_ID":              101,
+	"ZONE_NAME":            102,
+	"ZONE_TYPE":            103,
+	"ZONE_CONNECTION":      104,
+	"USER_ID":              201,
+	"USER_NAME":            202,
+	"USER_TYPE":            203,
+	"USER_ZONE":            204,
+	"RESC_ID":              301,
+	"RESC_NAME":            302,
+	"RESC_ZONE_NAME":       303,
+	"RESC_TYPE_NAME":       304,
+	"RESC_CLASS_NAME":      305,
+	"RESC_LOC":             306,
+	"RESC_VAULT_PATH":      307,
+	"DATA_ID":              401,
+	"D_COLL_ID":            402,
+	"DATA_NAME":            403,
+	"DATA_REPL_NUM":        404,
+	"DATA_VERSION":         405,
+	"DATA_TYPE_NAME":       406,
+	"DATA_SIZE":            407,
+	"DATA_RESC_NAME":       409,
+	"DATA_PATH":            410,
+	"DATA_OWNER_NAME":      411,
+	"DATA_OWNER_ZONE":      412,
+	"DATA_REPL_STATUS":     413,
+	"DATA_STATUS":          414,
+	"DATA_CHECKSUM":        415,
+	"DATA_EXPIRY":          416,
+	"DATA_MAP_ID":          417,
+	"DATA_COMMENTS":        418,
+	"DATA_CREATE_TIME":     419,
+	"DATA_MODIFY_TIME":     420,
+	"DATA_RESC_HIER":       422,
+	"COLL_ID":              500,
+	"COLL_NAME":            501,
+	"COLL_PARENT_NAME":     502,
+	"COLL_OWNER_NAME":      503,
+	"COLL_OWNER_ZONE":      504,
+	"COLL_MAP_ID":          505,
+	"COLL_INHERITANCE":     506,
+	"COLL_COMMENTS":        507,
+	"COLL_CREATE_TIME":     508,
+	"COLL_MODIFY_TIME":     509,
+	"META_DATA_ATTR_NAME":  600,
+	"META_DATA_ATTR_VALUE": 601,
+	"META_DATA_ATTR_UNITS": 602,
+	"META_DATA_ATTR_ID":    603,
+	"META_COLL_ATTR_NAME":  610,
+	"META_COLL_ATTR_VALUE": 611,
+	"META_COLL_ATTR_UNITS": 612,
+	"META_COLL_ATTR_ID":    613,
+	"DATA_ACCESS_TYPE":     700,
+	"DATA_ACCESS_NAME":     701,
+	"DATA_ACCESS_USER_ID":  703,
+	"DATA_ACCESS_DATA_ID":  704,
+	"COLL_ACCESS_TYPE":     710,
+	"COLL_ACCESS_NAME":     711,
+	"COLL_ACCESS_USER_ID":  713,
+	"COLL_ACCESS_COLL_ID":  714,
+	"USER_GROUP_ID":        900,
+	"USER_GROUP_NAME":      901,
+}
+
+// ColumnName returns the iquest name of a GenQuery column number
+func ColumnName(col int) (string, bool) {
+	for name, num := range Columns {
+		if num == col {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Query options, from rodsGenQuery.h
+const (
+	RETURN_TOTAL_ROW_COUNT = 0x20
+	UPPER_CASE_WHERE       = 0x200
+)
+
+// MaxRows is the number of rows fetched per GenQuery request
+const MaxRows = 500
+
+// InxIvalPair is the InxIvalPair_PI of the selected columns
+type InxIvalPair struct {
+	XMLName xml.Name `xml:"InxIvalPair_PI"`
+	Len     int      `xml:"iiLen"`
+	Inx     []int    `xml:"inx"`
+	Values  []int    `xml:"ivalue"`
+}
+
+// InxValPair is the InxValPair_PI of the conditions
+type InxValPair struct {
+	XMLName xml.Name `xml:"InxValPair_PI"`
+	Len     int      `xml:"isLen"`
+	Inx     []int    `xml:"inx"`
+	Values  []string `xml:"svalue"`
+}
+
+// GenQueryInp is the GenQueryInp_PI of GenQuery requests, PartialStartIndex is the rowOffset
+// of genQueryInp_t
+type GenQueryInp struct {
+	XMLName           xml.Name    `xml:"GenQueryInp_PI"`
+	MaxRows           int         `xml:"maxRows"`
+	ContinueInx       int         `xml:"continueInx"`
+	PartialStartIndex int         `xml:"partialStartIndex"`
+	Options           int         `xml:"options"`
+	CondInput         KeyValPair  `xml:"KeyValPair_PI"`
+	Select            InxIvalPair `xml:"InxIvalPair_PI"`
+	Where             InxValPair  `xml:"InxValPair_PI"`
+}
+
+// SqlResult is a column of GenQueryOut_PI
+type SqlResult struct {
+	XMLName  xml.Name `xml:"SqlResult_PI"`
+	AttriInx int      `xml:"attriInx"`
+	ResLen   int      `xml:"reslen"`
+	Values   []string `xml:"value"`
+}
+
+// GenQueryOut is the GenQueryOut_PI replied to GenQuery requests
+type GenQueryOut struct {
+	XMLName       xml.Name    `xml:"GenQueryOut_PI"`
+	RowCnt        int         `xml:"rowCnt"`
+	AttriCnt      int         `xml:"attriCnt"`
+	ContinueInx   int         `xml:"continueInx"`
+	TotalRowCount int         `xml:"totalRowCount"`
+	SqlResult     []SqlResult `xml:"SqlResult_PI"`
+}
+
+// Condition is a condition of a GenQuery, Value holds the operator and the quoted operand,
+// like "= 'x'" or "like '%.txt'"
+type Condition struct {
+	Column string
+	Value  string
+}
+
+// GenQuery is a catalog query on named columns
+type GenQuery struct {
+	Columns    []string
+	Conditions []Condition
+	UpperCase  bool
+}
+
+// Input builds the GenQueryInp_PI of the query
+func (query GenQuery) Input() (*GenQueryInp, error) {
+	inp := &GenQueryInp{
+		MaxRows: MaxRows,
+	}
+
+	if query.UpperCase {
+		inp.Options |= UPPER_CASE_WHERE
+	}
+
+	for _, col := range query.Columns {
+		num, ok := Columns[col]
+		if !ok {
+			return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Unknown column %v", col))
+		}
+
+		inp.Select.Inx = append(inp.Select.Inx, num)
+		inp.Select.Values = append(inp.Select.Values, 1)
+	}
+
+	for _, cond := range query.Conditions {
+		num, ok := Columns[cond.Column]
+		if !ok {
+			return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Unknown column %v", cond.Column))
+		}
+
+		inp.Where.Inx = append(inp.Where.Inx, num)
+		inp.Where.Values = append(inp.Where.Values, cond.Value)
+	}
+
+	inp.Select.Len = len(inp.Select.Inx)
+	inp.Where.Len = len(inp.Where.Inx)
+
+	return inp, nil
+}
+
+// Rows returns the rows of a GenQueryOut_PI, keyed by column name
+func (out *GenQueryOut) Rows() []map[string]string {
+	rows := make([]map[string]string, out.RowCnt)
+
+	for inx := range rows {
+		rows[inx] = make(map[string]string, len(out.SqlResult))
+	}
+
+	for _, res := range out.SqlResult {
+		name, ok := ColumnName(res.AttriInx)
+		if !ok {
+			name = fmt.Sprint(res.AttriInx)
+		}
+
+		for inx := 0; inx < out.RowCnt && inx < len(res.Values); inx++ {
+			rows[inx][name] = res.Values[inx]
+		}
+	}
+
+	return rows
+}
+
+// Quote quotes a condition operand
+func Quote(value string) string {
+	return "'" + value + "'"
+}
+
+// Equal is the condition col = 'value'
+func Equal(col string, value string) Condition {
+	return Condition{Column: col, Value: "= " + Quote(value)}
+}
+
+// ParseIQuest parses a query of the iquest language:
+//
+//	select COL[, COL]... [where COL op 'value' [|| op 'value']... [and COL op 'value']...]
+func ParseIQuest(query string, upperCase bool) (GenQuery, error) {
+	q := GenQuery{UpperCase: upperCase}
+
+	tokens, err := tokenize(strings.Replace(query, ",", " , ", -1))
+	if err != nil {
+		return q, err
+	}
+
+	if len(tokens) < 2 || strings.ToLower(tokens[0]) != "select" {
+		return q, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Invalid query %q, expected select", query))
+	}
+
+	tokens = tokens[1:]
+
+	for len(tokens) > 0 && strings.ToLower(tokens[0]) != "where" {
+		if tokens[0] != "," {
+			q.Columns = append(q.Columns, strings.ToUpper(tokens[0]))
+		}
+
+		tokens = tokens[1:]
+	}
+
+	if len(q.Columns) == 0 {
+		return q, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Invalid query %q, no columns selected", query))
+	}
+
+	if len(tokens) > 0 {
+		conds, err := parseConditions(tokens[1:])
+		if err != nil {
+			return q, err
+		}
+
+		for _, cond := range conds {
+			q.Conditions = append(q.Conditions, Condition{
+				Column: strings.ToUpper(cond[0]),
+				Value:  cond[1],
+			})
+		}
+	}
+
+	return q, nil
+}
+
+// parseConditions splits "a op 'v' and b op 'w' || op 'x'" tokens into attribute and condition
+// pairs, the condition being the operators and quoted operands of the attribute
+func parseConditions(tokens []string) ([][2]string, error) {
+	var conds [][2]string
+
+	for len(tokens) > 0 {
+		if len(tokens) < 3 {
+			return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Invalid condition %v", strings.Join(tokens, " ")))
+		}
+
+		attr := tokens[0]
+		tokens = tokens[1:]
+
+		var alts []string
+
+		for {
+			if len(tokens) < 2 {
+				return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Invalid condition of %v", attr))
+			}
+
+			op := strings.ToLower(tokens[0])
+			tokens = tokens[1:]
+
+			if op == "not" && len(tokens) > 1 {
+				op = "not " + strings.ToLower(tokens[0])
+				tokens = tokens[1:]
+			}
+
+			alts = append(alts, op+" "+Quote(tokens[0]))
+			tokens = tokens[1:]
+
+			if len(tokens) == 0 || tokens[0] != "||" {
+				break
+			}
+
+			tokens = tokens[1:]
+		}
+
+		conds = append(conds, [2]string{attr, strings.Join(alts, " || ")})
+
+		if len(tokens) > 0 {
+			if strings.ToLower(tokens[0]) != "and" {
+				return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Expected and, got %v", tokens[0]))
+			}
+
+			tokens = tokens[1:]
+		}
+	}
+
+	return conds, nil
+}
+
+// tokenize splits s on whitespace, keeping single or double quoted strings as one token
+func tokenize(s string) ([]string, error) {
+	var (
+		tokens []string
+		cur    []rune
+		quote  rune
+		quoted bool
+	)
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur = append(cur, r)
+			}
+		case r == '\'' || r == '"':
+			quote, quoted = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if len(cur) > 0 || quoted {
+				tokens = append(tokens, string(cur))
+			}
+
+			cur, quoted = nil, false
+		default:
+			cur = append(cur, r)
+		}
+	}
+
+	if quote != 0 {
+		return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Unterminated quote in %q", s))
+	}
+
+	if len(cur) > 0 || quoted {
+		tokens = append(tokens, string(cur))
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,174 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Message types of the header
+const (
+	RODS_CONNECT    = "RODS_CONNECT"
+	RODS_VERSION    = "RODS_VERSION"
+	RODS_API_REQ    = "RODS_API_REQ"
+	RODS_API_REPLY  = "RODS_API_REPLY"
+	RODS_DISCONNECT = "RODS_DISCONNECT"
+)
+
+// maxHeaderLen guards against reading garbage as a header length
+const maxHeaderLen = 1024 * 1024
+
+// MsgHeader is the MsgHeader_PI sent before every message. IntInfo is the API number of
+// requests and the status of replies.
+type MsgHeader struct {
+	XMLName  xml.Name `xml:"MsgHeader_PI"`
+	Type     string   `xml:"type"`
+	MsgLen   int      `xml:"msgLen"`
+	ErrorLen int      `xml:"errorLen"`
+	BsLen    int      `xml:"bsLen"`
+	IntInfo  int      `xml:"intInfo"`
+}
+
+// Message is a header with its body, error and byte stream
+type Message struct {
+	Header MsgHeader
+	Body   []byte
+	Error  []byte
+	Bs     []byte
+}
+
+// xmlEscapes replaces the numeric character references of encoding/xml with the entities
+// understood by the packing instruction parser of the server
+var xmlEscapes = [][2][]byte{
+	{[]byte("&#34;"), []byte("&quot;")},
+	{[]byte("&#39;"), []byte("&apos;")},
+	{[]byte("&#x9;"), []byte("\t")},
+	{[]byte("&#xA;"), []byte("\n")},
+	{[]byte("&#xD;"), []byte("\r")},
+}
+
+// Pack encodes a packing instruction struct as XML, nil encodes as an empty body
+func Pack(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	body, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, esc := range xmlEscapes {
+		body = bytes.Replace(body, esc[0], esc[1], -1)
+	}
+
+	return body, nil
+}
+
+// Unpack decodes an XML body into a packing instruction struct
+func Unpack(body []byte, v interface{}) error {
+	// The server sends apostrophes as &apos; and quotes as &quot;, both are understood by encoding/xml
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("Unable to decode %T: %v", v, err)
+	}
+
+	return nil
+}
+
+// NewMessage packs body into a message of type typ
+func NewMessage(typ string, intInfo int, body interface{}, bs []byte) (*Message, error) {
+	packed, err := Pack(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Header: MsgHeader{
+			Type:    typ,
+			MsgLen:  len(packed),
+			BsLen:   len(bs),
+			IntInfo: intInfo,
+		},
+		Body: packed,
+		Bs:   bs,
+	}, nil
+}
+
+// WriteMessage writes the header length, the header and the parts of msg to w
+func WriteMessage(w io.Writer, msg *Message) error {
+	msg.Header.MsgLen = len(msg.Body)
+	msg.Header.ErrorLen = len(msg.Error)
+	msg.Header.BsLen = len(msg.Bs)
+
+	header, err := Pack(&msg.Header)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.BigEndian, uint32(len(header)))
+	buf.Write(header)
+	buf.Write(msg.Body)
+	buf.Write(msg.Error)
+	buf.Write(msg.Bs)
+
+	_, err = w.Write(buf.Bytes())
+
+	return err
+}
+
+// ReadMessage reads a message written by WriteMessage from r
+func ReadMessage(r io.Reader) (*Message, error) {
+	var headerLen uint32
+
+	if err := binary.Read(r, binary.BigEndian, &headerLen); err != nil {
+		return nil, err
+	}
+
+	if headerLen == 0 || headerLen > maxHeaderLen {
+		return nil, fmt.Errorf("Invalid message header length %v", headerLen)
+	}
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	msg := new(Message)
+
+	if err := Unpack(header, &msg.Header); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		dest *[]byte
+		len  int
+	}{
+		{&msg.Body, msg.Header.MsgLen},
+		{&msg.Error, msg.Header.ErrorLen},
+		{&msg.Bs, msg.Header.BsLen},
+	}
+
+	for _, part := range parts {
+		if part.len < 0 {
+			return nil, fmt.Errorf("Invalid message part length %v", part.len)
+		}
+
+		if part.len == 0 {
+			continue
+		}
+
+		*part.dest = make([]byte, part.len)
+		if _, err := io.ReadFull(r, *part.dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
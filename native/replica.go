@@ -0,0 +1,185 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package native
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+var _ irodsfs.ReplicaFS = (*Conn)(nil)
+
+// Keywords of the replica requests, from rodsKeyWdDef.h
+const (
+	replNumKw      = "replNum"
+	rescNameKw     = "rescName"
+	destRescNameKw = "destRescName"
+	copiesKw       = "copies"
+	forceChksumKw  = "forceChksum"
+	verifyChksumKw = "verifyChksum"
+)
+
+// Replicas returns the replicas of the data object p, ordered by number
+func (con *Conn) Replicas(p string) ([]irodsfs.Replica, error) {
+	rows, err := con.Query(GenQuery{
+		Columns: []string{
+			"DATA_REPL_NUM", "DATA_RESC_NAME", "DATA_RESC_HIER", "DATA_REPL_STATUS", "DATA_SIZE", "DATA_CHECKSUM",
+			"DATA_PATH", "DATA_MODIFY_TIME",
+		},
+		Conditions: []Condition{Equal("COLL_NAME", path.Dir(p)), Equal("DATA_NAME", path.Base(p))},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, newError(USER_FILE_DOES_NOT_EXIST, fmt.Sprintf("%v does not exist", p))
+	}
+
+	repls := make([]irodsfs.Replica, 0, len(rows))
+
+	for _, row := range rows {
+		repl := irodsfs.Replica{
+			Resource:     row["DATA_RESC_NAME"],
+			RescHier:     row["DATA_RESC_HIER"],
+			Checksum:     row["DATA_CHECKSUM"],
+			PhysicalPath: row["DATA_PATH"],
+			ModifyTime:   parseTime(row["DATA_MODIFY_TIME"]),
+		}
+
+		repl.Num, _ = strconv.Atoi(row["DATA_REPL_NUM"])
+		repl.Status, _ = strconv.Atoi(row["DATA_REPL_STATUS"])
+		repl.Size, _ = strconv.ParseInt(row["DATA_SIZE"], 10, 64)
+
+		repls = append(repls, repl)
+	}
+
+	sort.Slice(repls, func(i, j int) bool { return repls[i].Num < repls[j].Num })
+
+	return repls, nil
+}
+
+// OpenReplica opens the replica numbered replNum of p, it can't create data objects
+func (con *Conn) OpenReplica(p string, replNum int, flag int) (irodsfs.File, error) {
+	if flag&os.O_CREATE != 0 {
+		return nil, newError(SYS_INVALID_INPUT_PARAM, fmt.Sprintf("Can't create the replica %v of %v", replNum, p))
+	}
+
+	return con.openFile(p, flag, replNumKw, strconv.Itoa(replNum))
+}
+
+// ChecksumReplica computes and registers the checksum of a replica, like ichksum -f -n
+func (con *Conn) ChecksumReplica(p string, replNum int) (string, error) {
+	var out STR
+
+	if _, err := con.Request(DATA_OBJ_CHKSUM_AN, &DataObjInp{
+		ObjPath:   p,
+		CondInput: NewKeyValPair(forceChksumKw, "", replNumKw, strconv.Itoa(replNum)),
+	}, nil, &out); err != nil {
+		return "", err
+	}
+
+	return out.MyStr, nil
+}
+
+// Replicate copies p to resource, like irepl -R
+func (con *Conn) Replicate(p string, resource string) error {
+	_, err := con.Request(DATA_OBJ_REPL_AN, &DataObjInp{
+		ObjPath:   p,
+		CondInput: NewKeyValPair(destRescNameKw, resource),
+	}, nil, nil)
+
+	return err
+}
+
+// Trim removes the replica numbered replNum of p, like itrim -N 1 -n
+func (con *Conn) Trim(p string, replNum int) error {
+	_, err := con.Request(DATA_OBJ_TRIM_AN, &DataObjInp{
+		ObjPath:   p,
+		CondInput: NewKeyValPair(replNumKw, strconv.Itoa(replNum), copiesKw, "1"),
+	}, nil, nil)
+
+	return err
+}
+
+// PhysicalMove moves the replica of p on the resource src to the resource dest, like iphymv
+func (con *Conn) PhysicalMove(p string, src string, dest string) error {
+	_, err := con.Request(DATA_OBJ_PHYMV_AN, &DataObjInp{
+		ObjPath:   p,
+		CondInput: NewKeyValPair(rescNameKw, src, destRescNameKw, dest),
+	}, nil, nil)
+
+	return err
+}
+
+// VerifyReplica finds the size of the data of a replica by seeking to its end, and has the
+// server compare its data to the registered checksum, like ichksum -K -n. The catalog isn't
+// changed. The checksum of the result is the registered one, irodsfs.ErrChecksumMismatch is
+// returned when the server reports a mismatch.
+func (con *Conn) VerifyReplica(p string, replNum int) (irodsfs.Fixity, error) {
+	repls, err := con.Replicas(p)
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	var repl *irodsfs.Replica
+
+	for inx := range repls {
+		if repls[inx].Num == replNum {
+			repl = &repls[inx]
+		}
+	}
+
+	if repl == nil {
+		return irodsfs.Fixity{}, newError(USER_FILE_DOES_NOT_EXIST, fmt.Sprintf("%v has no replica %v", p, replNum))
+	}
+
+	file, err := con.openFile(p, os.O_RDONLY, replNumKw, strconv.Itoa(replNum))
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return irodsfs.Fixity{}, err
+	}
+
+	fixity := irodsfs.Fixity{Size: size}
+
+	if repl.Checksum == "" {
+		return fixity, nil
+	}
+
+	if _, err := con.Request(DATA_OBJ_CHKSUM_AN, &DataObjInp{
+		ObjPath:   p,
+		CondInput: NewKeyValPair(verifyChksumKw, "", replNumKw, strconv.Itoa(replNum)),
+	}, nil, nil); err != nil {
+		if IsCode(err, USER_CHKSUM_MISMATCH) {
+			return fixity, irodsfs.ErrChecksumMismatch
+		}
+
+		return irodsfs.Fixity{}, err
+	}
+
+	fixity.Checksum = repl.Checksum
+
+	return fixity, nil
+}
+
+// SetTicket uses the ticket for the requests of the session, like iticket with -t
+func (con *Conn) SetTicket(ticket string) error {
+	_, err := con.Request(TICKET_ADMIN_AN, &TicketAdminInp{Arg1: "session", Arg2: ticket}, nil, nil)
+
+	return err
+}
@@ -0,0 +1,801 @@
+package native
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// pageSize is the number of GenQuery rows per reply of the fake server, smaller than MaxRows
+// to exercise continueInx
+const pageSize = 2
+
+// fakeServer speaks the server side of the protocol, backed by an in-memory zone
+type fakeServer struct {
+	zone      *gorodstest.Server
+	passwords map[string]string
+
+	// ticket is the last session ticket set by a client
+	ticket string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	srv := &fakeServer{
+		zone:      gorodstest.NewServer("tempZone"),
+		passwords: map[string]string{"rods": "rods"},
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := srv.zone.CreateUser(name, gorodstest.UserType); err != nil {
+			t.Fatal(err)
+		}
+
+		srv.passwords[name] = name + "-password"
+	}
+
+	return srv
+}
+
+func (srv *fakeServer) dial(t *testing.T, user string) *Conn {
+	con, err := srv.dialPassword(user, srv.passwords[user])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return con
+}
+
+func (srv *fakeServer) dialPassword(user string, password string) (*Conn, error) {
+	return srv.dialOptions(Options{Username: user, Password: password})
+}
+
+func (srv *fakeServer) dialOptions(opts Options) (*Conn, error) {
+	client, server := net.Pipe()
+	go srv.serve(server)
+
+	opts.Zone = "tempZone"
+
+	return NewConn(client, opts)
+}
+
+// session is the state of a client connection
+type session struct {
+	srv       *fakeServer
+	user      string
+	challenge []byte
+	con       *gorodstest.Connection
+	files     map[int]irodsfs.File
+	nextDesc  int
+	pages     map[int][]map[string]string
+}
+
+func (srv *fakeServer) serve(netConn net.Conn) {
+	defer netConn.Close()
+
+	msg, err := ReadMessage(netConn)
+	if err != nil || msg.Header.Type != RODS_CONNECT {
+		return
+	}
+
+	var startup StartupPack
+	if err := Unpack(msg.Body, &startup); err != nil {
+		return
+	}
+
+	reply, _ := NewMessage(RODS_VERSION, 0, &Version{RelVersion: RelVersion, APIVersion: APIVersion}, nil)
+	if err := WriteMessage(netConn, reply); err != nil {
+		return
+	}
+
+	sess := &session{
+		srv:      srv,
+		user:     startup.ClientUser,
+		files:    make(map[int]irodsfs.File),
+		nextDesc: 3,
+		pages:    make(map[int][]map[string]string),
+	}
+
+	for {
+		msg, err := ReadMessage(netConn)
+		if err != nil || msg.Header.Type == RODS_DISCONNECT {
+			return
+		}
+
+		status, out, bs, err := sess.handle(msg.Header.IntInfo, msg)
+		if err != nil {
+			status = SYS_INVALID_INPUT_PARAM
+			if e, ok := err.(*gorodstest.Error); ok {
+				status = e.Code
+			} else if e, ok := err.(*Error); ok {
+				status = e.Code
+			}
+
+			out, bs = nil, nil
+		}
+
+		reply, err := NewMessage(RODS_API_REPLY, status, out, bs)
+		if err != nil {
+			return
+		}
+
+		if err := WriteMessage(netConn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// handle runs a request and returns the status, the reply body and the reply byte stream
+func (sess *session) handle(apiNumber int, msg *Message) (int, interface{}, []byte, error) {
+	switch apiNumber {
+	case AUTH_REQUEST_AN:
+		sess.challenge = make([]byte, 64)
+		rand.Read(sess.challenge)
+
+		return 0, &AuthRequestOut{Challenge: base64.StdEncoding.EncodeToString(sess.challenge)}, nil, nil
+
+	case AUTH_RESPONSE_AN:
+		var inp AuthResponseInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		name := strings.TrimSuffix(inp.Username, "#tempZone")
+		password, ok := sess.srv.passwords[name]
+		expected := base64.StdEncoding.EncodeToString(ChallengeResponse(sess.challenge, password))
+
+		if !ok || sess.challenge == nil || inp.Response != expected {
+			return 0, nil, nil, newError(CAT_INVALID_AUTHENTICATION, "Invalid password")
+		}
+
+		con, err := sess.srv.zone.Connect(sess.user)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		sess.con = con
+
+		return 0, nil, nil, nil
+	}
+
+	if sess.con == nil {
+		return 0, nil, nil, newError(CAT_INVALID_AUTHENTICATION, "Not authenticated")
+	}
+
+	con := sess.con
+
+	switch apiNumber {
+	case OBJ_STAT_AN:
+		var inp DataObjInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		info, err := con.Stat(inp.ObjPath)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		owner := strings.SplitN(info.Owner, "#", 2)
+		stat := &RodsObjStat{
+			ObjSize:    info.Size,
+			ObjType:    DATA_OBJ_T,
+			Chksum:     info.Checksum,
+			OwnerName:  owner[0],
+			OwnerZone:  owner[1],
+			CreateTime: "0",
+			ModifyTime: "0",
+		}
+
+		if info.IsDir() {
+			stat.ObjType = COLL_OBJ_T
+		}
+
+		return 0, stat, nil, nil
+
+	case DATA_OBJ_CREATE_AN, DATA_OBJ_OPEN_AN:
+		var inp DataObjInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		flag := inp.OpenFlags
+		if apiNumber == DATA_OBJ_CREATE_AN {
+			flag = os.O_RDWR | os.O_CREATE | os.O_EXCL
+		}
+
+		var (
+			file irodsfs.File
+			err  error
+		)
+
+		if replNum, ok := inp.CondInput.Get(replNumKw); ok {
+			num, _ := strconv.Atoi(replNum)
+			file, err = con.OpenReplica(inp.ObjPath, num, flag)
+		} else {
+			file, err = con.Open(inp.ObjPath, flag)
+		}
+
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		desc := sess.nextDesc
+		sess.nextDesc++
+		sess.files[desc] = file
+
+		return desc, nil, nil, nil
+
+	case DATA_OBJ_READ_AN, DATA_OBJ_WRITE_AN, DATA_OBJ_LSEEK_AN, DATA_OBJ_CLOSE_AN:
+		var inp OpenedDataObjInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		file, ok := sess.files[inp.L1descInx]
+		if !ok {
+			return 0, nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Bad descriptor")
+		}
+
+		switch apiNumber {
+		case DATA_OBJ_READ_AN:
+			buf := make([]byte, inp.Len)
+
+			n, err := io.ReadFull(file, buf)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return 0, nil, nil, err
+			}
+
+			return n, nil, buf[:n], nil
+
+		case DATA_OBJ_WRITE_AN:
+			n, err := file.Write(msg.Bs)
+
+			return n, nil, nil, err
+
+		case DATA_OBJ_LSEEK_AN:
+			offset, err := file.Seek(inp.Offset, inp.Whence)
+
+			return 0, &FileLseekOut{Offset: offset}, nil, err
+		}
+
+		delete(sess.files, inp.L1descInx)
+
+		return 0, nil, nil, file.Close()
+
+	case COLL_CREATE_AN, RM_COLL_AN:
+		var inp CollInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		_, recursive := inp.CondInput.Get(recursiveOprKw)
+
+		if apiNumber == COLL_CREATE_AN {
+			return 0, nil, nil, con.Mkdir(inp.CollName, recursive)
+		}
+
+		return 0, nil, nil, con.Remove(inp.CollName, recursive)
+
+	case DATA_OBJ_UNLINK_AN:
+		var inp DataObjInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		return 0, nil, nil, con.Remove(inp.ObjPath, false)
+
+	case DATA_OBJ_RENAME_AN:
+		var inp DataObjCopyInp
+		if err := Unpack(msg.Body, &inp); err != nil || len(inp.Inp) != 2 {
+			return 0, nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Invalid rename")
+		}
+
+		return 0, nil, nil, con.Rename(inp.Inp[0].ObjPath, inp.Inp[1].ObjPath)
+
+	case MOD_AVU_METADATA_AN:
+		var inp ModAVUMetadataInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		switch inp.Arg0 {
+		case "add":
+			return 0, nil, nil, con.AddMeta(inp.Arg2, irodsfs.AVU{Attribute: inp.Arg3, Value: inp.Arg4, Units: inp.Arg5})
+		case "rmw":
+			return 0, nil, nil, con.DeleteMeta(inp.Arg2, inp.Arg3)
+		}
+
+		return 0, nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Unsupported imeta "+inp.Arg0)
+
+	case MOD_ACCESS_CONTROL_AN:
+		var inp ModAccessControlInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		recursive := inp.RecursiveFlag == 1
+
+		switch inp.AccessLevel {
+		case "inherit", "noinherit":
+			return 0, nil, nil, con.SetInheritance(inp.Path, inp.AccessLevel == "inherit", recursive)
+		}
+
+		principal := inp.UserName
+		if inp.Zone != "" {
+			principal += "#" + inp.Zone
+		}
+
+		return 0, nil, nil, con.Chmod(inp.Path, principal, accessLevel(inp.AccessLevel), recursive)
+
+	case DATA_OBJ_REPL_AN, DATA_OBJ_TRIM_AN, DATA_OBJ_PHYMV_AN, DATA_OBJ_CHKSUM_AN:
+		var inp DataObjInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		replNum, _ := inp.CondInput.Get(replNumKw)
+		num, _ := strconv.Atoi(replNum)
+		dest, _ := inp.CondInput.Get(destRescNameKw)
+
+		switch apiNumber {
+		case DATA_OBJ_REPL_AN:
+			return 0, nil, nil, con.Replicate(inp.ObjPath, dest)
+
+		case DATA_OBJ_TRIM_AN:
+			return 0, nil, nil, con.Trim(inp.ObjPath, num)
+
+		case DATA_OBJ_PHYMV_AN:
+			src, _ := inp.CondInput.Get(rescNameKw)
+
+			return 0, nil, nil, con.PhysicalMove(inp.ObjPath, src, dest)
+		}
+
+		if _, verify := inp.CondInput.Get(verifyChksumKw); verify {
+			if _, err := con.VerifyReplica(inp.ObjPath, num); err == irodsfs.ErrChecksumMismatch {
+				return 0, nil, nil, newError(USER_CHKSUM_MISMATCH, "Checksum mismatch")
+			} else if err != nil {
+				return 0, nil, nil, err
+			}
+
+			return 0, nil, nil, nil
+		}
+
+		sum, err := con.ChecksumReplica(inp.ObjPath, num)
+
+		return 0, &STR{MyStr: sum}, nil, err
+
+	case TICKET_ADMIN_AN:
+		var inp TicketAdminInp
+		if err := Unpack(msg.Body, &inp); err != nil || inp.Arg1 != "session" {
+			return 0, nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Unsupported iticket request")
+		}
+
+		sess.srv.ticket = inp.Arg2
+
+		return 0, nil, nil, nil
+
+	case GEN_QUERY_AN:
+		var inp GenQueryInp
+		if err := Unpack(msg.Body, &inp); err != nil {
+			return 0, nil, nil, err
+		}
+
+		return sess.query(&inp)
+	}
+
+	return 0, nil, nil, newError(SYS_INVALID_INPUT_PARAM, "Unsupported API")
+}
+
+// query translates a GenQueryInp_PI to the iquest language of gorodstest, and replies
+// pageSize rows at a time
+func (sess *session) query(inp *GenQueryInp) (int, interface{}, []byte, error) {
+	var cols, conds []string
+
+	for _, num := range inp.Select.Inx {
+		name, _ := ColumnName(num)
+		cols = append(cols, name)
+	}
+
+	for inx, num := range inp.Where.Inx {
+		name, _ := ColumnName(num)
+		conds = append(conds, name+" "+inp.Where.Values[inx])
+	}
+
+	rows, ok := sess.pages[inp.ContinueInx]
+	delete(sess.pages, inp.ContinueInx)
+
+	// maxRows 0 closes the statement
+	if inp.MaxRows == 0 {
+		return 0, &GenQueryOut{}, nil, nil
+	}
+
+	total := 0
+
+	if !ok {
+		q := "select " + strings.Join(cols, ", ")
+		if len(conds) > 0 {
+			q += " where " + strings.Join(conds, " and ")
+		}
+
+		var err error
+
+		rows, err = sess.con.IQuest(q, inp.Options&UPPER_CASE_WHERE != 0)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		if inp.Options&RETURN_TOTAL_ROW_COUNT != 0 {
+			total = len(rows)
+		}
+
+		if inp.PartialStartIndex < len(rows) {
+			rows = rows[inp.PartialStartIndex:]
+		} else {
+			rows = nil
+		}
+
+		if len(rows) == 0 {
+			return 0, nil, nil, newError(CAT_NO_ROWS_FOUND, "No rows found")
+		}
+	}
+
+	out := &GenQueryOut{
+		AttriCnt:      len(cols),
+		TotalRowCount: total,
+	}
+
+	size := pageSize
+	if inp.MaxRows < size {
+		size = inp.MaxRows
+	}
+
+	if len(rows) > size {
+		sess.nextDesc++
+		out.ContinueInx = sess.nextDesc
+		sess.pages[out.ContinueInx] = rows[size:]
+		rows = rows[:size]
+	}
+
+	out.RowCnt = len(rows)
+
+	for inx, col := range cols {
+		res := SqlResult{AttriInx: inp.Select.Inx[inx]}
+
+		for _, row := range rows {
+			res.Values = append(res.Values, row[col])
+		}
+
+		out.SqlResult = append(out.SqlResult, res)
+	}
+
+	return 0, out, nil, nil
+}
+
+func TestAuthentication(t *testing.T) {
+	srv := newFakeServer(t)
+
+	if _, err := srv.dialPassword("alice", "wrong"); !IsCode(err, CAT_INVALID_AUTHENTICATION) {
+		t.Fatalf("Expected CAT_INVALID_AUTHENTICATION, got %v", err)
+	}
+
+	con := srv.dial(t, "alice")
+
+	if con.Version() != RelVersion || con.Username() != "alice" {
+		t.Fatalf("Unexpected session %v %v", con.Version(), con.Username())
+	}
+
+	if err := con.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := con.Stat("/tempZone"); err == nil {
+		t.Fatal("Request succeeded after Disconnect")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	srv := newFakeServer(t)
+	con := srv.dial(t, "alice")
+	defer con.Disconnect()
+
+	p := "/tempZone/home/alice/hello.txt"
+
+	if err := irodsfs.WriteFile(con, p, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := con.Open(p, os.O_WRONLY|os.O_APPEND)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte(" world")); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+
+	data, err := irodsfs.ReadFile(con, p)
+	if err != nil || string(data) != "hello world" {
+		t.Fatalf("Read %q: %v", data, err)
+	}
+
+	f, _ = con.Open(p, os.O_RDONLY)
+	buf := make([]byte, 5)
+
+	if _, err := f.ReadAt(buf, 6); err != nil || string(buf) != "world" {
+		t.Fatalf("ReadAt %q: %v", buf, err)
+	}
+
+	f.Close()
+
+	if _, err := con.Open(p, os.O_RDWR|os.O_CREATE|os.O_EXCL); err == nil {
+		t.Fatal("O_EXCL opened an existing data object")
+	}
+
+	info, err := con.Stat(p)
+	if err != nil || info.Size != 11 || info.IsDir() || info.Owner != "alice#tempZone" {
+		t.Fatalf("Unexpected stat %+v: %v", info, err)
+	}
+
+	if _, err := con.Stat("/tempZone/home/alice/missing"); !IsCode(err, gorodstest.USER_FILE_DOES_NOT_EXIST) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected USER_FILE_DOES_NOT_EXIST, got %v", err)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	srv := newFakeServer(t)
+	con := srv.dial(t, "alice")
+	defer con.Disconnect()
+
+	home := "/tempZone/home/alice"
+
+	if err := con.Mkdir(home+"/a/b", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"1.txt", "2.txt", "3.txt"} {
+		irodsfs.WriteFile(con, path.Join(home, "a", name), []byte(name))
+	}
+
+	list, err := con.List(home + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, info := range list {
+		names = append(names, info.Name)
+	}
+
+	if strings.Join(names, " ") != "b 1.txt 2.txt 3.txt" || !list[0].IsDir() || list[1].Size != 5 {
+		t.Fatalf("Unexpected listing %+v", list)
+	}
+
+	if err := con.Rename(home+"/a", home+"/moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := con.Remove(home+"/moved/1.txt", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := con.Remove(home+"/moved", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if list, _ := con.List(home); len(list) != 0 {
+		t.Fatalf("Unexpected listing %+v", list)
+	}
+}
+
+func TestMetaAndACL(t *testing.T) {
+	srv := newFakeServer(t)
+	alice := srv.dial(t, "alice")
+	defer alice.Disconnect()
+
+	p := "/tempZone/home/alice/data.csv"
+	irodsfs.WriteFile(alice, p, []byte("a,b"))
+
+	for _, avu := range []irodsfs.AVU{
+		{Attribute: "project", Value: "apollo"},
+		{Attribute: "rows", Value: "2", Units: "lines"},
+	} {
+		if err := alice.AddMeta(p, avu); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice.AddMeta("/tempZone/home/alice", irodsfs.AVU{Attribute: "project", Value: "apollo"})
+
+	meta, err := alice.Meta(p)
+	if err != nil || len(meta) != 2 || meta[1].Units != "lines" {
+		t.Fatalf("Unexpected meta %+v: %v", meta, err)
+	}
+
+	objs, err := alice.QueryMeta("project = apollo and rows >= 2")
+	if err != nil || len(objs) != 1 || objs[0].Path != p {
+		t.Fatalf("Unexpected results %+v: %v", objs, err)
+	}
+
+	if objs, _ := alice.QueryMeta("project = apollo"); len(objs) != 2 || !objs[0].IsDir() {
+		t.Fatalf("Unexpected results %+v", objs)
+	}
+
+	alice.DeleteMeta(p, "rows")
+
+	if meta, _ := alice.Meta(p); len(meta) != 1 {
+		t.Fatalf("Unexpected meta %+v", meta)
+	}
+
+	bob := srv.dial(t, "bob")
+	defer bob.Disconnect()
+
+	if _, err := irodsfs.ReadFile(bob, p); err == nil {
+		t.Fatal("bob read without permission")
+	}
+
+	if err := alice.Chmod(p, "bob#tempZone", irodsfs.Read, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := irodsfs.ReadFile(bob, p); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := alice.ACL(p)
+	if err != nil || len(acl) != 2 || acl[0].Principal != "alice#tempZone" || acl[0].AccessLevel != irodsfs.Own || acl[1].AccessLevel != irodsfs.Read {
+		t.Fatalf("Unexpected ACL %+v: %v", acl, err)
+	}
+
+	if err := alice.SetInheritance("/tempZone/home/alice", true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if inherit, err := alice.Inheritance("/tempZone/home/alice"); err != nil || !inherit {
+		t.Fatalf("Inheritance %v: %v", inherit, err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv := newFakeServer(t)
+	con := srv.dial(t, "alice")
+	defer con.Disconnect()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		irodsfs.WriteFile(con, "/tempZone/home/alice/"+name+".txt", []byte(name))
+	}
+
+	rows, err := con.IQuest("select DATA_NAME, DATA_SIZE where COLL_NAME = '/tempZone/home/alice' and DATA_NAME like '%.txt'", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 5 || rows[4]["DATA_NAME"] != "e.txt" || rows[4]["DATA_SIZE"] != "1" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+
+	rows, err = con.IQuest("select DATA_NAME where DATA_NAME = 'missing'", false)
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("Unexpected rows %v: %v", rows, err)
+	}
+
+	if _, err := con.IQuest("select NOT_A_COLUMN", false); err == nil {
+		t.Fatal("Unknown column accepted")
+	}
+
+	rows, err = con.IQuest("select DATA_NAME where COLL_NAME = '/tempZone/home/bob' || like '/tempZone/home/alice%' and DATA_NAME = 'a.txt'", false)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Unexpected rows %v: %v", rows, err)
+	}
+
+	rows, total, err := con.IQuestPage("select DATA_NAME where COLL_NAME = '/tempZone/home/alice' and DATA_NAME like '%.txt'", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 5 || len(rows) != 3 || rows[0]["DATA_NAME"] != "b.txt" || rows[2]["DATA_NAME"] != "d.txt" {
+		t.Fatalf("Unexpected page %v of %v rows", rows, total)
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	client, server := net.Pipe()
+
+	go func() {
+		msg, _ := NewMessage(RODS_API_REQ, MOD_AVU_METADATA_AN, &ModAVUMetadataInp{Arg0: "add", Arg3: `it's "quoted" <&>`}, []byte{0, 1, 2})
+		WriteMessage(client, msg)
+	}()
+
+	msg, err := ReadMessage(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(msg.Body), "&#") {
+		t.Fatalf("Numeric character reference in %s", msg.Body)
+	}
+
+	var inp ModAVUMetadataInp
+	if err := Unpack(msg.Body, &inp); err != nil || inp.Arg3 != `it's "quoted" <&>` || len(msg.Bs) != 3 {
+		t.Fatalf("Unexpected message %+v %v: %v", inp, msg.Bs, err)
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	srv := newFakeServer(t)
+	srv.zone.CreateResource("archiveResc")
+
+	con := srv.dial(t, "alice")
+	defer con.Disconnect()
+
+	p := "/tempZone/home/alice/data.txt"
+	irodsfs.WriteFile(con, p, []byte("replicated"))
+
+	if err := con.Replicate(p, "archiveResc"); err != nil {
+		t.Fatal(err)
+	}
+
+	repls, err := con.Replicas(p)
+	if err != nil || len(repls) != 2 || repls[1].Num != 1 || repls[1].Resource != "archiveResc" || repls[1].Size != 10 {
+		t.Fatalf("Unexpected replicas %+v: %v", repls, err)
+	}
+
+	sum, err := con.ChecksumReplica(p, 1)
+	if err != nil || sum == "" {
+		t.Fatalf("Unexpected checksum %q: %v", sum, err)
+	}
+
+	if fixity, err := con.VerifyReplica(p, 1); err != nil || fixity.Size != 10 || fixity.Checksum != sum {
+		t.Fatalf("Unexpected fixity %+v: %v", fixity, err)
+	}
+
+	f, err := con.OpenReplica(p, 1, os.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadAll(f)
+	f.Close()
+
+	if string(data) != "replicated" {
+		t.Fatalf("Unexpected data %q", data)
+	}
+
+	if err := con.Trim(p, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if repls, _ := con.Replicas(p); len(repls) != 1 || repls[0].Num != 1 {
+		t.Fatalf("Unexpected replicas after trim %+v", repls)
+	}
+
+	if _, err := con.Replicas("/tempZone/home/alice/missing"); !IsCode(err, USER_FILE_DOES_NOT_EXIST) {
+		t.Fatalf("Expected USER_FILE_DOES_NOT_EXIST, got %v", err)
+	}
+}
+
+func TestTicket(t *testing.T) {
+	srv := newFakeServer(t)
+
+	con, err := srv.dialOptions(Options{Username: "bob", Password: srv.passwords["bob"], Ticket: "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Disconnect()
+
+	if srv.ticket != "abc123" {
+		t.Fatalf("Expected the session ticket abc123, got %q", srv.ticket)
+	}
+}
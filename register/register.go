@@ -0,0 +1,464 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package register registers files already stored on a resource, without copying them, like
+// ireg, and unregisters them, like iunreg. Tree registers a directory as a collection tree
+// (ireg -C) in parallel, with a result per file and retries:
+//
+//	report, err := register.Tree(fsys, "/instruments/seq01/run42", "/tempZone/raw/run42", register.Options{
+//		Resource: "instrumentResc",
+//		Verify:   "sha2",
+//	})
+//
+// Files already registered as the data object they map to are skipped, so an ingest can run
+// again after a failure, or on a directory that grows. The files are read from the local
+// filesystem to find them, compute the sizes and the checksums the server verifies: run it on
+// the host of the resource, or one mounting its storage at the same path.
+//
+// It works with the irodsfs implementations that support registration,
+// gorods.Connection.Filesystem() and gorodstest.Connection.
+package register
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// FS is the part of irodsfs used to register files
+type FS interface {
+	irodsfs.FS
+	irodsfs.QueryFS
+	irodsfs.ReplicaFS
+	irodsfs.RegisterFS
+}
+
+// Statuses of the results
+const (
+	// Registered is a file registered as a new data object
+	Registered = "registered"
+
+	// ReplicaRegistered is a file registered as a new replica of an existing data object
+	ReplicaRegistered = "replica_registered"
+
+	// AlreadyRegistered is a file found registered as the data object it maps to, with its size
+	AlreadyRegistered = "already_registered"
+
+	// Unregistered is a data object removed from the catalog
+	Unregistered = "unregistered"
+
+	// Failed is a file that couldn't be registered, or a data object that couldn't be unregistered
+	Failed = "failed"
+)
+
+// Options tune the registrations
+type Options struct {
+	// Resource holds the files, the default resource when empty
+	Resource string
+
+	// Replica registers the files as new replicas of existing data objects (ireg --repl)
+	Replica bool
+
+	// Checksum makes the server compute and register the checksums of the files (ireg -k)
+	Checksum bool
+
+	// Verify is a checksum scheme (md5, sha2...). The files are hashed locally with it and
+	// the server verifies them against these checksums before registering them (ireg -K).
+	Verify string
+
+	// Force registers files over existing data objects, and files registered with another
+	// size again (ireg -f)
+	Force bool
+
+	// Exclude holds patterns of file and directory names not registered, see path.Match
+	Exclude []string
+
+	// Workers is the number of files registered in parallel. It defaults to 4.
+	Workers int
+
+	// Retries is the number of times a failed registration is tried again. It defaults to 2,
+	// a negative value disables retries.
+	Retries int
+
+	// RetryDelay is the wait before the first retry, doubled after each one. It defaults to a
+	// second.
+	RetryDelay time.Duration
+}
+
+// Result is the registration of a file, or the unregistration of a data object
+type Result struct {
+	PhysicalPath string `json:"physicalPath,omitempty"`
+	Path         string `json:"path"`
+	Status       string `json:"status"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"checksum,omitempty"`
+	Attempts     int    `json:"attempts,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Report holds the results ordered by path, and counts them by status
+type Report struct {
+	Results []Result       `json:"results"`
+	Counts  map[string]int `json:"counts"`
+}
+
+// Failed returns the failed results
+func (report *Report) Failed() []Result {
+	var failed []Result
+
+	for _, res := range report.Results {
+		if res.Status == Failed {
+			failed = append(failed, res)
+		}
+	}
+
+	return failed
+}
+
+// String summarizes the report
+// example: 120 files: 100 registered, 18 already_registered, 2 failed
+func (report *Report) String() string {
+	s := fmt.Sprintf("%v files", len(report.Results))
+	sep := ": "
+
+	for _, status := range []string{Registered, ReplicaRegistered, AlreadyRegistered, Unregistered, Failed} {
+		if n := report.Counts[status]; n > 0 {
+			s += fmt.Sprintf("%v%v %v", sep, n, status)
+			sep = ", "
+		}
+	}
+
+	return s
+}
+
+func newReport(results []Result) *Report {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+
+	report := &Report{Results: results, Counts: make(map[string]int)}
+
+	for _, res := range results {
+		report.Counts[res.Status]++
+	}
+
+	return report
+}
+
+func (opts *Options) defaults() {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+
+	if opts.Retries == 0 {
+		opts.Retries = 2
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+}
+
+// excluded reports whether the name of a file or directory matches opts.Exclude
+func (opts *Options) excluded(name string) bool {
+	for _, pattern := range opts.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// File registers the file physPath as the data object p, or as a new replica of p with
+// opts.Replica. A file already registered as p with the same size is left as is.
+func File(fsys FS, physPath string, p string, opts Options) Result {
+	opts.defaults()
+
+	return file(fsys, filepath.Clean(physPath), path.Clean(p), opts)
+}
+
+func file(fsys FS, physPath string, p string, opts Options) Result {
+	res := Result{PhysicalPath: physPath, Path: p, Status: Failed}
+
+	fi, err := os.Stat(physPath)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	if !fi.Mode().IsRegular() {
+		res.Error = "not a regular file"
+		return res
+	}
+
+	res.Size = fi.Size()
+
+	regOpts := irodsfs.RegisterOptions{
+		Resource: opts.Resource,
+		Replica:  opts.Replica,
+		Checksum: opts.Checksum,
+		Force:    opts.Force,
+	}
+
+	existing, err := registeredAs(fsys, physPath)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	for _, repl := range existing {
+		switch {
+		case repl.path != p:
+			res.Error = fmt.Sprintf("%v is registered as %v", physPath, repl.path)
+			return res
+		case repl.size == res.Size:
+			res.Status = AlreadyRegistered
+			res.Checksum = repl.checksum
+			return res
+		case !opts.Force:
+			res.Error = fmt.Sprintf("%v is registered with size %v, the file has size %v", physPath, repl.size, res.Size)
+			return res
+		}
+
+		// Forced again over the stale registration
+		regOpts.Replica = false
+	}
+
+	if opts.Verify != "" {
+		alg, err := checksum.ParseAlgorithm(opts.Verify)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+
+		sum, err := checksum.File(alg, physPath)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+
+		regOpts.Verify = sum.String()
+	}
+
+	err = retry(&res.Attempts, opts, func() error {
+		return fsys.Register(physPath, p, regOpts)
+	})
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Status = Registered
+	if regOpts.Replica {
+		res.Status = ReplicaRegistered
+	}
+
+	// The registered checksum, empty without Checksum or Verify
+	if repls, err := fsys.Replicas(p); err == nil {
+		for _, repl := range repls {
+			if path.Clean(repl.PhysicalPath) == physPath {
+				res.Checksum = repl.Checksum
+			}
+		}
+	}
+
+	return res
+}
+
+// registration is a replica whose physical path is a file
+type registration struct {
+	path     string
+	size     int64
+	checksum string
+}
+
+// registeredAs returns the replicas registered with the physical path physPath
+func registeredAs(fsys FS, physPath string) ([]registration, error) {
+	// GenQuery has no escape sequence for single quotes
+	if strings.Contains(physPath, "'") {
+		return nil, fmt.Errorf("%v contains a single quote", physPath)
+	}
+
+	rows, err := fsys.IQuest("select COLL_NAME, DATA_NAME, DATA_SIZE, DATA_CHECKSUM where DATA_PATH = '"+physPath+"'", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var regs []registration
+
+	for _, row := range rows {
+		size, _ := strconv.ParseInt(row["DATA_SIZE"], 10, 64)
+
+		regs = append(regs, registration{
+			path:     path.Join(row["COLL_NAME"], row["DATA_NAME"]),
+			size:     size,
+			checksum: row["DATA_CHECKSUM"],
+		})
+	}
+
+	return regs, nil
+}
+
+// Tree registers the files below the directory dir as the data objects of the collection coll,
+// creating the collections of its subdirectories (ireg -C). The error is only set when dir
+// can't be read or coll created, files that can't be registered are reported as failures.
+func Tree(fsys FS, dir string, coll string, opts Options) (*Report, error) {
+	opts.defaults()
+
+	dir = filepath.Clean(dir)
+	coll = path.Clean(coll)
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%v is not a directory", dir)
+	}
+
+	if err := fsys.Mkdir(coll, true); err != nil {
+		return nil, err
+	}
+
+	type job struct{ physPath, p string }
+
+	var (
+		results []Result
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		jobs    = make(chan job)
+	)
+
+	add := func(res Result) {
+		mu.Lock()
+		results = append(results, res)
+		mu.Unlock()
+	}
+
+	for inx := 0; inx < opts.Workers; inx++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				add(file(fsys, j.physPath, j.p, opts))
+			}
+		}()
+	}
+
+	filepath.Walk(dir, func(physPath string, fi os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(dir, physPath)
+		p := path.Join(coll, filepath.ToSlash(rel))
+
+		switch {
+		case err != nil:
+			add(Result{PhysicalPath: physPath, Path: p, Status: Failed, Error: err.Error()})
+		case physPath == dir:
+		case opts.excluded(fi.Name()):
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+		case fi.IsDir():
+			if err := fsys.Mkdir(p, true); err != nil {
+				add(Result{PhysicalPath: physPath, Path: p, Status: Failed, Error: err.Error()})
+				return filepath.SkipDir
+			}
+		case fi.Mode().IsRegular():
+			jobs <- job{physPath, p}
+		}
+
+		return nil
+	})
+
+	close(jobs)
+	wg.Wait()
+
+	return newReport(results), nil
+}
+
+// Unregister removes the data object p, or the data objects and collections below the
+// collection p, from the catalog without deleting their files (iunreg). Collections are
+// removed once their objects are unregistered.
+func Unregister(fsys FS, p string) (*Report, error) {
+	p = path.Clean(p)
+
+	info, err := fsys.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results []Result
+		colls   []string
+	)
+
+	unregister := func(info irodsfs.ObjInfo) {
+		res := Result{Path: info.Path, Status: Unregistered, Size: info.Size, Checksum: info.Checksum}
+
+		if repls, err := fsys.Replicas(info.Path); err == nil && len(repls) > 0 {
+			res.PhysicalPath = repls[0].PhysicalPath
+		}
+
+		if err := fsys.Unregister(info.Path, -1); err != nil {
+			res.Status = Failed
+			res.Error = err.Error()
+		}
+
+		results = append(results, res)
+	}
+
+	if !info.IsDir() {
+		unregister(info)
+		return newReport(results), nil
+	}
+
+	irodsfs.Walk(fsys, p, func(objPath string, info irodsfs.ObjInfo, err error) error {
+		switch {
+		case err != nil:
+			results = append(results, Result{Path: objPath, Status: Failed, Error: err.Error()})
+		case info.IsDir():
+			colls = append(colls, objPath)
+		default:
+			info.Path = objPath
+			unregister(info)
+		}
+
+		return nil
+	})
+
+	// Deepest first, a collection still holding objects that failed stays
+	for inx := len(colls) - 1; inx >= 0; inx-- {
+		fsys.Remove(colls[inx], false)
+	}
+
+	return newReport(results), nil
+}
+
+// retry calls fn until it succeeds or opts.Retries is exhausted, counting the attempts
+func retry(attempts *int, opts Options, fn func() error) error {
+	delay := opts.RetryDelay
+
+	for {
+		err := fn()
+		*attempts++
+
+		if err == nil || *attempts > opts.Retries {
+			return err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
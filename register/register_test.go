@@ -0,0 +1,146 @@
+package register
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/checksum"
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+const coll = "/tempZone/home/rods/run42"
+
+func testFS(t *testing.T) (*gorodstest.FlakyConnection, string) {
+	srv := gorodstest.NewServer("tempZone")
+	srv.CreateResource("instrumentResc")
+
+	con, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "register")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{
+		"a.fastq":           "ACGT",
+		"b.fastq":           "TTGA",
+		"lane1/c.fastq":     "GGCC",
+		"lane1/c.fastq.tmp": "partial",
+		".cache/index":      "skip",
+	} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+	}
+
+	return gorodstest.NewFlakyConnection(con), dir
+}
+
+func TestTree(t *testing.T) {
+	fsys, dir := testFS(t)
+	defer os.RemoveAll(dir)
+
+	opts := Options{
+		Resource:   "instrumentResc",
+		Verify:     "sha2",
+		Exclude:    []string{"*.tmp", ".*"},
+		RetryDelay: time.Millisecond,
+	}
+
+	fsys.Fail("Register", coll+"/b.fastq", 1)
+
+	report, err := Tree(fsys, dir, coll, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.String() != "3 files: 3 registered" {
+		t.Fatalf("Unexpected report %v %+v", report, report.Results)
+	}
+
+	b := report.Results[1]
+	if b.Path != coll+"/b.fastq" || b.Attempts != 2 || b.Size != 4 || b.Checksum != checksum.Bytes(checksum.SHA256, []byte("TTGA")).String() {
+		t.Fatalf("Unexpected result %+v", b)
+	}
+
+	repls, err := fsys.Replicas(coll + "/lane1/c.fastq")
+	if err != nil || repls[0].PhysicalPath != filepath.Join(dir, "lane1/c.fastq") || repls[0].Resource != "instrumentResc" {
+		t.Fatalf("Unexpected replicas %v %+v", err, repls)
+	}
+
+	if _, err := fsys.Stat(coll + "/.cache"); err == nil {
+		t.Fatal("Excluded directory was registered")
+	}
+
+	// The ingest runs again once the directory grew, or a file was rewritten
+	ioutil.WriteFile(filepath.Join(dir, "d.fastq"), []byte("AAAA"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a.fastq"), []byte("ACGTACGT"), 0644)
+
+	if report, _ = Tree(fsys, dir, coll, opts); report.String() != "4 files: 1 registered, 2 already_registered, 1 failed" {
+		t.Fatalf("Unexpected report %v %+v", report, report.Results)
+	}
+
+	opts.Force = true
+
+	if report, _ = Tree(fsys, dir, coll, opts); report.String() != "4 files: 1 registered, 3 already_registered" {
+		t.Fatalf("Unexpected report %v %+v", report, report.Results)
+	}
+
+	if info, _ := fsys.Stat(coll + "/a.fastq"); info.Size != 8 {
+		t.Fatalf("File wasn't registered again: %+v", info)
+	}
+}
+
+func TestFile(t *testing.T) {
+	fsys, dir := testFS(t)
+	defer os.RemoveAll(dir)
+
+	fsys.Mkdir(coll, true)
+
+	a := filepath.Join(dir, "a.fastq")
+	p := coll + "/a.fastq"
+
+	if res := File(fsys, a, p, Options{Checksum: true}); res.Status != Registered || res.Checksum == "" {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+
+	// The same file can't be registered as another object
+	if res := File(fsys, a, coll+"/copy.fastq", Options{}); res.Status != Failed || res.Error != a+" is registered as "+p {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+
+	// A copy on another resource is registered as a replica
+	copyPath := filepath.Join(dir, "lane1/c.fastq")
+	ioutil.WriteFile(copyPath, []byte("ACGT"), 0644)
+
+	if res := File(fsys, copyPath, p, Options{Replica: true, Resource: "instrumentResc", Verify: "md5"}); res.Status != ReplicaRegistered || res.Checksum != checksum.Bytes(checksum.MD5, []byte("ACGT")).String() {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+
+	// An unknown scheme fails before the registration is tried
+	if res := File(fsys, filepath.Join(dir, "b.fastq"), coll+"/b.fastq", Options{Verify: "crc32", Retries: -1}); res.Status != Failed || res.Attempts != 0 {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+
+	report, err := Unregister(fsys, coll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.String() != "1 files: 1 unregistered" || report.Results[0].PhysicalPath != a {
+		t.Fatalf("Unexpected report %v %+v", report, report.Results)
+	}
+
+	if _, err := fsys.Stat(coll); err == nil {
+		t.Fatal("Collection wasn't removed")
+	}
+
+	if _, err := os.Stat(a); err != nil {
+		t.Fatalf("File was deleted: %v", err)
+	}
+}
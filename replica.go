@@ -0,0 +1,230 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Replica statuses, as reported by DATA_REPL_STATUS. The intermediate and locked statuses are set by iRODS 4.2.9 and
+// later while a replica is being written.
+const (
+	StaleReplica = iota
+	GoodReplica
+	IntermediateReplica
+	ReadLockedReplica
+	WriteLockedReplica
+)
+
+// Replica describes one copy of a data object on a resource, as returned by DataObj.Replicas()
+type Replica struct {
+	Num          int
+	Resource     string
+	RescHier     string
+	Status       int
+	Size         int64
+	Checksum     string
+	PhysicalPath string
+	ModifyTime   time.Time
+
+	obj *DataObj
+}
+
+// Good reports whether the replica holds the latest data
+func (repl *Replica) Good() bool {
+	return repl.Status == GoodReplica
+}
+
+// Stale reports whether the replica was left behind by a write to another replica
+func (repl *Replica) Stale() bool {
+	return repl.Status == StaleReplica
+}
+
+// Locked reports whether the replica is being written, or locked by an open of another replica
+func (repl *Replica) Locked() bool {
+	return repl.Status == IntermediateReplica || repl.Status == ReadLockedReplica || repl.Status == WriteLockedReplica
+}
+
+// StatusString returns good, stale, intermediate, read-locked or write-locked
+func (repl *Replica) StatusString() string {
+	switch repl.Status {
+	case StaleReplica:
+		return "stale"
+	case GoodReplica:
+		return "good"
+	case IntermediateReplica:
+		return "intermediate"
+	case ReadLockedReplica:
+		return "read-locked"
+	case WriteLockedReplica:
+		return "write-locked"
+	}
+
+	return fmt.Sprintf("status(%d)", repl.Status)
+}
+
+// String returns a formatted string describing the replica, like ils -L
+// example: 1 archiveResc;s3 1024 good sha2:JpUYe... /var/lib/irods/Vault/home/rods/file.txt
+func (repl *Replica) String() string {
+	return fmt.Sprintf("%v %v %v %v %v %v", repl.Num, repl.RescHier, repl.Size, repl.StatusString(), repl.Checksum, repl.PhysicalPath)
+}
+
+// DataObj returns the data object the replica belongs to
+func (repl *Replica) DataObj() *DataObj {
+	return repl.obj
+}
+
+// Open opens the replica for reading, see DataObj.OpenReplica
+func (repl *Replica) Open() (*DataObj, error) {
+	return repl.obj.OpenReplica(repl.Num)
+}
+
+// Chksum computes and registers the checksum of the replica, see DataObj.ChksumReplica
+func (repl *Replica) Chksum() (string, error) {
+	sum, err := repl.obj.ChksumReplica(repl.Num)
+	if err == nil {
+		repl.Checksum = sum
+	}
+
+	return sum, err
+}
+
+// Trim removes the replica from its resource, see DataObj.TrimReplica
+func (repl *Replica) Trim() error {
+	return repl.obj.TrimReplica(repl.Num)
+}
+
+// Move moves the replica to the resource dest, see DataObj.PhysicalMove
+func (repl *Replica) Move(dest string) error {
+	return repl.obj.PhysicalMove(repl.Resource, dest)
+}
+
+// Replicas returns every replica of the data object, ordered by number, with its resource hierarchy, status, size,
+// checksum and physical path. It queries the catalog, whatever replica the DataObj itself describes.
+func (obj *DataObj) Replicas() ([]*Replica, error) {
+	if obj.con.fs != nil {
+		return obj.fsReplicas()
+	}
+
+	qColl, err := genQueryString(path.Dir(obj.path))
+	if err != nil {
+		return nil, err
+	}
+
+	qName, err := genQueryString(obj.name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := obj.con.IQuest("select DATA_REPL_NUM, RESC_NAME, DATA_RESC_HIER, DATA_REPL_STATUS, DATA_SIZE, DATA_CHECKSUM, DATA_PATH, DATA_MODIFY_TIME where COLL_NAME = "+qColl+" and DATA_NAME = "+qName, false)
+	if err != nil {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Replicas Failed: %v, %v", obj.path, err))
+	}
+
+	repls := make([]*Replica, 0, len(rows))
+
+	for _, row := range rows {
+		num, _ := strconv.Atoi(row["DATA_REPL_NUM"])
+		status, _ := strconv.Atoi(row["DATA_REPL_STATUS"])
+		size, _ := strconv.ParseInt(row["DATA_SIZE"], 10, 64)
+
+		repls = append(repls, &Replica{
+			Num:          num,
+			Resource:     row["RESC_NAME"],
+			RescHier:     row["DATA_RESC_HIER"],
+			Status:       status,
+			Size:         size,
+			Checksum:     row["DATA_CHECKSUM"],
+			PhysicalPath: row["DATA_PATH"],
+			ModifyTime:   timeStringToTime(row["DATA_MODIFY_TIME"]),
+			obj:          obj,
+		})
+	}
+
+	if len(repls) == 0 {
+		return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Replicas Failed: %v does not exist or user lacks access permission", obj.path))
+	}
+
+	sort.Slice(repls, func(i, j int) bool {
+		return repls[i].Num < repls[j].Num
+	})
+
+	return repls, nil
+}
+
+// Replica returns the replica numbered replNum of the data object
+func (obj *DataObj) Replica(replNum int) (*Replica, error) {
+	repls, err := obj.Replicas()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repl := range repls {
+		if repl.Num == replNum {
+			return repl, nil
+		}
+	}
+
+	return nil, newError(Fatal, -1, fmt.Sprintf("iRODS Replica Failed: %v has no replica %v", obj.path, replNum))
+}
+
+// OpenReplica opens the replica numbered replNum for reading. It returns a copy of the data object describing that
+// replica, read it with Read, ReadBytes, ReadChunk or Reader, and Close it when done. obj itself is left as is.
+func (obj *DataObj) OpenReplica(replNum int) (*DataObj, error) {
+	return obj.openReplica(replNum, false)
+}
+
+// openReplica opens the replica numbered replNum for reading, and writing when write is set
+func (obj *DataObj) openReplica(replNum int, write bool) (*DataObj, error) {
+	if obj.con.fs != nil {
+		return obj.fsOpenReplica(replNum, write)
+	}
+
+	return obj.cOpenReplica(replNum, write)
+}
+
+// ChksumReplica computes and registers the checksum of the replica numbered replNum, like ichksum -f -n replNum.
+// Unlike Chksum, the checksum of obj isn't updated unless it describes that replica.
+func (obj *DataObj) ChksumReplica(replNum int) (string, error) {
+	if obj.con.fs != nil {
+		return obj.fsChksumReplica(replNum)
+	}
+
+	return obj.cChksumReplica(replNum)
+}
+
+// VerifyReplica checks the replica numbered replNum against the catalog without changing it, like ichksum -K -n replNum.
+// size is the size of the data on the resource, found by seeking to its end. When the replica has a registered checksum
+// the server recomputes it, ok is false when they differ. Replicas without a checksum are reported ok.
+func (obj *DataObj) VerifyReplica(replNum int) (size int64, ok bool, err error) {
+	if obj.con.fs != nil {
+		return obj.fsVerifyReplica(replNum)
+	}
+
+	return obj.cVerifyReplica(replNum)
+}
+
+// TrimReplica removes the replica numbered replNum from its resource, like itrim -N 1 -n replNum.
+// iRODS refuses to trim the last good replica of a data object.
+func (obj *DataObj) TrimReplica(replNum int) error {
+	if obj.con.fs != nil {
+		return obj.fsTrimReplica(replNum)
+	}
+
+	return obj.cTrimReplica(replNum)
+}
+
+// PhysicalMove moves the replica on the resource src to the resource dest, like iphymv -S src -R dest. Unlike
+// MoveToResource, src doesn't have to be the resource of the replica obj describes.
+func (obj *DataObj) PhysicalMove(src string, dest string) error {
+	if obj.con.fs != nil {
+		return obj.fsPhysicalMove(src, dest)
+	}
+
+	return obj.cPhysicalMove(src, dest)
+}
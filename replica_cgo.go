@@ -0,0 +1,162 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+	"unsafe"
+)
+
+func (obj *DataObj) cOpenReplica(replNum int, write bool) (*DataObj, error) {
+	repl, err := obj.Replica(replNum)
+	if err != nil {
+		return nil, err
+	}
+
+	r := *obj
+	r.replNum = repl.Num
+	r.rescHier = repl.RescHier
+	r.replStatus = repl.Status
+	r.size = repl.Size
+	r.checksum = repl.Checksum
+	r.phyPath = repl.PhysicalPath
+	r.modifyTime = repl.ModifyTime
+	r.offset = 0
+	r.chandle = C.int(-1)
+	r.openedAs = C.int(-1)
+
+	if rsrcs, err := obj.con.Resources(); err == nil {
+		if rsrc := rsrcs.FindByName(repl.Resource); rsrc != nil {
+			r.resource = rsrc
+		}
+	}
+
+	var errMsg *C.char
+
+	openFlag := C.int(C.O_RDONLY)
+	if write {
+		openFlag = C.O_RDWR
+	}
+
+	cPath := C.CString(obj.path)
+	cReplNum := C.CString(strconv.Itoa(replNum))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cReplNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_open_replica(cPath, cReplNum, openFlag, &r.chandle, ccon, &errMsg); status != 0 {
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS OpenReplica Failed: %v replica %v, %v", obj.path, replNum, C.GoString(errMsg)))
+	}
+
+	r.openedAs = openFlag
+
+	return &r, nil
+}
+
+func (obj *DataObj) cChksumReplica(replNum int) (string, error) {
+	var (
+		err       *C.char
+		chksumOut *C.char
+	)
+
+	cPath := C.CString(obj.path)
+	cReplNum := C.CString(strconv.Itoa(replNum))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cReplNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_checksum_replica(cPath, cReplNum, &chksumOut, ccon, &err); status != 0 {
+		return "", newError(Fatal, status, fmt.Sprintf("iRODS ChksumReplica Failed: %v replica %v, %v", obj.path, replNum, C.GoString(err)))
+	}
+
+	chksum := C.GoString(chksumOut)
+	C.free(unsafe.Pointer(chksumOut))
+
+	if obj.replNum == replNum {
+		obj.checksum = chksum
+	}
+
+	return chksum, nil
+}
+
+func (obj *DataObj) cVerifyReplica(replNum int) (size int64, ok bool, err error) {
+	repl, err := obj.Replica(replNum)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var (
+		errMsg   *C.char
+		cSize    C.rodsLong_t
+		mismatch C.int
+		verify   C.int
+	)
+
+	if repl.Checksum != "" {
+		verify = C.int(1)
+	}
+
+	cPath := C.CString(obj.path)
+	cReplNum := C.CString(strconv.Itoa(replNum))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cReplNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_verify_replica(cPath, cReplNum, verify, &cSize, &mismatch, ccon, &errMsg); status != 0 {
+		return 0, false, newError(Fatal, status, fmt.Sprintf("iRODS VerifyReplica Failed: %v replica %v, %v", obj.path, replNum, C.GoString(errMsg)))
+	}
+
+	return int64(cSize), mismatch == 0, nil
+}
+
+func (obj *DataObj) cTrimReplica(replNum int) error {
+	var err *C.char
+
+	cPath := C.CString(obj.path)
+	cReplNum := C.CString(strconv.Itoa(replNum))
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cReplNum))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_trim_replica(ccon, cPath, cReplNum, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS TrimReplica Failed: %v replica %v, %v", obj.path, replNum, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func (obj *DataObj) cPhysicalMove(src string, dest string) error {
+	var err *C.char
+
+	cPath := C.CString(obj.path)
+	cSrc := C.CString(src)
+	cDest := C.CString(dest)
+	defer C.free(unsafe.Pointer(cPath))
+	defer C.free(unsafe.Pointer(cSrc))
+	defer C.free(unsafe.Pointer(cDest))
+
+	ccon := obj.con.GetCcon()
+	defer obj.con.ReturnCcon(ccon)
+
+	if status := C.gorods_phymv_dataobject(ccon, cPath, cSrc, cDest, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS PhysicalMove Failed: %v from %v to %v, %v", obj.path, src, dest, C.GoString(err)))
+	}
+
+	return nil
+}
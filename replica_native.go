@@ -0,0 +1,27 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+func (obj *DataObj) cOpenReplica(replNum int, write bool) (*DataObj, error) {
+	return nil, errNoC
+}
+
+func (obj *DataObj) cChksumReplica(replNum int) (string, error) {
+	return "", errNoC
+}
+
+func (obj *DataObj) cVerifyReplica(replNum int) (size int64, ok bool, err error) {
+	return 0, false, errNoC
+}
+
+func (obj *DataObj) cTrimReplica(replNum int) error {
+	return errNoC
+}
+
+func (obj *DataObj) cPhysicalMove(src string, dest string) error {
+	return errNoC
+}
@@ -0,0 +1,38 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"testing"
+)
+
+func TestReplicaStatus(t *testing.T) {
+
+	tests := []struct {
+		status int
+		name   string
+		good   bool
+		stale  bool
+		locked bool
+	}{
+		{StaleReplica, "stale", false, true, false},
+		{GoodReplica, "good", true, false, false},
+		{IntermediateReplica, "intermediate", false, false, true},
+		{ReadLockedReplica, "read-locked", false, false, true},
+		{WriteLockedReplica, "write-locked", false, false, true},
+	}
+
+	for _, test := range tests {
+		repl := &Replica{Status: test.status}
+
+		if repl.StatusString() != test.name || repl.Good() != test.good || repl.Stale() != test.stale || repl.Locked() != test.locked {
+			t.Errorf("Unexpected status of %+v: %v %v %v %v", repl, repl.StatusString(), repl.Good(), repl.Stale(), repl.Locked())
+		}
+	}
+
+	repl := &Replica{Num: 1, RescHier: "archiveResc;s3", Size: 4, Status: GoodReplica, Checksum: "md5:abc", PhysicalPath: "/vault/a"}
+	if s := repl.String(); s != "1 archiveResc;s3 4 good md5:abc /vault/a" {
+		t.Errorf("Unexpected String() %q", s)
+	}
+}
@@ -0,0 +1,424 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+package replpolicy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/irodsfs"
+)
+
+// FS is the part of irodsfs.Filesystem used by the replication manager
+type FS interface {
+	irodsfs.QueryFS
+	irodsfs.ReplicaFS
+}
+
+// Actions of the operations
+const (
+	Replicate = "replicate"
+	Trim      = "trim"
+	Move      = "phymv"
+)
+
+// Options tune Apply
+type Options struct {
+	// DryRun only plans the operations
+	DryRun bool
+
+	// Workers is the number of data objects whose operations run in parallel. It defaults to 4.
+	Workers int
+
+	// PerResource limits the operations running in parallel on a resource, counting the
+	// destination of replications and moves and the resource of trimmed replicas. 0 is no limit.
+	PerResource int
+
+	// Retries is the number of times a failed operation is tried again. It defaults to 2, a
+	// negative value disables retries.
+	Retries int
+
+	// RetryDelay is the wait before the first retry, doubled after each one. It defaults to a
+	// second.
+	RetryDelay time.Duration
+}
+
+// Operation is a call bringing a data object in line with its rule. Replications set Resource,
+// trims set Source and ReplNum, moves set Source and Resource. Attempts and Error are set once
+// the operation ran.
+type Operation struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Resource string `json:"resource,omitempty"`
+	Source   string `json:"source,omitempty"`
+	ReplNum  int    `json:"replNum"`
+	Attempts int    `json:"attempts,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// String describes the operation like icommands arguments
+func (op Operation) String() string {
+	switch op.Action {
+	case Replicate:
+		return fmt.Sprintf("irepl -R %v %v", op.Resource, op.Path)
+	case Trim:
+		return fmt.Sprintf("itrim -N 1 -n %v %v", op.ReplNum, op.Path)
+	case Move:
+		return fmt.Sprintf("iphymv -S %v -R %v %v", op.Source, op.Resource, op.Path)
+	}
+
+	return fmt.Sprintf("%v %v", op.Action, op.Path)
+}
+
+// Failure is a query that failed, or an object whose rule can't be satisfied
+type Failure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Report is the result of Apply. Operations are ordered by path, the operations of an object
+// run one after the other in that order, and stop at the first that fails.
+type Report struct {
+	DryRun     bool        `json:"dryRun"`
+	Scanned    int         `json:"scanned"`
+	Compliant  int         `json:"compliant"`
+	Operations []Operation `json:"operations"`
+	Failures   []Failure   `json:"failures,omitempty"`
+}
+
+// Failed returns the operations that failed or were skipped
+func (report *Report) Failed() []Operation {
+	var ops []Operation
+
+	for _, op := range report.Operations {
+		if op.Error != "" {
+			ops = append(ops, op)
+		}
+	}
+
+	return ops
+}
+
+// String summarizes the report
+// example: 1200 objects scanned, 1190 compliant, 14 operations, 1 failed
+func (report *Report) String() string {
+	return fmt.Sprintf("%v objects scanned, %v compliant, %v operations, %v failed", report.Scanned, report.Compliant, len(report.Operations), len(report.Failed())+len(report.Failures))
+}
+
+// replica is a replica listed by the scan
+type replica struct {
+	num      int
+	resource string
+	good     bool
+}
+
+// object is a data object listed by the scan, and the rule governing it
+type object struct {
+	path     string
+	rule     *Rule
+	replicas []replica
+}
+
+// Apply scans the objects of the policy, plans the replications, moves and trims placing their
+// replicas as their rules want, and runs them unless opts.DryRun is set. Failed queries and
+// operations are reported, the error is only set for an invalid policy.
+func Apply(fsys FS, policy *Policy, opts Options) (*Report, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+
+	if opts.Retries == 0 {
+		opts.Retries = 2
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+
+	report := &Report{DryRun: opts.DryRun, Operations: []Operation{}}
+
+	objs := scan(fsys, policy, report)
+
+	var plans [][]Operation
+
+	for _, obj := range objs {
+		ops, err := plan(obj)
+		if err != nil {
+			report.Failures = append(report.Failures, Failure{Path: obj.path, Error: err.Error()})
+		}
+
+		if len(ops) == 0 && err == nil {
+			report.Compliant++
+		}
+
+		if len(ops) > 0 {
+			plans = append(plans, ops)
+		}
+	}
+
+	if !opts.DryRun {
+		run(fsys, plans, opts)
+	}
+
+	for _, ops := range plans {
+		report.Operations = append(report.Operations, ops...)
+	}
+
+	sort.Slice(report.Failures, func(i, j int) bool {
+		return report.Failures[i].Path < report.Failures[j].Path
+	})
+
+	return report, nil
+}
+
+// scan lists the replicas of the objects governed by the rules, with GenQuery
+func scan(fsys FS, policy *Policy, report *Report) []*object {
+	found := make(map[string]*object)
+
+	for inx := range policy.Rules {
+		rule := &policy.Rules[inx]
+		root := path.Clean(rule.Path)
+
+		// The objects selected by this rule, and not by an earlier one
+		mine := make(map[string]bool)
+
+		for _, query := range rule.queries() {
+			rows, err := fsys.IQuest(query, false)
+			if err != nil {
+				report.Failures = append(report.Failures, Failure{Path: root, Error: err.Error()})
+				continue
+			}
+
+			for _, row := range rows {
+				coll := row["COLL_NAME"]
+
+				// like also matches the _ and % of paths
+				if coll != root && !strings.HasPrefix(coll, strings.TrimSuffix(root, "/")+"/") {
+					continue
+				}
+
+				p := path.Join(coll, row["DATA_NAME"])
+
+				obj, ok := found[p]
+				if !ok {
+					obj = &object{path: p, rule: rule}
+					found[p] = obj
+					mine[p] = true
+				}
+
+				if !mine[p] {
+					continue
+				}
+
+				num, _ := strconv.Atoi(row["DATA_REPL_NUM"])
+				status, _ := strconv.Atoi(row["DATA_REPL_STATUS"])
+
+				obj.replicas = append(obj.replicas, replica{
+					num:      num,
+					resource: strings.SplitN(row["DATA_RESC_HIER"], ";", 2)[0],
+					good:     status == irodsfs.Good,
+				})
+			}
+		}
+	}
+
+	objs := make([]*object, 0, len(found))
+	for _, obj := range found {
+		objs = append(objs, obj)
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].path < objs[j].path
+	})
+
+	report.Scanned = len(objs)
+
+	return objs
+}
+
+// plan returns the operations placing the replicas of obj as its rule wants: a good replica of
+// a forbidden resource is moved to a wanted resource without one, other wanted resources get a
+// replication, then the replicas left on forbidden resources are trimmed. The error is set when
+// the trims would leave no good replica, those trims aren't planned.
+func plan(obj *object) ([]Operation, error) {
+	rule := obj.rule
+
+	sort.Slice(obj.replicas, func(i, j int) bool {
+		return obj.replicas[i].num < obj.replicas[j].num
+	})
+
+	var (
+		ops     []Operation
+		moved   = make(map[int]bool)
+		trimmed []replica
+	)
+
+	for _, resc := range rule.Resources {
+		good, present := false, false
+
+		for _, repl := range obj.replicas {
+			if repl.resource == resc {
+				present = true
+				good = good || repl.good
+			}
+		}
+
+		if good {
+			continue
+		}
+
+		op := Operation{Path: obj.path, Action: Replicate, Resource: resc}
+
+		// Moving saves a replication and a trim, the destination can't have a replica
+		if !present {
+			for _, repl := range obj.replicas {
+				if repl.good && !moved[repl.num] && rule.forbids(repl.resource) {
+					moved[repl.num] = true
+					op = Operation{Path: obj.path, Action: Move, Source: repl.resource, Resource: resc, ReplNum: repl.num}
+					break
+				}
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	// Good replicas once the replications and moves are done, before trimming
+	kept := len(rule.Resources)
+
+	for _, repl := range obj.replicas {
+		switch {
+		case moved[repl.num]:
+		case rule.forbids(repl.resource):
+			trimmed = append(trimmed, repl)
+		case repl.good && !rule.wants(repl.resource):
+			kept++
+		}
+	}
+
+	var err error
+
+	for _, repl := range trimmed {
+		if repl.good && kept == 0 {
+			err = fmt.Errorf("trimming the replica on %v would leave no good replica", repl.resource)
+			continue
+		}
+
+		ops = append(ops, Operation{Path: obj.path, Action: Trim, Source: repl.resource, ReplNum: repl.num})
+	}
+
+	return ops, err
+}
+
+// run runs the operations of each object in order, the objects in parallel
+func run(fsys FS, plans [][]Operation, opts Options) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		rescs = make(map[string]chan struct{})
+	)
+
+	// acquire waits for a free slot on resc, and returns its release
+	acquire := func(resc string) func() {
+		if opts.PerResource <= 0 {
+			return func() {}
+		}
+
+		mu.Lock()
+		sem, ok := rescs[resc]
+		if !ok {
+			sem = make(chan struct{}, opts.PerResource)
+			rescs[resc] = sem
+		}
+		mu.Unlock()
+
+		sem <- struct{}{}
+
+		return func() { <-sem }
+	}
+
+	queue := make(chan []Operation)
+
+	for inx := 0; inx < opts.Workers; inx++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for ops := range queue {
+				failed := false
+
+				for inx := range ops {
+					op := &ops[inx]
+
+					if failed {
+						op.Error = "skipped, a previous operation failed"
+						continue
+					}
+
+					resc := op.Resource
+					if op.Action == Trim {
+						resc = op.Source
+					}
+
+					release := acquire(resc)
+					runOp(fsys, op, opts)
+					release()
+
+					failed = op.Error != ""
+				}
+			}
+		}()
+	}
+
+	for _, ops := range plans {
+		queue <- ops
+	}
+
+	close(queue)
+	wg.Wait()
+}
+
+// runOp calls op, retrying it on failure
+func runOp(fsys FS, op *Operation, opts Options) {
+	delay := opts.RetryDelay
+
+	for {
+		var err error
+
+		switch op.Action {
+		case Replicate:
+			err = fsys.Replicate(op.Path, op.Resource)
+		case Trim:
+			err = fsys.Trim(op.Path, op.ReplNum)
+		case Move:
+			err = fsys.PhysicalMove(op.Path, op.Source, op.Resource)
+		}
+
+		op.Attempts++
+
+		if err == nil {
+			op.Error = ""
+			return
+		}
+
+		op.Error = err.Error()
+
+		if op.Attempts > opts.Retries {
+			return
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,176 @@
+/*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
+ *** For more information please refer to the LICENSE.md file                                   ***/
+
+// Package replpolicy keeps the replicas of data objects where a declarative policy wants them.
+// Apply scans the zone with GenQuery, plans the irepl, itrim and iphymv operations bringing
+// every object in line with its rule, and runs them in parallel with retries:
+//
+//	policy, err := replpolicy.Load("tiers.json")
+//	report, err := replpolicy.Apply(con.Filesystem().(replpolicy.FS), policy, replpolicy.Options{Workers: 8})
+//
+// A policy written in JSON, gold objects of the projects must have good replicas on
+// demoResc and archiveResc, and none on scratchResc:
+//
+//	{"rules": [
+//		{"path": "/tempZone/projects", "attribute": "tier", "value": "gold",
+//		 "resources": ["demoResc", "archiveResc"], "forbidden": ["scratchResc"]},
+//		{"path": "/tempZone/projects", "resources": ["demoResc"], "exclusive": true}
+//	]}
+//
+// Built with the gorods_yaml tag, Parse and Load also read the policies written in YAML.
+//
+// It works with the irodsfs implementations that support queries and replicas,
+// gorods.Connection.Filesystem() and gorodstest.Connection.
+package replpolicy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jjacquay712/GoRODS/internal/policyfile"
+)
+
+// Policy is the desired placement of the replicas of data objects
+type Policy struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule is the desired placement of the replicas of the data objects below the collection Path,
+// or of those with an AVU named Attribute when it's set, and with the value Value when it's
+// also set. When rules overlap, the first rule of the policy governs an object.
+type Rule struct {
+	Path      string `yaml:"path" json:"path"`
+	Attribute string `yaml:"attribute,omitempty" json:"attribute,omitempty"`
+	Value     string `yaml:"value,omitempty" json:"value,omitempty"`
+
+	// Resources must each hold a good replica
+	Resources []string `yaml:"resources,omitempty" json:"resources,omitempty"`
+
+	// Forbidden resources must hold no replica
+	Forbidden []string `yaml:"forbidden,omitempty" json:"forbidden,omitempty"`
+
+	// Exclusive forbids every resource missing from Resources
+	Exclusive bool `yaml:"exclusive,omitempty" json:"exclusive,omitempty"`
+}
+
+// Parse decodes a JSON policy, or a YAML one in builds with the gorods_yaml tag, and validates
+// it. Unknown fields are an error.
+func Parse(data []byte) (*Policy, error) {
+	policy := new(Policy)
+
+	if err := policyfile.Parse(data, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Load reads and parses the policy file name
+func Load(name string) (*Policy, error) {
+	policy := new(Policy)
+
+	if err := policyfile.Load(name, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Validate checks the paths, AVUs and resources of the rules
+func (policy *Policy) Validate() error {
+	for inx, rule := range policy.Rules {
+		if !path.IsAbs(rule.Path) {
+			return fmt.Errorf("rule %d: path %q isn't an absolute iRODS path", inx+1, rule.Path)
+		}
+
+		// GenQuery has no escape sequence for single quotes
+		for _, val := range []string{rule.Path, rule.Attribute, rule.Value} {
+			if strings.Contains(val, "'") {
+				return fmt.Errorf("rule %d: %q contains a single quote", inx+1, val)
+			}
+		}
+
+		if rule.Value != "" && rule.Attribute == "" {
+			return fmt.Errorf("rule %d: value without attribute", inx+1)
+		}
+
+		if len(rule.Resources) == 0 && len(rule.Forbidden) == 0 && !rule.Exclusive {
+			return fmt.Errorf("rule %d: no resources or forbidden resources", inx+1)
+		}
+
+		if rule.Exclusive && len(rule.Resources) == 0 {
+			return fmt.Errorf("rule %d: exclusive rule without resources", inx+1)
+		}
+
+		wanted := make(map[string]bool)
+
+		for _, resc := range rule.Resources {
+			if resc == "" || wanted[resc] {
+				return fmt.Errorf("rule %d: invalid or repeated resource %q", inx+1, resc)
+			}
+
+			wanted[resc] = true
+		}
+
+		for _, resc := range rule.Forbidden {
+			if wanted[resc] {
+				return fmt.Errorf("rule %d: resource %v is both wanted and forbidden", inx+1, resc)
+			}
+		}
+	}
+
+	return nil
+}
+
+// wants reports whether the rule wants a good replica on resc
+func (rule *Rule) wants(resc string) bool {
+	for _, r := range rule.Resources {
+		if r == resc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forbids reports whether the rule forbids replicas on resc
+func (rule *Rule) forbids(resc string) bool {
+	if rule.Exclusive {
+		return !rule.wants(resc)
+	}
+
+	for _, r := range rule.Forbidden {
+		if r == resc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queries returns the GenQueries listing the replicas of the objects governed by the rule
+func (rule *Rule) queries() []string {
+	p := path.Clean(rule.Path)
+
+	cond := ""
+	if rule.Attribute != "" {
+		cond += " and META_DATA_ATTR_NAME = '" + rule.Attribute + "'"
+	}
+
+	if rule.Value != "" {
+		cond += " and META_DATA_ATTR_VALUE = '" + rule.Value + "'"
+	}
+
+	below := p + "/%"
+	if p == "/" {
+		below = "/%"
+	}
+
+	sel := "select COLL_NAME, DATA_NAME, DATA_REPL_NUM, DATA_RESC_HIER, DATA_REPL_STATUS where COLL_NAME "
+
+	return []string{
+		sel + "= '" + p + "'" + cond,
+		sel + "like '" + below + "'" + cond,
+	}
+}
@@ -0,0 +1,166 @@
+package replpolicy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+const testPolicy = `
+{"rules": [
+	{"path": "/tempZone/home/rods/proj", "attribute": "tier", "value": "gold",
+	 "resources": ["demoResc", "archiveResc"], "forbidden": ["scratchResc"]},
+	{"path": "/tempZone/home/rods/proj", "resources": ["demoResc"], "exclusive": true}
+]}
+`
+
+const proj = "/tempZone/home/rods/proj"
+
+func testFS(t *testing.T) *gorodstest.FlakyConnection {
+	srv := gorodstest.NewServer("tempZone")
+	srv.CreateResource("archiveResc")
+	srv.CreateResource("scratchResc")
+
+	con, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	con.Mkdir(proj+"/sub", true)
+	con.Mkdir("/tempZone/home/rods/other", true)
+
+	put := func(p string, resource string, tier string) {
+		if err := con.Put(p, []byte(p), gorodstest.PutOptions{Resource: resource}); err != nil {
+			t.Fatal(err)
+		}
+
+		if tier != "" {
+			con.AddMeta(p, gorodstest.AVU{Attribute: "tier", Value: tier})
+		}
+	}
+
+	// Gold, on the wanted resources already
+	put(proj+"/done.txt", gorodstest.DefaultResource, "gold")
+	con.Replicate(proj+"/done.txt", "archiveResc")
+
+	// Gold, only on scratch: moved to demoResc, replicated to archiveResc
+	put(proj+"/scratch.txt", "scratchResc", "gold")
+
+	// Gold, stale on archiveResc and a copy on scratch to trim
+	put(proj+"/sub/stale.txt", gorodstest.DefaultResource, "gold")
+	con.Replicate(proj+"/sub/stale.txt", "archiveResc")
+	con.Replicate(proj+"/sub/stale.txt", "scratchResc")
+	con.Put(proj+"/sub/stale.txt", []byte("new"), gorodstest.PutOptions{Force: true})
+
+	// Silver, governed by the exclusive rule: the archive copy is trimmed
+	put(proj+"/silver.txt", gorodstest.DefaultResource, "silver")
+	con.Replicate(proj+"/silver.txt", "archiveResc")
+
+	// Outside of the policy
+	put("/tempZone/home/rods/other/scratch.txt", "scratchResc", "gold")
+
+	return gorodstest.NewFlakyConnection(con)
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse([]byte(testPolicy)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, invalid := range []string{
+		`{"rules": [{"path": "relative", "resources": ["demoResc"]}]}`,
+		`{"rules": [{"path": "/tempZone/proj"}]}`,
+		`{"rules": [{"path": "/tempZone/proj", "value": "gold", "resources": ["demoResc"]}]}`,
+		`{"rules": [{"path": "/tempZone/proj", "resources": ["demoResc"], "forbidden": ["demoResc"]}]}`,
+		`{"rules": [{"path": "/tempZone/it's", "resources": ["demoResc"]}]}`,
+		`{"rules": [{"path": "/tempZone/proj", "exclusive": true}]}`,
+		`{"rules": [{"path": "/tempZone/proj", "resources": ["demoResc"], "copies": 2}]}`,
+	} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Fatalf("Invalid policy was parsed: %q", invalid)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	fsys := testFS(t)
+	policy, _ := Parse([]byte(testPolicy))
+
+	report, err := Apply(fsys, policy, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var planned []string
+	for _, op := range report.Operations {
+		planned = append(planned, op.String())
+	}
+
+	want := []string{
+		"iphymv -S scratchResc -R demoResc " + proj + "/scratch.txt",
+		"irepl -R archiveResc " + proj + "/scratch.txt",
+		"itrim -N 1 -n 1 " + proj + "/silver.txt",
+		"irepl -R archiveResc " + proj + "/sub/stale.txt",
+		"itrim -N 1 -n 2 " + proj + "/sub/stale.txt",
+	}
+
+	if !reflect.DeepEqual(planned, want) || report.Scanned != 4 || report.Compliant != 1 {
+		t.Fatalf("Unexpected plan %q, %v", planned, report)
+	}
+
+	// The flaky replication of scratch.txt succeeds on its third attempt
+	fsys.Fail("Replicate", proj+"/scratch.txt", 2)
+
+	report, err = Apply(fsys, policy, Options{Workers: 2, PerResource: 1, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Failed()) != 0 || report.Operations[1].Attempts != 3 {
+		t.Fatalf("Unexpected report %v %+v", report, report.Operations)
+	}
+
+	repls, _ := fsys.Replicas(proj + "/sub/stale.txt")
+	if len(repls) != 2 || repls[1].Resource != "archiveResc" || repls[1].Status != gorodstest.Good {
+		t.Fatalf("Unexpected replicas %+v", repls)
+	}
+
+	if report, _ = Apply(fsys, policy, Options{DryRun: true}); len(report.Operations) != 0 || report.Compliant != 4 {
+		t.Fatalf("Objects still drift %v", report)
+	}
+
+	if repls, _ := fsys.Replicas("/tempZone/home/rods/other/scratch.txt"); len(repls) != 1 {
+		t.Fatalf("Object outside of the policy was changed: %+v", repls)
+	}
+}
+
+func TestApplyFailures(t *testing.T) {
+	fsys := testFS(t)
+	policy, _ := Parse([]byte(testPolicy))
+
+	// The replication fails for good, the trim after it is skipped
+	fsys.Fail("Replicate", proj+"/sub/stale.txt", 10)
+
+	report, err := Apply(fsys, policy, Options{Retries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := report.Failed()
+	if len(failed) != 2 || failed[0].Attempts != 1 || failed[0].Error != gorodstest.ErrConnectionReset.Error() || failed[1].Attempts != 0 {
+		t.Fatalf("Unexpected failures %+v", failed)
+	}
+
+	if repls, _ := fsys.Replicas(proj + "/sub/stale.txt"); len(repls) != 3 {
+		t.Fatalf("Replica was trimmed after a failed replication: %+v", repls)
+	}
+
+	// A rule that only forbids a resource can't trim the last good replica
+	policy, _ = Parse([]byte(`{"rules": [{"path": "/tempZone/home/rods/other", "forbidden": ["scratchResc"]}]}`))
+
+	if report, _ = Apply(fsys, policy, Options{}); len(report.Failures) != 1 || len(report.Operations) != 0 {
+		t.Fatalf("Unexpected report %v %+v", report, report.Failures)
+	}
+}
@@ -3,15 +3,10 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
-	"unsafe"
 )
 
 // Resource holds information about a resource server registered with iCAT.
@@ -318,50 +313,9 @@ func (resc *Resource) RefreshInfo() error {
 
 // FetchInfo fetches fresh resource info from the iCAT server and returns it as a map
 func (resc *Resource) FetchInfo() (map[string]string, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	cResource := C.CString(resc.name)
-	defer C.free(unsafe.Pointer(cResource))
-
-	ccon := resc.con.GetCcon()
-
-	if status := C.gorods_get_resource(cResource, ccon, &result, &err); status != 0 {
-		resc.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Resource Info Failed: %v", C.GoString(err)))
-	}
-
-	resc.con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	response := make(map[string]string)
-
-	for _, resourceInfo := range slice {
-
-		resourceAttributes := strings.Split(strings.Trim(C.GoString(resourceInfo), " \n"), "\n")
-
-		for _, attr := range resourceAttributes {
-
-			split := strings.Split(attr, ": ")
-
-			attrName := split[0]
-			attrVal := split[1]
-
-			response[attrName] = attrVal
-
-		}
+	if resc.con.fs != nil {
+		return resc.fsFetchInfo()
 	}
 
-	return response, nil
+	return resc.cFetchInfo()
 }
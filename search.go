@@ -0,0 +1,361 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchOptions describes a search for data objects and collections below a path.
+// All set fields must match. Name matches a substring of the object name. Value may
+// contain % wildcards. Sizes are in bytes; only data objects are returned when MinSize
+// or MaxSize is set. Page is 1-based, PerPage defaults to 50.
+type SearchOptions struct {
+	Name string
+
+	Attribute string
+	Value     string
+	Units     string
+
+	MinSize int64
+	MaxSize int64
+
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	Page    int
+	PerPage int
+}
+
+// SearchResult is a single match returned by Connection.Search
+type SearchResult struct {
+	Path       string
+	Name       string
+	Type       int
+	Size       int64
+	ModifyTime time.Time
+}
+
+// SearchResults is a single page of search matches
+type SearchResults struct {
+	Results SearchResultSlice
+	Total   int
+	Page    int
+	PerPage int
+}
+
+// SearchResultSlice is a slice of SearchResult, sortable by path
+type SearchResultSlice []SearchResult
+
+func (s SearchResultSlice) Len() int           { return len(s) }
+func (s SearchResultSlice) Less(i, j int) bool { return s[i].Path < s[j].Path }
+func (s SearchResultSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Pages returns the number of pages available
+func (sr *SearchResults) Pages() int {
+	if sr.PerPage <= 0 {
+		return 0
+	}
+
+	return (sr.Total + sr.PerPage - 1) / sr.PerPage
+}
+
+// HasNext returns true if there is a page after the current one
+func (sr *SearchResults) HasNext() bool {
+	return sr.Page < sr.Pages()
+}
+
+// HasPrev returns true if there is a page before the current one
+func (sr *SearchResults) HasPrev() bool {
+	return sr.Page > 1
+}
+
+// IsEmpty returns true if no search criteria are set
+func (opts SearchOptions) IsEmpty() bool {
+	return opts.Name == "" && opts.Attribute == "" && opts.Value == "" && opts.Units == "" &&
+		opts.MinSize == 0 && opts.MaxSize == 0 && opts.ModifiedAfter.IsZero() && opts.ModifiedBefore.IsZero()
+}
+
+// genQueryString quotes a value for use in a GenQuery condition. GenQuery has no escape
+// sequence for single quotes, so values containing them are rejected.
+func genQueryString(val string) (string, error) {
+	if strings.Contains(val, "'") {
+		return "", newError(Fatal, -1, fmt.Sprintf("GenQuery values can't contain single quotes: %v", val))
+	}
+
+	return "'" + val + "'", nil
+}
+
+// likeEscape escapes the wildcards of a LIKE pattern in val, so it only matches itself
+func likeEscape(val string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(val)
+}
+
+// genQueryTime formats t the way iRODS stores timestamps, so they can be compared as strings
+func genQueryTime(t time.Time) string {
+	return fmt.Sprintf("'%011d'", t.Unix())
+}
+
+func (opts SearchOptions) conditions(scope string, dataObjs bool) ([]string, error) {
+	var (
+		conds   []string
+		metaCol = "META_COLL_ATTR_"
+		nameCol = "COLL_NAME"
+		timeCol = "COLL_MODIFY_TIME"
+	)
+
+	scope = strings.TrimRight(scope, "/")
+
+	qScope, err := genQueryString(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	qScopeChildren, err := genQueryString(likeEscape(scope) + "/%")
+	if err != nil {
+		return nil, err
+	}
+
+	if dataObjs {
+		metaCol = "META_DATA_ATTR_"
+		nameCol = "DATA_NAME"
+		timeCol = "DATA_MODIFY_TIME"
+
+		conds = append(conds, "COLL_NAME = "+qScope+" || like "+qScopeChildren)
+	} else {
+		conds = append(conds, "COLL_NAME like "+qScopeChildren)
+	}
+
+	if opts.Name != "" {
+		if dataObjs {
+			q, err := genQueryString("%" + likeEscape(opts.Name) + "%")
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, nameCol+" like "+q)
+		} else {
+			q, err := genQueryString(likeEscape(scope) + "/%" + likeEscape(opts.Name) + "%")
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, nameCol+" like "+q)
+		}
+	}
+
+	avu := []struct{ col, val string }{
+		{"NAME = ", opts.Attribute},
+		{"VALUE like ", opts.Value},
+		{"UNITS = ", opts.Units},
+	}
+
+	for _, c := range avu {
+		if c.val == "" {
+			continue
+		}
+
+		q, err := genQueryString(c.val)
+		if err != nil {
+			return nil, err
+		}
+
+		conds = append(conds, metaCol+c.col+q)
+	}
+
+	if dataObjs {
+		if opts.MinSize > 0 {
+			conds = append(conds, "DATA_SIZE >= '"+strconv.FormatInt(opts.MinSize, 10)+"'")
+		}
+
+		if opts.MaxSize > 0 {
+			conds = append(conds, "DATA_SIZE <= '"+strconv.FormatInt(opts.MaxSize, 10)+"'")
+		}
+	}
+
+	if !opts.ModifiedAfter.IsZero() {
+		conds = append(conds, timeCol+" >= "+genQueryTime(opts.ModifiedAfter))
+	}
+
+	if !opts.ModifiedBefore.IsZero() {
+		conds = append(conds, timeCol+" <= "+genQueryTime(opts.ModifiedBefore))
+	}
+
+	return conds, nil
+}
+
+// Search finds data objects and collections below scope (inclusive) matching opts, using GenQuery.
+// Collections come first, then data objects, in the order of GenQuery. The page is fetched with
+// the row offset and limit of GenQuery, a page past the last one returns the last page. Total
+// counts the distinct rows, a data object whose replicas differ in size or modify time is
+// counted once per replica.
+func (con *Connection) Search(scope string, opts SearchOptions) (*SearchResults, error) {
+
+	if opts.PerPage <= 0 {
+		opts.PerPage = 50
+	}
+
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	objConds, err := opts.conditions(scope, true)
+	if err != nil {
+		return nil, err
+	}
+
+	objQuery := "select COLL_NAME, DATA_NAME, DATA_SIZE, DATA_MODIFY_TIME where " + strings.Join(objConds, " and ")
+
+	_, objTotal, err := con.iquestPage(objQuery, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		colQuery string
+		colTotal int
+		named    SearchResultSlice
+	)
+
+	// Collections have no size, so they can't match a size range
+	if opts.MinSize == 0 && opts.MaxSize == 0 {
+		colConds, err := opts.conditions(scope, false)
+		if err != nil {
+			return nil, err
+		}
+
+		colQuery = "select COLL_NAME, COLL_MODIFY_TIME where " + strings.Join(colConds, " and ")
+
+		if opts.Name != "" {
+			if named, err = con.namedCollections(colQuery, opts.Name); err != nil {
+				return nil, err
+			}
+
+			colTotal = len(named)
+		} else if _, colTotal, err = con.iquestPage(colQuery, 0, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	results := &SearchResults{
+		Results: make(SearchResultSlice, 0),
+		Total:   colTotal + objTotal,
+		Page:    opts.Page,
+		PerPage: opts.PerPage,
+	}
+
+	if pages := results.Pages(); results.Page > pages {
+		results.Page = pages
+	}
+
+	if results.Page < 1 {
+		results.Page = 1
+	}
+
+	start := (results.Page - 1) * opts.PerPage
+	end := start + opts.PerPage
+
+	if start < colTotal {
+		colEnd := end
+		if colEnd > colTotal {
+			colEnd = colTotal
+		}
+
+		if opts.Name != "" {
+			results.Results = append(results.Results, named[start:colEnd]...)
+		} else {
+			rows, _, err := con.iquestPage(colQuery, start, colEnd-start)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, row := range rows {
+				results.Results = append(results.Results, collectionResult(row))
+			}
+		}
+	}
+
+	if end > colTotal && objTotal > 0 {
+		objStart := start - colTotal
+		if objStart < 0 {
+			objStart = 0
+		}
+
+		rows, _, err := con.iquestPage(objQuery, objStart, end-colTotal-objStart)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			size, _ := strconv.ParseInt(row["DATA_SIZE"], 10, 64)
+
+			results.Results = append(results.Results, SearchResult{
+				Path:       row["COLL_NAME"] + "/" + row["DATA_NAME"],
+				Name:       row["DATA_NAME"],
+				Type:       DataObjType,
+				Size:       size,
+				ModifyTime: timeStringToTime(row["DATA_MODIFY_TIME"]),
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// namedCollections runs the collection query of a search on name. Its condition applies to the
+// whole path of collections, so the matches on the last segment are kept here.
+func (con *Connection) namedCollections(query string, name string) (SearchResultSlice, error) {
+	rows, err := con.IQuest(query, false)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(SearchResultSlice, 0, len(rows))
+
+	for _, row := range rows {
+		if match := collectionResult(row); strings.Contains(match.Name, name) {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.Sort(matches)
+
+	return matches, nil
+}
+
+func collectionResult(row map[string]string) SearchResult {
+	p := row["COLL_NAME"]
+
+	return SearchResult{
+		Path:       p,
+		Name:       p[strings.LastIndex(p, "/")+1:],
+		Type:       CollectionType,
+		ModifyTime: timeStringToTime(row["COLL_MODIFY_TIME"]),
+	}
+}
+
+// iquestPage runs query and returns limit rows starting at row offset, with the number of rows
+// matched by the query
+func (con *Connection) iquestPage(query string, offset int, limit int) ([]map[string]string, int, error) {
+	var (
+		rows  []map[string]string
+		total int
+		err   error
+	)
+
+	if con.fs != nil {
+		rows, total, err = con.fsIQuestPage(query, offset, limit)
+	} else {
+		rows, total, err = con.cIQuestPage(query, offset, limit)
+	}
+
+	// GenQuery doesn't count the rows when the offset is past the last one
+	if err == nil && len(rows) == 0 && offset > 0 {
+		_, total, err = con.iquestPage(query, 0, 1)
+	}
+
+	return rows, total, err
+}
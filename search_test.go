@@ -0,0 +1,136 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjacquay712/GoRODS/gorodstest"
+)
+
+func TestSearchConditions(t *testing.T) {
+	opts := SearchOptions{
+		Name:          "sample",
+		Attribute:     "project",
+		Value:         "abc%",
+		MinSize:       10,
+		ModifiedAfter: time.Unix(1500000000, 0),
+	}
+
+	conds, err := opts.conditions("/tempZone/home/rods/", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"COLL_NAME = '/tempZone/home/rods' || like '/tempZone/home/rods/%'",
+		"DATA_NAME like '%sample%'",
+		"META_DATA_ATTR_NAME = 'project'",
+		"META_DATA_ATTR_VALUE like 'abc%'",
+		"DATA_SIZE >= '10'",
+		"DATA_MODIFY_TIME >= '01500000000'",
+	}
+
+	if strings.Join(conds, " and ") != strings.Join(expected, " and ") {
+		t.Errorf("Expected %v, got %v", expected, conds)
+	}
+
+	conds, err = opts.conditions("/tempZone/home/rods", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conds[1] != "COLL_NAME like '/tempZone/home/rods/%sample%'" || conds[2] != "META_COLL_ATTR_NAME = 'project'" {
+		t.Errorf("Unexpected collection conditions %v", conds)
+	}
+
+	opts.Name = "50%_done"
+	if conds, _ := opts.conditions("/tempZone/my_home", true); conds[0] != `COLL_NAME = '/tempZone/my_home' || like '/tempZone/my\_home/%'` || conds[1] != `DATA_NAME like '%50\%\_done%'` {
+		t.Errorf("Expected escaped wildcards, got %v", conds)
+	}
+
+	opts.Name = "it's"
+	if _, err := opts.conditions("/tempZone", true); err == nil {
+		t.Errorf("Expected error for value containing a single quote")
+	}
+}
+
+func TestParseSearchOptions(t *testing.T) {
+	q, _ := url.ParseQuery("search=1&name=+foo+&minsize=5&before=2017-01-02&page=3&perpage=1000")
+
+	opts, err := parseSearchOptions(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Name != "foo" || opts.MinSize != 5 || opts.Page != 3 || opts.PerPage != 500 {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	if opts.ModifiedBefore.Format("2006-01-02 15:04:05") != "2017-01-02 23:59:59" {
+		t.Errorf("Expected before to include the whole day, got %v", opts.ModifiedBefore)
+	}
+
+	for _, bad := range []string{"minsize=-1", "maxsize=abc", "after=yesterday"} {
+		q, _ := url.ParseQuery(bad)
+		if _, err := parseSearchOptions(q); err == nil {
+			t.Errorf("Expected error for %v", bad)
+		}
+	}
+
+	if !(SearchOptions{Page: 2}).IsEmpty() {
+		t.Errorf("Expected options with only paging to be empty")
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	srv := gorodstest.NewServer("tempZone")
+
+	fsys, err := srv.Connect("rods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.Mkdir("/tempZone/home/rods/sample_dir", false)
+	fsys.Mkdir("/tempZone/home/rods/sampleXdir", false)
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "sample_1.txt", "sampleX1.txt"} {
+		fsys.Put("/tempZone/home/rods/"+name, []byte(name), gorodstest.PutOptions{})
+	}
+
+	con, err := NewConnection(&ConnectionOptions{Type: FilesystemDefined, Filesystem: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := con.Search("/tempZone/home/rods", SearchOptions{Page: 99, PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Total != 7 || results.Page != 4 || len(results.Results) != 1 || results.Results[0].Name != "sample_1.txt" {
+		t.Fatalf("Expected the last page, got %+v", results)
+	}
+
+	results, err = con.Search("/tempZone/home/rods", SearchOptions{Page: 2, PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.Results) != 2 || results.Results[0].Type != DataObjType || results.Results[0].Name != "a.txt" {
+		t.Fatalf("Expected the first data objects after the collections, got %+v", results.Results)
+	}
+
+	results, err = con.Search("/tempZone/home/rods", SearchOptions{Name: "sample_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Total != 2 || results.Results[0].Path != "/tempZone/home/rods/sample_dir" || results.Results[1].Name != "sample_1.txt" {
+		t.Fatalf("Expected the literal matches of sample_, got %+v", results.Results)
+	}
+}
@@ -0,0 +1,317 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ThumbnailSizes are the bounding box sizes (in pixels) thumbnails are generated at. Requested
+// sizes are rounded up to the next one, so a limited number of variants is cached per image.
+var ThumbnailSizes = []int{64, 128, 256, 512, 1024}
+
+// MaxThumbnailPixels is the largest image (width * height) that will be decoded to make a thumbnail
+const MaxThumbnailPixels = 50000000
+
+// thumbnailSize rounds size up to one of ThumbnailSizes
+func thumbnailSize(size int) int {
+	for _, s := range ThumbnailSizes {
+		if size <= s {
+			return s
+		}
+	}
+
+	return ThumbnailSizes[len(ThumbnailSizes)-1]
+}
+
+// Thumbnail decodes a PNG, JPEG or GIF image (the first frame of animated GIFs) and scales it
+// down to fit in a size x size box, keeping its aspect ratio. Images are never scaled up. JPEGs
+// are encoded as JPEG, everything else as PNG. The MIME type of the encoded thumbnail is returned.
+func Thumbnail(r io.Reader, size int) ([]byte, string, error) {
+	var head bytes.Buffer
+
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, "", newError(Fatal, -1, "Unable to read image: "+err.Error())
+	}
+
+	if cfg.Width*cfg.Height > MaxThumbnailPixels {
+		return nil, "", newError(Fatal, -1, "Image is too large to make a thumbnail")
+	}
+
+	var img image.Image
+
+	switch format {
+	case "png":
+		img, err = png.Decode(io.MultiReader(&head, r))
+	case "jpeg":
+		img, err = jpeg.Decode(io.MultiReader(&head, r))
+	case "gif":
+		img, err = gif.Decode(io.MultiReader(&head, r))
+	default:
+		return nil, "", newError(Fatal, -1, "Unsupported image format: "+format)
+	}
+
+	if err != nil {
+		return nil, "", newError(Fatal, -1, "Unable to decode image: "+err.Error())
+	}
+
+	thumb := scaleImage(img, size)
+
+	var out bytes.Buffer
+
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, thumb, &jpeg.Options{Quality: 85})
+		return out.Bytes(), "image/jpeg", err
+	}
+
+	err = png.Encode(&out, thumb)
+	return out.Bytes(), "image/png", err
+}
+
+// scaleImage shrinks img to fit in a size x size box using a box filter
+func scaleImage(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	if sw <= size && sh <= size || sw == 0 || sh == 0 {
+		return img
+	}
+
+	dw, dh := size, size
+	if sw > sh {
+		dh = sh * size / sw
+	} else {
+		dw = sw * size / sh
+	}
+
+	if dw < 1 {
+		dw = 1
+	}
+
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0 := b.Min.Y + y*sh/dh
+		y1 := b.Min.Y + (y+1)*sh/dh
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < dw; x++ {
+			x0 := b.Min.X + x*sw/dw
+			x1 := b.Min.X + (x+1)*sw/dw
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, bl, a, n uint64
+
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// ThumbnailCache stores generated thumbnails. Keys are derived from the checksum of the
+// source data object and the thumbnail size, so entries never go stale.
+type ThumbnailCache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, thumb []byte) error
+}
+
+// MemoryThumbnailCache keeps the most recently used thumbnails in memory
+type MemoryThumbnailCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryThumbnail struct {
+	key   string
+	thumb []byte
+}
+
+// NewMemoryThumbnailCache creates a MemoryThumbnailCache holding up to maxEntries thumbnails
+func NewMemoryThumbnailCache(maxEntries int) *MemoryThumbnailCache {
+	return &MemoryThumbnailCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached thumbnail for key
+func (c *MemoryThumbnailCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*memoryThumbnail).thumb, true
+	}
+
+	return nil, false
+}
+
+// Put adds a thumbnail to the cache, evicting the least recently used one when it's full
+func (c *MemoryThumbnailCache) Put(key string, thumb []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*memoryThumbnail).thumb = thumb
+		c.lru.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryThumbnail{key, thumb})
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryThumbnail).key)
+	}
+
+	return nil
+}
+
+// DirThumbnailCache stores thumbnails as files in a local directory
+type DirThumbnailCache struct {
+	dir string
+}
+
+// NewDirThumbnailCache creates a DirThumbnailCache in dir, creating the directory if needed
+func NewDirThumbnailCache(dir string) (*DirThumbnailCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DirThumbnailCache{dir: dir}, nil
+}
+
+// Get returns the cached thumbnail for key
+func (c *DirThumbnailCache) Get(key string) ([]byte, bool) {
+	thumb, err := ioutil.ReadFile(filepath.Join(c.dir, thumbnailFileName(key)))
+	if err != nil {
+		return nil, false
+	}
+
+	return thumb, true
+}
+
+// Put writes a thumbnail to the cache directory
+func (c *DirThumbnailCache) Put(key string, thumb []byte) error {
+	tmp, err := ioutil.TempFile(c.dir, ".thumb")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(thumb); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, thumbnailFileName(key)))
+}
+
+// ThumbnailCollection is the name of the hidden collection ObjectThumbnailCache stores thumbnails in
+const ThumbnailCollection = ".thumbnails"
+
+// ObjectThumbnailCache stores thumbnails as data objects in a hidden collection (ThumbnailCollection)
+// inside Collection, which is created when the first thumbnail is stored
+type ObjectThumbnailCache struct {
+	Collection *Collection
+}
+
+func (c *ObjectThumbnailCache) path(key string) string {
+	return c.Collection.Path() + "/" + ThumbnailCollection + "/" + thumbnailFileName(key)
+}
+
+// Get reads the thumbnail for key from iRODS
+func (c *ObjectThumbnailCache) Get(key string) ([]byte, bool) {
+	obj, err := c.Collection.Con().DataObject(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	thumb, err := obj.Read()
+	if err != nil || len(thumb) == 0 {
+		return nil, false
+	}
+
+	return thumb, true
+}
+
+// Put writes the thumbnail for key to iRODS
+func (c *ObjectThumbnailCache) Put(key string, thumb []byte) error {
+	con := c.Collection.Con()
+	thumbsPath := c.Collection.Path() + "/" + ThumbnailCollection
+
+	thumbs, err := con.Collection(CollectionOptions{Path: thumbsPath})
+	if err != nil {
+		if thumbs, err = c.Collection.CreateSubCollection(ThumbnailCollection); err != nil {
+			return err
+		}
+	}
+
+	obj, err := thumbs.CreateDataObj(DataObjOptions{
+		Name:  thumbnailFileName(key),
+		Force: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	return obj.Write(thumb)
+}
+
+// thumbnailFileName maps a cache key (which may contain any characters) to a file name
+func thumbnailFileName(key string) string {
+	sum := sha1.Sum([]byte(key))
+
+	return hex.EncodeToString(sum[:]) + ".thumb"
+}
@@ -0,0 +1,128 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 200, 255})
+		}
+	}
+
+	return img
+}
+
+func TestThumbnail(t *testing.T) {
+	src := testImage(400, 200)
+
+	encoders := map[string]func(*bytes.Buffer) error{
+		"image/png":  func(b *bytes.Buffer) error { return png.Encode(b, src) },
+		"image/jpeg": func(b *bytes.Buffer) error { return jpeg.Encode(b, src, nil) },
+		"image/gif":  func(b *bytes.Buffer) error { return gif.Encode(b, src, nil) },
+	}
+
+	for format, encode := range encoders {
+		var in bytes.Buffer
+
+		if err := encode(&in); err != nil {
+			t.Fatal(err)
+		}
+
+		thumb, mimeType, err := Thumbnail(&in, 100)
+		if err != nil {
+			t.Errorf("%v: %v", format, err)
+			continue
+		}
+
+		expectedType := "image/png"
+		if format == "image/jpeg" {
+			expectedType = "image/jpeg"
+		}
+
+		if mimeType != expectedType {
+			t.Errorf("%v: expected %v thumbnail, got %v", format, expectedType, mimeType)
+		}
+
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb))
+		if err != nil {
+			t.Errorf("%v: %v", format, err)
+			continue
+		}
+
+		if cfg.Width != 100 || cfg.Height != 50 {
+			t.Errorf("%v: expected 100x50 thumbnail, got %vx%v", format, cfg.Width, cfg.Height)
+		}
+	}
+
+	if _, _, err := Thumbnail(bytes.NewReader([]byte("not an image")), 100); err == nil {
+		t.Errorf("Expected error for invalid image")
+	}
+
+	// Small images aren't scaled up
+	if img := scaleImage(testImage(10, 20), 64); img.Bounds().Dx() != 10 || img.Bounds().Dy() != 20 {
+		t.Errorf("Expected small image to keep its size, got %v", img.Bounds())
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	for in, expected := range map[int]int{0: 64, 64: 64, 65: 128, 300: 512, 5000: 1024} {
+		if s := thumbnailSize(in); s != expected {
+			t.Errorf("Expected size %v for %v, got %v", expected, in, s)
+		}
+	}
+}
+
+func TestThumbnailCaches(t *testing.T) {
+	mem := NewMemoryThumbnailCache(2)
+
+	mem.Put("a", []byte("1"))
+	mem.Put("b", []byte("2"))
+	mem.Get("a")
+	mem.Put("c", []byte("3"))
+
+	if _, ok := mem.Get("b"); ok {
+		t.Errorf("Expected least recently used entry to be evicted")
+	}
+
+	if thumb, ok := mem.Get("a"); !ok || string(thumb) != "1" {
+		t.Errorf("Expected recently used entry to be kept")
+	}
+
+	dir, err := ioutil.TempDir("", "gorods-thumbs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	disk, err := NewDirThumbnailCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := disk.Get("sha2:abc=:64"); ok {
+		t.Errorf("Expected empty cache to miss")
+	}
+
+	if err := disk.Put("sha2:abc=:64", []byte("thumb")); err != nil {
+		t.Fatal(err)
+	}
+
+	if thumb, ok := disk.Get("sha2:abc=:64"); !ok || string(thumb) != "thumb" {
+		t.Errorf("Expected cached thumbnail, got %q", thumb)
+	}
+}
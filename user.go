@@ -3,15 +3,10 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
-	"unsafe"
 )
 
 // User contains information relating to an iRODS user
@@ -217,26 +212,44 @@ func (usr *User) RefreshGroups() error {
 	return nil
 }
 
-// FindByName searches the slice for a user by name.
+// FindByName searches the slice for a user by name, name#zone selects a user of a federated zone.
 // If no match is found, a new user with that name is created and returned.
 // This was designed to resolve issues of casting resources for DataObjects and Collections, even though the cache was empty due to permissions.
 func (usrs Users) FindByName(name string, con *Connection) *User {
-	for _, usr := range usrs {
-		if usr.name == name {
-			return usr
-		}
-	}
+	name, zone := SplitPrincipal(name)
 
-	zne, err := con.LocalZone()
+	return usrs.FindByNameAndZone(name, zone, con)
+}
+
+// FindByNameAndZone searches the slice for a user by name and zone, an empty zone is the local zone.
+// If no match is found, a new user with that name and zone is created and returned.
+func (usrs Users) FindByNameAndZone(name string, zone string, con *Connection) *User {
+	zne, err := findZone(zone, con)
 	if err != nil {
 		return nil
 	}
 
+	return usrs.findByNameAndZone(name, zne, con)
+}
+
+// findByNameAndZone is FindByNameAndZone with the zone already resolved
+func (usrs Users) findByNameAndZone(name string, zne *Zone, con *Connection) *User {
+	for _, usr := range usrs {
+		if usr.name == name && (usr.zone == nil || usr.zone.Name() == zne.Name()) {
+			return usr
+		}
+	}
+
 	usr, _ := initUser(name, zne, con)
 
 	return usr
 }
 
+// Principal returns the user in name#zone form
+func (usr *User) Principal() string {
+	return Principal(usr)
+}
+
 // Remove deletes an item from the slice based on the index.
 func (usrs *Users) Remove(index int) {
 	*usrs = append((*usrs)[:index], (*usrs)[index+1:]...)
@@ -250,108 +263,20 @@ func (usr *User) String() string {
 
 // FetchGroups fetches and returns fresh data about the user's groups from the iCAT server.
 func (usr *User) FetchGroups() (Groups, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	cName := C.CString(usr.name)
-	defer C.free(unsafe.Pointer(cName))
-
-	result.size = C.int(0)
-
-	ccon := usr.con.GetCcon()
-
-	if status := C.gorods_get_user_groups(ccon, cName, &result, &err); status != 0 {
-		usr.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Groups Failed: %v", C.GoString(err)))
-	}
-
-	usr.con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	if grps, err := usr.con.Groups(); err == nil {
-		response := make(Groups, 0)
-
-		for _, groupName := range slice {
-
-			gName := C.GoString(groupName)
-
-			if gName != usr.name {
-				grp := grps.FindByName(gName, usr.con)
-
-				if grp != nil {
-					response = append(response, grp)
-				} else {
-					return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchGroups Failed: Group in response not found in cache"))
-				}
-			}
-		}
-
-		return response, nil
-	} else {
-		return nil, err
+	if usr.con.fs != nil {
+		return usr.fsFetchGroups()
 	}
 
+	return usr.cFetchGroups()
 }
 
 // FetchInfo fetches fresh user info from the iCAT server, and returns it as a map.
 func (usr *User) FetchInfo() (map[string]string, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
-
-	result.size = C.int(0)
-
-	cUser := C.CString(usr.name)
-	defer C.free(unsafe.Pointer(cUser))
-
-	ccon := usr.con.GetCcon()
-
-	if status := C.gorods_get_user(cUser, ccon, &result, &err); status != 0 {
-		usr.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Users Failed: %v", C.GoString(err)))
-	}
-
-	usr.con.ReturnCcon(ccon)
-
-	defer C.gorods_free_string_result(&result)
-
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
-
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
-
-	//response := make(Users, 0)
-	response := make(map[string]string)
-
-	for _, userInfo := range slice {
-
-		userAttributes := strings.Split(strings.Trim(C.GoString(userInfo), " \n"), "\n")
-
-		for _, attr := range userAttributes {
-
-			split := strings.Split(attr, ": ")
-
-			attrName := split[0]
-			attrVal := split[1]
-
-			response[attrName] = attrVal
-
-		}
-
+	if usr.con.fs != nil {
+		return usr.con.fsPrincipalInfo(usr.name, usr.zone)
 	}
 
-	return response, nil
+	return usr.cFetchInfo()
 }
 
 // AddToGroup adds the user to an existing iRODS group.
@@ -386,25 +311,11 @@ func (usr *User) RemoveFromGroup(grp interface{}) error {
 // ChangePassword changes the user's password.
 // You will need to be a rodsadmin for this to succeed (I think).
 func (usr *User) ChangePassword(newPass string) error {
-	var (
-		err *C.char
-	)
-
-	cUserName := C.CString(usr.Name())
-	cNewPass := C.CString(newPass)
-	cMyPass := C.CString(usr.Con().Options.Password)
-	defer C.free(unsafe.Pointer(cUserName))
-	defer C.free(unsafe.Pointer(cNewPass))
-	defer C.free(unsafe.Pointer(cMyPass))
-
-	ccon := usr.Con().GetCcon()
-	defer usr.Con().ReturnCcon(ccon)
-
-	if status := C.gorods_change_user_password(cUserName, cNewPass, cMyPass, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS ChangePassword Failed: %v", C.GoString(err)))
+	if usr.con.fs != nil {
+		return unsupported("ChangePassword")
 	}
 
-	return nil
+	return usr.cChangePassword(newPass)
 }
 
 // Attribute gets slice of Meta AVU triples, matching by Attribute name for User
@@ -453,54 +364,17 @@ func (usr *User) Meta() (*MetaCollection, error) {
 }
 
 func deleteUser(userName string, zone *Zone, con *Connection) error {
-	var (
-		err *C.char
-	)
-
-	cZoneName := C.CString(zone.Name())
-	cUserName := C.CString(userName)
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cUserName))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_delete_user(cUserName, cZoneName, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS DeleteUser %v Failed: %v", userName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("DeleteUser")
 	}
 
-	return nil
+	return cDeleteUser(userName, zone, con)
 }
 
 func createUser(userName string, zoneName string, typ int, con *Connection) error {
-	var (
-		err   *C.char
-		cType *C.char
-	)
-
-	switch typ {
-	case AdminType:
-		cType = C.CString("rodsadmin")
-	case UserType:
-		cType = C.CString("rodsuser")
-	case GroupAdminType:
-		cType = C.CString("groupadmin")
-	default:
-		return newError(Fatal, -1, fmt.Sprintf("iRODS CreateUser Failed: Unknown user type passed"))
-	}
-
-	cZoneName := C.CString(zoneName)
-	cUserName := C.CString(userName)
-	defer C.free(unsafe.Pointer(cZoneName))
-	defer C.free(unsafe.Pointer(cUserName))
-	defer C.free(unsafe.Pointer(cType))
-
-	ccon := con.GetCcon()
-	defer con.ReturnCcon(ccon)
-
-	if status := C.gorods_create_user(cUserName, cZoneName, cType, ccon, &err); status != 0 {
-		return newError(Fatal, status, fmt.Sprintf("iRODS CreateUser %v Failed: %v", userName, C.GoString(err)))
+	if con.fs != nil {
+		return unsupported("CreateUser")
 	}
 
-	return nil
+	return cCreateUser(userName, zoneName, typ, con)
 }
@@ -0,0 +1,202 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+func (usr *User) cFetchGroups() (Groups, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	cName := C.CString(usr.name)
+	defer C.free(unsafe.Pointer(cName))
+
+	result.size = C.int(0)
+
+	ccon := usr.con.GetCcon()
+
+	if status := C.gorods_get_user_groups(ccon, cName, &result, &err); status != 0 {
+		usr.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Groups Failed: %v", C.GoString(err)))
+	}
+
+	usr.con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	if grps, err := usr.con.Groups(); err == nil {
+		response := make(Groups, 0)
+
+		for _, groupName := range slice {
+
+			gName := C.GoString(groupName)
+
+			if gName != usr.name {
+				grp := grps.FindByName(gName, usr.con)
+
+				if grp != nil {
+					response = append(response, grp)
+				} else {
+					return nil, newError(Fatal, -1, fmt.Sprintf("iRODS FetchGroups Failed: Group in response not found in cache"))
+				}
+			}
+		}
+
+		return response, nil
+	} else {
+		return nil, err
+	}
+
+}
+
+func (usr *User) cFetchInfo() (map[string]string, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	var zoneName string
+	if usr.zone != nil {
+		zoneName = usr.zone.Name()
+	}
+
+	cUser := C.CString(usr.name)
+	cZone := C.CString(zoneName)
+	defer C.free(unsafe.Pointer(cUser))
+	defer C.free(unsafe.Pointer(cZone))
+
+	ccon := usr.con.GetCcon()
+
+	if status := C.gorods_get_user(cUser, cZone, ccon, &result, &err); status != 0 {
+		usr.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Users Failed: %v", C.GoString(err)))
+	}
+
+	usr.con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	//response := make(Users, 0)
+	response := make(map[string]string)
+
+	for _, userInfo := range slice {
+
+		userAttributes := strings.Split(strings.Trim(C.GoString(userInfo), " \n"), "\n")
+
+		for _, attr := range userAttributes {
+
+			split := strings.Split(attr, ": ")
+
+			attrName := split[0]
+			attrVal := split[1]
+
+			response[attrName] = attrVal
+
+		}
+
+	}
+
+	return response, nil
+}
+
+func (usr *User) cChangePassword(newPass string) error {
+	var (
+		err *C.char
+	)
+
+	cUserName := C.CString(usr.Name())
+	cNewPass := C.CString(newPass)
+	cMyPass := C.CString(usr.Con().Options.Password)
+	defer C.free(unsafe.Pointer(cUserName))
+	defer C.free(unsafe.Pointer(cNewPass))
+	defer C.free(unsafe.Pointer(cMyPass))
+
+	ccon := usr.Con().GetCcon()
+	defer usr.Con().ReturnCcon(ccon)
+
+	if status := C.gorods_change_user_password(cUserName, cNewPass, cMyPass, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS ChangePassword Failed: %v", C.GoString(err)))
+	}
+
+	return nil
+}
+
+func cDeleteUser(userName string, zone *Zone, con *Connection) error {
+	var (
+		err *C.char
+	)
+
+	cZoneName := C.CString(zone.Name())
+	cUserName := C.CString(userName)
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cUserName))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_delete_user(cUserName, cZoneName, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS DeleteUser %v Failed: %v", userName, C.GoString(err)))
+	}
+
+	return nil
+}
+
+func cCreateUser(userName string, zoneName string, typ int, con *Connection) error {
+	var (
+		err   *C.char
+		cType *C.char
+	)
+
+	switch typ {
+	case AdminType:
+		cType = C.CString("rodsadmin")
+	case UserType:
+		cType = C.CString("rodsuser")
+	case GroupAdminType:
+		cType = C.CString("groupadmin")
+	default:
+		return newError(Fatal, -1, fmt.Sprintf("iRODS CreateUser Failed: Unknown user type passed"))
+	}
+
+	cZoneName := C.CString(zoneName)
+	cUserName := C.CString(userName)
+	defer C.free(unsafe.Pointer(cZoneName))
+	defer C.free(unsafe.Pointer(cUserName))
+	defer C.free(unsafe.Pointer(cType))
+
+	ccon := con.GetCcon()
+	defer con.ReturnCcon(ccon)
+
+	if status := C.gorods_create_user(cUserName, cZoneName, cType, ccon, &err); status != 0 {
+		return newError(Fatal, status, fmt.Sprintf("iRODS CreateUser %v Failed: %v", userName, C.GoString(err)))
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build !cgo || gorods_native
+// +build !cgo gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+func (usr *User) cFetchGroups() (Groups, error) {
+	return nil, errNoC
+}
+
+func (usr *User) cFetchInfo() (map[string]string, error) {
+	return nil, errNoC
+}
+
+func (usr *User) cChangePassword(newPass string) error {
+	return errNoC
+}
+
+func cDeleteUser(userName string, zone *Zone, con *Connection) error {
+	return errNoC
+}
+
+func cCreateUser(userName string, zoneName string, typ int, con *Connection) error {
+	return errNoC
+}
@@ -1,3 +1,6 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
 /*** Copyright (c) 2016, University of Florida Research Foundation, Inc. and The BioTeam, Inc.  ***
  *** For more information please refer to the LICENSE.md file                                   ***/
 
@@ -250,7 +253,7 @@ int gorods_iuserinfo(rcComm_t *myConn, char *name, userInfo_t* outInfo, char** e
 }
 
 
-int gorods_phys_path_reg(rcComm_t* ccon, char* physPath, char* rodsPath, int force, int collection, int replica, char* resourceName, char* excludeFiles) {
+int gorods_phys_path_reg(rcComm_t* ccon, char* physPath, char* rodsPath, int force, int collection, int replica, char* resourceName, char* excludeFiles, int regChksum, char* verifyChksum) {
 
     dataObjInp_t dataObjOprInp;
     memset(&dataObjOprInp, 0, sizeof(dataObjInp_t));
@@ -277,6 +280,16 @@ int gorods_phys_path_reg(rcComm_t* ccon, char* physPath, char* rodsPath, int for
         addKeyVal(&dataObjOprInp.condInput, DEST_RESC_NAME_KW, resourceName);
     }
 
+    // ireg -k
+    if ( regChksum > 0 ) {
+        addKeyVal(&dataObjOprInp.condInput, REG_CHKSUM_KW, "");
+    }
+
+    // ireg -K, the server verifies the file against the checksum and registers it with its scheme
+    if ( verifyChksum != NULL && verifyChksum[0] != '\0' ) {
+        addKeyVal(&dataObjOprInp.condInput, VERIFY_CHKSUM_KW, verifyChksum);
+    }
+
     addKeyVal(&dataObjOprInp.condInput, FILE_PATH_KW, physPath);
     rstrcpy(dataObjOprInp.objPath, rodsPath, MAX_NAME_LEN);
 
@@ -284,6 +297,122 @@ int gorods_phys_path_reg(rcComm_t* ccon, char* physPath, char* rodsPath, int for
     return rcPhyPathReg(ccon, &dataObjOprInp);
 }
 
+// ibun -c, creates the structured file objPath holding the data objects of collection
+int gorods_bundle(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char* dataType, int force, char** err) {
+
+    structFileExtAndRegInp_t structFileExtAndRegInp;
+    memset(&structFileExtAndRegInp, 0, sizeof(structFileExtAndRegInp_t));
+
+    rstrcpy(structFileExtAndRegInp.objPath, objPath, MAX_NAME_LEN);
+    rstrcpy(structFileExtAndRegInp.collection, collection, MAX_NAME_LEN);
+
+    if ( dataType != NULL && dataType[0] != '\0' ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, DATA_TYPE_KW, dataType);
+    }
+
+    if ( resourceName != NULL && resourceName[0] != '\0' ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, DEST_RESC_NAME_KW, resourceName);
+    }
+
+    if ( force > 0 ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, FORCE_FLAG_KW, "");
+    }
+
+    int status = rcStructFileBundle(ccon, &structFileExtAndRegInp);
+    clearKeyVal(&structFileExtAndRegInp.condInput);
+
+    if ( status < 0 ) {
+        *err = "rcStructFileBundle failed";
+        return status;
+    }
+
+    return 0;
+}
+
+// ibun -x, extracts the structured file objPath and registers its files in collection
+int gorods_extract_bundle(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char* dataType, int force, int bulk, char** err) {
+
+    structFileExtAndRegInp_t structFileExtAndRegInp;
+    memset(&structFileExtAndRegInp, 0, sizeof(structFileExtAndRegInp_t));
+
+    rstrcpy(structFileExtAndRegInp.objPath, objPath, MAX_NAME_LEN);
+    rstrcpy(structFileExtAndRegInp.collection, collection, MAX_NAME_LEN);
+
+    if ( dataType != NULL && dataType[0] != '\0' ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, DATA_TYPE_KW, dataType);
+    }
+
+    if ( resourceName != NULL && resourceName[0] != '\0' ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, DEST_RESC_NAME_KW, resourceName);
+    }
+
+    if ( force > 0 ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, FORCE_FLAG_KW, "");
+    }
+
+    // ibun -b, registers the files in bulk
+    if ( bulk > 0 ) {
+        addKeyVal(&structFileExtAndRegInp.condInput, BULK_OPR_KW, "");
+    }
+
+    int status = rcStructFileExtAndReg(ccon, &structFileExtAndRegInp);
+    clearKeyVal(&structFileExtAndRegInp.condInput);
+
+    if ( status < 0 ) {
+        *err = "rcStructFileExtAndReg failed";
+        return status;
+    }
+
+    return 0;
+}
+
+// imcoll -m tar, mounts the tar file objPath as collection
+int gorods_mount_collection(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char** err) {
+
+    dataObjInp_t dataObjOprInp;
+    memset(&dataObjOprInp, 0, sizeof(dataObjInp_t));
+
+    rstrcpy(dataObjOprInp.objPath, collection, MAX_NAME_LEN);
+
+    addKeyVal(&dataObjOprInp.condInput, COLLECTION_TYPE_KW, TAR_STRUCT_FILE_STR);
+    addKeyVal(&dataObjOprInp.condInput, FILE_PATH_KW, objPath);
+
+    if ( resourceName != NULL && resourceName[0] != '\0' ) {
+        addKeyVal(&dataObjOprInp.condInput, DEST_RESC_NAME_KW, resourceName);
+    }
+
+    int status = rcPhyPathReg(ccon, &dataObjOprInp);
+    clearKeyVal(&dataObjOprInp.condInput);
+
+    if ( status < 0 ) {
+        *err = "rcPhyPathReg failed";
+        return status;
+    }
+
+    return 0;
+}
+
+// imcoll -U, unmounts collection
+int gorods_unmount_collection(rcComm_t* ccon, char* collection, char** err) {
+
+    dataObjInp_t dataObjOprInp;
+    memset(&dataObjOprInp, 0, sizeof(dataObjInp_t));
+
+    rstrcpy(dataObjOprInp.objPath, collection, MAX_NAME_LEN);
+
+    addKeyVal(&dataObjOprInp.condInput, COLLECTION_TYPE_KW, UNMOUNT_STR);
+
+    int status = rcPhyPathReg(ccon, &dataObjOprInp);
+    clearKeyVal(&dataObjOprInp.condInput);
+
+    if ( status < 0 ) {
+        *err = "rcPhyPathReg failed";
+        return status;
+    }
+
+    return 0;
+}
+
 
 int gorods_open_collection(char* path, int trimRepls, collHandle_t* collHandle, rcComm_t* conn, char** err) {
 
@@ -308,7 +437,7 @@ int gorods_open_collection(char* path, int trimRepls, collHandle_t* collHandle,
 }
 
 
-int gorods_put_dataobject(char* inPath, char* outPath, rodsLong_t size, int mode, int force, char* resource, rcComm_t* conn, char** err) {
+int gorods_put_dataobject(char* inPath, char* outPath, rodsLong_t size, int mode, int force, char* resource, char* chksum, rcComm_t* conn, char** err) {
     
     int status;
     dataObjInp_t dataObjInp;
@@ -330,6 +459,11 @@ int gorods_put_dataobject(char* inPath, char* outPath, rodsLong_t size, int mode
         addKeyVal(&dataObjInp.condInput, FORCE_FLAG_KW, ""); 
     }
 
+    // iput -K, the server verifies the upload against the checksum and registers it with its scheme
+    if ( chksum != NULL && chksum[0] != '\0' ) {
+        addKeyVal(&dataObjInp.condInput, VERIFY_CHKSUM_KW, chksum); 
+    }
+
     status = rcDataObjPut(conn, &dataObjInp, locFilePath); 
     if ( status < 0 ) { 
         *err = "rcDataObjPut failed";
@@ -435,6 +569,29 @@ int gorods_open_dataobject(char* path, char* resourceName, char* replNum, int op
 	return 0;
 }
 
+int gorods_open_replica(char* path, char* replNum, int openFlag, int* handle, rcComm_t* conn, char** err) {
+	dataObjInp_t dataObjInp; 
+	
+	bzero(&dataObjInp, sizeof(dataObjInp)); 
+	rstrcpy(dataObjInp.objPath, path, MAX_NAME_LEN); 
+	
+	dataObjInp.openFlags = openFlag; 
+	dataObjInp.numThreads = conn->transStat.numThreads;
+
+    // The replica is selected by number only, its resource may be part of a hierarchy
+    addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum);
+
+	int thehandle = rcDataObjOpen(conn, &dataObjInp); 
+	if ( thehandle < 0 ) { 
+		*err = "rcDataObjOpen failed";
+		return thehandle;
+	}
+
+    *handle = thehandle;
+
+	return 0;
+}
+
 int gorods_close_dataobject(int handleInx, rcComm_t* conn, char** err) {
 	openedDataObjInp_t openedDataObjInp; 
 	
@@ -554,16 +711,23 @@ int gorods_get_resource(char* rescName, rcComm_t* conn, goRodsStringResult_t* re
 }
 
 
-int gorods_get_user(char *user, rcComm_t* conn, goRodsStringResult_t* result, char** err) {
+int gorods_get_user(char *user, char *zone, rcComm_t* conn, goRodsStringResult_t* result, char** err) {
     simpleQueryInp_t simpleQueryInp;
 
     memset(&simpleQueryInp, 0, sizeof(simpleQueryInp_t));
     simpleQueryInp.control = 0;
     
     simpleQueryInp.form = 2;
-    simpleQueryInp.sql = "select * from R_USER_MAIN where user_name=?";
     simpleQueryInp.arg1 = user;
     simpleQueryInp.maxBufSize = 1024;
+
+    // Users of federated zones can share the name of a local user
+    if ( zone != NULL && strlen(zone) > 0 ) {
+        simpleQueryInp.sql = "select * from R_USER_MAIN where user_name=? and zone_name=?";
+        simpleQueryInp.arg2 = zone;
+    } else {
+        simpleQueryInp.sql = "select * from R_USER_MAIN where user_name=?";
+    }
     
     return gorods_simple_query(simpleQueryInp, result, conn, err);
 }
@@ -1577,6 +1741,78 @@ int gorods_iquest_general(rcComm_t *conn, char *selectConditionString, int noDis
 
 }
 
+int gorods_iquest_page(rcComm_t *conn, char *selectConditionString, int offset, int limit, char *zoneName, goRodsHashResult_t* result, int* total, char** err) {
+    /*
+      Fetches limit rows starting at row offset, and the number of rows matched by the query.
+     */
+    int i;
+    int cont;
+    genQueryInp_t genQueryInp;
+    genQueryOut_t *genQueryOut = NULL;
+
+    memset(&genQueryInp, 0, sizeof(genQueryInp_t));
+
+    i = fillGenQueryInpFromStrCond(selectConditionString, &genQueryInp);
+    if ( i < 0 ) {
+        return i;
+    }
+
+    genQueryInp.options = RETURN_TOTAL_ROW_COUNT;
+
+    if ( zoneName != 0 && zoneName[0] != '\0' ) {
+        addKeyVal(&genQueryInp.condInput, ZONE_KW, zoneName);
+    }
+
+    genQueryInp.rowOffset = offset;
+    genQueryInp.maxRows = limit < MAX_SQL_ROWS ? limit : MAX_SQL_ROWS;
+    genQueryInp.continueInx = 0;
+
+    i = rcGenQuery(conn, &genQueryInp, &genQueryOut);
+    if ( i < 0 ) {
+        freeGenQueryOut(&genQueryOut);
+        return i;
+    }
+
+    *total = genQueryOut->totalRowCount;
+
+    for ( ;; ) {
+        cont = genQueryOut->continueInx;
+
+        i = gorods_build_iquest_result(genQueryOut, result, err);
+        freeGenQueryOut(&genQueryOut);
+
+        if ( i < 0 ) {
+            return i;
+        }
+
+        if ( cont <= 0 ) {
+            return 0;
+        }
+
+        if ( result->size >= limit ) {
+            break;
+        }
+
+        genQueryInp.continueInx = cont;
+        genQueryInp.maxRows = limit - result->size < MAX_SQL_ROWS ? limit - result->size : MAX_SQL_ROWS;
+
+        i = rcGenQuery(conn, &genQueryInp, &genQueryOut);
+        if ( i < 0 ) {
+            freeGenQueryOut(&genQueryOut);
+            return i;
+        }
+    }
+
+    // Close the statement, the rows after the page aren't fetched
+    genQueryInp.continueInx = cont;
+    genQueryInp.maxRows = 0;
+
+    rcGenQuery(conn, &genQueryInp, &genQueryOut);
+    freeGenQueryOut(&genQueryOut);
+
+    return 0;
+}
+
 // typedef struct {
 // 	int rowSize;
 // 	int attrSize;
@@ -1763,6 +1999,38 @@ int gorods_trimrepls_dataobject(rcComm_t *conn, char* objPath, char* ageStr, cha
 }
 
 
+int gorods_trim_replica(rcComm_t *conn, char* objPath, char* replNum, char** err) {
+
+    int status;
+    dataObjInp_t dataObjInp; 
+    bzero(&dataObjInp, sizeof(dataObjInp));
+
+    rstrcpy(dataObjInp.objPath, objPath, MAX_NAME_LEN); 
+
+    // itrim -N 1 -n replNum
+    addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum);
+    addKeyVal(&dataObjInp.condInput, COPIES_KW, "1");
+
+    dataObjInp.numThreads = conn->transStat.numThreads;
+    
+    status = rcDataObjTrim(conn, &dataObjInp);
+
+    if ( status < 0 ) { 
+        *err = "rcDataObjTrim failed";
+        return status;
+    }
+
+    // rcDataObjTrim returns the number of trimmed replicas
+    if ( status == 0 ) {
+        *err = "no replica trimmed, it may be the last good one";
+        return SYS_INVALID_INPUT_PARAM;
+    }
+
+    return 0;
+
+}
+
+
 int gorods_phymv_dataobject(rcComm_t *conn, char* objPath, char* sourceResource, char* destResource, char** err) {
 
     int status;
@@ -1786,7 +2054,7 @@ int gorods_phymv_dataobject(rcComm_t *conn, char* objPath, char* sourceResource,
 
 }
 
-int gorods_repl_dataobject(rcComm_t *conn, char* objPath, char* resourceName, int backupMode, int createMode, rodsLong_t dataSize, char** err) {
+int gorods_repl_dataobject(rcComm_t *conn, char* objPath, char* resourceName, int backupMode, int createMode, rodsLong_t dataSize, int verifyChksum, char** err) {
     
     int status;
     dataObjInp_t dataObjInp; 
@@ -1803,6 +2071,11 @@ int gorods_repl_dataobject(rcComm_t *conn, char* objPath, char* resourceName, in
         addKeyVal(&dataObjInp.condInput, DEST_RESC_NAME_KW, resourceName);
     }
 
+    // irepl -K, the server verifies the new replica against the checksum of the source, in its scheme
+    if ( verifyChksum > 0 ) {
+        addKeyVal(&dataObjInp.condInput, VERIFY_CHKSUM_KW, ""); 
+    }
+
     status = rcDataObjRepl(conn, &dataObjInp); 
     if ( status < 0 ) { 
         *err = "rcDataObjRepl failed";
@@ -2201,6 +2474,29 @@ int gorods_unlink_dataobject(char* path, int force, rcComm_t* conn, char** err)
 	return 0;
 }
 
+int gorods_unreg_dataobject(char* path, char* replNum, rcComm_t* conn, char** err) {
+	dataObjInp_t dataObjInp; 
+	bzero(&dataObjInp, sizeof(dataObjInp));
+
+	rstrcpy(dataObjInp.objPath, path, MAX_NAME_LEN); 
+
+	// irm -U, the catalog entries are removed and the files left in place
+	dataObjInp.oprType = UNREG_OPR;
+	addKeyVal(&dataObjInp.condInput, FORCE_FLAG_KW, ""); 
+
+	if ( replNum != NULL && replNum[0] != '\0' ) {
+		addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum); 
+	}
+
+	int status = rcDataObjUnlink(conn, &dataObjInp); 
+	if ( status < 0 ) { 
+		*err = "rcDataObjUnlink failed";
+		return status;
+	}
+
+	return 0;
+}
+
 int gorods_checksum_dataobject(char* path, char** outChksum, rcComm_t* conn, char** err) {
 
 	dataObjInp_t dataObjInp; 
@@ -2221,6 +2517,97 @@ int gorods_checksum_dataobject(char* path, char** outChksum, rcComm_t* conn, cha
 	return 0;
 }
 
+int gorods_checksum_replica(char* path, char* replNum, char** outChksum, rcComm_t* conn, char** err) {
+
+	dataObjInp_t dataObjInp; 
+
+	bzero(&dataObjInp, sizeof(dataObjInp)); 
+	rstrcpy(dataObjInp.objPath, path, MAX_NAME_LEN); 
+
+	addKeyVal(&dataObjInp.condInput, FORCE_CHKSUM_KW, ""); 
+	addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum); 
+
+    dataObjInp.numThreads = conn->transStat.numThreads;
+
+	int status = rcDataObjChksum(conn, &dataObjInp, outChksum); 
+	if ( status < 0 ) { 
+		*err = "rcDataObjChksum failed";
+		return status;
+	}
+
+	return 0;
+}
+
+int gorods_verify_replica(char* path, char* replNum, int verifyChksum, rodsLong_t* size, int* mismatch, rcComm_t* conn, char** err) {
+
+	dataObjInp_t dataObjInp; 
+	openedDataObjInp_t openedDataObjInp;
+	fileLseekOut_t *lseekOut = NULL;
+	char *chksumOut = NULL;
+
+	*mismatch = 0;
+
+	// The size of the data on the resource is found by seeking to its end
+	bzero(&dataObjInp, sizeof(dataObjInp)); 
+	rstrcpy(dataObjInp.objPath, path, MAX_NAME_LEN); 
+	dataObjInp.openFlags = O_RDONLY;
+	addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum);
+
+	int handle = rcDataObjOpen(conn, &dataObjInp); 
+	if ( handle < 0 ) { 
+		*err = "rcDataObjOpen failed";
+		return handle;
+	}
+
+	bzero(&openedDataObjInp, sizeof(openedDataObjInp)); 
+	openedDataObjInp.l1descInx = handle;
+	openedDataObjInp.offset = 0;
+	openedDataObjInp.whence = SEEK_END;
+
+	int status = rcDataObjLseek(conn, &openedDataObjInp, &lseekOut); 
+	if ( status < 0 ) { 
+		*err = "rcDataObjLseek failed";
+		rcDataObjClose(conn, &openedDataObjInp);
+		return status;
+	}
+
+	*size = lseekOut->offset;
+	free(lseekOut);
+
+	bzero(&openedDataObjInp, sizeof(openedDataObjInp)); 
+	openedDataObjInp.l1descInx = handle;
+	rcDataObjClose(conn, &openedDataObjInp);
+
+	if ( !verifyChksum ) {
+		return 0;
+	}
+
+	// ichksum -K -n replNum, the catalog isn't changed
+	bzero(&dataObjInp, sizeof(dataObjInp)); 
+	rstrcpy(dataObjInp.objPath, path, MAX_NAME_LEN); 
+	addKeyVal(&dataObjInp.condInput, VERIFY_CHKSUM_KW, ""); 
+	addKeyVal(&dataObjInp.condInput, REPL_NUM_KW, replNum); 
+
+	dataObjInp.numThreads = conn->transStat.numThreads;
+
+	status = rcDataObjChksum(conn, &dataObjInp, &chksumOut); 
+	if ( chksumOut != NULL ) {
+		free(chksumOut);
+	}
+
+	if ( status == USER_CHKSUM_MISMATCH ) {
+		*mismatch = 1;
+		return 0;
+	}
+
+	if ( status < 0 ) { 
+		*err = "rcDataObjChksum failed";
+		return status;
+	}
+
+	return 0;
+}
+
 
 const char NON_ROOT_COLL_CHECK_STR[] = "<>'/'";
 
@@ -13,6 +13,8 @@
 #include "dataObjRead.h"
 #include "dataObjChksum.h"
 #include "dataObjClose.h"
+#include "structFileExtAndReg.h"
+#include "structFileBundle.h"
 #include "lsUtil.h"
 #include <malloc.h>
 
@@ -83,7 +85,12 @@ int gorods_queryDataObjInColl( queryHandle_t *queryHandle, char *collection,
 int gorods_getNextCollMetaInfo( collHandle_t *collHandle, collEnt_t *outCollEnt );
 int gorods_getNextDataObjMetaInfo( collHandle_t *collHandle, collEnt_t *outCollEnt );
 
-int gorods_phys_path_reg(rcComm_t*, char*, char*, int, int, int, char*, char*);
+int gorods_phys_path_reg(rcComm_t*, char*, char*, int, int, int, char*, char*, int, char*);
+int gorods_unreg_dataobject(char* path, char* replNum, rcComm_t* conn, char** err);
+int gorods_bundle(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char* dataType, int force, char** err);
+int gorods_extract_bundle(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char* dataType, int force, int bulk, char** err);
+int gorods_mount_collection(rcComm_t* ccon, char* objPath, char* collection, char* resourceName, char** err);
+int gorods_unmount_collection(rcComm_t* ccon, char* collection, char** err);
 
 void display_mallinfo(void);
 void* gorods_malloc(size_t size);
@@ -101,12 +108,13 @@ int gorods_get_group(rcComm_t *conn, goRodsStringResult_t* result, char* groupNa
 
 int gorods_build_iquest_result(genQueryOut_t * genQueryOut, goRodsHashResult_t* result, char** err);
 int gorods_iquest_general(rcComm_t *conn, char *selectConditionString, int noDistinctFlag, int upperCaseFlag, char *zoneName, goRodsHashResult_t* result, char** err);
+int gorods_iquest_page(rcComm_t *conn, char *selectConditionString, int offset, int limit, char *zoneName, goRodsHashResult_t* result, int* total, char** err);
 void gorods_free_map_result(goRodsHashResult_t* result);
 int gorods_exec_specific_query(rcComm_t*, char*, char *args[], int, char*, goRodsGenQueryResult_t*, char**);
 void gorods_free_gen_query_result(goRodsGenQueryResult_t* result);
 
 int gorods_get_users(rcComm_t* conn, goRodsStringResult_t* result, char** err);
-int gorods_get_user(char *user, rcComm_t* conn, goRodsStringResult_t* result, char** err);
+int gorods_get_user(char *user, char *zone, rcComm_t* conn, goRodsStringResult_t* result, char** err);
 int gorods_change_user_password(char* userName, char* newPassword, char* myPassword, rcComm_t *conn, char** err);
 
 int gorods_get_resources(rcComm_t* conn, goRodsStringResult_t* result, char** err);
@@ -152,10 +160,12 @@ int gorods_get_dataobject_data(rcComm_t *conn, rodsArguments_t *rodsArgs, genQue
 
 
 int gorods_trimrepls_dataobject(rcComm_t *conn, char* objPath, char* ageStr, char* resource, char* keepCopiesStr, char** err);
+int gorods_trim_replica(rcComm_t *conn, char* objPath, char* replNum, char** err);
 int gorods_phymv_dataobject(rcComm_t *conn, char* objPath, char* sourceResource, char* destResource, char** err);
-int gorods_repl_dataobject(rcComm_t *conn, char* objPath, char* resourceName, int backupMode, int createMode, rodsLong_t dataSize, char** err);
-int gorods_put_dataobject(char* inPath, char* outPath, rodsLong_t size, int mode, int force, char* resource, rcComm_t* conn, char** err);
+int gorods_repl_dataobject(rcComm_t *conn, char* objPath, char* resourceName, int backupMode, int createMode, rodsLong_t dataSize, int verifyChksum, char** err);
+int gorods_put_dataobject(char* inPath, char* outPath, rodsLong_t size, int mode, int force, char* resource, char* chksum, rcComm_t* conn, char** err);
 int gorods_open_dataobject(char* path, char* resourceName, char* replNum, int openFlag, int* handle, rcComm_t* conn, char** err);
+int gorods_open_replica(char* path, char* replNum, int openFlag, int* handle, rcComm_t* conn, char** err);
 int gorods_read_dataobject(int handleInx, rodsLong_t length, bytesBuf_t* buffer, int* bytesRead, rcComm_t* conn, char** err);
 int gorods_lseek_dataobject(int handleInx, rodsLong_t offset, rcComm_t* conn, char** err);
 int gorods_close_dataobject(int handleInx, rcComm_t* conn, char** err);
@@ -166,6 +176,8 @@ int gorods_copy_dataobject(char* source, char* destination, int force, char* res
 int gorods_move_dataobject(char* source, char* destination, int objType, rcComm_t* conn, char** err);
 int gorods_unlink_dataobject(char* path, int force, rcComm_t* conn, char** err);
 int gorods_checksum_dataobject(char* path, char** outChksum, rcComm_t* conn, char** err);
+int gorods_checksum_replica(char* path, char* replNum, char** outChksum, rcComm_t* conn, char** err);
+int gorods_verify_replica(char* path, char* replNum, int verifyChksum, rodsLong_t* size, int* mismatch, rcComm_t* conn, char** err);
 int gorods_rm(char* path, int isCollection, int recursive, int force, int trash, rcComm_t* conn, char** err);
 int gorods_get_dataobject_acl(rcComm_t* conn, char* dataId, goRodsACLResult_t* result, char* zoneHint, char** err);
 void gorods_free_acl_result(goRodsACLResult_t* result);
@@ -3,15 +3,11 @@
 
 package gorods
 
-// #include "wrapper.h"
-import "C"
-
 import (
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
-	"unsafe"
 )
 
 // Zone contains information representing an iRODS zone.
@@ -177,50 +173,92 @@ func (zne *Zone) RefreshInfo() error {
 
 // FetchInfo returns a map of fresh zone info from the iCAT server.
 func (zne *Zone) FetchInfo() (map[string]string, error) {
-	var (
-		result C.goRodsStringResult_t
-		err    *C.char
-	)
+	if zne.con.fs != nil {
+		return zne.fsFetchInfo()
+	}
 
-	result.size = C.int(0)
+	return zne.cFetchInfo()
+}
 
-	cZone := C.CString(zne.name)
-	defer C.free(unsafe.Pointer(cZone))
+// IsRemote loads data from iRODS if needed, and reports whether the zone is a federated (remote) zone.
+func (zne *Zone) IsRemote() (bool, error) {
+	typ, err := zne.Type()
+
+	return typ == Remote, err
+}
 
-	ccon := zne.con.GetCcon()
+// Endpoint loads data from iRODS if needed, and returns the host and port of the zone's conString (host:port).
+// The port defaults to 1247, and the host is empty for zones without a conString, such as the local zone.
+func (zne *Zone) Endpoint() (string, int, error) {
+	conString, err := zne.ConString()
+	if err != nil {
+		return "", 0, err
+	}
 
-	if status := C.gorods_get_zone(cZone, ccon, &result, &err); status != 0 {
-		zne.con.ReturnCcon(ccon)
-		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Zone Info Failed: %v", C.GoString(err)))
+	host, port, err := parseConString(conString)
+	if err != nil {
+		return "", 0, newError(Fatal, -1, fmt.Sprintf("iRODS Zone Endpoint Failed: %v", err))
 	}
 
-	zne.con.ReturnCcon(ccon)
+	return host, port, nil
+}
+
+// Remote returns the federated zones of the slice, loading their info from iRODS if needed.
+func (znes Zones) Remote() (Zones, error) {
+	remote := make(Zones, 0)
 
-	defer C.gorods_free_string_result(&result)
+	for _, zne := range znes {
+		isRemote, err := zne.IsRemote()
+		if err != nil {
+			return nil, err
+		}
 
-	unsafeArr := unsafe.Pointer(result.strArr)
-	arrLen := int(result.size)
+		if isRemote {
+			remote = append(remote, zne)
+		}
+	}
 
-	// Convert C array to slice, backed by arr *C.char
-	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+	return remote, nil
+}
 
-	response := make(map[string]string)
+// FederatedZones returns the remote zones of con.Zones(), whose ConString() and Endpoint() give the address of their iCAT server.
+// You must have the proper rodsadmin privileges to use this function.
+func (con *Connection) FederatedZones() (Zones, error) {
+	znes, err := con.Zones()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, zoneInfo := range slice {
+	return znes.Remote()
+}
 
-		zoneAttributes := strings.Split(strings.Trim(C.GoString(zoneInfo), " \n"), "\n")
+// findZone returns the zone named name from the cache of con, an empty name is the local zone
+func findZone(name string, con *Connection) (*Zone, error) {
+	if name == "" {
+		return con.LocalZone()
+	}
 
-		for _, attr := range zoneAttributes {
+	znes, _ := con.Zones()
 
-			split := strings.Split(attr, ": ")
+	return znes.FindByName(name, con), nil
+}
 
-			attrName := split[0]
-			attrVal := split[1]
+// parseConString splits a zone conString (host:port, or host) into its host and port
+func parseConString(conString string) (string, int, error) {
+	conString = strings.TrimSpace(conString)
+	if conString == "" {
+		return "", 0, nil
+	}
 
-			response[attrName] = attrVal
+	inx := strings.LastIndex(conString, ":")
+	if inx < 0 {
+		return conString, 1247, nil
+	}
 
-		}
+	port, err := strconv.Atoi(conString[inx+1:])
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in conString %q", conString)
 	}
 
-	return response, nil
+	return conString[:inx], port, nil
 }
@@ -0,0 +1,65 @@
+//go:build cgo && !gorods_native
+// +build cgo,!gorods_native
+
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+// #include "wrapper.h"
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+func (zne *Zone) cFetchInfo() (map[string]string, error) {
+	var (
+		result C.goRodsStringResult_t
+		err    *C.char
+	)
+
+	result.size = C.int(0)
+
+	cZone := C.CString(zne.name)
+	defer C.free(unsafe.Pointer(cZone))
+
+	ccon := zne.con.GetCcon()
+
+	if status := C.gorods_get_zone(cZone, ccon, &result, &err); status != 0 {
+		zne.con.ReturnCcon(ccon)
+		return nil, newError(Fatal, status, fmt.Sprintf("iRODS Get Zone Info Failed: %v", C.GoString(err)))
+	}
+
+	zne.con.ReturnCcon(ccon)
+
+	defer C.gorods_free_string_result(&result)
+
+	unsafeArr := unsafe.Pointer(result.strArr)
+	arrLen := int(result.size)
+
+	// Convert C array to slice, backed by arr *C.char
+	slice := (*[1 << 30]*C.char)(unsafeArr)[:arrLen:arrLen]
+
+	response := make(map[string]string)
+
+	for _, zoneInfo := range slice {
+
+		zoneAttributes := strings.Split(strings.Trim(C.GoString(zoneInfo), " \n"), "\n")
+
+		for _, attr := range zoneAttributes {
+
+			split := strings.Split(attr, ": ")
+
+			attrName := split[0]
+			attrVal := split[1]
+
+			response[attrName] = attrVal
+
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,34 @@
+/*** Copyright (c) 2016, The BioTeam, Inc.                     ***
+ *** For more information please refer to the LICENSE.md file  ***/
+
+package gorods
+
+import (
+	"testing"
+)
+
+func TestParseConString(t *testing.T) {
+
+	tests := []struct {
+		conString string
+		host      string
+		port      int
+		fails     bool
+	}{
+		{"", "", 0, false},
+		{"icat.example.org:1247", "icat.example.org", 1247, false},
+		{"icat.example.org:2247\n", "icat.example.org", 2247, false},
+		{"icat.example.org", "icat.example.org", 1247, false},
+		{"icat.example.org:port", "", 0, true},
+		{"icat.example.org:70000", "", 0, true},
+	}
+
+	for _, test := range tests {
+		host, port, err := parseConString(test.conString)
+
+		if (err != nil) != test.fails || host != test.host || port != test.port {
+			t.Errorf("parseConString(%q) = %q, %v, %v", test.conString, host, port, err)
+		}
+	}
+
+}